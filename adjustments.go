@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ArgumentAdjustment is a change the server made to a tool argument
+// before using it, returned in the result's _meta so a model doesn't
+// read the result as an answer to the arguments it sent.
+type ArgumentAdjustment struct {
+	Argument  string      `json:"argument"`
+	Requested interface{} `json:"requested,omitempty"`
+	Used      interface{} `json:"used"`
+	Reason    string      `json:"reason"`
+}
+
+// argumentAdjustments collects one call's adjustments. Tools that fan
+// out record from several goroutines.
+type argumentAdjustments struct {
+	mu   sync.Mutex
+	list []ArgumentAdjustment
+}
+
+// withAdjustments returns a copy of the server that records argument
+// adjustments for a single tool call.
+func (s *MCPServer) withAdjustments() *MCPServer {
+	recording := *s
+	recording.adjustments = &argumentAdjustments{}
+	return &recording
+}
+
+// adjustArgument records that an argument was used differently than
+// given. A tool that applies the same argument several times, such as
+// once per service, reports it once.
+func (s *MCPServer) adjustArgument(argument string, requested, used interface{}, reason string) {
+	if s.adjustments == nil {
+		return
+	}
+	s.adjustments.mu.Lock()
+	defer s.adjustments.mu.Unlock()
+	for _, existing := range s.adjustments.list {
+		if existing.Argument == argument && existing.Reason == reason {
+			return
+		}
+	}
+	s.adjustments.list = append(s.adjustments.list, ArgumentAdjustment{Argument: argument, Requested: requested, Used: used, Reason: reason})
+}
+
+// argumentAdjustments returns what adjustArgument recorded.
+func (s *MCPServer) argumentAdjustments() []ArgumentAdjustment {
+	if s.adjustments == nil {
+		return nil
+	}
+	s.adjustments.mu.Lock()
+	defer s.adjustments.mu.Unlock()
+	return append([]ArgumentAdjustment(nil), s.adjustments.list...)
+}
+
+// clampArgument caps a numeric argument at the most a tool allows,
+// recording the cap when it applies.
+func clampArgument[T int | int32 | int64](s *MCPServer, argument string, value, maximum T) T {
+	if value <= maximum {
+		return value
+	}
+	s.adjustArgument(argument, value, maximum, fmt.Sprintf("capped at the maximum of %d", maximum))
+	return maximum
+}
+
+// timeParam parses a time argument like parseTimeParam, recording when
+// the default stood in for an omitted value or a time without a zone was
+// read as UTC.
+func (s *MCPServer) timeParam(argument, value string, defaultTime time.Time) (time.Time, error) {
+	if value == "" {
+		if !defaultTime.IsZero() {
+			s.adjustArgument(argument, nil, defaultTime.UTC().Format(time.RFC3339), "not given, so the default was used")
+		}
+		return defaultTime, nil
+	}
+	t, assumedUTC, err := parseTime(value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if assumedUTC {
+		s.adjustArgument(argument, value, t.Format(time.RFC3339), "no timezone given, so UTC was assumed")
+	}
+	return t, nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestArgumentAdjustmentsInMeta(t *testing.T) {
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":[]}`))
+	})
+
+	call := func(arguments string) []ArgumentAdjustment {
+		t.Helper()
+		params, _ := json.Marshal(ToolCallParams{Name: "query_processes", Arguments: json.RawMessage(arguments)})
+		resp := server.HandleRequest(MCPRequest{Jsonrpc: "2.0", ID: 1, Method: "tools/call", Params: params})
+		if resp.Error != nil {
+			t.Fatalf("unexpected error: %v", resp.Error.Message)
+		}
+		var result ToolCallResult
+		if err := json.Unmarshal(resp.Result, &result); err != nil {
+			t.Fatal(err)
+		}
+		return result.Meta.Adjustments
+	}
+
+	adjustments := call(`{"limit":5000,"from":"2026-01-20T09:00:00"}`)
+	if len(adjustments) != 2 {
+		t.Fatalf("expected the limit and from adjustments, got %+v", adjustments)
+	}
+	if limit := adjustments[0]; limit.Argument != "limit" || limit.Requested != float64(5000) || limit.Used != float64(maxProcessLimit) {
+		t.Errorf("unexpected limit adjustment %+v", limit)
+	}
+	if from := adjustments[1]; from.Argument != "from" || from.Used != "2026-01-20T09:00:00Z" || from.Reason != "no timezone given, so UTC was assumed" {
+		t.Errorf("unexpected from adjustment %+v", from)
+	}
+
+	if adjustments := call(`{"limit":10,"from":"2026-01-20T09:00:00Z"}`); len(adjustments) != 0 {
+		t.Fatalf("expected arguments used as given to report nothing, got %+v", adjustments)
+	}
+}
+
+func TestTimeParamDefault(t *testing.T) {
+	server := (&MCPServer{}).withAdjustments()
+	defaultTo := time.Date(2026, 1, 20, 10, 0, 0, 0, time.UTC)
+	if _, err := server.timeParam("to", "", defaultTo); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.timeParam("to", "", defaultTo); err != nil {
+		t.Fatal(err)
+	}
+	adjustments := server.argumentAdjustments()
+	if len(adjustments) != 1 || adjustments[0].Requested != nil || adjustments[0].Used != "2026-01-20T10:00:00Z" {
+		t.Fatalf("expected one defaulted to, got %+v", adjustments)
+	}
+	if _, err := server.timeParam("from", "2026-01-20", defaultTo); err == nil {
+		t.Fatal("expected a date without a time to be rejected")
+	}
+}
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV2"
+)
+
+// maxAlertEvents caps how many monitor events a single report will page
+// through, so a noisy month can't turn one tool call into hundreds of
+// API requests.
+const maxAlertEvents = 5000
+
+type AlertFatigueParams struct {
+	Query   string `json:"query,omitempty"`
+	GroupBy string `json:"group_by,omitempty"`
+	Window  string `json:"window,omitempty"`
+	Top     int    `json:"top,omitempty"`
+	// ExcludeMaintenance leaves out alerts that fired inside a configured
+	// maintenance window.
+	ExcludeMaintenance bool `json:"exclude_maintenance,omitempty"`
+}
+
+type AlertGroupStats struct {
+	Name                  string  `json:"name"`
+	Alerts                int     `json:"alerts"`
+	Recoveries            int     `json:"recoveries"`
+	Monitors              int     `json:"monitors"`
+	MeanRecoveryMinutes   float64 `json:"mean_recovery_minutes,omitempty"`
+	MedianRecoveryMinutes float64 `json:"median_recovery_minutes,omitempty"`
+}
+
+type NoisyMonitor struct {
+	MonitorID           int64   `json:"monitor_id"`
+	Title               string  `json:"title"`
+	Owner               string  `json:"owner"`
+	Alerts              int     `json:"alerts"`
+	Recoveries          int     `json:"recoveries"`
+	MeanRecoveryMinutes float64 `json:"mean_recovery_minutes,omitempty"`
+}
+
+type AlertFatigueReport struct {
+	From           string            `json:"from"`
+	To             string            `json:"to"`
+	GroupBy        string            `json:"group_by"`
+	EventsAnalyzed int               `json:"events_analyzed"`
+	TotalAlerts    int               `json:"total_alerts"`
+	Truncated      bool              `json:"truncated,omitempty"`
+	Groups         []AlertGroupStats `json:"groups"`
+	NoisyMonitors  []NoisyMonitor    `json:"noisy_monitors"`
+	// Maintenance counts the alerts inside each maintenance window,
+	// whether or not they were excluded.
+	Maintenance map[string]int `json:"maintenance,omitempty"`
+	Notes       []string       `json:"notes,omitempty"`
+	Freshness   *Freshness     `json:"freshness,omitempty"`
+}
+
+// monitorAlertEvent is the subset of a monitor event the report needs.
+type monitorAlertEvent struct {
+	MonitorID int64
+	Title     string
+	Group     string
+	Owner     string
+	Timestamp time.Time
+	Recovery  bool
+	Tags      []string
+}
+
+func (s *MCPServer) AlertFatigueReport(params AlertFatigueParams) (*AlertFatigueReport, error) {
+	groupBy := strings.ToLower(params.GroupBy)
+	if groupBy == "" {
+		groupBy = "team"
+	}
+	if groupBy != "team" && groupBy != "service" {
+		return nil, fmt.Errorf("invalid group_by: %s (use team or service)", params.GroupBy)
+	}
+
+	window, err := parseDurationParam(params.Window, 30*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	top := 10
+	if params.Top > 0 {
+		top = params.Top
+	}
+
+	to := time.Now()
+	from := to.Add(-window)
+
+	query := "source:alert"
+	if params.Query != "" {
+		query += " " + params.Query
+	}
+
+	api := datadogV2.NewEventsApi(s.ddClient)
+	opts := datadogV2.NewListEventsOptionalParameters().
+		WithFilterQuery(query).
+		WithFilterFrom(from.Format(time.RFC3339)).
+		WithFilterTo(to.Format(time.RFC3339)).
+		WithSort(datadogV2.EVENTSSORT_TIMESTAMP_ASCENDING).
+		WithPageLimit(1000)
+
+	results, cancel := api.ListEventsWithPagination(s.ctx, *opts)
+	defer cancel()
+
+	events := make([]monitorAlertEvent, 0)
+	truncated := false
+	for page := range results {
+		if page.Error != nil {
+			return nil, fmt.Errorf("failed to list monitor events: %w", page.Error)
+		}
+		if len(events) >= maxAlertEvents {
+			truncated = true
+			break
+		}
+		if event, ok := toMonitorAlertEvent(page.Item, groupBy); ok {
+			events = append(events, event)
+		}
+	}
+
+	var maintenance map[string]int
+	analyzed := len(events)
+	if params.ExcludeMaintenance {
+		events, maintenance = s.maintenance.filterMaintenance(events)
+	} else {
+		maintenance = s.maintenance.countMaintenance(events)
+	}
+
+	report := buildAlertFatigueReport(events, top)
+	report.EventsAnalyzed = analyzed
+	report.From = from.Format(time.RFC3339)
+	report.To = to.Format(time.RFC3339)
+	report.GroupBy = groupBy
+	report.Truncated = truncated
+	var latest *time.Time
+	if len(events) > 0 {
+		latest = &events[len(events)-1].Timestamp
+	}
+	report.Freshness = newFreshness("events", to, to, latest)
+	if len(maintenance) > 0 {
+		report.Maintenance = maintenance
+		report.Notes = append(report.Notes, maintenanceNote(maintenance, params.ExcludeMaintenance))
+	}
+	if truncated {
+		report.Notes = append(report.Notes, fmt.Sprintf("Stopped after %d events; narrow the query or window for a complete report.", maxAlertEvents))
+	}
+	return report, nil
+}
+
+func toMonitorAlertEvent(event datadogV2.EventResponse, groupBy string) (monitorAlertEvent, bool) {
+	outer := event.Attributes
+	if outer == nil || outer.Timestamp == nil {
+		return monitorAlertEvent{}, false
+	}
+	inner := outer.GetAttributes()
+
+	monitorID := inner.GetMonitorId()
+	if monitorID == 0 {
+		return monitorAlertEvent{}, false
+	}
+
+	tags := outer.GetTags()
+	if len(inner.GetTags()) > 0 {
+		tags = inner.GetTags()
+	}
+
+	owner := tagValue(tags, groupBy)
+	if owner == "" && groupBy == "service" {
+		owner = inner.GetService()
+	}
+	if owner == "" {
+		owner = "unassigned"
+	}
+
+	title := inner.GetTitle()
+	return monitorAlertEvent{
+		MonitorID: monitorID,
+		Title:     stripTransitionPrefix(title),
+		Group:     strings.Join(inner.GetMonitorGroups(), ","),
+		Owner:     owner,
+		Timestamp: outer.Timestamp.UTC(),
+		Recovery:  isRecoveryEvent(inner.GetStatus(), title),
+		Tags:      tags,
+	}, true
+}
+
+func isRecoveryEvent(status datadogV2.EventStatusType, title string) bool {
+	if status == datadogV2.EVENTSTATUSTYPE_SUCCESS {
+		return true
+	}
+	return strings.HasPrefix(title, "[Recovered")
+}
+
+// stripTransitionPrefix removes the "[Triggered on {...}]" style prefix so
+// the triggered and recovered events of one monitor share a title.
+func stripTransitionPrefix(title string) string {
+	if strings.HasPrefix(title, "[") {
+		if end := strings.Index(title, "] "); end >= 0 {
+			return title[end+2:]
+		}
+	}
+	return title
+}
+
+func tagValue(tags []string, key string) string {
+	prefix := key + ":"
+	for _, tag := range tags {
+		if value, ok := strings.CutPrefix(tag, prefix); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// buildAlertFatigueReport aggregates monitor events, which must be sorted
+// by timestamp. Recovery time is measured from the first alert of an
+// episode to the recovery of the same monitor group; acknowledgement is not
+// recorded on monitor events, so it is not reported.
+func buildAlertFatigueReport(events []monitorAlertEvent, top int) *AlertFatigueReport {
+	type monitorStats struct {
+		NoisyMonitor
+		recoveries []time.Duration
+	}
+	type groupStats struct {
+		AlertGroupStats
+		monitors   map[int64]bool
+		recoveries []time.Duration
+	}
+
+	monitors := make(map[int64]*monitorStats)
+	groups := make(map[string]*groupStats)
+	openSince := make(map[string]time.Time)
+	totalAlerts := 0
+
+	for _, e := range events {
+		m, ok := monitors[e.MonitorID]
+		if !ok {
+			m = &monitorStats{NoisyMonitor: NoisyMonitor{MonitorID: e.MonitorID, Title: e.Title, Owner: e.Owner}}
+			monitors[e.MonitorID] = m
+		}
+		g, ok := groups[e.Owner]
+		if !ok {
+			g = &groupStats{AlertGroupStats: AlertGroupStats{Name: e.Owner}, monitors: make(map[int64]bool)}
+			groups[e.Owner] = g
+		}
+		g.monitors[e.MonitorID] = true
+
+		episode := fmt.Sprintf("%d|%s", e.MonitorID, e.Group)
+		if e.Recovery {
+			m.Recoveries++
+			g.Recoveries++
+			if start, open := openSince[episode]; open {
+				m.recoveries = append(m.recoveries, e.Timestamp.Sub(start))
+				g.recoveries = append(g.recoveries, e.Timestamp.Sub(start))
+				delete(openSince, episode)
+			}
+			continue
+		}
+
+		totalAlerts++
+		m.Alerts++
+		g.Alerts++
+		if _, open := openSince[episode]; !open {
+			openSince[episode] = e.Timestamp
+		}
+	}
+
+	report := &AlertFatigueReport{
+		EventsAnalyzed: len(events),
+		TotalAlerts:    totalAlerts,
+		Groups:         make([]AlertGroupStats, 0, len(groups)),
+		NoisyMonitors:  make([]NoisyMonitor, 0, len(monitors)),
+		Notes:          []string{"Acknowledgement times are not recorded on monitor events and are not included."},
+	}
+
+	for _, g := range groups {
+		g.Monitors = len(g.monitors)
+		g.MeanRecoveryMinutes, g.MedianRecoveryMinutes = durationStats(g.recoveries)
+		report.Groups = append(report.Groups, g.AlertGroupStats)
+	}
+	sort.Slice(report.Groups, func(i, j int) bool {
+		if report.Groups[i].Alerts != report.Groups[j].Alerts {
+			return report.Groups[i].Alerts > report.Groups[j].Alerts
+		}
+		return report.Groups[i].Name < report.Groups[j].Name
+	})
+
+	for _, m := range monitors {
+		if m.Alerts == 0 {
+			continue
+		}
+		m.MeanRecoveryMinutes, _ = durationStats(m.recoveries)
+		report.NoisyMonitors = append(report.NoisyMonitors, m.NoisyMonitor)
+	}
+	sort.Slice(report.NoisyMonitors, func(i, j int) bool {
+		if report.NoisyMonitors[i].Alerts != report.NoisyMonitors[j].Alerts {
+			return report.NoisyMonitors[i].Alerts > report.NoisyMonitors[j].Alerts
+		}
+		return report.NoisyMonitors[i].MonitorID < report.NoisyMonitors[j].MonitorID
+	})
+	if len(report.NoisyMonitors) > top {
+		report.NoisyMonitors = report.NoisyMonitors[:top]
+	}
+
+	return report
+}
+
+// durationStats returns the mean and median of the durations in minutes.
+func durationStats(durations []time.Duration) (float64, float64) {
+	if len(durations) == 0 {
+		return 0, 0
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	mean := total.Minutes() / float64(len(sorted))
+
+	mid := len(sorted) / 2
+	median := sorted[mid].Minutes()
+	if len(sorted)%2 == 0 {
+		median = (sorted[mid-1].Minutes() + sorted[mid].Minutes()) / 2
+	}
+	return mean, median
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadog"
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV2"
+)
+
+func TestBuildAlertFatigueReport(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	events := []monitorAlertEvent{
+		{MonitorID: 1, Title: "High CPU", Group: "host:a", Owner: "core", Timestamp: start},
+		{MonitorID: 1, Title: "High CPU", Group: "host:a", Owner: "core", Timestamp: start.Add(5 * time.Minute)},
+		{MonitorID: 1, Title: "High CPU", Group: "host:a", Owner: "core", Timestamp: start.Add(30 * time.Minute), Recovery: true},
+		{MonitorID: 2, Title: "Disk full", Group: "host:b", Owner: "storage", Timestamp: start.Add(time.Hour)},
+		{MonitorID: 1, Title: "High CPU", Group: "host:a", Owner: "core", Timestamp: start.Add(2 * time.Hour)},
+		{MonitorID: 1, Title: "High CPU", Group: "host:a", Owner: "core", Timestamp: start.Add(2*time.Hour + 10*time.Minute), Recovery: true},
+	}
+
+	report := buildAlertFatigueReport(events, 1)
+
+	if report.TotalAlerts != 4 {
+		t.Errorf("expected 4 alerts, got %d", report.TotalAlerts)
+	}
+	if len(report.Groups) != 2 || report.Groups[0].Name != "core" {
+		t.Fatalf("expected core to be the noisiest group, got %+v", report.Groups)
+	}
+
+	core := report.Groups[0]
+	if core.Alerts != 3 || core.Recoveries != 2 {
+		t.Errorf("unexpected core counts: %+v", core)
+	}
+	if core.MeanRecoveryMinutes != 20 || core.MedianRecoveryMinutes != 20 {
+		t.Errorf("expected 20 minute recovery, got mean=%v median=%v", core.MeanRecoveryMinutes, core.MedianRecoveryMinutes)
+	}
+
+	if len(report.NoisyMonitors) != 1 || report.NoisyMonitors[0].MonitorID != 1 {
+		t.Errorf("expected monitor 1 as the only noisy monitor, got %+v", report.NoisyMonitors)
+	}
+}
+
+func TestToMonitorAlertEvent(t *testing.T) {
+	ts := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	inner := datadogV2.EventAttributes{
+		Title:  datadog.PtrString("[Recovered on {host:a}] High CPU"),
+		Status: datadogV2.EVENTSTATUSTYPE_SUCCESS.Ptr(),
+		Tags:   []string{"team:core", "service:api"},
+	}
+	inner.SetMonitorId(42)
+
+	event := datadogV2.EventResponse{
+		Attributes: &datadogV2.EventResponseAttributes{
+			Attributes: &inner,
+			Timestamp:  &ts,
+		},
+	}
+
+	parsed, ok := toMonitorAlertEvent(event, "service")
+	if !ok {
+		t.Fatal("expected event to be parsed")
+	}
+	if parsed.MonitorID != 42 || parsed.Owner != "api" || !parsed.Recovery || parsed.Title != "High CPU" {
+		t.Errorf("unexpected event: %+v", parsed)
+	}
+
+	if _, ok := toMonitorAlertEvent(datadogV2.EventResponse{}, "team"); ok {
+		t.Error("expected event without attributes to be skipped")
+	}
+}
+
+func TestDurationStats(t *testing.T) {
+	mean, median := durationStats([]time.Duration{time.Minute, 2 * time.Minute, 9 * time.Minute})
+	if mean != 4 || median != 2 {
+		t.Errorf("expected mean=4 median=2, got mean=%v median=%v", mean, median)
+	}
+
+	if mean, median := durationStats(nil); mean != 0 || median != 0 {
+		t.Errorf("expected zero stats for no durations, got mean=%v median=%v", mean, median)
+	}
+}
+
+func TestAlertFatigueReportInvalidGroupBy(t *testing.T) {
+	server := &MCPServer{}
+	if _, err := server.AlertFatigueReport(AlertFatigueParams{GroupBy: "host"}); err == nil {
+		t.Error("expected error for unsupported group_by")
+	}
+}
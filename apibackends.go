@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// apiFamilies lists the Datadog APIs that have two ways of being served:
+// the preferred backend first, then the one used when it is unavailable.
+// Dashboards have only the v1 API and aren't listed.
+var apiFamilies = map[string][2]string{
+	// v2 events search, then the v1 event stream.
+	"events": {"v2", "v1"},
+	// v2 downtimes, then the deprecated v1 downtimes.
+	"downtimes": {"v2", "v1"},
+	// The monitor search endpoint, then the plain monitor list, which
+	// older org setups and narrowly scoped keys still allow.
+	"monitors": {"search", "list"},
+}
+
+// apiBackends picks the backend for each API family. A backend can be
+// pinned with DD_MCP_API_VERSIONS; otherwise the preferred one is tried
+// first and an org that answers it with 404 or 403 is switched to the
+// fallback for good. It is shared by every copy of the server.
+type apiBackends struct {
+	mu        sync.Mutex
+	forced    map[string]string
+	fallbacks map[string]bool
+}
+
+// loadAPIBackends reads DD_MCP_API_VERSIONS, a comma-separated list of
+// family=backend pins such as "events=v1,monitors=list".
+// DD_MCP_EVENTS_API is still honored for the events family.
+func loadAPIBackends() (*apiBackends, error) {
+	b := &apiBackends{forced: make(map[string]string), fallbacks: make(map[string]bool)}
+	if events := strings.ToLower(os.Getenv("DD_MCP_EVENTS_API")); events == "v1" || events == "v2" {
+		b.forced["events"] = events
+	}
+	for _, pin := range splitList(os.Getenv("DD_MCP_API_VERSIONS")) {
+		family, backend, _ := strings.Cut(strings.ToLower(pin), "=")
+		family, backend = strings.TrimSpace(family), strings.TrimSpace(backend)
+		backends, ok := apiFamilies[family]
+		if !ok {
+			return nil, fmt.Errorf("invalid DD_MCP_API_VERSIONS: unknown API %q (use %s)", family, strings.Join(apiFamilyNames(), ", "))
+		}
+		if backend != backends[0] && backend != backends[1] {
+			return nil, fmt.Errorf("invalid DD_MCP_API_VERSIONS: %s must be %s or %s", family, backends[0], backends[1])
+		}
+		b.forced[family] = backend
+	}
+	return b, nil
+}
+
+func apiFamilyNames() []string {
+	names := make([]string, 0, len(apiFamilies))
+	for name := range apiFamilies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// useFallback reports whether org should be served by family's fallback.
+func (b *apiBackends) useFallback(family, org string) bool {
+	if b == nil {
+		return false
+	}
+	if forced, ok := b.forced[family]; ok {
+		return forced == apiFamilies[family][1]
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.fallbacks[family+"\x00"+org]
+}
+
+// fallBack records that org can't use family's preferred backend when the
+// status says so. Only auto-detection falls back; a pinned backend
+// reports the error instead.
+func (b *apiBackends) fallBack(family, org string, status int) bool {
+	if b == nil || (status != http.StatusNotFound && status != http.StatusForbidden) {
+		return false
+	}
+	if _, ok := b.forced[family]; ok {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.fallbacks[family+"\x00"+org] = true
+	return true
+}
+
+// probe settles family's backend for org on first use, for APIs where a
+// 404 from an ordinary call can mean a missing resource rather than a
+// missing API. check calls the preferred backend and returns the status.
+func (b *apiBackends) probe(family, org string, check func() int) bool {
+	if b == nil {
+		return false
+	}
+	if forced, ok := b.forced[family]; ok {
+		return forced == apiFamilies[family][1]
+	}
+	key := family + "\x00" + org
+	b.mu.Lock()
+	fallback, known := b.fallbacks[key]
+	b.mu.Unlock()
+	if known {
+		return fallback
+	}
+	status := check()
+	if status == 0 || status >= 500 {
+		// Undecided; try the preferred backend and probe again next time.
+		return false
+	}
+	fallback = status == http.StatusNotFound || status == http.StatusForbidden
+	b.mu.Lock()
+	b.fallbacks[key] = fallback
+	b.mu.Unlock()
+	return fallback
+}
+
+// report lists the backend each family uses for org, for
+// server_stats.
+func (b *apiBackends) report(org string) map[string]string {
+	report := make(map[string]string, len(apiFamilies))
+	for family, backends := range apiFamilies {
+		report[family] = backends[0]
+		if b.useFallback(family, org) {
+			report[family] = backends[1]
+		}
+	}
+	return report
+}
+
+// apiOrg keys per-org caches: one org normally, one per user in gateway
+// mode, and one per profile queried by compare_orgs.
+func (s *MCPServer) apiOrg() string {
+	if s.profile != "" {
+		return "org:" + s.profile
+	}
+	if s.tenants != nil {
+		return s.session
+	}
+	return ""
+}
+
+// httpStatus returns the status of a failed call, or 0 when it never got
+// a response.
+func httpStatus(resp *http.Response) int {
+	if resp == nil {
+		return 0
+	}
+	return resp.StatusCode
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func mustLoadAPIBackends(t *testing.T) *apiBackends {
+	t.Helper()
+	backends, err := loadAPIBackends()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return backends
+}
+
+func TestLoadAPIBackends(t *testing.T) {
+	t.Setenv("DD_MCP_EVENTS_API", "v1")
+	t.Setenv("DD_MCP_API_VERSIONS", "monitors=list, Downtimes=v2")
+	backends := mustLoadAPIBackends(t)
+	report := backends.report("")
+	if report["events"] != "v1" || report["monitors"] != "list" || report["downtimes"] != "v2" {
+		t.Fatalf("unexpected backends: %v", report)
+	}
+	if backends.fallBack("downtimes", "", http.StatusNotFound) {
+		t.Fatal("expected a pinned backend not to fall back")
+	}
+
+	for _, spec := range []string{"dashboards=v2", "events=v3"} {
+		t.Setenv("DD_MCP_API_VERSIONS", spec)
+		if _, err := loadAPIBackends(); err == nil {
+			t.Errorf("expected %q to be rejected", spec)
+		}
+	}
+}
+
+func TestListMonitorsFallsBackToMonitorList(t *testing.T) {
+	var searches int
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/monitor/search":
+			searches++
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["Forbidden"]}`))
+		case "/api/v1/monitor":
+			if r.URL.Query().Get("monitor_tags") != "team:core" || r.URL.Query().Get("name") != "disk" {
+				t.Errorf("unexpected list filters: %s", r.URL.RawQuery)
+			}
+			_, _ = w.Write([]byte(`[{"id":1,"name":"disk a","overall_state":"OK","type":"metric alert","query":"avg(last_5m):avg:disk{*} > 1"},{"id":2,"name":"disk b","overall_state":"Alert","type":"metric alert","query":"avg(last_5m):avg:disk{*} > 1"}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	server.backends = mustLoadAPIBackends(t)
+
+	params := ListMonitorsParams{Name: "disk", Tags: []string{"team:core"}, States: []string{"alert"}}
+	result, err := server.ListMonitors(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Backend != "list" || len(result.Monitors) != 1 || result.Monitors[0].ID != 2 {
+		t.Fatalf("expected the alerting monitor from the list API, got %+v", result)
+	}
+	if len(result.Notes) != 2 || !strings.Contains(result.Notes[1], "can't filter by state") {
+		t.Fatalf("expected notes about the fallback, got %q", result.Notes)
+	}
+
+	if _, err := server.ListMonitors(params); err != nil {
+		t.Fatal(err)
+	}
+	if searches != 1 {
+		t.Fatalf("expected the search API to be tried once, got %d", searches)
+	}
+}
+
+func TestMuteMonitorUsesV1DowntimesWhenV2IsMissing(t *testing.T) {
+	var paths []string
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/api/v2/"):
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":["Not found"]}`))
+		case r.URL.Path == "/api/v1/downtime":
+			_, _ = w.Write([]byte(`{"id":42,"monitor_id":7,"scope":["*"]}`))
+		case r.URL.Path == "/api/v1/monitor/7/downtimes":
+			_, _ = w.Write([]byte(`[{"id":42,"monitor_id":7,"scope":["*"]},{"id":43,"monitor_tags":["team:core"],"scope":["env:prod"]}]`))
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	server.allowWrites = true
+	server.backends = mustLoadAPIBackends(t)
+
+	muted, err := server.MuteMonitor(MuteMonitorParams{MonitorID: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if muted.DowntimeID != "42" {
+		t.Fatalf("expected the v1 downtime ID, got %+v", muted)
+	}
+
+	unmuted, err := server.UnmuteMonitor(UnmuteMonitorParams{MonitorID: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unmuted.Canceled) != 1 || unmuted.Canceled[0].ID != "42" || len(unmuted.Skipped) != 1 {
+		t.Fatalf("unexpected unmute result: %+v", unmuted)
+	}
+	if got := strings.Join(paths, ","); strings.Count(got, "/api/v2/") != 1 || !strings.Contains(got, "DELETE /api/v1/downtime/42") {
+		t.Fatalf("expected one v2 probe and v1 calls after it, got %s", got)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// apiKeyOnlyTools work without an application key: they either call only
+// Datadog intake endpoints, which accept the API key alone, or make no
+// Datadog call at all.
+var apiKeyOnlyTools = []string{
+	"record_deployment",
+	"post_event",
+	"explain_query",
+	"fetch_continuation",
+	"server_stats",
+	"set_context",
+	"get_context",
+	"export_session",
+	"post_slack_summary",
+	"create_jira_ticket",
+}
+
+// apiKeyOnlyReason explains why most tools are missing when the server
+// runs without an application key.
+const apiKeyOnlyReason = "The server was started with DD_API_KEY but without DD_APP_KEY. Searching and reading Datadog data (logs, metrics, traces, monitors, incidents, SLOs, dashboards and the rest) needs an application key, so only tools that post events or make no Datadog call are available. Set DD_APP_KEY and restart the server to enable every tool."
+
+// availableWithoutAppKey reports whether a tool is listed when the server
+// has no application key. Plugins run their own executables and keep
+// working; reports and macros read Datadog data and are hidden.
+func (s *MCPServer) availableWithoutAppKey(tool Tool) bool {
+	if _, ok := s.plugins.lookup(tool.Name); ok {
+		return true
+	}
+	return slices.Contains(apiKeyOnlyTools, tool.Name)
+}
+
+// requireAppKey refuses a tool that is hidden because the server has no
+// application key, saying why rather than calling it unknown.
+func (s *MCPServer) requireAppKey(name string) *MCPError {
+	if !s.apiKeyOnly {
+		return nil
+	}
+	full := *s
+	full.apiKeyOnly = false
+	for _, tool := range full.ListTools() {
+		if tool.Name == name && !s.availableWithoutAppKey(tool) {
+			return &MCPError{Code: -32000, Message: fmt.Sprintf("%s needs a Datadog application key. %s", name, apiKeyOnlyReason)}
+		}
+	}
+	return nil
+}
+
+// apiKeyOnlyInstructions tells clients at initialize which tools remain.
+func (s *MCPServer) apiKeyOnlyInstructions() string {
+	if !s.apiKeyOnly {
+		return ""
+	}
+	var names []string
+	for _, tool := range s.ListTools() {
+		names = append(names, tool.Name)
+	}
+	return fmt.Sprintf("Reduced mode: %s Available tools: %s.", apiKeyOnlyReason, strings.Join(names, ", "))
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestAPIKeyOnlyTools(t *testing.T) {
+	server := &MCPServer{apiKeyOnly: true, allowWrites: true}
+	var names []string
+	for _, tool := range server.ListTools() {
+		names = append(names, tool.Name)
+	}
+	for _, want := range []string{"record_deployment", "post_event", "explain_query", "server_stats"} {
+		if !slices.Contains(names, want) {
+			t.Errorf("expected %s to be offered, got %v", want, names)
+		}
+	}
+	for _, hidden := range []string{"query_logs", "list_monitors", "mute_monitor"} {
+		if slices.Contains(names, hidden) {
+			t.Errorf("expected %s to be hidden", hidden)
+		}
+	}
+
+	params, _ := json.Marshal(ToolCallParams{Name: "query_logs", Arguments: json.RawMessage(`{"query":"*"}`)})
+	resp := server.HandleRequest(MCPRequest{Jsonrpc: "2.0", ID: 1, Method: "tools/call", Params: params})
+	if resp.Error == nil || !strings.Contains(resp.Error.Message, "query_logs needs a Datadog application key") {
+		t.Fatalf("expected a hidden tool to explain why, got %+v", resp.Error)
+	}
+	params, _ = json.Marshal(ToolCallParams{Name: "no_such_tool"})
+	resp = server.HandleRequest(MCPRequest{Jsonrpc: "2.0", ID: 2, Method: "tools/call", Params: params})
+	if resp.Error == nil || resp.Error.Code != -32601 {
+		t.Fatalf("expected unknown tools to stay unknown, got %+v", resp.Error)
+	}
+
+	params, _ = json.Marshal(ToolCallParams{Name: "explain_query", Arguments: json.RawMessage(`{"query":"service:web"}`)})
+	resp = server.HandleRequest(MCPRequest{Jsonrpc: "2.0", ID: 3, Method: "tools/call", Params: params})
+	if resp.Error != nil {
+		t.Fatalf("expected an available tool to run, got %v", resp.Error.Message)
+	}
+
+	resp = server.HandleRequest(MCPRequest{Jsonrpc: "2.0", ID: 4, Method: "initialize"})
+	var init InitializeResult
+	if err := json.Unmarshal(resp.Result, &init); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(init.Instructions, "DD_APP_KEY") || !strings.Contains(init.Instructions, "record_deployment") {
+		t.Errorf("expected initialize to explain the reduced mode, got %q", init.Instructions)
+	}
+}
+
+func TestFullModeHasNoInstructions(t *testing.T) {
+	server := &MCPServer{}
+	resp := server.HandleRequest(MCPRequest{Jsonrpc: "2.0", ID: 1, Method: "initialize"})
+	if strings.Contains(string(resp.Result), "instructions") {
+		t.Errorf("expected no instructions with both keys, got %s", resp.Result)
+	}
+	if err := server.requireAppKey("query_logs"); err != nil {
+		t.Errorf("expected every tool to be allowed, got %v", err.Message)
+	}
+}
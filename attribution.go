@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// Headers identifying this integration on every Datadog call, so org
+// admins can tell its API usage apart from other clients.
+const (
+	headerServer  = "X-Dd-Mcp-Server"
+	headerSession = "X-Dd-Mcp-Session"
+	headerUser    = "X-Dd-Mcp-User"
+)
+
+// requestAttribution says who a Datadog call is made for. Session and User
+// are empty when unknown, such as over stdio.
+type requestAttribution struct {
+	Transport string
+	Session   string
+	User      string
+}
+
+type attributionKey struct{}
+
+// withAttribution stores a in ctx for attributionTransport to read.
+func withAttribution(ctx context.Context, a requestAttribution) context.Context {
+	return context.WithValue(ctx, attributionKey{}, a)
+}
+
+// userAgent is the product token and comment appended to the Datadog
+// client's User-Agent, e.g. "go-dd-mcp/0.1.0 (http; session abc; user
+// alice) team-sre". Datadog records the User-Agent in Audit Trail.
+func (a requestAttribution) userAgent(suffix string) string {
+	var comment []string
+	if a.Transport != "" {
+		comment = append(comment, a.Transport)
+	}
+	if a.Session != "" {
+		comment = append(comment, "session "+a.Session)
+	}
+	if a.User != "" {
+		comment = append(comment, "user "+a.User)
+	}
+	ua := "go-dd-mcp/" + serverVersion
+	if len(comment) > 0 {
+		ua += " (" + strings.Join(comment, "; ") + ")"
+	}
+	if suffix != "" {
+		ua += " " + suffix
+	}
+	return ua
+}
+
+// attributionTransport adds the server's identity and the caller's
+// attribution from the request context to every outbound call.
+type attributionTransport struct {
+	base   http.RoundTripper
+	suffix string
+}
+
+func (t *attributionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	a, _ := req.Context().Value(attributionKey{}).(requestAttribution)
+	a.Session = headerSafe(a.Session)
+	a.User = headerSafe(a.User)
+
+	// A RoundTripper must not modify the caller's request.
+	r := req.Clone(req.Context())
+	ua := a.userAgent(t.suffix)
+	if existing := r.Header.Get("User-Agent"); existing != "" {
+		ua = existing + " " + ua
+	}
+	r.Header.Set("User-Agent", ua)
+	r.Header.Set(headerServer, "go-dd-mcp/"+serverVersion)
+	if a.Session != "" {
+		r.Header.Set(headerSession, a.Session)
+	}
+	if a.User != "" {
+		r.Header.Set(headerUser, a.User)
+	}
+	return t.base.RoundTrip(r)
+}
+
+// headerSafe drops characters that can't appear in a header value or
+// would break the User-Agent comment. Session ids and user names come
+// from the client.
+func headerSafe(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x21 || r > 0x7e || strings.ContainsRune("();", r) {
+			return -1
+		}
+		return r
+	}, value)
+}
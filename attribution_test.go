@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadog"
+)
+
+func TestAttributionTransport(t *testing.T) {
+	var got http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":[]}`))
+	}))
+	t.Cleanup(ts.Close)
+
+	configuration := datadog.NewConfiguration()
+	configuration.Servers = datadog.ServerConfigurations{{URL: ts.URL}}
+	configuration.HTTPClient = &http.Client{Transport: &attributionTransport{base: http.DefaultTransport, suffix: "team-sre"}}
+	server := &MCPServer{
+		ddClient:    datadog.NewAPIClient(configuration),
+		credentials: datadogCredentials{APIKey: "api-key", AppKey: "app-key"},
+		attribution: requestAttribution{Transport: "http", Session: "abc\r\nX-Evil: 1", User: "alice"},
+	}
+	scoped, cancel := server.withContext(context.Background())
+	defer cancel()
+
+	if _, err := scoped.QueryLogs(QueryLogsParams{Query: "*", From: "2026-01-20T09:00:00Z", To: "2026-01-20T11:00:00Z"}); err != nil {
+		t.Fatal(err)
+	}
+	ua := got.Get("User-Agent")
+	if !strings.HasPrefix(ua, "datadog-api-client-go/") || !strings.HasSuffix(ua, "go-dd-mcp/"+serverVersion+" (http; session abcX-Evil:1; user alice) team-sre") {
+		t.Fatalf("unexpected User-Agent %q", ua)
+	}
+	if got.Get(headerSession) != "abcX-Evil:1" || got.Get(headerUser) != "alice" || got.Get("X-Evil") != "" {
+		t.Fatalf("unexpected attribution headers: %v", got)
+	}
+}
+
+func TestUserAgentWithoutSession(t *testing.T) {
+	if ua := (requestAttribution{Transport: "stdio"}).userAgent(""); ua != "go-dd-mcp/"+serverVersion+" (stdio)" {
+		t.Fatalf("unexpected User-Agent %q", ua)
+	}
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+const defaultBlastRadiusDepth = 3
+
+type BlastRadiusParams struct {
+	// MonitorID starts from the services an alerting monitor covers.
+	MonitorID int64 `json:"monitor_id,omitempty"`
+	// Service starts from a failing service.
+	Service string `json:"service,omitempty"`
+	// Env picks the service map; a monitor's env tag is used without it.
+	Env   string `json:"env,omitempty"`
+	From  string `json:"from,omitempty"`
+	Depth int    `json:"depth,omitempty"`
+}
+
+// AtRiskService is a service that calls a failing one, directly or
+// through others.
+type AtRiskService struct {
+	ServiceDependency
+	// UserFacing is set for services nothing else calls, which are
+	// where requests from users enter.
+	UserFacing bool `json:"user_facing,omitempty"`
+}
+
+// AtRiskSLO is an SLO that a failing or affected service feeds.
+type AtRiskSLO struct {
+	SLOSummary
+	// Reason says how the SLO is tied to the failure.
+	Reason string `json:"reason"`
+}
+
+type BlastRadiusResult struct {
+	// Failing are the services the search started from.
+	Failing []string        `json:"failing"`
+	Monitor *MonitorSummary `json:"monitor,omitempty"`
+	Env     string          `json:"env"`
+	Depth   int             `json:"depth"`
+	// Upstream are the services that call a failing one, nearest first.
+	Upstream []AtRiskService `json:"upstream"`
+	// UserFacing names the failing and upstream services users reach.
+	UserFacing []string    `json:"user_facing"`
+	SLOs       []AtRiskSLO `json:"slos"`
+	URL        string      `json:"url"`
+	Notes      []string    `json:"notes,omitempty"`
+}
+
+// BlastRadius reports what an alerting monitor or failing service puts
+// at risk: the services that call it up to depth hops away, which of
+// those users reach directly, and the SLOs tied to any of them, most
+// urgent first.
+func (s *MCPServer) BlastRadius(params BlastRadiusParams) (*BlastRadiusResult, error) {
+	if (params.MonitorID > 0) == (params.Service != "") {
+		return nil, fmt.Errorf("pass either monitor_id or service")
+	}
+	depth := params.Depth
+	if depth <= 0 {
+		depth = defaultBlastRadiusDepth
+	}
+	if depth > maxDependencyDepth {
+		return nil, fmt.Errorf("depth must be at most %d", maxDependencyDepth)
+	}
+	from, err := s.timeParam("from", params.From, time.Now().Add(-time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	to := time.Now()
+
+	result := &BlastRadiusResult{Env: params.Env, Depth: depth}
+	if params.Service != "" {
+		result.Failing = []string{params.Service}
+	} else {
+		monitor, err := s.GetMonitor(GetMonitorParams{MonitorID: params.MonitorID})
+		if err != nil {
+			return nil, err
+		}
+		result.Monitor = &MonitorSummary{ID: monitor.ID, Name: monitor.Name, Status: monitor.OverallState, Type: monitor.Type, Tags: monitor.Tags, URL: monitor.URL}
+		var envs []string
+		result.Failing, envs = monitorServices(monitor)
+		if len(result.Failing) == 0 {
+			return nil, fmt.Errorf("monitor %d has no service tag or alerting service group; pass service instead", params.MonitorID)
+		}
+		if result.Env == "" && len(envs) > 0 {
+			result.Env = envs[0]
+			if len(envs) > 1 {
+				result.Notes = append(result.Notes, fmt.Sprintf("The monitor covers several environments (%s); this uses %s. Pass env for another.", strings.Join(envs, ", "), result.Env))
+			}
+		}
+	}
+	if result.Env == "" {
+		return nil, fmt.Errorf("env parameter is required when the monitor has no env tag")
+	}
+	result.URL = s.appURL("/apm/map?" + url.Values{"env": {result.Env}, "service": {result.Failing[0]}}.Encode())
+
+	_, calledBy, names, err := s.serviceMap(result.Env, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for i, service := range result.Failing {
+		resolution := resolveName(service, names)
+		switch {
+		case !resolution.found():
+			result.Notes = append(result.Notes, resolution.note("service in the "+result.Env+" service map", service))
+		case resolution.Corrected:
+			result.Notes = append(result.Notes, resolution.note("service", service))
+			result.Failing[i] = resolution.Entity.Name
+		}
+	}
+	result.Upstream = blastRadiusUpstream(result.Failing, calledBy, depth)
+	for _, service := range result.Failing {
+		if len(calledBy[service]) == 0 && slices.ContainsFunc(names, func(n namedEntity) bool { return n.Name == service }) {
+			result.UserFacing = append(result.UserFacing, service)
+		}
+	}
+	for _, service := range result.Upstream {
+		if service.UserFacing {
+			result.UserFacing = append(result.UserFacing, service.Service)
+		}
+	}
+	if result.UserFacing == nil {
+		result.UserFacing = []string{}
+	}
+
+	affected := slices.Clone(result.Failing)
+	for _, service := range result.Upstream {
+		affected = append(affected, service.Service)
+	}
+	slos, truncated, err := s.searchSLOs("")
+	if err != nil {
+		result.Notes = append(result.Notes, fmt.Sprintf("Couldn't read SLOs: %v", err))
+	}
+	result.SLOs = atRiskSLOs(slos, affected, result.Env, params.MonitorID)
+	if truncated {
+		result.Notes = append(result.Notes, fmt.Sprintf("Only the first %d SLOs were checked.", maxSLOSearchPages*sloSearchPageSize))
+	}
+	if len(result.Upstream) == 0 {
+		result.Notes = append(result.Notes, "No traced service called the failing services in this window; the risk stays with them and their own SLOs.")
+	}
+	if slices.ContainsFunc(result.Upstream, func(service AtRiskService) bool { return service.Depth == depth && !service.UserFacing }) && depth < maxDependencyDepth {
+		result.Notes = append(result.Notes, fmt.Sprintf("Some services %d hops away have callers of their own; raise depth to follow them.", depth))
+	}
+	return result, nil
+}
+
+// monitorServices returns the services and environments a monitor
+// covers: its service and env tags, and those of its alerting groups.
+func monitorServices(monitor *MonitorDetail) (services, envs []string) {
+	add := func(tag string) {
+		key, value, ok := strings.Cut(strings.TrimSpace(tag), ":")
+		if !ok || value == "" {
+			return
+		}
+		switch key {
+		case "service":
+			if !slices.Contains(services, value) {
+				services = append(services, value)
+			}
+		case "env":
+			if !slices.Contains(envs, value) {
+				envs = append(envs, value)
+			}
+		}
+	}
+	for _, tag := range monitor.Tags {
+		add(tag)
+	}
+	for _, group := range monitor.Groups {
+		if group.Status != "Alert" && group.Status != "Warn" {
+			continue
+		}
+		for _, tag := range strings.Split(group.Group, ",") {
+			add(tag)
+		}
+	}
+	return services, envs
+}
+
+// blastRadiusUpstream walks callers from every failing service, keeping
+// each service at its shortest distance, and marks the ones nothing
+// calls as user-facing.
+func blastRadiusUpstream(failing []string, calledBy map[string][]string, depth int) []AtRiskService {
+	nearest := make(map[string]ServiceDependency)
+	for _, service := range failing {
+		for _, dep := range walkDependencies(service, calledBy, depth) {
+			if slices.Contains(failing, dep.Service) {
+				continue
+			}
+			if known, ok := nearest[dep.Service]; !ok || dep.Depth < known.Depth {
+				nearest[dep.Service] = dep
+			}
+		}
+	}
+	upstream := make([]AtRiskService, 0, len(nearest))
+	for _, dep := range nearest {
+		upstream = append(upstream, AtRiskService{ServiceDependency: dep, UserFacing: len(calledBy[dep.Service]) == 0})
+	}
+	sort.Slice(upstream, func(i, j int) bool {
+		if upstream[i].Depth != upstream[j].Depth {
+			return upstream[i].Depth < upstream[j].Depth
+		}
+		return upstream[i].Service < upstream[j].Service
+	})
+	return upstream
+}
+
+// atRiskSLOs picks the SLOs computed from the monitor or tagged with an
+// affected service, skipping those tagged only for other environments.
+func atRiskSLOs(slos []SLOSummary, services []string, env string, monitorID int64) []AtRiskSLO {
+	var matched []SLOSummary
+	reasons := make(map[string]string)
+	for _, slo := range slos {
+		if !sloInEnv(slo.Tags, env) {
+			continue
+		}
+		reason := ""
+		if monitorID > 0 && slices.Contains(slo.MonitorIDs, monitorID) {
+			reason = fmt.Sprintf("computed from monitor %d", monitorID)
+		} else {
+			for _, service := range services {
+				if hasAllTags(slo.Tags, []string{"service:" + service}) {
+					reason = "tagged service:" + service
+					break
+				}
+			}
+		}
+		if reason != "" {
+			matched = append(matched, slo)
+			reasons[slo.ID] = reason
+		}
+	}
+	sortSLOsByUrgency(matched)
+	atRisk := make([]AtRiskSLO, 0, len(matched))
+	for _, slo := range matched {
+		atRisk = append(atRisk, AtRiskSLO{SLOSummary: slo, Reason: reasons[slo.ID]})
+	}
+	return atRisk
+}
+
+// sloInEnv reports whether an SLO applies to env: it has no env tag, or
+// one for env.
+func sloInEnv(tags []string, env string) bool {
+	tagged := false
+	for _, tag := range tags {
+		if value, ok := strings.CutPrefix(strings.ToLower(tag), "env:"); ok {
+			if value == strings.ToLower(env) {
+				return true
+			}
+			tagged = true
+		}
+	}
+	return !tagged
+}
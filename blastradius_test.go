@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestBlastRadius(t *testing.T) {
+	var mapEnv string
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/monitor/7":
+			_, _ = w.Write([]byte(`{
+				"id":7,"name":"Payments errors","type":"query alert","query":"sum(last_5m):sum:trace.http.request.errors{env:prod} by {service} > 10",
+				"overall_state":"Alert","multi":true,"tags":["env:prod","team:payments"],
+				"state":{"groups":{
+					"service:payments":{"name":"service:payments","status":"Alert"},
+					"service:search":{"name":"service:search","status":"OK"}}}}`))
+		case "/api/v1/service_dependencies":
+			mapEnv = r.URL.Query().Get("env")
+			_, _ = w.Write([]byte(`{
+				"web":      {"calls": ["checkout", "search"]},
+				"mobile":   {"calls": ["checkout"]},
+				"checkout": {"calls": ["payments"]},
+				"payments": {"calls": ["stripe-proxy"]},
+				"search":   {"calls": []}}`))
+		case "/api/v1/slo/search":
+			_, _ = w.Write([]byte(`{"data":{"attributes":{"slos":[
+				{"data":{"id":"slo-web","type":"slo","attributes":{"name":"Web availability","all_tags":["service:web","env:prod"],"overall_status":[{"state":"ok","error_budget_remaining":80}]}}},
+				{"data":{"id":"slo-pay","type":"slo","attributes":{"name":"Payment success","all_tags":["team:payments"],"monitor_ids":[7],"overall_status":[{"state":"breached","error_budget_remaining":-20}]}}},
+				{"data":{"id":"slo-staging","type":"slo","attributes":{"name":"Checkout staging","all_tags":["service:checkout","env:staging"],"overall_status":[{"state":"ok"}]}}},
+				{"data":{"id":"slo-search","type":"slo","attributes":{"name":"Search latency","all_tags":["service:search"],"overall_status":[{"state":"ok"}]}}}]}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	result, err := server.BlastRadius(BlastRadiusParams{MonitorID: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mapEnv != "prod" || result.Env != "prod" {
+		t.Fatalf("expected the monitor's env to pick the service map, got %q", mapEnv)
+	}
+	if len(result.Failing) != 1 || result.Failing[0] != "payments" {
+		t.Fatalf("expected the alerting group's service to be failing, got %v", result.Failing)
+	}
+	want := []AtRiskService{
+		{ServiceDependency: ServiceDependency{Service: "checkout", Depth: 1}},
+		{ServiceDependency: ServiceDependency{Service: "mobile", Depth: 2, Via: "checkout"}, UserFacing: true},
+		{ServiceDependency: ServiceDependency{Service: "web", Depth: 2, Via: "checkout"}, UserFacing: true},
+	}
+	if len(result.Upstream) != len(want) {
+		t.Fatalf("unexpected upstream: %+v", result.Upstream)
+	}
+	for i := range want {
+		if result.Upstream[i] != want[i] {
+			t.Errorf("upstream %d: expected %+v, got %+v", i, want[i], result.Upstream[i])
+		}
+	}
+	if len(result.UserFacing) != 2 || result.UserFacing[0] != "mobile" || result.UserFacing[1] != "web" {
+		t.Errorf("unexpected user-facing services: %v", result.UserFacing)
+	}
+	if len(result.SLOs) != 2 || result.SLOs[0].ID != "slo-pay" || result.SLOs[0].Reason != "computed from monitor 7" || result.SLOs[1].Reason != "tagged service:web" {
+		t.Fatalf("expected the monitor's SLO first and the web SLO, got %+v", result.SLOs)
+	}
+
+	if _, err := server.BlastRadius(BlastRadiusParams{Service: "search"}); err == nil {
+		t.Fatal("expected env to be required for a service")
+	}
+	result, err = server.BlastRadius(BlastRadiusParams{Service: "search", Env: "prod", Depth: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Upstream) != 1 || !result.Upstream[0].UserFacing || len(result.SLOs) != 2 {
+		t.Fatalf("unexpected result for search: %+v", result)
+	}
+	if _, err := server.BlastRadius(BlastRadiusParams{MonitorID: 7, Service: "search"}); err == nil {
+		t.Fatal("expected monitor_id and service together to be rejected")
+	}
+}
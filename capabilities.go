@@ -0,0 +1,83 @@
+package main
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+)
+
+// domainTools lists the tools that read each Datadog product, alongside
+// productTools for the products a site may lack. A product counts as
+// enabled when any of its tools is listed.
+var domainTools = map[string][]string{
+	"logs":           {"query_logs"},
+	"metrics":        {"detect_anomalies", "forecast_metric", "detect_cardinality_growth", "metric_related_assets"},
+	"apm":            {"query_spans", "aggregate_spans", "get_trace", "get_service_dependencies", "get_blast_radius"},
+	"events":         {"query_events"},
+	"monitors":       {"list_monitors", "get_monitor", "watch_monitor", "simulate_monitor", "alert_fatigue_report"},
+	"slos":           {"list_slos", "get_slo_status", "get_slo_history"},
+	"dashboards":     {"list_dashboards", "get_dashboard"},
+	"infrastructure": {"list_hosts", "get_host_totals", "query_processes", "list_containers"},
+	"security":       {"list_security_rules", "list_security_findings"},
+	"ci":             {"query_ci_tests"},
+}
+
+// DatadogCapabilities is advertised under capabilities.experimental.datadog
+// at initialize, so clients can tailor their prompts to what this
+// deployment offers instead of finding out from failed calls.
+type DatadogCapabilities struct {
+	Site string `json:"site"`
+	// Products says whether each Datadog product's tools are available.
+	Products map[string]bool `json:"products"`
+	// Disabled explains why each unavailable product is off.
+	Disabled map[string]string `json:"disabled,omitempty"`
+	// Writes is set when tools that change Datadog, such as mute_monitor,
+	// are enabled.
+	Writes bool `json:"writes"`
+	// ReducedMode is set when the server has no application key.
+	ReducedMode bool `json:"reduced_mode,omitempty"`
+	// Orgs names the org profiles compare_orgs can query.
+	Orgs []string `json:"orgs,omitempty"`
+}
+
+// datadogCapabilities reports which products the tools this server lists
+// cover.
+func (s *MCPServer) datadogCapabilities() *DatadogCapabilities {
+	listed := make(map[string]bool)
+	for _, tool := range s.ListTools() {
+		listed[tool.Name] = true
+	}
+	caps := &DatadogCapabilities{
+		Site:        cmp.Or(s.site, "datadoghq.com"),
+		Products:    make(map[string]bool),
+		Writes:      s.allowWrites,
+		ReducedMode: s.apiKeyOnly,
+	}
+	for product, tools := range domainTools {
+		caps.Products[product] = slices.ContainsFunc(tools, func(name string) bool { return listed[name] })
+	}
+	for product, tools := range productTools {
+		caps.Products[product] = slices.ContainsFunc(tools, func(name string) bool { return listed[name] })
+	}
+	for product, enabled := range caps.Products {
+		reason := ""
+		switch {
+		case enabled:
+			continue
+		case slices.Contains(s.disabledProducts, product):
+			reason = s.productReason(product)
+		case s.apiKeyOnly:
+			reason = "the server has no application key; set DD_APP_KEY to enable it."
+		default:
+			continue
+		}
+		if caps.Disabled == nil {
+			caps.Disabled = make(map[string]string)
+		}
+		caps.Disabled[product] = reason
+	}
+	if len(s.orgs) > 1 {
+		caps.Orgs = slices.Sorted(maps.Keys(s.orgs))
+	}
+	return caps
+}
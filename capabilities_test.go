@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func initializeCapabilities(t *testing.T, server *MCPServer) DatadogCapabilities {
+	t.Helper()
+	resp := server.HandleRequest(MCPRequest{Jsonrpc: "2.0", ID: 1, Method: "initialize"})
+	if resp.Error != nil {
+		t.Fatal(resp.Error.Message)
+	}
+	var result struct {
+		Capabilities struct {
+			Experimental struct {
+				Datadog DatadogCapabilities `json:"datadog"`
+			} `json:"experimental"`
+		} `json:"capabilities"`
+	}
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		t.Fatal(err)
+	}
+	return result.Capabilities.Experimental.Datadog
+}
+
+func TestInitializeAdvertisesDatadogProducts(t *testing.T) {
+	caps := initializeCapabilities(t, &MCPServer{site: govSite, disabledProducts: []string{"incidents"}})
+	if caps.Site != govSite || caps.Writes || caps.ReducedMode {
+		t.Fatalf("unexpected capabilities %+v", caps)
+	}
+	for _, product := range []string{"logs", "metrics", "apm", "monitors", "security", "synthetics", "usage"} {
+		if !caps.Products[product] {
+			t.Errorf("expected %s to be enabled", product)
+		}
+	}
+	if enabled, ok := caps.Products["incidents"]; !ok || enabled {
+		t.Fatalf("expected incidents to be reported as disabled, got %v", caps.Products)
+	}
+	if !strings.Contains(caps.Disabled["incidents"], "DD_MCP_DISABLED_PRODUCTS") || len(caps.Disabled) != 1 {
+		t.Fatalf("expected only incidents to be explained, got %v", caps.Disabled)
+	}
+
+	caps = initializeCapabilities(t, &MCPServer{allowWrites: true})
+	if !caps.Writes || caps.Site != "datadoghq.com" || caps.Disabled != nil {
+		t.Fatalf("expected writes and every product, got %+v", caps)
+	}
+}
+
+func TestInitializeCapabilitiesInReducedMode(t *testing.T) {
+	caps := initializeCapabilities(t, &MCPServer{apiKeyOnly: true})
+	if !caps.ReducedMode || caps.Products["logs"] || caps.Products["incidents"] {
+		t.Fatalf("expected data products to be off without an application key, got %+v", caps)
+	}
+	if !strings.Contains(caps.Disabled["logs"], "DD_APP_KEY") {
+		t.Fatalf("expected the reason to name DD_APP_KEY, got %v", caps.Disabled)
+	}
+}
+
+func TestDomainToolsExist(t *testing.T) {
+	listed := make(map[string]bool)
+	for _, tool := range (&MCPServer{allowWrites: true}).ListTools() {
+		listed[tool.Name] = true
+	}
+	for product, tools := range domainTools {
+		for _, name := range tools {
+			if !listed[name] {
+				t.Errorf("%s lists unknown tool %s", product, name)
+			}
+		}
+	}
+}
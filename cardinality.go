@@ -0,0 +1,236 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV2"
+)
+
+const (
+	// customMetricsUsageQuery is Datadog's estimated custom metric count,
+	// the number of distinct series, per metric name.
+	customMetricsUsageQuery    = "sum:datadog.estimated_usage.metrics.custom.by_metric{*} by {metric_name}"
+	defaultCardinalityWindow   = 24 * time.Hour
+	defaultCardinalityBaseline = 7 * 24 * time.Hour
+	defaultCardinalityGrowth   = 25.0
+	defaultCardinalityMetrics  = 10
+	maxCardinalityMetrics      = 50
+	// maxCardinalityTagKeys bounds the tag keys listed per metric.
+	maxCardinalityTagKeys = 5
+)
+
+type CardinalityParams struct {
+	Metrics   []string `json:"metrics,omitempty"`
+	Window    string   `json:"window,omitempty"`
+	Baseline  string   `json:"baseline,omitempty"`
+	MinGrowth float64  `json:"min_growth,omitempty"`
+	Limit     int      `json:"limit,omitempty"`
+}
+
+// TagKeyGrowth is a tag key and how much its number of values changed
+// recently, as reported by Datadog.
+type TagKeyGrowth struct {
+	Key              string `json:"key"`
+	CardinalityDelta int64  `json:"cardinality_delta"`
+}
+
+type MetricCardinality struct {
+	Metric string `json:"metric"`
+	// BaselineSeries and RecentSeries are the average estimated series
+	// count before and during the window.
+	BaselineSeries float64 `json:"baseline_series"`
+	RecentSeries   float64 `json:"recent_series"`
+	AddedSeries    float64 `json:"added_series"`
+	// GrowthPercent is unset for a metric with no baseline, which is new.
+	GrowthPercent  *float64       `json:"growth_percent,omitempty"`
+	IngestedVolume *int64         `json:"ingested_volume,omitempty"`
+	IndexedVolume  *int64         `json:"indexed_volume,omitempty"`
+	DistinctVolume *int64         `json:"distinct_volume,omitempty"`
+	TagKeys        []TagKeyGrowth `json:"tag_keys,omitempty"`
+	URL            string         `json:"url"`
+	Error          string         `json:"error,omitempty"`
+}
+
+type CardinalityResult struct {
+	Query    string              `json:"query"`
+	Window   string              `json:"window"`
+	Baseline string              `json:"baseline"`
+	Scanned  int                 `json:"scanned"`
+	Metrics  []MetricCardinality `json:"metrics"`
+	Notes    []string            `json:"notes,omitempty"`
+}
+
+// DetectCardinalityGrowth finds custom metrics whose series count grew
+// over the window compared to the baseline before it, from Datadog's
+// estimated usage metric, and names the tag keys behind the growth.
+func (s *MCPServer) DetectCardinalityGrowth(params CardinalityParams) (*CardinalityResult, error) {
+	window, err := parseDurationParam(params.Window, defaultCardinalityWindow)
+	if err != nil {
+		return nil, err
+	}
+	baseline, err := parseDurationParam(params.Baseline, defaultCardinalityBaseline)
+	if err != nil {
+		return nil, err
+	}
+	minGrowth := params.MinGrowth
+	if minGrowth <= 0 {
+		minGrowth = defaultCardinalityGrowth
+	}
+	limit := params.Limit
+	if limit <= 0 {
+		limit = defaultCardinalityMetrics
+	}
+	limit = clampArgument(s, "limit", limit, maxCardinalityMetrics)
+	wanted := make(map[string]bool)
+	for _, metric := range params.Metrics {
+		if metric = strings.TrimSpace(metric); metric != "" {
+			wanted[metric] = true
+		}
+	}
+
+	now := time.Now()
+	split := now.Add(-window)
+	resp, err := s.queryMetrics(split.Add(-baseline), now, customMetricsUsageQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CardinalityResult{
+		Query:    customMetricsUsageQuery,
+		Window:   window.String(),
+		Baseline: baseline.String(),
+		Metrics:  make([]MetricCardinality, 0),
+	}
+	seen := make(map[string]bool)
+	var growing []MetricCardinality
+	for i := range resp.Series {
+		metric := seriesTagValue(&resp.Series[i], "metric_name")
+		if metric == "" || (len(wanted) > 0 && !wanted[metric]) {
+			continue
+		}
+		seen[metric] = true
+		result.Scanned++
+
+		before, during := splitSeriesMean(&resp.Series[i], split)
+		m := MetricCardinality{
+			Metric:         metric,
+			BaselineSeries: math.Round(before),
+			RecentSeries:   math.Round(during),
+			AddedSeries:    math.Round(during - before),
+			URL:            s.appURL("/metric/summary?metric=" + url.QueryEscape(metric)),
+		}
+		if before > 0 {
+			growth := math.Round((during-before)/before*1000) / 10
+			m.GrowthPercent = &growth
+			if growth < minGrowth {
+				continue
+			}
+		} else if during <= 0 {
+			continue
+		}
+		growing = append(growing, m)
+	}
+	for metric := range wanted {
+		if !seen[metric] {
+			result.Notes = append(result.Notes, fmt.Sprintf("No usage data for %s; it may not be a custom metric or may not have reported in the period.", metric))
+		}
+	}
+	sort.Strings(result.Notes)
+	if result.Scanned == 0 && len(wanted) == 0 {
+		result.Notes = append(result.Notes, "No estimated custom metric usage was returned; the org may have no custom metrics or the key may lack usage access.")
+	}
+
+	sort.SliceStable(growing, func(i, j int) bool {
+		return growing[i].AddedSeries > growing[j].AddedSeries
+	})
+	if len(growing) > limit {
+		result.Notes = append(result.Notes, fmt.Sprintf("%d metrics grew at least %.0f%%; only the %d that added the most series are listed.", len(growing), minGrowth, limit))
+		growing = growing[:limit]
+	}
+	api := datadogV2.NewMetricsApi(s.ddClient)
+	for i := range growing {
+		s.describeCardinality(api, &growing[i])
+	}
+	result.Metrics = append(result.Metrics, growing...)
+	return result, nil
+}
+
+// describeCardinality adds a metric's current volume and the tag keys
+// whose value counts grew the most. Failures are recorded on the metric
+// so one unreadable metric doesn't hide the others.
+func (s *MCPServer) describeCardinality(api *datadogV2.MetricsApi, m *MetricCardinality) {
+	var errs []string
+	if resp, _, err := api.ListVolumesByMetricName(s.ctx, m.Metric); err != nil {
+		errs = append(errs, fmt.Sprintf("volumes: %v", err))
+	} else if data := resp.Data; data != nil {
+		switch {
+		case data.MetricIngestedIndexedVolume != nil && data.MetricIngestedIndexedVolume.Attributes != nil:
+			attrs := data.MetricIngestedIndexedVolume.Attributes
+			m.IngestedVolume, m.IndexedVolume = attrs.IngestedVolume, attrs.IndexedVolume
+		case data.MetricDistinctVolume != nil && data.MetricDistinctVolume.Attributes != nil:
+			m.DistinctVolume = data.MetricDistinctVolume.Attributes.DistinctVolume
+		}
+	}
+
+	resp, _, err := api.GetMetricTagCardinalityDetails(s.ctx, m.Metric)
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("tag cardinality: %v", err))
+	}
+	for _, tag := range resp.Data {
+		if tag.Attributes == nil || tag.Attributes.GetCardinalityDelta() <= 0 {
+			continue
+		}
+		m.TagKeys = append(m.TagKeys, TagKeyGrowth{Key: tag.GetId(), CardinalityDelta: tag.Attributes.GetCardinalityDelta()})
+	}
+	sort.SliceStable(m.TagKeys, func(i, j int) bool {
+		return m.TagKeys[i].CardinalityDelta > m.TagKeys[j].CardinalityDelta
+	})
+	if len(m.TagKeys) > maxCardinalityTagKeys {
+		m.TagKeys = m.TagKeys[:maxCardinalityTagKeys]
+	}
+	m.Error = strings.Join(errs, "; ")
+}
+
+// splitSeriesMean averages a series' points before and from split.
+func splitSeriesMean(meta *datadogV1.MetricsQueryMetadata, split time.Time) (before, during float64) {
+	var sums [2]float64
+	var counts [2]int
+	for _, p := range meta.Pointlist {
+		if len(p) < 2 || p[0] == nil || p[1] == nil {
+			continue
+		}
+		i := 0
+		if int64(*p[0]) >= split.UnixMilli() {
+			i = 1
+		}
+		sums[i] += *p[1]
+		counts[i]++
+	}
+	if counts[0] > 0 {
+		before = sums[0] / float64(counts[0])
+	}
+	if counts[1] > 0 {
+		during = sums[1] / float64(counts[1])
+	}
+	return before, during
+}
+
+// seriesTagValue returns the value of key in a grouped series' tag set.
+func seriesTagValue(meta *datadogV1.MetricsQueryMetadata, key string) string {
+	tags := meta.TagSet
+	if len(tags) == 0 {
+		tags = strings.Split(meta.GetScope(), ",")
+	}
+	for _, tag := range tags {
+		if k, v, ok := strings.Cut(tag, ":"); ok && k == key {
+			return v
+		}
+	}
+	return ""
+}
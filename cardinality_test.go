@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDetectCardinalityGrowth(t *testing.T) {
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/query":
+			// Two points in the baseline and two in the 1d window.
+			to, _ := strconv.ParseInt(r.URL.Query().Get("to"), 10, 64)
+			ts := []int64{(to - 5*86400) * 1000, (to - 3*86400) * 1000, (to - 3600) * 1000, to * 1000}
+			series := func(metric string, values ...float64) string {
+				points := make([]string, len(values))
+				for i, v := range values {
+					points[i] = fmt.Sprintf("[%d,%g]", ts[i], v)
+				}
+				return fmt.Sprintf(`{"scope":"metric_name:%s","tag_set":["metric_name:%s"],"pointlist":[%s]}`, metric, metric, strings.Join(points, ","))
+			}
+			fmt.Fprintf(w, `{"status":"ok","series":[%s,%s,%s]}`,
+				series("app.requests", 100, 100, 900, 1100),
+				series("app.steady", 50, 50, 52, 52),
+				series("app.new", 0, 0, 40, 40))
+		case "/api/v2/metrics/app.requests/volumes":
+			_, _ = w.Write([]byte(`{"data":{"id":"app.requests","type":"metric_volumes","attributes":{"ingested_volume":1200,"indexed_volume":1000}}}`))
+		case "/api/v2/metrics/app.requests/tag-cardinalities":
+			_, _ = w.Write([]byte(`{"data":[{"id":"env","type":"tag_cardinality","attributes":{"cardinality_delta":0}},{"id":"request_id","type":"tag_cardinality","attributes":{"cardinality_delta":850}},{"id":"host","type":"tag_cardinality","attributes":{"cardinality_delta":3}}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	result, err := server.DetectCardinalityGrowth(CardinalityParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Scanned != 3 || len(result.Metrics) != 2 {
+		t.Fatalf("expected two growing metrics of three, got %+v", result)
+	}
+	top := result.Metrics[0]
+	if top.Metric != "app.requests" || top.AddedSeries != 900 || top.GrowthPercent == nil || *top.GrowthPercent != 900 {
+		t.Fatalf("unexpected top metric: %+v", top)
+	}
+	if top.IndexedVolume == nil || *top.IndexedVolume != 1000 {
+		t.Fatalf("expected the indexed volume, got %+v", top)
+	}
+	if len(top.TagKeys) != 2 || top.TagKeys[0].Key != "request_id" {
+		t.Fatalf("expected request_id to lead the tag keys, got %+v", top.TagKeys)
+	}
+	if added := result.Metrics[1]; added.Metric != "app.new" || added.GrowthPercent != nil || added.Error == "" {
+		t.Fatalf("expected the new metric without a growth percent and with a lookup error, got %+v", added)
+	}
+
+	result, err = server.DetectCardinalityGrowth(CardinalityParams{Metrics: []string{"app.steady", "app.missing"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Scanned != 1 || len(result.Metrics) != 0 || len(result.Notes) != 1 || !strings.Contains(result.Notes[0], "app.missing") {
+		t.Fatalf("expected the steady metric to pass and the missing one noted, got %+v", result)
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadog"
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV2"
+)
+
+const (
+	defaultCITestLimit = 20
+	maxCITestLimit     = 100
+)
+
+type QueryCITestsParams struct {
+	// Query narrows the test events, such as "@test.framework:pytest".
+	Query   string `json:"query,omitempty"`
+	Service string `json:"service,omitempty"`
+	Branch  string `json:"branch,omitempty"`
+	From    string `json:"from,omitempty"`
+	To      string `json:"to,omitempty"`
+	Limit   int    `json:"limit,omitempty"`
+}
+
+// CITestSummary is one test's runs in the window by status.
+type CITestSummary struct {
+	Name    string `json:"name"`
+	Suite   string `json:"suite,omitempty"`
+	Runs    int64  `json:"runs"`
+	Passed  int64  `json:"passed"`
+	Failed  int64  `json:"failed"`
+	Skipped int64  `json:"skipped,omitempty"`
+	// FailureRate is the share of passed and failed runs that failed.
+	FailureRate float64 `json:"failure_rate"`
+}
+
+type QueryCITestsResult struct {
+	Query string `json:"query"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+	// Flaky tests both passed and failed in the window, most failures
+	// first.
+	Flaky []CITestSummary `json:"flaky"`
+	// Failing tests failed and never passed in the window.
+	Failing []CITestSummary `json:"failing"`
+	URL     string          `json:"url"`
+	Notes   []string        `json:"notes,omitempty"`
+}
+
+// QueryCITests answers "why is CI red": it finds the tests that failed
+// in the window and, by counting their runs by status, tells the flaky
+// ones from the ones that are simply broken.
+func (s *MCPServer) QueryCITests(params QueryCITestsParams) (*QueryCITestsResult, error) {
+	from, err := s.timeParam("from", params.From, time.Now().Add(-7*24*time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.timeParam("to", params.To, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	limit := params.Limit
+	if limit <= 0 {
+		limit = defaultCITestLimit
+	}
+	limit = clampArgument(s, "limit", limit, maxCITestLimit)
+
+	var filters []string
+	if query := strings.TrimSpace(params.Query); query != "" {
+		filters = append(filters, query)
+	}
+	if params.Service != "" {
+		filters = append(filters, "@test.service:"+params.Service)
+	}
+	if params.Branch != "" {
+		filters = append(filters, "@git.branch:"+params.Branch)
+	}
+	base := strings.Join(filters, " ")
+	query := cmp.Or(base, "*")
+	failedQuery := strings.TrimSpace(base + " @test.status:fail")
+
+	result := &QueryCITestsResult{
+		Query:   query,
+		From:    from.Format(time.RFC3339),
+		To:      to.Format(time.RFC3339),
+		Flaky:   []CITestSummary{},
+		Failing: []CITestSummary{},
+		URL:     s.appURL("/ci/test-runs?" + url.Values{"query": {failedQuery}}.Encode()),
+	}
+
+	// The tests that failed, most failures first, then every run of
+	// those tests by status.
+	failed, err := s.countCITests(failedQuery, from, to, limit, "@test.suite", "@test.name")
+	if err != nil {
+		return nil, err
+	}
+	if len(failed) == 0 {
+		result.Notes = append(result.Notes, "No test failed in this window.")
+		return result, nil
+	}
+	// Each suite keeps its own top tests, so keep the top ones overall.
+	slices.SortStableFunc(failed, func(a, b ciTestBucket) int { return cmp.Compare(b.count, a.count) })
+	truncated := len(failed) > limit
+	failed = failed[:min(len(failed), limit)]
+	names := make([]string, 0, len(failed))
+	for _, bucket := range failed {
+		if name := bucket.by["@test.name"]; !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	runs, err := s.countCITests(strings.TrimSpace(fmt.Sprintf("%s @test.name:(%s)", base, quoteCIValues(names))), from, to, maxCITestLimit, "@test.suite", "@test.name", "@test.status")
+	if err != nil {
+		return nil, err
+	}
+
+	tests := make(map[[2]string]*CITestSummary)
+	for _, bucket := range failed {
+		key := [2]string{bucket.by["@test.suite"], bucket.by["@test.name"]}
+		tests[key] = &CITestSummary{Suite: key[0], Name: key[1]}
+	}
+	for _, bucket := range runs {
+		test, ok := tests[[2]string{bucket.by["@test.suite"], bucket.by["@test.name"]}]
+		if !ok {
+			// A test of the same name in a suite where it didn't fail.
+			continue
+		}
+		test.Runs += bucket.count
+		switch bucket.by["@test.status"] {
+		case "pass":
+			test.Passed += bucket.count
+		case "fail":
+			test.Failed += bucket.count
+		case "skip":
+			test.Skipped += bucket.count
+		}
+	}
+	for _, test := range tests {
+		if test.Passed+test.Failed > 0 {
+			test.FailureRate = float64(test.Failed) / float64(test.Passed+test.Failed)
+		}
+		if test.Passed > 0 {
+			result.Flaky = append(result.Flaky, *test)
+		} else {
+			result.Failing = append(result.Failing, *test)
+		}
+	}
+	byFailures := func(a, b CITestSummary) int {
+		return cmp.Or(cmp.Compare(b.Failed, a.Failed), cmp.Compare(a.Suite, b.Suite), cmp.Compare(a.Name, b.Name))
+	}
+	slices.SortFunc(result.Flaky, byFailures)
+	slices.SortFunc(result.Failing, byFailures)
+
+	if truncated {
+		result.Notes = append(result.Notes, fmt.Sprintf("Only the %d tests with the most failures are included; raise limit for more.", limit))
+	}
+	if len(result.Flaky) > 0 {
+		result.Notes = append(result.Notes, "Flaky tests passed and failed in the same window; they may also have failed on different commits, so check the url before quarantining one.")
+	}
+	return result, nil
+}
+
+// ciTestBucket is one group of test events and how many there were.
+type ciTestBucket struct {
+	by    map[string]string
+	count int64
+}
+
+// countCITests counts the test events matching query, grouped by the
+// facets in order, each keeping its top limit values by count.
+func (s *MCPServer) countCITests(query string, from, to time.Time, limit int, facets ...string) ([]ciTestBucket, error) {
+	sort := &datadogV2.CIAppAggregateSort{
+		Aggregation: datadogV2.CIAPPAGGREGATIONFUNCTION_COUNT.Ptr(),
+		Order:       datadogV2.CIAPPSORTORDER_DESCENDING.Ptr(),
+		Type:        datadogV2.CIAPPAGGREGATESORTTYPE_MEASURE.Ptr(),
+	}
+	groupBy := make([]datadogV2.CIAppTestsGroupBy, 0, len(facets))
+	for _, facet := range facets {
+		groupBy = append(groupBy, datadogV2.CIAppTestsGroupBy{
+			Facet: facet,
+			Limit: datadog.PtrInt64(int64(limit)),
+			Sort:  sort,
+		})
+	}
+	body := datadogV2.CIAppTestsAggregateRequest{
+		Compute: []datadogV2.CIAppCompute{{
+			Aggregation: datadogV2.CIAPPAGGREGATIONFUNCTION_COUNT,
+			Type:        datadogV2.CIAPPCOMPUTETYPE_TOTAL.Ptr(),
+		}},
+		Filter: &datadogV2.CIAppTestsQueryFilter{
+			From:  datadog.PtrString(from.Format(time.RFC3339)),
+			To:    datadog.PtrString(to.Format(time.RFC3339)),
+			Query: datadog.PtrString(query),
+		},
+		GroupBy: groupBy,
+	}
+	resp, _, err := datadogV2.NewCIVisibilityTestsApi(s.ddClient).AggregateCIAppTestEvents(s.ctx, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate CI test events: %w", err)
+	}
+
+	buckets := make([]ciTestBucket, 0, len(resp.GetData().Buckets))
+	for _, bucket := range resp.GetData().Buckets {
+		out := ciTestBucket{by: make(map[string]string, len(bucket.By))}
+		for facet, value := range bucket.By {
+			out.by[facet] = fmt.Sprint(value)
+		}
+		if value, ok := bucket.Computes["c0"]; ok && value.CIAppAggregateBucketValueSingleNumber != nil {
+			out.count = int64(*value.CIAppAggregateBucketValueSingleNumber)
+		}
+		buckets = append(buckets, out)
+	}
+	return buckets, nil
+}
+
+// quoteCIValues joins values into an OR list for a facet query, quoting
+// each so names with spaces or punctuation match exactly.
+func quoteCIValues(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+	}
+	return strings.Join(quoted, " OR ")
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestQueryCITests(t *testing.T) {
+	var queries []string
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/api/v2/ci/tests/analytics/aggregate" {
+			http.NotFound(w, r)
+			return
+		}
+		var body struct {
+			Filter struct {
+				Query string `json:"query"`
+			} `json:"filter"`
+			GroupBy []struct {
+				Facet string `json:"facet"`
+			} `json:"group_by"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		queries = append(queries, body.Filter.Query)
+		if len(body.GroupBy) == 2 {
+			_, _ = w.Write([]byte(`{"data":{"buckets":[
+				{"by":{"@test.suite":"checkout","@test.name":"test_pay"},"computes":{"c0":4}},
+				{"by":{"@test.suite":"search","@test.name":"test \"quoted\""},"computes":{"c0":9}},
+				{"by":{"@test.suite":"cart","@test.name":"test_total"},"computes":{"c0":1}}]}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"buckets":[
+			{"by":{"@test.suite":"checkout","@test.name":"test_pay","@test.status":"pass"},"computes":{"c0":36}},
+			{"by":{"@test.suite":"checkout","@test.name":"test_pay","@test.status":"fail"},"computes":{"c0":4}},
+			{"by":{"@test.suite":"search","@test.name":"test \"quoted\"","@test.status":"fail"},"computes":{"c0":9}},
+			{"by":{"@test.suite":"search","@test.name":"test \"quoted\"","@test.status":"skip"},"computes":{"c0":2}},
+			{"by":{"@test.suite":"cart","@test.name":"test_total","@test.status":"pass"},"computes":{"c0":99}},
+			{"by":{"@test.suite":"cart","@test.name":"test_total","@test.status":"fail"},"computes":{"c0":1}},
+			{"by":{"@test.suite":"other","@test.name":"test_pay","@test.status":"pass"},"computes":{"c0":50}}]}}`))
+	})
+
+	result, err := server.QueryCITests(QueryCITestsParams{Service: "web", Branch: "main", Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queries) != 2 || queries[0] != "@test.service:web @git.branch:main @test.status:fail" ||
+		queries[1] != `@test.service:web @git.branch:main @test.name:("test \"quoted\"" OR "test_pay")` {
+		t.Fatalf("unexpected queries %q", queries)
+	}
+	if len(result.Flaky) != 1 || result.Flaky[0].Name != "test_pay" || result.Flaky[0].Passed != 36 || result.Flaky[0].FailureRate != 0.1 {
+		t.Fatalf("expected the top flaky test without the other suite's runs, got %+v", result.Flaky)
+	}
+	if len(result.Failing) != 1 || result.Failing[0].Suite != "search" || result.Failing[0].Runs != 11 || result.Failing[0].Skipped != 2 || result.Failing[0].FailureRate != 1 {
+		t.Fatalf("expected the never-passing test as failing, got %+v", result.Failing)
+	}
+	if !strings.Contains(result.URL, "/ci/test-runs?") || !strings.Contains(strings.Join(result.Notes, " "), "Only the 2 tests") {
+		t.Fatalf("unexpected url or notes: %s %v", result.URL, result.Notes)
+	}
+}
+
+func TestQueryCITestsNoFailures(t *testing.T) {
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"buckets":[]}}`))
+	})
+	result, err := server.QueryCITests(QueryCITestsParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Query != "*" || len(result.Flaky) != 0 || len(result.Notes) != 1 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// runCall runs one tool from the command line and writes its result to
+// out, for scripts and shell pipelines that don't speak MCP:
+//
+//	go-dd-mcp call query_logs '{"query":"service:web status:error"}'
+//
+// With -ndjson, query_logs writes one log per line as each page arrives,
+// so results far larger than the usual limit never have to fit in
+// memory; its summary goes to errOut. Other tools write their result as a
+// single line.
+func runCall(args []string, in io.Reader, out, errOut io.Writer, newServer func() (*MCPServer, error)) error {
+	flags := flag.NewFlagSet("call", flag.ContinueOnError)
+	flags.SetOutput(errOut)
+	ndjson := flags.Bool("ndjson", false, "write newline-delimited JSON, streaming query_logs one log per line")
+	flags.Usage = func() {
+		fmt.Fprintln(errOut, "Usage: go-dd-mcp call [flags] tool [arguments-json | -]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 || flags.NArg() > 2 {
+		flags.Usage()
+		return fmt.Errorf("a tool name and at most one arguments object are required")
+	}
+	name := flags.Arg(0)
+	arguments := []byte("{}")
+	if flags.NArg() == 2 {
+		arguments = []byte(flags.Arg(1))
+		if flags.Arg(1) == "-" {
+			var err error
+			if arguments, err = io.ReadAll(in); err != nil {
+				return fmt.Errorf("failed to read arguments: %w", err)
+			}
+		}
+	}
+	if trimmed := bytes.TrimSpace(arguments); !json.Valid(trimmed) || trimmed[0] != '{' {
+		return fmt.Errorf("arguments must be a JSON object")
+	}
+
+	server, err := newServer()
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(out)
+	defer w.Flush()
+
+	// Pages of a multi-service query arrive concurrently.
+	var mu sync.Mutex
+	var writeErr error
+	streamed := false
+	if *ndjson {
+		encoder := json.NewEncoder(w)
+		server = server.withLogStream(func(page []LogEntry) error {
+			mu.Lock()
+			defer mu.Unlock()
+			streamed = true
+			for _, entry := range page {
+				if writeErr = encoder.Encode(entry); writeErr != nil {
+					return writeErr
+				}
+			}
+			// Flush each page so the next command in the pipeline can
+			// start on it while more are fetched.
+			writeErr = w.Flush()
+			return writeErr
+		})
+	}
+
+	text, toolErr := server.callTool(ToolCallParams{Name: name, Arguments: json.RawMessage(arguments)})
+	if writeErr != nil {
+		// The reader went away, such as head having read enough.
+		if errors.Is(writeErr, syscall.EPIPE) {
+			return nil
+		}
+		return fmt.Errorf("failed to write results: %w", writeErr)
+	}
+	if toolErr != nil {
+		return fmt.Errorf("%s failed: %s", name, toolErr.Message)
+	}
+	if !*ndjson {
+		_, err := fmt.Fprintln(w, text)
+		return err
+	}
+
+	line := compactJSON(text)
+	if streamed || name == "query_logs" {
+		_, err := fmt.Fprintln(errOut, line)
+		return err
+	}
+	_, err = fmt.Fprintln(w, line)
+	return err
+}
+
+// withLogStream returns a copy of the server that hands each page of
+// query_logs results to stream instead of keeping them in the result.
+func (s *MCPServer) withLogStream(stream func([]LogEntry) error) *MCPServer {
+	streaming := *s
+	streaming.logStream = stream
+	return &streaming
+}
+
+// compactJSON puts a JSON result on one line, leaving text that isn't
+// JSON as it is.
+func compactJSON(text string) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(text)); err != nil {
+		return strings.ReplaceAll(text, "\n", " ")
+	}
+	return buf.String()
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRunCallNDJSON(t *testing.T) {
+	var pageLimits []int
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/api/v2/logs/events/search" {
+			http.NotFound(w, r)
+			return
+		}
+		var body struct {
+			Page struct {
+				Cursor string `json:"cursor"`
+				Limit  int    `json:"limit"`
+			} `json:"page"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		pageLimits = append(pageLimits, body.Page.Limit)
+		if body.Page.Cursor == "" {
+			_, _ = w.Write([]byte(`{"data":[
+				{"id":"a","attributes":{"message":"first","service":"web","timestamp":"2026-03-01T12:00:02Z"}},
+				{"id":"b","attributes":{"message":"second","service":"web","timestamp":"2026-03-01T12:00:01Z"}}],
+				"meta":{"page":{"after":"next"}}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[{"id":"c","attributes":{"message":"third","service":"web","timestamp":"2026-03-01T12:00:00Z"}}]}`))
+	})
+	newServer := func() (*MCPServer, error) { return server, nil }
+
+	var out, errOut bytes.Buffer
+	if err := runCall([]string{"-ndjson", "query_logs", "-"}, strings.NewReader(`{"query":"service:web","limit":20000}`), &out, &errOut, newServer); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[0], `"id":"a"`) || !strings.Contains(lines[2], `"message":"third"`) {
+		t.Fatalf("expected one log per line, got %q", out.String())
+	}
+	if len(pageLimits) != 2 || pageLimits[0] != logsPageSize {
+		t.Fatalf("expected full pages beyond the usual limit, got %v", pageLimits)
+	}
+	var summary QueryLogsResult
+	if err := json.Unmarshal(errOut.Bytes(), &summary); err != nil || summary.Count != 3 || len(summary.Logs) != 0 {
+		t.Fatalf("expected the summary on stderr without the logs, got %s (%v)", errOut.String(), err)
+	}
+	if server.logStream != nil {
+		t.Fatal("expected streaming to be limited to the call")
+	}
+}
+
+func TestRunCall(t *testing.T) {
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":7,"name":"Checkout errors","type":"query alert","query":"avg(last_5m):avg:checkout.errors{*} > 5","overall_state":"OK"}`))
+	})
+	newServer := func() (*MCPServer, error) { return server, nil }
+
+	var out, errOut bytes.Buffer
+	if err := runCall([]string{"get_monitor", `{"monitor_id":7}`}, nil, &out, &errOut, newServer); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "\n  \"name\": \"Checkout errors\"") {
+		t.Fatalf("expected the indented result, got %s", out.String())
+	}
+
+	out.Reset()
+	if err := runCall([]string{"-ndjson", "get_monitor", `{"monitor_id":7}`}, nil, &out, &errOut, newServer); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(out.String(), "\n") != 1 || !strings.HasPrefix(out.String(), `{"schema_version":2,"id":7,`) {
+		t.Fatalf("expected the result on one line, got %q", out.String())
+	}
+
+	if err := runCall([]string{"get_monitor", `{"monitor_id":`}, nil, &out, &errOut, newServer); err == nil {
+		t.Fatal("expected invalid arguments to be rejected")
+	}
+	for _, arguments := range []string{`[{"monitor_id":7}]`, `7`, `null`, ``} {
+		if err := runCall([]string{"get_monitor", arguments}, nil, &out, &errOut, newServer); err == nil || !strings.Contains(err.Error(), "must be a JSON object") {
+			t.Fatalf("expected %q to be rejected as not an object, got %v", arguments, err)
+		}
+	}
+	if err := runCall([]string{"get_monitor", `{"monitor_id":0}`}, nil, &out, &errOut, newServer); err == nil || !strings.Contains(err.Error(), "get_monitor failed") {
+		t.Fatalf("expected the tool error, got %v", err)
+	}
+	if err := runCall(nil, nil, &out, &errOut, newServer); err == nil {
+		t.Fatal("expected a missing tool name to be rejected")
+	}
+}
@@ -0,0 +1,449 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+const defaultSimulateStepTimeout = 30 * time.Second
+
+// clientScenario is a scripted conversation with the server, read from a
+// YAML file, that reproduces how a particular client talks to it.
+type clientScenario struct {
+	Name string `yaml:"name"`
+	// Client names the client whose behavior the scenario reproduces,
+	// such as "Claude Desktop" or "Cursor".
+	Client string `yaml:"client"`
+	// Headers are sent with every HTTP request, as some clients add
+	// their own.
+	Headers map[string]string `yaml:"headers"`
+	Steps   []clientStep      `yaml:"steps"`
+}
+
+type clientStep struct {
+	Name string `yaml:"name"`
+	// Send is the JSON-RPC message to send. Raw sends text as-is instead,
+	// for clients that send malformed messages.
+	Send   map[string]interface{} `yaml:"send"`
+	Raw    string                 `yaml:"raw"`
+	Expect *clientExpectation     `yaml:"expect"`
+}
+
+// clientExpectation is what a step's response must look like. Without
+// one, a request only has to be answered and a notification is sent
+// without waiting.
+type clientExpectation struct {
+	// Error is true to expect an error response and false to expect a
+	// result.
+	Error     *bool `yaml:"error"`
+	ErrorCode int   `yaml:"error_code"`
+	// Result must be contained in the response's result; objects may
+	// have keys it leaves out.
+	Result interface{} `yaml:"result"`
+	// Contains and NotContains are matched against the response and the
+	// text of any tool result.
+	Contains    []string `yaml:"contains"`
+	NotContains []string `yaml:"not_contains"`
+}
+
+// clientTransport sends a message to the server under test and, when
+// wantReply is set, returns the response with the given id.
+type clientTransport interface {
+	roundTrip(ctx context.Context, message []byte, id int, wantReply bool) ([]byte, error)
+	Close() error
+}
+
+// runSimulate implements "go-dd-mcp simulate": it plays each scenario
+// file against a server and reports every step, returning an error if
+// any failed.
+func runSimulate(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("simulate", flag.ContinueOnError)
+	flags.SetOutput(out)
+	serverURL := flags.String("url", "", "MCP endpoint of a running HTTP server, such as http://localhost:8080/mcp")
+	token := flags.String("token", os.Getenv("DD_MCP_SIMULATE_TOKEN"), "bearer token for the HTTP server")
+	command := flags.String("command", "", "command that starts a stdio server (default: this binary)")
+	timeout := flags.Duration("timeout", defaultSimulateStepTimeout, "how long to wait for each response")
+	flags.Usage = func() {
+		fmt.Fprintln(out, "Usage: go-dd-mcp simulate [flags] scenario.yaml...")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("at least one scenario file is required")
+	}
+	if *serverURL != "" && *command != "" {
+		return fmt.Errorf("pass either -url or -command")
+	}
+
+	var scenarios []clientScenario
+	for _, path := range flags.Args() {
+		scenario, err := loadClientScenario(path)
+		if err != nil {
+			return err
+		}
+		scenarios = append(scenarios, scenario)
+	}
+
+	steps, failed := 0, 0
+	for _, scenario := range scenarios {
+		var transport clientTransport
+		var err error
+		if *serverURL != "" {
+			transport = newHTTPClientTransport(*serverURL, *token, scenario.Headers)
+		} else {
+			transport, err = startStdioClientTransport(*command)
+			if err != nil {
+				return err
+			}
+		}
+		n, f := playScenario(scenario, transport, *timeout, out)
+		transport.Close()
+		steps += n
+		failed += f
+	}
+	fmt.Fprintf(out, "%d scenarios, %d steps, %d failed\n", len(scenarios), steps, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d steps failed", failed, steps)
+	}
+	return nil
+}
+
+func loadClientScenario(path string) (clientScenario, error) {
+	var scenario clientScenario
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return scenario, fmt.Errorf("failed to read scenario: %w", err)
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&scenario); err != nil {
+		return scenario, fmt.Errorf("invalid scenario %s: %w", path, err)
+	}
+	if scenario.Name == "" {
+		scenario.Name = path
+	}
+	if len(scenario.Steps) == 0 {
+		return scenario, fmt.Errorf("scenario %s has no steps", path)
+	}
+	for i, step := range scenario.Steps {
+		if (step.Send == nil) == (step.Raw == "") {
+			return scenario, fmt.Errorf("scenario %s step %d: set either send or raw", path, i+1)
+		}
+	}
+	return scenario, nil
+}
+
+// playScenario runs a scenario's steps in order, printing PASS or FAIL
+// for each, and returns how many ran and failed.
+func playScenario(scenario clientScenario, transport clientTransport, timeout time.Duration, out io.Writer) (steps, failed int) {
+	title := scenario.Name
+	if scenario.Client != "" {
+		title += " (" + scenario.Client + ")"
+	}
+	fmt.Fprintln(out, title)
+	for i, step := range scenario.Steps {
+		name := step.Name
+		if name == "" {
+			name = fmt.Sprintf("step %d", i+1)
+			if method, ok := step.Send["method"].(string); ok {
+				name += " " + method
+			}
+		}
+		steps++
+		if err := playStep(step, transport, timeout); err != nil {
+			failed++
+			fmt.Fprintf(out, "  FAIL %s: %v\n", name, err)
+			continue
+		}
+		fmt.Fprintf(out, "  PASS %s\n", name)
+	}
+	return steps, failed
+}
+
+func playStep(step clientStep, transport clientTransport, timeout time.Duration) error {
+	message := []byte(step.Raw)
+	_, hasID := step.Send["id"]
+	if step.Send != nil {
+		var err error
+		if message, err = json.Marshal(step.Send); err != nil {
+			return fmt.Errorf("failed to encode message: %w", err)
+		}
+	}
+	// Responses to raw messages and notifications carry id 0.
+	var req MCPRequest
+	_ = json.Unmarshal(message, &req)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	wantReply := step.Expect != nil || hasID
+	reply, err := transport.roundTrip(ctx, message, req.ID, wantReply)
+	if err != nil {
+		return err
+	}
+	if step.Expect == nil {
+		return nil
+	}
+	return step.Expect.check(reply)
+}
+
+// check reports the first way reply falls short of the expectation.
+func (e *clientExpectation) check(reply []byte) error {
+	var resp MCPResponse
+	if err := json.Unmarshal(reply, &resp); err != nil {
+		return fmt.Errorf("response isn't JSON-RPC: %v", err)
+	}
+	if e.ErrorCode != 0 {
+		if resp.Error == nil {
+			return fmt.Errorf("expected error %d, got a result", e.ErrorCode)
+		}
+		if resp.Error.Code != e.ErrorCode {
+			return fmt.Errorf("expected error %d, got %d: %s", e.ErrorCode, resp.Error.Code, resp.Error.Message)
+		}
+	}
+	if e.Error != nil && *e.Error != (resp.Error != nil) {
+		if resp.Error != nil {
+			return fmt.Errorf("expected a result, got error %d: %s", resp.Error.Code, resp.Error.Message)
+		}
+		return fmt.Errorf("expected an error, got a result")
+	}
+	if e.Result != nil {
+		if resp.Result == nil {
+			return fmt.Errorf("expected a result, got none")
+		}
+		var want, got interface{}
+		data, err := json.Marshal(e.Result)
+		if err != nil {
+			return fmt.Errorf("invalid expected result: %v", err)
+		}
+		_ = json.Unmarshal(data, &want)
+		_ = json.Unmarshal(resp.Result, &got)
+		if path, ok := jsonSubset(want, got, "result"); !ok {
+			return fmt.Errorf("%s doesn't match the expected value", path)
+		}
+	}
+
+	haystack := string(reply)
+	var result ToolCallResult
+	if json.Unmarshal(resp.Result, &result) == nil {
+		for _, item := range result.Content {
+			haystack += "\n" + item.Text
+		}
+	}
+	for _, want := range e.Contains {
+		if !strings.Contains(haystack, want) {
+			return fmt.Errorf("expected the response to contain %q", want)
+		}
+	}
+	for _, unwanted := range e.NotContains {
+		if strings.Contains(haystack, unwanted) {
+			return fmt.Errorf("expected the response not to contain %q", unwanted)
+		}
+	}
+	return nil
+}
+
+// jsonSubset reports whether got contains want: objects may have extra
+// keys, while arrays and other values must match exactly. On a mismatch
+// it returns the path of the first differing value.
+func jsonSubset(want, got interface{}, path string) (string, bool) {
+	switch want := want.(type) {
+	case map[string]interface{}:
+		object, ok := got.(map[string]interface{})
+		if !ok {
+			return path, false
+		}
+		for key, value := range want {
+			if p, ok := jsonSubset(value, object[key], path+"."+key); !ok {
+				return p, false
+			}
+		}
+		return "", true
+	case []interface{}:
+		array, ok := got.([]interface{})
+		if !ok || len(array) != len(want) {
+			return path, false
+		}
+		for i := range want {
+			if p, ok := jsonSubset(want[i], array[i], fmt.Sprintf("%s[%d]", path, i)); !ok {
+				return p, false
+			}
+		}
+		return "", true
+	default:
+		if !reflect.DeepEqual(want, got) {
+			return path, false
+		}
+		return "", true
+	}
+}
+
+// httpClientTransport posts each message to a running HTTP server.
+type httpClientTransport struct {
+	url     string
+	token   string
+	headers map[string]string
+	client  *http.Client
+}
+
+func newHTTPClientTransport(url, token string, headers map[string]string) *httpClientTransport {
+	return &httpClientTransport{url: url, token: token, headers: headers, client: &http.Client{}}
+}
+
+func (t *httpClientTransport) roundTrip(ctx context.Context, message []byte, id int, wantReply bool) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(message))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	if t.token != "" {
+		req.Header.Set("Authorization", "Bearer "+t.token)
+	}
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("server returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	if !wantReply {
+		return nil, nil
+	}
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		return body, nil
+	}
+	// The response is the event that carries an id; the rest are
+	// notifications sent while the request ran.
+	for _, line := range strings.Split(string(body), "\n") {
+		data, ok := strings.CutPrefix(line, "data: ")
+		if ok && isResponseTo([]byte(data), id) {
+			return []byte(data), nil
+		}
+	}
+	return nil, fmt.Errorf("the event stream ended without a response")
+}
+
+func (t *httpClientTransport) Close() error {
+	t.client.CloseIdleConnections()
+	return nil
+}
+
+// stdioClientTransport writes messages to a server's stdin and reads
+// its replies from stdout.
+type stdioClientTransport struct {
+	w       io.Writer
+	replies chan []byte
+	closer  func() error
+}
+
+// startStdioClientTransport starts command, or this binary when it's
+// empty, as a stdio server.
+func startStdioClientTransport(command string) (*stdioClientTransport, error) {
+	var cmd *exec.Cmd
+	if command == "" {
+		self, err := os.Executable()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the server binary: %w", err)
+		}
+		cmd = exec.Command(self)
+		cmd.Env = append(os.Environ(), "DD_MCP_TRANSPORT=stdio")
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start the server: %w", err)
+	}
+	return newStdioClientTransport(stdin, stdout, func() error {
+		stdin.Close()
+		return cmd.Wait()
+	}), nil
+}
+
+func newStdioClientTransport(w io.Writer, r io.Reader, closer func() error) *stdioClientTransport {
+	t := &stdioClientTransport{w: w, replies: make(chan []byte, 16), closer: closer}
+	go func() {
+		defer close(t.replies)
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), maxRequestBytes)
+		for scanner.Scan() {
+			t.replies <- append([]byte(nil), scanner.Bytes()...)
+		}
+	}()
+	return t
+}
+
+func (t *stdioClientTransport) roundTrip(ctx context.Context, message []byte, id int, wantReply bool) ([]byte, error) {
+	if _, err := t.w.Write(append(bytes.TrimSpace(message), '\n')); err != nil {
+		return nil, fmt.Errorf("failed to write to the server: %w", err)
+	}
+	if !wantReply {
+		return nil, nil
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("no response to id %d: %w", id, ctx.Err())
+		case reply, ok := <-t.replies:
+			if !ok {
+				return nil, fmt.Errorf("the server exited without responding")
+			}
+			if isResponseTo(reply, id) {
+				return reply, nil
+			}
+		}
+	}
+}
+
+func (t *stdioClientTransport) Close() error {
+	if t.closer == nil {
+		return nil
+	}
+	return t.closer()
+}
+
+// isResponseTo reports whether message is the response to request id,
+// rather than a notification or another request's response.
+func isResponseTo(message []byte, id int) bool {
+	var envelope struct {
+		ID     *int            `json:"id"`
+		Result json.RawMessage `json:"result"`
+		Error  *MCPError       `json:"error"`
+	}
+	if err := json.Unmarshal(message, &envelope); err != nil {
+		return false
+	}
+	return envelope.ID != nil && *envelope.ID == id && (envelope.Result != nil || envelope.Error != nil)
+}
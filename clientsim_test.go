@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testClientScenario = `
+name: Handshake
+client: Cursor
+headers:
+  Mcp-Session-Id: sim-1
+steps:
+  - send: {jsonrpc: "2.0", id: 1, method: initialize, params: {clientInfo: {name: cursor, version: "1.0"}}}
+    expect:
+      error: false
+      result:
+        serverInfo: {name: datadog-mcp-server}
+  - send: {jsonrpc: "2.0", method: notifications/initialized}
+  - name: lists tools
+    send: {jsonrpc: "2.0", id: 2, method: tools/list}
+    expect:
+      contains: [list_monitors]
+  - name: unknown method
+    send: {jsonrpc: "2.0", id: 3, method: tools/nope}
+    expect:
+      error_code: -32601
+  - name: wrong expectation
+    send: {jsonrpc: "2.0", id: 4, method: tools/list}
+    expect:
+      not_contains: [list_monitors]
+`
+
+func TestRunSimulateHTTP(t *testing.T) {
+	server := newFakeDatadogServer(t, http.NotFound)
+	ts := httptest.NewServer(server.httpHandler())
+	t.Cleanup(ts.Close)
+	path := writeTempFile(t, "scenario.yaml", testClientScenario)
+
+	var out bytes.Buffer
+	err := runSimulate([]string{"-url", ts.URL + "/mcp", path}, &out)
+	if err == nil || err.Error() != "1 of 5 steps failed" {
+		t.Fatalf("expected the wrong expectation to fail, got %v:\n%s", err, out.String())
+	}
+	for _, want := range []string{
+		"Handshake (Cursor)",
+		"  PASS step 1 initialize",
+		"  PASS step 2 notifications/initialized",
+		"  PASS lists tools",
+		"  PASS unknown method",
+		`  FAIL wrong expectation: expected the response not to contain "list_monitors"`,
+		"1 scenarios, 5 steps, 1 failed",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("expected %q in:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestClientScenarioStdio(t *testing.T) {
+	server := newFakeDatadogServer(t, http.NotFound)
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		serveStream(server, serverR, serverW)
+		serverW.Close()
+	}()
+	transport := newStdioClientTransport(clientW, clientR, func() error {
+		clientW.Close()
+		<-done
+		return nil
+	})
+
+	scenario, err := loadClientScenario(writeTempFile(t, "scenario.yaml", `
+name: Malformed
+steps:
+  - raw: '{"jsonrpc":"2.0","id":1,"method":"initialize"}'
+    expect: {result: {protocolVersion: "2024-11-05"}}
+  - send: {jsonrpc: "2.0", id: 2, method: tools/call, params: {name: get_monitor, arguments: {monitor_id: "ten"}}}
+    expect: {error_code: -32602, contains: [invalid arguments]}
+  - send: {jsonrpc: "2.0", id: 3, method: initialize}
+    expect: {result: {serverInfo: {name: other}}}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	steps, failed := playScenario(scenario, transport, defaultSimulateStepTimeout, &out)
+	transport.Close()
+	if steps != 3 || failed != 1 {
+		t.Fatalf("expected only the last step to fail, got %d of %d:\n%s", failed, steps, out.String())
+	}
+	if !strings.Contains(out.String(), "FAIL step 3 initialize: result.serverInfo.name doesn't match") {
+		t.Fatalf("expected the mismatch path to be reported:\n%s", out.String())
+	}
+}
+
+func TestLoadClientScenarioRejectsBadSteps(t *testing.T) {
+	for _, text := range []string{
+		"name: empty\n",
+		"steps:\n  - name: nothing\n",
+		"steps:\n  - send: {id: 1}\n    expct: {}\n",
+	} {
+		if _, err := loadClientScenario(writeTempFile(t, "scenario.yaml", text)); err == nil {
+			t.Errorf("expected %q to be rejected", text)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+// streamEncoder is the part of gzip.Writer and zstd.Encoder that
+// compressedWriter needs.
+type streamEncoder interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// withCompression compresses responses with zstd or gzip when the client
+// accepts either, preferring zstd.
+func withCompression(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		var enc streamEncoder
+		switch encoding := negotiateEncoding(r.Header.Get("Accept-Encoding")); encoding {
+		case "zstd":
+			zw, err := zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			enc = zw
+		case "gzip":
+			enc = gzip.NewWriter(w)
+		default:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressedWriter{ResponseWriter: w, enc: enc}
+		defer cw.close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// negotiateEncoding picks the best supported encoding from an
+// Accept-Encoding header, ignoring codings the client refuses with q=0.
+func negotiateEncoding(header string) string {
+	accepted := make(map[string]bool)
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				continue
+			}
+		}
+		accepted[strings.ToLower(strings.TrimSpace(coding))] = true
+	}
+
+	for _, encoding := range []string{"zstd", "gzip"} {
+		if accepted[encoding] {
+			return encoding
+		}
+	}
+	return ""
+}
+
+// compressedWriter sets the encoding headers on the first write, so
+// handlers can still set status codes and content types, and flushes the
+// encoder along with the connection for server-sent events.
+type compressedWriter struct {
+	http.ResponseWriter
+	enc         streamEncoder
+	wroteHeader bool
+}
+
+func (c *compressedWriter) WriteHeader(status int) {
+	if !c.wroteHeader {
+		c.wroteHeader = true
+		c.Header().Del("Content-Length")
+		c.Header().Set("Content-Encoding", c.encoding())
+	}
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *compressedWriter) Write(b []byte) (int, error) {
+	if !c.wroteHeader {
+		c.WriteHeader(http.StatusOK)
+	}
+	return c.enc.Write(b)
+}
+
+func (c *compressedWriter) Flush() {
+	c.enc.Flush()
+	if f, ok := c.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// close finishes the compressed stream. Responses without a body are left
+// uncompressed.
+func (c *compressedWriter) close() {
+	if c.wroteHeader {
+		c.enc.Close()
+	}
+}
+
+func (c *compressedWriter) encoding() string {
+	if _, ok := c.enc.(*zstd.Encoder); ok {
+		return "zstd"
+	}
+	return "gzip"
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := map[string]string{
+		"":                        "",
+		"gzip":                    "gzip",
+		"gzip, deflate, br, zstd": "zstd",
+		"zstd;q=0, gzip;q=0.5":    "gzip",
+		"GZIP":                    "gzip",
+		"br":                      "",
+	}
+	for header, expected := range tests {
+		if got := negotiateEncoding(header); got != expected {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", header, got, expected)
+		}
+	}
+}
+
+func TestHTTPHandlerCompression(t *testing.T) {
+	handler := (&MCPServer{}).httpHandler()
+	body := `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`
+
+	decoders := map[string]func(io.Reader) (io.Reader, error){
+		"gzip": func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		"zstd": func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) },
+	}
+	for encoding, decode := range decoders {
+		t.Run(encoding, func(t *testing.T) {
+			rec := postMCP(t, handler, body, map[string]string{"Accept-Encoding": encoding})
+			if got := rec.Header().Get("Content-Encoding"); got != encoding {
+				t.Fatalf("expected Content-Encoding %s, got %q", encoding, got)
+			}
+			r, err := decode(rec.Body)
+			if err != nil {
+				t.Fatalf("failed to open %s stream: %v", encoding, err)
+			}
+			plain, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("failed to decompress: %v", err)
+			}
+			if !strings.Contains(string(plain), `"query_logs"`) {
+				t.Errorf("unexpected body: %s", plain)
+			}
+		})
+	}
+
+	rec := postMCP(t, handler, body, nil)
+	if rec.Header().Get("Content-Encoding") != "" || rec.Code != http.StatusOK {
+		t.Errorf("expected an uncompressed response without Accept-Encoding")
+	}
+}
+
+func TestCompressedErrorResponse(t *testing.T) {
+	handler := (&MCPServer{}).httpHandler()
+	req := httptest.NewRequest(http.MethodGet, "/mcp", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rec.Code)
+	}
+	r, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("expected a gzip body: %v", err)
+	}
+	plain, _ := io.ReadAll(r)
+	if !strings.Contains(string(plain), "method not allowed") {
+		t.Errorf("unexpected body: %q", plain)
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// endpointFamily groups the API paths that share a Datadog rate-limit
+// bucket.
+type endpointFamily struct {
+	name     string
+	prefixes []string
+}
+
+var endpointFamilies = []endpointFamily{
+	{"logs", []string{"/api/v2/logs/events", "/api/v2/logs/analytics", "/api/v1/logs-queries"}},
+	{"metrics", []string{"/api/v1/query", "/api/v2/query/", "/api/v1/metrics", "/api/v2/metrics"}},
+	{"spans", []string{"/api/v2/spans/"}},
+	{"events", []string{"/api/v1/events", "/api/v2/events"}},
+	{"monitors", []string{"/api/v1/monitor"}},
+	{"incidents", []string{"/api/v2/incidents"}},
+	{"slos", []string{"/api/v1/slo"}},
+	{"dashboards", []string{"/api/v1/dashboard"}},
+	{"usage", []string{"/api/v1/usage", "/api/v2/usage"}},
+}
+
+// endpointFamilyOf returns the family of an API path, or "" for paths that
+// belong to none.
+func endpointFamilyOf(path string) string {
+	for _, family := range endpointFamilies {
+		for _, prefix := range family.prefixes {
+			if strings.HasPrefix(path, prefix) {
+				return family.name
+			}
+		}
+	}
+	return ""
+}
+
+// parseConcurrencyLimits reads "family=n" pairs, such as "logs=2,spans=4".
+func parseConcurrencyLimits(value string) (map[string]int, error) {
+	limits := make(map[string]int)
+	for _, item := range splitList(value) {
+		name, count, ok := strings.Cut(item, "=")
+		name = strings.TrimSpace(name)
+		if !ok {
+			return nil, fmt.Errorf("invalid concurrency limit %q: use family=n", item)
+		}
+		if !slices.Contains(endpointFamilyNames(), name) {
+			return nil, fmt.Errorf("unknown endpoint family %q (use %s)", name, strings.Join(endpointFamilyNames(), ", "))
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(count))
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid concurrency limit for %s: %q must be a positive integer", name, count)
+		}
+		limits[name] = n
+	}
+	return limits, nil
+}
+
+func endpointFamilyNames() []string {
+	names := make([]string, 0, len(endpointFamilies))
+	for _, family := range endpointFamilies {
+		names = append(names, family.name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// concurrencyTransport caps the requests in flight to each endpoint
+// family. A request holds its slot until its response body is closed, and
+// gives up waiting when its context is done.
+type concurrencyTransport struct {
+	base  http.RoundTripper
+	slots map[string]chan struct{}
+}
+
+func newConcurrencyTransport(base http.RoundTripper, limits map[string]int) *concurrencyTransport {
+	slots := make(map[string]chan struct{}, len(limits))
+	for name, n := range limits {
+		slots[name] = make(chan struct{}, n)
+	}
+	return &concurrencyTransport{base: base, slots: slots}
+}
+
+func (t *concurrencyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	slot, ok := t.slots[endpointFamilyOf(req.URL.Path)]
+	if !ok {
+		return t.base.RoundTrip(req)
+	}
+	select {
+	case slot <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+
+	release := sync.OnceFunc(func() { <-slot })
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &releaseOnClose{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// releaseOnClose frees a concurrency slot once the response body has been
+// read.
+type releaseOnClose struct {
+	io.ReadCloser
+	release func()
+}
+
+func (r *releaseOnClose) Close() error {
+	err := r.ReadCloser.Close()
+	r.release()
+	return err
+}
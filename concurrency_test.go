@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseConcurrencyLimits(t *testing.T) {
+	limits, err := parseConcurrencyLimits(" logs=2, spans = 4 ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(limits) != 2 || limits["logs"] != 2 || limits["spans"] != 4 {
+		t.Errorf("unexpected limits: %v", limits)
+	}
+	if limits, err := parseConcurrencyLimits(""); err != nil || len(limits) != 0 {
+		t.Errorf("expected no limits when unset, got %v, %v", limits, err)
+	}
+	for _, value := range []string{"logs", "traces=2", "logs=0", "logs=x"} {
+		if _, err := parseConcurrencyLimits(value); err == nil {
+			t.Errorf("expected %q to be rejected", value)
+		}
+	}
+}
+
+func TestEndpointFamilyOf(t *testing.T) {
+	tests := map[string]string{
+		"/api/v2/logs/events/search":       "logs",
+		"/api/v2/logs/analytics/aggregate": "logs",
+		"/api/v1/query":                    "metrics",
+		"/api/v2/query/timeseries":         "metrics",
+		"/api/v2/spans/events/search":      "spans",
+		"/api/v1/monitor/12":               "monitors",
+		"/api/v1/usage/summary":            "usage",
+		"/api/v2/services/definitions":     "",
+	}
+	for path, want := range tests {
+		if got := endpointFamilyOf(path); got != want {
+			t.Errorf("%s: expected %q, got %q", path, want, got)
+		}
+	}
+}
+
+func TestConcurrencyTransportCapsFamily(t *testing.T) {
+	var inFlight, peak, other int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/monitor" {
+			atomic.AddInt32(&other, 1)
+			return
+		}
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(30 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+	}))
+	t.Cleanup(ts.Close)
+	client := &http.Client{Transport: newConcurrencyTransport(http.DefaultTransport, map[string]int{"logs": 2})}
+
+	var wg sync.WaitGroup
+	for range 6 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Post(ts.URL+"/api/v2/logs/events/search", "application/json", nil)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}()
+	}
+	resp, err := client.Get(ts.URL + "/api/v1/monitor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&peak); got != 2 {
+		t.Errorf("expected at most 2 log searches in flight, peaked at %d", got)
+	}
+	if atomic.LoadInt32(&other) != 1 {
+		t.Error("expected other families to pass through")
+	}
+}
+
+func TestConcurrencyTransportWaitHonorsContext(t *testing.T) {
+	transport := newConcurrencyTransport(http.DefaultTransport, map[string]int{"logs": 1})
+	transport.slots["logs"] <- struct{}{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "http://127.0.0.1:1/api/v2/logs/events/search", nil)
+	if _, err := transport.RoundTrip(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the wait to end with the request's deadline, got %v", err)
+	}
+}
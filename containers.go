@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV2"
+)
+
+const (
+	defaultContainerLimit = 100
+	maxContainerLimit     = 1000
+)
+
+type ListContainersParams struct {
+	// Tags filter containers, such as "service:checkout" or "host:web-1".
+	Tags []string `json:"tags,omitempty"`
+	// GroupBy counts containers per tag key instead of listing them, such
+	// as ["kube_deployment"] or ["host", "container_state"].
+	GroupBy []string `json:"group_by,omitempty"`
+	// Sort is an attribute to sort by, such as "started_at" or "-name".
+	Sort  string `json:"sort,omitempty"`
+	Limit int32  `json:"limit,omitempty"`
+	// Cursor continues from a previous call's NextCursor.
+	Cursor string `json:"cursor,omitempty"`
+}
+
+type ContainerEntry struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Host      string   `json:"host,omitempty"`
+	State     string   `json:"state,omitempty"`
+	Image     string   `json:"image,omitempty"`
+	ImageTags []string `json:"image_tags,omitempty"`
+	Started   string   `json:"started,omitempty"`
+	Created   string   `json:"created,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+}
+
+// ContainerGroupEntry counts the containers sharing a group_by value.
+type ContainerGroupEntry struct {
+	Tags  map[string]string `json:"tags"`
+	Count int64             `json:"count"`
+}
+
+type ListContainersResult struct {
+	Containers []ContainerEntry      `json:"containers,omitempty"`
+	Groups     []ContainerGroupEntry `json:"groups,omitempty"`
+	Count      int                   `json:"count"`
+	// Total is the number of containers matching, across all pages.
+	Total int64 `json:"total,omitempty"`
+	// ByState counts the returned containers in each state.
+	ByState    map[string]int `json:"by_state,omitempty"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+	URL        string         `json:"url"`
+	Notes      []string       `json:"notes,omitempty"`
+}
+
+// ListContainers lists the containers the Agent reports, or counts them
+// per group, to see what runs where and in which state.
+func (s *MCPServer) ListContainers(params ListContainersParams) (*ListContainersResult, error) {
+	limit := params.Limit
+	if limit <= 0 {
+		limit = defaultContainerLimit
+	}
+	limit = clampArgument(s, "limit", limit, maxContainerLimit)
+	opts := datadogV2.NewListContainersOptionalParameters().WithPageSize(limit)
+	if len(params.Tags) > 0 {
+		opts = opts.WithFilterTags(strings.Join(params.Tags, ","))
+	}
+	if len(params.GroupBy) > 0 {
+		opts = opts.WithGroupBy(strings.Join(params.GroupBy, ","))
+	}
+	if sortBy := strings.TrimSpace(params.Sort); sortBy != "" {
+		opts = opts.WithSort(sortBy)
+	}
+	if params.Cursor != "" {
+		opts = opts.WithPageCursor(params.Cursor)
+	}
+
+	resp, _, err := datadogV2.NewContainersApi(s.ddClient).ListContainers(s.ctx, *opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	query := url.Values{}
+	if len(params.Tags) > 0 {
+		query.Set("query", strings.Join(params.Tags, " "))
+	}
+	result := &ListContainersResult{URL: s.appURL("/containers?" + query.Encode())}
+	for _, item := range resp.Data {
+		switch {
+		case item.Container != nil && item.Container.Attributes != nil:
+			attrs := item.Container.Attributes
+			tags := append([]string(nil), attrs.Tags...)
+			sort.Strings(tags)
+			result.Containers = append(result.Containers, ContainerEntry{
+				ID:        item.Container.GetId(),
+				Name:      attrs.GetName(),
+				Host:      attrs.GetHost(),
+				State:     attrs.GetState(),
+				Image:     attrs.GetImageName(),
+				ImageTags: attrs.GetImageTags(),
+				Started:   attrs.GetStartedAt(),
+				Created:   attrs.GetCreatedAt(),
+				Tags:      tags,
+			})
+			if state := attrs.GetState(); state != "" {
+				if result.ByState == nil {
+					result.ByState = make(map[string]int)
+				}
+				result.ByState[state]++
+			}
+		case item.ContainerGroup != nil && item.ContainerGroup.Attributes != nil:
+			attrs := item.ContainerGroup.Attributes
+			result.Groups = append(result.Groups, ContainerGroupEntry{Tags: containerGroupTags(attrs.Tags), Count: attrs.GetCount()})
+		}
+	}
+	sort.SliceStable(result.Groups, func(i, j int) bool { return result.Groups[i].Count > result.Groups[j].Count })
+	result.Count = len(result.Containers) + len(result.Groups)
+	if meta := resp.Meta; meta != nil && meta.Pagination != nil {
+		page := meta.Pagination
+		result.Total = page.GetTotal()
+		if page.GetNextCursor() != "" && int32(result.Count) >= limit {
+			result.NextCursor = page.GetNextCursor()
+			result.Notes = append(result.Notes, "More containers match; pass next_cursor as cursor for the next page.")
+		}
+	}
+	if result.Count == 0 {
+		result.Notes = append(result.Notes, "No containers match. Containers appear once the Agent runs with container collection enabled on their hosts.")
+	}
+	return result, nil
+}
+
+// containerGroupTags flattens a group's tag values, which the API
+// returns as a loosely typed object.
+func containerGroupTags(raw interface{}) map[string]string {
+	tags := make(map[string]string)
+	values, ok := raw.(map[string]interface{})
+	if !ok {
+		return tags
+	}
+	for key, value := range values {
+		tags[key] = fmt.Sprint(value)
+	}
+	return tags
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestListContainers(t *testing.T) {
+	var query url.Values
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/api/v2/containers" {
+			http.NotFound(w, r)
+			return
+		}
+		query = r.URL.Query()
+		if query.Get("group_by") != "" {
+			_, _ = w.Write([]byte(`{"data":[
+				{"id":"g1","type":"container_group","attributes":{"count":2,"tags":{"host":"web-1"}}},
+				{"id":"g2","type":"container_group","attributes":{"count":5,"tags":{"host":"web-2"}}}],
+				"meta":{"pagination":{"total":2}}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[
+			{"id":"c1","type":"container","attributes":{"container_id":"c1","name":"checkout","host":"web-1","state":"running","image_name":"checkout","image_tags":["v42"],"started_at":"2026-01-20T08:00:00Z","tags":["service:checkout","env:prod"]}},
+			{"id":"c2","type":"container","attributes":{"container_id":"c2","name":"checkout-old","host":"web-1","state":"exited","image_name":"checkout","image_tags":["v41"]}}],
+			"meta":{"pagination":{"next_cursor":"cursor-2","total":7,"limit":2}}}`))
+	})
+
+	result, err := server.ListContainers(ListContainersParams{Tags: []string{"service:checkout", "env:prod"}, Sort: "-started_at", Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query.Get("filter[tags]") != "service:checkout,env:prod" || query.Get("sort") != "-started_at" || query.Get("page[size]") != "2" {
+		t.Fatalf("unexpected query %v", query)
+	}
+	if result.Count != 2 || result.Total != 7 || result.NextCursor != "cursor-2" || result.ByState["running"] != 1 || result.ByState["exited"] != 1 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	first := result.Containers[0]
+	if first.ID != "c1" || first.Host != "web-1" || first.Image != "checkout" || len(first.ImageTags) != 1 || first.Tags[0] != "env:prod" {
+		t.Errorf("unexpected container %+v", first)
+	}
+
+	result, err = server.ListContainers(ListContainersParams{GroupBy: []string{"host"}, Cursor: "cursor-2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query.Get("group_by") != "host" || query.Get("page[cursor]") != "cursor-2" || query.Get("page[size]") != "100" {
+		t.Fatalf("expected the grouping, cursor and default size, got %v", query)
+	}
+	if len(result.Containers) != 0 || len(result.Groups) != 2 || result.Groups[0].Tags["host"] != "web-2" || result.Groups[0].Count != 5 {
+		t.Fatalf("expected groups largest first, got %+v", result)
+	}
+}
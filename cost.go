@@ -0,0 +1,208 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV2"
+)
+
+// maxCostHistoryMonths bounds how far back get_estimated_cost looks.
+const maxCostHistoryMonths = 12
+
+type EstimatedCostParams struct {
+	// Month is "2006-01"; it defaults to the current month.
+	Month string `json:"month,omitempty"`
+	// Months is how many months before Month to include for comparison.
+	Months int `json:"months,omitempty"`
+}
+
+type ProductCost struct {
+	Product string  `json:"product"`
+	Cost    float64 `json:"cost"`
+	// Share is the product's part of the month's total, in percent.
+	Share float64 `json:"share_percent"`
+}
+
+// MonthCost is one month's cost by product.
+type MonthCost struct {
+	Month string `json:"month"`
+	// Status is "final" for billed months and "estimated" while the month
+	// is running or not yet billed.
+	Status string  `json:"status"`
+	Total  float64 `json:"total"`
+	// ChangePercent compares the total with the month before, when that
+	// month is included.
+	ChangePercent *float64      `json:"change_percent,omitempty"`
+	Products      []ProductCost `json:"products"`
+}
+
+type EstimatedCostResult struct {
+	Month string `json:"month"`
+	// Months are oldest first and end with Month.
+	Months []MonthCost `json:"months"`
+	Notes  []string    `json:"notes,omitempty"`
+}
+
+// GetEstimatedCost reports the org's cost by product for a month and the
+// months before it, taking billed months from the historical cost API and
+// the rest from the estimated cost API.
+func (s *MCPServer) GetEstimatedCost(params EstimatedCostParams) (*EstimatedCostResult, error) {
+	now := time.Now().UTC()
+	current := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	month := current
+	if params.Month != "" {
+		parsed, err := time.Parse("2006-01", params.Month)
+		if err != nil {
+			return nil, fmt.Errorf("invalid month: %s (use YYYY-MM)", params.Month)
+		}
+		if parsed.After(current) {
+			return nil, fmt.Errorf("month %s is in the future", params.Month)
+		}
+		month = parsed
+	}
+	months := 2
+	if params.Months > 0 {
+		months = clampArgument(s, "months", params.Months, maxCostHistoryMonths)
+	}
+	first := month.AddDate(0, -months, 0)
+	end := month.AddDate(0, 1, 0)
+
+	api := datadogV2.NewUsageMeteringApi(s.ddClient)
+	costs := make(map[string]*MonthCost)
+	result := &EstimatedCostResult{Month: month.Format("2006-01"), Months: []MonthCost{}}
+
+	// Only months before the current one can have been billed.
+	if first.Before(current) {
+		resp, _, err := api.GetHistoricalCostByOrg(s.ctx, first, *datadogV2.NewGetHistoricalCostByOrgOptionalParameters().
+			WithView("summary").WithEndMonth(earlier(end, current)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get historical cost: %w", err)
+		}
+		addMonthCosts(costs, resp.Data, "final", first, end)
+	}
+	// Estimates cover the current month and the previous one until it is
+	// billed.
+	if estimatedFrom := current.AddDate(0, -1, 0); !month.Before(estimatedFrom) {
+		resp, _, err := api.GetEstimatedCostByOrg(s.ctx, *datadogV2.NewGetEstimatedCostByOrgOptionalParameters().
+			WithView("summary").WithStartMonth(later(first, estimatedFrom)).WithEndMonth(end))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get estimated cost: %w", err)
+		}
+		addMonthCosts(costs, resp.Data, "estimated", first, end)
+	}
+	if costs[result.Month] == nil {
+		return nil, fmt.Errorf("no cost reported for %s", result.Month)
+	}
+
+	for m := first; m.Before(end); m = m.AddDate(0, 1, 0) {
+		cost := costs[m.Format("2006-01")]
+		if cost == nil {
+			continue
+		}
+		if n := len(result.Months); n > 0 && result.Months[n-1].Month == m.AddDate(0, -1, 0).Format("2006-01") && result.Months[n-1].Total > 0 {
+			previous := result.Months[n-1].Total
+			change := math.Round((cost.Total-previous)/previous*1000) / 10
+			cost.ChangePercent = &change
+		}
+		result.Months = append(result.Months, *cost)
+	}
+
+	if result.Months[len(result.Months)-1].Status == "estimated" {
+		note := "Estimated costs can change until the month is billed"
+		if month.Equal(current) {
+			note += "; the current month's estimate only covers usage so far"
+		}
+		result.Notes = append(result.Notes, note+".")
+	}
+	return result, nil
+}
+
+// addMonthCosts adds the cost of each month in [first, end) to costs,
+// keeping a month already added, so final costs win over estimates.
+func addMonthCosts(costs map[string]*MonthCost, data []datadogV2.CostByOrg, status string, first, end time.Time) {
+	added := make(map[string]map[string]float64)
+	for _, org := range data {
+		attrs := org.Attributes
+		if attrs == nil || attrs.Date == nil {
+			continue
+		}
+		date := attrs.Date.UTC()
+		if date.Before(first) || !date.Before(end) {
+			continue
+		}
+		key := date.Format("2006-01")
+		if costs[key] != nil && added[key] == nil {
+			continue
+		}
+		if added[key] == nil {
+			added[key] = make(map[string]float64)
+		}
+		for product, cost := range productCosts(attrs.Charges) {
+			added[key][product] += cost
+		}
+	}
+	for key, products := range added {
+		month := &MonthCost{Month: key, Status: status, Products: make([]ProductCost, 0, len(products))}
+		for product, cost := range products {
+			month.Total += cost
+			month.Products = append(month.Products, ProductCost{Product: product, Cost: roundCents(cost)})
+		}
+		for i := range month.Products {
+			if month.Total > 0 {
+				month.Products[i].Share = math.Round(month.Products[i].Cost/month.Total*1000) / 10
+			}
+		}
+		month.Total = roundCents(month.Total)
+		slices.SortFunc(month.Products, func(a, b ProductCost) int {
+			return cmp.Or(cmp.Compare(b.Cost, a.Cost), cmp.Compare(a.Product, b.Product))
+		})
+		costs[key] = month
+	}
+}
+
+// productCosts sums the charges of each product, taking a product's
+// "total" charge when there is one and adding its other charge types,
+// such as committed and on-demand, when there isn't.
+func productCosts(charges []datadogV2.ChargebackBreakdown) map[string]float64 {
+	totals := make(map[string]float64)
+	parts := make(map[string]float64)
+	for _, charge := range charges {
+		product := charge.GetProductName()
+		if product == "" {
+			continue
+		}
+		if charge.GetChargeType() == "total" {
+			totals[product] += charge.GetCost()
+		} else {
+			parts[product] += charge.GetCost()
+		}
+	}
+	for product, cost := range parts {
+		if _, ok := totals[product]; !ok {
+			totals[product] = cost
+		}
+	}
+	return totals
+}
+
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func earlier(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetEstimatedCost(t *testing.T) {
+	now := time.Now().UTC()
+	current := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	month := func(offset int) string { return current.AddDate(0, offset, 0).Format(time.RFC3339) }
+	var paths []string
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		paths = append(paths, r.URL.Path)
+		if r.URL.Query().Get("view") != "summary" {
+			t.Errorf("expected the summary view, got %q", r.URL.RawQuery)
+		}
+		switch r.URL.Path {
+		case "/api/v2/usage/historical_cost":
+			// The previous month isn't billed yet.
+			_, _ = w.Write([]byte(`{"data":[{"attributes":{"date":"` + month(-2) + `","total_cost":150,"charges":[
+				{"product_name":"logs","charge_type":"total","cost":100},
+				{"product_name":"logs","charge_type":"on_demand","cost":40},
+				{"product_name":"infra_host","charge_type":"committed","cost":30},
+				{"product_name":"infra_host","charge_type":"on_demand","cost":20}]}}]}`))
+		case "/api/v2/usage/estimated_cost":
+			_, _ = w.Write([]byte(`{"data":[
+				{"attributes":{"date":"` + month(-1) + `","charges":[{"product_name":"logs","charge_type":"total","cost":200},{"product_name":"infra_host","charge_type":"total","cost":50.004}]}},
+				{"attributes":{"date":"` + month(0) + `","charges":[{"product_name":"logs","charge_type":"total","cost":80}]}}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	result, err := server.GetEstimatedCost(EstimatedCostParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2 || len(result.Months) != 3 || result.Month != current.Format("2006-01") {
+		t.Fatalf("unexpected result %+v after %v", result, paths)
+	}
+	billed, previous := result.Months[0], result.Months[1]
+	if billed.Status != "final" || billed.Total != 150 || billed.Products[0].Product != "logs" || billed.Products[0].Cost != 100 || billed.Products[1].Share != 33.3 {
+		t.Fatalf("unexpected billed month %+v", billed)
+	}
+	if previous.Status != "estimated" || previous.Total != 250 || previous.ChangePercent == nil || *previous.ChangePercent != 66.7 {
+		t.Fatalf("unexpected estimated month %+v", previous)
+	}
+	if len(result.Notes) != 1 || !strings.Contains(result.Notes[0], "usage so far") {
+		t.Fatalf("unexpected notes %v", result.Notes)
+	}
+
+	paths = nil
+	if _, err := server.GetEstimatedCost(EstimatedCostParams{Month: current.AddDate(0, -6, 0).Format("2006-01"), Months: 1}); err == nil || !strings.Contains(err.Error(), "no cost reported") {
+		t.Fatalf("expected a month without cost to be reported, got %v", err)
+	}
+	if len(paths) != 1 || paths[0] != "/api/v2/usage/historical_cost" {
+		t.Fatalf("expected only billed cost for an old month, got %v", paths)
+	}
+	if _, err := server.GetEstimatedCost(EstimatedCostParams{Month: "next"}); err == nil {
+		t.Fatal("expected an invalid month to be rejected")
+	}
+}
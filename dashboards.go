@@ -0,0 +1,347 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+)
+
+const (
+	defaultDashboardsPerPage = 30
+	maxDashboardsPerPage     = 100
+	// maxDashboardTagLookups bounds how many dashboards are fetched one by
+	// one for a tag filter, since dashboard summaries don't carry tags.
+	maxDashboardTagLookups = 200
+)
+
+type ListDashboardsParams struct {
+	Title   string   `json:"title,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+	Page    int64    `json:"page,omitempty"`
+	PerPage int64    `json:"per_page,omitempty"`
+}
+
+type DashboardSummary struct {
+	ID         string   `json:"id"`
+	Title      string   `json:"title"`
+	Author     string   `json:"author,omitempty"`
+	LayoutType string   `json:"layout_type,omitempty"`
+	ModifiedAt string   `json:"modified_at,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+	URL        string   `json:"url"`
+}
+
+type ListDashboardsResult struct {
+	Dashboards []DashboardSummary `json:"dashboards"`
+	Total      int64              `json:"total"`
+	Page       int64              `json:"page"`
+	PageCount  int64              `json:"page_count"`
+	PerPage    int64              `json:"per_page"`
+	Notes      []string           `json:"notes,omitempty"`
+}
+
+// ListDashboards lists dashboards sorted by title, filtered by title text
+// and tags, a page at a time. The dashboards API filters on neither, so
+// every dashboard is listed and the filters are applied here.
+func (s *MCPServer) ListDashboards(params ListDashboardsParams) (*ListDashboardsResult, error) {
+	perPage := params.PerPage
+	if perPage <= 0 {
+		perPage = defaultDashboardsPerPage
+	}
+	perPage = clampArgument(s, "per_page", perPage, maxDashboardsPerPage)
+	if params.Page < 0 {
+		return nil, fmt.Errorf("page must not be negative")
+	}
+	var tags []string
+	for _, tag := range params.Tags {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	all, truncated, err := s.listAllDashboards()
+	if err != nil {
+		return nil, err
+	}
+	result := &ListDashboardsResult{Dashboards: make([]DashboardSummary, 0), Page: params.Page, PerPage: perPage}
+	if truncated {
+		result.Notes = append(result.Notes, fmt.Sprintf("Only the first %d dashboards were searched.", len(all)))
+	}
+
+	title := strings.ToLower(strings.TrimSpace(params.Title))
+	var matches []DashboardSummary
+	for _, d := range all {
+		if title != "" && !strings.Contains(strings.ToLower(d.GetTitle()), title) {
+			continue
+		}
+		summary := DashboardSummary{
+			ID:         d.GetId(),
+			Title:      d.GetTitle(),
+			Author:     d.GetAuthorHandle(),
+			LayoutType: string(d.GetLayoutType()),
+			ModifiedAt: formatOptionalTime(d.ModifiedAt),
+			URL:        s.appURL(d.GetUrl()),
+		}
+		matches = append(matches, summary)
+	}
+
+	if len(tags) > 0 {
+		if matches, err = s.filterDashboardTags(matches, tags, result); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return strings.ToLower(matches[i].Title) < strings.ToLower(matches[j].Title)
+	})
+	result.Total = int64(len(matches))
+	result.PageCount = (result.Total + perPage - 1) / perPage
+	if start := params.Page * perPage; start < result.Total {
+		result.Dashboards = matches[start:min(start+perPage, result.Total)]
+	}
+	if params.Page+1 < result.PageCount {
+		result.Notes = append(result.Notes, fmt.Sprintf("More dashboards match; pass page=%d for the next page.", params.Page+1))
+	}
+	return result, nil
+}
+
+// filterDashboardTags keeps the dashboards carrying every tag, fetching
+// each one since summaries don't include tags.
+func (s *MCPServer) filterDashboardTags(dashboards []DashboardSummary, tags []string, result *ListDashboardsResult) ([]DashboardSummary, error) {
+	if len(dashboards) > maxDashboardTagLookups {
+		result.Notes = append(result.Notes, fmt.Sprintf("Tags were only checked on the first %d of %d dashboards; pass title to narrow the search.", maxDashboardTagLookups, len(dashboards)))
+		dashboards = dashboards[:maxDashboardTagLookups]
+	}
+	api := datadogV1.NewDashboardsApi(s.ddClient)
+	var kept []DashboardSummary
+	for _, d := range dashboards {
+		dashboard, _, err := api.GetDashboard(s.ctx, d.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get dashboard %s: %w", d.ID, err)
+		}
+		d.Tags = dashboard.GetTags()
+		have := make(map[string]bool, len(d.Tags))
+		for _, tag := range d.Tags {
+			have[strings.ToLower(tag)] = true
+		}
+		matched := true
+		for _, tag := range tags {
+			if !have[tag] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			kept = append(kept, d)
+		}
+	}
+	return kept, nil
+}
+
+type GetDashboardParams struct {
+	DashboardID string `json:"dashboard_id"`
+}
+
+// DashboardVariable is a template variable; queries refer to it as $name.
+type DashboardVariable struct {
+	Name     string   `json:"name"`
+	Prefix   string   `json:"prefix,omitempty"`
+	Defaults []string `json:"defaults,omitempty"`
+}
+
+// WidgetQuery is one query behind a widget. DataSource is "metrics" for
+// metric queries and "logs" for log searches; other products keep
+// Datadog's name for them.
+type WidgetQuery struct {
+	Name        string `json:"name,omitempty"`
+	DataSource  string `json:"data_source"`
+	Query       string `json:"query"`
+	Aggregation string `json:"aggregation,omitempty"`
+}
+
+// DashboardWidget is a widget reduced to what it queries. Group widgets
+// hold their children in Widgets.
+type DashboardWidget struct {
+	ID        int64             `json:"id,omitempty"`
+	Type      string            `json:"type"`
+	Title     string            `json:"title,omitempty"`
+	Queries   []WidgetQuery     `json:"queries,omitempty"`
+	Formulas  []string          `json:"formulas,omitempty"`
+	MonitorID string            `json:"monitor_id,omitempty"`
+	Widgets   []DashboardWidget `json:"widgets,omitempty"`
+}
+
+type GetDashboardResult struct {
+	ID                string              `json:"id"`
+	Title             string              `json:"title"`
+	Description       string              `json:"description,omitempty"`
+	Author            string              `json:"author,omitempty"`
+	LayoutType        string              `json:"layout_type"`
+	Tags              []string            `json:"tags,omitempty"`
+	URL               string              `json:"url"`
+	TemplateVariables []DashboardVariable `json:"template_variables,omitempty"`
+	QueryCount        int                 `json:"query_count"`
+	Widgets           []DashboardWidget   `json:"widgets"`
+}
+
+// GetDashboard returns a dashboard's widgets reduced to their queries, so
+// they can be replayed through the metric and log tools.
+func (s *MCPServer) GetDashboard(params GetDashboardParams) (*GetDashboardResult, error) {
+	id := dashboardID(params.DashboardID)
+	if id == "" {
+		return nil, fmt.Errorf("dashboard_id parameter is required")
+	}
+
+	api := datadogV1.NewDashboardsApi(s.ddClient)
+	dashboard, httpResp, err := api.GetDashboard(s.ctx, id)
+	if err != nil {
+		if httpStatus(httpResp) == http.StatusNotFound {
+			return nil, fmt.Errorf("dashboard %s not found", id)
+		}
+		return nil, fmt.Errorf("failed to get dashboard %s: %w", id, err)
+	}
+	if _, _, err := s.snapshotResource("dashboard", id, dashboard, formatOptionalTime(dashboard.ModifiedAt)); err != nil {
+		log.Printf("Snapshotting dashboard %s failed: %v", id, err)
+	}
+
+	result := &GetDashboardResult{
+		ID:          dashboard.GetId(),
+		Title:       dashboard.GetTitle(),
+		Description: dashboard.GetDescription(),
+		Author:      dashboard.GetAuthorHandle(),
+		LayoutType:  string(dashboard.GetLayoutType()),
+		Tags:        dashboard.GetTags(),
+		URL:         s.appURL(dashboard.GetUrl()),
+		Widgets:     make([]DashboardWidget, 0, len(dashboard.Widgets)),
+	}
+	for _, v := range dashboard.TemplateVariables {
+		variable := DashboardVariable{Name: v.Name, Prefix: v.GetPrefix(), Defaults: v.Defaults}
+		if len(variable.Defaults) == 0 && v.GetDefault() != "" {
+			variable.Defaults = []string{v.GetDefault()}
+		}
+		result.TemplateVariables = append(result.TemplateVariables, variable)
+	}
+	for _, w := range dashboard.Widgets {
+		widget, err := condenseWidget(w.GetId(), w.Definition)
+		if err != nil {
+			return nil, err
+		}
+		result.QueryCount += countWidgetQueries(widget)
+		result.Widgets = append(result.Widgets, widget)
+	}
+	return result, nil
+}
+
+// dashboardID accepts a dashboard link as well as its ID.
+func dashboardID(value string) string {
+	id := strings.TrimSpace(value)
+	if _, rest, ok := strings.Cut(id, "/dashboard/"); ok {
+		id, _, _ = strings.Cut(rest, "/")
+	}
+	return id
+}
+
+// condenseWidget reduces a widget definition to its queries. The
+// definition is one of dozens of widget types, so it is walked as JSON
+// rather than type by type: metric queries are "q" fields, formula
+// queries carry a "data_source", and older widgets nest searches under
+// keys such as "log_query".
+func condenseWidget(id int64, definition datadogV1.WidgetDefinition) (DashboardWidget, error) {
+	raw, err := json.Marshal(definition)
+	if err != nil {
+		return DashboardWidget{}, fmt.Errorf("failed to read widget %d: %w", id, err)
+	}
+	var def map[string]interface{}
+	if err := json.Unmarshal(raw, &def); err != nil {
+		return DashboardWidget{}, fmt.Errorf("failed to read widget %d: %w", id, err)
+	}
+	return condenseDefinition(id, def), nil
+}
+
+func condenseDefinition(id int64, def map[string]interface{}) DashboardWidget {
+	widget := DashboardWidget{ID: id}
+	widget.Type, _ = def["type"].(string)
+	widget.Title, _ = def["title"].(string)
+	widget.MonitorID, _ = def["alert_id"].(string)
+	if query, ok := def["query"].(string); ok && widget.Type == "log_stream" {
+		widget.Queries = append(widget.Queries, WidgetQuery{DataSource: "logs", Query: query})
+	}
+	collectWidgetQueries(def["requests"], &widget)
+
+	children, _ := def["widgets"].([]interface{})
+	for _, child := range children {
+		w, _ := child.(map[string]interface{})
+		childID, _ := w["id"].(float64)
+		childDef, _ := w["definition"].(map[string]interface{})
+		widget.Widgets = append(widget.Widgets, condenseDefinition(int64(childID), childDef))
+	}
+	return widget
+}
+
+func collectWidgetQueries(value interface{}, widget *DashboardWidget) {
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			collectWidgetQueries(item, widget)
+		}
+	case map[string]interface{}:
+		if q, ok := v["q"].(string); ok {
+			widget.Queries = append(widget.Queries, WidgetQuery{DataSource: "metrics", Query: q})
+		}
+		if source, ok := v["data_source"].(string); ok {
+			widget.Queries = append(widget.Queries, searchQuery(v, source))
+			return
+		}
+		if formula, ok := v["formula"].(string); ok {
+			widget.Formulas = append(widget.Formulas, formula)
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if source, ok := strings.CutSuffix(key, "_query"); ok {
+				if search, ok := v[key].(map[string]interface{}); ok {
+					widget.Queries = append(widget.Queries, searchQuery(search, source))
+					continue
+				}
+			}
+			collectWidgetQueries(v[key], widget)
+		}
+	}
+}
+
+// searchQuery reads a formula query or a legacy product query such as
+// log_query: {search: {query}, compute: {aggregation}}.
+func searchQuery(v map[string]interface{}, source string) WidgetQuery {
+	if source == "log" {
+		source = "logs"
+	}
+	q := WidgetQuery{DataSource: source}
+	q.Name, _ = v["name"].(string)
+	if query, ok := v["query"].(string); ok {
+		q.Query = query
+	} else if search, ok := v["search"].(map[string]interface{}); ok {
+		q.Query, _ = search["query"].(string)
+	}
+	if compute, ok := v["compute"].(map[string]interface{}); ok {
+		q.Aggregation, _ = compute["aggregation"].(string)
+	} else if aggregator, ok := v["aggregator"].(string); ok {
+		q.Aggregation = aggregator
+	}
+	return q
+}
+
+func countWidgetQueries(widget DashboardWidget) int {
+	n := len(widget.Queries)
+	for _, child := range widget.Widgets {
+		n += countWidgetQueries(child)
+	}
+	return n
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestListDashboards(t *testing.T) {
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/dashboard":
+			_, _ = w.Write([]byte(`{"dashboards":[
+				{"id":"ccc-333","title":"Checkout Latency","author_handle":"ana@example.com","url":"/dashboard/ccc-333/checkout-latency","layout_type":"ordered"},
+				{"id":"aaa-111","title":"API Overview","author_handle":"bo@example.com","url":"/dashboard/aaa-111/api-overview","layout_type":"free"},
+				{"id":"bbb-222","title":"Checkout Errors","author_handle":"bo@example.com","url":"/dashboard/bbb-222/checkout-errors","layout_type":"ordered"}]}`))
+		case "/api/v1/dashboard/ccc-333":
+			_, _ = w.Write([]byte(`{"id":"ccc-333","title":"Checkout Latency","layout_type":"ordered","widgets":[],"tags":["team:payments"]}`))
+		case "/api/v1/dashboard/bbb-222":
+			_, _ = w.Write([]byte(`{"id":"bbb-222","title":"Checkout Errors","layout_type":"ordered","widgets":[],"tags":["team:web"]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	result, err := server.ListDashboards(ListDashboardsParams{Title: "checkout", PerPage: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 2 || result.PageCount != 2 || len(result.Dashboards) != 1 || result.Dashboards[0].ID != "bbb-222" {
+		t.Fatalf("expected the first of two checkout dashboards by title, got %+v", result)
+	}
+	if d := result.Dashboards[0]; d.Author != "bo@example.com" || d.URL != "https://app.datadoghq.com/dashboard/bbb-222/checkout-errors" {
+		t.Fatalf("unexpected summary: %+v", d)
+	}
+	if len(result.Notes) != 1 {
+		t.Fatalf("expected a next-page note, got %v", result.Notes)
+	}
+
+	result, err = server.ListDashboards(ListDashboardsParams{Title: "checkout", Tags: []string{"Team:Payments"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 1 || result.Dashboards[0].ID != "ccc-333" || len(result.Dashboards[0].Tags) != 1 {
+		t.Fatalf("expected only the payments dashboard, got %+v", result)
+	}
+
+	result, err = server.ListDashboards(ListDashboardsParams{Page: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 3 || len(result.Dashboards) != 0 {
+		t.Fatalf("expected an empty page past the end, got %+v", result)
+	}
+}
+
+func TestGetDashboard(t *testing.T) {
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/api/v1/dashboard/abc-123" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"abc-123","title":"Checkout","layout_type":"ordered","url":"/dashboard/abc-123/checkout",
+			"template_variables":[{"name":"env","prefix":"env","defaults":["prod"]}],
+			"widgets":[
+				{"id":1,"definition":{"type":"timeseries","title":"Latency","requests":[{"q":"avg:trace.http.request.duration{$env,service:checkout}","display_type":"line"}]}},
+				{"id":2,"definition":{"type":"group","layout_type":"ordered","title":"Errors","widgets":[
+					{"id":3,"definition":{"type":"query_value","title":"Error rate","requests":[{
+						"queries":[{"data_source":"metrics","name":"errors","query":"sum:trace.http.request.errors{$env}.as_count()","aggregator":"sum"},
+							{"data_source":"logs","name":"logs","search":{"query":"service:checkout status:error"},"compute":{"aggregation":"count"},"indexes":["*"]}],
+						"formulas":[{"formula":"errors + logs"}],"response_format":"scalar"}]}},
+					{"id":4,"definition":{"type":"log_stream","query":"service:checkout","indexes":[]}},
+					{"id":5,"definition":{"type":"toplist","requests":[{"log_query":{"index":"*","search":{"query":"@http.status_code:500"},"compute":{"aggregation":"count"}}}]}}]}},
+				{"id":6,"definition":{"type":"alert_graph","alert_id":"42","viz_type":"timeseries"}}]}`))
+	})
+
+	result, err := server.GetDashboard(GetDashboardParams{DashboardID: "https://app.datadoghq.com/dashboard/abc-123/checkout?from_ts=1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ID != "abc-123" || len(result.TemplateVariables) != 1 || result.TemplateVariables[0].Defaults[0] != "prod" {
+		t.Fatalf("unexpected dashboard: %+v", result)
+	}
+	if len(result.Widgets) != 3 || result.QueryCount != 5 {
+		t.Fatalf("expected three top-level widgets and five queries, got %d and %d", len(result.Widgets), result.QueryCount)
+	}
+	if q := result.Widgets[0].Queries; len(q) != 1 || q[0].DataSource != "metrics" || q[0].Query != "avg:trace.http.request.duration{$env,service:checkout}" {
+		t.Fatalf("unexpected timeseries queries: %+v", q)
+	}
+	group := result.Widgets[1]
+	if group.Type != "group" || len(group.Widgets) != 3 {
+		t.Fatalf("expected the group's widgets, got %+v", group)
+	}
+	value := group.Widgets[0]
+	if len(value.Queries) != 2 || value.Queries[1].DataSource != "logs" || value.Queries[1].Query != "service:checkout status:error" || value.Queries[1].Aggregation != "count" {
+		t.Fatalf("unexpected formula queries: %+v", value.Queries)
+	}
+	if len(value.Formulas) != 1 || value.Formulas[0] != "errors + logs" {
+		t.Fatalf("unexpected formulas: %v", value.Formulas)
+	}
+	if q := group.Widgets[2].Queries; len(q) != 1 || q[0].DataSource != "logs" || q[0].Query != "@http.status_code:500" {
+		t.Fatalf("unexpected legacy log query: %+v", q)
+	}
+	if result.Widgets[2].MonitorID != "42" {
+		t.Fatalf("expected the alert widget's monitor, got %+v", result.Widgets[2])
+	}
+
+	if _, err := server.GetDashboard(GetDashboardParams{DashboardID: "missing"}); err == nil || err.Error() != "dashboard missing not found" {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const maxDependencyDepth = 5
+
+type ServiceDependenciesParams struct {
+	Service string `json:"service"`
+	Env     string `json:"env"`
+	From    string `json:"from,omitempty"`
+	To      string `json:"to,omitempty"`
+	// Depth follows calls this many hops in each direction.
+	Depth int `json:"depth,omitempty"`
+	// Diagram adds a Mermaid flowchart of the dependencies to the result.
+	Diagram bool `json:"diagram,omitempty"`
+}
+
+// ServiceDependency is a service reached from the one asked about.
+type ServiceDependency struct {
+	Service string `json:"service"`
+	// Depth is 1 for direct calls; Via is the service one hop closer.
+	Depth int    `json:"depth"`
+	Via   string `json:"via,omitempty"`
+}
+
+type ServiceDependenciesResult struct {
+	Service string `json:"service"`
+	Env     string `json:"env"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Depth   int    `json:"depth"`
+	// Downstream are the services it calls; Upstream are the services
+	// that call it, and would feel its errors.
+	Downstream []ServiceDependency `json:"downstream"`
+	Upstream   []ServiceDependency `json:"upstream"`
+	URL        string              `json:"url"`
+	Notes      []string            `json:"notes,omitempty"`
+}
+
+// GetServiceDependencies walks the APM service map around a service.
+func (s *MCPServer) GetServiceDependencies(params ServiceDependenciesParams) (*ServiceDependenciesResult, error) {
+	if params.Service == "" {
+		return nil, fmt.Errorf("service parameter is required")
+	}
+	if params.Env == "" {
+		return nil, fmt.Errorf("env parameter is required")
+	}
+	depth := params.Depth
+	if depth <= 0 {
+		depth = 1
+	}
+	if depth > maxDependencyDepth {
+		return nil, fmt.Errorf("depth must be at most %d", maxDependencyDepth)
+	}
+	from, err := s.timeParam("from", params.From, time.Now().Add(-time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.timeParam("to", params.To, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	calls, calledBy, names, err := s.serviceMap(params.Env, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ServiceDependenciesResult{
+		Service: params.Service,
+		Env:     params.Env,
+		From:    from.Format(time.RFC3339),
+		To:      to.Format(time.RFC3339),
+		Depth:   depth,
+		URL:     s.appURL("/apm/map?" + url.Values{"env": {params.Env}, "service": {params.Service}}.Encode()),
+	}
+	resolution := resolveName(params.Service, names)
+	if !resolution.found() {
+		result.Notes = append(result.Notes, resolution.note("service in the "+params.Env+" service map", params.Service))
+		result.Downstream, result.Upstream = []ServiceDependency{}, []ServiceDependency{}
+		return result, nil
+	}
+	if resolution.Corrected {
+		result.Service = resolution.Entity.Name
+		result.URL = s.appURL("/apm/map?" + url.Values{"env": {params.Env}, "service": {result.Service}}.Encode())
+		result.Notes = append(result.Notes, resolution.note("service", params.Service))
+	}
+
+	result.Downstream = walkDependencies(result.Service, calls, depth)
+	result.Upstream = walkDependencies(result.Service, calledBy, depth)
+	if len(result.Downstream) == 0 && len(result.Upstream) == 0 {
+		result.Notes = append(result.Notes, "The service made and received no traced calls in this window.")
+	}
+	return result, nil
+}
+
+// dependencyGraph renders the dependencies as Mermaid flowchart source,
+// with an arrow from each caller to the service it calls. Services beyond
+// the first hop hang off the service they were reached via.
+func dependencyGraph(result *ServiceDependenciesResult) string {
+	ids := make(map[string]string)
+	var b strings.Builder
+	b.WriteString("```mermaid\nflowchart LR\n")
+	node := func(service string) string {
+		id, ok := ids[service]
+		if !ok {
+			id = fmt.Sprintf("s%d", len(ids))
+			ids[service] = id
+			fmt.Fprintf(&b, "    %s[\"%s\"]\n", id, strings.ReplaceAll(service, `"`, "#quot;"))
+		}
+		return id
+	}
+	root := node(result.Service)
+	for _, dep := range result.Upstream {
+		caller, callee := node(dep.Service), node(cmp.Or(dep.Via, result.Service))
+		fmt.Fprintf(&b, "    %s --> %s\n", caller, callee)
+	}
+	for _, dep := range result.Downstream {
+		caller, callee := node(cmp.Or(dep.Via, result.Service)), node(dep.Service)
+		fmt.Fprintf(&b, "    %s --> %s\n", caller, callee)
+	}
+	fmt.Fprintf(&b, "    style %s stroke-width:3px\n", root)
+	b.WriteString("```\n")
+	return b.String()
+}
+
+// serviceMap reads the APM service map for env: which services each one
+// calls, which call it, and every service named in it.
+func (s *MCPServer) serviceMap(env string, from, to time.Time) (calls, calledBy map[string][]string, names []namedEntity, err error) {
+	query := url.Values{}
+	query.Set("env", env)
+	query.Set("start", strconv.FormatInt(from.Unix(), 10))
+	query.Set("end", strconv.FormatInt(to.Unix(), 10))
+	var serviceMap map[string]struct {
+		Calls []string `json:"calls"`
+	}
+	if err := s.datadogGet("/api/v1/service_dependencies", query, &serviceMap); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get service dependencies: %w", err)
+	}
+
+	calls = make(map[string][]string, len(serviceMap))
+	calledBy = make(map[string][]string)
+	names = make([]namedEntity, 0, len(serviceMap))
+	for service, deps := range serviceMap {
+		names = append(names, namedEntity{Name: service})
+		calls[service] = deps.Calls
+		for _, callee := range deps.Calls {
+			calledBy[callee] = append(calledBy[callee], service)
+			if _, ok := serviceMap[callee]; !ok {
+				names = append(names, namedEntity{Name: callee})
+			}
+		}
+	}
+	return calls, calledBy, names, nil
+}
+
+// walkDependencies follows edges breadth-first from service up to depth
+// hops, listing each service once at its shortest distance.
+func walkDependencies(service string, edges map[string][]string, depth int) []ServiceDependency {
+	seen := map[string]bool{service: true}
+	found := make([]ServiceDependency, 0)
+	frontier := []string{service}
+	for hop := 1; hop <= depth && len(frontier) > 0; hop++ {
+		var next []string
+		for _, from := range frontier {
+			for _, to := range edges[from] {
+				if seen[to] {
+					continue
+				}
+				seen[to] = true
+				dep := ServiceDependency{Service: to, Depth: hop}
+				if hop > 1 {
+					dep.Via = from
+				}
+				found = append(found, dep)
+				next = append(next, to)
+			}
+		}
+		sort.Strings(next)
+		frontier = next
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].Depth != found[j].Depth {
+			return found[i].Depth < found[j].Depth
+		}
+		return found[i].Service < found[j].Service
+	})
+	return found
+}
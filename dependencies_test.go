@@ -0,0 +1,90 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestGetServiceDependencies(t *testing.T) {
+	var query url.Values
+	var apiKey string
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/service_dependencies" {
+			http.NotFound(w, r)
+			return
+		}
+		query, apiKey = r.URL.Query(), r.Header.Get("DD-API-KEY")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"web":      {"calls": ["checkout", "search"]},
+			"mobile":   {"calls": ["checkout"]},
+			"checkout": {"calls": ["payments", "postgres"]},
+			"payments": {"calls": ["stripe-proxy"]},
+			"search":   {"calls": []}}`))
+	})
+
+	result, err := server.GetServiceDependencies(ServiceDependenciesParams{Service: "checkout", Env: "prod", Depth: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query.Get("env") != "prod" || query.Get("start") == "" || query.Get("end") == "" || apiKey != "api-key" {
+		t.Fatalf("unexpected request: %v with key %q", query, apiKey)
+	}
+	want := []ServiceDependency{{Service: "payments", Depth: 1}, {Service: "postgres", Depth: 1}, {Service: "stripe-proxy", Depth: 2, Via: "payments"}}
+	if len(result.Downstream) != len(want) {
+		t.Fatalf("unexpected downstream: %+v", result.Downstream)
+	}
+	for i := range want {
+		if result.Downstream[i] != want[i] {
+			t.Errorf("downstream %d: expected %+v, got %+v", i, want[i], result.Downstream[i])
+		}
+	}
+	if len(result.Upstream) != 2 || result.Upstream[0].Service != "mobile" || result.Upstream[1].Service != "web" {
+		t.Errorf("expected both callers upstream, got %+v", result.Upstream)
+	}
+
+	result, err = server.GetServiceDependencies(ServiceDependenciesParams{Service: "Payments", Env: "prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Service != "payments" || len(result.Notes) != 1 || len(result.Upstream) != 1 || len(result.Downstream) != 1 {
+		t.Errorf("expected the name corrected and direct neighbours only, got %+v", result)
+	}
+
+	result, err = server.GetServiceDependencies(ServiceDependenciesParams{Service: "inventory", Env: "prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Notes) != 1 || len(result.Downstream) != 0 || len(result.Upstream) != 0 {
+		t.Errorf("expected an unknown service to be noted, got %+v", result)
+	}
+
+	if _, err := server.GetServiceDependencies(ServiceDependenciesParams{Service: "checkout"}); err == nil {
+		t.Error("expected env to be required")
+	}
+}
+
+func TestDependencyGraph(t *testing.T) {
+	graph := dependencyGraph(&ServiceDependenciesResult{
+		Service:    "checkout",
+		Upstream:   []ServiceDependency{{Service: "web", Depth: 1}},
+		Downstream: []ServiceDependency{{Service: "payments", Depth: 1}, {Service: "stripe-proxy", Depth: 2, Via: "payments"}},
+	})
+	want := "```mermaid\nflowchart LR\n" +
+		"    s0[\"checkout\"]\n" +
+		"    s1[\"web\"]\n" +
+		"    s1 --> s0\n" +
+		"    s2[\"payments\"]\n" +
+		"    s0 --> s2\n" +
+		"    s3[\"stripe-proxy\"]\n" +
+		"    s2 --> s3\n" +
+		"    style s0 stroke-width:3px\n```\n"
+	if graph != want {
+		t.Fatalf("unexpected graph:\n%s", graph)
+	}
+	if !strings.Contains(dependencyGraph(&ServiceDependenciesResult{Service: `a"b`}), `s0["a#quot;b"]`) {
+		t.Error("expected quotes in service names to be escaped")
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadog"
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+)
+
+// deploymentEventTag marks events posted by record_deployment so
+// deployment-impact analysis can find them with a single tag filter.
+const deploymentEventTag = "event_type:deployment"
+
+type RecordDeploymentParams struct {
+	Service       string   `json:"service"`
+	Version       string   `json:"version"`
+	Env           string   `json:"env"`
+	Status        string   `json:"status,omitempty"`
+	CommitSHA     string   `json:"commit_sha,omitempty"`
+	RepositoryURL string   `json:"repository_url,omitempty"`
+	DeployedBy    string   `json:"deployed_by,omitempty"`
+	Description   string   `json:"description,omitempty"`
+	Links         []string `json:"links,omitempty"`
+	Tags          []string `json:"tags,omitempty"`
+}
+
+type RecordDeploymentResult struct {
+	EventID string   `json:"event_id"`
+	URL     string   `json:"url,omitempty"`
+	Title   string   `json:"title"`
+	Tags    []string `json:"tags"`
+}
+
+func (s *MCPServer) RecordDeployment(params RecordDeploymentParams) (*RecordDeploymentResult, error) {
+	if err := s.requireWrites("record_deployment"); err != nil {
+		return nil, err
+	}
+
+	body, err := buildDeploymentEvent(params, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	api := datadogV1.NewEventsApi(s.ddClient)
+	resp, _, err := api.CreateEvent(s.ctx, *body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record deployment: %w", err)
+	}
+
+	result := &RecordDeploymentResult{
+		Title: body.Title,
+		Tags:  body.Tags,
+	}
+	if resp.Event != nil {
+		result.EventID = resp.Event.GetIdStr()
+		result.URL = resp.Event.GetUrl()
+	}
+	return result, nil
+}
+
+// buildDeploymentEvent validates the parameters and renders the standard
+// deployment event: a predictable title, the deployment tags, and a
+// markdown body carrying the commit, author and links.
+func buildDeploymentEvent(params RecordDeploymentParams, now time.Time) (*datadogV1.EventCreateRequest, error) {
+	if params.Service == "" || params.Version == "" || params.Env == "" {
+		return nil, fmt.Errorf("service, version and env parameters are required")
+	}
+
+	status := strings.ToLower(params.Status)
+	if status == "" {
+		status = "success"
+	}
+	alertType, ok := map[string]datadogV1.EventAlertType{
+		"success": datadogV1.EVENTALERTTYPE_SUCCESS,
+		"failure": datadogV1.EVENTALERTTYPE_ERROR,
+	}[status]
+	if !ok {
+		return nil, fmt.Errorf("invalid status: %s (use success or failure)", params.Status)
+	}
+
+	tags := []string{
+		deploymentEventTag,
+		"service:" + params.Service,
+		"version:" + params.Version,
+		"env:" + params.Env,
+		"deployment_status:" + status,
+	}
+	if params.CommitSHA != "" {
+		tags = append(tags, "git.commit.sha:"+params.CommitSHA)
+	}
+	if params.RepositoryURL != "" {
+		tags = append(tags, "git.repository_url:"+params.RepositoryURL)
+	}
+	tags = append(tags, params.Tags...)
+
+	var text strings.Builder
+	text.WriteString("%%% \n")
+	fmt.Fprintf(&text, "**Service:** %s\n**Version:** %s\n**Environment:** %s\n**Status:** %s\n", params.Service, params.Version, params.Env, status)
+	if params.CommitSHA != "" {
+		fmt.Fprintf(&text, "**Commit:** %s\n", params.CommitSHA)
+	}
+	if params.DeployedBy != "" {
+		fmt.Fprintf(&text, "**Deployed by:** %s\n", params.DeployedBy)
+	}
+	if params.Description != "" {
+		fmt.Fprintf(&text, "\n%s\n", params.Description)
+	}
+	if len(params.Links) > 0 {
+		text.WriteString("\n**Links:**\n")
+		for _, link := range params.Links {
+			fmt.Fprintf(&text, "- %s\n", link)
+		}
+	}
+	text.WriteString("\n %%%")
+
+	title := fmt.Sprintf("Deployed %s %s to %s", params.Service, params.Version, params.Env)
+	if status == "failure" {
+		title = fmt.Sprintf("Failed deployment of %s %s to %s", params.Service, params.Version, params.Env)
+	}
+
+	return &datadogV1.EventCreateRequest{
+		Title:          title,
+		Text:           text.String(),
+		Tags:           tags,
+		AlertType:      alertType.Ptr(),
+		AggregationKey: datadog.PtrString(fmt.Sprintf("deployment:%s:%s", params.Service, params.Env)),
+		DateHappened:   datadog.PtrInt64(now.Unix()),
+	}, nil
+}
+
+// recordDeploymentTool is registered only when writes are enabled.
+var recordDeploymentTool = Tool{
+	Name:        "record_deployment",
+	Description: "Post a standardized deployment event (service, version, env, links) to Datadog.",
+	InputSchema: InputSchema{
+		Type: "object",
+		Properties: map[string]SchemaProperty{
+			"service": {
+				Type:        "string",
+				Description: "Service that was deployed",
+			},
+			"version": {
+				Type:        "string",
+				Description: "Deployed version (e.g., '1.4.2' or a commit SHA)",
+			},
+			"env": {
+				Type:        "string",
+				Description: "Environment deployed to (e.g., 'production')",
+			},
+			"status": {
+				Type:        "string",
+				Description: "Deployment outcome: 'success' or 'failure'. Defaults to success.",
+			},
+			"commit_sha": {
+				Type:        "string",
+				Description: "Git commit SHA of the deployed revision",
+			},
+			"repository_url": {
+				Type:        "string",
+				Description: "Git repository URL of the service",
+			},
+			"deployed_by": {
+				Type:        "string",
+				Description: "Person or pipeline that ran the deployment",
+			},
+			"description": {
+				Type:        "string",
+				Description: "Free-form notes about the deployment",
+			},
+			"links": {
+				Type:        "array",
+				Description: "Related URLs (CI run, pull request, changelog)",
+				Items:       &SchemaProperty{Type: "string"},
+			},
+			"tags": {
+				Type:        "array",
+				Description: "Additional tags to attach (e.g., 'team:payments')",
+				Items:       &SchemaProperty{Type: "string"},
+			},
+			"confirm": confirmProperty,
+		},
+		Required: []string{"service", "version", "env", "confirm"},
+	},
+	Annotations: writeToolAnnotations(false),
+}
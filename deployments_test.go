@@ -0,0 +1,56 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildDeploymentEvent(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	event, err := buildDeploymentEvent(RecordDeploymentParams{
+		Service:   "checkout",
+		Version:   "1.4.2",
+		Env:       "production",
+		CommitSHA: "abc123",
+		Links:     []string{"https://ci.example.com/run/1"},
+		Tags:      []string{"team:payments"},
+	}, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if event.Title != "Deployed checkout 1.4.2 to production" {
+		t.Errorf("unexpected title: %s", event.Title)
+	}
+	for _, want := range []string{deploymentEventTag, "service:checkout", "version:1.4.2", "env:production", "git.commit.sha:abc123", "team:payments"} {
+		if tagValue(event.Tags, strings.SplitN(want, ":", 2)[0]) == "" {
+			t.Errorf("expected tag %s in %v", want, event.Tags)
+		}
+	}
+	if !strings.Contains(event.Text, "https://ci.example.com/run/1") {
+		t.Errorf("expected link in event text: %s", event.Text)
+	}
+	if event.GetDateHappened() != now.Unix() {
+		t.Errorf("expected date_happened %d, got %d", now.Unix(), event.GetDateHappened())
+	}
+}
+
+func TestBuildDeploymentEventValidation(t *testing.T) {
+	if _, err := buildDeploymentEvent(RecordDeploymentParams{Service: "checkout"}, time.Now()); err == nil {
+		t.Error("expected error when version and env are missing")
+	}
+
+	params := RecordDeploymentParams{Service: "checkout", Version: "1", Env: "prod", Status: "rolled-back"}
+	if _, err := buildDeploymentEvent(params, time.Now()); err == nil {
+		t.Error("expected error for invalid status")
+	}
+}
+
+func TestRecordDeploymentRequiresWrites(t *testing.T) {
+	server := &MCPServer{}
+	_, err := server.RecordDeployment(RecordDeploymentParams{Service: "checkout", Version: "1", Env: "prod"})
+	if err == nil || !strings.Contains(err.Error(), "DD_MCP_ALLOW_WRITES") {
+		t.Errorf("expected write gate error, got %v", err)
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadog"
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV2"
+)
+
+// maxFacetChecks bounds how many @attribute filters are probed when a
+// search comes back empty.
+const maxFacetChecks = 3
+
+// Diagnostic is one finding about why a search matched nothing. Problem
+// marks findings that explain the empty result; the rest rule causes out.
+type Diagnostic struct {
+	Check   string `json:"check"`
+	Problem bool   `json:"problem"`
+	Detail  string `json:"detail"`
+}
+
+var (
+	facetFilterPattern = regexp.MustCompile(`(^|[\s(])-?@([\w.\-]+):`)
+	indexFilterPattern = regexp.MustCompile(`(^|[\s(])index:([^\s()]+)`)
+)
+
+// diagnoseEmptyLogs runs a few cheap searches and config lookups to tell
+// the caller why query found no logs between from and to, since a bare
+// "0 logs" is easily read as "nothing is wrong".
+func (s *MCPServer) diagnoseEmptyLogs(query string, from, to time.Time) []Diagnostic {
+	var findings []Diagnostic
+	if !from.Before(to) {
+		return append(findings, Diagnostic{
+			Check:   "time_range",
+			Problem: true,
+			Detail:  fmt.Sprintf("The range is empty: from (%s) is not before to (%s).", from.Format(time.RFC3339), to.Format(time.RFC3339)),
+		})
+	}
+	if from.After(time.Now()) {
+		return append(findings, Diagnostic{
+			Check:   "time_range",
+			Problem: true,
+			Detail:  fmt.Sprintf("The range starts in the future (%s).", from.Format(time.RFC3339)),
+		})
+	}
+
+	findings = append(findings, s.diagnoseIndexes(query, from)...)
+
+	anyLogs, err := s.logsExist("*", from, to)
+	switch {
+	case err != nil:
+		return append(findings, Diagnostic{Check: "any_logs", Detail: fmt.Sprintf("Couldn't check for logs in the range: %v", err)})
+	case !anyLogs:
+		return append(findings, Diagnostic{
+			Check:   "any_logs",
+			Problem: true,
+			Detail:  "No logs at all were found in this time range. Check the range, index retention and whether logs are being ingested.",
+		})
+	}
+	findings = append(findings, Diagnostic{Check: "any_logs", Detail: "Other logs exist in this time range."})
+
+	if match := serviceFilterPattern.FindStringSubmatch(query); match != nil {
+		service := strings.Trim(match[2], `"`)
+		if service != "" && !strings.ContainsAny(service, "*?") {
+			findings = append(findings, s.diagnoseService(service, from, to))
+		}
+	}
+
+	checked := make(map[string]bool)
+	for _, match := range facetFilterPattern.FindAllStringSubmatch(query, -1) {
+		facet := match[2]
+		if checked[facet] || len(checked) == maxFacetChecks {
+			continue
+		}
+		checked[facet] = true
+		exists, err := s.logsExist("@"+facet+":*", from, to)
+		switch {
+		case err != nil:
+			findings = append(findings, Diagnostic{Check: "facet", Detail: fmt.Sprintf("Couldn't check @%s: %v", facet, err)})
+		case !exists:
+			findings = append(findings, Diagnostic{
+				Check:   "facet",
+				Problem: true,
+				Detail:  fmt.Sprintf("No logs in this range have the attribute @%s. Check the attribute name and that it is parsed.", facet),
+			})
+		default:
+			findings = append(findings, Diagnostic{Check: "facet", Detail: fmt.Sprintf("Logs in this range have @%s; its value may not match.", facet)})
+		}
+	}
+	return findings
+}
+
+func (s *MCPServer) diagnoseService(service string, from, to time.Time) Diagnostic {
+	exists, err := s.logsExist("service:"+service, from, to)
+	if err != nil {
+		return Diagnostic{Check: "service", Detail: fmt.Sprintf("Couldn't check service %s: %v", service, err)}
+	}
+	if exists {
+		return Diagnostic{Check: "service", Detail: fmt.Sprintf("Service %s has logs in this range; the other filters exclude them.", service)}
+	}
+
+	detail := fmt.Sprintf("Service %s has no logs in this range.", service)
+	if resolution, err := s.resolveService(service); err == nil {
+		switch {
+		case resolution.found() && !resolution.Corrected:
+			detail += " It is in the Service Catalog, so it may not be sending logs."
+		case len(resolution.Suggestions) > 0:
+			detail += " Similar services are listed in the notes."
+		default:
+			detail += " It isn't in the Service Catalog either; check the name."
+		}
+	}
+	return Diagnostic{Check: "service", Problem: true, Detail: detail}
+}
+
+// diagnoseIndexes looks for index configuration that hides logs: an
+// unknown index: filter, exclusion filters on the searched service, and a
+// range older than every index retains.
+func (s *MCPServer) diagnoseIndexes(query string, from time.Time) []Diagnostic {
+	api := datadogV1.NewLogsIndexesApi(s.ddClient)
+	resp, _, err := api.ListLogIndexes(s.ctx)
+	if err != nil {
+		// Reading index config needs logs_read_config; not every key has it.
+		return []Diagnostic{{Check: "indexes", Detail: fmt.Sprintf("Couldn't read log index configuration: %v", err)}}
+	}
+
+	var findings []Diagnostic
+	names := make(map[string]bool)
+	var maxRetention int64
+	for _, index := range resp.Indexes {
+		names[index.Name] = true
+		maxRetention = max(maxRetention, index.GetNumRetentionDays(), index.GetNumFlexLogsRetentionDays())
+	}
+
+	if match := indexFilterPattern.FindStringSubmatch(query); match != nil && !strings.ContainsAny(match[2], "*?") && !names[match[2]] {
+		findings = append(findings, Diagnostic{
+			Check:   "indexes",
+			Problem: true,
+			Detail:  fmt.Sprintf("No log index is named %s.", match[2]),
+		})
+	}
+
+	if maxRetention > 0 && time.Since(from) > time.Duration(maxRetention)*24*time.Hour {
+		findings = append(findings, Diagnostic{
+			Check:   "retention",
+			Problem: true,
+			Detail:  fmt.Sprintf("The range starts %s ago, but no index keeps logs longer than %d days.", time.Since(from).Round(time.Hour), maxRetention),
+		})
+	}
+
+	var service string
+	if match := serviceFilterPattern.FindStringSubmatch(query); match != nil {
+		service = strings.ToLower(strings.Trim(match[2], `"`))
+	}
+	for _, index := range resp.Indexes {
+		for _, exclusion := range index.ExclusionFilters {
+			if !exclusion.GetIsEnabled() || exclusion.Filter == nil {
+				continue
+			}
+			exclusionQuery := strings.TrimSpace(exclusion.Filter.GetQuery())
+			excludesAll := exclusionQuery == "" || exclusionQuery == "*"
+			if !excludesAll && (service == "" || !strings.Contains(strings.ToLower(exclusionQuery), "service:"+service)) {
+				continue
+			}
+			findings = append(findings, Diagnostic{
+				Check:   "exclusion_filter",
+				Problem: true,
+				Detail: fmt.Sprintf("Index %s's exclusion filter %q drops %.0f%% of logs matching %q.",
+					index.Name, exclusion.Name, exclusion.Filter.SampleRate*100, exclusionQuery),
+			})
+		}
+	}
+	return findings
+}
+
+// logsExist reports whether query matches at least one log in the range.
+func (s *MCPServer) logsExist(query string, from, to time.Time) (bool, error) {
+	body := datadogV2.LogsListRequest{
+		Filter: &datadogV2.LogsQueryFilter{
+			From:  datadog.PtrString(from.Format(time.RFC3339)),
+			To:    datadog.PtrString(to.Format(time.RFC3339)),
+			Query: datadog.PtrString(query),
+		},
+		Page: &datadogV2.LogsListRequestPage{Limit: datadog.PtrInt32(1)},
+	}
+	api := datadogV2.NewLogsApi(s.ddClient)
+	resp, _, err := api.ListLogs(s.ctx, *datadogV2.NewListLogsOptionalParameters().WithBody(body))
+	if err != nil {
+		return false, err
+	}
+	return len(resp.Data) > 0, nil
+}
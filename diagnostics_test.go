@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeLogsWithIndexes answers a log search with one log when the query is
+// in matching, and serves indexes as the log index configuration.
+func fakeLogsWithIndexes(t *testing.T, matching map[string]bool, indexes string) *MCPServer {
+	t.Helper()
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/logs/events/search"):
+			body, _ := io.ReadAll(r.Body)
+			var req struct {
+				Filter struct {
+					Query string `json:"query"`
+				} `json:"filter"`
+			}
+			_ = json.Unmarshal(body, &req)
+			if matching[req.Filter.Query] {
+				_, _ = w.Write([]byte(`{"data":[{"id":"1","attributes":{"message":"hello"}}]}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"data":[]}`))
+		case strings.HasSuffix(r.URL.Path, "/logs/config/indexes"):
+			_, _ = w.Write([]byte(indexes))
+		case strings.HasSuffix(r.URL.Path, "/services/definitions"):
+			_, _ = w.Write([]byte(`{"data":[]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	server.names = newNameCache()
+	return server
+}
+
+func findDiagnostic(diagnostics []Diagnostic, check string) *Diagnostic {
+	for i := range diagnostics {
+		if diagnostics[i].Check == check {
+			return &diagnostics[i]
+		}
+	}
+	return nil
+}
+
+func TestDiagnoseEmptyLogs(t *testing.T) {
+	indexes := `{"indexes":[{"name":"main","filter":{"query":"*"},"num_retention_days":15,
+		"exclusion_filters":[{"name":"drop checkout debug","is_enabled":true,"filter":{"query":"service:checkout status:debug","sample_rate":1}},
+		{"name":"disabled","is_enabled":false,"filter":{"query":"*","sample_rate":1}}]}]}`
+	server := fakeLogsWithIndexes(t, map[string]bool{"*": true, "service:checkout": true}, indexes)
+
+	now := time.Now()
+	diagnostics := server.diagnoseEmptyLogs("service:checkout @http.status_code:500 index:archive", now.Add(-time.Hour), now)
+
+	if d := findDiagnostic(diagnostics, "any_logs"); d == nil || d.Problem {
+		t.Errorf("expected other logs to be found, got %+v", d)
+	}
+	if d := findDiagnostic(diagnostics, "service"); d == nil || d.Problem || !strings.Contains(d.Detail, "other filters exclude them") {
+		t.Errorf("expected the service to have logs, got %+v", d)
+	}
+	if d := findDiagnostic(diagnostics, "facet"); d == nil || !d.Problem || !strings.Contains(d.Detail, "@http.status_code") {
+		t.Errorf("expected a missing facet, got %+v", d)
+	}
+	if d := findDiagnostic(diagnostics, "indexes"); d == nil || !d.Problem || !strings.Contains(d.Detail, "archive") {
+		t.Errorf("expected an unknown index, got %+v", d)
+	}
+	if d := findDiagnostic(diagnostics, "exclusion_filter"); d == nil || !strings.Contains(d.Detail, "drop checkout debug") {
+		t.Errorf("expected the checkout exclusion filter, got %+v", d)
+	}
+	if d := findDiagnostic(diagnostics, "retention"); d != nil {
+		t.Errorf("expected no retention problem for the last hour, got %+v", d)
+	}
+}
+
+func TestDiagnoseEmptyLogsNoData(t *testing.T) {
+	server := fakeLogsWithIndexes(t, nil, `{"indexes":[{"name":"main","filter":{"query":"*"},"num_retention_days":15}]}`)
+
+	now := time.Now()
+	diagnostics := server.diagnoseEmptyLogs("service:checkout", now.Add(-30*24*time.Hour), now)
+
+	if d := findDiagnostic(diagnostics, "retention"); d == nil || !d.Problem || !strings.Contains(d.Detail, "15 days") {
+		t.Errorf("expected a retention problem, got %+v", d)
+	}
+	if d := findDiagnostic(diagnostics, "any_logs"); d == nil || !d.Problem {
+		t.Errorf("expected no logs in the range, got %+v", d)
+	}
+	// Narrower checks are pointless once nothing at all is found.
+	if d := findDiagnostic(diagnostics, "service"); d != nil {
+		t.Errorf("expected no service check, got %+v", d)
+	}
+
+	diagnostics = server.diagnoseEmptyLogs("*", now, now.Add(-time.Hour))
+	if len(diagnostics) != 1 || diagnostics[0].Check != "time_range" || !diagnostics[0].Problem {
+		t.Errorf("expected only an inverted range finding, got %+v", diagnostics)
+	}
+}
+
+func TestQueryLogsIncludesDiagnostics(t *testing.T) {
+	server := fakeLogsWithIndexes(t, map[string]bool{"*": true}, `{"indexes":[]}`)
+
+	result, err := server.QueryLogs(QueryLogsParams{Query: "service:ghost"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := findDiagnostic(result.Diagnostics, "service"); d == nil || !d.Problem || !strings.Contains(d.Detail, "isn't in the Service Catalog") {
+		t.Fatalf("expected a service finding, got %+v", result.Diagnostics)
+	}
+
+	server = fakeLogsWithIndexes(t, map[string]bool{"*": true, "status:error": true}, `{"indexes":[]}`)
+	result, err = server.QueryLogs(QueryLogsParams{Query: "status:error"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Count != 1 || result.Diagnostics != nil {
+		t.Fatalf("expected no diagnostics for a non-empty result, got %+v", result.Diagnostics)
+	}
+}
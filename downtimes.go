@@ -0,0 +1,349 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadog"
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV2"
+)
+
+// downtimeSpec is a one-time downtime for a single monitor, or for every
+// monitor with all of MonitorTags when MonitorID is zero.
+type downtimeSpec struct {
+	MonitorID   int64
+	MonitorTags []string
+	Scope       string
+	Message     string
+	Start       time.Time
+	End         time.Time
+}
+
+// monitorDowntime is a downtime that silences a monitor. Targeted is set
+// when it names the monitor by ID rather than matching its tags.
+type monitorDowntime struct {
+	ID       string
+	Scope    string
+	Targeted bool
+	Err      error
+}
+
+// downtimesV1 reports whether the org needs the deprecated v1 downtimes
+// API. Downtime calls answer 404 for unknown monitors and downtimes, so
+// the v2 API is probed with a one-item list instead.
+func (s *MCPServer) downtimesV1() bool {
+	return s.backends.probe("downtimes", s.apiOrg(), func() int {
+		api := datadogV2.NewDowntimesApi(s.ddClient)
+		_, httpResp, err := api.ListDowntimes(s.ctx, *datadogV2.NewListDowntimesOptionalParameters().WithPageLimit(1))
+		if err == nil {
+			return http.StatusOK
+		}
+		return httpStatus(httpResp)
+	})
+}
+
+// createDowntime schedules spec and returns the new downtime's ID.
+func (s *MCPServer) createDowntime(spec downtimeSpec) (string, error) {
+	if s.downtimesV1() {
+		return s.createDowntimeV1(spec)
+	}
+	return s.createDowntimeV2(spec)
+}
+
+func (s *MCPServer) createDowntimeV2(spec downtimeSpec) (string, error) {
+	schedule := datadogV2.NewDowntimeScheduleOneTimeCreateUpdateRequest()
+	schedule.SetStart(spec.Start)
+	schedule.SetEnd(spec.End)
+	identifier := datadogV2.DowntimeMonitorIdentifierIdAsDowntimeMonitorIdentifier(datadogV2.NewDowntimeMonitorIdentifierId(spec.MonitorID))
+	if spec.MonitorID == 0 {
+		identifier = datadogV2.DowntimeMonitorIdentifierTagsAsDowntimeMonitorIdentifier(datadogV2.NewDowntimeMonitorIdentifierTags(spec.MonitorTags))
+	}
+	attributes := datadogV2.NewDowntimeCreateRequestAttributes(identifier, spec.Scope)
+	attributes.SetMessage(spec.Message)
+	attributes.Schedule = &datadogV2.DowntimeScheduleCreateRequest{DowntimeScheduleOneTimeCreateUpdateRequest: schedule}
+	body := datadogV2.NewDowntimeCreateRequest(*datadogV2.NewDowntimeCreateRequestData(*attributes, datadogV2.DOWNTIMERESOURCETYPE_DOWNTIME))
+
+	api := datadogV2.NewDowntimesApi(s.ddClient)
+	resp, _, err := api.CreateDowntime(s.ctx, *body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create downtime: %w", err)
+	}
+	if resp.Data == nil {
+		return "", nil
+	}
+	return resp.Data.GetId(), nil
+}
+
+func (s *MCPServer) createDowntimeV1(spec downtimeSpec) (string, error) {
+	body := datadogV1.Downtime{
+		Scope:   []string{spec.Scope},
+		Start:   datadog.PtrInt64(spec.Start.Unix()),
+		End:     *datadog.NewNullableInt64(datadog.PtrInt64(spec.End.Unix())),
+		Message: *datadog.NewNullableString(datadog.PtrString(spec.Message)),
+	}
+	if spec.MonitorID != 0 {
+		body.MonitorId = *datadog.NewNullableInt64(datadog.PtrInt64(spec.MonitorID))
+	} else {
+		body.MonitorTags = spec.MonitorTags
+	}
+	api := datadogV1.NewDowntimesApi(s.ddClient)
+	resp, _, err := api.CreateDowntime(s.ctx, body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create downtime: %w", err)
+	}
+	return strconv.FormatInt(resp.GetId(), 10), nil
+}
+
+// listMonitorDowntimes returns the active and scheduled downtimes that
+// silence a monitor.
+func (s *MCPServer) listMonitorDowntimes(monitorID int64) ([]monitorDowntime, error) {
+	if s.downtimesV1() {
+		return s.listMonitorDowntimesV1(monitorID)
+	}
+	return s.listMonitorDowntimesV2(monitorID)
+}
+
+func (s *MCPServer) listMonitorDowntimesV2(monitorID int64) ([]monitorDowntime, error) {
+	api := datadogV2.NewDowntimesApi(s.ddClient)
+	matches, httpResp, err := api.ListMonitorDowntimes(s.ctx, monitorID, *datadogV2.NewListMonitorDowntimesOptionalParameters().WithPageLimit(100))
+	if err != nil {
+		if httpStatus(httpResp) == http.StatusNotFound {
+			return nil, fmt.Errorf("monitor %d not found", monitorID)
+		}
+		return nil, fmt.Errorf("failed to list downtimes for monitor %d: %w", monitorID, err)
+	}
+	downtimes := make([]monitorDowntime, 0, len(matches.Data))
+	for _, match := range matches.Data {
+		downtime := monitorDowntime{ID: match.GetId()}
+		if match.Attributes != nil {
+			downtime.Scope = match.Attributes.GetScope()
+		}
+		// Matches don't say how the downtime picks its monitors.
+		resp, _, err := api.GetDowntime(s.ctx, downtime.ID)
+		if err != nil {
+			downtime.Err = err
+		} else if resp.Data != nil && resp.Data.Attributes != nil && resp.Data.Attributes.MonitorIdentifier != nil {
+			byID := resp.Data.Attributes.MonitorIdentifier.DowntimeMonitorIdentifierId
+			downtime.Targeted = byID != nil && byID.MonitorId == monitorID
+		}
+		downtimes = append(downtimes, downtime)
+	}
+	return downtimes, nil
+}
+
+func (s *MCPServer) listMonitorDowntimesV1(monitorID int64) ([]monitorDowntime, error) {
+	api := datadogV1.NewDowntimesApi(s.ddClient)
+	all, httpResp, err := api.ListMonitorDowntimes(s.ctx, monitorID)
+	if err != nil {
+		if httpStatus(httpResp) == http.StatusNotFound {
+			return nil, fmt.Errorf("monitor %d not found", monitorID)
+		}
+		return nil, fmt.Errorf("failed to list downtimes for monitor %d: %w", monitorID, err)
+	}
+	downtimes := make([]monitorDowntime, 0, len(all))
+	for _, d := range all {
+		if d.Canceled.IsSet() && d.Canceled.Get() != nil {
+			continue
+		}
+		downtime := monitorDowntime{ID: strconv.FormatInt(d.GetId(), 10)}
+		if len(d.Scope) > 0 {
+			downtime.Scope = d.Scope[0]
+		}
+		if id, ok := d.GetMonitorIdOk(); ok && id != nil {
+			downtime.Targeted = *id == monitorID
+		}
+		downtimes = append(downtimes, downtime)
+	}
+	return downtimes, nil
+}
+
+// cancelDowntime cancels a downtime by ID.
+func (s *MCPServer) cancelDowntime(id string) error {
+	if !s.downtimesV1() {
+		if _, err := datadogV2.NewDowntimesApi(s.ddClient).CancelDowntime(s.ctx, id); err != nil {
+			return fmt.Errorf("failed to cancel downtime %s: %w", id, err)
+		}
+		return nil
+	}
+	v1ID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return fmt.Errorf("downtime %s isn't a v1 downtime ID", id)
+	}
+	if _, err := datadogV1.NewDowntimesApi(s.ddClient).CancelDowntime(s.ctx, v1ID); err != nil {
+		return fmt.Errorf("failed to cancel downtime %s: %w", id, err)
+	}
+	return nil
+}
+
+type CreateDowntimeParams struct {
+	MonitorID   int64    `json:"monitor_id,omitempty"`
+	MonitorTags []string `json:"monitor_tags,omitempty"`
+	Scope       string   `json:"scope,omitempty"`
+	Start       string   `json:"start,omitempty"`
+	End         string   `json:"end"`
+	Message     string   `json:"message,omitempty"`
+}
+
+type CreateDowntimeResult struct {
+	DowntimeID  string   `json:"downtime_id"`
+	MonitorID   int64    `json:"monitor_id,omitempty"`
+	MonitorTags []string `json:"monitor_tags,omitempty"`
+	Scope       string   `json:"scope"`
+	Start       string   `json:"start"`
+	End         string   `json:"end"`
+	URL         string   `json:"url"`
+}
+
+type CancelDowntimeParams struct {
+	DowntimeID string `json:"downtime_id"`
+}
+
+type CancelDowntimeResult struct {
+	DowntimeID string `json:"downtime_id"`
+	Canceled   bool   `json:"canceled"`
+}
+
+// CreateDowntime schedules a one-time downtime, such as a maintenance
+// window, for one monitor or every monitor with the given tags.
+func (s *MCPServer) CreateDowntime(params CreateDowntimeParams) (*CreateDowntimeResult, error) {
+	if err := s.requireWrites("create_downtime"); err != nil {
+		return nil, err
+	}
+	if (params.MonitorID == 0) == (len(params.MonitorTags) == 0) {
+		return nil, fmt.Errorf("exactly one of monitor_id and monitor_tags is required")
+	}
+	if params.End == "" {
+		return nil, fmt.Errorf("end parameter is required")
+	}
+	now := time.Now().UTC()
+	start, err := parseScheduleTime(params.Start, now)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseScheduleTime(params.End, start)
+	if err != nil {
+		return nil, err
+	}
+	if !end.After(start) || !end.After(now) {
+		return nil, fmt.Errorf("end must be after start and in the future")
+	}
+	scope := strings.TrimSpace(params.Scope)
+	if scope == "" {
+		scope = "*"
+	}
+
+	id, err := s.createDowntime(downtimeSpec{
+		MonitorID:   params.MonitorID,
+		MonitorTags: params.MonitorTags,
+		Scope:       scope,
+		Message:     params.Message,
+		Start:       start,
+		End:         end,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &CreateDowntimeResult{
+		DowntimeID:  id,
+		MonitorID:   params.MonitorID,
+		MonitorTags: params.MonitorTags,
+		Scope:       scope,
+		Start:       start.Format(time.RFC3339),
+		End:         end.Format(time.RFC3339),
+		URL:         s.appURL("/monitors/downtimes"),
+	}, nil
+}
+
+// CancelDowntime cancels a downtime before it ends.
+func (s *MCPServer) CancelDowntime(params CancelDowntimeParams) (*CancelDowntimeResult, error) {
+	if err := s.requireWrites("cancel_downtime"); err != nil {
+		return nil, err
+	}
+	id := strings.TrimSpace(params.DowntimeID)
+	if id == "" {
+		return nil, fmt.Errorf("downtime_id parameter is required")
+	}
+	if err := s.cancelDowntime(id); err != nil {
+		return nil, err
+	}
+	return &CancelDowntimeResult{DowntimeID: id, Canceled: true}, nil
+}
+
+// parseScheduleTime reads an RFC3339 time or a duration after base, such
+// as "2h" or "1d", the same durations the other tools accept.
+func parseScheduleTime(value string, base time.Time) (time.Time, error) {
+	if value == "" {
+		return base, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	d, err := parseDurationParam(value, 0)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time: %s (use RFC3339 or a duration from now such as '2h' or '1d')", value)
+	}
+	return base.Add(d), nil
+}
+
+// downtimeTools are registered only when writes are enabled.
+var downtimeTools = []Tool{
+	{
+		Name:        "create_downtime",
+		Description: "Schedule a downtime, such as a maintenance window, for one monitor or every monitor with the given tags",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]SchemaProperty{
+				"monitor_id": {
+					Type:        "integer",
+					Description: "Monitor to silence",
+				},
+				"monitor_tags": {
+					Type:        "array",
+					Description: "Silence every monitor that has all of these tags (e.g., 'team:payments')",
+					Items:       &SchemaProperty{Type: "string"},
+				},
+				"scope": {
+					Type:        "string",
+					Description: "Groups to silence as a tag query (e.g., 'env:prod'). Defaults to '*', every group.",
+				},
+				"start": {
+					Type:        "string",
+					Description: "Start in RFC3339 format or as a duration from now (e.g., '2h', '1d'). Defaults to now.",
+				},
+				"end": {
+					Type:        "string",
+					Description: "End in RFC3339 format or as a duration after start (e.g., '4h')",
+				},
+				"message": {
+					Type:        "string",
+					Description: "Why the downtime is scheduled, shown on the downtime and its notifications",
+				},
+				"confirm": confirmProperty,
+			},
+			Required: []string{"end", "confirm"},
+			AnyOf:    []SchemaCondition{{Required: []string{"monitor_id"}}, {Required: []string{"monitor_tags"}}},
+			Not:      &SchemaCondition{Required: []string{"monitor_id", "monitor_tags"}},
+		},
+		Annotations: writeToolAnnotations(false),
+	},
+	{
+		Name:        "cancel_downtime",
+		Description: "Cancel a scheduled or active downtime",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]SchemaProperty{
+				"downtime_id": {
+					Type:        "string",
+					Description: "Downtime ID, as returned by create_downtime or mute_monitor",
+				},
+				"confirm": confirmProperty,
+			},
+			Required: []string{"downtime_id", "confirm"},
+		},
+		Annotations: writeToolAnnotations(true),
+	},
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseScheduleTime(t *testing.T) {
+	base := time.Date(2026, 1, 20, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"", base},
+		{"2h", base.Add(2 * time.Hour)},
+		{"1d", base.Add(24 * time.Hour)},
+		{"2026-02-01T03:00:00Z", time.Date(2026, 2, 1, 3, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := parseScheduleTime(tt.value, base)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("%q: expected %v, got %v (%v)", tt.value, tt.want, got, err)
+		}
+	}
+	if _, err := parseScheduleTime("tomorrow", base); err == nil {
+		t.Error("expected an unparseable time to be rejected")
+	}
+}
+
+func TestCreateDowntimeByMonitorTags(t *testing.T) {
+	var body struct {
+		Data struct {
+			Attributes struct {
+				MonitorIdentifier struct {
+					MonitorTags []string `json:"monitor_tags"`
+				} `json:"monitor_identifier"`
+				Scope    string `json:"scope"`
+				Schedule struct {
+					Start string `json:"start"`
+					End   string `json:"end"`
+				} `json:"schedule"`
+			} `json:"attributes"`
+		} `json:"data"`
+	}
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		data, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(data, &body)
+		_, _ = w.Write([]byte(`{"data":{"id":"dt-9","type":"downtime"}}`))
+	})
+	server.allowWrites = true
+
+	result, err := server.CreateDowntime(CreateDowntimeParams{MonitorTags: []string{"team:payments"}, Scope: "env:prod", Start: "2030-01-01T02:00:00Z", End: "3h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	attrs := body.Data.Attributes
+	if strings.Join(attrs.MonitorIdentifier.MonitorTags, ",") != "team:payments" || attrs.Scope != "env:prod" {
+		t.Fatalf("unexpected downtime request: %+v", attrs)
+	}
+	if !strings.HasPrefix(attrs.Schedule.Start, "2030-01-01T02:00:00") || !strings.HasPrefix(attrs.Schedule.End, "2030-01-01T05:00:00") {
+		t.Fatalf("unexpected schedule: %+v", attrs.Schedule)
+	}
+	if result.DowntimeID != "dt-9" || result.End != "2030-01-01T05:00:00Z" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	if _, err := server.CreateDowntime(CreateDowntimeParams{MonitorID: 7, End: "2020-01-01T00:00:00Z"}); err == nil {
+		t.Fatal("expected an end in the past to be rejected")
+	}
+	if _, err := server.CreateDowntime(CreateDowntimeParams{End: "1h"}); err == nil {
+		t.Fatal("expected a downtime without monitors to be rejected")
+	}
+}
+
+func TestCancelDowntime(t *testing.T) {
+	var deleted string
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			deleted = r.URL.Path
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	server.allowWrites = true
+
+	if _, err := server.CancelDowntime(CancelDowntimeParams{DowntimeID: "dt-9"}); err != nil {
+		t.Fatal(err)
+	}
+	if deleted != "/api/v2/downtime/dt-9" {
+		t.Fatalf("expected the downtime to be canceled, got %q", deleted)
+	}
+	if _, err := (&MCPServer{}).CancelDowntime(CancelDowntimeParams{DowntimeID: "dt-9"}); err == nil {
+		t.Fatal("expected the write gate to refuse the call")
+	}
+}
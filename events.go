@@ -0,0 +1,246 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadog"
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV2"
+)
+
+// maxEventsLimit bounds one query_events call; v2 returns at most 1000
+// events per page.
+const maxEventsLimit = 1000
+
+type QueryEventsParams struct {
+	Query          string   `json:"query,omitempty"`
+	Sources        []string `json:"sources,omitempty"`
+	Tags           []string `json:"tags,omitempty"`
+	Priority       string   `json:"priority,omitempty"`
+	AggregationKey string   `json:"aggregation_key,omitempty"`
+	From           string   `json:"from,omitempty"`
+	To             string   `json:"to,omitempty"`
+	Limit          int      `json:"limit,omitempty"`
+}
+
+type EventEntry struct {
+	ID             string     `json:"id"`
+	Timestamp      *time.Time `json:"timestamp,omitempty"`
+	Title          string     `json:"title,omitempty"`
+	Message        string     `json:"message,omitempty"`
+	Source         string     `json:"source,omitempty"`
+	Priority       string     `json:"priority,omitempty"`
+	AggregationKey string     `json:"aggregation_key,omitempty"`
+	Host           string     `json:"host,omitempty"`
+	Tags           []string   `json:"tags,omitempty"`
+}
+
+type QueryEventsResult struct {
+	Events  []EventEntry `json:"events"`
+	Count   int          `json:"count"`
+	Backend string       `json:"backend"`
+	Query   string       `json:"query,omitempty"`
+	From    string       `json:"from"`
+	To      string       `json:"to"`
+	Notes   []string     `json:"notes,omitempty"`
+	// Freshness is filled in by QueryEvents.
+	Freshness *Freshness   `json:"freshness,omitempty"`
+	Partial   *PartialData `json:"partial,omitempty"`
+}
+
+func (s *MCPServer) QueryEvents(params QueryEventsParams) (*QueryEventsResult, error) {
+	started := time.Now()
+	from, err := s.timeParam("from", params.From, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.timeParam("to", params.To, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	priority := strings.ToLower(params.Priority)
+	if priority != "" && priority != "normal" && priority != "low" {
+		return nil, fmt.Errorf("invalid priority: %s (use normal or low)", params.Priority)
+	}
+	params.Priority = priority
+
+	limit := 50
+	if params.Limit > 0 {
+		limit = clampArgument(s, "limit", params.Limit, maxEventsLimit)
+	}
+
+	org := s.apiOrg()
+	if !s.backends.useFallback("events", org) {
+		result, status, err := s.searchEventsV2(params, from, to, limit)
+		if err == nil {
+			result.Freshness = eventsFreshness(result, started, to)
+			return result, nil
+		}
+		// 404 and 403 mean the site or org doesn't offer the v2 search
+		// (or the key lacks its scope); v1 may still work.
+		if !s.backends.fallBack("events", org, status) {
+			return nil, err
+		}
+	}
+	result, err := s.listEventsV1(params, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	result.Freshness = eventsFreshness(result, started, to)
+	return result, nil
+}
+
+func eventsFreshness(result *QueryEventsResult, started, to time.Time) *Freshness {
+	times := make([]*time.Time, 0, len(result.Events))
+	for _, event := range result.Events {
+		times = append(times, event.Timestamp)
+	}
+	return newFreshness("events", started, to, latestTime(times...))
+}
+
+func (s *MCPServer) searchEventsV2(params QueryEventsParams, from, to time.Time, limit int) (*QueryEventsResult, int, error) {
+	query := buildEventsQuery(params)
+	body := datadogV2.EventsListRequest{
+		Filter: &datadogV2.EventsQueryFilter{
+			From:  datadog.PtrString(from.Format(time.RFC3339)),
+			To:    datadog.PtrString(to.Format(time.RFC3339)),
+			Query: datadog.PtrString(query),
+		},
+		Page: &datadogV2.EventsRequestPage{Limit: datadog.PtrInt32(int32(limit))},
+		Sort: datadogV2.EVENTSSORT_TIMESTAMP_DESCENDING.Ptr(),
+	}
+
+	api := datadogV2.NewEventsApi(s.ddClient)
+	resp, httpResp, err := api.SearchEvents(s.ctx, *datadogV2.NewSearchEventsOptionalParameters().WithBody(body))
+	if err != nil {
+		return nil, httpStatus(httpResp), fmt.Errorf("failed to search events: %w", err)
+	}
+
+	events := make([]EventEntry, 0, len(resp.Data))
+	var partial *PartialData
+	for _, event := range resp.Data {
+		entry, missing := toEventEntryV2(event)
+		events = append(events, entry)
+		partial = partial.record(missing...)
+	}
+	result := &QueryEventsResult{
+		Events:  events,
+		Count:   len(events),
+		Backend: "v2",
+		Query:   query,
+		From:    from.Format(time.RFC3339),
+		To:      to.Format(time.RFC3339),
+		Partial: partial,
+	}
+	return result, 0, nil
+}
+
+// buildEventsQuery folds the structured filters into v2 search syntax.
+func buildEventsQuery(params QueryEventsParams) string {
+	var parts []string
+	if params.Query != "" {
+		parts = append(parts, params.Query)
+	}
+	if len(params.Sources) == 1 {
+		parts = append(parts, "source:"+params.Sources[0])
+	} else if len(params.Sources) > 1 {
+		parts = append(parts, "source:("+strings.Join(params.Sources, " OR ")+")")
+	}
+	parts = append(parts, params.Tags...)
+	if params.Priority != "" {
+		parts = append(parts, "priority:"+params.Priority)
+	}
+	if params.AggregationKey != "" {
+		parts = append(parts, "@aggregation_key:"+params.AggregationKey)
+	}
+	return strings.Join(parts, " ")
+}
+
+// toEventEntryV2 also names the fields every event should have but this
+// one lacks.
+func toEventEntryV2(event datadogV2.EventResponse) (EventEntry, []string) {
+	entry := EventEntry{ID: event.GetId()}
+	outer := event.Attributes
+	if outer == nil {
+		return entry, []string{"attributes"}
+	}
+	inner := outer.GetAttributes()
+	entry.Timestamp = outer.Timestamp
+	entry.Message = outer.GetMessage()
+	entry.Tags = outer.GetTags()
+	entry.Title = inner.GetTitle()
+	entry.Source = inner.GetSourceTypeName()
+	entry.Priority = string(inner.GetPriority())
+	entry.AggregationKey = inner.GetAggregationKey()
+	entry.Host = inner.GetHostname()
+	return entry, missingFields(
+		fieldCheck{"id", event.Id != nil},
+		fieldCheck{"timestamp", outer.Timestamp != nil},
+	)
+}
+
+// listEventsV1 serves the same filters from the v1 event stream, which
+// has no free-text query or aggregation key filter.
+func (s *MCPServer) listEventsV1(params QueryEventsParams, from, to time.Time, limit int) (*QueryEventsResult, error) {
+	opts := datadogV1.NewListEventsOptionalParameters()
+	if len(params.Sources) > 0 {
+		opts = opts.WithSources(strings.Join(params.Sources, ","))
+	}
+	if len(params.Tags) > 0 {
+		opts = opts.WithTags(strings.Join(params.Tags, ","))
+	}
+	if params.Priority != "" {
+		opts = opts.WithPriority(datadogV1.EventPriority(params.Priority))
+	}
+
+	var notes []string
+	if params.Query != "" {
+		notes = append(notes, "The v1 events API has no free-text search; query was ignored.")
+	}
+	if params.AggregationKey != "" {
+		notes = append(notes, "The v1 events API can't filter by aggregation key; aggregation_key was ignored.")
+	}
+
+	api := datadogV1.NewEventsApi(s.ddClient)
+	resp, _, err := api.ListEvents(s.ctx, from.Unix(), to.Unix(), *opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+
+	events := make([]EventEntry, 0, len(resp.Events))
+	for _, event := range resp.Events {
+		if len(events) >= limit {
+			notes = append(notes, fmt.Sprintf("Showing the first %d events.", limit))
+			break
+		}
+		events = append(events, toEventEntryV1(event))
+	}
+	return &QueryEventsResult{
+		Events:  events,
+		Count:   len(events),
+		Backend: "v1",
+		From:    from.Format(time.RFC3339),
+		To:      to.Format(time.RFC3339),
+		Notes:   notes,
+	}, nil
+}
+
+func toEventEntryV1(event datadogV1.Event) EventEntry {
+	entry := EventEntry{
+		ID:       event.GetIdStr(),
+		Title:    event.GetTitle(),
+		Message:  event.GetText(),
+		Source:   event.GetSourceTypeName(),
+		Priority: string(event.GetPriority()),
+		Host:     event.GetHost(),
+		Tags:     event.GetTags(),
+	}
+	if happened, ok := event.GetDateHappenedOk(); ok {
+		ts := time.Unix(*happened, 0).UTC()
+		entry.Timestamp = &ts
+	}
+	return entry
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestBuildEventsQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		params   QueryEventsParams
+		expected string
+	}{
+		{name: "empty", params: QueryEventsParams{}, expected: ""},
+		{name: "single source", params: QueryEventsParams{Query: "deploy", Sources: []string{"github"}}, expected: "deploy source:github"},
+		{
+			name: "all filters",
+			params: QueryEventsParams{
+				Sources:        []string{"kubernetes", "github"},
+				Tags:           []string{"env:prod", "team:payments"},
+				Priority:       "low",
+				AggregationKey: "abc",
+			},
+			expected: "source:(kubernetes OR github) env:prod team:payments priority:low @aggregation_key:abc",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildEventsQuery(tt.params); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestQueryEventsV2(t *testing.T) {
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/events/search" {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+		fmt.Fprint(w, `{"data":[{"id":"AAA","attributes":{"message":"deployed","tags":["env:prod"],"timestamp":"2026-01-20T10:00:00Z","attributes":{"title":"Deploy","source_type_name":"github","priority":"normal","aggregation_key":"k1","hostname":"web-1"}}}]}`)
+	})
+	server.backends = mustLoadAPIBackends(t)
+
+	result, err := server.QueryEvents(QueryEventsParams{Sources: []string{"github"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Backend != "v2" || result.Count != 1 {
+		t.Fatalf("expected one v2 event, got %+v", result)
+	}
+	event := result.Events[0]
+	if event.Title != "Deploy" || event.Source != "github" || event.AggregationKey != "k1" || event.Host != "web-1" {
+		t.Errorf("unexpected event: %+v", event)
+	}
+}
+
+func TestQueryEventsFallsBackToV1(t *testing.T) {
+	var paths []string
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if strings.HasPrefix(r.URL.Path, "/api/v2/") {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"errors":["Not found"]}`)
+			return
+		}
+		if got := r.URL.Query().Get("sources"); got != "github,jenkins" {
+			t.Errorf("expected sources to be passed to v1, got %q", got)
+		}
+		fmt.Fprint(w, `{"events":[{"id_str":"1","title":"Deploy","date_happened":1768903200,"priority":"normal"},{"id_str":"2","title":"Other"}]}`)
+	})
+	server.backends = mustLoadAPIBackends(t)
+
+	params := QueryEventsParams{Query: "deploy", Sources: []string{"github", "jenkins"}, Limit: 1}
+	result, err := server.QueryEvents(params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Backend != "v1" || result.Count != 1 || result.Events[0].Timestamp == nil {
+		t.Fatalf("expected one v1 event, got %+v", result)
+	}
+	if len(result.Notes) != 2 {
+		t.Errorf("expected notes about the ignored query and the limit, got %q", result.Notes)
+	}
+
+	if _, err := server.QueryEvents(params); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(paths) != 3 {
+		t.Errorf("expected the v2 probe only once, got requests %q", paths)
+	}
+}
+
+func TestQueryEventsPinnedV2DoesNotFallBack(t *testing.T) {
+	t.Setenv("DD_MCP_EVENTS_API", "v2")
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	server.backends = mustLoadAPIBackends(t)
+
+	if _, err := server.QueryEvents(QueryEventsParams{}); err == nil {
+		t.Error("expected an error when v2 is pinned and unavailable")
+	}
+}
@@ -0,0 +1,697 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+type ExplainQueryParams struct {
+	Query string `json:"query"`
+	// Kind is "logs" (also spans and events, which share the syntax) or
+	// "metric". Empty guesses from the query.
+	Kind string `json:"kind,omitempty"`
+}
+
+// QueryFilter is one condition of a search query or metric scope.
+type QueryFilter struct {
+	// Field is empty for free-text terms.
+	Field string `json:"field,omitempty"`
+	// Type is "tag", "attribute", "reserved" or "full_text".
+	Type       string `json:"type"`
+	Value      string `json:"value"`
+	Negated    bool   `json:"negated,omitempty"`
+	Phrase     bool   `json:"phrase,omitempty"`
+	Wildcard   bool   `json:"wildcard,omitempty"`
+	Range      bool   `json:"range,omitempty"`
+	Comparison string `json:"comparison,omitempty"`
+}
+
+// MetricQueryPart explains one metric query of a possibly arithmetic
+// expression.
+type MetricQueryPart struct {
+	Query            string        `json:"query"`
+	Metric           string        `json:"metric"`
+	SpaceAggregation string        `json:"space_aggregation"`
+	Scope            []QueryFilter `json:"scope,omitempty"`
+	ScopeStructure   string        `json:"scope_structure"`
+	GroupBy          []string      `json:"group_by,omitempty"`
+	Rollup           string        `json:"rollup,omitempty"`
+	// Functions wrap the query, outermost first; Modifiers follow it, such
+	// as as_count().
+	Functions []string `json:"functions,omitempty"`
+	Modifiers []string `json:"modifiers,omitempty"`
+}
+
+type ExplainQueryResult struct {
+	Query string `json:"query"`
+	Kind  string `json:"kind"`
+	// Structure is the search with every implicit AND and grouping
+	// spelled out.
+	Structure string            `json:"structure,omitempty"`
+	Filters   []QueryFilter     `json:"filters,omitempty"`
+	Operators []string          `json:"operators,omitempty"`
+	Metrics   []MetricQueryPart `json:"metrics,omitempty"`
+	// Expression is the arithmetic over the metric queries, with each
+	// query replaced by a, b, c....
+	Expression string   `json:"expression,omitempty"`
+	Notes      []string `json:"notes,omitempty"`
+}
+
+// reservedSearchFields are the attributes search syntax addresses without
+// an @ prefix.
+var reservedSearchFields = []string{"host", "service", "status", "source", "env", "version", "trace_id", "message", "resource_name", "operation_name", "index"}
+
+var metricQueryHint = regexp.MustCompile(`(^|[\s(:,])[A-Za-z][\w.]*\{`)
+
+// ExplainQuery describes what a search or metric query matches without
+// running it.
+func (s *MCPServer) ExplainQuery(params ExplainQueryParams) (*ExplainQueryResult, error) {
+	query := strings.TrimSpace(params.Query)
+	if query == "" {
+		return nil, fmt.Errorf("query parameter is required")
+	}
+	kind := strings.ToLower(params.Kind)
+	switch kind {
+	case "":
+		kind = "logs"
+		if metricQueryHint.MatchString(query) {
+			kind = "metric"
+		}
+	case "logs", "spans", "events", "metric":
+	default:
+		return nil, fmt.Errorf("invalid kind: %s (use logs, spans, events or metric)", params.Kind)
+	}
+
+	if kind == "metric" {
+		return explainMetricQuery(query)
+	}
+	return explainSearchQuery(query, kind)
+}
+
+// searchNode is a parsed search: a boolean operator over children, or a
+// single filter.
+type searchNode struct {
+	op       string
+	children []*searchNode
+	filter   *QueryFilter
+}
+
+func (n *searchNode) String() string {
+	switch n.op {
+	case "NOT":
+		return "NOT " + n.children[0].group()
+	case "AND", "OR":
+		parts := make([]string, 0, len(n.children))
+		for _, child := range n.children {
+			parts = append(parts, child.group())
+		}
+		return strings.Join(parts, " "+n.op+" ")
+	}
+	return n.filter.String()
+}
+
+// group parenthesizes operators so nesting stays unambiguous.
+func (n *searchNode) group() string {
+	if n.op == "AND" || n.op == "OR" {
+		return "(" + n.String() + ")"
+	}
+	return n.String()
+}
+
+func (f QueryFilter) String() string {
+	value := f.Value
+	if f.Phrase {
+		value = `"` + value + `"`
+	}
+	if f.Field == "" {
+		return value
+	}
+	return f.Field + ":" + value
+}
+
+// filters lists the leaves in query order, marking those under an odd
+// number of NOTs as negated.
+func (n *searchNode) filters(negated bool, out []QueryFilter) []QueryFilter {
+	if n.filter != nil {
+		f := *n.filter
+		f.Negated = negated
+		return append(out, f)
+	}
+	if n.op == "NOT" {
+		negated = !negated
+	}
+	for _, child := range n.children {
+		out = child.filters(negated, out)
+	}
+	return out
+}
+
+type searchToken struct {
+	kind  string // "(", ")", "AND", "OR", "NOT", "field(" or "term"
+	value string
+}
+
+// tokenizeSearch splits search syntax into operators, parentheses and
+// terms, keeping quoted phrases and [a TO b] ranges whole.
+func tokenizeSearch(query string) ([]searchToken, error) {
+	var tokens []searchToken
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case r == '(':
+			tokens = append(tokens, searchToken{kind: "("})
+			i++
+			continue
+		case r == ')':
+			tokens = append(tokens, searchToken{kind: ")"})
+			i++
+			continue
+		case (r == '-' || r == '!') && i+1 < len(runes) && runes[i+1] == '(':
+			tokens = append(tokens, searchToken{kind: "NOT"})
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '(' && runes[i] != ')' {
+			switch runes[i] {
+			case '\\':
+				i++
+			case '"':
+				end := closingRune(runes, i+1, '"')
+				if end < 0 {
+					return nil, fmt.Errorf("unclosed quote in %q", string(runes[i:]))
+				}
+				i = end
+			case '[', '{':
+				closer := ']'
+				if runes[i] == '{' {
+					closer = '}'
+				}
+				end := closingRune(runes, i+1, closer)
+				if end < 0 {
+					return nil, fmt.Errorf("unclosed range in %q", string(runes[i:]))
+				}
+				i = end
+			}
+			i++
+		}
+		word := string(runes[start:min(i, len(runes))])
+		switch {
+		case word == "AND" || word == "OR" || word == "NOT":
+			tokens = append(tokens, searchToken{kind: word})
+		case strings.HasSuffix(word, ":") && i < len(runes) && runes[i] == '(':
+			tokens = append(tokens, searchToken{kind: "field(", value: strings.TrimSuffix(word, ":")})
+			i++
+		default:
+			tokens = append(tokens, searchToken{kind: "term", value: word})
+		}
+	}
+	return tokens, nil
+}
+
+func closingRune(runes []rune, from int, closer rune) int {
+	for i := from; i < len(runes); i++ {
+		if runes[i] == '\\' {
+			i++
+			continue
+		}
+		if runes[i] == closer {
+			return i
+		}
+	}
+	return -1
+}
+
+// searchParser is a recursive-descent parser: OR binds loosest, then AND
+// (explicit or implied by adjacency), then NOT and -.
+type searchParser struct {
+	tokens   []searchToken
+	pos      int
+	implicit bool
+	words    []string
+}
+
+func (p *searchParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos].kind
+	}
+	return ""
+}
+
+func (p *searchParser) parseOr(field string) (*searchNode, error) {
+	left, err := p.parseAnd(field)
+	if err != nil {
+		return nil, err
+	}
+	node := left
+	for p.peek() == "OR" {
+		p.pos++
+		right, err := p.parseAnd(field)
+		if err != nil {
+			return nil, err
+		}
+		node = joinNodes("OR", node, right)
+	}
+	return node, nil
+}
+
+func (p *searchParser) parseAnd(field string) (*searchNode, error) {
+	node, err := p.parseUnary(field)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch p.peek() {
+		case "AND":
+			p.pos++
+		case "term", "(", "NOT", "field(":
+			p.implicit = true
+		default:
+			return node, nil
+		}
+		right, err := p.parseUnary(field)
+		if err != nil {
+			return nil, err
+		}
+		node = joinNodes("AND", node, right)
+	}
+}
+
+func (p *searchParser) parseUnary(field string) (*searchNode, error) {
+	if p.peek() == "NOT" {
+		p.pos++
+		child, err := p.parseUnary(field)
+		if err != nil {
+			return nil, err
+		}
+		return &searchNode{op: "NOT", children: []*searchNode{child}}, nil
+	}
+	return p.parsePrimary(field)
+}
+
+func (p *searchParser) parsePrimary(field string) (*searchNode, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("query ends where a term was expected")
+	}
+	tok := p.tokens[p.pos]
+	p.pos++
+	switch tok.kind {
+	case "(", "field(":
+		if tok.kind == "field(" {
+			field = tok.value
+		}
+		node, err := p.parseOr(field)
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return node, nil
+	case "term":
+		word := tok.value
+		if strings.HasPrefix(word, "-") && len(word) > 1 {
+			child := p.termNode(field, word[1:])
+			return &searchNode{op: "NOT", children: []*searchNode{child}}, nil
+		}
+		return p.termNode(field, word), nil
+	}
+	return nil, fmt.Errorf("unexpected %s", tok.kind)
+}
+
+func (p *searchParser) termNode(field, word string) *searchNode {
+	f := QueryFilter{Field: field, Value: word}
+	if field == "" {
+		if i := unescapedIndex(word, ':'); i > 0 {
+			f.Field, f.Value = word[:i], word[i+1:]
+		}
+	}
+	if f.Field == "" {
+		p.words = append(p.words, word)
+	}
+	classifyFilter(&f)
+	return &searchNode{filter: &f}
+}
+
+func joinNodes(op string, left, right *searchNode) *searchNode {
+	if left.op == op {
+		left.children = append(left.children, right)
+		return left
+	}
+	return &searchNode{op: op, children: []*searchNode{left, right}}
+}
+
+// unescapedIndex finds c outside quotes and backslash escapes.
+func unescapedIndex(s string, c byte) int {
+	quoted := false
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			quoted = !quoted
+		case c:
+			if !quoted {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func classifyFilter(f *QueryFilter) {
+	switch {
+	case f.Field == "":
+		f.Type = "full_text"
+	case strings.HasPrefix(f.Field, "@"):
+		f.Type = "attribute"
+	case slices.Contains(reservedSearchFields, strings.ToLower(f.Field)):
+		f.Type = "reserved"
+	default:
+		f.Type = "tag"
+	}
+
+	value := f.Value
+	switch {
+	case len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`):
+		f.Phrase = true
+		f.Value = value[1 : len(value)-1]
+		return
+	case strings.HasPrefix(value, "[") || strings.HasPrefix(value, "{"):
+		f.Range = true
+		return
+	}
+	for _, op := range []string{">=", "<=", ">", "<"} {
+		if strings.HasPrefix(value, op) {
+			f.Comparison = op
+			f.Value = value[len(op):]
+			return
+		}
+	}
+	f.Wildcard = unescapedIndex(value, '*') >= 0 || unescapedIndex(value, '?') >= 0
+}
+
+func parseSearch(query string) (*searchNode, *searchParser, error) {
+	tokens, err := tokenizeSearch(query)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil, fmt.Errorf("query has no terms")
+	}
+	p := &searchParser{tokens: tokens}
+	node, err := p.parseOr("")
+	if err != nil {
+		return nil, nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, nil, fmt.Errorf("unexpected %s", p.tokens[p.pos].kind)
+	}
+	return node, p, nil
+}
+
+func explainSearchQuery(query, kind string) (*ExplainQueryResult, error) {
+	if query == "*" {
+		return &ExplainQueryResult{
+			Query:     query,
+			Kind:      kind,
+			Structure: "*",
+			Notes:     []string{fmt.Sprintf("Matches all %s in the time range.", kind)},
+		}, nil
+	}
+	node, p, err := parseSearch(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse query: %w", err)
+	}
+
+	result := &ExplainQueryResult{
+		Query:     query,
+		Kind:      kind,
+		Structure: node.String(),
+		Filters:   node.filters(false, nil),
+	}
+	for _, tok := range p.tokens {
+		if (tok.kind == "AND" || tok.kind == "OR" || tok.kind == "NOT") && !slices.Contains(result.Operators, tok.kind) {
+			result.Operators = append(result.Operators, tok.kind)
+		}
+	}
+	if p.implicit && !slices.Contains(result.Operators, "AND") {
+		result.Operators = append(result.Operators, "AND")
+	}
+	result.Notes = searchNotes(result, p, kind)
+	return result, nil
+}
+
+func searchNotes(result *ExplainQueryResult, p *searchParser, kind string) []string {
+	var notes []string
+	if p.implicit {
+		notes = append(notes, "Terms separated only by spaces are combined with AND.")
+	}
+	for _, word := range p.words {
+		if lower := strings.ToLower(word); lower == "and" || lower == "or" || lower == "not" {
+			notes = append(notes, fmt.Sprintf("%q is searched as a word; boolean operators must be uppercase.", word))
+		}
+	}
+
+	var attributes, leading, freeText []string
+	var index string
+	for _, f := range result.Filters {
+		switch f.Type {
+		case "attribute":
+			if !slices.Contains(attributes, f.Field) {
+				attributes = append(attributes, f.Field)
+			}
+		case "full_text":
+			freeText = append(freeText, f.String())
+		case "reserved":
+			if strings.EqualFold(f.Field, "index") {
+				index = f.Value
+			}
+		}
+		if f.Wildcard && (strings.HasPrefix(f.Value, "*") || strings.HasPrefix(f.Value, "?")) && f.Value != "*" {
+			leading = append(leading, f.String())
+		}
+		if f.Phrase && strings.Contains(f.Value, "*") {
+			notes = append(notes, fmt.Sprintf("The * in %s is matched literally, because wildcards don't apply inside quotes.", f.String()))
+		}
+		if f.Value == "*" && f.Field != "" {
+			notes = append(notes, fmt.Sprintf("%s matches any %s that has %s set.", f.String(), strings.TrimSuffix(kind, "s"), f.Field))
+		}
+	}
+	if len(attributes) > 0 {
+		notes = append(notes, fmt.Sprintf("%s %s only searchable where defined as a facet (or a measure, for ranges and comparisons); without one, the filter matches nothing.",
+			strings.Join(attributes, ", "), pluralVerb(len(attributes))))
+	}
+	if len(freeText) > 0 {
+		notes = append(notes, fmt.Sprintf("Free-text terms (%s) match words in the message, not attribute values; use *:term to search every attribute.", strings.Join(freeText, ", ")))
+	}
+	if len(leading) > 0 {
+		notes = append(notes, fmt.Sprintf("Leading wildcards (%s) can't use prefix lookups and make the search slower.", strings.Join(leading, ", ")))
+	}
+	if kind == "logs" {
+		if index != "" {
+			notes = append(notes, fmt.Sprintf("Only the %s index is searched.", index))
+		} else {
+			notes = append(notes, "Every index the key can read is searched. Logs dropped by exclusion filters, or kept only in archives, match nothing until rehydrated.")
+		}
+	}
+	if kind == "spans" {
+		notes = append(notes, "Only indexed spans are searched; spans not kept by a retention filter won't match.")
+	}
+	return notes
+}
+
+func pluralVerb(n int) string {
+	if n == 1 {
+		return "is"
+	}
+	return "are"
+}
+
+var (
+	metricPartPattern    = regexp.MustCompile(`^(?:(avg|sum|min|max|count):)?([A-Za-z][\w.]*)\{([^}]*)\}(?:\s*by\s*\{([^}]*)\})?((?:\s*\.\s*\w+\([^)]*\))*)$`)
+	metricCallPattern    = regexp.MustCompile(`\.\s*(\w+)\(([^)]*)\)`)
+	scopeNegationPattern = regexp.MustCompile(`(^|[\s(])!`)
+)
+
+func explainMetricQuery(query string) (*ExplainQueryResult, error) {
+	operands, expression := splitMetricExpression(query)
+	result := &ExplainQueryResult{Query: query, Kind: "metric"}
+	if len(operands) > 1 {
+		result.Expression = expression
+	}
+	for _, operand := range operands {
+		part, err := explainMetricPart(operand)
+		if err != nil {
+			return nil, err
+		}
+		result.Metrics = append(result.Metrics, *part)
+		result.Notes = append(result.Notes, metricNotes(part)...)
+	}
+	if len(operands) > 1 {
+		result.Notes = append(result.Notes, "Arithmetic joins the queries' series by timestamp and by matching group tags; series without a partner are dropped.")
+	}
+	return result, nil
+}
+
+// splitMetricExpression separates the top-level arithmetic operands of a
+// metric expression, naming them a, b, c... in the returned expression.
+// Numeric operands stay in the expression.
+func splitMetricExpression(query string) ([]string, string) {
+	var operands []string
+	var expression strings.Builder
+	depth, start := 0, 0
+	flush := func(end int) {
+		operand := strings.TrimSpace(query[start:end])
+		if operand == "" {
+			return
+		}
+		if strings.Trim(operand, "0123456789.") == "" {
+			expression.WriteString(operand)
+			return
+		}
+		expression.WriteString(string(rune('a' + len(operands))))
+		operands = append(operands, operand)
+	}
+	for i, r := range query {
+		switch r {
+		case '(', '{':
+			depth++
+		case ')', '}':
+			depth--
+		case '+', '-', '*', '/':
+			// A - inside a metric name or tag value isn't an operator.
+			if depth == 0 && (r != '-' || (i > 0 && query[i-1] == ' ')) {
+				flush(i)
+				expression.WriteString(" " + string(r) + " ")
+				start = i + 1
+			}
+		}
+	}
+	flush(len(query))
+	return operands, expression.String()
+}
+
+func explainMetricPart(query string) (*MetricQueryPart, error) {
+	part := &MetricQueryPart{Query: query}
+	inner := query
+	// Peel wrapping functions such as top(q, 10, 'mean', 'desc') or
+	// per_second(q).
+	for {
+		open := strings.Index(inner, "(")
+		if open <= 0 || !strings.HasSuffix(inner, ")") || strings.ContainsAny(inner[:open], "{:.") {
+			break
+		}
+		args := inner[open+1 : len(inner)-1]
+		first, rest := splitTopLevelComma(args)
+		fn := inner[:open]
+		if rest != "" {
+			fn += "(..., " + rest + ")"
+		}
+		part.Functions = append(part.Functions, fn)
+		inner = strings.TrimSpace(first)
+	}
+
+	m := metricPartPattern.FindStringSubmatch(inner)
+	if m == nil {
+		return nil, fmt.Errorf("can't parse metric query %q; expected [aggregation:]metric{scope} [by {tags}]", inner)
+	}
+	part.SpaceAggregation = m[1]
+	part.Metric = m[2]
+	if m[4] != "" {
+		for _, tag := range strings.Split(m[4], ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				part.GroupBy = append(part.GroupBy, tag)
+			}
+		}
+	}
+	for _, call := range metricCallPattern.FindAllStringSubmatch(m[5], -1) {
+		if call[1] == "rollup" {
+			part.Rollup = strings.ReplaceAll(call[2], " ", "")
+			continue
+		}
+		part.Modifiers = append(part.Modifiers, call[1]+"("+call[2]+")")
+	}
+
+	scope := strings.TrimSpace(m[3])
+	if scope == "" || scope == "*" {
+		part.ScopeStructure = "*"
+		return part, nil
+	}
+	// Commas in a scope mean AND, and ! negates.
+	search := strings.ReplaceAll(scope, ",", " AND ")
+	search = scopeNegationPattern.ReplaceAllString(search, "${1}-")
+	node, _, err := parseSearch(search)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse scope of %s: %w", part.Metric, err)
+	}
+	part.ScopeStructure = node.String()
+	part.Scope = node.filters(false, nil)
+	for i := range part.Scope {
+		// Every scope term is a tag, including bare ones such as a host.
+		part.Scope[i].Type = "tag"
+	}
+	return part, nil
+}
+
+func splitTopLevelComma(s string) (string, string) {
+	depth := 0
+	for i, r := range s {
+		switch r {
+		case '(', '{':
+			depth++
+		case ')', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				return s[:i], strings.TrimSpace(s[i+1:])
+			}
+		}
+	}
+	return s, ""
+}
+
+func metricNotes(part *MetricQueryPart) []string {
+	var notes []string
+	if part.SpaceAggregation == "" {
+		notes = append(notes, fmt.Sprintf("%s has no space aggregation, so series are averaged (avg).", part.Metric))
+	}
+	if part.ScopeStructure == "*" {
+		notes = append(notes, fmt.Sprintf("%s is not filtered: every source reporting it is included.", part.Metric))
+	}
+	for _, f := range part.Scope {
+		if f.Wildcard {
+			notes = append(notes, fmt.Sprintf("%s matches tag values by pattern.", f.String()))
+		}
+	}
+	if len(part.GroupBy) > 0 {
+		notes = append(notes, fmt.Sprintf("%s returns one series per %s combination; high-cardinality tags can return many series.", part.Metric, strings.Join(part.GroupBy, "/")))
+	} else {
+		notes = append(notes, fmt.Sprintf("%s is aggregated into a single series.", part.Metric))
+	}
+	if part.Rollup == "" {
+		notes = append(notes, fmt.Sprintf("%s has no rollup, so Datadog picks the interval from the time range and averages the points in each; over long ranges short spikes are smoothed away.", part.Metric))
+	} else {
+		notes = append(notes, fmt.Sprintf("%s is rolled up with %s.", part.Metric, part.Rollup))
+	}
+	for _, modifier := range part.Modifiers {
+		switch {
+		case strings.HasPrefix(modifier, "as_count"):
+			notes = append(notes, fmt.Sprintf("%s: as_count() shows the raw count per interval rather than a per-second rate.", part.Metric))
+		case strings.HasPrefix(modifier, "as_rate"):
+			notes = append(notes, fmt.Sprintf("%s: as_rate() shows a per-second rate.", part.Metric))
+		case strings.HasPrefix(modifier, "fill"):
+			notes = append(notes, fmt.Sprintf("%s: %s fills gaps, so missing data can look like real values.", part.Metric, modifier))
+		}
+	}
+	return notes
+}
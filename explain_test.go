@@ -0,0 +1,85 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExplainSearchQuery(t *testing.T) {
+	server := &MCPServer{}
+	result, err := server.ExplainQuery(ExplainQueryParams{Query: `service:checkout status:(error OR warn) -@http.status_code:404 "card declined" timeout*`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `service:checkout AND (status:error OR status:warn) AND NOT @http.status_code:404 AND "card declined" AND timeout*`
+	if result.Kind != "logs" || result.Structure != want {
+		t.Fatalf("unexpected structure %q", result.Structure)
+	}
+	if len(result.Filters) != 6 {
+		t.Fatalf("expected six filters, got %+v", result.Filters)
+	}
+	status, code, phrase, prefix := result.Filters[2], result.Filters[3], result.Filters[4], result.Filters[5]
+	if status.Field != "status" || status.Value != "warn" || status.Type != "reserved" {
+		t.Fatalf("expected the grouped status values as filters, got %+v", status)
+	}
+	if !code.Negated || code.Type != "attribute" || !phrase.Phrase || phrase.Type != "full_text" || !prefix.Wildcard {
+		t.Fatalf("unexpected filters: %+v", result.Filters[3:])
+	}
+	notes := strings.Join(result.Notes, "\n")
+	for _, note := range []string{"combined with AND", "@http.status_code is only searchable where defined as a facet", "Every index"} {
+		if !strings.Contains(notes, note) {
+			t.Fatalf("expected %q in notes:\n%s", note, notes)
+		}
+	}
+
+	result, err = server.ExplainQuery(ExplainQueryParams{Query: `index:main @duration:>1s and *timeout`, Kind: "logs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Filters[1].Comparison != ">" || result.Filters[1].Value != "1s" {
+		t.Fatalf("expected a comparison filter, got %+v", result.Filters[1])
+	}
+	notes = strings.Join(result.Notes, "\n")
+	for _, note := range []string{`"and" is searched as a word`, "Leading wildcards (*timeout)", "Only the main index"} {
+		if !strings.Contains(notes, note) {
+			t.Fatalf("expected %q in notes:\n%s", note, notes)
+		}
+	}
+
+	if _, err := server.ExplainQuery(ExplainQueryParams{Query: "service:(a OR b"}); err == nil {
+		t.Fatal("expected an unbalanced query to be rejected")
+	}
+}
+
+func TestExplainMetricQuery(t *testing.T) {
+	server := &MCPServer{}
+	result, err := server.ExplainQuery(ExplainQueryParams{
+		Query: "sum:trace.http.request.errors{env:prod,!service:web-*} by {service}.as_count() / sum:trace.http.request.hits{env:prod} by {service}.as_count() * 100",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Kind != "metric" || result.Expression != "a / b * 100" || len(result.Metrics) != 2 {
+		t.Fatalf("unexpected explanation: %+v", result)
+	}
+	errors := result.Metrics[0]
+	if errors.Metric != "trace.http.request.errors" || errors.SpaceAggregation != "sum" || errors.GroupBy[0] != "service" || errors.Modifiers[0] != "as_count()" {
+		t.Fatalf("unexpected part: %+v", errors)
+	}
+	if errors.ScopeStructure != "env:prod AND NOT service:web-*" || !errors.Scope[1].Negated || !errors.Scope[1].Wildcard || errors.Scope[1].Type != "tag" {
+		t.Fatalf("unexpected scope: %q %+v", errors.ScopeStructure, errors.Scope)
+	}
+
+	result, err = server.ExplainQuery(ExplainQueryParams{Query: "top(avg:system.cpu.user{*} by {host}.rollup(max, 60), 10, 'mean', 'desc')"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cpu := result.Metrics[0]
+	if cpu.Functions[0] != "top(..., 10, 'mean', 'desc')" || cpu.Rollup != "max,60" || cpu.ScopeStructure != "*" {
+		t.Fatalf("unexpected part: %+v", cpu)
+	}
+
+	if _, err := server.ExplainQuery(ExplainQueryParams{Query: "system.cpu.user", Kind: "metric"}); err == nil {
+		t.Fatal("expected a metric query without a scope to be rejected")
+	}
+}
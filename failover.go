@@ -0,0 +1,271 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultFailoverAfter    = 3
+	defaultFailoverCooldown = 5 * time.Minute
+)
+
+// failoverTransport keeps reads working while the primary keys fail, as
+// during a key rotation. A read made with the primary keys that fails
+// with an auth error or a 5xx is retried with the secondary keys, or on
+// the secondary site. Once the primary has failed after times in a row,
+// reads go straight to the secondary until cooldown has passed. Writes
+// always use the keys they were made with.
+type failoverTransport struct {
+	base          http.RoundTripper
+	primary       datadogCredentials
+	secondary     datadogCredentials
+	primarySite   string
+	secondarySite string
+	after         int
+	cooldown      time.Duration
+	now           func() time.Time
+
+	mu       sync.Mutex
+	failures int
+	until    time.Time
+	reason   string
+	served   int
+}
+
+// loadFailover reads the secondary key pair and site. It returns nil
+// when none is configured.
+func loadFailover(base http.RoundTripper, primary datadogCredentials, site string) (*failoverTransport, error) {
+	apiKey := os.Getenv("DD_MCP_SECONDARY_API_KEY")
+	appKey := os.Getenv("DD_MCP_SECONDARY_APP_KEY")
+	secondarySite := normalizeSite(os.Getenv("DD_MCP_SECONDARY_SITE"))
+	if apiKey == "" && appKey == "" && secondarySite == "" {
+		return nil, nil
+	}
+	if primary.APIKey == "" {
+		return nil, fmt.Errorf("DD_MCP_SECONDARY_* needs DD_API_KEY; gateway mode has no shared keys to fail over from")
+	}
+	t := &failoverTransport{
+		base:          base,
+		primary:       primary,
+		secondary:     datadogCredentials{APIKey: cmp.Or(apiKey, primary.APIKey), AppKey: cmp.Or(appKey, primary.AppKey)},
+		primarySite:   cmp.Or(site, "datadoghq.com"),
+		secondarySite: cmp.Or(secondarySite, site, "datadoghq.com"),
+		after:         defaultFailoverAfter,
+		cooldown:      defaultFailoverCooldown,
+		now:           time.Now,
+	}
+	if t.secondary == primary && t.secondarySite == t.primarySite {
+		return nil, fmt.Errorf("the secondary keys and site are the same as the primary ones")
+	}
+	if value := os.Getenv("DD_MCP_FAILOVER_AFTER"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid DD_MCP_FAILOVER_AFTER: %s", value)
+		}
+		t.after = n
+	}
+	if value := os.Getenv("DD_MCP_FAILOVER_COOLDOWN"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid DD_MCP_FAILOVER_COOLDOWN: %s", value)
+		}
+		t.cooldown = d
+	}
+	return t, nil
+}
+
+func (t *failoverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Calls for other orgs and gateway users carry their own keys.
+	if !hedgeable(req) || req.Header.Get("DD-API-KEY") != t.primary.APIKey {
+		return t.base.RoundTrip(req)
+	}
+	if reason, active := t.active(); active {
+		resp, err := t.base.RoundTrip(t.toSecondary(req))
+		if err == nil {
+			t.record(req.Context(), reason)
+		}
+		return resp, err
+	}
+
+	retry, cloneErr := cloneRequest(req)
+	resp, err := t.base.RoundTrip(req)
+	reason := failoverReason(resp, err)
+	if reason == "" {
+		t.succeeded()
+		return resp, err
+	}
+	if req.Context().Err() != nil || cloneErr != nil {
+		return resp, err
+	}
+	t.failed(reason)
+
+	secondary, secondaryErr := t.base.RoundTrip(t.toSecondary(retry))
+	if secondaryErr != nil || failoverReason(secondary, nil) != "" {
+		// The primary's failure is the one to report.
+		drainResponse(secondary)
+		return resp, err
+	}
+	drainResponse(resp)
+	t.record(req.Context(), reason)
+	return secondary, nil
+}
+
+// failoverReason describes a response that the secondary may answer
+// instead: an auth failure or a server error. Network errors aren't
+// counted, since they are as likely to be local.
+func failoverReason(resp *http.Response, err error) string {
+	if err != nil || resp == nil {
+		return ""
+	}
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden || resp.StatusCode >= 500 {
+		return resp.Status
+	}
+	return ""
+}
+
+// toSecondary returns a copy of req made with the secondary keys and
+// site.
+func (t *failoverTransport) toSecondary(req *http.Request) *http.Request {
+	r := req.Clone(req.Context())
+	r.Header.Set("DD-API-KEY", t.secondary.APIKey)
+	if t.secondary.AppKey != "" {
+		r.Header.Set("DD-APPLICATION-KEY", t.secondary.AppKey)
+	}
+	if t.secondarySite != t.primarySite {
+		if host, ok := strings.CutSuffix(r.URL.Host, t.primarySite); ok {
+			r.URL.Host = host + t.secondarySite
+			r.Host = ""
+		}
+	}
+	return r
+}
+
+// active reports whether reads currently skip the primary, and why.
+func (t *failoverTransport) active() (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.until.IsZero() {
+		return "", false
+	}
+	if t.now().Before(t.until) {
+		return t.reason, true
+	}
+	// The cooldown is over; try the primary again.
+	t.until = time.Time{}
+	t.failures = 0
+	return "", false
+}
+
+func (t *failoverTransport) failed(reason string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.failures++
+	t.reason = reason
+	if t.failures >= t.after {
+		t.until = t.now().Add(t.cooldown)
+	}
+}
+
+func (t *failoverTransport) succeeded() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.failures = 0
+}
+
+// record counts a read the secondary served and notes it for the tool
+// call that made it.
+func (t *failoverTransport) record(ctx context.Context, reason string) {
+	t.mu.Lock()
+	t.served++
+	t.mu.Unlock()
+	if report, ok := ctx.Value(failoverReportKey{}).(*failoverReport); ok {
+		report.note(t.describe(reason))
+	}
+}
+
+func (t *failoverTransport) describe(reason string) string {
+	target := "the secondary API key"
+	if t.secondarySite != t.primarySite {
+		target = "the secondary site " + t.secondarySite
+	}
+	return fmt.Sprintf("The primary Datadog keys failed with %s, so reads were served by %s.", reason, target)
+}
+
+// FailoverStats reports failover for server_stats.
+type FailoverStats struct {
+	// Active is set while reads skip the primary keys.
+	Active bool   `json:"active"`
+	Until  string `json:"until,omitempty"`
+	Reason string `json:"reason,omitempty"`
+	// Served counts the reads the secondary answered.
+	Served int `json:"served"`
+}
+
+func (t *failoverTransport) stats() *FailoverStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	stats := &FailoverStats{Served: t.served, Reason: t.reason}
+	if !t.until.IsZero() && t.now().Before(t.until) {
+		stats.Active = true
+		stats.Until = t.until.UTC().Format(time.RFC3339)
+	}
+	return stats
+}
+
+type failoverReportKey struct{}
+
+// failoverReport collects what one tool call's reads failed over for.
+type failoverReport struct {
+	mu    sync.Mutex
+	notes []string
+}
+
+func (r *failoverReport) note(text string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.notes {
+		if existing == text {
+			return
+		}
+	}
+	r.notes = append(r.notes, text)
+}
+
+// withFailoverReport returns a copy of the server whose reads note any
+// failover for a single tool call.
+func (s *MCPServer) withFailoverReport() *MCPServer {
+	if s.failover == nil {
+		return s
+	}
+	reporting := *s
+	reporting.failoverReport = &failoverReport{}
+	reporting.ctx = context.WithValue(s.ctx, failoverReportKey{}, reporting.failoverReport)
+	return &reporting
+}
+
+// failoverNotes returns what the call's reads noted.
+func (s *MCPServer) failoverNotes() []string {
+	if s.failoverReport == nil {
+		return nil
+	}
+	s.failoverReport.mu.Lock()
+	defer s.failoverReport.mu.Unlock()
+	return append([]string(nil), s.failoverReport.notes...)
+}
+
+// drainResponse releases a response that won't be returned.
+func drainResponse(resp *http.Response) {
+	if resp != nil {
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+}
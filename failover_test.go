@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadog"
+)
+
+func TestFailoverToSecondaryKeys(t *testing.T) {
+	var primaryCalls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Header.Get("DD-API-KEY") {
+		case "primary":
+			primaryCalls.Add(1)
+			http.Error(w, `{"errors":["Unauthorized"]}`, http.StatusUnauthorized)
+		case "secondary":
+			if r.Header.Get("DD-APPLICATION-KEY") != "app-2" {
+				t.Errorf("expected the secondary application key, got %q", r.Header.Get("DD-APPLICATION-KEY"))
+			}
+			if r.Method != http.MethodGet {
+				t.Errorf("expected only reads to fail over, got %s %s", r.Method, r.URL.Path)
+			}
+			_, _ = w.Write([]byte(`{"id":7,"name":"Checkout errors","type":"query alert","query":"avg(last_5m):avg:checkout.errors{*} > 5","overall_state":"OK"}`))
+		default:
+			http.Error(w, `{"errors":["Forbidden"]}`, http.StatusForbidden)
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	failover := &failoverTransport{
+		base:          http.DefaultTransport,
+		primary:       datadogCredentials{APIKey: "primary", AppKey: "app-1"},
+		secondary:     datadogCredentials{APIKey: "secondary", AppKey: "app-2"},
+		primarySite:   "datadoghq.com",
+		secondarySite: "datadoghq.com",
+		after:         2,
+		cooldown:      time.Minute,
+		now:           func() time.Time { return now },
+	}
+	configuration := datadog.NewConfiguration()
+	configuration.Servers = datadog.ServerConfigurations{{URL: ts.URL}}
+	configuration.HTTPClient = &http.Client{Transport: failover}
+	server := &MCPServer{
+		ddClient:    datadog.NewAPIClient(configuration),
+		credentials: failover.primary,
+		failover:    failover,
+	}
+
+	call := func() ToolCallResult {
+		t.Helper()
+		resp := server.HandleRequest(MCPRequest{Jsonrpc: "2.0", ID: 1, Method: "tools/call", Params: json.RawMessage(`{"name":"get_monitor","arguments":{"monitor_id":7}}`)})
+		if resp.Error != nil {
+			t.Fatalf("expected the secondary keys to answer, got %v", resp.Error.Message)
+		}
+		var result ToolCallResult
+		if err := json.Unmarshal(resp.Result, &result); err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	result := call()
+	if !strings.Contains(result.Content[0].Text, "Checkout errors") {
+		t.Fatalf("unexpected result %s", result.Content[0].Text)
+	}
+	if result.Meta == nil || len(result.Meta.Failover) != 1 || !strings.Contains(result.Meta.Failover[0], "401 Unauthorized") {
+		t.Fatalf("expected the failover to be reported, got %+v", result.Meta)
+	}
+	if primaryCalls.Load() != 1 || server.Stats().Failover.Active {
+		t.Fatalf("expected one failure not to switch over yet, got %d calls and %+v", primaryCalls.Load(), server.Stats().Failover)
+	}
+
+	call()
+	call()
+	if primaryCalls.Load() != 2 {
+		t.Fatalf("expected reads to skip the primary after two failures, got %d primary calls", primaryCalls.Load())
+	}
+	stats := server.Stats().Failover
+	if !stats.Active || stats.Served != 3 || stats.Reason != "401 Unauthorized" {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	now = now.Add(2 * time.Minute)
+	call()
+	if primaryCalls.Load() != 3 {
+		t.Fatalf("expected the primary to be tried again after the cooldown, got %d calls", primaryCalls.Load())
+	}
+
+	// Writes and other orgs' keys never fail over.
+	post, _ := http.NewRequest(http.MethodPost, ts.URL+"/api/v1/monitor/7/mute", strings.NewReader("{}"))
+	post.Header.Set("DD-API-KEY", "primary")
+	if resp, err := failover.RoundTrip(post); err != nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected the write to keep the primary's failure, got %v %v", resp, err)
+	}
+	other, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, ts.URL+"/api/v1/monitor/7", nil)
+	other.Header.Set("DD-API-KEY", "other-org")
+	if resp, err := failover.RoundTrip(other); err != nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected another org's read to be left alone, got %v %v", resp, err)
+	}
+}
+
+func TestFailoverSecondarySite(t *testing.T) {
+	failover := &failoverTransport{
+		primary:       datadogCredentials{APIKey: "primary"},
+		secondary:     datadogCredentials{APIKey: "primary"},
+		primarySite:   "datadoghq.com",
+		secondarySite: "us3.datadoghq.com",
+	}
+	req, _ := http.NewRequest(http.MethodGet, "https://api.datadoghq.com/api/v1/monitor/7", nil)
+	if host := failover.toSecondary(req).URL.Host; host != "api.us3.datadoghq.com" {
+		t.Fatalf("expected the secondary site's host, got %s", host)
+	}
+	if note := failover.describe("503 Service Unavailable"); !strings.Contains(note, "us3.datadoghq.com") {
+		t.Fatalf("expected the note to name the site, got %q", note)
+	}
+}
+
+func TestLoadFailover(t *testing.T) {
+	primary := datadogCredentials{APIKey: "primary", AppKey: "app-1"}
+	if f, err := loadFailover(nil, primary, ""); f != nil || err != nil {
+		t.Fatalf("expected no failover without a secondary, got %v %v", f, err)
+	}
+
+	t.Setenv("DD_MCP_SECONDARY_APP_KEY", "app-2")
+	t.Setenv("DD_MCP_FAILOVER_AFTER", "5")
+	f, err := loadFailover(nil, primary, "eu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.secondary.APIKey != "primary" || f.secondary.AppKey != "app-2" || f.after != 5 || f.secondarySite != "eu" {
+		t.Fatalf("unexpected failover %+v", f)
+	}
+	if _, err := loadFailover(nil, datadogCredentials{}, ""); err == nil {
+		t.Fatal("expected gateway mode to be rejected")
+	}
+
+	t.Setenv("DD_MCP_SECONDARY_APP_KEY", "app-1")
+	if _, err := loadFailover(nil, primary, ""); err == nil {
+		t.Fatal("expected a secondary identical to the primary to be rejected")
+	}
+}
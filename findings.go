@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV2"
+)
+
+const (
+	defaultFindingLimit = 100
+	maxFindingLimit     = 1000
+)
+
+// findingOperations are the posture management calls the tools make,
+// which the client marks unstable.
+var findingOperations = []string{"v2.ListFindings"}
+
+type ListSecurityFindingsParams struct {
+	// Status is the finding's severity, such as "critical".
+	Status string `json:"status,omitempty"`
+	// Evaluation is "pass" or "fail".
+	Evaluation string `json:"evaluation,omitempty"`
+	// ResourceType is a type such as "aws_s3_bucket".
+	ResourceType string `json:"resource_type,omitempty"`
+	RuleID       string `json:"rule_id,omitempty"`
+	// Muted keeps only muted, or only unmuted, findings.
+	Muted *bool    `json:"muted,omitempty"`
+	Tags  []string `json:"tags,omitempty"`
+	Limit int64    `json:"limit,omitempty"`
+	// Cursor continues from a previous call's NextCursor.
+	Cursor string `json:"cursor,omitempty"`
+}
+
+type SecurityFinding struct {
+	ID           string `json:"id"`
+	Rule         string `json:"rule"`
+	RuleID       string `json:"rule_id,omitempty"`
+	Status       string `json:"status"`
+	Evaluation   string `json:"evaluation"`
+	Resource     string `json:"resource"`
+	ResourceType string `json:"resource_type"`
+	Muted        bool   `json:"muted,omitempty"`
+	// EvaluationChanged is when the finding last passed or failed anew.
+	EvaluationChanged string   `json:"evaluation_changed,omitempty"`
+	Tags              []string `json:"tags,omitempty"`
+	URL               string   `json:"url,omitempty"`
+}
+
+type ListSecurityFindingsResult struct {
+	// Findings are failing first, then by severity.
+	Findings []SecurityFinding `json:"findings"`
+	Count    int               `json:"count"`
+	// Total is how many findings match, across all pages.
+	Total          int64          `json:"total,omitempty"`
+	ByStatus       map[string]int `json:"by_status,omitempty"`
+	ByResourceType map[string]int `json:"by_resource_type,omitempty"`
+	// Snapshot is the time of the posture evaluation the findings are from.
+	Snapshot   string   `json:"snapshot,omitempty"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+	URL        string   `json:"url"`
+	Notes      []string `json:"notes,omitempty"`
+}
+
+// ListSecurityFindings lists Cloud Security posture findings: resources
+// evaluated against compliance rules, and whether they pass.
+func (s *MCPServer) ListSecurityFindings(params ListSecurityFindingsParams) (*ListSecurityFindingsResult, error) {
+	limit := params.Limit
+	if limit <= 0 {
+		limit = defaultFindingLimit
+	}
+	limit = clampArgument(s, "limit", limit, maxFindingLimit)
+	opts := datadogV2.NewListFindingsOptionalParameters().WithPageLimit(limit)
+	if params.Status != "" {
+		status, err := datadogV2.NewFindingStatusFromValue(strings.ToLower(params.Status))
+		if err != nil {
+			return nil, fmt.Errorf("invalid status: %s (use %s)", params.Status, strings.Join(securitySeverities, ", "))
+		}
+		opts = opts.WithFilterStatus(*status)
+	}
+	if params.Evaluation != "" {
+		evaluation, err := datadogV2.NewFindingEvaluationFromValue(strings.ToLower(params.Evaluation))
+		if err != nil {
+			return nil, fmt.Errorf("invalid evaluation: %s (use pass or fail)", params.Evaluation)
+		}
+		opts = opts.WithFilterEvaluation(*evaluation)
+	}
+	if params.ResourceType != "" {
+		opts = opts.WithFilterResourceType(params.ResourceType)
+	}
+	if params.RuleID != "" {
+		opts = opts.WithFilterRuleId(params.RuleID)
+	}
+	if params.Muted != nil {
+		opts = opts.WithFilterMuted(*params.Muted)
+	}
+	if len(params.Tags) > 0 {
+		opts = opts.WithFilterTags(strings.Join(params.Tags, ","))
+	}
+	if params.Cursor != "" {
+		opts = opts.WithPageCursor(params.Cursor)
+	}
+
+	resp, _, err := datadogV2.NewSecurityMonitoringApi(s.ddClient).ListFindings(s.ctx, *opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list security findings: %w", err)
+	}
+
+	query := url.Values{}
+	if params.Evaluation != "" {
+		query.Set("query", "@evaluation:"+strings.ToLower(params.Evaluation))
+	}
+	result := &ListSecurityFindingsResult{
+		Findings: make([]SecurityFinding, 0, len(resp.Data)),
+		URL:      s.appURL("/security/compliance?" + query.Encode()),
+	}
+	for _, finding := range resp.Data {
+		attrs := finding.Attributes
+		if attrs == nil {
+			continue
+		}
+		entry := SecurityFinding{
+			ID:           finding.GetId(),
+			Status:       string(attrs.GetStatus()),
+			Evaluation:   string(attrs.GetEvaluation()),
+			Resource:     attrs.GetResource(),
+			ResourceType: attrs.GetResourceType(),
+			Muted:        attrs.Mute != nil && attrs.Mute.GetMuted(),
+			Tags:         attrs.Tags,
+			URL:          attrs.GetDatadogLink(),
+		}
+		if rule := attrs.Rule; rule != nil {
+			entry.Rule, entry.RuleID = rule.GetName(), rule.GetId()
+		}
+		if changed := attrs.GetEvaluationChangedAt(); changed > 0 {
+			entry.EvaluationChanged = time.UnixMilli(changed).UTC().Format(time.RFC3339)
+		}
+		result.Findings = append(result.Findings, entry)
+		if result.ByStatus == nil {
+			result.ByStatus, result.ByResourceType = make(map[string]int), make(map[string]int)
+		}
+		result.ByStatus[entry.Status]++
+		result.ByResourceType[entry.ResourceType]++
+	}
+	sort.SliceStable(result.Findings, func(i, j int) bool {
+		a, b := result.Findings[i], result.Findings[j]
+		if (a.Evaluation == "fail") != (b.Evaluation == "fail") {
+			return a.Evaluation == "fail"
+		}
+		return slices.Index(securitySeverities, a.Status) > slices.Index(securitySeverities, b.Status)
+	})
+	result.Count = len(result.Findings)
+	if page := resp.Meta.Page; page != nil {
+		result.Total = page.GetTotalFilteredCount()
+		if page.GetCursor() != "" && int64(result.Count) >= limit {
+			result.NextCursor = page.GetCursor()
+			result.Notes = append(result.Notes, "More findings match; pass next_cursor as cursor for the next page.")
+		}
+	}
+	if snapshot := resp.Meta.GetSnapshotTimestamp(); snapshot > 0 {
+		result.Snapshot = time.UnixMilli(snapshot).UTC().Format(time.RFC3339)
+	}
+	if result.Count == 0 {
+		result.Notes = append(result.Notes, "No findings match. Findings need Cloud Security Misconfigurations enabled for the org's cloud accounts or hosts.")
+	}
+	return result, nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestListSecurityFindings(t *testing.T) {
+	var query url.Values
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/api/v2/posture_management/findings" {
+			http.NotFound(w, r)
+			return
+		}
+		query = r.URL.Query()
+		_, _ = w.Write([]byte(`{"data":[
+			{"id":"f1","type":"finding","attributes":{"evaluation":"pass","status":"critical","resource":"arn:aws:s3:::logs","resource_type":"aws_s3_bucket",
+				"rule":{"id":"r1","name":"S3 bucket is not public"},"datadog_link":"/security/compliance?finding=f1"}},
+			{"id":"f2","type":"finding","attributes":{"evaluation":"fail","status":"low","resource":"arn:aws:s3:::tmp","resource_type":"aws_s3_bucket",
+				"rule":{"id":"r2","name":"S3 bucket has versioning"},"mute":{"muted":true},"evaluation_changed_at":1768899780000}},
+			{"id":"f3","type":"finding","attributes":{"evaluation":"fail","status":"high","resource":"sg-123","resource_type":"aws_security_group",
+				"rule":{"id":"r3","name":"Security group allows SSH from anywhere"}}}],
+			"meta":{"page":{"cursor":"next-1","total_filtered_count":42},"snapshot_timestamp":1768899000000}}`))
+	})
+
+	result, err := server.ListSecurityFindings(ListSecurityFindingsParams{Evaluation: "FAIL", Status: "high", ResourceType: "aws_s3_bucket", Tags: []string{"env:prod"}, Limit: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query.Get("filter[evaluation]") != "fail" || query.Get("filter[status]") != "high" || query.Get("filter[resource_type]") != "aws_s3_bucket" ||
+		query.Get("filter[tags]") != "env:prod" || query.Get("page[limit]") != "3" {
+		t.Fatalf("unexpected query %v", query)
+	}
+	if result.Count != 3 || result.Total != 42 || result.NextCursor != "next-1" || result.Snapshot != "2026-01-20T08:50:00Z" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if ids := []string{result.Findings[0].ID, result.Findings[1].ID, result.Findings[2].ID}; ids[0] != "f3" || ids[1] != "f2" || ids[2] != "f1" {
+		t.Errorf("expected failing findings first by severity, got %v", ids)
+	}
+	second := result.Findings[1]
+	if !second.Muted || second.Rule != "S3 bucket has versioning" || second.RuleID != "r2" || second.EvaluationChanged != "2026-01-20T09:03:00Z" {
+		t.Errorf("unexpected finding %+v", second)
+	}
+	if result.ByStatus["critical"] != 1 || result.ByResourceType["aws_s3_bucket"] != 2 {
+		t.Errorf("unexpected counts %v %v", result.ByStatus, result.ByResourceType)
+	}
+
+	if _, err := server.ListSecurityFindings(ListSecurityFindingsParams{Evaluation: "maybe"}); err == nil {
+		t.Fatal("expected an unknown evaluation to be rejected")
+	}
+	if _, err := server.ListSecurityFindings(ListSecurityFindingsParams{Status: "severe"}); err == nil {
+		t.Fatal("expected an unknown status to be rejected")
+	}
+}
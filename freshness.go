@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// indexingDelays is roughly how long Datadog takes to make new data
+// searchable, by kind. Ranges ending closer to now than this may be
+// missing their most recent data.
+var indexingDelays = map[string]time.Duration{
+	"logs":    time.Minute,
+	"events":  time.Minute,
+	"metrics": 2 * time.Minute,
+}
+
+// Freshness tells the caller how current a result is: when the query ran,
+// how long it took, the newest data point Datadog returned and whether the
+// range ends too recently for everything to be indexed.
+type Freshness struct {
+	QueriedAt     string `json:"queried_at"`
+	QueryDuration string `json:"query_duration"`
+	LatestData    string `json:"latest_data,omitempty"`
+	LatestDataAge string `json:"latest_data_age,omitempty"`
+	Note          string `json:"note,omitempty"`
+}
+
+// newFreshness describes a query of kind that started at started and
+// covered data up to to. latest is the newest timestamp in the result, if
+// any.
+func newFreshness(kind string, started, to time.Time, latest *time.Time) *Freshness {
+	now := time.Now()
+	f := &Freshness{
+		QueriedAt:     started.UTC().Format(time.RFC3339),
+		QueryDuration: now.Sub(started).Round(time.Millisecond).String(),
+	}
+	if latest != nil && !latest.IsZero() {
+		f.LatestData = latest.UTC().Format(time.RFC3339)
+		f.LatestDataAge = max(now.Sub(*latest), 0).Round(time.Second).String()
+	}
+	if delay, ok := indexingDelays[kind]; ok && started.Sub(to) < delay {
+		f.Note = fmt.Sprintf("The range includes the last %s, within Datadog's typical indexing delay for %s, so the most recent %s may not be searchable yet.", delay, kind, kind)
+	}
+	return f
+}
+
+// latestTime returns the newest of times, or nil when there are none.
+func latestTime(times ...*time.Time) *time.Time {
+	var latest *time.Time
+	for _, t := range times {
+		if t != nil && (latest == nil || t.After(*latest)) {
+			latest = t
+		}
+	}
+	return latest
+}
+
+// latestPoint returns the newest observed point in series, ignoring
+// projected points past now.
+func latestPoint(series []BandSeries, now time.Time) *time.Time {
+	var latest *time.Time
+	for _, s := range series {
+		for i := range s.Points {
+			p := &s.Points[i]
+			if p.Value != nil && !p.Timestamp.After(now) && (latest == nil || p.Timestamp.After(*latest)) {
+				latest = &p.Timestamp
+			}
+		}
+	}
+	return latest
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewFreshness(t *testing.T) {
+	started := time.Now().Add(-250 * time.Millisecond)
+	latest := started.Add(-90 * time.Second)
+
+	f := newFreshness("logs", started, started, &latest)
+	if f.QueriedAt != started.UTC().Format(time.RFC3339) {
+		t.Errorf("expected queried_at %s, got %s", started.UTC().Format(time.RFC3339), f.QueriedAt)
+	}
+	if d, err := time.ParseDuration(f.QueryDuration); err != nil || d < 250*time.Millisecond {
+		t.Errorf("expected a query duration of at least 250ms, got %q", f.QueryDuration)
+	}
+	if f.LatestData != latest.UTC().Format(time.RFC3339) || !strings.HasPrefix(f.LatestDataAge, "1m3") {
+		t.Errorf("unexpected latest data %q aged %q", f.LatestData, f.LatestDataAge)
+	}
+	if !strings.Contains(f.Note, "indexing delay for logs") {
+		t.Errorf("expected an indexing delay note for a range ending now, got %q", f.Note)
+	}
+
+	f = newFreshness("metrics", started, started.Add(-time.Hour), nil)
+	if f.Note != "" || f.LatestData != "" {
+		t.Errorf("expected no note or latest data for an older range, got %+v", f)
+	}
+}
+
+func TestLatestPointIgnoresProjections(t *testing.T) {
+	now := time.Now()
+	value := 1.0
+	series := []BandSeries{{Points: []BandPoint{
+		{Timestamp: now.Add(-2 * time.Minute), Value: &value},
+		{Timestamp: now.Add(-time.Minute)},
+		{Timestamp: now.Add(time.Hour), Value: &value},
+	}}}
+	if got := latestPoint(series, now); got == nil || !got.Equal(now.Add(-2*time.Minute)) {
+		t.Fatalf("expected the last observed point, got %v", got)
+	}
+}
+
+func TestQueryLogsReportsFreshness(t *testing.T) {
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":[
+			{"id":"1","attributes":{"message":"a","timestamp":"2026-01-20T10:00:00Z"}},
+			{"id":"2","attributes":{"message":"b","timestamp":"2026-01-20T10:05:00Z"}}]}`))
+	})
+
+	result, err := server.QueryLogs(QueryLogsParams{Query: "*", From: "2026-01-20T09:00:00Z", To: "2026-01-20T11:00:00Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Freshness == nil || result.Freshness.LatestData != "2026-01-20T10:05:00Z" {
+		t.Fatalf("expected the newest log as latest data, got %+v", result.Freshness)
+	}
+	if result.Freshness.Note != "" {
+		t.Fatalf("expected no indexing note for a past range, got %q", result.Freshness.Note)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/oschwald/maxminddb-golang/v2"
+)
+
+// clientIPAttributes are the log attributes, as dotted paths, that hold
+// the client's IP address, in the order they are tried.
+var clientIPAttributes = []string{"network.client.ip", "http.client_ip", "client_ip"}
+
+// ClientLocation is where a log's client IP is registered, looked up in
+// the operator's local GeoIP databases.
+type ClientLocation struct {
+	IP      string `json:"ip"`
+	Country string `json:"country,omitempty"`
+	ASN     uint   `json:"asn,omitempty"`
+	ASOrg   string `json:"as_org,omitempty"`
+}
+
+// geoIPRecord reads the fields of the MaxMind Country, City and ASN
+// databases and compatible ones; each database fills what it has.
+type geoIPRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	ASN   uint   `maxminddb:"autonomous_system_number"`
+	ASOrg string `maxminddb:"autonomous_system_organization"`
+}
+
+// geoIPDatabases are the local MMDB files client IPs are looked up in, so
+// no IP leaves the host. A nil set looks up nothing.
+type geoIPDatabases struct {
+	readers []*maxminddb.Reader
+}
+
+// loadGeoIP opens the comma-separated database paths, such as a country
+// database and an ASN one.
+func loadGeoIP(paths string) (*geoIPDatabases, error) {
+	databases := &geoIPDatabases{}
+	for _, path := range strings.Split(paths, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		reader, err := maxminddb.Open(path)
+		if err != nil {
+			databases.Close()
+			return nil, fmt.Errorf("failed to open GeoIP database %s: %w", path, err)
+		}
+		databases.readers = append(databases.readers, reader)
+	}
+	if len(databases.readers) == 0 {
+		return nil, fmt.Errorf("no GeoIP database paths given")
+	}
+	return databases, nil
+}
+
+func (g *geoIPDatabases) Close() {
+	if g == nil {
+		return
+	}
+	for _, reader := range g.readers {
+		_ = reader.Close()
+	}
+}
+
+// lookup returns where ip is registered, or nil when it isn't a public
+// address or no database knows it.
+func (g *geoIPDatabases) lookup(ip netip.Addr) *ClientLocation {
+	if g == nil || !ip.IsValid() || ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() || ip.IsMulticast() {
+		return nil
+	}
+	ip = ip.Unmap()
+	location := &ClientLocation{IP: ip.String()}
+	for _, reader := range g.readers {
+		var record geoIPRecord
+		if err := reader.Lookup(ip).Decode(&record); err != nil {
+			continue
+		}
+		if location.Country == "" {
+			location.Country = record.Country.ISOCode
+		}
+		if location.ASN == 0 {
+			location.ASN, location.ASOrg = record.ASN, record.ASOrg
+		}
+	}
+	if location.Country == "" && location.ASN == 0 {
+		return nil
+	}
+	return location
+}
+
+// enrich looks up the client IP found in a log's attributes.
+func (g *geoIPDatabases) enrich(attributes map[string]interface{}) *ClientLocation {
+	if g == nil {
+		return nil
+	}
+	for _, path := range clientIPAttributes {
+		value, _ := attributePath(attributes, path).(string)
+		if ip, err := netip.ParseAddr(strings.TrimSpace(value)); err == nil {
+			return g.lookup(ip)
+		}
+	}
+	return nil
+}
+
+// attributePath reads a dotted attribute path, such as
+// "network.client.ip", whether the attributes nest it or hold the dotted
+// key as is.
+func attributePath(attributes map[string]interface{}, path string) interface{} {
+	if value, ok := attributes[path]; ok {
+		return value
+	}
+	head, rest, found := strings.Cut(path, ".")
+	if !found {
+		return nil
+	}
+	nested, ok := attributes[head].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	return attributePath(nested, rest)
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/http"
+	"net/netip"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// mmdbValue encodes a value in the MaxMind DB data format.
+func mmdbValue(v interface{}) []byte {
+	var buf bytes.Buffer
+	switch v := v.(type) {
+	case string:
+		if len(v) < 29 {
+			buf.WriteByte(2<<5 | byte(len(v)))
+		} else {
+			buf.Write([]byte{2<<5 | 29, byte(len(v) - 29)})
+		}
+		buf.WriteString(v)
+	case uint16:
+		buf.WriteByte(5<<5 | 2)
+		_ = binary.Write(&buf, binary.BigEndian, v)
+	case uint32:
+		buf.WriteByte(6<<5 | 4)
+		_ = binary.Write(&buf, binary.BigEndian, v)
+	case uint64:
+		buf.WriteByte(8)
+		buf.WriteByte(9 - 7)
+		_ = binary.Write(&buf, binary.BigEndian, v)
+	case []interface{}:
+		buf.WriteByte(byte(len(v)))
+		buf.WriteByte(11 - 7)
+		for _, item := range v {
+			buf.Write(mmdbValue(item))
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		buf.WriteByte(7<<5 | byte(len(v)))
+		for _, key := range keys {
+			buf.Write(mmdbValue(key))
+			buf.Write(mmdbValue(v[key]))
+		}
+	default:
+		panic("unsupported mmdb value")
+	}
+	return buf.Bytes()
+}
+
+// writeTestMMDB writes an IPv4 MaxMind DB with 24-bit records mapping
+// each prefix to its record.
+func writeTestMMDB(t *testing.T, records map[string]map[string]interface{}) string {
+	t.Helper()
+	const child, data = 1, 2
+	type record struct{ kind, value int }
+	nodes := [][2]record{{}}
+	var section bytes.Buffer
+	for prefix, value := range records {
+		p := netip.MustParsePrefix(prefix)
+		offset := section.Len()
+		section.Write(mmdbValue(value))
+		ip := p.Addr().As4()
+		node := 0
+		for bit := 0; bit < p.Bits(); bit++ {
+			side := int(ip[bit/8]>>(7-bit%8)) & 1
+			if bit == p.Bits()-1 {
+				nodes[node][side] = record{data, offset}
+				break
+			}
+			if nodes[node][side].kind != child {
+				nodes = append(nodes, [2]record{})
+				nodes[node][side] = record{child, len(nodes) - 1}
+			}
+			node = nodes[node][side].value
+		}
+	}
+
+	var file bytes.Buffer
+	count := len(nodes)
+	for _, node := range nodes {
+		for _, r := range node {
+			value := count
+			switch r.kind {
+			case child:
+				value = r.value
+			case data:
+				value = count + 16 + r.value
+			}
+			file.Write([]byte{byte(value >> 16), byte(value >> 8), byte(value)})
+		}
+	}
+	file.Write(make([]byte, 16))
+	file.Write(section.Bytes())
+	file.WriteString("\xab\xcd\xefMaxMind.com")
+	file.Write(mmdbValue(map[string]interface{}{
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"build_epoch":                 uint64(1767225600),
+		"database_type":               "Test",
+		"description":                 map[string]interface{}{"en": "test"},
+		"ip_version":                  uint16(4),
+		"languages":                   []interface{}{"en"},
+		"node_count":                  uint32(count),
+		"record_size":                 uint16(24),
+	}))
+
+	return writeTempFile(t, "test.mmdb", file.String())
+}
+
+func testGeoIP(t *testing.T) *geoIPDatabases {
+	t.Helper()
+	country := writeTestMMDB(t, map[string]map[string]interface{}{
+		"81.2.69.0/24": {"country": map[string]interface{}{"iso_code": "GB"}},
+	})
+	asn := writeTestMMDB(t, map[string]map[string]interface{}{
+		"81.2.0.0/16": {"autonomous_system_number": uint32(20712), "autonomous_system_organization": "Andrews & Arnold"},
+	})
+	geoip, err := loadGeoIP(country + ", " + asn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(geoip.Close)
+	return geoip
+}
+
+func TestGeoIPEnrich(t *testing.T) {
+	geoip := testGeoIP(t)
+	cases := []struct {
+		attributes map[string]interface{}
+		want       *ClientLocation
+	}{
+		{map[string]interface{}{"network": map[string]interface{}{"client": map[string]interface{}{"ip": "81.2.69.160"}}},
+			&ClientLocation{IP: "81.2.69.160", Country: "GB", ASN: 20712, ASOrg: "Andrews & Arnold"}},
+		{map[string]interface{}{"http.client_ip": "81.2.70.1"}, &ClientLocation{IP: "81.2.70.1", ASN: 20712, ASOrg: "Andrews & Arnold"}},
+		{map[string]interface{}{"client_ip": "10.0.0.8"}, nil},
+		{map[string]interface{}{"client_ip": "8.8.8.8"}, nil},
+		{map[string]interface{}{"client_ip": "not an ip"}, nil},
+		{nil, nil},
+	}
+	for _, c := range cases {
+		got := geoip.enrich(c.attributes)
+		if (got == nil) != (c.want == nil) || (got != nil && *got != *c.want) {
+			t.Errorf("enrich(%v) = %+v, want %+v", c.attributes, got, c.want)
+		}
+	}
+
+	var none *geoIPDatabases
+	if none.enrich(cases[0].attributes) != nil {
+		t.Fatal("expected no enrichment without databases")
+	}
+	if _, err := loadGeoIP(filepath.Join(t.TempDir(), "missing.mmdb")); err == nil {
+		t.Fatal("expected a missing database to be rejected")
+	}
+}
+
+func TestQueryLogsGeoIP(t *testing.T) {
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":[
+			{"id":"a","attributes":{"message":"POST /login 401","service":"web","attributes":{"network":{"client":{"ip":"81.2.69.160"}}}}},
+			{"id":"b","attributes":{"message":"GET / 200","service":"web"}}]}`))
+	})
+	server.geoip = testGeoIP(t)
+
+	result, err := server.QueryLogs(QueryLogsParams{Query: "service:web"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client := result.Logs[0].Client; client == nil || client.Country != "GB" || client.ASN != 20712 {
+		t.Fatalf("expected the first log's client located, got %+v", client)
+	}
+	if result.Logs[1].Client != nil {
+		t.Fatalf("expected no client for a log without an IP, got %+v", result.Logs[1].Client)
+	}
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	handoffTimeout = 15 * time.Second
+	// handoffTranscriptCalls is how many recent tool calls a Jira ticket
+	// includes when asked to attach the session transcript.
+	handoffTranscriptCalls = 20
+	// maxSlackTextBytes keeps messages well under Slack's 40,000
+	// character limit for the text field.
+	maxSlackTextBytes = 35000
+)
+
+// handoffConfig holds the per-deployment Slack and Jira destinations.
+// Empty fields disable the matching tool.
+type handoffConfig struct {
+	SlackWebhookURL string
+	JiraURL         string
+	JiraEmail       string
+	JiraToken       string
+	JiraProject     string
+	JiraIssueType   string
+}
+
+func loadHandoffConfig() (handoffConfig, error) {
+	cfg := handoffConfig{
+		SlackWebhookURL: os.Getenv("DD_MCP_SLACK_WEBHOOK_URL"),
+		JiraURL:         strings.TrimRight(os.Getenv("DD_MCP_JIRA_URL"), "/"),
+		JiraEmail:       os.Getenv("DD_MCP_JIRA_EMAIL"),
+		JiraToken:       os.Getenv("DD_MCP_JIRA_API_TOKEN"),
+		JiraProject:     os.Getenv("DD_MCP_JIRA_PROJECT"),
+		JiraIssueType:   os.Getenv("DD_MCP_JIRA_ISSUE_TYPE"),
+	}
+	if cfg.JiraIssueType == "" {
+		cfg.JiraIssueType = "Task"
+	}
+	for name, value := range map[string]string{"DD_MCP_SLACK_WEBHOOK_URL": cfg.SlackWebhookURL, "DD_MCP_JIRA_URL": cfg.JiraURL} {
+		if value == "" {
+			continue
+		}
+		if u, err := url.Parse(value); err != nil || u.Scheme != "https" || u.Host == "" {
+			return handoffConfig{}, fmt.Errorf("invalid %s: must be an https URL", name)
+		}
+	}
+	if cfg.JiraURL != "" && (cfg.JiraEmail == "" || cfg.JiraToken == "") {
+		return handoffConfig{}, fmt.Errorf("DD_MCP_JIRA_EMAIL and DD_MCP_JIRA_API_TOKEN must be set with DD_MCP_JIRA_URL")
+	}
+	return cfg, nil
+}
+
+// HandoffParams is an investigation summary to hand off. Evidence holds
+// the individual findings and Links point back into Datadog.
+type HandoffParams struct {
+	Title    string   `json:"title"`
+	Summary  string   `json:"summary"`
+	Evidence []string `json:"evidence,omitempty"`
+	Links    []string `json:"links,omitempty"`
+}
+
+func (p HandoffParams) validate() error {
+	if strings.TrimSpace(p.Title) == "" || strings.TrimSpace(p.Summary) == "" {
+		return fmt.Errorf("title and summary parameters are required")
+	}
+	return nil
+}
+
+type SlackHandoffResult struct {
+	Posted bool   `json:"posted"`
+	Title  string `json:"title"`
+}
+
+type JiraHandoffParams struct {
+	HandoffParams
+	Project           string   `json:"project,omitempty"`
+	IssueType         string   `json:"issue_type,omitempty"`
+	Labels            []string `json:"labels,omitempty"`
+	IncludeTranscript bool     `json:"include_transcript,omitempty"`
+}
+
+type JiraHandoffResult struct {
+	Key string `json:"key"`
+	URL string `json:"url"`
+}
+
+// PostSlackSummary posts an investigation summary to the deployment's
+// Slack incoming webhook.
+func (s *MCPServer) PostSlackSummary(params HandoffParams) (*SlackHandoffResult, error) {
+	if err := s.requireWrites("post_slack_summary"); err != nil {
+		return nil, err
+	}
+	if s.handoff.SlackWebhookURL == "" {
+		return nil, fmt.Errorf("post_slack_summary is not configured; set DD_MCP_SLACK_WEBHOOK_URL")
+	}
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+
+	text, _ := truncateUTF8(slackMessage(params), maxSlackTextBytes)
+	body, _ := json.Marshal(map[string]string{"text": text})
+	if _, err := s.postHandoff(s.handoff.SlackWebhookURL, body, nil); err != nil {
+		return nil, fmt.Errorf("failed to post to Slack: %w", err)
+	}
+	return &SlackHandoffResult{Posted: true, Title: params.Title}, nil
+}
+
+// CreateJiraTicket files an issue carrying the investigation summary,
+// evidence and links, optionally with the session's recent tool calls.
+func (s *MCPServer) CreateJiraTicket(params JiraHandoffParams) (*JiraHandoffResult, error) {
+	if err := s.requireWrites("create_jira_ticket"); err != nil {
+		return nil, err
+	}
+	if s.handoff.JiraURL == "" {
+		return nil, fmt.Errorf("create_jira_ticket is not configured; set DD_MCP_JIRA_URL, DD_MCP_JIRA_EMAIL and DD_MCP_JIRA_API_TOKEN")
+	}
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+	project := params.Project
+	if project == "" {
+		project = s.handoff.JiraProject
+	}
+	if project == "" {
+		return nil, fmt.Errorf("project parameter is required when DD_MCP_JIRA_PROJECT is not set")
+	}
+	issueType := params.IssueType
+	if issueType == "" {
+		issueType = s.handoff.JiraIssueType
+	}
+
+	description := jiraDescription(params.HandoffParams)
+	if params.IncludeTranscript {
+		transcript, err := s.ExportSession(ExportSessionParams{Last: handoffTranscriptCalls})
+		if err == nil {
+			description += "\nh2. Session transcript\n{noformat}\n" + strings.ReplaceAll(transcript, "{noformat}", "{ noformat}") + "\n{noformat}\n"
+		}
+	}
+
+	fields := map[string]interface{}{
+		"project":     map[string]string{"key": project},
+		"issuetype":   map[string]string{"name": issueType},
+		"summary":     strings.Join(strings.Fields(params.Title), " "),
+		"description": description,
+	}
+	if len(params.Labels) > 0 {
+		fields["labels"] = params.Labels
+	}
+	body, _ := json.Marshal(map[string]interface{}{"fields": fields})
+	headers := map[string]string{"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte(s.handoff.JiraEmail+":"+s.handoff.JiraToken))}
+	resp, err := s.postHandoff(s.handoff.JiraURL+"/rest/api/2/issue", body, headers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Jira ticket: %w", err)
+	}
+
+	var created struct {
+		Key string `json:"key"`
+	}
+	if err := json.Unmarshal(resp, &created); err != nil || created.Key == "" {
+		return nil, fmt.Errorf("unexpected Jira response: %s", resp)
+	}
+	return &JiraHandoffResult{Key: created.Key, URL: s.handoff.JiraURL + "/browse/" + created.Key}, nil
+}
+
+// postHandoff POSTs a JSON body and returns the response body, failing on
+// any non-2xx status.
+func (s *MCPServer) postHandoff(target string, body []byte, headers map[string]string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	client := &http.Client{Timeout: handoffTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
+
+// slackMessage renders the handoff in Slack mrkdwn.
+func slackMessage(p HandoffParams) string {
+	escape := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%s*\n%s\n", escape(p.Title), escape(p.Summary))
+	if len(p.Evidence) > 0 {
+		b.WriteString("\n*Evidence*\n")
+		for _, e := range p.Evidence {
+			fmt.Fprintf(&b, "• %s\n", escape(e))
+		}
+	}
+	if len(p.Links) > 0 {
+		b.WriteString("\n*Links*\n")
+		for _, link := range p.Links {
+			fmt.Fprintf(&b, "• <%s>\n", strings.NewReplacer("<", "", ">", "", "|", "%7C").Replace(link))
+		}
+	}
+	return b.String()
+}
+
+// jiraDescription renders the handoff in Jira wiki markup.
+func jiraDescription(p HandoffParams) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n", p.Summary)
+	if len(p.Evidence) > 0 {
+		b.WriteString("\nh2. Evidence\n")
+		for _, e := range p.Evidence {
+			fmt.Fprintf(&b, "* %s\n", strings.ReplaceAll(e, "\n", " "))
+		}
+	}
+	if len(p.Links) > 0 {
+		b.WriteString("\nh2. Links\n")
+		for _, link := range p.Links {
+			fmt.Fprintf(&b, "* [%s]\n", strings.NewReplacer("[", "", "]", "", "|", "%7C").Replace(link))
+		}
+	}
+	return b.String()
+}
+
+// handoffTools are registered only when writes are enabled.
+var handoffTools = []Tool{
+	{
+		Name:        "post_slack_summary",
+		Description: "Post an investigation summary with evidence and Datadog links to the Slack channel configured for this deployment.",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]SchemaProperty{
+				"title": {
+					Type:        "string",
+					Description: "One-line headline of the finding",
+				},
+				"summary": {
+					Type:        "string",
+					Description: "What happened, the impact and the likely cause",
+				},
+				"evidence": {
+					Type:        "array",
+					Description: "Individual findings backing the summary, one per item",
+					Items:       &SchemaProperty{Type: "string"},
+				},
+				"links": {
+					Type:        "array",
+					Description: "Datadog links to the dashboards, monitors, logs or traces examined",
+					Items:       &SchemaProperty{Type: "string"},
+				},
+				"confirm": confirmProperty,
+			},
+			Required: []string{"title", "summary", "confirm"},
+		},
+		Annotations: writeToolAnnotations(false),
+	},
+	{
+		Name:        "create_jira_ticket",
+		Description: "Create a Jira ticket carrying an investigation summary, evidence and Datadog links, optionally with this session's transcript.",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]SchemaProperty{
+				"title": {
+					Type:        "string",
+					Description: "One-line headline of the finding",
+				},
+				"summary": {
+					Type:        "string",
+					Description: "What happened, the impact and the likely cause",
+				},
+				"evidence": {
+					Type:        "array",
+					Description: "Individual findings backing the summary, one per item",
+					Items:       &SchemaProperty{Type: "string"},
+				},
+				"links": {
+					Type:        "array",
+					Description: "Datadog links to the dashboards, monitors, logs or traces examined",
+					Items:       &SchemaProperty{Type: "string"},
+				},
+				"project": {
+					Type:        "string",
+					Description: "Jira project key (default: DD_MCP_JIRA_PROJECT)",
+				},
+				"issue_type": {
+					Type:        "string",
+					Description: "Jira issue type (default: DD_MCP_JIRA_ISSUE_TYPE, or Task)",
+				},
+				"labels": {
+					Type:        "array",
+					Description: "Labels to add to the ticket",
+					Items:       &SchemaProperty{Type: "string"},
+				},
+				"include_transcript": {
+					Type:        "boolean",
+					Description: "Append the session's last 20 tool calls and results (default: false)",
+				},
+				"confirm": confirmProperty,
+			},
+			Required: []string{"title", "summary", "confirm"},
+		},
+		Annotations: writeToolAnnotations(false),
+	},
+}
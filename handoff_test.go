@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPostSlackSummary(t *testing.T) {
+	var payload map[string]string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(ts.Close)
+
+	server := &MCPServer{allowWrites: true, ctx: context.Background(), handoff: handoffConfig{SlackWebhookURL: ts.URL}}
+	result, err := server.PostSlackSummary(HandoffParams{
+		Title:    "checkout 5xx spike",
+		Summary:  "Errors rose after deploy <1.4.2>",
+		Evidence: []string{"p99 latency doubled"},
+		Links:    []string{"https://app.datadoghq.com/monitors/1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Posted {
+		t.Fatal("expected the summary to be posted")
+	}
+	text := payload["text"]
+	if !strings.HasPrefix(text, "*checkout 5xx spike*\nErrors rose after deploy &lt;1.4.2&gt;") || !strings.Contains(text, "• <https://app.datadoghq.com/monitors/1>") {
+		t.Fatalf("unexpected Slack text:\n%s", text)
+	}
+}
+
+func TestCreateJiraTicket(t *testing.T) {
+	var fields map[string]interface{}
+	var auth string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/2/issue" {
+			http.NotFound(w, r)
+			return
+		}
+		user, pass, _ := r.BasicAuth()
+		auth = user + ":" + pass
+		var body struct {
+			Fields map[string]interface{} `json:"fields"`
+		}
+		data, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(data, &body)
+		fields = body.Fields
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":"1","key":"OPS-42"}`))
+	}))
+	t.Cleanup(ts.Close)
+
+	server := &MCPServer{
+		allowWrites: true,
+		ctx:         context.Background(),
+		session:     "stdio",
+		transcripts: newTranscriptStore(),
+		handoff:     handoffConfig{JiraURL: ts.URL, JiraEmail: "bot@example.com", JiraToken: "secret", JiraProject: "OPS", JiraIssueType: "Task"},
+	}
+	server.transcripts.record("stdio", TranscriptCall{Tool: "query_logs", Result: "{}"}, time.Now())
+
+	result, err := server.CreateJiraTicket(JiraHandoffParams{
+		HandoffParams:     HandoffParams{Title: "checkout 5xx spike", Summary: "Errors rose", Links: []string{"https://app.datadoghq.com/monitors/1"}},
+		Labels:            []string{"incident"},
+		IncludeTranscript: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Key != "OPS-42" || result.URL != ts.URL+"/browse/OPS-42" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if auth != "bot@example.com:secret" {
+		t.Fatalf("expected basic auth with the configured account, got %q", auth)
+	}
+	description, _ := fields["description"].(string)
+	if fields["project"].(map[string]interface{})["key"] != "OPS" || !strings.Contains(description, "* [https://app.datadoghq.com/monitors/1]") || !strings.Contains(description, "## 1. query_logs") {
+		t.Fatalf("unexpected fields: %v", fields)
+	}
+}
+
+func TestLoadHandoffConfig(t *testing.T) {
+	t.Setenv("DD_MCP_SLACK_WEBHOOK_URL", "http://hooks.example.com/x")
+	if _, err := loadHandoffConfig(); err == nil {
+		t.Fatal("expected a plain http webhook to be rejected")
+	}
+	t.Setenv("DD_MCP_SLACK_WEBHOOK_URL", "")
+	t.Setenv("DD_MCP_JIRA_URL", "https://acme.atlassian.net/")
+	if _, err := loadHandoffConfig(); err == nil {
+		t.Fatal("expected Jira without credentials to be rejected")
+	}
+	t.Setenv("DD_MCP_JIRA_EMAIL", "bot@example.com")
+	t.Setenv("DD_MCP_JIRA_API_TOKEN", "secret")
+	cfg, err := loadHandoffConfig()
+	if err != nil || cfg.JiraURL != "https://acme.atlassian.net" || cfg.JiraIssueType != "Task" {
+		t.Fatalf("unexpected config %+v, %v", cfg, err)
+	}
+}
+
+func TestHandoffRequiresConfiguration(t *testing.T) {
+	params := HandoffParams{Title: "t", Summary: "s"}
+	if _, err := (&MCPServer{}).PostSlackSummary(params); err == nil || !strings.Contains(err.Error(), "DD_MCP_ALLOW_WRITES") {
+		t.Fatalf("expected the write gate, got %v", err)
+	}
+	if _, err := (&MCPServer{allowWrites: true}).CreateJiraTicket(JiraHandoffParams{HandoffParams: params}); err == nil || !strings.Contains(err.Error(), "DD_MCP_JIRA_URL") {
+		t.Fatalf("expected a configuration error, got %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+)
+
+// readyTTL is how long a successful readiness check is reused, so frequent
+// probes don't each cost a Datadog API call.
+const readyTTL = 30 * time.Second
+
+// readyCheckTimeout bounds a single readiness check against Datadog.
+const readyCheckTimeout = 5 * time.Second
+
+var errDraining = errors.New("shutting down")
+
+// readiness caches the result of a readiness check and fails every check
+// once the server starts draining.
+type readiness struct {
+	mu       sync.Mutex
+	check    func() error
+	lastOK   time.Time
+	draining bool
+}
+
+func newReadiness(check func() error) *readiness {
+	return &readiness{check: check}
+}
+
+func (r *readiness) ready(now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.draining {
+		return errDraining
+	}
+	if !r.lastOK.IsZero() && now.Sub(r.lastOK) < readyTTL {
+		return nil
+	}
+	if err := r.check(); err != nil {
+		return err
+	}
+	r.lastOK = now
+	return nil
+}
+
+// drain makes /readyz fail so load balancers stop routing new requests
+// while in-flight ones finish.
+func (r *readiness) drain() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.draining = true
+}
+
+func (r *readiness) handleReadyz(w http.ResponseWriter, _ *http.Request) {
+	if err := r.ready(time.Now()); err != nil {
+		http.Error(w, "not ready: "+err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	w.Write([]byte("ok\n"))
+}
+
+// handleHealthz reports that the process is up; it never calls Datadog.
+func handleHealthz(w http.ResponseWriter, _ *http.Request) {
+	w.Write([]byte("ok\n"))
+}
+
+// checkDatadog validates the shared API key. In gateway mode there may be
+// no shared key, so any response from Datadog counts as reachable.
+func (s *MCPServer) checkDatadog() error {
+	ctx, cancel := context.WithTimeout(s.datadogContext(context.Background()), readyCheckTimeout)
+	defer cancel()
+
+	api := datadogV1.NewAuthenticationApi(s.ddClient)
+	_, httpResp, err := api.Validate(ctx)
+	if err == nil {
+		return nil
+	}
+	if s.tenants != nil && httpResp != nil {
+		return nil
+	}
+	if httpResp != nil {
+		return fmt.Errorf("Datadog API key validation failed: %s", httpResp.Status)
+	}
+	return fmt.Errorf("Datadog unreachable: %w", err)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReadinessCachesSuccess(t *testing.T) {
+	calls := 0
+	var checkErr error
+	r := newReadiness(func() error {
+		calls++
+		return checkErr
+	})
+	now := time.Now()
+
+	checkErr = errors.New("Datadog unreachable")
+	if err := r.ready(now); err == nil {
+		t.Fatal("expected failure to be reported")
+	}
+	checkErr = nil
+	if err := r.ready(now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.ready(now.Add(readyTTL / 2)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected failures to be retried and successes cached, got %d checks", calls)
+	}
+
+	r.ready(now.Add(readyTTL))
+	if calls != 3 {
+		t.Errorf("expected a new check after the TTL, got %d checks", calls)
+	}
+
+	r.drain()
+	if err := r.ready(now); !errors.Is(err, errDraining) {
+		t.Errorf("expected errDraining, got %v", err)
+	}
+}
+
+func TestProbesBypassAuth(t *testing.T) {
+	cfg := httpConfig{Tokens: []string{"s3cret"}}
+	ready := newReadiness(func() error { return errors.New("Datadog unreachable") })
+	handler := cfg.handler(&MCPServer{}, ready)
+
+	tests := []struct {
+		path     string
+		expected int
+	}{
+		{path: "/healthz", expected: http.StatusOK},
+		{path: "/readyz", expected: http.StatusServiceUnavailable},
+		{path: "/mcp", expected: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.expected {
+			t.Errorf("%s: expected %d, got %d", tt.path, tt.expected, rec.Code)
+		}
+	}
+}
+
+func TestWithAuthRequiresClientCert(t *testing.T) {
+	cfg := httpConfig{ClientCA: "ca.pem"}
+	handler := cfg.withAuth((&MCPServer{}).httpHandler())
+	body := `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`
+
+	if rec := postMCP(t, handler, body, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a client certificate, got %d", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{}}}}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code == http.StatusUnauthorized {
+		t.Error("expected a verified client certificate to be accepted")
+	}
+}
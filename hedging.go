@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// hedgedReadSuffixes are the POST endpoints that only read data and are
+// safe to send twice.
+var hedgedReadSuffixes = []string{
+	"/search",
+	"/aggregate",
+	"/query/timeseries",
+	"/query/scalar",
+}
+
+// hedgedTransport sends a second copy of a read request when the first has
+// not answered within delay, and returns whichever response arrives first.
+// The slower attempt is cancelled.
+type hedgedTransport struct {
+	base  http.RoundTripper
+	delay time.Duration
+}
+
+type hedgeResult struct {
+	resp    *http.Response
+	err     error
+	attempt int
+}
+
+func (t *hedgedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.delay <= 0 || !hedgeable(req) {
+		return t.base.RoundTrip(req)
+	}
+
+	results := make(chan hedgeResult, 2)
+	var cancels []context.CancelFunc
+	launch := func(r *http.Request) {
+		ctx, cancel := context.WithCancel(req.Context())
+		attempt := len(cancels)
+		cancels = append(cancels, cancel)
+		go func() {
+			resp, err := t.base.RoundTrip(r.WithContext(ctx))
+			results <- hedgeResult{resp: resp, err: err, attempt: attempt}
+		}()
+	}
+
+	launch(req)
+	timer := time.NewTimer(t.delay)
+	defer timer.Stop()
+
+	pending := 1
+	var last hedgeResult
+	for pending > 0 {
+		select {
+		case <-timer.C:
+			if hedge, err := cloneRequest(req); err == nil {
+				launch(hedge)
+				pending++
+			}
+		case res := <-results:
+			pending--
+			if res.err != nil {
+				last = res
+				continue
+			}
+			for i, cancel := range cancels {
+				if i != res.attempt {
+					cancel()
+				}
+			}
+			if pending > 0 {
+				go discardHedge(results)
+			}
+			res.resp.Body = &cancelOnClose{ReadCloser: res.resp.Body, cancel: cancels[res.attempt]}
+			return res.resp, nil
+		}
+	}
+
+	for _, cancel := range cancels {
+		cancel()
+	}
+	return nil, last.err
+}
+
+// hedgeable reports whether req is an idempotent read whose body, if any,
+// can be replayed.
+func hedgeable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	case http.MethodPost:
+		if req.GetBody == nil {
+			return false
+		}
+		for _, suffix := range hedgedReadSuffixes {
+			if strings.HasSuffix(req.URL.Path, suffix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func cloneRequest(req *http.Request) (*http.Request, error) {
+	clone := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		clone.Body = body
+	}
+	return clone, nil
+}
+
+// discardHedge closes the losing attempt's response so its connection is
+// released.
+func discardHedge(results <-chan hedgeResult) {
+	if res := <-results; res.resp != nil {
+		io.Copy(io.Discard, res.resp.Body)
+		res.resp.Body.Close()
+	}
+}
+
+// cancelOnClose releases the winning attempt's context once its body has
+// been read.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowFirstServer stalls the first request until it is cancelled and
+// answers every later one immediately.
+func slowFirstServer(t *testing.T) (*httptest.Server, *int32) {
+	t.Helper()
+	var count int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if atomic.AddInt32(&count, 1) == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		w.Write(append([]byte("fast:"), body...))
+	}))
+	t.Cleanup(ts.Close)
+	return ts, &count
+}
+
+func TestHedgedTransportTakesFasterResponse(t *testing.T) {
+	ts, count := slowFirstServer(t)
+	client := &http.Client{Transport: &hedgedTransport{base: http.DefaultTransport, delay: 20 * time.Millisecond}}
+
+	start := time.Now()
+	resp, err := client.Post(ts.URL+"/api/v2/logs/events/search", "application/json", bytes.NewBufferString(`{"q":1}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if string(body) != `fast:{"q":1}` {
+		t.Errorf("expected the hedged response with the replayed body, got %q", body)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected hedge to avoid the slow attempt, took %s", elapsed)
+	}
+	if n := atomic.LoadInt32(count); n != 2 {
+		t.Errorf("expected 2 attempts, got %d", n)
+	}
+}
+
+func TestHedgedTransportSkipsWrites(t *testing.T) {
+	var count int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		time.Sleep(50 * time.Millisecond)
+	}))
+	defer ts.Close()
+	client := &http.Client{Transport: &hedgedTransport{base: http.DefaultTransport, delay: 5 * time.Millisecond}}
+
+	resp, err := client.Post(ts.URL+"/api/v1/events", "application/json", bytes.NewBufferString(`{}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if n := atomic.LoadInt32(&count); n != 1 {
+		t.Errorf("expected writes to be sent once, got %d attempts", n)
+	}
+}
+
+func TestHedgeable(t *testing.T) {
+	tests := []struct {
+		method   string
+		path     string
+		body     bool
+		expected bool
+	}{
+		{method: http.MethodGet, path: "/api/v1/query", expected: true},
+		{method: http.MethodPost, path: "/api/v2/logs/events/search", body: true, expected: true},
+		{method: http.MethodPost, path: "/api/v2/query/timeseries", body: true, expected: true},
+		{method: http.MethodPost, path: "/api/v1/events", body: true, expected: false},
+		{method: http.MethodDelete, path: "/api/v1/monitor/1", expected: false},
+	}
+	for _, tt := range tests {
+		var body io.Reader
+		if tt.body {
+			body = bytes.NewBufferString("{}")
+		}
+		req := httptest.NewRequest(tt.method, "https://api.datadoghq.com"+tt.path, body)
+		req.GetBody = nil
+		if tt.body {
+			req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewBufferString("{}")), nil }
+		}
+		if got := hedgeable(req); got != tt.expected {
+			t.Errorf("hedgeable(%s %s) = %v, want %v", tt.method, tt.path, got, tt.expected)
+		}
+	}
+}
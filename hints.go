@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadog"
+)
+
+// errorHint is advice for a Datadog API error. A hint applies when the
+// status matches and, if Matches is set, Datadog's error text contains
+// one of them, ignoring case. "{scopes}" in Text is replaced by the
+// tool's application key scopes.
+type errorHint struct {
+	Status  int
+	Matches []string
+	Text    string
+}
+
+// errorHints are tried in order; the first that applies is used, so
+// specific hints come before the catch-all for their status.
+var errorHints = []errorHint{
+	{
+		Status:  400,
+		Matches: []string{"time range", "15 days", "too far in the past", "out of retention", "retention period"},
+		Text:    "The time range is too long or too old for this endpoint. Some Datadog APIs only search the last 15 days or the data's retention; narrow from/to and retry.",
+	},
+	{
+		Status:  422,
+		Matches: []string{"time range", "15 days"},
+		Text:    "The time range is too long for this endpoint. Some Datadog APIs only search the last 15 days; narrow from/to and retry.",
+	},
+	{
+		Status:  400,
+		Matches: []string{"query", "syntax", "parse"},
+		Text:    "Datadog couldn't parse the query. Use explain_query to check it, and quote values that contain spaces or special characters.",
+	},
+	{
+		Status: 401,
+		Text:   "Datadog rejected the API key. Check that DD_API_KEY is valid and belongs to the org on DD_SITE.",
+	},
+	{
+		Status:  403,
+		Matches: []string{"scope", "scoped"},
+		Text:    "The application key is scoped and lacks a scope this tool needs. Add {scopes} to the key, or create an application key with those scopes.",
+	},
+	{
+		Status: 403,
+		Text:   "The application key lacks permission for this call. Its owner needs {scopes}; create a key for a user or service account that has them. Also check that DD_API_KEY and DD_APP_KEY belong to the same org and DD_SITE matches it.",
+	},
+	{
+		Status: 429,
+		Text:   "Datadog rate limited this request. Wait a minute before retrying, and narrow the time range or lower the limit to make fewer calls.",
+	},
+}
+
+// toolScopes are the application key scopes each tool needs, for hints.
+var toolScopes = map[string][]string{
+	"query_logs":                {"logs_read_data", "logs_read_index_data"},
+	"detect_anomalies":          {"timeseries_query"},
+	"forecast_metric":           {"timeseries_query"},
+	"simulate_monitor":          {"timeseries_query"},
+	"detect_cardinality_growth": {"timeseries_query", "metrics_read"},
+	"alert_fatigue_report":      {"monitors_read", "events_read"},
+	"query_events":              {"events_read"},
+	"query_spans":               {"apm_read"},
+	"aggregate_spans":           {"apm_read"},
+	"get_trace":                 {"apm_read"},
+	"list_services":             {"apm_service_catalog_read"},
+	"get_service_dependencies":  {"apm_read"},
+	"get_blast_radius":          {"apm_read", "monitors_read", "slos_read"},
+	"list_hosts":                {"hosts_read"},
+	"get_host_totals":           {"hosts_read"},
+	"list_security_rules":       {"security_monitoring_rules_read"},
+	"list_security_findings":    {"security_monitoring_findings_read"},
+	"query_ci_tests":            {"ci_visibility_read"},
+	"list_users":                {"user_access_read"},
+	"list_teams":                {"teams_read"},
+	"get_team":                  {"teams_read"},
+	"list_monitors":             {"monitors_read"},
+	"get_monitor":               {"monitors_read"},
+	"watch_monitor":             {"monitors_read"},
+	"list_synthetic_tests":      {"synthetics_read", "monitors_read"},
+	"get_synthetic_results":     {"synthetics_read"},
+	"trigger_synthetic_test":    {"synthetics_read", "synthetics_write"},
+	"get_synthetic_batch":       {"synthetics_read"},
+	"list_incidents":            {"incident_read"},
+	"get_incident":              {"incident_read"},
+	"get_incident_timeline":     {"incident_read"},
+	"generate_postmortem":       {"incident_read", "monitors_read", "events_read", "logs_read_data", "notebooks_write", "incident_write"},
+	"list_slos":                 {"slos_read"},
+	"get_slo_status":            {"slos_read"},
+	"get_slo_history":           {"slos_read"},
+	"list_dashboards":           {"dashboards_read"},
+	"get_dashboard":             {"dashboards_read"},
+	"diff_resource":             {"monitors_read", "dashboards_read"},
+	"metric_related_assets":     {"metrics_read", "dashboards_read", "monitors_read"},
+	"audit_orphaned_resources":  {"monitors_read", "dashboards_read", "slos_read"},
+	"detect_usage_anomalies":    {"usage_read"},
+	"get_usage":                 {"usage_read"},
+	"get_estimated_cost":        {"usage_read", "billing_read"},
+	"mute_monitor":              {"monitors_downtime"},
+	"unmute_monitor":            {"monitors_downtime"},
+	"create_downtime":           {"monitors_downtime"},
+	"cancel_downtime":           {"monitors_downtime"},
+}
+
+// toolError turns a failed Datadog call into a tool error. It passes
+// Datadog's own error text through as written, since it often names the
+// missing permission or bad field, and adds a hint for common failures.
+func toolError(tool string, err error) *MCPError {
+	message := err.Error()
+	var apiErr datadog.GenericOpenAPIError
+	if !errors.As(err, &apiErr) {
+		return &MCPError{Code: -32000, Message: message}
+	}
+	details := apiErrorDetails(apiErr.ErrorBody)
+	if len(details) > 0 {
+		message += ": " + strings.Join(details, "; ")
+	}
+	status, _ := strconv.Atoi(strings.Fields(apiErr.ErrorMessage + " 0")[0])
+	// Hints match Datadog's text only; our own prefix, such as "failed to
+	// query logs", would match too much.
+	if hint := findErrorHint(status, strings.Join(details, " ")); hint != "" {
+		message += ". Hint: " + strings.ReplaceAll(hint, "{scopes}", scopesFor(tool))
+	}
+	return &MCPError{Code: -32000, Message: message}
+}
+
+// findErrorHint returns the first hint for the status and error text.
+func findErrorHint(status int, text string) string {
+	text = strings.ToLower(text)
+	for _, hint := range errorHints {
+		if hint.Status != status {
+			continue
+		}
+		if len(hint.Matches) == 0 {
+			return hint.Text
+		}
+		for _, match := range hint.Matches {
+			if strings.Contains(text, match) {
+				return hint.Text
+			}
+		}
+	}
+	return ""
+}
+
+func scopesFor(tool string) string {
+	scopes := toolScopes[tool]
+	if len(scopes) == 0 {
+		return "the permissions for this tool's Datadog product"
+	}
+	return strings.Join(scopes, " and ")
+}
+
+// apiErrorDetails reads the error text out of a Datadog error body. The
+// v1 APIs send {"errors": ["..."]}; the v2 APIs send objects with a title
+// and detail.
+func apiErrorDetails(body []byte) []string {
+	var payload struct {
+		Errors []json.RawMessage `json:"errors"`
+	}
+	if json.Unmarshal(body, &payload) != nil {
+		return nil
+	}
+	details := make([]string, 0, len(payload.Errors))
+	for _, raw := range payload.Errors {
+		var text string
+		if json.Unmarshal(raw, &text) == nil {
+			details = append(details, text)
+			continue
+		}
+		var object struct {
+			Title  string `json:"title"`
+			Detail string `json:"detail"`
+		}
+		if json.Unmarshal(raw, &object) == nil && (object.Title != "" || object.Detail != "") {
+			if object.Title != "" && object.Detail != "" {
+				details = append(details, fmt.Sprintf("%s: %s", object.Title, object.Detail))
+			} else {
+				details = append(details, object.Title+object.Detail)
+			}
+		}
+	}
+	return details
+}
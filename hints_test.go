@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadog"
+)
+
+func TestToolErrorHints(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		tool string
+		err  error
+		want []string
+	}{
+		{
+			name: "missing permission",
+			tool: "query_logs",
+			err:  fmt.Errorf("failed to query logs: %w", datadog.GenericOpenAPIError{ErrorMessage: "403 Forbidden", ErrorBody: []byte(`{"errors":["Forbidden"]}`)}),
+			want: []string{"failed to query logs: 403 Forbidden: Forbidden", "logs_read_data and logs_read_index_data"},
+		},
+		{
+			name: "scoped key",
+			tool: "list_slos",
+			err:  datadog.GenericOpenAPIError{ErrorMessage: "403 Forbidden", ErrorBody: []byte(`{"errors":[{"title":"Forbidden","detail":"Missing required scope"}]}`)},
+			want: []string{"Forbidden: Missing required scope", "is scoped", "Add slos_read"},
+		},
+		{
+			name: "rate limited",
+			tool: "query_spans",
+			err:  datadog.GenericOpenAPIError{ErrorMessage: "429 Too Many Requests"},
+			want: []string{"429 Too Many Requests. Hint: Datadog rate limited"},
+		},
+		{
+			name: "time range",
+			tool: "query_events",
+			err:  datadog.GenericOpenAPIError{ErrorMessage: "400 Bad Request", ErrorBody: []byte(`{"errors":["Invalid query: time range exceeds 15 days"]}`)},
+			want: []string{"time range exceeds 15 days", "narrow from/to"},
+		},
+		{
+			name: "body passed through as written",
+			tool: "query_logs",
+			err:  datadog.GenericOpenAPIError{ErrorMessage: "500 Internal Server Error", ErrorBody: []byte(`{"errors":["Interner Fehler"]}`)},
+			want: []string{"500 Internal Server Error: Interner Fehler"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			toolErr := toolError(tc.tool, tc.err)
+			for _, want := range tc.want {
+				if !strings.Contains(toolErr.Message, want) {
+					t.Errorf("expected %q in %q", want, toolErr.Message)
+				}
+			}
+		})
+	}
+
+	if toolErr := toolError("query_logs", fmt.Errorf("query parameter is required")); toolErr.Message != "query parameter is required" {
+		t.Errorf("expected other errors unchanged, got %q", toolErr.Message)
+	}
+	if toolErr := toolError("query_logs", datadog.GenericOpenAPIError{ErrorMessage: "500 Internal Server Error"}); strings.Contains(toolErr.Message, "Hint") {
+		t.Errorf("expected no hint for an unlisted status, got %q", toolErr.Message)
+	}
+}
+
+func TestToolErrorHintsInResults(t *testing.T) {
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"errors":["Forbidden"]}`))
+	})
+	arguments, _ := json.Marshal(ListMonitorsParams{})
+	_, toolErr := server.callTool(ToolCallParams{Name: "list_monitors", Arguments: arguments})
+	if toolErr == nil || !strings.Contains(toolErr.Message, "monitors_read") {
+		t.Fatalf("expected a hint naming the scope, got %+v", toolErr)
+	}
+}
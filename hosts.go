@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+)
+
+const (
+	defaultHostsPerPage = 50
+	maxHostsPerPage     = 1000
+)
+
+// hostSortFields are the sort fields the hosts API accepts.
+var hostSortFields = []string{"status", "apps", "cpu", "iowait", "load"}
+
+type ListHostsParams struct {
+	// Filter matches host names, aliases and tags, such as "env:prod" or
+	// "web-".
+	Filter string `json:"filter,omitempty"`
+	// From keeps hosts that reported since then.
+	From    string `json:"from,omitempty"`
+	Sort    string `json:"sort,omitempty"`
+	SortDir string `json:"sort_dir,omitempty"`
+	Page    int64  `json:"page,omitempty"`
+	PerPage int64  `json:"per_page,omitempty"`
+}
+
+type HostSummary struct {
+	Name         string   `json:"name"`
+	Aliases      []string `json:"aliases,omitempty"`
+	Up           bool     `json:"up"`
+	Muted        bool     `json:"muted,omitempty"`
+	Apps         []string `json:"apps,omitempty"`
+	AgentVersion string   `json:"agent_version,omitempty"`
+	Platform     string   `json:"platform,omitempty"`
+	// Tags are the host's tags from every source, deduplicated.
+	Tags         []string `json:"tags,omitempty"`
+	LastReported string   `json:"last_reported,omitempty"`
+	URL          string   `json:"url"`
+}
+
+type ListHostsResult struct {
+	Hosts     []HostSummary `json:"hosts"`
+	Total     int64         `json:"total"`
+	Page      int64         `json:"page"`
+	PageCount int64         `json:"page_count"`
+	PerPage   int64         `json:"per_page"`
+	// Down counts the hosts on this page that aren't reporting.
+	Down  int      `json:"down"`
+	Notes []string `json:"notes,omitempty"`
+}
+
+// ListHosts lists infrastructure hosts matching a filter, a page at a
+// time.
+func (s *MCPServer) ListHosts(params ListHostsParams) (*ListHostsResult, error) {
+	perPage := params.PerPage
+	if perPage <= 0 {
+		perPage = defaultHostsPerPage
+	}
+	perPage = clampArgument(s, "per_page", perPage, maxHostsPerPage)
+	if params.Page < 0 {
+		return nil, fmt.Errorf("page must not be negative")
+	}
+	opts := datadogV1.NewListHostsOptionalParameters().
+		WithStart(params.Page * perPage).
+		WithCount(perPage).
+		WithIncludeHostsMetadata(true).
+		WithIncludeMutedHostsData(true)
+	if params.Filter != "" {
+		opts = opts.WithFilter(params.Filter)
+	}
+	if params.Sort != "" {
+		if !slices.Contains(hostSortFields, params.Sort) {
+			return nil, fmt.Errorf("invalid sort: %s (use %s)", params.Sort, strings.Join(hostSortFields, ", "))
+		}
+		opts = opts.WithSortField(params.Sort)
+	}
+	switch params.SortDir {
+	case "":
+	case "asc", "desc":
+		opts = opts.WithSortDir(params.SortDir)
+	default:
+		return nil, fmt.Errorf("invalid sort_dir: %s (use asc or desc)", params.SortDir)
+	}
+	if params.From != "" {
+		from, err := s.timeParam("from", params.From, time.Time{})
+		if err != nil {
+			return nil, err
+		}
+		opts = opts.WithFrom(from.Unix())
+	}
+
+	resp, _, err := datadogV1.NewHostsApi(s.ddClient).ListHosts(s.ctx, *opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hosts: %w", err)
+	}
+
+	result := &ListHostsResult{
+		Hosts:   make([]HostSummary, 0, len(resp.HostList)),
+		Total:   resp.GetTotalMatching(),
+		Page:    params.Page,
+		PerPage: perPage,
+	}
+	result.PageCount = (result.Total + perPage - 1) / perPage
+	for _, host := range resp.HostList {
+		summary := HostSummary{
+			Name:         host.GetName(),
+			Aliases:      host.Aliases,
+			Up:           host.GetUp(),
+			Muted:        host.GetIsMuted(),
+			Apps:         host.Apps,
+			AgentVersion: host.Meta.GetAgentVersion(),
+			Platform:     host.Meta.GetPlatform(),
+			Tags:         flattenHostTags(host.TagsBySource),
+			URL:          s.appURL("/infrastructure?" + url.Values{"host": {host.GetName()}}.Encode()),
+		}
+		if reported := host.GetLastReportedTime(); reported > 0 {
+			summary.LastReported = time.Unix(reported, 0).UTC().Format(time.RFC3339)
+		}
+		if !summary.Up {
+			result.Down++
+		}
+		result.Hosts = append(result.Hosts, summary)
+	}
+
+	if result.Total == 0 && params.Filter != "" {
+		result.Notes = append(result.Notes, "No hosts match the filter. It matches host names, aliases and tags; try a shorter part of the name.")
+	}
+	if result.PageCount > params.Page+1 {
+		result.Notes = append(result.Notes, fmt.Sprintf("This is page %d of %d (pages start at 0); pass page=%d for more.", params.Page, result.PageCount, params.Page+1))
+	}
+	return result, nil
+}
+
+// flattenHostTags merges a host's tags from all sources, sorted.
+func flattenHostTags(bySource map[string][]string) []string {
+	var tags []string
+	for _, sourceTags := range bySource {
+		for _, tag := range sourceTags {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+type GetHostTotalsParams struct {
+	// From counts hosts active since then; Datadog defaults to the last
+	// two hours.
+	From string `json:"from,omitempty"`
+}
+
+type HostTotals struct {
+	// Active counts hosts that reported in the window; Up counts those
+	// of them that are up now.
+	Active int64 `json:"active"`
+	Up     int64 `json:"up"`
+	// NotUp is Active less Up: hosts that reported but have since stopped.
+	NotUp int64    `json:"not_up"`
+	From  string   `json:"from,omitempty"`
+	URL   string   `json:"url"`
+	Notes []string `json:"notes,omitempty"`
+}
+
+// GetHostTotals counts the org's active and up hosts, for capacity and
+// billing checks.
+func (s *MCPServer) GetHostTotals(params GetHostTotalsParams) (*HostTotals, error) {
+	opts := datadogV1.NewGetHostTotalsOptionalParameters()
+	result := &HostTotals{URL: s.appURL("/infrastructure")}
+	if params.From != "" {
+		from, err := s.timeParam("from", params.From, time.Time{})
+		if err != nil {
+			return nil, err
+		}
+		opts = opts.WithFrom(from.Unix())
+		result.From = from.UTC().Format(time.RFC3339)
+	}
+
+	resp, _, err := datadogV1.NewHostsApi(s.ddClient).GetHostTotals(s.ctx, *opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get host totals: %w", err)
+	}
+	result.Active = resp.GetTotalActive()
+	result.Up = resp.GetTotalUp()
+	result.NotUp = max(result.Active-result.Up, 0)
+	if result.NotUp > 0 {
+		result.Notes = append(result.Notes, fmt.Sprintf("%d active hosts aren't up; list them with list_hosts sorted by status.", result.NotUp))
+	}
+	return result, nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestListHosts(t *testing.T) {
+	var query url.Values
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/api/v1/hosts" {
+			http.NotFound(w, r)
+			return
+		}
+		query = r.URL.Query()
+		_, _ = w.Write([]byte(`{"total_matching":3,"total_returned":2,"host_list":[
+			{"name":"web-1","up":true,"apps":["nginx","agent"],"last_reported_time":1768903200,
+			 "meta":{"agent_version":"7.52.0","platform":"linux"},
+			 "tags_by_source":{"Datadog":["env:prod","role:web"],"Amazon Web Services":["env:prod","region:us-east-1"]}},
+			{"name":"web-2","up":false,"is_muted":true,"aliases":["i-0abc"]}]}`))
+	})
+
+	result, err := server.ListHosts(ListHostsParams{Filter: "web-", Sort: "cpu", SortDir: "desc", PerPage: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query.Get("filter") != "web-" || query.Get("sort_field") != "cpu" || query.Get("sort_dir") != "desc" || query.Get("count") != "2" || query.Get("start") != "0" || query.Get("include_hosts_metadata") != "true" {
+		t.Fatalf("unexpected request: %v", query)
+	}
+	web1 := result.Hosts[0]
+	if !web1.Up || web1.AgentVersion != "7.52.0" || strings.Join(web1.Tags, ",") != "env:prod,region:us-east-1,role:web" || web1.LastReported != "2026-01-20T10:00:00Z" {
+		t.Errorf("unexpected host: %+v", web1)
+	}
+	if web1.URL != "https://app.datadoghq.com/infrastructure?host=web-1" {
+		t.Errorf("unexpected url: %s", web1.URL)
+	}
+	if result.Down != 1 || !result.Hosts[1].Muted || result.Total != 3 || result.PageCount != 2 || len(result.Notes) != 1 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	if _, err := server.ListHosts(ListHostsParams{Page: 1, PerPage: 2}); err != nil || query.Get("start") != "2" {
+		t.Errorf("expected page 1 to start at 2, got %v, %v", query.Get("start"), err)
+	}
+	if _, err := server.ListHosts(ListHostsParams{Sort: "memory"}); err == nil {
+		t.Error("expected an unknown sort field to be rejected")
+	}
+}
+
+func TestGetHostTotals(t *testing.T) {
+	var from string
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/api/v1/hosts/totals" {
+			http.NotFound(w, r)
+			return
+		}
+		from = r.URL.Query().Get("from")
+		_, _ = w.Write([]byte(`{"total_active":120,"total_up":117}`))
+	})
+
+	result, err := server.GetHostTotals(GetHostTotalsParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from != "" || result.Active != 120 || result.Up != 117 || result.NotUp != 3 || len(result.Notes) != 1 {
+		t.Fatalf("unexpected totals %+v (from %q)", result, from)
+	}
+	if !strings.HasSuffix(result.URL, "/infrastructure") {
+		t.Errorf("unexpected url %s", result.URL)
+	}
+
+	result, err = server.GetHostTotals(GetHostTotalsParams{From: "2026-01-20T00:00:00Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from != "1768867200" || result.From != "2026-01-20T00:00:00Z" {
+		t.Fatalf("expected from to be passed through, got %q and %+v", from, result)
+	}
+}
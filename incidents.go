@@ -0,0 +1,536 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV2"
+)
+
+const (
+	defaultIncidentLimit = 20
+	maxIncidentLimit     = 100
+)
+
+// incidentOperations are the Incidents API calls the tools make. The
+// client marks them unstable, so they have to be enabled explicitly.
+var incidentOperations = []string{"v2.SearchIncidents", "v2.GetIncident", "v2.ListIncidentTodos"}
+
+var incidentStates = []string{"active", "stable", "resolved"}
+
+type ListIncidentsParams struct {
+	States     []string `json:"states,omitempty"`
+	Severities []string `json:"severities,omitempty"`
+	Query      string   `json:"query,omitempty"`
+	Limit      int      `json:"limit,omitempty"`
+}
+
+type GetIncidentParams struct {
+	IncidentID string `json:"incident_id"`
+	// Diagram adds a Mermaid gantt chart of the timeline to the result.
+	Diagram bool `json:"diagram,omitempty"`
+}
+
+type IncidentUser struct {
+	ID     string `json:"id"`
+	Name   string `json:"name,omitempty"`
+	Handle string `json:"handle,omitempty"`
+	Email  string `json:"email,omitempty"`
+}
+
+type IncidentSummary struct {
+	ID               string        `json:"id"`
+	PublicID         int64         `json:"public_id,omitempty"`
+	Title            string        `json:"title"`
+	Severity         string        `json:"severity,omitempty"`
+	State            string        `json:"state,omitempty"`
+	Commander        *IncidentUser `json:"commander,omitempty"`
+	CustomerImpacted bool          `json:"customer_impacted"`
+	Created          string        `json:"created,omitempty"`
+	Resolved         string        `json:"resolved,omitempty"`
+	URL              string        `json:"url"`
+}
+
+// Incident is an incident with its timeline and the values of its
+// custom fields, such as teams and services.
+type Incident struct {
+	IncidentSummary
+	CustomerImpactScope    string              `json:"customer_impact_scope,omitempty"`
+	CustomerImpactStart    string              `json:"customer_impact_start,omitempty"`
+	CustomerImpactEnd      string              `json:"customer_impact_end,omitempty"`
+	CustomerImpactDuration *int64              `json:"customer_impact_duration_seconds,omitempty"`
+	Detected               string              `json:"detected,omitempty"`
+	Declared               string              `json:"declared,omitempty"`
+	DeclaredBy             *IncidentUser       `json:"declared_by,omitempty"`
+	Modified               string              `json:"modified,omitempty"`
+	TimeToDetect           *int64              `json:"time_to_detect_seconds,omitempty"`
+	TimeToRepair           *int64              `json:"time_to_repair_seconds,omitempty"`
+	TimeToResolve          *int64              `json:"time_to_resolve_seconds,omitempty"`
+	Fields                 map[string][]string `json:"fields,omitempty"`
+}
+
+type ListIncidentsResult struct {
+	Query     string            `json:"query"`
+	Total     int32             `json:"total"`
+	Incidents []IncidentSummary `json:"incidents"`
+	URL       string            `json:"url"`
+	Partial   *PartialData      `json:"partial,omitempty"`
+}
+
+// ListIncidents searches incidents, newest first. Without states it lists
+// the ones still open.
+func (s *MCPServer) ListIncidents(params ListIncidentsParams) (*ListIncidentsResult, error) {
+	query, err := incidentSearchQuery(params)
+	if err != nil {
+		return nil, err
+	}
+	limit := params.Limit
+	if limit <= 0 {
+		limit = defaultIncidentLimit
+	}
+	limit = clampArgument(s, "limit", limit, maxIncidentLimit)
+
+	api := datadogV2.NewIncidentsApi(s.ddClient)
+	opts := datadogV2.NewSearchIncidentsOptionalParameters().
+		WithSort(datadogV2.INCIDENTSEARCHSORTORDER_CREATED_DESCENDING).
+		WithInclude(datadogV2.INCIDENTRELATEDOBJECT_USERS).
+		WithPageSize(int64(limit))
+	resp, _, err := api.SearchIncidents(s.ctx, query, *opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search incidents: %w", err)
+	}
+
+	result := &ListIncidentsResult{
+		Query:     query,
+		Incidents: make([]IncidentSummary, 0),
+		URL:       s.appURL("/incidents?query=" + url.QueryEscape(query)),
+	}
+	if attrs := resp.Data.Attributes; attrs != nil {
+		result.Total = attrs.Total
+		users := incidentUsers(resp.Included)
+		for _, incident := range attrs.Incidents {
+			summary := s.summarizeIncident(incident.Data, users)
+			result.Incidents = append(result.Incidents, summary)
+			if incident.Data.Attributes == nil {
+				result.Partial = result.Partial.record("attributes")
+			}
+		}
+	}
+	return result, nil
+}
+
+// GetIncident returns one incident with its customer impact, timeline and
+// custom fields.
+func (s *MCPServer) GetIncident(params GetIncidentParams) (*Incident, error) {
+	id := strings.TrimSpace(params.IncidentID)
+	if id == "" {
+		return nil, fmt.Errorf("incident_id parameter is required")
+	}
+
+	api := datadogV2.NewIncidentsApi(s.ddClient)
+	opts := datadogV2.NewGetIncidentOptionalParameters().WithInclude([]datadogV2.IncidentRelatedObject{datadogV2.INCIDENTRELATEDOBJECT_USERS})
+	resp, _, err := api.GetIncident(s.ctx, id, *opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get incident %s: %w", id, err)
+	}
+
+	users := incidentUsers(resp.Included)
+	incident := &Incident{IncidentSummary: s.summarizeIncident(resp.Data, users)}
+	attrs := resp.Data.Attributes
+	if attrs == nil {
+		return incident, nil
+	}
+	incident.CustomerImpactScope = attrs.GetCustomerImpactScope()
+	incident.CustomerImpactStart = formatOptionalTime(attrs.CustomerImpactStart.Get())
+	incident.CustomerImpactEnd = formatOptionalTime(attrs.CustomerImpactEnd.Get())
+	incident.CustomerImpactDuration = attrs.CustomerImpactDuration
+	incident.Detected = formatOptionalTime(attrs.Detected.Get())
+	incident.Declared = formatOptionalTime(attrs.Declared)
+	incident.Modified = formatOptionalTime(attrs.Modified)
+	incident.TimeToDetect = attrs.TimeToDetect
+	incident.TimeToRepair = attrs.TimeToRepair
+	incident.TimeToResolve = attrs.TimeToResolve
+	if rel := resp.Data.Relationships; rel != nil && rel.DeclaredByUser != nil {
+		incident.DeclaredBy = lookupIncidentUser(rel.DeclaredByUser.Data.Id, users)
+	}
+	for name, field := range attrs.Fields {
+		// State and severity are already on the summary.
+		if name == "state" || name == "severity" {
+			continue
+		}
+		if values := incidentFieldValues(field); len(values) > 0 {
+			if incident.Fields == nil {
+				incident.Fields = make(map[string][]string)
+			}
+			incident.Fields[name] = values
+		}
+	}
+	return incident, nil
+}
+
+func (s *MCPServer) summarizeIncident(data datadogV2.IncidentResponseData, users map[string]IncidentUser) IncidentSummary {
+	summary := IncidentSummary{ID: data.Id, URL: s.appURL("/incidents/" + data.Id)}
+	if attrs := data.Attributes; attrs != nil {
+		summary.PublicID = attrs.GetPublicId()
+		summary.Title = attrs.Title
+		summary.Severity = string(attrs.GetSeverity())
+		summary.State = attrs.GetState()
+		summary.CustomerImpacted = attrs.GetCustomerImpacted()
+		summary.Created = formatOptionalTime(attrs.Created)
+		summary.Resolved = formatOptionalTime(attrs.Resolved.Get())
+		if summary.PublicID != 0 {
+			summary.URL = s.appURL(fmt.Sprintf("/incidents/%d", summary.PublicID))
+		}
+	}
+	if rel := data.Relationships; rel != nil {
+		if commander := rel.CommanderUser.Get(); commander != nil {
+			if user := commander.Data.Get(); user != nil {
+				summary.Commander = lookupIncidentUser(user.Id, users)
+			}
+		}
+	}
+	return summary
+}
+
+// incidentSearchQuery turns the list filters into an incident search
+// query.
+func incidentSearchQuery(params ListIncidentsParams) (string, error) {
+	states := []string{"active", "stable"}
+	if len(params.States) > 0 {
+		states = make([]string, len(params.States))
+		for i, state := range params.States {
+			states[i] = strings.ToLower(strings.TrimSpace(state))
+			if !slices.Contains(incidentStates, states[i]) {
+				return "", fmt.Errorf("invalid state: %q (use %s)", state, strings.Join(incidentStates, ", "))
+			}
+		}
+	}
+	terms := []string{"state:(" + strings.Join(states, " OR ") + ")"}
+	if len(params.Severities) > 0 {
+		severities := make([]string, len(params.Severities))
+		for i, severity := range params.Severities {
+			severities[i] = strings.ToUpper(strings.TrimSpace(severity))
+		}
+		terms = append(terms, "severity:("+strings.Join(severities, " OR ")+")")
+	}
+	if query := strings.TrimSpace(params.Query); query != "" {
+		terms = append(terms, query)
+	}
+	return strings.Join(terms, " "), nil
+}
+
+// incidentUsers indexes the users included with an incident response.
+func incidentUsers(included []datadogV2.IncidentResponseIncludedItem) map[string]IncidentUser {
+	users := make(map[string]IncidentUser)
+	for _, item := range included {
+		data := item.IncidentUserData
+		if data == nil || data.Id == nil {
+			continue
+		}
+		user := IncidentUser{ID: *data.Id}
+		if attrs := data.Attributes; attrs != nil {
+			user.Name = attrs.GetName()
+			user.Handle = attrs.GetHandle()
+			user.Email = attrs.GetEmail()
+		}
+		users[user.ID] = user
+	}
+	return users
+}
+
+// lookupIncidentUser resolves a related user, falling back to the bare
+// ID when the user wasn't included.
+func lookupIncidentUser(id string, users map[string]IncidentUser) *IncidentUser {
+	if id == "" {
+		return nil
+	}
+	if user, ok := users[id]; ok {
+		return &user
+	}
+	return &IncidentUser{ID: id}
+}
+
+func incidentFieldValues(field datadogV2.IncidentFieldAttributes) []string {
+	switch {
+	case field.IncidentFieldAttributesSingleValue != nil:
+		if value := field.IncidentFieldAttributesSingleValue.Value.Get(); value != nil && *value != "" {
+			return []string{*value}
+		}
+	case field.IncidentFieldAttributesMultipleValue != nil:
+		values := slices.Clone(field.IncidentFieldAttributesMultipleValue.GetValue())
+		sort.Strings(values)
+		return values
+	}
+	return nil
+}
+
+// mermaidTimeLayout is the layout of the gantt chart's dateFormat.
+const mermaidTimeLayout = "2006-01-02 15:04"
+
+// incidentGantt renders an incident's timeline as Mermaid gantt source:
+// bars for how long it was open and customers were impacted, and
+// milestones for when it was detected, declared and resolved. Bars of an
+// incident still open end at now.
+func incidentGantt(incident *Incident, now time.Time) string {
+	parse := func(value string) (time.Time, bool) {
+		t, err := time.Parse(time.RFC3339, value)
+		return t.UTC(), err == nil
+	}
+	end := now.UTC()
+	if resolved, ok := parse(incident.Resolved); ok {
+		end = resolved
+	}
+
+	var b strings.Builder
+	b.WriteString("```mermaid\ngantt\n")
+	fmt.Fprintf(&b, "    title %s\n", mermaidText(incidentTitle(incident.IncidentSummary)))
+	b.WriteString("    dateFormat YYYY-MM-DD HH:mm\n    axisFormat %m-%d %H:%M\n")
+	bar := func(name, tag string, start, finish time.Time) {
+		if !finish.After(start) {
+			finish = start.Add(time.Minute)
+		}
+		fmt.Fprintf(&b, "    %s :%s%s, %s\n", name, tag, start.Format(mermaidTimeLayout), finish.Format(mermaidTimeLayout))
+	}
+
+	b.WriteString("    section Incident\n")
+	open := incident.Declared
+	if open == "" {
+		open = incident.Created
+	}
+	if start, ok := parse(open); ok {
+		status := "active, "
+		if incident.Resolved != "" {
+			status = "done, "
+		}
+		bar("Open", status, start, end)
+	}
+	if start, ok := parse(incident.CustomerImpactStart); ok {
+		finish := end
+		if impactEnd, ok := parse(incident.CustomerImpactEnd); ok {
+			finish = impactEnd
+		}
+		b.WriteString("    section Customer impact\n")
+		bar("Impact", "crit, ", start, finish)
+	}
+
+	b.WriteString("    section Milestones\n")
+	for _, milestone := range []struct{ name, at string }{
+		{"Detected", incident.Detected},
+		{"Declared", incident.Declared},
+		{"Resolved", incident.Resolved},
+	} {
+		if at, ok := parse(milestone.at); ok {
+			fmt.Fprintf(&b, "    %s :milestone, %s, 0m\n", milestone.name, at.Format(mermaidTimeLayout))
+		}
+	}
+	b.WriteString("```\n")
+	return b.String()
+}
+
+// mermaidText makes a label safe inside a gantt line, where colons and
+// semicolons separate fields and '#' starts an entity code.
+func mermaidText(s string) string {
+	return strings.NewReplacer(":", " -", ";", ",", "#", "", "\n", " ", "\r", " ").Replace(s)
+}
+
+type GetIncidentTimelineParams struct {
+	IncidentID string `json:"incident_id"`
+	Format     string `json:"format,omitempty"`
+}
+
+// TimelineEntry is one event in an incident's response history.
+type TimelineEntry struct {
+	Time string `json:"time"`
+	Kind string `json:"kind"`
+	Text string `json:"text"`
+}
+
+type IncidentTimeline struct {
+	Incident IncidentSummary `json:"incident"`
+	Entries  []TimelineEntry `json:"entries"`
+	Notes    []string        `json:"notes,omitempty"`
+}
+
+// GetIncidentTimeline assembles an incident's response history from its
+// milestones, impacts, todos and attachments, oldest first. An unreadable
+// source is noted and the rest of the timeline is still returned.
+func (s *MCPServer) GetIncidentTimeline(params GetIncidentTimelineParams) (string, error) {
+	format := strings.ToLower(params.Format)
+	if format == "" {
+		format = "markdown"
+	}
+	if format != "markdown" && format != "json" {
+		return "", fmt.Errorf("format must be markdown or json, got %q", params.Format)
+	}
+	incident, err := s.GetIncident(GetIncidentParams{IncidentID: params.IncidentID})
+	if err != nil {
+		return "", err
+	}
+	timeline := s.incidentTimeline(incident)
+	if format == "json" {
+		return formatResult(timeline), nil
+	}
+	return formatIncidentTimelineMarkdown(timeline), nil
+}
+
+// incidentTimeline reads the timeline sources of a fetched incident.
+func (s *MCPServer) incidentTimeline(incident *Incident) *IncidentTimeline {
+	timeline := &IncidentTimeline{Incident: incident.IncidentSummary, Entries: make([]TimelineEntry, 0)}
+	add := func(at, kind, text string) {
+		if at != "" {
+			timeline.Entries = append(timeline.Entries, TimelineEntry{Time: at, Kind: kind, Text: text})
+		}
+	}
+	add(incident.Created, "created", "Incident created")
+	add(incident.Detected, "detected", "Issue detected")
+	declared := "Incident declared"
+	if incident.DeclaredBy != nil {
+		declared += " by " + incidentUserName(incident.DeclaredBy)
+	}
+	add(incident.Declared, "declared", declared)
+	add(incident.CustomerImpactStart, "customer_impact", strings.TrimSuffix("Customer impact started: "+incident.CustomerImpactScope, ": "))
+	add(incident.CustomerImpactEnd, "customer_impact", "Customer impact ended")
+	add(incident.Resolved, "resolved", "Incident resolved")
+
+	api := datadogV2.NewIncidentsApi(s.ddClient)
+	id := incident.ID
+	if resp, _, err := api.ListIncidentImpacts(s.ctx, id); err != nil {
+		timeline.Notes = append(timeline.Notes, fmt.Sprintf("Couldn't read impacts: %v", err))
+	} else {
+		for _, impact := range resp.Data {
+			if attrs := impact.Attributes; attrs != nil {
+				add(formatOptionalTime(&attrs.StartAt), "impact", "Impact started: "+attrs.Description)
+				add(formatOptionalTime(attrs.EndAt.Get()), "impact", "Impact ended: "+attrs.Description)
+			}
+		}
+	}
+	if resp, _, err := api.ListIncidentTodos(s.ctx, id); err != nil {
+		timeline.Notes = append(timeline.Notes, fmt.Sprintf("Couldn't read todos: %v", err))
+	} else {
+		for _, todo := range resp.Data {
+			attrs := todo.Attributes
+			if attrs == nil {
+				continue
+			}
+			text := "Todo added: " + attrs.Content
+			if assignees := todoAssignees(attrs.Assignees); assignees != "" {
+				text += " (assigned to " + assignees + ")"
+			}
+			add(formatOptionalTime(attrs.Created), "todo", text)
+			if completed := attrs.GetCompleted(); completed != "" {
+				if at, err := time.Parse(time.RFC3339, completed); err == nil {
+					add(formatOptionalTime(&at), "todo", "Todo completed: "+attrs.Content)
+				}
+			}
+		}
+	}
+	if attachments, err := s.listIncidentAttachments(id); err != nil {
+		timeline.Notes = append(timeline.Notes, fmt.Sprintf("Couldn't read attachments: %v", err))
+	} else {
+		for _, attachment := range attachments {
+			attrs := attachment.Attributes
+			kind := attrs.AttachmentType
+			if kind == "" {
+				kind = "attachment"
+			}
+			text := strings.ToUpper(kind[:1]) + kind[1:] + " attached"
+			if detail := strings.TrimSpace(attrs.Attachment.Title + " " + attrs.Attachment.DocumentURL); detail != "" {
+				text += ": " + detail
+			}
+			add(formatOptionalTime(attrs.Modified), kind, text)
+		}
+	}
+	sortTimeline(timeline)
+	timeline.Notes = append(timeline.Notes, "Timeline notes, status changes and responder changes aren't available through the API; open the incident in Datadog for those.")
+	return timeline
+}
+
+// sortTimeline orders entries oldest first.
+func sortTimeline(timeline *IncidentTimeline) {
+	// RFC3339 times in UTC sort chronologically as strings.
+	sort.SliceStable(timeline.Entries, func(i, j int) bool {
+		return timeline.Entries[i].Time < timeline.Entries[j].Time
+	})
+}
+
+func incidentTitle(incident IncidentSummary) string {
+	if incident.PublicID != 0 {
+		return fmt.Sprintf("Incident %d: %s", incident.PublicID, incident.Title)
+	}
+	return incident.Title
+}
+
+func formatIncidentTimelineMarkdown(timeline *IncidentTimeline) string {
+	incident := timeline.Incident
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", incidentTitle(incident))
+	var facts []string
+	for _, fact := range []string{incident.Severity, incident.State} {
+		if fact != "" {
+			facts = append(facts, fact)
+		}
+	}
+	if incident.Commander != nil {
+		facts = append(facts, "commander: "+incidentUserName(incident.Commander))
+	}
+	if incident.CustomerImpacted {
+		facts = append(facts, "customer impact")
+	}
+	if len(facts) > 0 {
+		fmt.Fprintf(&b, "%s\n\n", strings.Join(facts, " · "))
+	}
+	fmt.Fprintf(&b, "[Incident in Datadog](%s)\n\n", incident.URL)
+
+	writeTimelineTable(&b, timeline.Entries)
+	for _, note := range timeline.Notes {
+		fmt.Fprintf(&b, "\n_%s_\n", note)
+	}
+	return b.String()
+}
+
+// writeTimelineTable renders timeline entries as a markdown table.
+func writeTimelineTable(b *strings.Builder, entries []TimelineEntry) {
+	if len(entries) == 0 {
+		b.WriteString("No timeline events were found.\n")
+		return
+	}
+	b.WriteString("| Time (UTC) | Event |\n|---|---|\n")
+	for _, entry := range entries {
+		at := entry.Time
+		if t, err := time.Parse(time.RFC3339, entry.Time); err == nil {
+			at = t.UTC().Format(mermaidTimeLayout)
+		}
+		fmt.Fprintf(b, "| %s | %s |\n", at, markdownCell(entry.Text))
+	}
+}
+
+// markdownCell makes text safe inside a markdown table cell.
+func markdownCell(text string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(text, "|", "\\|"), "\n", " ")
+}
+
+func incidentUserName(user *IncidentUser) string {
+	for _, name := range []string{user.Name, user.Handle, user.Email} {
+		if name != "" {
+			return name
+		}
+	}
+	return user.ID
+}
+
+func todoAssignees(assignees []datadogV2.IncidentTodoAssignee) string {
+	names := make([]string, 0, len(assignees))
+	for _, assignee := range assignees {
+		switch {
+		case assignee.IncidentTodoAssigneeHandle != nil:
+			names = append(names, *assignee.IncidentTodoAssigneeHandle)
+		case assignee.IncidentTodoAnonymousAssignee != nil:
+			names = append(names, assignee.IncidentTodoAnonymousAssignee.Name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testIncidentData = `{"id":"8a9b2c3d","type":"incidents",
+	"attributes":{"title":"Checkout errors","public_id":42,"severity":"SEV-2","state":"active","customer_impacted":true,
+		"customer_impact_scope":"Card payments fail","customer_impact_start":"2026-01-20T09:05:00Z","customer_impact_duration":600,
+		"created":"2026-01-20T09:10:00Z","declared":"2026-01-20T09:10:00Z",
+		"fields":{"state":{"type":"dropdown","value":"active"},"teams":{"type":"autocomplete","value":["web","payments"]},"summary":{"type":"textbox","value":null}}},
+	"relationships":{"commander_user":{"data":{"id":"u-1","type":"users"}},"declared_by_user":{"data":{"id":"u-2","type":"users"}}}}`
+
+const testIncidentUsers = `[{"id":"u-1","type":"users","attributes":{"name":"Ana","handle":"ana@example.com","email":"ana@example.com"}}]`
+
+func TestListIncidents(t *testing.T) {
+	var query string
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/incidents/search" {
+			http.NotFound(w, r)
+			return
+		}
+		query = r.URL.Query().Get("query")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"type":"incidents_search_results","attributes":{"facets":{},"total":1,"incidents":[{"data":` + testIncidentData + `}]}},"included":` + testIncidentUsers + `}`))
+	})
+
+	result, err := server.ListIncidents(ListIncidentsParams{Severities: []string{"sev-1", "sev-2"}, Query: "teams:payments"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "state:(active OR stable) severity:(SEV-1 OR SEV-2) teams:payments" || result.Query != query {
+		t.Fatalf("unexpected search query %q", query)
+	}
+	if result.Total != 1 || len(result.Incidents) != 1 {
+		t.Fatalf("expected one incident, got %+v", result)
+	}
+	incident := result.Incidents[0]
+	if incident.Severity != "SEV-2" || incident.State != "active" || !incident.CustomerImpacted || incident.URL != "https://app.datadoghq.com/incidents/42" {
+		t.Fatalf("unexpected summary: %+v", incident)
+	}
+	if incident.Commander == nil || incident.Commander.Name != "Ana" {
+		t.Fatalf("expected the commander resolved from included users, got %+v", incident.Commander)
+	}
+
+	if _, err := server.ListIncidents(ListIncidentsParams{States: []string{"open"}}); err == nil {
+		t.Fatal("expected an invalid state to be rejected")
+	}
+}
+
+func TestGetIncident(t *testing.T) {
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/incidents/8a9b2c3d" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":` + testIncidentData + `,"included":` + testIncidentUsers + `}`))
+	})
+
+	incident, err := server.GetIncident(GetIncidentParams{IncidentID: "8a9b2c3d"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if incident.Title != "Checkout errors" || incident.CustomerImpactScope != "Card payments fail" || incident.CustomerImpactStart != "2026-01-20T09:05:00Z" {
+		t.Fatalf("unexpected incident: %+v", incident)
+	}
+	if incident.CustomerImpactDuration == nil || *incident.CustomerImpactDuration != 600 {
+		t.Fatalf("expected the customer impact duration, got %v", incident.CustomerImpactDuration)
+	}
+	if incident.DeclaredBy == nil || incident.DeclaredBy.ID != "u-2" || incident.DeclaredBy.Name != "" {
+		t.Fatalf("expected the declaring user by ID only, got %+v", incident.DeclaredBy)
+	}
+	if len(incident.Fields) != 1 || len(incident.Fields["teams"]) != 2 || incident.Fields["teams"][0] != "payments" {
+		t.Fatalf("expected only the teams field, sorted, got %v", incident.Fields)
+	}
+}
+
+func TestIncidentGantt(t *testing.T) {
+	incident := &Incident{
+		IncidentSummary:     IncidentSummary{PublicID: 42, Title: "Checkout: card errors", State: "active", Created: "2026-01-20T09:10:00Z"},
+		CustomerImpactStart: "2026-01-20T09:05:00Z",
+		Detected:            "2026-01-20T09:07:00Z",
+		Declared:            "2026-01-20T09:10:00Z",
+	}
+	chart := incidentGantt(incident, time.Date(2026, 1, 20, 10, 0, 0, 0, time.UTC))
+
+	for _, want := range []string{
+		"```mermaid\ngantt\n",
+		"title Incident 42 - Checkout - card errors\n",
+		"Open :active, 2026-01-20 09:10, 2026-01-20 10:00\n",
+		"Impact :crit, 2026-01-20 09:05, 2026-01-20 10:00\n",
+		"Detected :milestone, 2026-01-20 09:07, 0m\n",
+	} {
+		if !strings.Contains(chart, want) {
+			t.Fatalf("expected %q in chart:\n%s", want, chart)
+		}
+	}
+	if strings.Contains(chart, "Resolved") {
+		t.Fatalf("expected no resolved milestone for an open incident:\n%s", chart)
+	}
+}
+
+func TestGetIncidentTimeline(t *testing.T) {
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v2/incidents/8a9b2c3d":
+			_, _ = w.Write([]byte(`{"data":` + testIncidentData + `,"included":` + testIncidentUsers + `}`))
+		case "/api/v2/incidents/8a9b2c3d/relationships/todos":
+			_, _ = w.Write([]byte(`{"data":[{"id":"t-1","type":"incident_todos","attributes":{"content":"Roll back checkout","assignees":["@ana"],
+				"created":"2026-01-20T09:12:00Z","completed":"2026-01-20T09:20:00Z"}}]}`))
+		case "/api/v2/incidents/8a9b2c3d/attachments":
+			_, _ = w.Write([]byte(`{"data":[{"id":"a-1","type":"incident_attachments","relationships":{},
+				"attributes":{"attachment_type":"link","attachment":{"title":"Runbook","documentUrl":"https://wiki.example.com/checkout"},"modified":"2026-01-20T09:15:00Z"}}]}`))
+		default:
+			http.Error(w, `{"errors":["forbidden"]}`, http.StatusForbidden)
+		}
+	})
+
+	text, err := server.GetIncidentTimeline(GetIncidentTimelineParams{IncidentID: "8a9b2c3d"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"| 2026-01-20 09:05 | Customer impact started: Card payments fail |",
+		"| 2026-01-20 09:10 | Incident declared by u-2 |",
+		"| 2026-01-20 09:12 | Todo added: Roll back checkout (assigned to @ana) |",
+		"| 2026-01-20 09:15 | Link attached: Runbook https://wiki.example.com/checkout |",
+		"| 2026-01-20 09:20 | Todo completed: Roll back checkout |",
+	}
+	last := -1
+	for _, line := range want {
+		i := strings.Index(text, line)
+		if i < last {
+			t.Fatalf("expected %q after the previous entries in:\n%s", line, text)
+		}
+		last = i
+	}
+	if !strings.Contains(text, "# Incident 42: Checkout errors") || !strings.Contains(text, "commander: Ana") || !strings.Contains(text, "Couldn't read impacts") {
+		t.Fatalf("unexpected timeline:\n%s", text)
+	}
+
+	if _, err := server.GetIncidentTimeline(GetIncidentTimelineParams{IncidentID: "8a9b2c3d", Format: "html"}); err == nil {
+		t.Fatal("expected an unknown format to be rejected")
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Datadog accepts log events whose timestamp is up to 18 hours in the
+// past or 2 hours in the future of when they arrive, so any log ingested
+// during a range has an event time within these margins of it.
+const (
+	maxLogBackdate = 18 * time.Hour
+	maxLogPostdate = 2 * time.Hour
+)
+
+// ingestWindow turns a range of ingestion times into the event-time range
+// that must be searched to find every log ingested during it. The logs
+// search API only filters on event time, so this is as close to an
+// ingestion-time filter as it permits: the result is a superset that
+// also holds logs ingested outside the range.
+func ingestWindow(timeField string, from, to time.Time) (time.Time, time.Time, string, error) {
+	switch strings.ToLower(timeField) {
+	case "", "event":
+		return from, to, "", nil
+	case "ingest":
+		note := fmt.Sprintf("time_field is ingest: Datadog can't search by ingestion time, so the range was widened to the event times a log ingested in it can carry (%s earlier, %s later). Backdated logs are included, along with logs ingested outside the requested range.",
+			maxLogBackdate, maxLogPostdate)
+		return from.Add(-maxLogBackdate), to.Add(maxLogPostdate), note, nil
+	default:
+		return time.Time{}, time.Time{}, "", fmt.Errorf("time_field must be event or ingest, got %q", timeField)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIngestWindow(t *testing.T) {
+	from := time.Date(2026, 1, 20, 12, 0, 0, 0, time.UTC)
+	to := from.Add(5 * time.Minute)
+
+	gotFrom, gotTo, note, err := ingestWindow("", from, to)
+	if err != nil || !gotFrom.Equal(from) || !gotTo.Equal(to) || note != "" {
+		t.Fatalf("expected event time to leave the range alone, got %v %v %q %v", gotFrom, gotTo, note, err)
+	}
+
+	gotFrom, gotTo, note, err = ingestWindow("INGEST", from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !gotFrom.Equal(from.Add(-18*time.Hour)) || !gotTo.Equal(to.Add(2*time.Hour)) || note == "" {
+		t.Fatalf("unexpected ingest window: %v %v %q", gotFrom, gotTo, note)
+	}
+
+	if _, _, _, err := ingestWindow("arrival", from, to); err == nil {
+		t.Fatal("expected an unknown time field to be rejected")
+	}
+}
+
+func TestQueryLogsByIngestTime(t *testing.T) {
+	var filterFrom, filterTo string
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		data, _ := io.ReadAll(r.Body)
+		var body struct {
+			Filter struct {
+				From string `json:"from"`
+				To   string `json:"to"`
+			} `json:"filter"`
+		}
+		_ = json.Unmarshal(data, &body)
+		filterFrom, filterTo = body.Filter.From, body.Filter.To
+		_, _ = w.Write([]byte(`{"data":[{"id":"1","attributes":{"message":"late"}}]}`))
+	})
+
+	result, err := server.QueryLogs(QueryLogsParams{Query: "*", From: "2026-01-20T12:00:00Z", To: "2026-01-20T12:05:00Z", TimeField: "ingest"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filterFrom != "2026-01-19T18:00:00Z" || filterTo != "2026-01-20T14:05:00Z" {
+		t.Fatalf("expected the widened range to be searched, got %s to %s", filterFrom, filterTo)
+	}
+	if len(result.Notes) != 1 || !strings.Contains(result.Notes[0], "ingestion time") {
+		t.Fatalf("expected a note about the widened range, got %v", result.Notes)
+	}
+}
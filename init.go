@@ -0,0 +1,299 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadog"
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV2"
+)
+
+// setupSites are the sites init tries an API key on, in the order a match
+// is preferred.
+var setupSites = []string{
+	"datadoghq.com",
+	"datadoghq.eu",
+	"us3.datadoghq.com",
+	"us5.datadoghq.com",
+	"ap1.datadoghq.com",
+	"ap2.datadoghq.com",
+	govSite,
+}
+
+// setupCheckTimeout bounds each key check init makes.
+const setupCheckTimeout = 15 * time.Second
+
+// setupConfig is the config file init writes. The server reads it at
+// startup for whichever of DD_API_KEY, DD_APP_KEY and DD_SITE aren't set.
+type setupConfig struct {
+	APIKey string `json:"api_key"`
+	AppKey string `json:"app_key,omitempty"`
+	Site   string `json:"site"`
+}
+
+// defaultConfigPath is where init writes the config file and the server
+// looks for it when DD_MCP_CONFIG_FILE isn't set.
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "go-dd-mcp", "config.json")
+}
+
+// applyConfigFile sets DD_API_KEY, DD_APP_KEY and DD_SITE from the config
+// file written by init, leaving any already set alone. A missing file at
+// the default path is fine; one named by DD_MCP_CONFIG_FILE must exist.
+func applyConfigFile() error {
+	path := os.Getenv("DD_MCP_CONFIG_FILE")
+	explicit := path != ""
+	if !explicit {
+		if path = defaultConfigPath(); path == "" {
+			return nil
+		}
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !explicit {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	var config setupConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	for name, value := range map[string]string{"DD_API_KEY": config.APIKey, "DD_APP_KEY": config.AppKey, "DD_SITE": config.Site} {
+		if _, set := os.LookupEnv(name); !set && value != "" {
+			os.Setenv(name, value)
+		}
+	}
+	log.Printf("Loaded config file %s", path)
+	return nil
+}
+
+// runInit sets the server up interactively: it asks for the keys, checks
+// them, finds the site the org is on, writes the config file and prints
+// the block to add to an MCP client's configuration.
+func runInit(args []string, in io.Reader, out io.Writer, client *datadog.APIClient) error {
+	flags := flag.NewFlagSet("init", flag.ContinueOnError)
+	flags.SetOutput(out)
+	path := flags.String("config", cmp.Or(os.Getenv("DD_MCP_CONFIG_FILE"), defaultConfigPath()), "config file to write")
+	site := flags.String("site", os.Getenv("DD_SITE"), "Datadog site, such as datadoghq.eu; found from the API key when not given")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(flags.Args(), " "))
+	}
+	if *path == "" {
+		return fmt.Errorf("no config directory found; pass -config")
+	}
+
+	input := bufio.NewScanner(in)
+	ask := func(prompt, current string, secret bool) string {
+		if current != "" {
+			prompt += fmt.Sprintf(" [%s]", maskKey(current))
+		}
+		fmt.Fprintf(out, "%s: ", prompt)
+		if secret {
+			if restore, hidden := hideInput(in); hidden {
+				defer fmt.Fprintln(out)
+				defer restore()
+			}
+		}
+		if !input.Scan() {
+			return current
+		}
+		return cmp.Or(strings.TrimSpace(input.Text()), current)
+	}
+
+	config := setupConfig{}
+	config.APIKey = ask("Datadog API key", os.Getenv("DD_API_KEY"), true)
+	if config.APIKey == "" {
+		return fmt.Errorf("an API key is required; create one under Organization Settings > API Keys")
+	}
+
+	sites := setupSites
+	if *site != "" {
+		sites = []string{normalizeSite(*site)}
+	}
+	fmt.Fprintf(out, "Checking the API key on %s...\n", strings.Join(sites, ", "))
+	detected, err := detectSite(client, config.APIKey, sites)
+	if err != nil {
+		return err
+	}
+	config.Site = detected
+	fmt.Fprintf(out, "API key is valid on %s.\n", config.Site)
+
+	config.AppKey = ask("Datadog application key (empty to only post events)", os.Getenv("DD_APP_KEY"), true)
+	if config.AppKey != "" {
+		if err := checkAppKey(client, config); err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "Application key is valid.")
+	} else {
+		fmt.Fprintln(out, "Without an application key only tools that post events or make no Datadog call are available.")
+	}
+
+	if _, err := os.Stat(*path); err == nil {
+		if answer := ask(fmt.Sprintf("%s exists; overwrite it? (y/N)", *path), "", false); !strings.EqualFold(answer, "y") && !strings.EqualFold(answer, "yes") {
+			return fmt.Errorf("left %s as it was", *path)
+		}
+	}
+	if err := writeSetupConfig(*path, config); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Wrote %s.\n\n", *path)
+
+	snippet, err := clientConfigSnippet(*path)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "Add this server to your MCP client. Claude Desktop reads claude_desktop_config.json, in ~/Library/Application Support/Claude on macOS and %APPDATA%\\Claude on Windows; Cursor reads ~/.cursor/mcp.json or .cursor/mcp.json in a project:")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, snippet)
+	return nil
+}
+
+// detectSite returns the first of sites that accepts the API key. The
+// sites are checked at once, since a key is only valid on one.
+func detectSite(client *datadog.APIClient, apiKey string, sites []string) (string, error) {
+	errs := make([]error, len(sites))
+	valid := make([]bool, len(sites))
+	var wg sync.WaitGroup
+	for i, site := range sites {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(newDatadogContext(context.Background(), apiKey, "", site), setupCheckTimeout)
+			defer cancel()
+			_, httpResp, err := datadogV1.NewAuthenticationApi(client).Validate(ctx)
+			valid[i] = err == nil
+			if err != nil && httpStatus(httpResp) != http.StatusForbidden {
+				errs[i] = fmt.Errorf("%s: %w", site, err)
+			}
+		}()
+	}
+	wg.Wait()
+	for i, site := range sites {
+		if valid[i] {
+			return site, nil
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return "", fmt.Errorf("the API key wasn't accepted, and some sites couldn't be checked:\n%w", err)
+	}
+	return "", fmt.Errorf("no Datadog site accepted the API key; check it was copied whole")
+}
+
+// checkAppKey lists the key owner's own application keys, which any valid
+// application key may do.
+func checkAppKey(client *datadog.APIClient, config setupConfig) error {
+	ctx, cancel := context.WithTimeout(newDatadogContext(context.Background(), config.APIKey, config.AppKey, config.Site), setupCheckTimeout)
+	defer cancel()
+	_, httpResp, err := datadogV2.NewKeyManagementApi(client).ListCurrentUserApplicationKeys(ctx,
+		*datadogV2.NewListCurrentUserApplicationKeysOptionalParameters().WithPageSize(1))
+	if err == nil {
+		return nil
+	}
+	if status := httpStatus(httpResp); status == http.StatusForbidden || status == http.StatusUnauthorized {
+		return fmt.Errorf("%s rejected the application key; check it belongs to the same org as the API key and its scopes allow reading its own keys", config.Site)
+	}
+	return fmt.Errorf("failed to check the application key: %w", err)
+}
+
+// hideInput turns off echo while a key is typed, when in is a terminal.
+// It returns whether it did, and the function that turns echo back on.
+func hideInput(in io.Reader) (func(), bool) {
+	f, ok := in.(*os.File)
+	if !ok {
+		return nil, false
+	}
+	if info, err := f.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return nil, false
+	}
+	stty := func(arg string) error {
+		cmd := exec.Command("stty", arg)
+		cmd.Stdin = f
+		return cmd.Run()
+	}
+	// Without stty, as on Windows, the key is shown as it is typed.
+	if stty("-echo") != nil {
+		return nil, false
+	}
+	return func() { _ = stty("echo") }, true
+}
+
+// writeSetupConfig writes the config file readable only by its owner, as
+// it holds the keys. It is written to a new file and renamed into place,
+// so an existing file's looser mode isn't kept.
+func writeSetupConfig(path string, config setupConfig) error {
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	// CreateTemp makes the file with mode 0600.
+	f, err := os.CreateTemp(filepath.Dir(path), ".config-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// clientConfigSnippet is the mcpServers entry Claude Desktop and Cursor
+// both read. It names the config file rather than repeating the keys.
+func clientConfigSnippet(configPath string) (string, error) {
+	command, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the server's path: %w", err)
+	}
+	entry := map[string]interface{}{"command": command}
+	if configPath != defaultConfigPath() {
+		entry["env"] = map[string]string{"DD_MCP_CONFIG_FILE": configPath}
+	}
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"mcpServers": map[string]interface{}{"datadog": entry},
+	}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// maskKey shows enough of a key to recognize it.
+func maskKey(key string) string {
+	if len(key) <= 8 {
+		return strings.Repeat("*", len(key))
+	}
+	return key[:4] + "..." + key[len(key)-4:]
+}
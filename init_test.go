@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadog"
+)
+
+// newSiteClient returns a client that sends each site's requests to
+// handler under a path prefix naming the site, such as /datadoghq.eu/.
+func newSiteClient(t *testing.T, handler http.HandlerFunc) *datadog.APIClient {
+	t.Helper()
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+	configuration := datadog.NewConfiguration()
+	configuration.Servers = datadog.ServerConfigurations{{
+		URL:       ts.URL + "/{site}",
+		Variables: map[string]datadog.ServerVariable{"site": {DefaultValue: "datadoghq.com"}},
+	}}
+	return datadog.NewAPIClient(configuration)
+}
+
+func TestRunInit(t *testing.T) {
+	t.Setenv("DD_API_KEY", "")
+	t.Setenv("DD_APP_KEY", "")
+	t.Setenv("DD_SITE", "")
+	t.Setenv("DD_MCP_CONFIG_FILE", "")
+	client := newSiteClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/datadoghq.eu/api/v1/validate" && r.Header.Get("DD-API-KEY") == "eu-api-key":
+			_, _ = w.Write([]byte(`{"valid":true}`))
+		case r.URL.Path == "/datadoghq.eu/api/v2/current_user/application_keys" && r.Header.Get("DD-APPLICATION-KEY") == "eu-app-key":
+			_, _ = w.Write([]byte(`{"data":[]}`))
+		default:
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["Forbidden"]}`))
+		}
+	})
+	path := filepath.Join(t.TempDir(), "go-dd-mcp", "config.json")
+
+	var out bytes.Buffer
+	if err := runInit([]string{"-config", path}, strings.NewReader("eu-api-key\neu-app-key\n"), &out, client); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var config setupConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		t.Fatal(err)
+	}
+	if config != (setupConfig{APIKey: "eu-api-key", AppKey: "eu-app-key", Site: "datadoghq.eu"}) {
+		t.Fatalf("unexpected config %+v", config)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected the config readable only by its owner, got %v", info.Mode())
+	}
+	if !strings.Contains(out.String(), `"mcpServers"`) || !strings.Contains(out.String(), `"DD_MCP_CONFIG_FILE": "`+path+`"`) {
+		t.Fatalf("expected the client config snippet, got %s", out.String())
+	}
+
+	if err := runInit([]string{"-config", path}, strings.NewReader("eu-api-key\neu-app-key\nn\n"), &out, client); err == nil {
+		t.Fatal("expected an existing config to be kept when not confirmed")
+	}
+	if err := runInit([]string{"-config", path}, strings.NewReader("eu-api-key\nother-app-key\n"), &out, client); err == nil || !strings.Contains(err.Error(), "rejected the application key") {
+		t.Fatalf("expected the application key to be rejected, got %v", err)
+	}
+	if err := runInit([]string{"-config", path, "-site", "us3"}, strings.NewReader("eu-api-key\n"), &out, client); err == nil || !strings.Contains(err.Error(), "no Datadog site accepted") {
+		t.Fatalf("expected the key to be rejected on the given site, got %v", err)
+	}
+}
+
+func TestWriteSetupConfigTightensMode(t *testing.T) {
+	path := writeTempFile(t, "config.json", "{}")
+	if err := os.Chmod(path, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeSetupConfig(path, setupConfig{APIKey: "api-key", Site: "datadoghq.com"}); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected an existing config to be made readable only by its owner, got %v", info.Mode())
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Fatalf("expected no temporary files left behind, got %v", entries)
+	}
+}
+
+func TestApplyConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := writeSetupConfig(path, setupConfig{APIKey: "file-api-key", AppKey: "file-app-key", Site: "datadoghq.eu"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DD_MCP_CONFIG_FILE", path)
+	t.Setenv("DD_API_KEY", "env-api-key")
+	// t.Setenv restores the variables afterwards; unset the ones the file
+	// should fill.
+	t.Setenv("DD_APP_KEY", "")
+	t.Setenv("DD_SITE", "")
+	os.Unsetenv("DD_APP_KEY")
+	os.Unsetenv("DD_SITE")
+
+	if err := applyConfigFile(); err != nil {
+		t.Fatal(err)
+	}
+	if os.Getenv("DD_API_KEY") != "env-api-key" || os.Getenv("DD_APP_KEY") != "file-app-key" || os.Getenv("DD_SITE") != "datadoghq.eu" {
+		t.Fatalf("expected the environment to win over the file, got %s %s %s", os.Getenv("DD_API_KEY"), os.Getenv("DD_APP_KEY"), os.Getenv("DD_SITE"))
+	}
+
+	t.Setenv("DD_MCP_CONFIG_FILE", filepath.Join(t.TempDir(), "missing.json"))
+	if err := applyConfigFile(); err == nil {
+		t.Fatal("expected a missing named config file to be rejected")
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+)
+
+// LogSuppression hides logs whose message matches Pattern, optionally only
+// for one service, such as health checks or a known benign warning.
+type LogSuppression struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+	Service string `json:"service,omitempty"`
+
+	re *regexp.Regexp
+}
+
+// suppressionList holds the configured suppressions. A nil list hides
+// nothing.
+type suppressionList struct {
+	rules []LogSuppression
+}
+
+// loadSuppressions reads the suppressions file and compiles each pattern.
+func loadSuppressions(path string) (*suppressionList, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read suppressions file: %w", err)
+	}
+	var file struct {
+		Suppressions []LogSuppression `json:"suppressions"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse suppressions file: %w", err)
+	}
+
+	list := &suppressionList{}
+	seen := make(map[string]bool)
+	for _, rule := range file.Suppressions {
+		if rule.Name == "" || rule.Pattern == "" {
+			return nil, fmt.Errorf("suppression %q needs a name and a pattern", rule.Name)
+		}
+		if seen[rule.Name] {
+			return nil, fmt.Errorf("suppression %s is defined twice", rule.Name)
+		}
+		seen[rule.Name] = true
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("suppression %s: invalid pattern: %w", rule.Name, err)
+		}
+		rule.re = re
+		list.rules = append(list.rules, rule)
+	}
+	return list, nil
+}
+
+// match returns the name of the first suppression that hides the log, or
+// "" when none does.
+func (l *suppressionList) match(entry LogEntry) string {
+	if l == nil {
+		return ""
+	}
+	for _, rule := range l.rules {
+		if rule.Service != "" && rule.Service != entry.Service {
+			continue
+		}
+		if rule.re.MatchString(entry.Message) {
+			return rule.Name
+		}
+	}
+	return ""
+}
+
+// suppressionNote summarizes what was hidden, busiest suppression first.
+func suppressionNote(counts map[string]int) string {
+	names := make([]string, 0, len(counts))
+	total := 0
+	for name, count := range counts {
+		names = append(names, name)
+		total += count
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	note := fmt.Sprintf("%d logs matching configured suppressions were hidden (", total)
+	for i, name := range names {
+		if i > 0 {
+			note += ", "
+		}
+		note += fmt.Sprintf("%s: %d", name, counts[name])
+	}
+	return note + "); pass include_suppressed to see them."
+}
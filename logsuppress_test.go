@@ -0,0 +1,84 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const testSuppressions = `{"suppressions": [
+  {"name": "health-checks", "pattern": "GET /(healthz|ready)"},
+  {"name": "pool-warning", "pattern": "^pool nearly exhausted", "service": "checkout"}
+]}`
+
+func TestLoadSuppressions(t *testing.T) {
+	list, err := loadSuppressions(writeTempFile(t, "suppressions.json", testSuppressions))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		entry LogEntry
+		want  string
+	}{
+		{LogEntry{Service: "web", Message: "GET /healthz 200"}, "health-checks"},
+		{LogEntry{Service: "checkout", Message: "pool nearly exhausted (9/10)"}, "pool-warning"},
+		{LogEntry{Service: "payments", Message: "pool nearly exhausted (9/10)"}, ""},
+		{LogEntry{Service: "checkout", Message: "card declined"}, ""},
+	}
+	for _, c := range cases {
+		if got := list.match(c.entry); got != c.want {
+			t.Errorf("match(%+v) = %q, want %q", c.entry, got, c.want)
+		}
+	}
+
+	var none *suppressionList
+	if none.match(cases[0].entry) != "" {
+		t.Fatal("expected a nil list to hide nothing")
+	}
+
+	for _, content := range []string{
+		`{"suppressions": [{"name": "bad", "pattern": "("}]}`,
+		`{"suppressions": [{"name": "empty"}]}`,
+		`{"suppressions": [{"name": "a", "pattern": "x"}, {"name": "a", "pattern": "y"}]}`,
+	} {
+		if _, err := loadSuppressions(writeTempFile(t, "suppressions.json", content)); err == nil {
+			t.Errorf("expected %s to be rejected", content)
+		}
+	}
+}
+
+func TestQueryLogsHidesSuppressedLogs(t *testing.T) {
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":[
+			{"id":"1","attributes":{"message":"GET /healthz 200","service":"web","timestamp":"2026-01-20T10:00:00Z"}},
+			{"id":"2","attributes":{"message":"card declined","service":"checkout","timestamp":"2026-01-20T10:01:00Z"}},
+			{"id":"3","attributes":{"message":"GET /ready 200","service":"web","timestamp":"2026-01-20T10:02:00Z"}}]}`))
+	})
+	list, err := loadSuppressions(writeTempFile(t, "suppressions.json", testSuppressions))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.suppressions = list
+
+	params := QueryLogsParams{Query: "*", From: "2026-01-20T09:00:00Z", To: "2026-01-20T11:00:00Z"}
+	result, err := server.QueryLogs(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Count != 1 || result.Logs[0].ID != "2" || result.Suppressed["health-checks"] != 2 {
+		t.Fatalf("expected two health checks hidden, got %+v", result)
+	}
+	if len(result.Notes) != 1 || !strings.Contains(result.Notes[0], "2 logs matching configured suppressions were hidden (health-checks: 2)") {
+		t.Fatalf("unexpected notes: %v", result.Notes)
+	}
+
+	params.IncludeSuppressed = true
+	result, err = server.QueryLogs(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Count != 3 || result.Suppressed != nil {
+		t.Fatalf("expected every log with include_suppressed, got %+v", result)
+	}
+}
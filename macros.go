@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// MacroDefinition declares a tool that runs several existing tools in
+// order. Step arguments are Go templates over the macro's arguments and,
+// under .steps, the decoded results of earlier steps.
+type MacroDefinition struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	InputSchema InputSchema `json:"inputSchema"`
+	Steps       []MacroStep `json:"steps"`
+}
+
+type MacroStep struct {
+	Name      string                 `json:"name"`
+	Tool      string                 `json:"tool"`
+	Arguments map[string]interface{} `json:"arguments,omitempty"`
+}
+
+// MacroStepResult is one step's output in a macro result. A failed step
+// records its error and the macro carries on, since later steps are often
+// still useful on their own.
+type MacroStepResult struct {
+	Step   string          `json:"step"`
+	Tool   string          `json:"tool"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+type MacroResult struct {
+	Macro string            `json:"macro"`
+	Steps []MacroStepResult `json:"steps"`
+}
+
+// macroRegistry holds the configured macros. A nil registry has none.
+type macroRegistry struct {
+	tools  []Tool
+	byName map[string]MacroDefinition
+}
+
+// loadMacros reads macro definitions and checks that every step calls a
+// known tool and every argument template parses. Macros can't call other
+// macros.
+func loadMacros(path string, known []Tool) (*macroRegistry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read macros file: %w", err)
+	}
+	var file struct {
+		Macros []MacroDefinition `json:"macros"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse macros file: %w", err)
+	}
+
+	tools := make(map[string]bool)
+	annotations := make(map[string]*ToolAnnotations)
+	for _, tool := range known {
+		tools[tool.Name] = true
+		annotations[tool.Name] = tool.Annotations
+	}
+
+	registry := &macroRegistry{byName: make(map[string]MacroDefinition)}
+	for _, macro := range file.Macros {
+		if macro.Name == "" || len(macro.Steps) == 0 {
+			return nil, fmt.Errorf("macro %q needs a name and at least one step", macro.Name)
+		}
+		if tools[macro.Name] || registry.byName[macro.Name].Name != "" {
+			return nil, fmt.Errorf("macro %s: tool %s is already defined", macro.Name, macro.Name)
+		}
+
+		steps := make(map[string]bool)
+		// A macro only reads when each of its steps does.
+		macroAnnotations := readOnlyToolAnnotations()
+		for _, step := range macro.Steps {
+			if step.Name == "" || steps[step.Name] {
+				return nil, fmt.Errorf("macro %s: step names must be unique and non-empty", macro.Name)
+			}
+			steps[step.Name] = true
+			if !tools[step.Tool] {
+				return nil, fmt.Errorf("macro %s: step %s calls unknown tool %s", macro.Name, step.Name, step.Tool)
+			}
+			if a := annotations[step.Tool]; a == nil || !a.ReadOnlyHint {
+				macroAnnotations.ReadOnlyHint = false
+				macroAnnotations.DestructiveHint = macroAnnotations.DestructiveHint || a == nil || a.DestructiveHint
+			}
+			if _, err := renderArguments(step.Arguments, nil, true); err != nil {
+				return nil, fmt.Errorf("macro %s: step %s: %w", macro.Name, step.Name, err)
+			}
+		}
+
+		if macro.InputSchema.Type == "" {
+			macro.InputSchema.Type = "object"
+		}
+		if macro.InputSchema.Properties == nil {
+			macro.InputSchema.Properties = map[string]SchemaProperty{}
+		}
+		registry.byName[macro.Name] = macro
+		registry.tools = append(registry.tools, Tool{
+			Name:        macro.Name,
+			Description: macro.Description,
+			InputSchema: macro.InputSchema,
+			Annotations: macroAnnotations,
+		})
+	}
+	return registry, nil
+}
+
+func (r *macroRegistry) list() []Tool {
+	if r == nil {
+		return nil
+	}
+	return r.tools
+}
+
+func (r *macroRegistry) lookup(name string) (MacroDefinition, bool) {
+	if r == nil {
+		return MacroDefinition{}, false
+	}
+	macro, ok := r.byName[name]
+	return macro, ok
+}
+
+// runMacro executes the macro's steps in order.
+func (s *MCPServer) runMacro(macro MacroDefinition, arguments json.RawMessage) (*MacroResult, *MCPError) {
+	args := make(map[string]interface{})
+	if len(arguments) > 0 {
+		if err := json.Unmarshal(arguments, &args); err != nil {
+			return nil, &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+	}
+	for _, name := range macro.InputSchema.Required {
+		if _, ok := args[name]; !ok {
+			return nil, &MCPError{Code: -32602, Message: fmt.Sprintf("missing required argument: %s", name)}
+		}
+	}
+
+	// Declared but omitted arguments render as empty strings, so templates
+	// can supply defaults with {{or .window "1h"}}.
+	data := map[string]interface{}{}
+	for name := range macro.InputSchema.Properties {
+		data[name] = ""
+	}
+	for name, value := range args {
+		data[name] = value
+	}
+	stepData := map[string]interface{}{}
+	data["steps"] = stepData
+
+	result := &MacroResult{Macro: macro.Name}
+	for _, step := range macro.Steps {
+		stepResult := MacroStepResult{Step: step.Name, Tool: step.Tool}
+
+		rendered, err := renderArguments(step.Arguments, data, false)
+		if err != nil {
+			stepResult.Error = err.Error()
+			result.Steps = append(result.Steps, stepResult)
+			continue
+		}
+		// Each step is a tool call of its own, so a macro can't be used
+		// to get around the calls-per-minute quota.
+		if err := s.quotas.allowCall(s.quotaKey(), time.Now()); err != nil {
+			stepResult.Error = err.Error()
+			result.Steps = append(result.Steps, stepResult)
+			continue
+		}
+		text, toolErr := s.callTool(ToolCallParams{Name: step.Tool, Arguments: rendered})
+		if toolErr != nil {
+			stepResult.Error = toolErr.Message
+			result.Steps = append(result.Steps, stepResult)
+			continue
+		}
+
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(text), &decoded); err == nil {
+			stepResult.Result = json.RawMessage(text)
+			stepData[step.Name] = decoded
+		} else {
+			stepResult.Result, _ = json.Marshal(text)
+			stepData[step.Name] = text
+		}
+		result.Steps = append(result.Steps, stepResult)
+	}
+	return result, nil
+}
+
+// renderArguments executes every string in the argument tree as a
+// template. A string that is a single action and renders to a JSON number
+// or boolean becomes that value, so "{{.limit}}" can fill an integer
+// parameter. With parseOnly, templates are only checked.
+func renderArguments(arguments map[string]interface{}, data map[string]interface{}, parseOnly bool) (json.RawMessage, error) {
+	var render func(interface{}) (interface{}, error)
+	render = func(value interface{}) (interface{}, error) {
+		switch v := value.(type) {
+		case string:
+			return renderString(v, data, parseOnly)
+		case map[string]interface{}:
+			out := make(map[string]interface{}, len(v))
+			for k, item := range v {
+				r, err := render(item)
+				if err != nil {
+					return nil, err
+				}
+				out[k] = r
+			}
+			return out, nil
+		case []interface{}:
+			out := make([]interface{}, len(v))
+			for i, item := range v {
+				r, err := render(item)
+				if err != nil {
+					return nil, err
+				}
+				out[i] = r
+			}
+			return out, nil
+		default:
+			return v, nil
+		}
+	}
+
+	rendered, err := render(map[string]interface{}(arguments))
+	if err != nil || parseOnly {
+		return nil, err
+	}
+	return json.Marshal(rendered)
+}
+
+func renderString(text string, data map[string]interface{}, parseOnly bool) (interface{}, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	tmpl, err := template.New("argument").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	if parseOnly {
+		return text, nil
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return nil, err
+	}
+
+	trimmed := strings.TrimSpace(text)
+	if strings.HasPrefix(trimmed, "{{") && strings.HasSuffix(trimmed, "}}") && strings.Count(trimmed, "{{") == 1 {
+		var scalar interface{}
+		if err := json.Unmarshal(out.Bytes(), &scalar); err == nil {
+			switch scalar.(type) {
+			case float64, bool:
+				return scalar, nil
+			}
+		}
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const testMacros = `{"macros": [{
+  "name": "triage_service",
+  "description": "Recent errors for a service plus server stats",
+  "inputSchema": {
+    "type": "object",
+    "properties": {
+      "service": {"type": "string", "description": "Service name"},
+      "limit": {"type": "integer", "description": "Logs to fetch"},
+      "window": {"type": "string", "description": "Lookback"}
+    },
+    "required": ["service"]
+  },
+  "steps": [
+    {"name": "errors", "tool": "query_logs", "arguments": {"query": "service:{{.service}} status:error", "limit": "{{.limit}}", "from": "{{or .window \"15m\"}}"}},
+    {"name": "rest", "tool": "fetch_continuation", "arguments": {"id": "{{.steps.errors.query}}"}},
+    {"name": "stats", "tool": "server_stats"}
+  ]
+}]}`
+
+func TestMacroChainsTools(t *testing.T) {
+	var requests []string
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Filter struct {
+				Query string `json:"query"`
+			} `json:"filter"`
+			Page struct {
+				Limit int `json:"limit"`
+			} `json:"page"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		requests = append(requests, fmt.Sprintf("%s limit=%d", body.Filter.Query, body.Page.Limit))
+		fmt.Fprint(w, `{"data":[{"id":"1","attributes":{"message":"boom"}}]}`)
+	})
+
+	registry, err := loadMacros(writeTempFile(t, "macros.json", testMacros), server.ListTools())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a := registry.list()[0].Annotations; a == nil || !a.ReadOnlyHint {
+		t.Errorf("expected a macro of read tools to be read-only, got %+v", a)
+	}
+	server.macros = registry
+	server.results = newResultStore(1024)
+
+	text, toolErr := server.callTool(ToolCallParams{
+		Name:      "triage_service",
+		Arguments: json.RawMessage(`{"service":"checkout","limit":5}`),
+	})
+	if toolErr != nil {
+		t.Fatalf("unexpected error: %v", toolErr.Message)
+	}
+
+	if len(requests) != 1 || requests[0] != "service:checkout status:error limit=5" {
+		t.Errorf("unexpected Datadog requests: %q", requests)
+	}
+
+	var result MacroResult
+	if err := json.Unmarshal([]byte(text), &result); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Steps) != 3 {
+		t.Fatalf("expected 3 steps, got %d", len(result.Steps))
+	}
+	if !strings.Contains(string(result.Steps[0].Result), `"boom"`) {
+		t.Errorf("expected logs in first step, got %s", result.Steps[0].Result)
+	}
+	if !strings.Contains(result.Steps[1].Error, "service:checkout status:error") {
+		t.Errorf("expected second step to use the first step's result, got %q", result.Steps[1].Error)
+	}
+	if result.Steps[2].Error != "" || len(result.Steps[2].Result) == 0 {
+		t.Errorf("expected later steps to run after a failure, got %+v", result.Steps[2])
+	}
+
+	if _, toolErr := server.callTool(ToolCallParams{Name: "triage_service", Arguments: json.RawMessage(`{}`)}); toolErr == nil {
+		t.Error("expected missing required argument to fail")
+	}
+
+	requests = nil
+	server.quotas = newQuotaTracker(quotaLimits{CallsPerMinute: 2})
+	text, toolErr = server.callTool(ToolCallParams{Name: "triage_service", Arguments: json.RawMessage(`{"service":"checkout","limit":5}`)})
+	if toolErr != nil {
+		t.Fatalf("unexpected error: %v", toolErr.Message)
+	}
+	result = MacroResult{}
+	if err := json.Unmarshal([]byte(text), &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 1 || !strings.Contains(result.Steps[2].Error, "quota exceeded") {
+		t.Errorf("expected each step counted against the quota, got %+v", result.Steps)
+	}
+}
+
+func TestLoadMacrosValidation(t *testing.T) {
+	known := (&MCPServer{}).ListTools()
+	tests := map[string]string{
+		"unknown tool":    `{"macros":[{"name":"m","steps":[{"name":"a","tool":"nope"}]}]}`,
+		"shadows builtin": `{"macros":[{"name":"query_logs","steps":[{"name":"a","tool":"server_stats"}]}]}`,
+		"bad template":    `{"macros":[{"name":"m","steps":[{"name":"a","tool":"query_logs","arguments":{"query":"{{.x"}}]}]}`,
+		"duplicate step":  `{"macros":[{"name":"m","steps":[{"name":"a","tool":"server_stats"},{"name":"a","tool":"server_stats"}]}]}`,
+		"calls a macro":   `{"macros":[{"name":"m","steps":[{"name":"a","tool":"server_stats"}]},{"name":"n","steps":[{"name":"a","tool":"m"}]}]}`,
+	}
+	for name, content := range tests {
+		if _, err := loadMacros(writeTempFile(t, "macros.json", content), known); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
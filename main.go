@@ -4,18 +4,130 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
+	"net/http"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/DataDog/datadog-api-client-go/v2/api/datadog"
 	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV2"
+	"go.opentelemetry.io/otel"
 )
 
+const serverVersion = "0.1.0"
+
 type MCPServer struct {
-	ddClient *datadog.APIClient
-	ctx      context.Context
+	ddClient    *datadog.APIClient
+	credentials datadogCredentials
+	site        string
+	allowWrites bool
+	// apiKeyOnly is set when no application key was given; only tools
+	// that can work with the API key alone are offered.
+	apiKeyOnly bool
+	// disabledProducts are Datadog products the site doesn't offer; their
+	// tools are hidden.
+	disabledProducts []string
+	// handoff configures where post_slack_summary and create_jira_ticket
+	// send investigation summaries.
+	handoff handoffConfig
+
+	// ctx is set only on the per-request copy made by withContext. It
+	// carries the caller's credentials, cancellation and deadline.
+	ctx context.Context
+	// requestTimeout bounds each request; zero means no deadline.
+	requestTimeout time.Duration
+
+	// runbookHosts lists the hosts resolve_runbooks may fetch content from.
+	runbookHosts []string
+
+	// orgs are the other Datadog orgs compare_orgs can query, by name.
+	orgs map[string]OrgProfile
+	// profile names the org a copy made by forOrg calls.
+	profile string
+	// tenants is set in gateway mode, where each HTTP caller uses their
+	// own Datadog keys.
+	tenants *tenantStore
+
+	// quotas limits tool usage per caller: the authenticated identity of
+	// the current request, or its session when there is none. session
+	// also keys the state kept between a client's calls.
+	quotas  *quotaTracker
+	session string
+	caller  string
+	// attribution identifies the transport, session and user of the
+	// current request on outbound Datadog calls.
+	attribution requestAttribution
+
+	// results holds the remainder of tool results longer than
+	// maxResultBytes until fetch_continuation collects them.
+	results        *resultStore
+	maxResultBytes int
+	// tokens estimates result sizes for _meta; tokenBudgets caps them per
+	// client.
+	tokens       tokenEstimator
+	tokenBudgets *tokenBudgets
+	// plugins provides tools implemented by external executables.
+	plugins *pluginRegistry
+	// backends remembers which backend of each dual-path API an org
+	// supports.
+	backends *apiBackends
+	// contexts holds the defaults each session pinned with set_context.
+	contexts *contextStore
+	// snapshots records monitor and dashboard definitions for
+	// diff_resource.
+	snapshots *snapshotStore
+	// documents holds generated documents, such as postmortem drafts,
+	// for resources/read.
+	documents *documentStore
+	// names caches service and monitor names for fuzzy matching.
+	names *nameCache
+	// warmer prefetches names into the cache before they are needed.
+	warmer *warmer
+	// transcripts records each session's tool calls for export_session.
+	transcripts *transcriptStore
+	// requestLog logs request and response envelopes when enabled.
+	requestLog *requestLogger
+	// macros are tools that chain other tools.
+	macros *macroRegistry
+	// reports are the templates generate_report renders.
+	reports *reportRegistry
+	// suppressions hide known-noisy logs from query_logs results.
+	suppressions *suppressionList
+	// maintenance are the planned windows monitor tools annotate alerts
+	// with.
+	maintenance *maintenanceWindows
+	// geoip locates client IPs in query_logs results.
+	geoip *geoIPDatabases
+	// postProcessor rewrites tool results with operator-defined scripts.
+	postProcessor *postProcessor
+	// maxFrameBytes bounds HTTP responses; larger tool results are
+	// downloaded from /results/{id} instead.
+	maxFrameBytes int
+
+	startedAt time.Time
+
+	// telemetry is set when OpenTelemetry export is enabled.
+	telemetry         *instruments
+	shutdownTelemetry func(context.Context) error
+	// metricsHandler serves Prometheus metrics in HTTP mode when enabled.
+	metricsHandler http.Handler
+	// notify delivers notifications for the current request, and
+	// progressToken is set when its client asked for progress.
+	notify        func(MCPNotification)
+	progressToken json.RawMessage
+	// logStream, set by the call command's NDJSON mode, receives each
+	// page of query_logs results instead of the result holding them.
+	logStream func([]LogEntry) error
+	// adjustments records the changes made to the current call's
+	// arguments.
+	adjustments *argumentAdjustments
+	// failover serves reads with the secondary keys while the primary
+	// ones fail; failoverReport notes it for the current call.
+	failover       *failoverTransport
+	failoverReport *failoverReport
 }
 
 type MCPRequest struct {
@@ -38,42 +150,92 @@ type MCPError struct {
 }
 
 type SchemaProperty struct {
-	Type        string   `json:"type"`
-	Description string   `json:"description,omitempty"`
+	Type        string          `json:"type"`
+	Description string          `json:"description,omitempty"`
 	Items       *SchemaProperty `json:"items,omitempty"`
+	// Const is the only value the argument may take.
+	Const interface{} `json:"const,omitempty"`
 }
 
+// InputSchema describes a tool's arguments. Besides Required, it can say
+// which arguments need others (Dependencies), that at least one of several
+// alternatives must be given (AnyOf), and which combinations are invalid
+// (Not). callTool enforces all of them before running the tool.
 type InputSchema struct {
-	Type       string                    `json:"type"`
-	Properties map[string]SchemaProperty `json:"properties"`
-	Required   []string                  `json:"required,omitempty"`
+	Type         string                    `json:"type"`
+	Properties   map[string]SchemaProperty `json:"properties"`
+	Required     []string                  `json:"required,omitempty"`
+	Dependencies map[string][]string       `json:"dependencies,omitempty"`
+	AnyOf        []SchemaCondition         `json:"anyOf,omitempty"`
+	Not          *SchemaCondition          `json:"not,omitempty"`
 }
 
 type Tool struct {
-	InputSchema InputSchema `json:"inputSchema"`
-	Name        string      `json:"name"`
-	Description string      `json:"description"`
+	InputSchema InputSchema      `json:"inputSchema"`
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Annotations *ToolAnnotations `json:"annotations,omitempty"`
+}
+
+// ToolAnnotations tell clients how a tool behaves, so they can ask before
+// running one that changes something. Every built-in tool sets them, as
+// clients that find none must assume the tool is destructive.
+type ToolAnnotations struct {
+	ReadOnlyHint bool `json:"readOnlyHint"`
+	// DestructiveHint marks writes that remove or undo something rather
+	// than only adding.
+	DestructiveHint bool `json:"destructiveHint"`
+}
+
+// writeToolAnnotations marks a write tool.
+func writeToolAnnotations(destructive bool) *ToolAnnotations {
+	return &ToolAnnotations{DestructiveHint: destructive}
+}
+
+// readOnlyToolAnnotations marks a tool that changes nothing.
+func readOnlyToolAnnotations() *ToolAnnotations {
+	return &ToolAnnotations{ReadOnlyHint: true}
 }
 
 type ToolCallParams struct {
 	Name      string          `json:"name"`
 	Arguments json.RawMessage `json:"arguments"`
+	Meta      *RequestMeta    `json:"_meta,omitempty"`
 }
 
+// logsPageSize is the most logs Datadog returns per request; maxLogsLimit
+// bounds how many pages one query_logs call may fetch, and
+// maxStreamedLogs how many when pages are streamed rather than held.
+const (
+	logsPageSize    = 1000
+	maxLogsLimit    = 5000
+	maxStreamedLogs = 1000000
+)
+
 type QueryLogsParams struct {
 	Query string `json:"query"`
 	From  string `json:"from,omitempty"`
 	To    string `json:"to,omitempty"`
 	Limit int32  `json:"limit,omitempty"`
+	// Services runs the query once per service; see QueryServiceLogs.
+	Services []string `json:"services,omitempty"`
+	// TimeField selects whether from and to bound event or ingestion time.
+	TimeField string `json:"time_field,omitempty"`
+	// IncludeSuppressed turns off the configured log suppressions.
+	IncludeSuppressed bool `json:"include_suppressed,omitempty"`
 }
 
 type LogEntry struct {
-	ID        string     `json:"id"`
-	Timestamp *time.Time `json:"timestamp"`
-	Message   string     `json:"message"`
-	Status    string     `json:"status"`
-	Service   string     `json:"service"`
-	Tags      []string   `json:"tags"`
+	ID          string       `json:"id"`
+	Timestamp   *time.Time   `json:"timestamp"`
+	Message     string       `json:"message"`
+	Status      string       `json:"status"`
+	Service     string       `json:"service"`
+	Tags        []string     `json:"tags"`
+	SourceLinks []SourceLink `json:"source_links,omitempty"`
+	// Client is where the log's client IP is registered, when GeoIP
+	// enrichment is enabled.
+	Client *ClientLocation `json:"client,omitempty"`
 }
 
 type QueryLogsResult struct {
@@ -82,12 +244,23 @@ type QueryLogsResult struct {
 	Query string     `json:"query"`
 	From  string     `json:"from"`
 	To    string     `json:"to"`
+	Notes []string   `json:"notes,omitempty"`
+	// Diagnostics explain an empty result.
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"`
+	// Refinement suggests narrower windows when the limit was hit.
+	Refinement *Refinement `json:"refinement,omitempty"`
+	Freshness  *Freshness  `json:"freshness,omitempty"`
+	// Suppressed counts the fetched logs hidden by each suppression.
+	Suppressed map[string]int `json:"suppressed,omitempty"`
+	Partial    *PartialData   `json:"partial,omitempty"`
 }
 
 type InitializeResult struct {
-	ProtocolVersion string           `json:"protocolVersion"`
-	ServerInfo      ServerInfo       `json:"serverInfo"`
+	ProtocolVersion string             `json:"protocolVersion"`
+	ServerInfo      ServerInfo         `json:"serverInfo"`
 	Capabilities    ServerCapabilities `json:"capabilities"`
+	// Instructions explains a reduced tool set to the client.
+	Instructions string `json:"instructions,omitempty"`
 }
 
 type ServerInfo struct {
@@ -96,7 +269,11 @@ type ServerInfo struct {
 }
 
 type ServerCapabilities struct {
-	Tools ToolsCapability `json:"tools"`
+	Tools     ToolsCapability     `json:"tools"`
+	Resources ResourcesCapability `json:"resources"`
+	// Experimental carries server-specific capabilities, such as the
+	// Datadog products this deployment serves.
+	Experimental map[string]interface{} `json:"experimental,omitempty"`
 }
 
 type ToolsCapability struct{}
@@ -112,282 +289,3118 @@ type TextContent struct {
 
 type ToolCallResult struct {
 	Content []TextContent `json:"content"`
+	Meta    *ResultMeta   `json:"_meta,omitempty"`
 }
 
 func NewMCPServer() (*MCPServer, error) {
 	apiKey := os.Getenv("DD_API_KEY")
 	appKey := os.Getenv("DD_APP_KEY")
-	site := os.Getenv("DD_SITE") // Optional: datadoghq.com (default), datadoghq.eu, us3.datadoghq.com, etc.
+	site := normalizeSite(os.Getenv("DD_SITE")) // Optional: datadoghq.com (default), datadoghq.eu, us3.datadoghq.com, etc.
+	allowWrites, _ := strconv.ParseBool(os.Getenv("DD_MCP_ALLOW_WRITES"))
+	runbookHosts := splitList(os.Getenv("DD_MCP_RUNBOOK_HOSTS"))
+	credentialsFile := os.Getenv("DD_MCP_CREDENTIALS_FILE")
+	callsPerMinute, _ := strconv.Atoi(os.Getenv("DD_MCP_QUOTA_CALLS_PER_MINUTE"))
+	logsPerHour, _ := strconv.Atoi(os.Getenv("DD_MCP_QUOTA_LOGS_PER_HOUR"))
+	maxResultBytes, _ := strconv.Atoi(os.Getenv("DD_MCP_MAX_RESULT_BYTES"))
+	maxFrameBytes, _ := strconv.Atoi(os.Getenv("DD_MCP_MAX_FRAME_BYTES"))
+	resultStoreBytes, _ := strconv.Atoi(os.Getenv("DD_MCP_RESULT_STORE_BYTES"))
+	if resultStoreBytes <= 0 {
+		resultStoreBytes = defaultResultStoreBytes
+	}
+	requestTimeout := defaultRequestTimeout
+	if value := os.Getenv("DD_MCP_REQUEST_TIMEOUT"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid DD_MCP_REQUEST_TIMEOUT: %s", value)
+		}
+		requestTimeout = d
+	}
 
-	if apiKey == "" || appKey == "" {
-		return nil, fmt.Errorf("DD_API_KEY and DD_APP_KEY environment variables must be set")
+	handoff, err := loadHandoffConfig()
+	if err != nil {
+		return nil, err
 	}
 
-	ctx := context.WithValue(
-		context.Background(),
-		datadog.ContextAPIKeys,
-		map[string]datadog.APIKey{
-			"apiKeyAuth": {Key: apiKey},
-			"appKeyAuth": {Key: appKey},
-		},
-	)
+	backends, err := loadAPIBackends()
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots, err := loadSnapshotStore(os.Getenv("DD_MCP_SNAPSHOTS_FILE"))
+	if err != nil {
+		return nil, err
+	}
+
+	// In gateway mode every user brings their own keys, so shared keys are
+	// optional.
+	var tenants *tenantStore
+	if credentialsFile != "" {
+		store, err := loadTenantStore(
+			credentialsFile,
+			os.Getenv("DD_MCP_USER_HEADER"),
+			os.Getenv("DD_MCP_JWT_SECRET"),
+			os.Getenv("DD_MCP_JWT_CLAIM"),
+		)
+		if err != nil {
+			return nil, err
+		}
+		tenants = store
+		log.Printf("Gateway mode enabled with %d users", len(store.credentials))
+	} else if apiKey == "" {
+		return nil, fmt.Errorf("DD_API_KEY environment variable must be set")
+	} else if appKey == "" {
+		log.Printf("DD_APP_KEY is not set; starting in reduced mode with only the tools that need just an API key")
+	}
+
+	var orgs map[string]OrgProfile
+	if orgsFile := os.Getenv("DD_MCP_ORGS_FILE"); orgsFile != "" {
+		// Gateway users must only reach Datadog with their own keys.
+		if tenants != nil {
+			return nil, fmt.Errorf("DD_MCP_ORGS_FILE can't be used in gateway mode")
+		}
+		profiles, err := loadOrgProfiles(orgsFile)
+		if err != nil {
+			return nil, err
+		}
+		orgs = profiles
+		log.Printf("Loaded %d org profiles", len(profiles))
+	}
 
-	// Configure site/region if specified
 	if site != "" {
-		ctx = context.WithValue(ctx, datadog.ContextServerVariables, map[string]string{
-			"site": site,
-		})
 		log.Printf("Using Datadog site: %s", site)
 	}
+	disabledProducts, err := parseDisabledProducts(os.Getenv("DD_MCP_DISABLED_PRODUCTS"), site)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DD_MCP_DISABLED_PRODUCTS: %w", err)
+	}
+	if len(disabledProducts) > 0 {
+		log.Printf("Hiding tools for products disabled on this site: %s", strings.Join(disabledProducts, ", "))
+	}
 
 	configuration := datadog.NewConfiguration()
-	apiClient := datadog.NewAPIClient(configuration)
-
-	return &MCPServer{
-		ddClient: apiClient,
-		ctx:      ctx,
-	}, nil
-}
+	enableUnstableOperations(configuration)
 
-func (s *MCPServer) ListTools() []Tool {
-	return []Tool{
-		{
-			Name:        "query_logs",
-			Description: "Search and query Datadog logs with filters and time ranges",
-			InputSchema: InputSchema{
-				Type: "object",
-				Properties: map[string]SchemaProperty{
-					"query": {
-						Type:        "string",
-						Description: "Search query using Datadog query syntax (e.g., 'service:web status:error')",
-					},
-					"from": {
-						Type:        "string",
-						Description: "Start time in RFC3339 format or relative time (e.g., '1h', '30m'). Defaults to 1 hour ago.",
-					},
-					"to": {
-						Type:        "string",
-						Description: "End time in RFC3339 format or relative time. Defaults to now.",
-					},
-					"limit": {
-						Type:        "integer",
-						Description: "Maximum number of logs to return (max 1000). Defaults to 50.",
-					},
-				},
-				Required: []string{"query"},
-			},
-		},
+	var reports *reportRegistry
+	if reportsFile := os.Getenv("DD_MCP_REPORTS_FILE"); reportsFile != "" {
+		registry, err := loadReports(reportsFile)
+		if err != nil {
+			return nil, err
+		}
+		reports = registry
+		log.Printf("Loaded %d report templates", len(registry.names))
 	}
-}
 
-func parseTimeParam(timeStr string, defaultTime time.Time) (time.Time, error) {
-	if timeStr == "" {
-		return defaultTime, nil
+	var plugins *pluginRegistry
+	if pluginsFile := os.Getenv("DD_MCP_PLUGINS_FILE"); pluginsFile != "" {
+		registry, err := loadPlugins(pluginsFile, (&MCPServer{orgs: orgs, reports: reports}).ListTools())
+		if err != nil {
+			return nil, err
+		}
+		plugins = registry
+		log.Printf("Loaded %d plugin tools", len(registry.tools))
 	}
 
-	// Try parsing as RFC3339
-	if t, err := time.Parse(time.RFC3339, timeStr); err == nil {
-		return t, nil
+	var macros *macroRegistry
+	if macrosFile := os.Getenv("DD_MCP_MACROS_FILE"); macrosFile != "" {
+		registry, err := loadMacros(macrosFile, (&MCPServer{orgs: orgs, plugins: plugins, reports: reports}).ListTools())
+		if err != nil {
+			return nil, err
+		}
+		macros = registry
+		log.Printf("Loaded %d macros", len(registry.tools))
 	}
 
-	// Try parsing as relative time (e.g., "1h", "30m")
-	if duration, err := time.ParseDuration(timeStr); err == nil {
-		return time.Now().Add(-duration), nil
+	var suppressions *suppressionList
+	if suppressionsFile := os.Getenv("DD_MCP_SUPPRESSIONS_FILE"); suppressionsFile != "" {
+		list, err := loadSuppressions(suppressionsFile)
+		if err != nil {
+			return nil, err
+		}
+		suppressions = list
+		log.Printf("Loaded %d log suppressions", len(list.rules))
 	}
 
-	return time.Time{}, fmt.Errorf("invalid time format: %s (use RFC3339 or duration like '1h')", timeStr)
-}
-
-func (s *MCPServer) QueryLogs(params QueryLogsParams) (*QueryLogsResult, error) {
-	if params.Query == "" {
-		return nil, fmt.Errorf("query parameter is required")
+	var maintenance *maintenanceWindows
+	if maintenanceFile := os.Getenv("DD_MCP_MAINTENANCE_FILE"); maintenanceFile != "" {
+		list, err := loadMaintenanceWindows(maintenanceFile)
+		if err != nil {
+			return nil, err
+		}
+		maintenance = list
+		log.Printf("Loaded %d maintenance windows", len(list.windows))
 	}
 
-	// Default time range: last 1 hour
-	defaultFrom := time.Now().Add(-1 * time.Hour)
-	defaultTo := time.Now()
+	var geoip *geoIPDatabases
+	if paths := os.Getenv("DD_MCP_GEOIP_DATABASE"); paths != "" {
+		databases, err := loadGeoIP(paths)
+		if err != nil {
+			return nil, err
+		}
+		geoip = databases
+		log.Printf("GeoIP enrichment enabled with %d databases", len(databases.readers))
+	}
 
-	from, err := parseTimeParam(params.From, defaultFrom)
-	if err != nil {
-		return nil, err
+	var processor *postProcessor
+	if script := os.Getenv("DD_MCP_POSTPROCESS_SCRIPT"); script != "" {
+		p, err := loadPostProcessor(script)
+		if err != nil {
+			return nil, err
+		}
+		processor = p
+		log.Printf("Post-processing results for %d tools", len(p.functions))
 	}
 
-	to, err := parseTimeParam(params.To, defaultTo)
+	tokens, err := parseTokenEstimator(os.Getenv("DD_MCP_TOKEN_ESTIMATOR"))
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("invalid DD_MCP_TOKEN_ESTIMATOR: %w", err)
 	}
 
-	limit := int32(50)
-	if params.Limit > 0 {
-		limit = params.Limit
-		if limit > 1000 {
-			limit = 1000
+	var budgets *tokenBudgets
+	if budgetsFile := os.Getenv("DD_MCP_TOKEN_BUDGETS_FILE"); budgetsFile != "" {
+		b, err := loadTokenBudgets(budgetsFile)
+		if err != nil {
+			return nil, err
 		}
+		budgets = b
+		log.Printf("Token budgets enabled for %d clients", len(b.Clients))
 	}
 
-	// Build the logs search request
-	body := datadogV2.LogsListRequest{
-		Filter: &datadogV2.LogsQueryFilter{
-			From:  datadog.PtrString(from.Format(time.RFC3339)),
-			To:    datadog.PtrString(to.Format(time.RFC3339)),
-			Query: datadog.PtrString(params.Query),
-		},
-		Page: &datadogV2.LogsListRequestPage{
-			Limit: datadog.PtrInt32(limit),
-		},
-		Sort: datadogV2.LOGSSORT_TIMESTAMP_DESCENDING.Ptr(),
-	}
+	results := newResultStore(resultStoreBytes)
 
-	api := datadogV2.NewLogsApi(s.ddClient)
-	resp, _, err := api.ListLogs(s.ctx, *datadogV2.NewListLogsOptionalParameters().WithBody(body))
+	prefetch, err := parsePrefetch(os.Getenv("DD_MCP_PREFETCH"))
 	if err != nil {
-		return nil, fmt.Errorf("failed to query logs: %w", err)
+		return nil, fmt.Errorf("invalid DD_MCP_PREFETCH: %w", err)
 	}
 
-	// Format the response
-	logs := make([]LogEntry, 0)
-	if resp.Data != nil {
-		for _, log := range resp.Data {
-			entry := LogEntry{
-				ID:        log.GetId(),
-				Timestamp: log.Attributes.Timestamp,
-				Message:   log.Attributes.GetMessage(),
-				Status:    log.Attributes.GetStatus(),
-				Service:   log.Attributes.GetService(),
-				Tags:      log.Attributes.GetTags(),
-			}
-			logs = append(logs, entry)
-		}
+	concurrency, err := parseConcurrencyLimits(os.Getenv("DD_MCP_CONCURRENCY"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DD_MCP_CONCURRENCY: %w", err)
 	}
 
-	return &QueryLogsResult{
-		Logs:  logs,
-		Count: len(logs),
-		Query: params.Query,
-		From:  from.Format(time.RFC3339),
-		To:    to.Format(time.RFC3339),
-	}, nil
-}
-
-func (s *MCPServer) HandleRequest(req MCPRequest) MCPResponse {
-	resp := MCPResponse{
-		Jsonrpc: "2.0",
-		ID:      req.ID,
+	var transport http.RoundTripper = http.DefaultTransport
+	if len(concurrency) > 0 {
+		// Innermost, so hedged copies each take a slot.
+		transport = newConcurrencyTransport(transport, concurrency)
+		log.Printf("Concurrency limits: %v", concurrency)
+	}
+	if hedgeAfter, err := time.ParseDuration(os.Getenv("DD_MCP_HEDGE_AFTER")); err == nil && hedgeAfter > 0 {
+		transport = &hedgedTransport{base: transport, delay: hedgeAfter}
+		log.Printf("Hedging read requests slower than %s", hedgeAfter)
+	}
+	// Outside hedging, so both copies of a read use the same keys.
+	failover, err := loadFailover(transport, datadogCredentials{APIKey: apiKey, AppKey: appKey}, site)
+	if err != nil {
+		return nil, err
+	}
+	if failover != nil {
+		transport = failover
+		log.Printf("Failing reads over to the secondary keys after %d primary failures", failover.after)
 	}
 
-	switch req.Method {
-	case "initialize":
-		result := InitializeResult{
-			ProtocolVersion: "2024-11-05",
-			ServerInfo: ServerInfo{
-				Name:    "datadog-mcp-server",
-				Version: "0.1.0",
-			},
-			Capabilities: ServerCapabilities{
-				Tools: ToolsCapability{},
-			},
-		}
-		resultJSON, err := json.Marshal(result)
+	var telemetry *instruments
+	var shutdownTelemetry func(context.Context) error
+	var metricsHandler http.Handler
+	if otlp, prom := telemetryEnabled(), prometheusEnabled(); otlp || prom {
+		setup, err := setupTelemetry(context.Background(), otlp, prom)
 		if err != nil {
-			resp.Error = &MCPError{Code: -32603, Message: fmt.Sprintf("failed to marshal result: %v", err)}
-			return resp
-		}
-		resp.Result = resultJSON
-
-	case "tools/list":
-		result := ToolsListResult{
-			Tools: s.ListTools(),
+			return nil, err
 		}
-		resultJSON, err := json.Marshal(result)
-		if err != nil {
-			resp.Error = &MCPError{Code: -32603, Message: fmt.Sprintf("failed to marshal result: %v", err)}
-			return resp
+		telemetry = newInstruments(otel.Tracer(instrumentationName), otel.Meter(instrumentationName))
+		if err := telemetry.observeResultStore(results); err != nil {
+			return nil, fmt.Errorf("failed to observe result store: %w", err)
 		}
-		resp.Result = resultJSON
-
-	case "tools/call":
-		var params ToolCallParams
-		if err := json.Unmarshal(req.Params, &params); err != nil {
-			resp.Error = &MCPError{Code: -32602, Message: fmt.Sprintf("invalid params: %v", err)}
-			return resp
+		shutdownTelemetry = setup.shutdown
+		metricsHandler = setup.metricsHandler
+		transport = &instrumentedTransport{base: transport, instruments: telemetry}
+		if otlp {
+			log.Printf("OpenTelemetry export enabled")
 		}
-
-		if params.Name == "" {
-			resp.Error = &MCPError{Code: -32602, Message: "tool name is required"}
-			return resp
+		if prom {
+			log.Printf("Prometheus metrics enabled")
 		}
+	}
 
-		switch params.Name {
-		case "query_logs":
-			var queryParams QueryLogsParams
-			if err := json.Unmarshal(params.Arguments, &queryParams); err != nil {
-				resp.Error = &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
-				return resp
-			}
-
-			result, err := s.QueryLogs(queryParams)
-			if err != nil {
-				resp.Error = &MCPError{Code: -32000, Message: err.Error()}
-				return resp
-			}
-
-			toolResult := ToolCallResult{
-				Content: []TextContent{
-					{
-						Type: "text",
-						Text: formatLogsResult(result),
-					},
-				},
-			}
-			resultJSON, err := json.Marshal(toolResult)
-			if err != nil {
-				resp.Error = &MCPError{Code: -32603, Message: fmt.Sprintf("failed to marshal result: %v", err)}
-				return resp
-			}
-			resp.Result = resultJSON
+	userAgentSuffix := strings.TrimSpace(os.Getenv("DD_MCP_USER_AGENT_SUFFIX"))
+	if strings.IndexFunc(userAgentSuffix, func(r rune) bool { return r < 0x20 || r > 0x7e }) >= 0 {
+		return nil, fmt.Errorf("invalid DD_MCP_USER_AGENT_SUFFIX: only printable ASCII is allowed")
+	}
+	// Attribution is outermost so hedged copies carry the same headers.
+	transport = &attributionTransport{base: transport, suffix: userAgentSuffix}
+	configuration.HTTPClient = &http.Client{Transport: transport}
+	apiClient := datadog.NewAPIClient(configuration)
 
-		default:
-			resp.Error = &MCPError{Code: -32601, Message: fmt.Sprintf("unknown tool: %s", params.Name)}
-		}
+	if allowWrites {
+		log.Printf("Write tools enabled")
+	}
 
-	default:
-		resp.Error = &MCPError{Code: -32601, Message: fmt.Sprintf("unknown method: %s", req.Method)}
+	requestLog, err := loadRequestLogger(os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	if requestLog != nil {
+		log.Printf("Request logging enabled")
 	}
 
-	return resp
+	return &MCPServer{
+		ddClient:          apiClient,
+		credentials:       datadogCredentials{APIKey: apiKey, AppKey: appKey},
+		failover:          failover,
+		requestTimeout:    requestTimeout,
+		site:              site,
+		allowWrites:       allowWrites,
+		apiKeyOnly:        tenants == nil && appKey == "",
+		disabledProducts:  disabledProducts,
+		handoff:           handoff,
+		runbookHosts:      runbookHosts,
+		tenants:           tenants,
+		orgs:              orgs,
+		quotas:            newQuotaTracker(quotaLimits{CallsPerMinute: callsPerMinute, LogsPerHour: logsPerHour}),
+		session:           "stdio",
+		attribution:       requestAttribution{Transport: "stdio"},
+		results:           results,
+		maxResultBytes:    maxResultBytes,
+		tokens:            tokens,
+		tokenBudgets:      budgets,
+		maxFrameBytes:     maxFrameBytes,
+		plugins:           plugins,
+		postProcessor:     processor,
+		macros:            macros,
+		reports:           reports,
+		suppressions:      suppressions,
+		maintenance:       maintenance,
+		geoip:             geoip,
+		backends:          backends,
+		contexts:          newContextStore(),
+		snapshots:         snapshots,
+		documents:         newDocumentStore(),
+		names:             newNameCache(),
+		warmer:            newWarmer(prefetch),
+		transcripts:       newTranscriptStore(),
+		requestLog:        requestLog,
+		startedAt:         time.Now(),
+		telemetry:         telemetry,
+		shutdownTelemetry: shutdownTelemetry,
+		metricsHandler:    metricsHandler,
+	}, nil
 }
 
-func formatLogsResult(result *QueryLogsResult) string {
-	data, err := json.MarshalIndent(result, "", "  ")
-	if err != nil {
-		return fmt.Sprintf(`{"error": "failed to format result: %v"}`, err)
+// enableUnstableOperations turns on the client calls marked unstable that
+// tools make.
+func enableUnstableOperations(configuration *datadog.Configuration) {
+	for _, op := range slices.Concat(incidentOperations, findingOperations) {
+		configuration.SetUnstableOperationEnabled(op, true)
 	}
-	return string(data)
 }
 
-func main() {
-	server, err := NewMCPServer()
-	if err != nil {
-		log.Fatalf("Failed to initialize MCP server: %v", err)
+// newDatadogContext returns a context derived from parent carrying the key
+// pair and, if set, the site the Datadog client should call.
+func newDatadogContext(parent context.Context, apiKey, appKey, site string) context.Context {
+	keys := map[string]datadog.APIKey{"apiKeyAuth": {Key: apiKey}}
+	// Without an application key the header is left out rather than sent
+	// empty, which intake endpoints reject.
+	if appKey != "" {
+		keys["appKeyAuth"] = datadog.APIKey{Key: appKey}
 	}
+	ctx := context.WithValue(parent, datadog.ContextAPIKeys, keys)
 
-	decoder := json.NewDecoder(os.Stdin)
-	encoder := json.NewEncoder(os.Stdout)
+	// Configure site/region if specified
+	if site != "" {
+		ctx = context.WithValue(ctx, datadog.ContextServerVariables, map[string]string{
+			"site": site,
+		})
+	}
+	return ctx
+}
 
-	for {
-		var req MCPRequest
-		if err := decoder.Decode(&req); err != nil {
-			if err == io.EOF {
-				break
-			}
-			log.Printf("Error decoding request: %v", err)
-			continue
+// splitList parses a comma-separated environment value, dropping blanks.
+func splitList(value string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
 		}
+	}
+	return items
+}
 
-		resp := server.HandleRequest(req)
-		if err := encoder.Encode(resp); err != nil {
-			log.Printf("Error encoding response: %v", err)
-			continue
-		}
+// requireWrites guards tools that change state in Datadog. They are refused
+// unless the operator opted in with DD_MCP_ALLOW_WRITES.
+func (s *MCPServer) requireWrites(tool string) error {
+	if !s.allowWrites {
+		return fmt.Errorf("%s is a write tool and writes are disabled; set DD_MCP_ALLOW_WRITES=true to enable", tool)
+	}
+	return nil
+}
+
+func (s *MCPServer) ListTools() []Tool {
+	tools := []Tool{
+		{
+			Name:        "query_logs",
+			Description: "Search and query Datadog logs with filters and time ranges",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"query": {
+						Type:        "string",
+						Description: "Search query using Datadog query syntax (e.g., 'service:web status:error')",
+					},
+					"from": {
+						Type:        "string",
+						Description: "Start time in RFC3339 format or relative time (e.g., '1h', '30m'). Defaults to 1 hour ago.",
+					},
+					"to": {
+						Type:        "string",
+						Description: "End time in RFC3339 format or relative time. Defaults to now.",
+					},
+					"limit": {
+						Type:        "integer",
+						Description: "Maximum number of logs to return (max 5000). More than 1000 are fetched in pages. Defaults to 50.",
+					},
+					"time_field": {
+						Type:        "string",
+						Description: "Which timestamp from and to bound: 'event' (default) or 'ingest'. Datadog only searches event time, so 'ingest' widens the range to every event time a log ingested in it can carry, catching sources that send backdated events.",
+					},
+					"include_suppressed": {
+						Type:        "boolean",
+						Description: "Return logs hidden by the configured suppressions (default false). Hidden logs are always counted in the suppressed field.",
+					},
+					"services": {
+						Type:        "array",
+						Description: "Run the query once per service, concurrently, and return the logs keyed by service (max 10). The query must not filter on service itself; limit applies to each service.",
+						Items:       &SchemaProperty{Type: "string"},
+					},
+				},
+				Required:     []string{"query"},
+				Dependencies: map[string][]string{"to": {"from"}},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "detect_anomalies",
+			Description: "Run Datadog's anomalies() function over a metric and summarize which points fell outside the expected range",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"services": {
+						Type:        "array",
+						Description: "Run the analysis once per service, concurrently, adding service:<name> to the metric's {scope}, and return the results keyed by service (max 10)",
+						Items:       &SchemaProperty{Type: "string"},
+					},
+					"metric": {
+						Type:        "string",
+						Description: "Metric query to analyze (e.g., 'avg:system.cpu.user{service:web}')",
+					},
+					"window": {
+						Type:        "string",
+						Description: "How far back to analyze (e.g., '4h', '2d', '1w'). Defaults to 4h.",
+					},
+					"sensitivity": {
+						Type:        "string",
+						Description: "Band width: 'low' (3 deviations), 'medium' (2) or 'high' (1). Defaults to medium.",
+					},
+					"algorithm": {
+						Type:        "string",
+						Description: "Anomaly algorithm: 'basic', 'agile' or 'robust'. Defaults to basic.",
+					},
+					"max_points": {
+						Type:        "integer",
+						Description: "Most points to return per series (max 1000). Longer series are averaged into round intervals, and several series are aligned to the same timestamps. Findings use the raw points. Defaults to 120.",
+					},
+				},
+				Required: []string{"metric"},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "forecast_metric",
+			Description: "Run Datadog's forecast() function over a metric to project future values for capacity planning",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"services": {
+						Type:        "array",
+						Description: "Run the analysis once per service, concurrently, adding service:<name> to the metric's {scope}, and return the results keyed by service (max 10)",
+						Items:       &SchemaProperty{Type: "string"},
+					},
+					"metric": {
+						Type:        "string",
+						Description: "Metric query to forecast (e.g., 'max:system.disk.in_use{host:db-1}')",
+					},
+					"window": {
+						Type:        "string",
+						Description: "History used to build the forecast (e.g., '1w', '30d'). Defaults to 1w.",
+					},
+					"horizon": {
+						Type:        "string",
+						Description: "How far into the future to forecast (e.g., '1d', '2w'). Defaults to 1d.",
+					},
+					"sensitivity": {
+						Type:        "string",
+						Description: "Band width: 'low' (3 deviations), 'medium' (2) or 'high' (1). Defaults to medium.",
+					},
+					"algorithm": {
+						Type:        "string",
+						Description: "Forecast algorithm: 'linear' or 'seasonal'. Defaults to linear.",
+					},
+					"threshold": {
+						Type:        "number",
+						Description: "Optional capacity threshold; the findings report when the forecast is expected to cross it.",
+					},
+					"max_points": {
+						Type:        "integer",
+						Description: "Most points to return per series (max 1000). Longer series are averaged into round intervals, and several series are aligned to the same timestamps. Findings use the raw points. Defaults to 120.",
+					},
+				},
+				Required: []string{"metric"},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "simulate_monitor",
+			Description: "Replay a proposed metric monitor against the past days of data and report how many times, and for how long, it would have alerted, to tune its threshold before creating it",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"query": {
+						Type:        "string",
+						Description: "Monitor query (e.g., 'avg(last_5m):avg:system.cpu.user{env:prod} by {host} > 90') or a metric query with 'threshold'",
+					},
+					"threshold": {
+						Type:        "number",
+						Description: "Alert threshold; overrides the one in the query",
+					},
+					"comparator": {
+						Type:        "string",
+						Description: "'>', '>=', '<' or '<='; overrides the query's. Defaults to '>'.",
+					},
+					"aggregation": {
+						Type:        "string",
+						Description: "How values are reduced over the window: avg, min, max or sum; overrides the query's. Defaults to avg.",
+					},
+					"window": {
+						Type:        "string",
+						Description: "Evaluation window (e.g., '5m', '1h'); overrides the query's. Defaults to 5m.",
+					},
+					"days": {
+						Type:        "integer",
+						Description: "Days of history to replay (default: 7, max: 30)",
+					},
+				},
+				Required: []string{"query"},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "detect_cardinality_growth",
+			Description: "Find custom metrics whose tag cardinality (series count) grew recently and the tag keys responsible, to catch surprise cost and performance blowups",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"metrics": {
+						Type:        "array",
+						Description: "Only check these metric names (default: every custom metric)",
+						Items:       &SchemaProperty{Type: "string"},
+					},
+					"window": {
+						Type:        "string",
+						Description: "Recent period to compare (e.g., '6h', '1d'). Defaults to 1d.",
+					},
+					"baseline": {
+						Type:        "string",
+						Description: "Period before the window to compare against (e.g., '1w'). Defaults to 1w.",
+					},
+					"min_growth": {
+						Type:        "number",
+						Description: "Minimum series count growth in percent to report (default: 25)",
+					},
+					"limit": {
+						Type:        "integer",
+						Description: "Maximum metrics to report, largest growth first (default: 10, max: 50)",
+					},
+				},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "alert_fatigue_report",
+			Description: "Aggregate monitor alert events by team or service and report alert volume, recovery times and the noisiest monitors for on-call health reviews",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"query": {
+						Type:        "string",
+						Description: "Optional event search filter added to 'source:alert' (e.g., 'env:production')",
+					},
+					"group_by": {
+						Type:        "string",
+						Description: "Tag to aggregate by: 'team' or 'service'. Defaults to team.",
+					},
+					"window": {
+						Type:        "string",
+						Description: "How far back to analyze (e.g., '7d', '30d'). Defaults to 30d.",
+					},
+					"top": {
+						Type:        "integer",
+						Description: "Number of noisiest monitors to return. Defaults to 10.",
+					},
+					"exclude_maintenance": {
+						Type:        "boolean",
+						Description: "Leave out alerts that fired inside the configured maintenance windows, with their recoveries (default false). They are counted in the maintenance field either way.",
+					},
+				},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "query_events",
+			Description: "Search Datadog events by text, source, tags, priority and aggregation key. Uses the v2 events search, falling back to the v1 event stream where v2 isn't available.",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"query": {
+						Type:        "string",
+						Description: "Event search query (e.g., 'env:production deploy'). Not supported by the v1 fallback.",
+					},
+					"sources": {
+						Type:        "array",
+						Description: "Event sources to include (e.g., ['kubernetes', 'github'])",
+						Items:       &SchemaProperty{Type: "string"},
+					},
+					"tags": {
+						Type:        "array",
+						Description: "Tags every event must have (e.g., ['env:production'])",
+						Items:       &SchemaProperty{Type: "string"},
+					},
+					"priority": {
+						Type:        "string",
+						Description: "Event priority: 'normal' or 'low'",
+					},
+					"aggregation_key": {
+						Type:        "string",
+						Description: "Only events with this aggregation key. Not supported by the v1 fallback.",
+					},
+					"from": {
+						Type:        "string",
+						Description: "Start time in RFC3339 format or relative time (e.g., '1h', '30m'). Defaults to 24 hours ago.",
+					},
+					"to": {
+						Type:        "string",
+						Description: "End time in RFC3339 format or relative time. Defaults to now.",
+					},
+					"limit": {
+						Type:        "integer",
+						Description: "Maximum number of events to return (max 1000). Defaults to 50.",
+					},
+				},
+				Dependencies: map[string][]string{"to": {"from"}},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "query_spans",
+			Description: "Search indexed APM spans, returning each span's service, resource, duration, status and trace ID. Use it for latency and error investigations that logs alone can't answer.",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"query": {
+						Type:        "string",
+						Description: "Span search query (e.g., 'service:checkout @duration:>1s', 'service:api status:error')",
+					},
+					"from": {
+						Type:        "string",
+						Description: "Start time in RFC3339 format or relative time (e.g., '1h', '30m'). Defaults to 1 hour ago.",
+					},
+					"to": {
+						Type:        "string",
+						Description: "End time in RFC3339 format or relative time. Defaults to now.",
+					},
+					"limit": {
+						Type:        "integer",
+						Description: "Maximum number of spans to return (max 1000). Defaults to 50.",
+					},
+					"sort": {
+						Type:        "string",
+						Description: "'-timestamp' (newest first, default) or 'timestamp' (oldest first)",
+					},
+				},
+				Required:     []string{"query"},
+				Dependencies: map[string][]string{"to": {"from"}},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "aggregate_spans",
+			Description: "Aggregate APM spans without fetching them: counts and duration percentiles or averages, grouped by facets such as service, resource and status. Use it for p95 latency per endpoint or error counts per service.",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"query": {
+						Type:        "string",
+						Description: "Span search query (e.g., 'service:checkout env:prod'). Defaults to all spans.",
+					},
+					"from": {
+						Type:        "string",
+						Description: "Start time in RFC3339 format or relative time (e.g., '1h', '30m'). Defaults to 1 hour ago.",
+					},
+					"to": {
+						Type:        "string",
+						Description: "End time in RFC3339 format or relative time. Defaults to now.",
+					},
+					"compute": {
+						Type:        "array",
+						Description: "What to compute: 'count', or an aggregation (avg, sum, min, max, median, p75, p90, p95, p98, p99, cardinality) optionally followed by ':<measure>', e.g. 'p95' or 'avg:@duration'. Measures default to @duration, reported in milliseconds. Defaults to ['count'].",
+						Items:       &SchemaProperty{Type: "string"},
+					},
+					"group_by": {
+						Type:        "array",
+						Description: "Facets to group by, e.g. ['service', 'resource', 'status']",
+						Items:       &SchemaProperty{Type: "string"},
+					},
+					"limit": {
+						Type:        "integer",
+						Description: "Top values kept per group-by facet, ranked by the first compute (max 100). Defaults to 10.",
+					},
+				},
+				Dependencies: map[string][]string{"to": {"from"}},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "get_trace",
+			Description: "Fetch every span of one trace, for example the dd.trace_id on a log line, and show them as a call tree with each span's duration, start offset and errors",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"trace_id": {
+						Type:        "string",
+						Description: "Trace ID, as in a log's dd.trace_id or a span's trace_id",
+					},
+					"from": {
+						Type:        "string",
+						Description: "Start time in RFC3339 format or relative time (e.g., '1h', '7d'). Defaults to 24 hours ago.",
+					},
+					"to": {
+						Type:        "string",
+						Description: "End time in RFC3339 format or relative time. Defaults to now.",
+					},
+					"format": {
+						Type:        "string",
+						Description: "'markdown' (default), 'json', or 'mermaid' for sequence diagram source",
+					},
+				},
+				Required:     []string{"trace_id"},
+				Dependencies: map[string][]string{"to": {"from"}},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "explain_query",
+			Description: "Explain what a log, span, event or metric query matches without running it: the boolean structure with implicit ANDs spelled out, each filter's field, type, wildcards and negation, and caveats such as facets, leading wildcards, rollups and index coverage. Use it to check a query does what you intend.",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"query": {
+						Type:        "string",
+						Description: "The query to explain (e.g., 'service:checkout -status:info @http.status_code:>=500', 'sum:trace.http.request.hits{env:prod} by {service}.as_count()')",
+					},
+					"kind": {
+						Type:        "string",
+						Description: "'logs', 'spans', 'events' or 'metric'. Guessed from the query when omitted: a metric{scope} means metric, anything else logs.",
+					},
+				},
+				Required: []string{"query"},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "list_services",
+			Description: "List services in the Software Catalog with their team, contacts, on-call (PagerDuty, Opsgenie) links and repositories. Use it to find who owns the service named in a log's service tag.",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"query": {
+						Type:        "string",
+						Description: "Only services whose name or description contains this text (case-insensitive)",
+					},
+					"team": {
+						Type:        "string",
+						Description: "Only services owned by this team",
+					},
+					"limit": {
+						Type:        "integer",
+						Description: "Maximum services to return (max 500). Defaults to 50.",
+					},
+				},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "get_service_dependencies",
+			Description: "Get the services a service calls (downstream) and the services that call it (upstream) from the APM service map, optionally several hops out. Use it to gauge the blast radius when a service shows errors.",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"service": {
+						Type:        "string",
+						Description: "APM service name",
+					},
+					"env": {
+						Type:        "string",
+						Description: "Environment of the service map (e.g., 'prod')",
+					},
+					"from": {
+						Type:        "string",
+						Description: "Start time in RFC3339 format or relative time (e.g., '1h', '24h'). Defaults to 1 hour ago.",
+					},
+					"to": {
+						Type:        "string",
+						Description: "End time in RFC3339 format or relative time. Defaults to now.",
+					},
+					"depth": {
+						Type:        "integer",
+						Description: "How many hops to follow in each direction (max 5). Defaults to 1, direct callers and callees only.",
+					},
+					"diagram": {
+						Type:        "boolean",
+						Description: "Also return the dependencies as Mermaid flowchart source, for clients that render Mermaid",
+					},
+				},
+				Required:     []string{"service", "env"},
+				Dependencies: map[string][]string{"to": {"from"}},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "get_blast_radius",
+			Description: "Given an alerting monitor or a failing service, list the services that call it up to several hops away, which of them users reach directly, and the SLOs tied to any of them, most urgent first. Use it to prioritize during an alert storm.",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"monitor_id": {
+						Type:        "integer",
+						Description: "Alerting monitor; its service tags and alerting service groups are the failing services",
+					},
+					"service": {
+						Type:        "string",
+						Description: "Failing APM service",
+					},
+					"env": {
+						Type:        "string",
+						Description: "Environment of the service map (e.g., 'prod'). Defaults to the monitor's env tag.",
+					},
+					"from": {
+						Type:        "string",
+						Description: "Start of the service map window in RFC3339 format or relative time (e.g., '24h'). Defaults to 1 hour ago.",
+					},
+					"depth": {
+						Type:        "integer",
+						Description: "How many hops of callers to follow (max 5, default 3)",
+					},
+				},
+				AnyOf: []SchemaCondition{{Required: []string{"monitor_id"}}, {Required: []string{"service"}}},
+				Not:   &SchemaCondition{Required: []string{"monitor_id", "service"}},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "list_hosts",
+			Description: "List infrastructure hosts matching a filter, with apps, agent version, up/down status and tags, for infrastructure triage",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"filter": {
+						Type:        "string",
+						Description: "Text matching host names, aliases and tags (e.g., 'web-', 'env:prod')",
+					},
+					"from": {
+						Type:        "string",
+						Description: "Only hosts that reported since this time (RFC3339 or relative like '1h')",
+					},
+					"sort": {
+						Type:        "string",
+						Description: "Sort by status, apps, cpu, iowait or load",
+					},
+					"sort_dir": {
+						Type:        "string",
+						Description: "asc or desc",
+					},
+					"page": {
+						Type:        "integer",
+						Description: "Page to return, starting at 0 (default: 0)",
+					},
+					"per_page": {
+						Type:        "integer",
+						Description: "Hosts per page (default: 50, max: 1000)",
+					},
+				},
+				Dependencies: map[string][]string{"sort_dir": {"sort"}},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "query_processes",
+			Description: "Search the live processes the Datadog Agent reports by command line and tags, returning command, host, PID, parent PID and user, to tie a log error to the process behind it",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"search": {
+						Type:        "string",
+						Description: "Text to match in process command lines (e.g., 'java', 'worker --queue')",
+					},
+					"tags": {
+						Type:        "array",
+						Description: "Tags the processes' hosts must have (e.g., ['host:web-1', 'env:prod'])",
+						Items:       &SchemaProperty{Type: "string"},
+					},
+					"from": {
+						Type:        "string",
+						Description: "Start time in RFC3339 format or relative time (e.g., '15m'). Defaults to the latest snapshot.",
+					},
+					"to": {
+						Type:        "string",
+						Description: "End time in RFC3339 format or relative time",
+					},
+					"limit": {
+						Type:        "integer",
+						Description: "Maximum processes to return (default: 50, max: 1000)",
+					},
+					"cursor": {
+						Type:        "string",
+						Description: "next_cursor from a previous call, for the next page",
+					},
+				},
+				Dependencies: map[string][]string{"to": {"from"}},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "list_security_rules",
+			Description: "List Datadog Security detection rules with their queries, case conditions, severities and enabled state, or get one rule with its message, to explain which rule produced a security signal and how to tune it",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"rule_id": {
+						Type:        "string",
+						Description: "Return only this rule, including its message (e.g., the workflow.rule.id of a signal)",
+					},
+					"query": {
+						Type:        "string",
+						Description: "Text to search for in rule names and queries (e.g., 'brute force', 'source:cloudtrail')",
+					},
+					"enabled": {
+						Type:        "boolean",
+						Description: "Return only enabled (true) or only disabled (false) rules",
+					},
+					"min_severity": {
+						Type:        "string",
+						Description: "Return rules with a case at this severity or higher: info, low, medium, high or critical",
+					},
+					"type": {
+						Type:        "string",
+						Description: "Rule type (e.g., 'log_detection', 'signal_correlation', 'workload_security')",
+					},
+					"limit": {
+						Type:        "integer",
+						Description: "Maximum rules to return (default: 50, max: 500)",
+					},
+				},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "list_security_findings",
+			Description: "List Cloud Security posture findings, which are resources evaluated against compliance rules, filtered by severity, pass or fail evaluation, resource type, rule or tags, to query the org's compliance posture",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"status": {
+						Type:        "string",
+						Description: "Finding severity: info, low, medium, high or critical",
+					},
+					"evaluation": {
+						Type:        "string",
+						Description: "Whether the resource passes the rule: pass or fail",
+					},
+					"resource_type": {
+						Type:        "string",
+						Description: "Resource type (e.g., 'aws_s3_bucket', 'gcp_compute_instance', 'kubernetes_pod')",
+					},
+					"rule_id": {
+						Type:        "string",
+						Description: "Only findings from this compliance rule",
+					},
+					"muted": {
+						Type:        "boolean",
+						Description: "Return only muted (true) or only unmuted (false) findings",
+					},
+					"tags": {
+						Type:        "array",
+						Description: "Tags the findings must have (e.g., ['cloud_provider:aws', 'env:prod'])",
+						Items:       &SchemaProperty{Type: "string"},
+					},
+					"limit": {
+						Type:        "integer",
+						Description: "Maximum findings to return (default: 100, max: 1000)",
+					},
+					"cursor": {
+						Type:        "string",
+						Description: "next_cursor from a previous call, for the next page",
+					},
+				},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "query_ci_tests",
+			Description: "Find the tests that failed in CI Visibility over a window and tell flaky tests (which also passed) from consistently failing ones, with run counts by status, to answer why CI is red",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"query": {
+						Type:        "string",
+						Description: "CI Visibility test search query to narrow the tests (e.g., '@test.framework:pytest @ci.provider.name:github')",
+					},
+					"service": {
+						Type:        "string",
+						Description: "Test service, as set by DD_SERVICE in the test run",
+					},
+					"branch": {
+						Type:        "string",
+						Description: "Git branch (e.g., 'main')",
+					},
+					"from": {
+						Type:        "string",
+						Description: "Start time in RFC3339 format or relative time (e.g., '7d'). Defaults to 7 days ago.",
+					},
+					"to": {
+						Type:        "string",
+						Description: "End time in RFC3339 format or relative time. Defaults to now.",
+					},
+					"limit": {
+						Type:        "integer",
+						Description: "How many of the most-failing tests to analyze (default: 20, max: 100)",
+					},
+				},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "list_users",
+			Description: "List the org's users with their roles, status, MFA and last login, filtered by status and email, for access audits. Needs the user_access_read permission.",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"statuses": {
+						Type:        "array",
+						Description: "Only users in these statuses: active, pending or disabled",
+						Items:       &SchemaProperty{Type: "string"},
+					},
+					"email": {
+						Type:        "string",
+						Description: "Only users whose email contains this text, ignoring case (e.g., '@contractor.com')",
+					},
+					"page": {
+						Type:        "integer",
+						Description: "Page to return, starting at 0",
+					},
+					"per_page": {
+						Type:        "integer",
+						Description: "Users per page (default: 50, max: 100)",
+					},
+				},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "list_teams",
+			Description: "List the org's teams with their handles, member counts and links, filtered by name or handle, to find the team an alert or service belongs to",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"query": {
+						Type:        "string",
+						Description: "Only teams whose name or handle matches this text (e.g., 'payments')",
+					},
+					"mine": {
+						Type:        "boolean",
+						Description: "Only teams the key's user belongs to",
+					},
+					"page": {
+						Type:        "integer",
+						Description: "Page to return, starting at 0",
+					},
+					"per_page": {
+						Type:        "integer",
+						Description: "Teams per page (default: 50, max: 100)",
+					},
+				},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "get_team",
+			Description: "Get a team by handle or ID with its members, their roles and the team's links, such as runbooks, repositories and chat channels",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"team": {
+						Type:        "string",
+						Description: "Team handle (e.g., 'payments' or '@payments') or team ID",
+					},
+				},
+				Required: []string{"team"},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "list_containers",
+			Description: "List the containers the Datadog Agent reports with their host, state, image and tags, or count them grouped by tags such as host or kube_deployment",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"tags": {
+						Type:        "array",
+						Description: "Tags the containers must have (e.g., ['service:checkout', 'host:web-1'])",
+						Items:       &SchemaProperty{Type: "string"},
+					},
+					"group_by": {
+						Type:        "array",
+						Description: "Tag keys to count containers by instead of listing them (e.g., ['host'], ['kube_deployment', 'container_state'])",
+						Items:       &SchemaProperty{Type: "string"},
+					},
+					"sort": {
+						Type:        "string",
+						Description: "Attribute to sort by, prefixed with '-' for descending (e.g., '-started_at', 'name')",
+					},
+					"limit": {
+						Type:        "integer",
+						Description: "Maximum containers or groups to return (default: 100, max: 1000)",
+					},
+					"cursor": {
+						Type:        "string",
+						Description: "next_cursor from a previous call, for the next page",
+					},
+				},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "get_host_totals",
+			Description: "Count the org's active hosts and how many of them are up, for capacity and billing sanity checks",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"from": {
+						Type:        "string",
+						Description: "Count hosts active since this time, in RFC3339 format or relative time (e.g., '24h'). Defaults to the last 2 hours.",
+					},
+				},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "list_monitors",
+			Description: "List and search Datadog monitors by name, tags and state (Alert, Warn, No Data, OK), with pagination, for incident triage",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"name": {
+						Type:        "string",
+						Description: "Text the monitor name contains (e.g., 'checkout latency')",
+					},
+					"tags": {
+						Type:        "array",
+						Description: "Monitor tags that must all be present (e.g., 'env:prod', 'team:payments')",
+						Items:       &SchemaProperty{Type: "string"},
+					},
+					"states": {
+						Type:        "array",
+						Description: "Only monitors in any of these states: Alert, Warn, No Data, OK, Ignored, Skipped, Unknown",
+						Items:       &SchemaProperty{Type: "string"},
+					},
+					"page": {
+						Type:        "integer",
+						Description: "Page to return, starting at 0 (default: 0)",
+					},
+					"per_page": {
+						Type:        "integer",
+						Description: "Monitors per page (default: 30, max: 100)",
+					},
+				},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "get_monitor",
+			Description: "Get a monitor's full definition and the state of each of its groups, to see which hosts or services of a multi-alert monitor are actually alerting",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"monitor_id": {
+						Type:        "integer",
+						Description: "Monitor ID",
+					},
+					"group_states": {
+						Type:        "array",
+						Description: "Only include groups in these states: alert, warn, no data, or all (default: all)",
+						Items:       &SchemaProperty{Type: "string"},
+					},
+				},
+				Required: []string{"monitor_id"},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "watch_monitor",
+			Description: "Watch a monitor for up to 30 minutes and return as soon as its state changes, or reaches a given state such as OK, so \"tell me when it recovers\" needs one call. Progress is reported while waiting.",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"monitor_id": {
+						Type:        "integer",
+						Description: "Monitor ID, as returned by list_monitors",
+					},
+					"until": {
+						Type:        "string",
+						Description: "Wait for this state: OK, Alert, Warn or No Data (default: any change)",
+					},
+					"timeout": {
+						Type:        "string",
+						Description: "How long to watch (e.g., '15m'; max 30m, default 10m)",
+					},
+				},
+				Required: []string{"monitor_id"},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "list_synthetic_tests",
+			Description: "List Synthetic tests with their type, locations and whether they are currently passing, failing tests first, to see if users can reach a service",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"tags": {
+						Type:        "array",
+						Description: "Test tags that must all be present (e.g., 'env:prod', 'team:payments')",
+						Items:       &SchemaProperty{Type: "string"},
+					},
+					"type": {
+						Type:        "string",
+						Description: "Only tests of this type: api, browser or mobile",
+					},
+					"query": {
+						Type:        "string",
+						Description: "Text the test name contains",
+					},
+					"limit": {
+						Type:        "integer",
+						Description: "Maximum tests to return (default: 50, max: 500)",
+					},
+				},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "get_synthetic_results",
+			Description: "Get a Synthetic test's recent runs with pass rate, response times and, for failed runs, the failure and failing browser steps, to explain why an uptime check is failing",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"test_id": {
+						Type:        "string",
+						Description: "Public ID of the test (e.g., 'abc-def-ghi')",
+					},
+					"from": {
+						Type:        "string",
+						Description: "Start time (RFC3339 or relative like '6h', default: 24h ago)",
+					},
+					"to": {
+						Type:        "string",
+						Description: "End time (RFC3339 or relative, default: now)",
+					},
+					"locations": {
+						Type:        "array",
+						Description: "Only runs from these locations (e.g., 'aws:us-east-1')",
+						Items:       &SchemaProperty{Type: "string"},
+					},
+					"limit": {
+						Type:        "integer",
+						Description: "Maximum runs to return (default: 20, max: 100)",
+					},
+				},
+				Required: []string{"test_id"},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "get_synthetic_batch",
+			Description: "Check on Synthetic test runs started by trigger_synthetic_test, waiting for them to finish",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"batch_id": {
+						Type:        "string",
+						Description: "batch_id returned by trigger_synthetic_test",
+					},
+					"wait": {
+						Type:        "string",
+						Description: "How long to wait for the runs to finish (e.g., '5m'; max 10m, default 2m). '0' returns their current status.",
+					},
+				},
+				Required: []string{"batch_id"},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "list_incidents",
+			Description: "List Datadog incidents, newest first, with severity, state, commander and customer impact, to pull current incident context",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"states": {
+						Type:        "array",
+						Description: "Incident states to include: active, stable, resolved (default: active and stable)",
+						Items:       &SchemaProperty{Type: "string"},
+					},
+					"severities": {
+						Type:        "array",
+						Description: "Severities to include (e.g., 'SEV-1', 'SEV-2')",
+						Items:       &SchemaProperty{Type: "string"},
+					},
+					"query": {
+						Type:        "string",
+						Description: "Additional incident search terms (e.g., 'teams:payments')",
+					},
+					"limit": {
+						Type:        "integer",
+						Description: "Maximum incidents to return (default: 20, max: 100)",
+					},
+				},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "get_incident",
+			Description: "Get one Datadog incident with its severity, state, commander, customer impact, timeline and custom fields",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"incident_id": {
+						Type:        "string",
+						Description: "Incident ID, as returned by list_incidents",
+					},
+					"diagram": {
+						Type:        "boolean",
+						Description: "Also return the timeline as Mermaid gantt chart source, for clients that render Mermaid",
+					},
+				},
+				Required: []string{"incident_id"},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "get_incident_timeline",
+			Description: "Get an incident's response history (milestones, customer impact, todos and attachments) in time order, to summarize how the response went",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"incident_id": {
+						Type:        "string",
+						Description: "Incident ID, as returned by list_incidents",
+					},
+					"format": {
+						Type:        "string",
+						Description: "'markdown' (default) or 'json'",
+					},
+				},
+				Required: []string{"incident_id"},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "generate_postmortem",
+			Description: "Draft a postmortem for an incident from its timeline, the monitors that triggered, deployments and common error logs, as markdown sections to edit. The draft is also kept as a resource, and can be attached to the incident when writes are enabled.",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"incident_id": {
+						Type:        "string",
+						Description: "Incident ID, as returned by list_incidents",
+					},
+					"services": {
+						Type:        "array",
+						Description: "Services to search for monitors, deployments and error logs (default: the incident's services field)",
+						Items:       &SchemaProperty{Type: "string"},
+					},
+					"env": {
+						Type:        "string",
+						Description: "Only deployments and logs from this environment (e.g., 'prod')",
+					},
+					"attach": {
+						Type:        "boolean",
+						Description: "Save the draft to a notebook and attach it to the incident as its postmortem (default: false)",
+					},
+					"confirm": {
+						Type:        "boolean",
+						Description: "Must be true with attach, confirming the write was intended",
+					},
+				},
+				Required: []string{"incident_id"},
+			},
+			Annotations: writeToolAnnotations(false),
+		},
+		{
+			Name:        "list_slos",
+			Description: "List and search SLOs with their status and error budget, most urgent first, to find which SLOs are burning",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"query": {
+						Type:        "string",
+						Description: "SLO search query (e.g., 'checkout')",
+					},
+					"tags": {
+						Type:        "array",
+						Description: "Tags every SLO must have (e.g., 'team:payments')",
+						Items:       &SchemaProperty{Type: "string"},
+					},
+					"states": {
+						Type:        "array",
+						Description: "Only SLOs in these states: breached, warning, ok, no_data",
+						Items:       &SchemaProperty{Type: "string"},
+					},
+					"page": {
+						Type:        "integer",
+						Description: "Page to return, starting at 0",
+					},
+					"per_page": {
+						Type:        "integer",
+						Description: "SLOs per page (default: 30, max: 100)",
+					},
+				},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "get_slo_status",
+			Description: "Get an SLO's current SLI, error budget remaining and target for each of its timeframes",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"slo_id": {
+						Type:        "string",
+						Description: "SLO ID, as returned by list_slos",
+					},
+				},
+				Required: []string{"slo_id"},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "get_slo_history",
+			Description: "Get an SLO's SLI history over a window as a time series with burn rates, to correlate error budget burn with incidents and deployments",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"slo_id": {
+						Type:        "string",
+						Description: "SLO ID, as returned by list_slos",
+					},
+					"from": {
+						Type:        "string",
+						Description: "Start of the window (RFC3339 or relative, e.g., '7d'). Defaults to 7 days before 'to'.",
+					},
+					"to": {
+						Type:        "string",
+						Description: "End of the window (RFC3339 or relative). Defaults to now.",
+					},
+				},
+				Required: []string{"slo_id"},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "list_dashboards",
+			Description: "List Datadog dashboards filtered by title and tags, with pagination, returning IDs, titles, authors and links to point people at the right view",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"title": {
+						Type:        "string",
+						Description: "Text the dashboard title contains (e.g., 'checkout')",
+					},
+					"tags": {
+						Type:        "array",
+						Description: "Dashboard tags that must all be present (e.g., 'team:payments')",
+						Items:       &SchemaProperty{Type: "string"},
+					},
+					"page": {
+						Type:        "integer",
+						Description: "Page to return, starting at 0 (default: 0)",
+					},
+					"per_page": {
+						Type:        "integer",
+						Description: "Dashboards per page (default: 30, max: 100)",
+					},
+				},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "get_dashboard",
+			Description: "Get a dashboard's widgets condensed to their metric and log queries, to replay what the dashboard shows through the metric and log tools",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"dashboard_id": {
+						Type:        "string",
+						Description: "Dashboard ID (e.g., 'abc-def-ghi') or a link to the dashboard",
+					},
+				},
+				Required: []string{"dashboard_id"},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "diff_resource",
+			Description: "Show what changed in a monitor's or dashboard's definition since it was last fetched, for when someone changed the monitor during an incident",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"type": {
+						Type:        "string",
+						Description: "monitor or dashboard",
+					},
+					"id": {
+						Type:        "string",
+						Description: "Monitor ID, or dashboard ID or link",
+					},
+				},
+				Required: []string{"type", "id"},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "metric_related_assets",
+			Description: "List the dashboards, monitors, notebooks and SLOs that use a metric, to see what would break if it stopped being emitted",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"metric": {
+						Type:        "string",
+						Description: "Metric name (e.g., 'system.cpu.user'), not a query",
+					},
+				},
+				Required: []string{"metric"},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "audit_orphaned_resources",
+			Description: "Produce a cleanup report of dashboards not viewed in N months, monitors with no notification recipients, SLOs built on deleted monitors and log facets that no longer receive data",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"stale_months": {
+						Type:        "integer",
+						Description: "Flag dashboards not viewed in this many months and facets without data in that period (default: 6, max: 24)",
+					},
+					"checks": {
+						Type:        "array",
+						Description: "Checks to run: dashboards, monitors, slos, facets (default: all)",
+						Items:       &SchemaProperty{Type: "string"},
+					},
+					"facets": {
+						Type:        "array",
+						Description: "Log facets to check for data (e.g., '@http.status_code'); Datadog's API can't list them",
+						Items:       &SchemaProperty{Type: "string"},
+					},
+					"limit": {
+						Type:        "integer",
+						Description: "Maximum resources listed per check (default: 50, max: 200)",
+					},
+				},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "audit_tag_policy",
+			Description: "Check hosts, monitors and dashboards for required tag keys (e.g., env, service, team) and report compliance, optionally adding missing tags in bulk. Dry run unless apply is set; applying requires DD_MCP_ALLOW_WRITES=true.",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"required_keys": {
+						Type:        "array",
+						Description: "Tag keys every resource must carry (e.g., ['env', 'service', 'team'])",
+						Items:       &SchemaProperty{Type: "string"},
+					},
+					"resources": {
+						Type:        "array",
+						Description: "Resource types to scan: hosts, monitors, dashboards (default: all)",
+						Items:       &SchemaProperty{Type: "string"},
+					},
+					"name": {
+						Type:        "string",
+						Description: "Only resources whose host name or title contains this text",
+					},
+					"fixes": {
+						Type:        "array",
+						Description: "Tags to add where their key is missing, as key:value (e.g., 'team:payments')",
+						Items:       &SchemaProperty{Type: "string"},
+					},
+					"apply": {
+						Type:        "boolean",
+						Description: "Add the planned tags instead of only reporting them (default: false)",
+					},
+					"confirm": {
+						Type:        "boolean",
+						Description: "Must be true with apply, confirming the write was intended",
+					},
+					"limit": {
+						Type:        "integer",
+						Description: "Maximum violations listed per resource type (default: 50, max: 200)",
+					},
+				},
+				Required: []string{"required_keys"},
+			},
+			Annotations: writeToolAnnotations(false),
+		},
+		{
+			Name:        "detect_usage_anomalies",
+			Description: "Compare this month's Datadog usage per product (hosts, custom metrics, logs, spans, RUM, synthetics...) with previous months, flag significant changes and name the likely driver: the logs index, custom metric or usage attribution tags that changed most. Needs the usage_read permission.",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"month": {
+						Type:        "string",
+						Description: "Month to check as YYYY-MM. Defaults to the current month, whose summed usage is projected to the full month.",
+					},
+					"months": {
+						Type:        "integer",
+						Description: "Earlier months averaged as the baseline (default: 3, max: 12)",
+					},
+					"threshold": {
+						Type:        "number",
+						Description: "Change from the baseline, in percent, that counts as an anomaly (default: 25)",
+					},
+				},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "get_usage",
+			Description: "Get Datadog usage by product family over time (hourly usage summed per hour or day) to see ingestion trends for logs, APM spans, custom metrics and other products. Needs the usage_read permission.",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"product_families": {
+						Type:        "array",
+						Description: "Usage product families such as logs, indexed_logs, ingested_spans, indexed_spans, timeseries (custom metrics), infra_hosts or rum. 'apm' and 'custom_metrics' are accepted as aliases. Defaults to logs, spans and custom metrics.",
+						Items:       &SchemaProperty{Type: "string"},
+					},
+					"from": {
+						Type:        "string",
+						Description: "Start time (RFC3339 or relative like '30d'). Defaults to 7 days ago; windows are limited to 90 days.",
+					},
+					"to": {
+						Type:        "string",
+						Description: "End time (RFC3339 or relative). Defaults to now.",
+					},
+					"interval": {
+						Type:        "string",
+						Description: "Point interval: 'hour' or 'day'. Defaults to hour for windows up to 2 days, day otherwise.",
+					},
+				},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "get_estimated_cost",
+			Description: "Get the org's Datadog cost by product for a month and the months before it: billed cost for past months and Datadog's estimate for the current and not yet billed months. Needs the usage_read and billing_read permissions.",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"month": {
+						Type:        "string",
+						Description: "Month as YYYY-MM. Defaults to the current month, whose estimate covers usage so far.",
+					},
+					"months": {
+						Type:        "integer",
+						Description: "Earlier months to include for comparison (default: 2, max: 12)",
+					},
+				},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "list_reference_tables",
+			Description: "List Datadog reference tables (enrichment data such as customer-id to customer-name) with their schema and primary keys",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"name_contains": {
+						Type:        "string",
+						Description: "Only tables whose name contains this text",
+					},
+					"limit": {
+						Type:        "integer",
+						Description: "Maximum number of tables to return (max 100). Defaults to 50.",
+					},
+				},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "lookup_reference_table",
+			Description: "Look up rows of a Datadog reference table by primary key, to enrich ids found in logs or events with the data Datadog already holds",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"table": {
+						Type:        "string",
+						Description: "Table name or id",
+					},
+					"keys": {
+						Type:        "array",
+						Description: "Primary key values to look up (max 100)",
+						Items:       &SchemaProperty{Type: "string"},
+					},
+				},
+				Required: []string{"table", "keys"},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "resolve_runbooks",
+			Description: "Find runbook links for a monitor or service from monitor messages, monitor assets and service catalog metadata, optionally fetching their content",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"monitor_id": {
+						Type:        "integer",
+						Description: "Monitor whose runbooks to resolve. Its service tag is also used for the service catalog lookup.",
+					},
+					"monitor": {
+						Type:        "string",
+						Description: "Monitor name, used when monitor_id isn't known. Close misspellings are corrected.",
+					},
+					"service": {
+						Type:        "string",
+						Description: "Service whose service catalog runbook links to resolve. Close misspellings are corrected.",
+					},
+					"fetch_content": {
+						Type:        "boolean",
+						Description: "Fetch runbook page content for URLs on hosts allowed by DD_MCP_RUNBOOK_HOSTS. Defaults to false.",
+					},
+				},
+				AnyOf: []SchemaCondition{{Required: []string{"monitor_id"}}, {Required: []string{"monitor"}}, {Required: []string{"service"}}},
+				Not:   &SchemaCondition{Required: []string{"monitor_id", "monitor"}},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "fetch_continuation",
+			Description: "Fetch the next part of a tool result that was truncated because it exceeded the configured size limit",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"id": {
+						Type:        "string",
+						Description: "Continuation id from the truncation notice",
+					},
+				},
+				Required: []string{"id"},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "server_stats",
+			Description: "Report the MCP server's own uptime and result store occupancy",
+			InputSchema: InputSchema{
+				Type:       "object",
+				Properties: map[string]SchemaProperty{},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "set_context",
+			Description: "Pin service, env, cluster and time window defaults for this session. They are merged into query_logs, query_events, alert_fatigue_report, detect_anomalies and forecast_metric unless a call sets them itself.",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"service": {
+						Type:        "string",
+						Description: "Service to scope queries to (adds service:<value>). Empty string unpins it.",
+					},
+					"env": {
+						Type:        "string",
+						Description: "Environment to scope queries to (adds env:<value>). Empty string unpins it.",
+					},
+					"cluster": {
+						Type:        "string",
+						Description: "Kubernetes cluster to scope queries to (adds kube_cluster_name:<value>). Empty string unpins it.",
+					},
+					"window": {
+						Type:        "string",
+						Description: "Default lookback (e.g., '4h', '7d') used when a call gives no from or window. Empty string unpins it.",
+					},
+					"clear": {
+						Type:        "boolean",
+						Description: "Unpin everything before applying the other fields",
+					},
+				},
+			},
+			Annotations: writeToolAnnotations(false),
+		},
+		{
+			Name:        "get_context",
+			Description: "Show the defaults pinned for this session with set_context",
+			InputSchema: InputSchema{
+				Type:       "object",
+				Properties: map[string]SchemaProperty{},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+		{
+			Name:        "export_session",
+			Description: "Export this session's tool calls, their arguments and summarized results as a markdown or JSON transcript, for attaching investigation evidence to incident tickets and postmortems",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]SchemaProperty{
+					"format": {
+						Type:        "string",
+						Description: "Transcript format: 'markdown' or 'json' (default: markdown)",
+					},
+					"last": {
+						Type:        "integer",
+						Description: "Only export the most recent N calls (default: all recorded calls)",
+					},
+				},
+			},
+			Annotations: readOnlyToolAnnotations(),
+		},
+	}
+	if s.allowWrites {
+		tools = append(tools, muteTools...)
+		tools = append(tools, downtimeTools...)
+		tools = append(tools, postEventTool)
+		tools = append(tools, triggerSyntheticTool)
+		tools = append(tools, recordDeploymentTool)
+		tools = append(tools, handoffTools...)
+	}
+	if len(s.orgs) > 1 {
+		tools = append(tools, s.compareOrgsTool())
+	}
+	tools = append(tools, s.reports.list()...)
+	tools = append(tools, s.plugins.list()...)
+	tools = append(tools, s.macros.list()...)
+	tools = s.withSchemaVersion(tools)
+	if s.apiKeyOnly {
+		tools = slices.DeleteFunc(tools, func(tool Tool) bool { return !s.availableWithoutAppKey(tool) })
+	}
+	if len(s.disabledProducts) > 0 {
+		tools = slices.DeleteFunc(tools, func(tool Tool) bool { return s.disabledProduct(tool.Name) != "" })
+	}
+	return tools
+}
+
+func parseTimeParam(timeStr string, defaultTime time.Time) (time.Time, error) {
+	if timeStr == "" {
+		return defaultTime, nil
+	}
+	t, _, err := parseTime(timeStr)
+	return t, err
+}
+
+// zonelessLayouts are the timestamp forms read as UTC when a time has no
+// offset.
+var zonelessLayouts = []string{"2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02T15:04", "2006-01-02 15:04"}
+
+// parseTime reads an RFC3339 time, a duration ago, or a timestamp without
+// a zone, which it reads as UTC and reports.
+func parseTime(timeStr string) (t time.Time, assumedUTC bool, err error) {
+	// Try parsing as RFC3339
+	if t, err := time.Parse(time.RFC3339, timeStr); err == nil {
+		return t, false, nil
+	}
+
+	// Try parsing as relative time (e.g., "1h", "30m")
+	if duration, err := time.ParseDuration(timeStr); err == nil {
+		return time.Now().Add(-duration), false, nil
+	}
+
+	for _, layout := range zonelessLayouts {
+		if t, err := time.Parse(layout, timeStr); err == nil {
+			return t, true, nil
+		}
+	}
+
+	return time.Time{}, false, fmt.Errorf("invalid time format: %s (use RFC3339 or duration like '1h')", timeStr)
+}
+
+func (s *MCPServer) QueryLogs(params QueryLogsParams) (*QueryLogsResult, error) {
+	if params.Query == "" {
+		return nil, fmt.Errorf("query parameter is required")
+	}
+	started := time.Now()
+
+	// Default time range: last 1 hour
+	defaultFrom := time.Now().Add(-1 * time.Hour)
+	defaultTo := time.Now()
+
+	from, err := s.timeParam("from", params.From, defaultFrom)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := s.timeParam("to", params.To, defaultTo)
+	if err != nil {
+		return nil, err
+	}
+
+	var notes []string
+	from, to, note, err := ingestWindow(params.TimeField, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if note != "" {
+		notes = append(notes, note)
+	}
+
+	limit := 50
+	if params.Limit > 0 {
+		maxLimit := maxLogsLimit
+		if s.logStream != nil {
+			maxLimit = maxStreamedLogs
+		}
+		limit = clampArgument(s, "limit", int(params.Limit), maxLimit)
+	}
+
+	// Build the logs search request
+	body := datadogV2.LogsListRequest{
+		Filter: &datadogV2.LogsQueryFilter{
+			From:  datadog.PtrString(from.Format(time.RFC3339)),
+			To:    datadog.PtrString(to.Format(time.RFC3339)),
+			Query: datadog.PtrString(params.Query),
+		},
+		Page: &datadogV2.LogsListRequestPage{},
+		Sort: datadogV2.LOGSSORT_TIMESTAMP_DESCENDING.Ptr(),
+	}
+
+	api := datadogV2.NewLogsApi(s.ddClient)
+
+	// Format the response, fetching further pages until the limit is
+	// reached or Datadog has no more results.
+	logs := make([]LogEntry, 0)
+	stacks := make([]string, 0)
+	suppressions := s.suppressions
+	if params.IncludeSuppressed {
+		suppressions = nil
+	}
+	var suppressed map[string]int
+	var partial *PartialData
+	var latest *time.Time
+	fetched, streamed := 0, 0
+	more := false
+	for fetched < limit {
+		body.Page.Limit = datadog.PtrInt32(int32(min(limit-fetched, logsPageSize)))
+		resp, _, err := api.ListLogs(s.ctx, *datadogV2.NewListLogsOptionalParameters().WithBody(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to query logs: %w", err)
+		}
+
+		page := make([]LogEntry, 0, len(resp.Data))
+		for _, log := range resp.Data {
+			entry := LogEntry{ID: log.GetId()}
+			if attrs := log.Attributes; attrs != nil {
+				entry.Timestamp = attrs.Timestamp
+				entry.Message = attrs.GetMessage()
+				entry.Status = attrs.GetStatus()
+				entry.Service = attrs.GetService()
+				entry.Tags = attrs.GetTags()
+				entry.Client = s.geoip.enrich(attrs.GetAttributes())
+				partial = partial.record(missingFields(
+					fieldCheck{"id", log.Id != nil},
+					fieldCheck{"timestamp", attrs.Timestamp != nil},
+					fieldCheck{"status", attrs.Status != nil},
+				)...)
+			} else {
+				partial = partial.record("attributes")
+			}
+			if name := suppressions.match(entry); name != "" {
+				if suppressed == nil {
+					suppressed = make(map[string]int)
+				}
+				suppressed[name]++
+				continue
+			}
+			page = append(page, entry)
+			stacks = append(stacks, errorStack(log.Attributes.GetAttributes()))
+		}
+		fetched += len(resp.Data)
+		if s.logStream != nil {
+			// Streamed logs skip source linking, which looks up each
+			// service's definition for every page.
+			stacks = stacks[:0]
+			if err := s.logStream(page); err != nil {
+				return nil, err
+			}
+			streamed += len(page)
+			latest = latestTime(latest, latestLog(page))
+		} else {
+			logs = append(logs, page...)
+		}
+
+		cursor := resp.GetMeta().Page.GetAfter()
+		more = cursor != "" && len(resp.Data) > 0
+		if !more || fetched >= limit {
+			break
+		}
+		body.Page.Cursor = datadog.PtrString(cursor)
+		s.reportLogsPage(&QueryLogsResult{
+			Logs:  page,
+			Count: len(page),
+			Query: params.Query,
+			From:  from.Format(time.RFC3339),
+			To:    to.Format(time.RFC3339),
+		}, fetched, limit)
+	}
+
+	if s.logStream == nil {
+		s.linkSources(logs, stacks)
+		streamed, latest = len(logs), latestLog(logs)
+	}
+	if len(suppressed) > 0 {
+		notes = append(notes, suppressionNote(suppressed))
+	}
+
+	// An empty result for a misspelled service reads as "no errors", so
+	// check the name before reporting nothing.
+	var diagnostics []Diagnostic
+	if fetched == 0 {
+		corrected, note := s.correctServiceFilter(params.Query)
+		if corrected != "" {
+			params.Query = corrected
+			result, err := s.QueryLogs(params)
+			if err != nil {
+				return nil, err
+			}
+			result.Notes = append([]string{note}, result.Notes...)
+			return result, nil
+		}
+		if note != "" {
+			notes = append(notes, note)
+		}
+		diagnostics = s.diagnoseEmptyLogs(params.Query, from, to)
+	}
+	var refinement *Refinement
+	if more {
+		refinement = s.refineLogs(params.Query, from, to, fetched)
+	}
+
+	return &QueryLogsResult{
+		Logs:        logs,
+		Count:       streamed,
+		Query:       params.Query,
+		From:        from.Format(time.RFC3339),
+		To:          to.Format(time.RFC3339),
+		Notes:       notes,
+		Diagnostics: diagnostics,
+		Refinement:  refinement,
+		Freshness:   newFreshness("logs", started, to, latest),
+		Suppressed:  suppressed,
+		Partial:     partial,
+	}, nil
+}
+
+func latestLog(logs []LogEntry) *time.Time {
+	times := make([]*time.Time, 0, len(logs))
+	for _, log := range logs {
+		times = append(times, log.Timestamp)
+	}
+	return latestTime(times...)
+}
+
+// callTool runs one tool and returns its unprocessed text result.
+func (s *MCPServer) callTool(params ToolCallParams) (text string, toolErr *MCPError) {
+	defer recoverToolPanic(params.Name, &toolErr)
+	defer func() { s.explainSiteError(toolErr) }()
+	if err := s.requireAppKey(params.Name); err != nil {
+		return "", err
+	}
+	if err := s.requireProduct(params.Name); err != nil {
+		return "", err
+	}
+	params.Arguments = s.applySessionContext(params.Name, params.Arguments)
+	if err := s.preflight(params); err != nil {
+		return "", err
+	}
+	arguments, version, versionErr := s.schemaVersion(params)
+	if versionErr != nil {
+		return "", versionErr
+	}
+	params.Arguments = arguments
+	if version > 0 {
+		defer func() {
+			if toolErr == nil {
+				text = versionOutput(params.Name, text, version)
+			}
+		}()
+	}
+
+	switch params.Name {
+	case "query_logs":
+		var queryParams QueryLogsParams
+		if err := json.Unmarshal(params.Arguments, &queryParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		if err := s.quotas.checkLogs(s.quotaKey(), time.Now()); err != nil {
+			return "", toolError(params.Name, err)
+		}
+
+		if len(queryParams.Services) > 0 {
+			result, err := s.QueryServiceLogs(queryParams)
+			if err != nil {
+				return "", toolError(params.Name, err)
+			}
+			s.quotas.recordLogs(s.quotaKey(), result.Count, time.Now())
+			text = formatResult(result)
+			break
+		}
+
+		result, err := s.QueryLogs(queryParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		s.quotas.recordLogs(s.quotaKey(), result.Count, time.Now())
+		text = formatLogsResult(result)
+
+	case "detect_anomalies":
+		var anomalyParams AnomalyParams
+		if err := json.Unmarshal(params.Arguments, &anomalyParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		if len(anomalyParams.Services) > 0 {
+			result, err := s.DetectServiceAnomalies(anomalyParams)
+			if err != nil {
+				return "", toolError(params.Name, err)
+			}
+			text = formatResult(result)
+			break
+		}
+
+		result, err := s.DetectAnomalies(anomalyParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatMetricInsightResult(result)
+
+	case "forecast_metric":
+		var forecastParams ForecastParams
+		if err := json.Unmarshal(params.Arguments, &forecastParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		if len(forecastParams.Services) > 0 {
+			result, err := s.ForecastServiceMetrics(forecastParams)
+			if err != nil {
+				return "", toolError(params.Name, err)
+			}
+			text = formatResult(result)
+			break
+		}
+
+		result, err := s.ForecastMetric(forecastParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatMetricInsightResult(result)
+
+	case "simulate_monitor":
+		var simulateParams SimulateMonitorParams
+		if err := json.Unmarshal(params.Arguments, &simulateParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.SimulateMonitor(simulateParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "detect_cardinality_growth":
+		var cardinalityParams CardinalityParams
+		if err := json.Unmarshal(params.Arguments, &cardinalityParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.DetectCardinalityGrowth(cardinalityParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "alert_fatigue_report":
+		var fatigueParams AlertFatigueParams
+		if err := json.Unmarshal(params.Arguments, &fatigueParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.AlertFatigueReport(fatigueParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "query_events":
+		var eventsParams QueryEventsParams
+		if err := json.Unmarshal(params.Arguments, &eventsParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.QueryEvents(eventsParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "query_spans":
+		var spansParams QuerySpansParams
+		if err := json.Unmarshal(params.Arguments, &spansParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.QuerySpans(spansParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "aggregate_spans":
+		var aggregateParams AggregateSpansParams
+		if err := json.Unmarshal(params.Arguments, &aggregateParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.AggregateSpans(aggregateParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "get_trace":
+		var traceParams GetTraceParams
+		if err := json.Unmarshal(params.Arguments, &traceParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		trace, err := s.GetTrace(traceParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = trace
+
+	case "explain_query":
+		var explainParams ExplainQueryParams
+		if err := json.Unmarshal(params.Arguments, &explainParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.ExplainQuery(explainParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "list_services":
+		var servicesParams ListServicesParams
+		if err := json.Unmarshal(params.Arguments, &servicesParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.ListServices(servicesParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "get_service_dependencies":
+		var dependencyParams ServiceDependenciesParams
+		if err := json.Unmarshal(params.Arguments, &dependencyParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.GetServiceDependencies(dependencyParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+		if dependencyParams.Diagram {
+			text += "\n\n" + dependencyGraph(result)
+		}
+
+	case "get_blast_radius":
+		var blastParams BlastRadiusParams
+		if err := json.Unmarshal(params.Arguments, &blastParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.BlastRadius(blastParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "list_monitors":
+		var monitorsParams ListMonitorsParams
+		if err := json.Unmarshal(params.Arguments, &monitorsParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.ListMonitors(monitorsParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "list_slos":
+		var slosParams ListSLOsParams
+		if err := json.Unmarshal(params.Arguments, &slosParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.ListSLOs(slosParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "get_slo_status":
+		var sloParams GetSLOStatusParams
+		if err := json.Unmarshal(params.Arguments, &sloParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.GetSLOStatus(sloParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "get_slo_history":
+		var historyParams GetSLOHistoryParams
+		if err := json.Unmarshal(params.Arguments, &historyParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.GetSLOHistory(historyParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "list_dashboards":
+		var dashboardsParams ListDashboardsParams
+		if err := json.Unmarshal(params.Arguments, &dashboardsParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.ListDashboards(dashboardsParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "diff_resource":
+		var diffParams DiffResourceParams
+		if err := json.Unmarshal(params.Arguments, &diffParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.DiffResource(diffParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "get_dashboard":
+		var dashboardParams GetDashboardParams
+		if err := json.Unmarshal(params.Arguments, &dashboardParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.GetDashboard(dashboardParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "list_incidents":
+		var incidentsParams ListIncidentsParams
+		if err := json.Unmarshal(params.Arguments, &incidentsParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.ListIncidents(incidentsParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "get_incident":
+		var incidentParams GetIncidentParams
+		if err := json.Unmarshal(params.Arguments, &incidentParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.GetIncident(incidentParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+		if incidentParams.Diagram {
+			text += "\n\n" + incidentGantt(result, time.Now())
+		}
+
+	case "get_incident_timeline":
+		var timelineParams GetIncidentTimelineParams
+		if err := json.Unmarshal(params.Arguments, &timelineParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		timeline, err := s.GetIncidentTimeline(timelineParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = timeline
+
+	case "generate_postmortem":
+		var postmortemParams GeneratePostmortemParams
+		if err := json.Unmarshal(params.Arguments, &postmortemParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		postmortem, err := s.GeneratePostmortem(postmortemParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = postmortem
+
+	case "query_processes":
+		var processParams QueryProcessesParams
+		if err := json.Unmarshal(params.Arguments, &processParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.QueryProcesses(processParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "list_security_rules":
+		var ruleParams ListSecurityRulesParams
+		if err := json.Unmarshal(params.Arguments, &ruleParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.ListSecurityRules(ruleParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "list_security_findings":
+		var findingParams ListSecurityFindingsParams
+		if err := json.Unmarshal(params.Arguments, &findingParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.ListSecurityFindings(findingParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "query_ci_tests":
+		var ciParams QueryCITestsParams
+		if err := json.Unmarshal(params.Arguments, &ciParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.QueryCITests(ciParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "list_users":
+		var usersParams ListUsersParams
+		if err := json.Unmarshal(params.Arguments, &usersParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.ListUsers(usersParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "list_teams":
+		var teamsParams ListTeamsParams
+		if err := json.Unmarshal(params.Arguments, &teamsParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.ListTeams(teamsParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "get_team":
+		var teamParams GetTeamParams
+		if err := json.Unmarshal(params.Arguments, &teamParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.GetTeam(teamParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "list_containers":
+		var containerParams ListContainersParams
+		if err := json.Unmarshal(params.Arguments, &containerParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.ListContainers(containerParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "get_host_totals":
+		var totalsParams GetHostTotalsParams
+		if err := json.Unmarshal(params.Arguments, &totalsParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.GetHostTotals(totalsParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "list_hosts":
+		var hostsParams ListHostsParams
+		if err := json.Unmarshal(params.Arguments, &hostsParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.ListHosts(hostsParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "get_monitor":
+		var monitorParams GetMonitorParams
+		if err := json.Unmarshal(params.Arguments, &monitorParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.GetMonitor(monitorParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatMonitorResult(result)
+
+	case "watch_monitor":
+		var watchParams WatchMonitorParams
+		if err := json.Unmarshal(params.Arguments, &watchParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.WatchMonitor(watchParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "list_synthetic_tests":
+		var syntheticsParams ListSyntheticTestsParams
+		if err := json.Unmarshal(params.Arguments, &syntheticsParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.ListSyntheticTests(syntheticsParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "get_synthetic_results":
+		var resultsParams GetSyntheticResultsParams
+		if err := json.Unmarshal(params.Arguments, &resultsParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.GetSyntheticResults(resultsParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "get_synthetic_batch":
+		var batchParams GetSyntheticBatchParams
+		if err := json.Unmarshal(params.Arguments, &batchParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.GetSyntheticBatch(batchParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "mute_monitor":
+		var muteParams MuteMonitorParams
+		if err := json.Unmarshal(params.Arguments, &muteParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.MuteMonitor(muteParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "unmute_monitor":
+		var unmuteParams UnmuteMonitorParams
+		if err := json.Unmarshal(params.Arguments, &unmuteParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.UnmuteMonitor(unmuteParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "create_downtime":
+		var downtimeParams CreateDowntimeParams
+		if err := json.Unmarshal(params.Arguments, &downtimeParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.CreateDowntime(downtimeParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "cancel_downtime":
+		var cancelParams CancelDowntimeParams
+		if err := json.Unmarshal(params.Arguments, &cancelParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.CancelDowntime(cancelParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "metric_related_assets":
+		var assetsParams MetricAssetsParams
+		if err := json.Unmarshal(params.Arguments, &assetsParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.MetricRelatedAssets(assetsParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "post_slack_summary":
+		var slackParams HandoffParams
+		if err := json.Unmarshal(params.Arguments, &slackParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.PostSlackSummary(slackParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "create_jira_ticket":
+		var jiraParams JiraHandoffParams
+		if err := json.Unmarshal(params.Arguments, &jiraParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.CreateJiraTicket(jiraParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "export_session":
+		var exportParams ExportSessionParams
+		if err := json.Unmarshal(params.Arguments, &exportParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		transcript, err := s.ExportSession(exportParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = transcript
+
+	case "compare_orgs":
+		var compareParams CompareOrgsParams
+		if err := json.Unmarshal(params.Arguments, &compareParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.CompareOrgs(compareParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "generate_report":
+		var reportParams GenerateReportParams
+		if err := json.Unmarshal(params.Arguments, &reportParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		report, err := s.GenerateReport(reportParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = report
+
+	case "audit_orphaned_resources":
+		var auditParams OrphanAuditParams
+		if err := json.Unmarshal(params.Arguments, &auditParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.AuditOrphanedResources(auditParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "audit_tag_policy":
+		var policyParams TagPolicyParams
+		if err := json.Unmarshal(params.Arguments, &policyParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.AuditTagPolicy(policyParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "detect_usage_anomalies":
+		var usageParams UsageAnomaliesParams
+		if err := json.Unmarshal(params.Arguments, &usageParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.DetectUsageAnomalies(usageParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "get_usage":
+		var usageParams GetUsageParams
+		if err := json.Unmarshal(params.Arguments, &usageParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.GetUsage(usageParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "get_estimated_cost":
+		var costParams EstimatedCostParams
+		if err := json.Unmarshal(params.Arguments, &costParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.GetEstimatedCost(costParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "list_reference_tables":
+		var tablesParams ListReferenceTablesParams
+		if err := json.Unmarshal(params.Arguments, &tablesParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.ListReferenceTables(tablesParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "lookup_reference_table":
+		var lookupParams LookupReferenceTableParams
+		if err := json.Unmarshal(params.Arguments, &lookupParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.LookupReferenceTable(lookupParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "post_event":
+		var eventParams PostEventParams
+		if err := json.Unmarshal(params.Arguments, &eventParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.PostEvent(eventParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "trigger_synthetic_test":
+		var triggerParams TriggerSyntheticTestParams
+		if err := json.Unmarshal(params.Arguments, &triggerParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.TriggerSyntheticTest(triggerParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "record_deployment":
+		var deploymentParams RecordDeploymentParams
+		if err := json.Unmarshal(params.Arguments, &deploymentParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.RecordDeployment(deploymentParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "resolve_runbooks":
+		var runbookParams ResolveRunbooksParams
+		if err := json.Unmarshal(params.Arguments, &runbookParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.ResolveRunbooks(runbookParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "fetch_continuation":
+		var continuationParams struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(params.Arguments, &continuationParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		rest, err := s.FetchContinuation(continuationParams.ID)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = rest
+
+	case "server_stats":
+		text = formatResult(s.Stats())
+
+	case "set_context":
+		var contextParams SetContextParams
+		if err := json.Unmarshal(params.Arguments, &contextParams); err != nil {
+			return "", &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+
+		result, err := s.SetContext(contextParams)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = formatResult(result)
+
+	case "get_context":
+		text = formatResult(s.GetContext())
+
+	default:
+		if macro, ok := s.macros.lookup(params.Name); ok {
+			result, macroErr := s.runMacro(macro, params.Arguments)
+			if macroErr != nil {
+				return "", macroErr
+			}
+			text = formatResult(result)
+			break
+		}
+
+		p, ok := s.plugins.lookup(params.Name)
+		if !ok {
+			return "", &MCPError{Code: -32601, Message: fmt.Sprintf("unknown tool: %s", params.Name)}
+		}
+
+		result, err := p.call(s.ctx, params.Name, params.Arguments)
+		if err != nil {
+			return "", toolError(params.Name, err)
+		}
+		text = result
+	}
+	return text, nil
+}
+
+func (s *MCPServer) HandleRequest(req MCPRequest) MCPResponse {
+	return s.HandleRequestContext(context.Background(), req)
+}
+
+// HandleRequestContext handles req under a context derived from parent, so
+// cancelling parent aborts the request's Datadog calls.
+func (s *MCPServer) HandleRequestContext(parent context.Context, req MCPRequest) MCPResponse {
+	server, cancel := s.withContext(parent)
+	defer cancel()
+	started := time.Now()
+	resp := server.traceRequest(req, func(server *MCPServer) MCPResponse {
+		return server.handleRequest(req)
+	})
+	s.requestLog.log(s.session, req, resp, time.Since(started))
+	return resp
+}
+
+func (s *MCPServer) handleRequest(req MCPRequest) MCPResponse {
+	resp := MCPResponse{
+		Jsonrpc: "2.0",
+		ID:      req.ID,
+	}
+
+	switch req.Method {
+	case "initialize":
+		s.recordClient(req.Params)
+		result := InitializeResult{
+			ProtocolVersion: "2024-11-05",
+			Instructions:    strings.TrimSpace(s.apiKeyOnlyInstructions() + " " + s.siteInstructions()),
+			ServerInfo: ServerInfo{
+				Name:    "datadog-mcp-server",
+				Version: serverVersion,
+			},
+			Capabilities: ServerCapabilities{
+				Tools:     ToolsCapability{},
+				Resources: ResourcesCapability{},
+				Experimental: map[string]interface{}{
+					"datadog": s.datadogCapabilities(),
+				},
+			},
+		}
+		resultJSON, err := json.Marshal(result)
+		if err != nil {
+			resp.Error = &MCPError{Code: -32603, Message: fmt.Sprintf("failed to marshal result: %v", err)}
+			return resp
+		}
+		resp.Result = resultJSON
+
+	case "tools/list":
+		result := ToolsListResult{
+			Tools: s.ListTools(),
+		}
+		resultJSON, err := json.Marshal(result)
+		if err != nil {
+			resp.Error = &MCPError{Code: -32603, Message: fmt.Sprintf("failed to marshal result: %v", err)}
+			return resp
+		}
+		resp.Result = resultJSON
+
+	case "resources/list":
+		resultJSON, err := json.Marshal(ResourcesListResult{Resources: s.documents.list(s.apiOrg())})
+		if err != nil {
+			resp.Error = &MCPError{Code: -32603, Message: fmt.Sprintf("failed to marshal result: %v", err)}
+			return resp
+		}
+		resp.Result = resultJSON
+
+	case "resources/read":
+		result, readErr := s.readResource(req.Params)
+		if readErr != nil {
+			resp.Error = readErr
+			return resp
+		}
+		resultJSON, err := json.Marshal(result)
+		if err != nil {
+			resp.Error = &MCPError{Code: -32603, Message: fmt.Sprintf("failed to marshal result: %v", err)}
+			return resp
+		}
+		resp.Result = resultJSON
+
+	case "tools/call":
+		var params ToolCallParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			resp.Error = &MCPError{Code: -32602, Message: fmt.Sprintf("invalid params: %v", err)}
+			return resp
+		}
+
+		if params.Name == "" {
+			resp.Error = &MCPError{Code: -32602, Message: "tool name is required"}
+			return resp
+		}
+
+		if err := s.quotas.allowCall(s.quotaKey(), time.Now()); err != nil {
+			resp.Error = &MCPError{Code: -32000, Message: err.Error()}
+			return resp
+		}
+
+		if params.Meta != nil && len(params.Meta.ProgressToken) > 0 {
+			s = s.withProgress(params.Meta.ProgressToken)
+		}
+
+		s = s.withAdjustments().withFailoverReport()
+		started := time.Now()
+		text, toolErr := s.callTool(params)
+		s.recordCall(params, started, text, toolErr)
+		if toolErr != nil {
+			resp.Error = toolErr
+			return resp
+		}
+
+		text, meta := s.fitTokenBudget(s.postProcess(params.Name, text))
+		meta.Adjustments = s.argumentAdjustments()
+		meta.Failover = s.failoverNotes()
+		if _, version, _ := s.schemaVersion(params); version > 0 {
+			meta.SchemaVersion = version
+			meta.Deprecation = schemaDeprecation(params.Name, version)
+		}
+		toolResult := ToolCallResult{
+			Content: []TextContent{
+				{
+					Type: "text",
+					Text: text,
+				},
+			},
+			Meta: meta,
+		}
+		resultJSON, err := json.Marshal(toolResult)
+		if err != nil {
+			resp.Error = &MCPError{Code: -32603, Message: fmt.Sprintf("failed to marshal result: %v", err)}
+			return resp
+		}
+		resp.Result = resultJSON
+
+	default:
+		resp.Error = &MCPError{Code: -32601, Message: fmt.Sprintf("unknown method: %s", req.Method)}
+	}
+
+	return resp
+}
+
+func formatLogsResult(result *QueryLogsResult) string {
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Sprintf(`{"error": "failed to format result: %v"}`, err)
+	}
+	return string(data)
+}
+
+// errorStack returns the error.stack attribute of a log, if present.
+func errorStack(attributes map[string]interface{}) string {
+	errAttr, ok := attributes["error"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	stack, _ := errAttr["stack"].(string)
+	return stack
+}
+
+// formatResult renders any tool result as indented JSON.
+func formatResult(result interface{}) string {
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Sprintf(`{"error": "failed to format result: %v"}`, err)
+	}
+	return string(data)
+}
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "simulate" {
+		if err := runSimulate(os.Args[2:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "init" {
+		configuration := datadog.NewConfiguration()
+		if err := runInit(os.Args[2:], os.Stdin, os.Stdout, datadog.NewAPIClient(configuration)); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if err := applyConfigFile(); err != nil {
+		log.Fatal(err)
+	}
+	if len(os.Args) > 1 && os.Args[1] == "call" {
+		if err := runCall(os.Args[2:], os.Stdin, os.Stdout, os.Stderr, NewMCPServer); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	server, err := NewMCPServer()
+	if err != nil {
+		log.Fatalf("Failed to initialize MCP server: %v", err)
+	}
+	// Gateway users are warmed up on their first request instead.
+	if server.tenants == nil {
+		server.warmUp()
+	}
+
+	switch transport := os.Getenv("DD_MCP_TRANSPORT"); transport {
+	case "", "stdio":
+		if server.tenants != nil {
+			log.Fatalf("Gateway mode requires DD_MCP_TRANSPORT=http")
+		}
+		serveStdio(server)
+		server.Shutdown()
+	case "http":
+		if err := serveHTTP(server, loadHTTPConfig()); err != nil {
+			log.Fatalf("HTTP server failed: %v", err)
+		}
+		server.Shutdown()
+	default:
+		log.Fatalf("Unknown transport: %s (use stdio or http)", transport)
+	}
+}
+
+// Shutdown flushes telemetry before the process exits.
+func (s *MCPServer) Shutdown() {
+	if s.shutdownTelemetry == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.shutdownTelemetry(ctx); err != nil {
+		log.Printf("Error flushing telemetry: %v", err)
 	}
 }
@@ -2,10 +2,24 @@ package main
 
 import (
 	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
 	"testing"
 	"time"
 )
 
+// writeTempFile writes content to a file called name in a fresh temporary
+// directory and returns its path.
+func writeTempFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
 func TestParseTimeParam(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -107,6 +121,40 @@ func TestMCPServerListTools(t *testing.T) {
 	}
 }
 
+func TestToolAnnotations(t *testing.T) {
+	server := &MCPServer{
+		allowWrites: true,
+		orgs:        map[string]OrgProfile{"us": {}, "eu": {}},
+		reports:     &reportRegistry{names: []string{"weekly"}},
+	}
+	writes := map[string]bool{"set_context": true}
+	for _, tool := range server.ListTools() {
+		if tool.Annotations == nil {
+			t.Errorf("%s has no annotations, so clients must treat it as destructive", tool.Name)
+			continue
+		}
+		_, confirms := tool.InputSchema.Properties["confirm"]
+		if confirms || writes[tool.Name] {
+			if tool.Annotations.ReadOnlyHint {
+				t.Errorf("write tool %s is marked read-only", tool.Name)
+			}
+			continue
+		}
+		if !tool.Annotations.ReadOnlyHint || tool.Annotations.DestructiveHint {
+			t.Errorf("read tool %s is not marked read-only: %+v", tool.Name, tool.Annotations)
+		}
+	}
+
+	// Without writes, tools that always write aren't listed. Tools that
+	// only write on request, such as generate_postmortem with attach, are.
+	server.allowWrites = false
+	for _, tool := range server.ListTools() {
+		if slices.Contains(tool.InputSchema.Required, "confirm") {
+			t.Errorf("write tool %s is listed with writes disabled", tool.Name)
+		}
+	}
+}
+
 func TestHandleInitializeRequest(t *testing.T) {
 	server := &MCPServer{}
 
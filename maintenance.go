@@ -0,0 +1,272 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaintenanceWindow is planned work during which alerts are expected,
+// such as a nightly backup or a one-off migration. A recurring window
+// repeats on Days (every day when empty) from Start to End in Timezone;
+// an End before Start runs past midnight. A one-off window runs From To.
+// Scope tags and MonitorIDs limit it to some monitors; with neither it
+// covers every monitor.
+type MaintenanceWindow struct {
+	Name       string   `json:"name"`
+	Days       []string `json:"days,omitempty"`
+	Start      string   `json:"start,omitempty"`
+	End        string   `json:"end,omitempty"`
+	Timezone   string   `json:"timezone,omitempty"`
+	From       string   `json:"from,omitempty"`
+	To         string   `json:"to,omitempty"`
+	Scope      []string `json:"scope,omitempty"`
+	MonitorIDs []int64  `json:"monitor_ids,omitempty"`
+
+	days       map[time.Weekday]bool
+	start, end time.Duration
+	location   *time.Location
+	from, to   time.Time
+}
+
+// maintenanceWindows holds the configured windows. A nil list covers
+// nothing.
+type maintenanceWindows struct {
+	windows []MaintenanceWindow
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// loadMaintenanceWindows reads the maintenance file and checks each
+// window's schedule.
+func loadMaintenanceWindows(path string) (*maintenanceWindows, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read maintenance file: %w", err)
+	}
+	var file struct {
+		Windows []MaintenanceWindow `json:"windows"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse maintenance file: %w", err)
+	}
+
+	list := &maintenanceWindows{}
+	seen := make(map[string]bool)
+	for _, window := range file.Windows {
+		if window.Name == "" {
+			return nil, fmt.Errorf("every maintenance window needs a name")
+		}
+		if seen[window.Name] {
+			return nil, fmt.Errorf("maintenance window %s is defined twice", window.Name)
+		}
+		seen[window.Name] = true
+		if err := window.parse(); err != nil {
+			return nil, fmt.Errorf("maintenance window %s: %w", window.Name, err)
+		}
+		list.windows = append(list.windows, window)
+	}
+	return list, nil
+}
+
+func (w *MaintenanceWindow) parse() error {
+	recurring := w.Start != "" || w.End != ""
+	oneOff := w.From != "" || w.To != ""
+	switch {
+	case recurring && oneOff:
+		return fmt.Errorf("set start and end for a recurring window or from and to for a one-off one, not both")
+	case oneOff:
+		if len(w.Days) > 0 || w.Timezone != "" {
+			return fmt.Errorf("days and timezone only apply to recurring windows")
+		}
+		var err error
+		if w.from, err = time.Parse(time.RFC3339, w.From); err != nil {
+			return fmt.Errorf("invalid from: %w", err)
+		}
+		if w.to, err = time.Parse(time.RFC3339, w.To); err != nil {
+			return fmt.Errorf("invalid to: %w", err)
+		}
+		if !w.to.After(w.from) {
+			return fmt.Errorf("to must be after from")
+		}
+		return nil
+	case !recurring:
+		return fmt.Errorf("needs start and end, or from and to")
+	}
+
+	var err error
+	if w.start, err = clockTime(w.Start); err != nil {
+		return fmt.Errorf("invalid start: %w", err)
+	}
+	if w.end, err = clockTime(w.End); err != nil {
+		return fmt.Errorf("invalid end: %w", err)
+	}
+	if w.start == w.end {
+		return fmt.Errorf("start and end are the same time")
+	}
+	w.location = time.UTC
+	if w.Timezone != "" {
+		if w.location, err = time.LoadLocation(w.Timezone); err != nil {
+			return fmt.Errorf("invalid timezone: %w", err)
+		}
+	}
+	if len(w.Days) > 0 {
+		w.days = make(map[time.Weekday]bool, len(w.Days))
+		for _, day := range w.Days {
+			weekday, ok := weekdays[strings.ToLower(day)[:min(3, len(day))]]
+			if !ok {
+				return fmt.Errorf("invalid day %q (use mon through sun)", day)
+			}
+			w.days[weekday] = true
+		}
+	}
+	return nil
+}
+
+// clockTime parses an "HH:MM" time of day into the time since midnight.
+func clockTime(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not an HH:MM time", value)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// covers reports whether the window is open at t.
+func (w *MaintenanceWindow) covers(t time.Time) bool {
+	if w.location == nil {
+		return !t.Before(w.from) && t.Before(w.to)
+	}
+	local := t.In(w.location)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, w.location)
+	offset := local.Sub(midnight)
+	if w.start < w.end {
+		return offset >= w.start && offset < w.end && w.onDay(local.Weekday())
+	}
+	// The window runs past midnight: it is open late on a day it starts
+	// and early on the day after.
+	if offset >= w.start {
+		return w.onDay(local.Weekday())
+	}
+	return offset < w.end && w.onDay((local.Weekday()+6)%7)
+}
+
+func (w *MaintenanceWindow) onDay(day time.Weekday) bool {
+	return w.days == nil || w.days[day]
+}
+
+// applies reports whether the window's scope includes a monitor with the
+// given ID and tags.
+func (w *MaintenanceWindow) applies(monitorID int64, tags []string) bool {
+	if len(w.MonitorIDs) > 0 && !slices.Contains(w.MonitorIDs, monitorID) {
+		return false
+	}
+	for _, tag := range w.Scope {
+		if !slices.Contains(tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// match returns the name of the first window that is open at t and covers
+// the monitor, or "" when none does.
+func (l *maintenanceWindows) match(t time.Time, monitorID int64, tags []string) string {
+	if l == nil || t.IsZero() {
+		return ""
+	}
+	for i := range l.windows {
+		if l.windows[i].applies(monitorID, tags) && l.windows[i].covers(t) {
+			return l.windows[i].Name
+		}
+	}
+	return ""
+}
+
+// matchRFC3339 is match for a timestamp as the monitor tools render it.
+func (l *maintenanceWindows) matchRFC3339(ts string, monitorID int64, tags []string) string {
+	t, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		return ""
+	}
+	return l.match(t, monitorID, tags)
+}
+
+// filterMaintenance drops the alerts that fired inside a maintenance
+// window, with the recoveries that close them, and counts the dropped
+// alerts by window. Events must be sorted by timestamp.
+func (l *maintenanceWindows) filterMaintenance(events []monitorAlertEvent) ([]monitorAlertEvent, map[string]int) {
+	counts := make(map[string]int)
+	kept := make([]monitorAlertEvent, 0, len(events))
+	// planned marks episodes opened only by alerts in a window.
+	planned := make(map[string]bool)
+	for _, e := range events {
+		episode := fmt.Sprintf("%d|%s", e.MonitorID, e.Group)
+		if e.Recovery {
+			wasPlanned := planned[episode]
+			delete(planned, episode)
+			if !wasPlanned {
+				kept = append(kept, e)
+			}
+			continue
+		}
+		if name := l.match(e.Timestamp, e.MonitorID, e.Tags); name != "" {
+			counts[name]++
+			if _, open := planned[episode]; !open {
+				planned[episode] = true
+			}
+			continue
+		}
+		// An unplanned alert makes the episode's recovery count.
+		planned[episode] = false
+		kept = append(kept, e)
+	}
+	return kept, counts
+}
+
+// countMaintenance counts the alerts that fired inside a maintenance
+// window by window, leaving the events as they are.
+func (l *maintenanceWindows) countMaintenance(events []monitorAlertEvent) map[string]int {
+	counts := make(map[string]int)
+	for _, e := range events {
+		if e.Recovery {
+			continue
+		}
+		if name := l.match(e.Timestamp, e.MonitorID, e.Tags); name != "" {
+			counts[name]++
+		}
+	}
+	return counts
+}
+
+// maintenanceNote summarizes the alerts in maintenance windows, busiest
+// window first.
+func maintenanceNote(counts map[string]int, excluded bool) string {
+	names := make([]string, 0, len(counts))
+	total := 0
+	for name, count := range counts {
+		names = append(names, name)
+		total += count
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s: %d", name, counts[name])
+	}
+	if excluded {
+		return fmt.Sprintf("%d alerts inside maintenance windows were excluded (%s).", total, strings.Join(parts, ", "))
+	}
+	return fmt.Sprintf("%d alerts fired inside maintenance windows (%s); these are planned, so pass exclude_maintenance to leave them out.", total, strings.Join(parts, ", "))
+}
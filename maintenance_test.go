@@ -0,0 +1,125 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testMaintenance = `{"windows": [
+  {"name": "nightly-backup", "start": "23:30", "end": "01:00", "timezone": "America/New_York", "scope": ["service:postgres"]},
+  {"name": "weekend-reindex", "days": ["sat", "Sunday"], "start": "02:00", "end": "04:00", "monitor_ids": [7]},
+  {"name": "db-migration", "from": "2026-03-04T02:00:00Z", "to": "2026-03-04T06:00:00Z"}
+]}`
+
+func TestLoadMaintenanceWindows(t *testing.T) {
+	list, err := loadMaintenanceWindows(writeTempFile(t, "maintenance.json", testMaintenance))
+	if err != nil {
+		t.Fatal(err)
+	}
+	postgres := []string{"service:postgres", "env:prod"}
+	cases := []struct {
+		at        string
+		monitorID int64
+		tags      []string
+		want      string
+	}{
+		// 23:45 and 00:30 in New York, either side of midnight.
+		{"2026-03-03T04:45:00Z", 1, postgres, "nightly-backup"},
+		{"2026-03-03T05:30:00Z", 1, postgres, "nightly-backup"},
+		{"2026-03-03T06:30:00Z", 1, postgres, ""},
+		{"2026-03-03T04:45:00Z", 1, []string{"service:web"}, ""},
+		// Saturday 2026-03-07.
+		{"2026-03-07T03:00:00Z", 7, nil, "weekend-reindex"},
+		{"2026-03-07T03:00:00Z", 8, nil, ""},
+		{"2026-03-06T03:00:00Z", 7, nil, ""},
+		{"2026-03-04T05:59:00Z", 8, nil, "db-migration"},
+		{"2026-03-04T06:00:00Z", 8, nil, ""},
+	}
+	for _, c := range cases {
+		at, _ := time.Parse(time.RFC3339, c.at)
+		if got := list.match(at, c.monitorID, c.tags); got != c.want {
+			t.Errorf("match(%s, %d, %v) = %q, want %q", c.at, c.monitorID, c.tags, got, c.want)
+		}
+	}
+
+	var none *maintenanceWindows
+	if none.match(time.Now(), 7, nil) != "" {
+		t.Fatal("expected a nil list to cover nothing")
+	}
+
+	for _, content := range []string{
+		`{"windows": [{"start": "02:00", "end": "03:00"}]}`,
+		`{"windows": [{"name": "a", "start": "02:00", "end": "03:00"}, {"name": "a", "start": "04:00", "end": "05:00"}]}`,
+		`{"windows": [{"name": "a"}]}`,
+		`{"windows": [{"name": "a", "start": "2am", "end": "03:00"}]}`,
+		`{"windows": [{"name": "a", "start": "02:00", "end": "02:00"}]}`,
+		`{"windows": [{"name": "a", "start": "02:00", "end": "03:00", "days": ["someday"]}]}`,
+		`{"windows": [{"name": "a", "start": "02:00", "end": "03:00", "timezone": "Mars/Olympus"}]}`,
+		`{"windows": [{"name": "a", "start": "02:00", "from": "2026-03-04T02:00:00Z"}]}`,
+		`{"windows": [{"name": "a", "from": "2026-03-04T02:00:00Z", "to": "2026-03-04T01:00:00Z"}]}`,
+	} {
+		if _, err := loadMaintenanceWindows(writeTempFile(t, "maintenance.json", content)); err == nil {
+			t.Errorf("expected %s to be rejected", content)
+		}
+	}
+}
+
+func TestFilterMaintenance(t *testing.T) {
+	list, err := loadMaintenanceWindows(writeTempFile(t, "maintenance.json", testMaintenance))
+	if err != nil {
+		t.Fatal(err)
+	}
+	migration := time.Date(2026, 3, 4, 3, 0, 0, 0, time.UTC)
+	events := []monitorAlertEvent{
+		{MonitorID: 1, Group: "host:a", Owner: "core", Timestamp: migration},
+		{MonitorID: 1, Group: "host:a", Owner: "core", Timestamp: migration.Add(4 * time.Hour), Recovery: true},
+		{MonitorID: 2, Group: "host:b", Owner: "core", Timestamp: migration.Add(time.Hour)},
+		{MonitorID: 2, Group: "host:b", Owner: "core", Timestamp: migration.Add(4 * time.Hour)},
+		{MonitorID: 2, Group: "host:b", Owner: "core", Timestamp: migration.Add(5 * time.Hour), Recovery: true},
+		{MonitorID: 1, Group: "host:a", Owner: "core", Timestamp: migration.Add(6 * time.Hour)},
+	}
+
+	if counts := list.countMaintenance(events); counts["db-migration"] != 2 {
+		t.Fatalf("expected two alerts in the migration, got %v", counts)
+	}
+	kept, counts := list.filterMaintenance(events)
+	if counts["db-migration"] != 2 || len(kept) != 3 {
+		t.Fatalf("expected the planned alerts and their recovery dropped, got %v %+v", counts, kept)
+	}
+	// Monitor 2 alerted again after the migration, so its recovery stays.
+	if kept[0].MonitorID != 2 || !kept[1].Recovery || kept[2].MonitorID != 1 {
+		t.Fatalf("unexpected events kept: %+v", kept)
+	}
+	if note := maintenanceNote(counts, true); !strings.Contains(note, "2 alerts") || !strings.Contains(note, "db-migration: 2") {
+		t.Fatalf("unexpected note %q", note)
+	}
+}
+
+func TestListMonitorsMaintenance(t *testing.T) {
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"monitors":[
+				{"id":7,"name":"Reindex lag","status":"OK","tags":["env:prod"],"last_triggered_ts":1772852400},
+				{"id":8,"name":"Checkout latency","status":"Alert","tags":["env:prod"],"last_triggered_ts":1772852400}],
+			"metadata":{"page":0,"page_count":1,"per_page":2,"total_count":2}}`))
+	})
+	list, err := loadMaintenanceWindows(writeTempFile(t, "maintenance.json", testMaintenance))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.maintenance = list
+
+	result, err := server.ListMonitors(ListMonitorsParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Monitors[0].Maintenance != "weekend-reindex" || result.Monitors[1].Maintenance != "" {
+		t.Fatalf("expected only monitor 7 in its window, got %+v", result.Monitors)
+	}
+	if len(result.Notes) != 1 || !strings.Contains(result.Notes[0], "1 monitors last triggered inside a maintenance window") {
+		t.Fatalf("unexpected notes %v", result.Notes)
+	}
+}
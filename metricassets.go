@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV2"
+)
+
+type MetricAssetsParams struct {
+	Metric string `json:"metric"`
+}
+
+// RelatedAsset is a dashboard, monitor, notebook or SLO that queries a
+// metric. Title and URL are empty when Datadog only returned the id.
+type RelatedAsset struct {
+	ID         string   `json:"id"`
+	Title      string   `json:"title,omitempty"`
+	URL        string   `json:"url,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+	Popularity *float64 `json:"popularity,omitempty"`
+}
+
+type MetricAssetsResult struct {
+	Metric     string         `json:"metric"`
+	Total      int            `json:"total"`
+	Dashboards []RelatedAsset `json:"dashboards"`
+	Monitors   []RelatedAsset `json:"monitors"`
+	Notebooks  []RelatedAsset `json:"notebooks"`
+	SLOs       []RelatedAsset `json:"slos"`
+	Notes      []string       `json:"notes,omitempty"`
+}
+
+// MetricRelatedAssets lists what would break if metric stopped being
+// emitted, using Datadog's related assets index.
+func (s *MCPServer) MetricRelatedAssets(params MetricAssetsParams) (*MetricAssetsResult, error) {
+	metric := strings.TrimSpace(params.Metric)
+	if metric == "" {
+		return nil, fmt.Errorf("metric parameter is required")
+	}
+	if strings.ContainsAny(metric, ":{}() ") {
+		return nil, fmt.Errorf("metric must be a bare metric name such as system.cpu.user, not a query: %s", metric)
+	}
+
+	api := datadogV2.NewMetricsApi(s.ddClient)
+	resp, httpResp, err := api.ListMetricAssets(s.ctx, metric)
+	if err != nil {
+		if httpResp != nil && httpResp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("metric %s not found", metric)
+		}
+		return nil, fmt.Errorf("failed to list assets for metric %s: %w", metric, err)
+	}
+
+	// Details arrive in included; relationships only carry ids.
+	details := make(map[string]RelatedAsset)
+	for _, item := range resp.Included {
+		switch {
+		case item.MetricDashboardAsset != nil:
+			a := item.MetricDashboardAsset
+			asset := RelatedAsset{ID: a.Id}
+			if attrs := a.Attributes; attrs != nil {
+				asset.Title, asset.URL, asset.Tags, asset.Popularity = attrs.GetTitle(), s.appURL(attrs.GetUrl()), attrs.Tags, attrs.Popularity
+			}
+			details["dashboards/"+a.Id] = asset
+		case item.MetricMonitorAsset != nil:
+			details["monitors/"+item.MetricMonitorAsset.Id] = s.relatedAsset(item.MetricMonitorAsset.Id, item.MetricMonitorAsset.Attributes)
+		case item.MetricNotebookAsset != nil:
+			details["notebooks/"+item.MetricNotebookAsset.Id] = s.relatedAsset(item.MetricNotebookAsset.Id, item.MetricNotebookAsset.Attributes)
+		case item.MetricSLOAsset != nil:
+			details["slos/"+item.MetricSLOAsset.Id] = s.relatedAsset(item.MetricSLOAsset.Id, item.MetricSLOAsset.Attributes)
+		}
+	}
+
+	result := &MetricAssetsResult{
+		Metric:     metric,
+		Dashboards: make([]RelatedAsset, 0),
+		Monitors:   make([]RelatedAsset, 0),
+		Notebooks:  make([]RelatedAsset, 0),
+		SLOs:       make([]RelatedAsset, 0),
+	}
+	lookup := func(kind string, id *string) RelatedAsset {
+		if asset, ok := details[kind+"/"+*id]; ok {
+			return asset
+		}
+		return RelatedAsset{ID: *id}
+	}
+	if resp.Data != nil && resp.Data.Relationships != nil {
+		rels := resp.Data.Relationships
+		if rels.Dashboards != nil {
+			for _, rel := range rels.Dashboards.Data {
+				if rel.Id != nil {
+					result.Dashboards = append(result.Dashboards, lookup("dashboards", rel.Id))
+				}
+			}
+		}
+		if rels.Monitors != nil {
+			for _, rel := range rels.Monitors.Data {
+				if rel.Id != nil {
+					result.Monitors = append(result.Monitors, lookup("monitors", rel.Id))
+				}
+			}
+		}
+		if rels.Notebooks != nil {
+			for _, rel := range rels.Notebooks.Data {
+				if rel.Id != nil {
+					result.Notebooks = append(result.Notebooks, lookup("notebooks", rel.Id))
+				}
+			}
+		}
+		if rels.Slos != nil {
+			for _, rel := range rels.Slos.Data {
+				if rel.Id != nil {
+					result.SLOs = append(result.SLOs, lookup("slos", rel.Id))
+				}
+			}
+		}
+	}
+
+	result.Total = len(result.Dashboards) + len(result.Monitors) + len(result.Notebooks) + len(result.SLOs)
+	if result.Total == 0 {
+		result.Notes = append(result.Notes, "No dashboards, monitors, notebooks or SLOs use this metric. Scripts and integrations that query it through the API aren't tracked, so check those before removing it.")
+	}
+	return result, nil
+}
+
+func (s *MCPServer) relatedAsset(id string, attrs *datadogV2.MetricAssetAttributes) RelatedAsset {
+	asset := RelatedAsset{ID: id}
+	if attrs != nil {
+		asset.Title, asset.URL, asset.Tags = attrs.GetTitle(), s.appURL(attrs.GetUrl()), attrs.Tags
+	}
+	return asset
+}
+
+// appURL turns a path in the Datadog web app into an absolute URL for the
+// configured site. Absolute URLs are returned unchanged.
+func (s *MCPServer) appURL(path string) string {
+	if path == "" || strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	site := s.site
+	if site == "" {
+		site = "datadoghq.com"
+	}
+	// The main sites serve the app from app.<site>; regional sites such as
+	// us3.datadoghq.com serve it from the site itself.
+	host := site
+	switch site {
+	case "datadoghq.com", "datadoghq.eu", "ddog-gov.com":
+		host = "app." + site
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return "https://" + host + path
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMetricRelatedAssets(t *testing.T) {
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/metrics/checkout.latency/assets" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"data": {"id": "checkout.latency", "type": "metrics", "relationships": {
+				"dashboards": {"data": [{"id": "abc-123", "type": "dashboards"}]},
+				"monitors": {"data": [{"id": "42", "type": "monitors"}, {"id": "43", "type": "monitors"}]},
+				"slos": {"data": [{"id": "slo1", "type": "slos"}]}
+			}},
+			"included": [
+				{"id": "abc-123", "type": "dashboards", "attributes": {"title": "Checkout", "url": "/dashboard/abc-123", "popularity": 4}},
+				{"id": "42", "type": "monitors", "attributes": {"title": "Checkout latency", "url": "/monitors/42", "tags": ["team:payments"]}},
+				{"id": "slo1", "type": "slos", "attributes": {"title": "Checkout p99", "url": "https://app.datadoghq.com/slo?slo_id=slo1"}}
+			]}`))
+	})
+
+	result, err := server.MetricRelatedAssets(MetricAssetsParams{Metric: "checkout.latency"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 4 || len(result.Dashboards) != 1 || len(result.Monitors) != 2 || len(result.SLOs) != 1 || len(result.Notebooks) != 0 {
+		t.Fatalf("unexpected assets: %+v", result)
+	}
+	if d := result.Dashboards[0]; d.Title != "Checkout" || d.URL != "https://app.datadoghq.com/dashboard/abc-123" || d.Popularity == nil || *d.Popularity != 4 {
+		t.Errorf("unexpected dashboard: %+v", d)
+	}
+	if m := result.Monitors[0]; m.Title != "Checkout latency" || len(m.Tags) != 1 {
+		t.Errorf("unexpected monitor: %+v", m)
+	}
+	// Monitor 43 had no included details.
+	if m := result.Monitors[1]; m.ID != "43" || m.Title != "" {
+		t.Errorf("expected a bare monitor id, got %+v", m)
+	}
+	if s := result.SLOs[0]; s.URL != "https://app.datadoghq.com/slo?slo_id=slo1" {
+		t.Errorf("expected the absolute SLO URL unchanged, got %q", s.URL)
+	}
+}
+
+func TestMetricRelatedAssetsRejectsQueries(t *testing.T) {
+	server := &MCPServer{}
+	if _, err := server.MetricRelatedAssets(MetricAssetsParams{Metric: "avg:system.cpu.user{*}"}); err == nil || !strings.Contains(err.Error(), "bare metric name") {
+		t.Fatalf("expected a query to be rejected, got %v", err)
+	}
+}
+
+func TestAppURL(t *testing.T) {
+	tests := []struct{ site, want string }{
+		{"", "https://app.datadoghq.com/dashboard/x"},
+		{"datadoghq.eu", "https://app.datadoghq.eu/dashboard/x"},
+		{"us3.datadoghq.com", "https://us3.datadoghq.com/dashboard/x"},
+	}
+	for _, tt := range tests {
+		if got := (&MCPServer{site: tt.site}).appURL("/dashboard/x"); got != tt.want {
+			t.Errorf("appURL on %q = %q, want %q", tt.site, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,399 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+)
+
+type AnomalyParams struct {
+	Metric      string `json:"metric"`
+	Window      string `json:"window,omitempty"`
+	Sensitivity string `json:"sensitivity,omitempty"`
+	Algorithm   string `json:"algorithm,omitempty"`
+	// Services runs the analysis once per service.
+	Services []string `json:"services,omitempty"`
+	// MaxPoints bounds the points returned per series.
+	MaxPoints int `json:"max_points,omitempty"`
+}
+
+type ForecastParams struct {
+	Metric      string   `json:"metric"`
+	Window      string   `json:"window,omitempty"`
+	Horizon     string   `json:"horizon,omitempty"`
+	Sensitivity string   `json:"sensitivity,omitempty"`
+	Algorithm   string   `json:"algorithm,omitempty"`
+	Threshold   *float64 `json:"threshold,omitempty"`
+	Services    []string `json:"services,omitempty"`
+	MaxPoints   int      `json:"max_points,omitempty"`
+}
+
+type BandPoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     *float64  `json:"value"`
+	Lower     *float64  `json:"lower,omitempty"`
+	Upper     *float64  `json:"upper,omitempty"`
+}
+
+type BandSeries struct {
+	Scope  string      `json:"scope"`
+	Points []BandPoint `json:"points"`
+}
+
+type MetricInsightResult struct {
+	Query    string       `json:"query"`
+	From     string       `json:"from"`
+	To       string       `json:"to"`
+	Findings []string     `json:"findings"`
+	Series   []BandSeries `json:"series"`
+	// Resolution is set when the series were downsampled or aligned.
+	Resolution *SeriesResolution `json:"resolution,omitempty"`
+	Freshness  *Freshness        `json:"freshness,omitempty"`
+}
+
+// sensitivityBounds maps the plain-language sensitivity input onto the
+// deviation count passed to anomalies()/forecast(). Higher sensitivity
+// means tighter bands.
+var sensitivityBounds = map[string]int{
+	"low":    3,
+	"medium": 2,
+	"high":   1,
+}
+
+func boundsForSensitivity(sensitivity string) (int, error) {
+	if sensitivity == "" {
+		return sensitivityBounds["medium"], nil
+	}
+	bounds, ok := sensitivityBounds[strings.ToLower(sensitivity)]
+	if !ok {
+		return 0, fmt.Errorf("invalid sensitivity: %s (use low, medium or high)", sensitivity)
+	}
+	return bounds, nil
+}
+
+func (s *MCPServer) DetectAnomalies(params AnomalyParams) (*MetricInsightResult, error) {
+	if params.Metric == "" {
+		return nil, fmt.Errorf("metric parameter is required")
+	}
+
+	window, err := parseDurationParam(params.Window, 4*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds, err := boundsForSensitivity(params.Sensitivity)
+	if err != nil {
+		return nil, err
+	}
+
+	algorithm := params.Algorithm
+	switch algorithm {
+	case "":
+		algorithm = "basic"
+	case "basic", "agile", "robust":
+	default:
+		return nil, fmt.Errorf("invalid algorithm: %s (use basic, agile or robust)", params.Algorithm)
+	}
+
+	to := time.Now()
+	from := to.Add(-window)
+	query := fmt.Sprintf("anomalies(%s, '%s', %d)", params.Metric, algorithm, bounds)
+
+	resp, err := s.queryMetrics(from, to, query)
+	if err != nil {
+		return nil, err
+	}
+
+	series := buildBandSeries(resp.Series)
+	result := &MetricInsightResult{
+		Query:     query,
+		From:      from.Format(time.RFC3339),
+		To:        to.Format(time.RFC3339),
+		Findings:  describeAnomalies(series),
+		Freshness: newFreshness("metrics", to, to, latestPoint(series, to)),
+	}
+	result.Series, result.Resolution = s.resampleSeries(series, params.MaxPoints)
+	return result, nil
+}
+
+func (s *MCPServer) ForecastMetric(params ForecastParams) (*MetricInsightResult, error) {
+	if params.Metric == "" {
+		return nil, fmt.Errorf("metric parameter is required")
+	}
+
+	window, err := parseDurationParam(params.Window, 7*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	horizon, err := parseDurationParam(params.Horizon, 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds, err := boundsForSensitivity(params.Sensitivity)
+	if err != nil {
+		return nil, err
+	}
+
+	algorithm := params.Algorithm
+	switch algorithm {
+	case "":
+		algorithm = "linear"
+	case "linear", "seasonal":
+	default:
+		return nil, fmt.Errorf("invalid algorithm: %s (use linear or seasonal)", params.Algorithm)
+	}
+
+	now := time.Now()
+	from := now.Add(-window)
+	to := now.Add(horizon)
+	query := fmt.Sprintf("forecast(%s, '%s', %d)", params.Metric, algorithm, bounds)
+
+	resp, err := s.queryMetrics(from, to, query)
+	if err != nil {
+		return nil, err
+	}
+
+	series := buildBandSeries(resp.Series)
+	result := &MetricInsightResult{
+		Query:    query,
+		From:     from.Format(time.RFC3339),
+		To:       to.Format(time.RFC3339),
+		Findings: describeForecast(series, now, params.Threshold),
+		// Observed data ends now; the rest of the range is projected.
+		Freshness: newFreshness("metrics", now, now, latestPoint(series, now)),
+	}
+	result.Series, result.Resolution = s.resampleSeries(series, params.MaxPoints)
+	return result, nil
+}
+
+func (s *MCPServer) queryMetrics(from, to time.Time, query string) (*datadogV1.MetricsQueryResponse, error) {
+	api := datadogV1.NewMetricsApi(s.ddClient)
+	resp, _, err := api.QueryMetrics(s.ctx, from.Unix(), to.Unix(), query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query metrics: %w", err)
+	}
+	if resp.Error != nil && *resp.Error != "" {
+		return nil, fmt.Errorf("metrics query error: %s", *resp.Error)
+	}
+	return &resp, nil
+}
+
+// buildBandSeries folds the series returned for an anomalies()/forecast()
+// query into one entry per scope. The API returns the bands as sibling
+// series; they are recognised by an "upper"/"lower" marker in their display
+// name, or, when unlabeled, by ranking a scope's three series by mean.
+func buildBandSeries(raw []datadogV1.MetricsQueryMetadata) []BandSeries {
+	type scopeGroup struct {
+		value, lower, upper *datadogV1.MetricsQueryMetadata
+		unlabeled           []*datadogV1.MetricsQueryMetadata
+	}
+
+	groups := make(map[string]*scopeGroup)
+	order := make([]string, 0)
+	for i := range raw {
+		meta := &raw[i]
+		scope := meta.GetScope()
+		g, ok := groups[scope]
+		if !ok {
+			g = &scopeGroup{}
+			groups[scope] = g
+			order = append(order, scope)
+		}
+
+		label := strings.ToLower(meta.GetDisplayName() + " " + meta.GetExpression())
+		switch {
+		case strings.Contains(label, "upper"):
+			g.upper = meta
+		case strings.Contains(label, "lower"):
+			g.lower = meta
+		default:
+			g.unlabeled = append(g.unlabeled, meta)
+		}
+	}
+
+	result := make([]BandSeries, 0, len(order))
+	for _, scope := range order {
+		g := groups[scope]
+		if g.lower == nil && g.upper == nil && len(g.unlabeled) == 3 {
+			sort.SliceStable(g.unlabeled, func(i, j int) bool {
+				return seriesMean(g.unlabeled[i]) < seriesMean(g.unlabeled[j])
+			})
+			g.lower, g.value, g.upper = g.unlabeled[0], g.unlabeled[1], g.unlabeled[2]
+		} else if len(g.unlabeled) > 0 {
+			g.value = g.unlabeled[0]
+		}
+		if g.value == nil {
+			continue
+		}
+
+		lower := pointIndex(g.lower)
+		upper := pointIndex(g.upper)
+		points := make([]BandPoint, 0, len(g.value.Pointlist))
+		for _, p := range g.value.Pointlist {
+			if len(p) < 2 || p[0] == nil {
+				continue
+			}
+			ts := int64(*p[0])
+			points = append(points, BandPoint{
+				Timestamp: time.UnixMilli(ts).UTC(),
+				Value:     p[1],
+				Lower:     lower[ts],
+				Upper:     upper[ts],
+			})
+		}
+		result = append(result, BandSeries{Scope: scope, Points: points})
+	}
+	return result
+}
+
+func pointIndex(meta *datadogV1.MetricsQueryMetadata) map[int64]*float64 {
+	index := make(map[int64]*float64)
+	if meta == nil {
+		return index
+	}
+	for _, p := range meta.Pointlist {
+		if len(p) < 2 || p[0] == nil {
+			continue
+		}
+		index[int64(*p[0])] = p[1]
+	}
+	return index
+}
+
+func seriesMean(meta *datadogV1.MetricsQueryMetadata) float64 {
+	var sum float64
+	var n int
+	for _, p := range meta.Pointlist {
+		if len(p) < 2 || p[1] == nil {
+			continue
+		}
+		sum += *p[1]
+		n++
+	}
+	if n == 0 {
+		return math.Inf(1)
+	}
+	return sum / float64(n)
+}
+
+func describeAnomalies(series []BandSeries) []string {
+	if len(series) == 0 {
+		return []string{"No data returned for this metric in the requested window."}
+	}
+
+	findings := make([]string, 0)
+	for _, s := range series {
+		var above, below, withBands int
+		var lastAnomaly *BandPoint
+		for i := range s.Points {
+			p := &s.Points[i]
+			if p.Value == nil || p.Lower == nil || p.Upper == nil {
+				continue
+			}
+			withBands++
+			switch {
+			case *p.Value > *p.Upper:
+				above++
+				lastAnomaly = p
+			case *p.Value < *p.Lower:
+				below++
+				lastAnomaly = p
+			}
+		}
+
+		switch {
+		case withBands == 0:
+			findings = append(findings, fmt.Sprintf("%s: no expected-range bands were returned, so anomalies could not be evaluated.", s.Scope))
+		case above+below == 0:
+			findings = append(findings, fmt.Sprintf("%s: all %d points stayed within the expected range.", s.Scope, withBands))
+		default:
+			findings = append(findings, fmt.Sprintf(
+				"%s: %d of %d points were anomalous (%d above, %d below the expected range); most recent at %s with value %.4g.",
+				s.Scope, above+below, withBands, above, below,
+				lastAnomaly.Timestamp.Format(time.RFC3339), *lastAnomaly.Value,
+			))
+		}
+	}
+	return findings
+}
+
+func describeForecast(series []BandSeries, now time.Time, threshold *float64) []string {
+	if len(series) == 0 {
+		return []string{"No data returned for this metric in the requested window."}
+	}
+
+	findings := make([]string, 0)
+	for _, s := range series {
+		var last *BandPoint
+		var crossing *BandPoint
+		for i := range s.Points {
+			p := &s.Points[i]
+			if p.Value == nil || !p.Timestamp.After(now) {
+				continue
+			}
+			last = p
+			if threshold != nil && crossing == nil && *p.Value >= *threshold {
+				crossing = p
+			}
+		}
+
+		if last == nil {
+			findings = append(findings, fmt.Sprintf("%s: no forecast points were returned beyond the current time.", s.Scope))
+			continue
+		}
+
+		finding := fmt.Sprintf("%s: forecast to reach %.4g by %s", s.Scope, *last.Value, last.Timestamp.Format(time.RFC3339))
+		if last.Lower != nil && last.Upper != nil {
+			finding += fmt.Sprintf(" (likely range %.4g to %.4g)", *last.Lower, *last.Upper)
+		}
+		finding += "."
+		if threshold != nil {
+			if crossing != nil {
+				finding += fmt.Sprintf(" Expected to cross the threshold of %.4g at %s.", *threshold, crossing.Timestamp.Format(time.RFC3339))
+			} else {
+				finding += fmt.Sprintf(" Not expected to cross the threshold of %.4g within the horizon.", *threshold)
+			}
+		}
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+// parseDurationParam accepts Go durations plus day ("d") and week ("w")
+// suffixes, which are the natural units for capacity planning windows.
+func parseDurationParam(value string, defaultDuration time.Duration) (time.Duration, error) {
+	if value == "" {
+		return defaultDuration, nil
+	}
+
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(value, suffix); ok {
+			if count, err := strconv.ParseFloat(n, 64); err == nil && count > 0 {
+				return time.Duration(count * float64(unit)), nil
+			}
+			return 0, fmt.Errorf("invalid duration: %s (use e.g. '4h', '7d' or '2w')", value)
+		}
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid duration: %s (use e.g. '4h', '7d' or '2w')", value)
+	}
+	return d, nil
+}
+
+func formatMetricInsightResult(result *MetricInsightResult) string {
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Sprintf(`{"error": "failed to format result: %v"}`, err)
+	}
+	return string(data)
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+)
+
+func TestParseDurationParam(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    time.Duration
+		expectError bool
+	}{
+		{
+			name:     "empty string uses default",
+			input:    "",
+			expected: time.Hour,
+		},
+		{
+			name:     "go duration",
+			input:    "90m",
+			expected: 90 * time.Minute,
+		},
+		{
+			name:     "days",
+			input:    "7d",
+			expected: 7 * 24 * time.Hour,
+		},
+		{
+			name:     "weeks",
+			input:    "2w",
+			expected: 14 * 24 * time.Hour,
+		},
+		{
+			name:        "invalid days",
+			input:       "xd",
+			expectError: true,
+		},
+		{
+			name:        "negative duration",
+			input:       "-1h",
+			expectError: true,
+		},
+		{
+			name:        "invalid format",
+			input:       "soon",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parseDurationParam(tt.input, time.Hour)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestBoundsForSensitivity(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    int
+		expectError bool
+	}{
+		{input: "", expected: 2},
+		{input: "low", expected: 3},
+		{input: "HIGH", expected: 1},
+		{input: "extreme", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			bounds, err := boundsForSensitivity(tt.input)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if bounds != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, bounds)
+			}
+		})
+	}
+}
+
+func metricSeries(scope, displayName string, values ...float64) datadogV1.MetricsQueryMetadata {
+	points := make([][]*float64, 0, len(values))
+	for i, v := range values {
+		ts := float64(1700000000000 + int64(i)*60000)
+		value := v
+		points = append(points, []*float64{&ts, &value})
+	}
+	return datadogV1.MetricsQueryMetadata{
+		Scope:       &scope,
+		DisplayName: &displayName,
+		Pointlist:   points,
+	}
+}
+
+func TestBuildBandSeriesLabeled(t *testing.T) {
+	raw := []datadogV1.MetricsQueryMetadata{
+		metricSeries("host:a", "system.cpu.user", 5, 50),
+		metricSeries("host:a", "system.cpu.user (lower)", 1, 1),
+		metricSeries("host:a", "system.cpu.user (upper)", 10, 10),
+	}
+
+	series := buildBandSeries(raw)
+	if len(series) != 1 {
+		t.Fatalf("expected 1 series, got %d", len(series))
+	}
+
+	points := series[0].Points
+	if len(points) != 2 {
+		t.Fatalf("expected 2 points, got %d", len(points))
+	}
+	if *points[1].Value != 50 || *points[1].Lower != 1 || *points[1].Upper != 10 {
+		t.Errorf("unexpected band point: value=%v lower=%v upper=%v", *points[1].Value, *points[1].Lower, *points[1].Upper)
+	}
+}
+
+func TestBuildBandSeriesUnlabeledTriplet(t *testing.T) {
+	raw := []datadogV1.MetricsQueryMetadata{
+		metricSeries("*", "q", 20, 20),
+		metricSeries("*", "q", 5, 6),
+		metricSeries("*", "q", 0, 0),
+	}
+
+	series := buildBandSeries(raw)
+	if len(series) != 1 {
+		t.Fatalf("expected 1 series, got %d", len(series))
+	}
+
+	p := series[0].Points[0]
+	if *p.Value != 5 || *p.Lower != 0 || *p.Upper != 20 {
+		t.Errorf("unexpected band point: value=%v lower=%v upper=%v", *p.Value, *p.Lower, *p.Upper)
+	}
+}
+
+func TestDescribeAnomalies(t *testing.T) {
+	series := buildBandSeries([]datadogV1.MetricsQueryMetadata{
+		metricSeries("host:a", "cpu", 5, 50, 5),
+		metricSeries("host:a", "cpu lower", 1, 1, 1),
+		metricSeries("host:a", "cpu upper", 10, 10, 10),
+	})
+
+	findings := describeAnomalies(series)
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding, got %d", len(findings))
+	}
+	if !strings.Contains(findings[0], "1 of 3 points were anomalous") {
+		t.Errorf("unexpected finding: %s", findings[0])
+	}
+
+	if findings := describeAnomalies(nil); !strings.Contains(findings[0], "No data") {
+		t.Errorf("expected no-data finding, got %s", findings[0])
+	}
+}
+
+func TestDescribeForecastThreshold(t *testing.T) {
+	series := buildBandSeries([]datadogV1.MetricsQueryMetadata{
+		metricSeries("*", "disk", 70, 80, 90),
+	})
+	now := series[0].Points[0].Timestamp
+
+	threshold := 85.0
+	findings := describeForecast(series, now, &threshold)
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding, got %d", len(findings))
+	}
+	crossing := series[0].Points[2].Timestamp.Format(time.RFC3339)
+	if !strings.Contains(findings[0], "cross the threshold of 85 at "+crossing) {
+		t.Errorf("unexpected finding: %s", findings[0])
+	}
+
+	high := 1000.0
+	findings = describeForecast(series, now, &high)
+	if !strings.Contains(findings[0], "Not expected to cross") {
+		t.Errorf("unexpected finding: %s", findings[0])
+	}
+}
+
+func TestDetectAnomaliesRequiresMetric(t *testing.T) {
+	server := &MCPServer{}
+	if _, err := server.DetectAnomalies(AnomalyParams{}); err == nil {
+		t.Error("expected error when metric is missing")
+	}
+	if _, err := server.ForecastMetric(ForecastParams{}); err == nil {
+		t.Error("expected error when metric is missing")
+	}
+}
+
+func TestMetricInsightAlgorithms(t *testing.T) {
+	server := &MCPServer{}
+	if _, err := server.DetectAnomalies(AnomalyParams{Metric: "avg:latency{*}", Algorithm: "seasonal"}); err == nil || !strings.Contains(err.Error(), "use basic, agile or robust") {
+		t.Errorf("expected an unknown anomaly algorithm to be rejected, got %v", err)
+	}
+	if _, err := server.ForecastMetric(ForecastParams{Metric: "avg:disk.used{*}", Algorithm: "robust"}); err == nil || !strings.Contains(err.Error(), "use linear or seasonal") {
+		t.Errorf("expected an unknown forecast algorithm to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,434 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+)
+
+// stateSeverity orders monitor states from most to least urgent.
+var stateSeverity = map[string]int{"Alert": 0, "Warn": 1, "No Data": 2, "Unknown": 3, "Skipped": 4, "Ignored": 5, "OK": 6}
+
+const (
+	defaultMonitorsPerPage = 30
+	maxMonitorsPerPage     = 100
+)
+
+// monitorStates maps the states accepted by list_monitors to the values
+// of the monitor search status: facet.
+var monitorStates = map[string]string{
+	"alert":   "alert",
+	"warn":    "warn",
+	"no data": "\"no data\"",
+	"ok":      "ok",
+	"ignored": "ignored",
+	"skipped": "skipped",
+	"unknown": "unknown",
+}
+
+type ListMonitorsParams struct {
+	Name    string   `json:"name,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+	States  []string `json:"states,omitempty"`
+	Page    int64    `json:"page,omitempty"`
+	PerPage int64    `json:"per_page,omitempty"`
+}
+
+type MonitorSummary struct {
+	ID            int64    `json:"id"`
+	Name          string   `json:"name"`
+	Status        string   `json:"status"`
+	Type          string   `json:"type,omitempty"`
+	Query         string   `json:"query,omitempty"`
+	Tags          []string `json:"tags,omitempty"`
+	LastTriggered string   `json:"last_triggered,omitempty"`
+	// Maintenance names the maintenance window the monitor last
+	// triggered in, if any.
+	Maintenance string `json:"maintenance,omitempty"`
+	URL         string `json:"url"`
+}
+
+type ListMonitorsResult struct {
+	Monitors []MonitorSummary `json:"monitors"`
+	// Search is the monitor search query the filters were turned into.
+	Search    string           `json:"search"`
+	Total     int64            `json:"total"`
+	Page      int64            `json:"page"`
+	PageCount int64            `json:"page_count"`
+	PerPage   int64            `json:"per_page"`
+	ByStatus  map[string]int64 `json:"by_status,omitempty"`
+	// Backend is "search", or "list" where the search API isn't available.
+	Backend string   `json:"backend"`
+	Notes   []string `json:"notes,omitempty"`
+}
+
+// ListMonitors searches monitors by name, tags and state, a page at a
+// time. ByStatus counts every matching monitor, not just this page.
+func (s *MCPServer) ListMonitors(params ListMonitorsParams) (*ListMonitorsResult, error) {
+	search, err := monitorSearchQuery(params)
+	if err != nil {
+		return nil, err
+	}
+	perPage := params.PerPage
+	if perPage <= 0 {
+		perPage = defaultMonitorsPerPage
+	}
+	perPage = clampArgument(s, "per_page", perPage, maxMonitorsPerPage)
+	if params.Page < 0 {
+		return nil, fmt.Errorf("page must not be negative")
+	}
+
+	var result *ListMonitorsResult
+	org := s.apiOrg()
+	if !s.backends.useFallback("monitors", org) {
+		var status int
+		result, status, err = s.searchMonitors(search, params.Page, perPage)
+		if err != nil && !s.backends.fallBack("monitors", org, status) {
+			return nil, err
+		}
+	}
+	if result == nil {
+		if result, err = s.listMonitorsPage(params, perPage); err != nil {
+			return nil, err
+		}
+	}
+	result.Search = search
+
+	// A misspelled name matches nothing, which reads as "no such monitor".
+	if result.Total == 0 && params.Name != "" {
+		resolution, err := s.resolveMonitor(params.Name)
+		if err == nil && resolution.Corrected {
+			asked := params.Name
+			params.Name = resolution.Entity.Name
+			corrected, err := s.ListMonitors(params)
+			if err != nil {
+				return nil, err
+			}
+			corrected.Notes = append([]string{resolution.note("monitor", asked)}, corrected.Notes...)
+			return corrected, nil
+		}
+		if err == nil && len(resolution.Suggestions) > 0 {
+			result.Notes = append(result.Notes, resolution.note("monitor", params.Name))
+		}
+	}
+	planned := 0
+	for _, m := range result.Monitors {
+		if m.Maintenance != "" {
+			planned++
+		}
+	}
+	if planned > 0 {
+		result.Notes = append(result.Notes, fmt.Sprintf("%d monitors last triggered inside a maintenance window (see maintenance); those alerts were likely planned.", planned))
+	}
+	if result.PageCount > params.Page+1 {
+		result.Notes = append(result.Notes, fmt.Sprintf("This is page %d of %d (pages start at 0); pass page=%d for more.", params.Page, result.PageCount, params.Page+1))
+	}
+	return result, nil
+}
+
+func (s *MCPServer) searchMonitors(search string, page, perPage int64) (*ListMonitorsResult, int, error) {
+	api := datadogV1.NewMonitorsApi(s.ddClient)
+	opts := datadogV1.NewSearchMonitorsOptionalParameters().WithPage(page).WithPerPage(perPage).WithSort("status,asc")
+	if search != "" {
+		opts = opts.WithQuery(search)
+	}
+	resp, httpResp, err := api.SearchMonitors(s.ctx, *opts)
+	if err != nil {
+		return nil, httpStatus(httpResp), fmt.Errorf("failed to search monitors: %w", err)
+	}
+
+	result := &ListMonitorsResult{
+		Monitors: make([]MonitorSummary, 0, len(resp.Monitors)),
+		Page:     page,
+		PerPage:  perPage,
+		Backend:  "search",
+	}
+	for _, m := range resp.Monitors {
+		summary := MonitorSummary{
+			ID:     m.GetId(),
+			Name:   m.GetName(),
+			Status: string(m.GetStatus()),
+			Type:   string(m.GetType()),
+			Query:  m.GetQuery(),
+			Tags:   m.Tags,
+			URL:    s.appURL(fmt.Sprintf("/monitors/%d", m.GetId())),
+		}
+		if ts := m.GetLastTriggeredTs(); ts > 0 {
+			summary.LastTriggered = time.Unix(ts, 0).UTC().Format(time.RFC3339)
+			summary.Maintenance = s.maintenance.match(time.Unix(ts, 0), summary.ID, summary.Tags)
+		}
+		result.Monitors = append(result.Monitors, summary)
+	}
+	if meta := resp.Metadata; meta != nil {
+		result.Total, result.PageCount = meta.GetTotalCount(), meta.GetPageCount()
+	}
+	if resp.Counts != nil && len(resp.Counts.Status) > 0 {
+		result.ByStatus = make(map[string]int64)
+		for _, c := range resp.Counts.Status {
+			result.ByStatus[fmt.Sprint(c.Name)] += c.GetCount()
+		}
+	}
+	return result, 0, nil
+}
+
+// listMonitorsPage serves list_monitors from the plain monitor list. It
+// filters by name and monitor tags but not by state, and reports no
+// totals, so states are applied to the fetched page only.
+func (s *MCPServer) listMonitorsPage(params ListMonitorsParams, perPage int64) (*ListMonitorsResult, error) {
+	opts := datadogV1.NewListMonitorsOptionalParameters().WithPage(params.Page).WithPageSize(int32(perPage))
+	if name := strings.TrimSpace(params.Name); name != "" {
+		opts = opts.WithName(name)
+	}
+	if len(params.Tags) > 0 {
+		opts = opts.WithMonitorTags(strings.Join(params.Tags, ","))
+	}
+	api := datadogV1.NewMonitorsApi(s.ddClient)
+	monitors, _, err := api.ListMonitors(s.ctx, *opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list monitors: %w", err)
+	}
+
+	wanted := make(map[string]bool)
+	for _, state := range params.States {
+		wanted[strings.ToLower(strings.TrimSpace(state))] = true
+	}
+	result := &ListMonitorsResult{
+		Monitors: make([]MonitorSummary, 0, len(monitors)),
+		Page:     params.Page,
+		PerPage:  perPage,
+		Backend:  "list",
+		ByStatus: make(map[string]int64),
+	}
+	for _, m := range monitors {
+		status := string(m.GetOverallState())
+		if len(wanted) > 0 && !wanted[strings.ToLower(status)] {
+			continue
+		}
+		result.Monitors = append(result.Monitors, MonitorSummary{
+			ID:     m.GetId(),
+			Name:   m.GetName(),
+			Status: status,
+			Type:   string(m.GetType()),
+			Query:  m.GetQuery(),
+			Tags:   m.Tags,
+			URL:    s.appURL(fmt.Sprintf("/monitors/%d", m.GetId())),
+		})
+		result.ByStatus[status]++
+	}
+	sort.SliceStable(result.Monitors, func(i, j int) bool {
+		return stateSeverity[result.Monitors[i].Status] < stateSeverity[result.Monitors[j].Status]
+	})
+	result.Total = int64(len(result.Monitors))
+
+	result.Notes = append(result.Notes, "The monitor search API isn't available, so the monitor list was used: total and by_status cover this page only.")
+	if len(params.States) > 0 {
+		result.Notes = append(result.Notes, "The monitor list can't filter by state; states were applied to this page.")
+	}
+	if int64(len(monitors)) == perPage {
+		result.Notes = append(result.Notes, fmt.Sprintf("More monitors may match; pass page=%d for the next page.", params.Page+1))
+	}
+	return result, nil
+}
+
+// monitorSearchQuery turns the filters into monitor search syntax, e.g.
+// `"checkout" tag:"env:prod" status:(alert OR warn)`.
+func monitorSearchQuery(params ListMonitorsParams) (string, error) {
+	var terms []string
+	if name := strings.TrimSpace(params.Name); name != "" {
+		terms = append(terms, strconv.Quote(name))
+	}
+	for _, tag := range params.Tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			terms = append(terms, "tag:"+strconv.Quote(tag))
+		}
+	}
+	var states []string
+	for _, state := range params.States {
+		value, ok := monitorStates[strings.ToLower(strings.TrimSpace(state))]
+		if !ok {
+			return "", fmt.Errorf("invalid state: %s (use Alert, Warn, No Data, OK, Ignored, Skipped or Unknown)", state)
+		}
+		states = append(states, value)
+	}
+	switch len(states) {
+	case 0:
+	case 1:
+		terms = append(terms, "status:"+states[0])
+	default:
+		terms = append(terms, "status:("+strings.Join(states, " OR ")+")")
+	}
+	return strings.Join(terms, " "), nil
+}
+
+type GetMonitorParams struct {
+	MonitorID   int64    `json:"monitor_id"`
+	GroupStates []string `json:"group_states,omitempty"`
+}
+
+// MonitorGroupState is the state of one group of a multi-alert monitor,
+// such as a single host or service.
+type MonitorGroupState struct {
+	Group         string `json:"group"`
+	Status        string `json:"status"`
+	LastTriggered string `json:"last_triggered,omitempty"`
+	LastResolved  string `json:"last_resolved,omitempty"`
+	LastNotified  string `json:"last_notified,omitempty"`
+	LastNoData    string `json:"last_no_data,omitempty"`
+	// Maintenance names the maintenance window the group last triggered
+	// in, if any.
+	Maintenance string `json:"maintenance,omitempty"`
+}
+
+type MonitorDowntime struct {
+	ID    int64    `json:"id"`
+	Scope []string `json:"scope,omitempty"`
+	Start string   `json:"start,omitempty"`
+	End   string   `json:"end,omitempty"`
+}
+
+// MonitorDetail is a monitor's full definition with the state of each of
+// its groups, most urgent first.
+type MonitorDetail struct {
+	ID           int64                     `json:"id"`
+	Name         string                    `json:"name"`
+	Type         string                    `json:"type"`
+	Query        string                    `json:"query"`
+	Message      string                    `json:"message,omitempty"`
+	Tags         []string                  `json:"tags,omitempty"`
+	Priority     *int64                    `json:"priority,omitempty"`
+	OverallState string                    `json:"overall_state"`
+	Multi        bool                      `json:"multi"`
+	Creator      string                    `json:"creator,omitempty"`
+	Created      string                    `json:"created,omitempty"`
+	Modified     string                    `json:"modified,omitempty"`
+	URL          string                    `json:"url"`
+	Options      *datadogV1.MonitorOptions `json:"options,omitempty"`
+	GroupCounts  map[string]int            `json:"group_counts,omitempty"`
+	Groups       []MonitorGroupState       `json:"groups"`
+	Downtimes    []MonitorDowntime         `json:"downtimes,omitempty"`
+}
+
+// GetMonitor returns a monitor's definition and per-group states, so the
+// caller can see which hosts or services of a multi-alert monitor are
+// actually alerting.
+func (s *MCPServer) GetMonitor(params GetMonitorParams) (*MonitorDetail, error) {
+	if params.MonitorID <= 0 {
+		return nil, fmt.Errorf("monitor_id parameter is required")
+	}
+	groupStates := "all"
+	if len(params.GroupStates) > 0 {
+		var states []string
+		for _, state := range params.GroupStates {
+			state = strings.ToLower(strings.TrimSpace(state))
+			switch state {
+			case "all", "alert", "warn", "no data":
+				states = append(states, state)
+			default:
+				return nil, fmt.Errorf("invalid group state: %s (use all, alert, warn or no data)", state)
+			}
+		}
+		groupStates = strings.Join(states, ",")
+	}
+
+	api := datadogV1.NewMonitorsApi(s.ddClient)
+	opts := datadogV1.NewGetMonitorOptionalParameters().WithGroupStates(groupStates).WithWithDowntimes(true)
+	monitor, httpResp, err := api.GetMonitor(s.ctx, params.MonitorID, *opts)
+	if err != nil {
+		if httpResp != nil && httpResp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("monitor %d not found", params.MonitorID)
+		}
+		return nil, fmt.Errorf("failed to get monitor %d: %w", params.MonitorID, err)
+	}
+	if _, _, err := s.snapshotResource("monitor", strconv.FormatInt(params.MonitorID, 10), monitor, formatOptionalTime(monitor.Modified)); err != nil {
+		log.Printf("Snapshotting monitor %d failed: %v", params.MonitorID, err)
+	}
+	return s.monitorDetail(monitor), nil
+}
+
+func (s *MCPServer) monitorDetail(m datadogV1.Monitor) *MonitorDetail {
+	detail := &MonitorDetail{
+		ID:           m.GetId(),
+		Name:         m.GetName(),
+		Type:         string(m.GetType()),
+		Query:        m.GetQuery(),
+		Message:      m.GetMessage(),
+		Tags:         m.Tags,
+		OverallState: string(m.GetOverallState()),
+		Multi:        m.GetMulti(),
+		Created:      formatOptionalTime(m.Created),
+		Modified:     formatOptionalTime(m.Modified),
+		URL:          s.appURL(fmt.Sprintf("/monitors/%d", m.GetId())),
+		Options:      m.Options,
+		Groups:       make([]MonitorGroupState, 0),
+	}
+	if priority, ok := m.GetPriorityOk(); ok && priority != nil {
+		detail.Priority = priority
+	}
+	if c := m.Creator; c != nil {
+		detail.Creator = c.GetEmail()
+		if detail.Creator == "" {
+			detail.Creator = c.GetHandle()
+		}
+	}
+
+	if m.State != nil && len(m.State.Groups) > 0 {
+		detail.GroupCounts = make(map[string]int)
+		for key, g := range m.State.Groups {
+			name := g.GetName()
+			if name == "" {
+				name = key
+			}
+			status := string(g.GetStatus())
+			detail.GroupCounts[status]++
+			detail.Groups = append(detail.Groups, MonitorGroupState{
+				Group:         name,
+				Status:        status,
+				LastTriggered: formatUnix(g.LastTriggeredTs),
+				LastResolved:  formatUnix(g.LastResolvedTs),
+				LastNotified:  formatUnix(g.LastNotifiedTs),
+				LastNoData:    formatUnix(g.LastNodataTs),
+				Maintenance:   s.maintenance.matchRFC3339(formatUnix(g.LastTriggeredTs), detail.ID, detail.Tags),
+			})
+		}
+		sort.Slice(detail.Groups, func(i, j int) bool {
+			a, b := detail.Groups[i], detail.Groups[j]
+			if stateSeverity[a.Status] != stateSeverity[b.Status] {
+				return stateSeverity[a.Status] < stateSeverity[b.Status]
+			}
+			return a.Group < b.Group
+		})
+	}
+
+	for _, d := range m.MatchingDowntimes {
+		downtime := MonitorDowntime{ID: d.Id, Scope: d.Scope, Start: formatUnix(d.Start)}
+		if end := d.End.Get(); end != nil {
+			downtime.End = formatUnix(end)
+		}
+		detail.Downtimes = append(detail.Downtimes, downtime)
+	}
+	return detail
+}
+
+// formatUnix renders a Unix timestamp in seconds as RFC3339, or "" when
+// it is unset.
+func formatUnix(ts *int64) string {
+	if ts == nil || *ts <= 0 {
+		return ""
+	}
+	return time.Unix(*ts, 0).UTC().Format(time.RFC3339)
+}
+
+func formatMonitorResult(result *MonitorDetail) string {
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Sprintf(`{"error": "failed to format result: %v"}`, err)
+	}
+	return string(data)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMonitorSearchQuery(t *testing.T) {
+	query, err := monitorSearchQuery(ListMonitorsParams{Name: "checkout latency", Tags: []string{"env:prod"}, States: []string{"Alert", "No Data"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != `"checkout latency" tag:"env:prod" status:(alert OR "no data")` {
+		t.Fatalf("unexpected query: %s", query)
+	}
+	if query, _ := monitorSearchQuery(ListMonitorsParams{States: []string{"warn"}}); query != "status:warn" {
+		t.Fatalf("unexpected single-state query: %s", query)
+	}
+	if _, err := monitorSearchQuery(ListMonitorsParams{States: []string{"broken"}}); err == nil {
+		t.Fatal("expected an unknown state to be rejected")
+	}
+}
+
+func TestListMonitors(t *testing.T) {
+	var search string
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/api/v1/monitor/search" {
+			http.NotFound(w, r)
+			return
+		}
+		search = r.URL.Query().Get("query")
+		_, _ = w.Write([]byte(`{
+			"monitors":[{"id":7,"name":"Checkout latency","status":"Alert","type":"metric alert","query":"avg(last_5m):x > 1","tags":["env:prod"],"last_triggered_ts":1768903200}],
+			"metadata":{"page":0,"page_count":3,"per_page":1,"total_count":3},
+			"counts":{"status":[{"name":"Alert","count":1},{"name":"OK","count":2}]}}`))
+	})
+
+	result, err := server.ListMonitors(ListMonitorsParams{Tags: []string{"env:prod"}, PerPage: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if search != `tag:"env:prod"` {
+		t.Fatalf("unexpected search sent: %s", search)
+	}
+	m := result.Monitors[0]
+	if m.ID != 7 || m.Status != "Alert" || m.LastTriggered != "2026-01-20T10:00:00Z" || m.URL != "https://app.datadoghq.com/monitors/7" {
+		t.Fatalf("unexpected monitor: %+v", m)
+	}
+	if result.Total != 3 || result.ByStatus["OK"] != 2 || len(result.Notes) != 1 || !strings.Contains(result.Notes[0], "page=1") {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+}
+
+func TestGetMonitor(t *testing.T) {
+	var groupStates string
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/api/v1/monitor/7" {
+			http.NotFound(w, r)
+			return
+		}
+		groupStates = r.URL.Query().Get("group_states")
+		_, _ = w.Write([]byte(`{
+			"id":7,"name":"Disk full","type":"metric alert","query":"avg(last_5m):max:system.disk.in_use{*} by {host} > 0.9",
+			"message":"@pagerduty","overall_state":"Alert","multi":true,"priority":2,
+			"options":{"thresholds":{"critical":0.9}},
+			"state":{"groups":{
+				"host:b":{"name":"host:b","status":"OK","last_resolved_ts":1768903200},
+				"host:c":{"name":"host:c","status":"Alert","last_triggered_ts":1768903200},
+				"host:a":{"name":"host:a","status":"Alert","last_triggered_ts":1768903100}}},
+			"matching_downtimes":[{"id":3,"scope":["host:c"],"start":1768903000,"end":null}]}`))
+	})
+
+	result, err := server.GetMonitor(GetMonitorParams{MonitorID: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if groupStates != "all" {
+		t.Fatalf("expected all group states to be requested, got %q", groupStates)
+	}
+	var order []string
+	for _, g := range result.Groups {
+		order = append(order, g.Group+"="+g.Status)
+	}
+	if strings.Join(order, ",") != "host:a=Alert,host:c=Alert,host:b=OK" {
+		t.Fatalf("expected alerting groups first, got %v", order)
+	}
+	if result.GroupCounts["Alert"] != 2 || result.Groups[1].LastTriggered != "2026-01-20T10:00:00Z" {
+		t.Fatalf("unexpected groups: %+v", result)
+	}
+	if *result.Priority != 2 || result.Options == nil || len(result.Downtimes) != 1 || result.Downtimes[0].End != "" {
+		t.Fatalf("unexpected definition: %+v", result)
+	}
+
+	if _, err := server.GetMonitor(GetMonitorParams{MonitorID: 8}); err == nil || !strings.Contains(err.Error(), "monitor 8 not found") {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+	if _, err := server.GetMonitor(GetMonitorParams{MonitorID: 7, GroupStates: []string{"bad"}}); err == nil {
+		t.Fatal("expected an invalid group state to be rejected")
+	}
+}
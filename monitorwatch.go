@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+)
+
+const (
+	defaultMonitorWatch = 10 * time.Minute
+	maxMonitorWatch     = 30 * time.Minute
+)
+
+// monitorWatchInterval is how often a watched monitor is checked.
+var monitorWatchInterval = 15 * time.Second
+
+type WatchMonitorParams struct {
+	MonitorID int64 `json:"monitor_id"`
+	// Until waits for this state, such as "OK"; without it any change of
+	// the monitor's overall state ends the watch.
+	Until string `json:"until,omitempty"`
+	// Timeout is how long to watch, such as "15m".
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// MonitorGroupChange is a group whose state changed while watching.
+type MonitorGroupChange struct {
+	Group string `json:"group"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+type WatchMonitorResult struct {
+	MonitorID int64  `json:"monitor_id"`
+	Name      string `json:"name"`
+	// InitialState and State are the overall state when the watch began
+	// and when it ended.
+	InitialState string `json:"initial_state"`
+	State        string `json:"state"`
+	// Changed is set when the watch ended on the change it waited for.
+	Changed      bool                 `json:"changed"`
+	ChangedAt    string               `json:"changed_at,omitempty"`
+	Waited       string               `json:"waited"`
+	GroupChanges []MonitorGroupChange `json:"group_changes,omitempty"`
+	URL          string               `json:"url"`
+	Notes        []string             `json:"notes,omitempty"`
+}
+
+// WatchMonitor polls a monitor until its overall state changes, or
+// reaches the state asked for, or the timeout passes, reporting progress
+// while it waits.
+func (s *MCPServer) WatchMonitor(params WatchMonitorParams) (*WatchMonitorResult, error) {
+	if params.MonitorID <= 0 {
+		return nil, fmt.Errorf("monitor_id parameter is required")
+	}
+	until := ""
+	if params.Until != "" {
+		for state := range stateSeverity {
+			if strings.EqualFold(state, strings.TrimSpace(params.Until)) {
+				until = state
+			}
+		}
+		if until == "" {
+			return nil, fmt.Errorf("invalid until: %s (use OK, Alert, Warn or No Data)", params.Until)
+		}
+	}
+	timeout, err := parseDurationParam(params.Timeout, defaultMonitorWatch)
+	if err != nil {
+		return nil, err
+	}
+	if timeout > maxMonitorWatch {
+		return nil, fmt.Errorf("timeout must be at most %s", maxMonitorWatch)
+	}
+
+	started := time.Now()
+	initial, err := s.monitorState(params.MonitorID)
+	if err != nil {
+		return nil, err
+	}
+	result := &WatchMonitorResult{
+		MonitorID:    initial.ID,
+		Name:         initial.Name,
+		InitialState: initial.OverallState,
+		State:        initial.OverallState,
+		URL:          initial.URL,
+	}
+	done := func(state string) bool {
+		if until != "" {
+			return state == until
+		}
+		return state != result.InitialState
+	}
+	if until != "" && done(initial.OverallState) {
+		result.Changed = true
+		result.Waited = "0s"
+		result.Notes = append(result.Notes, fmt.Sprintf("The monitor is already %s.", until))
+		return result, nil
+	}
+
+	current := initial
+	deadline := started.Add(timeout)
+	for !time.Now().Add(monitorWatchInterval).After(deadline) {
+		elapsed := time.Since(started)
+		s.reportProgress(int(elapsed.Seconds()), int(timeout.Seconds()), fmt.Sprintf("Monitor is %s after %s", current.OverallState, elapsed.Round(time.Second)), "")
+		select {
+		case <-s.ctx.Done():
+			return nil, s.ctx.Err()
+		case <-time.After(monitorWatchInterval):
+		}
+		if current, err = s.monitorState(params.MonitorID); err != nil {
+			return nil, err
+		}
+		if done(current.OverallState) {
+			result.Changed = true
+			result.ChangedAt = time.Now().UTC().Format(time.RFC3339)
+			break
+		}
+	}
+
+	result.State = current.OverallState
+	result.Waited = time.Since(started).Round(time.Second).String()
+	result.GroupChanges = monitorGroupChanges(initial.Groups, current.Groups)
+	if !result.Changed {
+		want := "to change"
+		if until != "" {
+			want = "to become " + until
+		}
+		result.Notes = append(result.Notes, fmt.Sprintf("The monitor is still %s after %s; call watch_monitor again to keep waiting for it %s.", result.State, result.Waited, want))
+	}
+	return result, nil
+}
+
+// monitorState fetches a monitor's overall and group states. Unlike
+// get_monitor it doesn't snapshot the definition, since a watch fetches
+// it many times.
+func (s *MCPServer) monitorState(id int64) (*MonitorDetail, error) {
+	opts := datadogV1.NewGetMonitorOptionalParameters().WithGroupStates("all")
+	monitor, httpResp, err := datadogV1.NewMonitorsApi(s.ddClient).GetMonitor(s.ctx, id, *opts)
+	if err != nil {
+		if httpResp != nil && httpResp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("monitor %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get monitor %d: %w", id, err)
+	}
+	return s.monitorDetail(monitor), nil
+}
+
+// monitorGroupChanges lists the groups whose state differs between two
+// fetches, including groups that appeared or went away.
+func monitorGroupChanges(before, after []MonitorGroupState) []MonitorGroupChange {
+	was := make(map[string]string, len(before))
+	for _, group := range before {
+		was[group.Group] = group.Status
+	}
+	var changes []MonitorGroupChange
+	for _, group := range after {
+		if previous, ok := was[group.Group]; !ok || previous != group.Status {
+			changes = append(changes, MonitorGroupChange{Group: group.Group, From: previous, To: group.Status})
+		}
+		delete(was, group.Group)
+	}
+	for _, group := range before {
+		if _, gone := was[group.Group]; gone {
+			changes = append(changes, MonitorGroupChange{Group: group.Group, From: group.Status})
+		}
+	}
+	return changes
+}
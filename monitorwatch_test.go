@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWatchMonitor(t *testing.T) {
+	interval := monitorWatchInterval
+	monitorWatchInterval = time.Millisecond
+	t.Cleanup(func() { monitorWatchInterval = interval })
+
+	var calls atomic.Int32
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/monitor/7" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		state, group := "Alert", "Alert"
+		switch n := calls.Add(1); {
+		case n == 3:
+			state = "Warn"
+		case n >= 4:
+			state, group = "OK", "OK"
+		}
+		_, _ = w.Write([]byte(`{"id":7,"name":"Checkout errors","type":"query alert","query":"avg(last_5m):sum:errors{*} > 1","overall_state":"` + state + `",
+			"state":{"groups":{"service:checkout":{"name":"service:checkout","status":"` + group + `"}}}}`))
+	})
+	var sent []MCPNotification
+	watcher := server.withNotifier(func(n MCPNotification) { sent = append(sent, n) }).withProgress(json.RawMessage(`1`))
+
+	result, err := watcher.WatchMonitor(WatchMonitorParams{MonitorID: 7, Until: "ok", Timeout: "1m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Changed || result.InitialState != "Alert" || result.State != "OK" || result.ChangedAt == "" || calls.Load() != 4 {
+		t.Fatalf("expected the watch to end when the monitor recovered, got %+v after %d calls", result, calls.Load())
+	}
+	if len(result.GroupChanges) != 1 || result.GroupChanges[0] != (MonitorGroupChange{Group: "service:checkout", From: "Alert", To: "OK"}) {
+		t.Errorf("unexpected group changes %+v", result.GroupChanges)
+	}
+	if len(sent) != 3 {
+		t.Errorf("expected a progress notification per poll, got %d", len(sent))
+	}
+
+	calls.Store(0)
+	result, err = server.WatchMonitor(WatchMonitorParams{MonitorID: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Changed || result.State != "Warn" {
+		t.Fatalf("expected any change to end the watch, got %+v", result)
+	}
+
+	calls.Store(10)
+	result, err = server.WatchMonitor(WatchMonitorParams{MonitorID: 7, Until: "OK"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Changed || result.Waited != "0s" || len(result.Notes) != 1 {
+		t.Fatalf("expected a monitor already OK to return at once, got %+v", result)
+	}
+
+	if _, err := server.WatchMonitor(WatchMonitorParams{MonitorID: 7, Until: "recovered"}); err == nil {
+		t.Fatal("expected an unknown state to be rejected")
+	}
+	if _, err := server.WatchMonitor(WatchMonitorParams{MonitorID: 7, Timeout: "2h"}); err == nil || !strings.Contains(err.Error(), "at most") {
+		t.Fatalf("expected a long timeout to be rejected, got %v", err)
+	}
+}
+
+func TestWatchMonitorTimesOut(t *testing.T) {
+	interval := monitorWatchInterval
+	monitorWatchInterval = 20 * time.Millisecond
+	t.Cleanup(func() { monitorWatchInterval = interval })
+
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":7,"name":"Checkout errors","type":"query alert","query":"avg(last_5m):sum:errors{*} > 1","overall_state":"Alert"}`))
+	})
+	result, err := server.WatchMonitor(WatchMonitorParams{MonitorID: 7, Timeout: "50ms"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Changed || result.State != "Alert" || len(result.Notes) != 1 || !strings.Contains(result.Notes[0], "call watch_monitor again") {
+		t.Fatalf("expected the watch to time out, got %+v", result)
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMuteDuration = time.Hour
+	// maxMuteDuration keeps a mute made mid-incident from being forgotten.
+	maxMuteDuration = 7 * 24 * time.Hour
+	// muteMessagePrefix marks downtimes created by mute_monitor.
+	muteMessagePrefix = "Muted with go-dd-mcp"
+)
+
+type MuteMonitorParams struct {
+	MonitorID int64  `json:"monitor_id"`
+	Scope     string `json:"scope,omitempty"`
+	Duration  string `json:"duration,omitempty"`
+	Message   string `json:"message,omitempty"`
+}
+
+type MuteMonitorResult struct {
+	DowntimeID string `json:"downtime_id"`
+	MonitorID  int64  `json:"monitor_id"`
+	Scope      string `json:"scope"`
+	Start      string `json:"start"`
+	End        string `json:"end"`
+	URL        string `json:"url"`
+}
+
+type UnmuteMonitorParams struct {
+	MonitorID  int64  `json:"monitor_id"`
+	DowntimeID string `json:"downtime_id,omitempty"`
+}
+
+type UnmutedDowntime struct {
+	ID    string `json:"id"`
+	Scope string `json:"scope,omitempty"`
+}
+
+type UnmuteMonitorResult struct {
+	MonitorID int64             `json:"monitor_id"`
+	Canceled  []UnmutedDowntime `json:"canceled"`
+	// Skipped are downtimes that silence the monitor through tags rather
+	// than by its ID. They usually cover other monitors too, so they are
+	// only canceled when named with downtime_id.
+	Skipped []UnmutedDowntime `json:"skipped,omitempty"`
+	Notes   []string          `json:"notes,omitempty"`
+}
+
+// MuteMonitor silences a monitor, or some of its groups, for a limited
+// time by scheduling a one-time downtime that targets it by ID.
+func (s *MCPServer) MuteMonitor(params MuteMonitorParams) (*MuteMonitorResult, error) {
+	if err := s.requireWrites("mute_monitor"); err != nil {
+		return nil, err
+	}
+	if params.MonitorID <= 0 {
+		return nil, fmt.Errorf("monitor_id parameter is required")
+	}
+	duration, err := parseDurationParam(params.Duration, defaultMuteDuration)
+	if err != nil {
+		return nil, err
+	}
+	if duration > maxMuteDuration {
+		return nil, fmt.Errorf("duration must be at most %s, got %s", maxMuteDuration, duration)
+	}
+	scope := strings.TrimSpace(params.Scope)
+	if scope == "" {
+		scope = "*"
+	}
+
+	message := muteMessagePrefix
+	if params.Message != "" {
+		message += ": " + params.Message
+	}
+	start := time.Now().UTC()
+	end := start.Add(duration)
+	id, err := s.createDowntime(downtimeSpec{MonitorID: params.MonitorID, Scope: scope, Message: message, Start: start, End: end})
+	if err != nil {
+		return nil, fmt.Errorf("failed to mute monitor %d: %w", params.MonitorID, err)
+	}
+
+	return &MuteMonitorResult{
+		DowntimeID: id,
+		MonitorID:  params.MonitorID,
+		Scope:      scope,
+		Start:      start.Format(time.RFC3339),
+		End:        end.Format(time.RFC3339),
+		URL:        s.appURL(fmt.Sprintf("/monitors/%d", params.MonitorID)),
+	}, nil
+}
+
+// UnmuteMonitor cancels the downtimes that target a monitor by ID, or the
+// single downtime named by DowntimeID.
+func (s *MCPServer) UnmuteMonitor(params UnmuteMonitorParams) (*UnmuteMonitorResult, error) {
+	if err := s.requireWrites("unmute_monitor"); err != nil {
+		return nil, err
+	}
+	if params.MonitorID <= 0 {
+		return nil, fmt.Errorf("monitor_id parameter is required")
+	}
+
+	downtimes, err := s.listMonitorDowntimes(params.MonitorID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &UnmuteMonitorResult{MonitorID: params.MonitorID, Canceled: make([]UnmutedDowntime, 0)}
+	found := false
+	for _, d := range downtimes {
+		downtime := UnmutedDowntime{ID: d.ID, Scope: d.Scope}
+		if params.DowntimeID != "" {
+			if d.ID != params.DowntimeID {
+				continue
+			}
+			found = true
+		} else if d.Err != nil {
+			result.Notes = append(result.Notes, fmt.Sprintf("Couldn't read downtime %s: %v", d.ID, d.Err))
+			continue
+		} else if !d.Targeted {
+			result.Skipped = append(result.Skipped, downtime)
+			continue
+		}
+		if err := s.cancelDowntime(d.ID); err != nil {
+			return nil, err
+		}
+		result.Canceled = append(result.Canceled, downtime)
+	}
+
+	if params.DowntimeID != "" && !found {
+		return nil, fmt.Errorf("downtime %s doesn't silence monitor %d", params.DowntimeID, params.MonitorID)
+	}
+	if len(result.Skipped) > 0 {
+		result.Notes = append(result.Notes, "Skipped downtimes silence this monitor through tags and may cover other monitors; pass downtime_id to cancel one.")
+	}
+	if len(downtimes) == 0 {
+		result.Notes = append(result.Notes, "The monitor has no active or scheduled downtimes.")
+	}
+	return result, nil
+}
+
+// muteTools are registered only when writes are enabled.
+var muteTools = []Tool{
+	{
+		Name:        "mute_monitor",
+		Description: "Mute a monitor, or some of its groups, for a limited time by scheduling a downtime for it",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]SchemaProperty{
+				"monitor_id": {
+					Type:        "integer",
+					Description: "Monitor ID",
+				},
+				"scope": {
+					Type:        "string",
+					Description: "Groups to mute as a tag query (e.g., 'host:web-1' or 'env:staging'). Defaults to '*', every group.",
+				},
+				"duration": {
+					Type:        "string",
+					Description: "How long to mute for (e.g., '30m', '4h', '1d'; max 7d). Defaults to 1h.",
+				},
+				"message": {
+					Type:        "string",
+					Description: "Why the monitor is muted, shown on the downtime",
+				},
+				"confirm": confirmProperty,
+			},
+			Required: []string{"monitor_id", "confirm"},
+		},
+		Annotations: writeToolAnnotations(false),
+	},
+	{
+		Name:        "unmute_monitor",
+		Description: "Unmute a monitor by canceling the downtimes that target it by ID, or one named downtime",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]SchemaProperty{
+				"monitor_id": {
+					Type:        "integer",
+					Description: "Monitor ID",
+				},
+				"downtime_id": {
+					Type:        "string",
+					Description: "Cancel only this downtime. Needed for downtimes that match the monitor through tags.",
+				},
+				"confirm": confirmProperty,
+			},
+			Required: []string{"monitor_id", "confirm"},
+		},
+		Annotations: writeToolAnnotations(true),
+	},
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMuteToolsOnlyListedWithWrites(t *testing.T) {
+	listed := func(server *MCPServer) bool {
+		for _, tool := range server.ListTools() {
+			if tool.Name == "mute_monitor" {
+				return true
+			}
+		}
+		return false
+	}
+	if listed(&MCPServer{}) {
+		t.Fatal("expected mute_monitor to be hidden in read-only mode")
+	}
+	if !listed(&MCPServer{allowWrites: true}) {
+		t.Fatal("expected mute_monitor to be listed when writes are enabled")
+	}
+	if _, err := (&MCPServer{}).MuteMonitor(MuteMonitorParams{MonitorID: 7}); err == nil || !strings.Contains(err.Error(), "DD_MCP_ALLOW_WRITES") {
+		t.Fatalf("expected the write gate to refuse the call, got %v", err)
+	}
+}
+
+func TestMuteMonitor(t *testing.T) {
+	var created struct {
+		Data struct {
+			Attributes struct {
+				MonitorIdentifier struct {
+					MonitorID int64 `json:"monitor_id"`
+				} `json:"monitor_identifier"`
+				Scope    string `json:"scope"`
+				Message  string `json:"message"`
+				Schedule struct {
+					End string `json:"end"`
+				} `json:"schedule"`
+			} `json:"attributes"`
+		} `json:"data"`
+	}
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		data, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(data, &created)
+		_, _ = w.Write([]byte(`{"data":{"id":"dt-1","type":"downtime"}}`))
+	})
+	server.allowWrites = true
+
+	result, err := server.MuteMonitor(MuteMonitorParams{MonitorID: 7, Scope: "host:web-1", Duration: "30m", Message: "flapping during deploy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	attrs := created.Data.Attributes
+	if attrs.MonitorIdentifier.MonitorID != 7 || attrs.Scope != "host:web-1" || attrs.Message != "Muted with go-dd-mcp: flapping during deploy" || attrs.Schedule.End == "" {
+		t.Fatalf("unexpected downtime request: %+v", attrs)
+	}
+	if result.DowntimeID != "dt-1" || result.Scope != "host:web-1" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	if _, err := server.MuteMonitor(MuteMonitorParams{MonitorID: 7, Duration: "30d"}); err == nil {
+		t.Fatal("expected a mute longer than 7 days to be rejected")
+	}
+}
+
+func TestUnmuteMonitorSkipsTagDowntimes(t *testing.T) {
+	var canceled []string
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/api/v2/monitor/7/downtime_matches":
+			_, _ = w.Write([]byte(`{"data":[{"id":"mine","attributes":{"scope":"*"}},{"id":"team","attributes":{"scope":"env:prod"}}]}`))
+		case r.Method == http.MethodDelete:
+			canceled = append(canceled, strings.TrimPrefix(r.URL.Path, "/api/v2/downtime/"))
+			w.WriteHeader(http.StatusNoContent)
+		case r.URL.Path == "/api/v2/downtime/mine":
+			_, _ = w.Write([]byte(`{"data":{"id":"mine","attributes":{"monitor_identifier":{"monitor_id":7}}}}`))
+		case r.URL.Path == "/api/v2/downtime/team":
+			_, _ = w.Write([]byte(`{"data":{"id":"team","attributes":{"monitor_identifier":{"monitor_tags":["team:core"]}}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	server.allowWrites = true
+
+	result, err := server.UnmuteMonitor(UnmuteMonitorParams{MonitorID: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(canceled, ",") != "mine" || len(result.Canceled) != 1 {
+		t.Fatalf("expected only the downtime targeting the monitor to be canceled, got %v", canceled)
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0].ID != "team" {
+		t.Fatalf("expected the tag downtime to be skipped, got %+v", result.Skipped)
+	}
+
+	canceled = nil
+	if _, err := server.UnmuteMonitor(UnmuteMonitorParams{MonitorID: 7, DowntimeID: "team"}); err != nil || strings.Join(canceled, ",") != "team" {
+		t.Fatalf("expected the named downtime to be canceled, got %v %v", canceled, err)
+	}
+	if _, err := server.UnmuteMonitor(UnmuteMonitorParams{MonitorID: 7, DowntimeID: "other"}); err == nil {
+		t.Fatal("expected an unrelated downtime to be rejected")
+	}
+}
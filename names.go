@@ -0,0 +1,335 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV2"
+)
+
+const (
+	// nameCacheTTL is how long listed service and monitor names are reused
+	// before being fetched again.
+	nameCacheTTL = 5 * time.Minute
+	// maxSuggestions bounds how many close matches are offered.
+	maxSuggestions = 5
+	// maxMonitorPages bounds the monitor listing used for fuzzy matching.
+	maxMonitorPages = 10
+	monitorPageSize = 1000
+)
+
+// namedEntity is a service or monitor that a misspelled name can resolve
+// to. ID is zero for services.
+type namedEntity struct {
+	Name string
+	ID   int64
+}
+
+// nameResolution is the outcome of looking up a name that may be
+// misspelled. Corrected is set when Name differs from what was asked for;
+// otherwise Suggestions lists close matches, if any.
+type nameResolution struct {
+	Entity      namedEntity
+	Corrected   bool
+	Suggestions []string
+}
+
+// found reports whether the lookup produced a usable entity.
+func (r nameResolution) found() bool {
+	return r.Entity.Name != ""
+}
+
+// note explains a correction or the lack of a match to the caller, so an
+// empty result isn't mistaken for "nothing wrong".
+func (r nameResolution) note(kind, asked string) string {
+	switch {
+	case r.Corrected:
+		return fmt.Sprintf("No %s named %q exists; using the closest match %q.", kind, asked, r.Entity.Name)
+	case r.found():
+		return ""
+	case len(r.Suggestions) > 0:
+		return fmt.Sprintf("No %s named %q exists. Did you mean: %s?", kind, asked, strings.Join(r.Suggestions, ", "))
+	default:
+		return fmt.Sprintf("No %s named %q exists.", kind, asked)
+	}
+}
+
+// resolveName looks name up among entities. An exact match wins; failing
+// that, a single clearly closest candidate (same name modulo case or
+// separators, a unique prefix or token, or a one-letter typo) is used, and
+// otherwise the closest candidates are returned as suggestions.
+func resolveName(name string, entities []namedEntity) nameResolution {
+	for _, e := range entities {
+		if e.Name == name {
+			return nameResolution{Entity: e}
+		}
+	}
+
+	type scored struct {
+		entity namedEntity
+		score  int
+	}
+	var matches []scored
+	for _, e := range entities {
+		if score, ok := nameDistance(name, e.Name); ok {
+			matches = append(matches, scored{e, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score < matches[j].score
+		}
+		return matches[i].entity.Name < matches[j].entity.Name
+	})
+
+	if len(matches) > 0 && matches[0].score <= 3 && (len(matches) == 1 || matches[1].score > matches[0].score) {
+		return nameResolution{Entity: matches[0].entity, Corrected: true}
+	}
+
+	var result nameResolution
+	seen := make(map[string]bool)
+	for _, m := range matches {
+		if len(result.Suggestions) == maxSuggestions {
+			break
+		}
+		if !seen[m.entity.Name] {
+			seen[m.entity.Name] = true
+			result.Suggestions = append(result.Suggestions, m.entity.Name)
+		}
+	}
+	return result
+}
+
+// nameDistance scores how close candidate is to name, lower being closer:
+// 0 for the same normalized name, 1 for a prefix, 2 for a matching token
+// and 2 plus the edit distance for near misses. ok is false when the two
+// aren't plausibly the same thing.
+func nameDistance(name, candidate string) (int, bool) {
+	a, b := normalizeName(name), normalizeName(candidate)
+	if a == "" || b == "" {
+		return 0, false
+	}
+	if a == b {
+		return 0, true
+	}
+	if strings.HasPrefix(b, a) || strings.HasPrefix(a, b) {
+		return 1, true
+	}
+	for _, token := range strings.Split(b, "-") {
+		if token == a {
+			return 2, true
+		}
+	}
+	if len(a) >= 4 && strings.Contains(b, a) {
+		return 2, true
+	}
+
+	maxEdits := min(max(len(a)/4, 1), 3)
+	if d := editDistance(a, b); d <= maxEdits {
+		return 2 + d, true
+	}
+	return 0, false
+}
+
+// normalizeName lowercases and folds the usual separators to "-" so
+// "Checkout_API" and "checkout-api" compare equal.
+func normalizeName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '_', '.', ' ', '/':
+			return '-'
+		}
+		return r
+	}, name)
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+type cachedNames struct {
+	entities []namedEntity
+	fetched  time.Time
+}
+
+// nameCache keeps recently listed service and monitor names per org. It
+// is shared by every copy of the server.
+type nameCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedNames
+}
+
+func newNameCache() *nameCache {
+	return &nameCache{entries: make(map[string]cachedNames)}
+}
+
+// get returns the cached list for key, calling fetch when it is missing
+// or stale. A nil cache always fetches.
+func (c *nameCache) get(key string, now time.Time, fetch func() ([]namedEntity, error)) ([]namedEntity, error) {
+	if c != nil {
+		c.mu.Lock()
+		entry, ok := c.entries[key]
+		c.mu.Unlock()
+		if ok && now.Sub(entry.fetched) < nameCacheTTL {
+			return entry.entities, nil
+		}
+	}
+
+	entities, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	if c != nil {
+		c.mu.Lock()
+		c.entries[key] = cachedNames{entities: entities, fetched: now}
+		c.mu.Unlock()
+	}
+	return entities, nil
+}
+
+// serviceNames returns the Service Catalog's service names, cached.
+func (s *MCPServer) serviceNames() ([]namedEntity, error) {
+	return s.names.get("services:"+s.apiOrg(), time.Now(), s.listServiceNames)
+}
+
+// monitorNames returns every monitor's name and ID, cached.
+func (s *MCPServer) monitorNames() ([]namedEntity, error) {
+	return s.names.get("monitors:"+s.apiOrg(), time.Now(), s.listMonitorNames)
+}
+
+// resolveService matches a service name against the Service Catalog.
+func (s *MCPServer) resolveService(name string) (nameResolution, error) {
+	services, err := s.serviceNames()
+	if err != nil {
+		return nameResolution{}, err
+	}
+	return resolveName(name, services), nil
+}
+
+func (s *MCPServer) listServiceNames() ([]namedEntity, error) {
+	api := datadogV2.NewServiceDefinitionApi(s.ddClient)
+	var services []namedEntity
+	for page := int64(0); ; page++ {
+		opts := datadogV2.NewListServiceDefinitionsOptionalParameters().WithPageSize(100).WithPageNumber(page)
+		resp, _, err := api.ListServiceDefinitions(s.ctx, *opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list services: %w", err)
+		}
+		for _, def := range resp.Data {
+			if def.Attributes == nil || def.Attributes.Schema == nil {
+				continue
+			}
+			raw, err := json.Marshal(def.Attributes.Schema)
+			if err != nil {
+				continue
+			}
+			var schema struct {
+				Service string `json:"dd-service"`
+			}
+			if json.Unmarshal(raw, &schema) == nil && schema.Service != "" {
+				services = append(services, namedEntity{Name: schema.Service})
+			}
+		}
+		if len(resp.Data) < 100 {
+			return services, nil
+		}
+	}
+}
+
+// resolveMonitor finds a monitor by name, trying Datadog's own name filter
+// before fuzzy matching against all monitors.
+func (s *MCPServer) resolveMonitor(name string) (nameResolution, error) {
+	api := datadogV1.NewMonitorsApi(s.ddClient)
+	monitors, _, err := api.ListMonitors(s.ctx, *datadogV1.NewListMonitorsOptionalParameters().WithName(name))
+	if err != nil {
+		return nameResolution{}, fmt.Errorf("failed to search monitors: %w", err)
+	}
+	for _, m := range monitors {
+		if strings.EqualFold(m.GetName(), name) {
+			return nameResolution{Entity: namedEntity{Name: m.GetName(), ID: m.GetId()}, Corrected: m.GetName() != name}, nil
+		}
+	}
+
+	all, err := s.monitorNames()
+	if err != nil {
+		return nameResolution{}, err
+	}
+	return resolveName(name, all), nil
+}
+
+func (s *MCPServer) listMonitorNames() ([]namedEntity, error) {
+	api := datadogV1.NewMonitorsApi(s.ddClient)
+	var monitors []namedEntity
+	for page := int64(0); page < maxMonitorPages; page++ {
+		opts := datadogV1.NewListMonitorsOptionalParameters().WithPage(page).WithPageSize(monitorPageSize)
+		resp, _, err := api.ListMonitors(s.ctx, *opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list monitors: %w", err)
+		}
+		for _, m := range resp {
+			monitors = append(monitors, namedEntity{Name: m.GetName(), ID: m.GetId()})
+		}
+		if len(resp) < monitorPageSize {
+			break
+		}
+	}
+	return monitors, nil
+}
+
+var serviceFilterPattern = regexp.MustCompile(`(^|[\s(])service:("[^"]*"|[^\s()]+)`)
+
+// correctServiceFilter checks the service:<name> filter of a search query
+// that matched nothing. It returns the query with a misspelled service
+// corrected, or "" when there is nothing to correct, plus a note for the
+// caller. Wildcards and negated filters are left alone.
+func (s *MCPServer) correctServiceFilter(query string) (string, string) {
+	match := serviceFilterPattern.FindStringSubmatchIndex(query)
+	if match == nil {
+		return "", ""
+	}
+	service := strings.Trim(query[match[4]:match[5]], `"`)
+	if service == "" || strings.ContainsAny(service, "*?") {
+		return "", ""
+	}
+
+	resolution, err := s.resolveService(service)
+	if err != nil || resolution.found() && !resolution.Corrected {
+		return "", ""
+	}
+	if !resolution.Corrected {
+		if len(resolution.Suggestions) == 0 {
+			// Services outside the catalog still log, so silence here
+			// proves nothing.
+			return "", ""
+		}
+		return "", resolution.note("service", service)
+	}
+
+	corrected := query[:match[4]] + resolution.Entity.Name + query[match[5]:]
+	return corrected, resolution.note("service", service)
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolveName(t *testing.T) {
+	services := []namedEntity{
+		{Name: "checkout"}, {Name: "checkout-worker"}, {Name: "cart"},
+		{Name: "payments-api"}, {Name: "payments-worker"}, {Name: "search"},
+	}
+
+	tests := []struct {
+		name        string
+		want        string
+		corrected   bool
+		suggestions []string
+	}{
+		{name: "checkout", want: "checkout"},
+		{name: "chekout", want: "checkout", corrected: true},
+		{name: "Payments_API", want: "payments-api", corrected: true},
+		{name: "payments", suggestions: []string{"payments-api", "payments-worker"}},
+		{name: "inventory"},
+	}
+	for _, tt := range tests {
+		got := resolveName(tt.name, services)
+		if got.Entity.Name != tt.want || got.Corrected != tt.corrected || !reflect.DeepEqual(got.Suggestions, tt.suggestions) {
+			t.Errorf("resolveName(%q) = %+v, want %q corrected=%v suggestions=%v", tt.name, got, tt.want, tt.corrected, tt.suggestions)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	for _, tt := range []struct {
+		a, b string
+		want int
+	}{
+		{"", "abc", 3}, {"kitten", "sitting", 3}, {"checkout", "chekout", 1}, {"same", "same", 0},
+	} {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// fakeCatalog serves a service catalog and records log search queries.
+func fakeCatalog(t *testing.T, services []string, logsFor string, queries *[]string) *MCPServer {
+	t.Helper()
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/services/definitions"):
+			var data []map[string]interface{}
+			for _, name := range services {
+				data = append(data, map[string]interface{}{
+					"type":       "service-definition",
+					"attributes": map[string]interface{}{"schema": map[string]interface{}{"schema-version": "v2", "dd-service": name}},
+				})
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+		case strings.HasSuffix(r.URL.Path, "/logs/events/search"):
+			body, _ := io.ReadAll(r.Body)
+			var req struct {
+				Filter struct {
+					Query string `json:"query"`
+				} `json:"filter"`
+			}
+			_ = json.Unmarshal(body, &req)
+			*queries = append(*queries, req.Filter.Query)
+			if strings.Contains(req.Filter.Query, "service:"+logsFor+" ") {
+				_, _ = w.Write([]byte(`{"data":[{"id":"1","attributes":{"message":"boom","service":"` + logsFor + `"}}]}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"data":[]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	server.names = newNameCache()
+	return server
+}
+
+func TestQueryLogsCorrectsMisspelledService(t *testing.T) {
+	var queries []string
+	server := fakeCatalog(t, []string{"checkout", "cart"}, "checkout", &queries)
+
+	result, err := server.QueryLogs(QueryLogsParams{Query: "service:chekout status:error"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Count != 1 || result.Query != "service:checkout status:error" {
+		t.Fatalf("expected the corrected query to return the log, got %+v", result)
+	}
+	if len(result.Notes) != 1 || !strings.Contains(result.Notes[0], `using the closest match "checkout"`) {
+		t.Fatalf("expected a correction note, got %v", result.Notes)
+	}
+	if len(queries) != 2 {
+		t.Fatalf("expected the original and corrected searches, got %v", queries)
+	}
+}
+
+func TestQueryLogsSuggestsServices(t *testing.T) {
+	var queries []string
+	server := fakeCatalog(t, []string{"payments-api", "payments-worker"}, "", &queries)
+
+	result, err := server.QueryLogs(QueryLogsParams{Query: "service:payments status:error"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Count != 0 || len(result.Notes) != 1 || !strings.Contains(result.Notes[0], "Did you mean: payments-api, payments-worker?") {
+		t.Fatalf("expected suggestions in the notes, got %+v", result)
+	}
+
+	// A service the catalog doesn't know and nothing resembles may still
+	// log, so no note is added.
+	result, err = server.QueryLogs(QueryLogsParams{Query: "service:legacy-batch"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Notes) != 0 {
+		t.Fatalf("expected no notes for an unrelated service, got %v", result.Notes)
+	}
+}
+
+func TestResolveRunbooksByMonitorName(t *testing.T) {
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/api/v1/monitor" && r.URL.Query().Get("name") != "":
+			_, _ = w.Write([]byte(`[]`))
+		case r.URL.Path == "/api/v1/monitor":
+			_, _ = w.Write([]byte(`[{"id":7,"name":"Checkout error rate","type":"metric alert","query":"q"},{"id":8,"name":"Cart latency","type":"metric alert","query":"q"}]`))
+		case r.URL.Path == "/api/v1/monitor/7":
+			_, _ = w.Write([]byte(`{"id":7,"name":"Checkout error rate","type":"metric alert","query":"q","message":"[Runbook](https://wiki.example.com/checkout)"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	server.names = newNameCache()
+
+	result, err := server.ResolveRunbooks(ResolveRunbooksParams{Monitor: "checkout eror rate"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.MonitorID != 7 || len(result.Runbooks) != 1 {
+		t.Fatalf("expected monitor 7's runbook, got %+v", result)
+	}
+	if len(result.Notes) == 0 || !strings.Contains(result.Notes[0], `"Checkout error rate"`) {
+		t.Fatalf("expected a correction note, got %v", result.Notes)
+	}
+
+	if _, err := server.ResolveRunbooks(ResolveRunbooksParams{Monitor: "disk full"}); err == nil || !strings.Contains(err.Error(), `No monitor named "disk full" exists`) {
+		t.Fatalf("expected an unknown monitor error, got %v", err)
+	}
+}
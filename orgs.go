@@ -0,0 +1,273 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// maxCompareSLOs bounds the SLOs listed per org; the state counts
+	// cover every match.
+	maxCompareSLOs = 20
+	// maxCompareSeries bounds the series listed per org for a metric.
+	maxCompareSeries = 20
+)
+
+var compareKinds = []string{"monitors", "slos", "metric"}
+
+// OrgProfile is one Datadog org the server can query besides its own,
+// named in DD_MCP_ORGS_FILE. Site defaults to the server's DD_SITE.
+type OrgProfile struct {
+	APIKey string `json:"api_key"`
+	AppKey string `json:"app_key"`
+	Site   string `json:"site,omitempty"`
+}
+
+// loadOrgProfiles reads the org profiles file: a JSON object mapping
+// each org's name to its keys and site.
+func loadOrgProfiles(path string) (map[string]OrgProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read orgs file: %w", err)
+	}
+	profiles := make(map[string]OrgProfile)
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return nil, fmt.Errorf("failed to parse orgs file: %w", err)
+	}
+	for name, profile := range profiles {
+		if name == "" || profile.APIKey == "" || profile.AppKey == "" {
+			return nil, fmt.Errorf("org %q must include api_key and app_key", name)
+		}
+	}
+	return profiles, nil
+}
+
+// forOrg returns a copy of the server whose Datadog calls go to the named
+// org.
+func (s *MCPServer) forOrg(name string) *MCPServer {
+	profile := s.orgs[name]
+	org := *s
+	org.credentials = datadogCredentials{APIKey: profile.APIKey, AppKey: profile.AppKey}
+	if profile.Site != "" {
+		org.site = normalizeSite(profile.Site)
+	}
+	org.profile = name
+	org.ctx = org.datadogContext(s.ctx)
+	return &org
+}
+
+func (s *MCPServer) orgNames() []string {
+	names := make([]string, 0, len(s.orgs))
+	for name := range s.orgs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type CompareOrgsParams struct {
+	Kind  string   `json:"kind"`
+	Query string   `json:"query,omitempty"`
+	Orgs  []string `json:"orgs,omitempty"`
+	From  string   `json:"from,omitempty"`
+	To    string   `json:"to,omitempty"`
+}
+
+type OrgSeries struct {
+	Scope string  `json:"scope"`
+	Avg   float64 `json:"avg"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Last  float64 `json:"last"`
+}
+
+// OrgComparison is one org's column of a comparison. Total counts the
+// matching monitors or SLOs, or the series for a metric; ByState breaks
+// monitors and SLOs down by state.
+type OrgComparison struct {
+	Org     string           `json:"org"`
+	Site    string           `json:"site"`
+	Total   int64            `json:"total"`
+	ByState map[string]int64 `json:"by_state,omitempty"`
+	SLOs    []SLOSummary     `json:"slos,omitempty"`
+	Series  []OrgSeries      `json:"series,omitempty"`
+	URL     string           `json:"url,omitempty"`
+	Notes   []string         `json:"notes,omitempty"`
+}
+
+type CompareOrgsResult struct {
+	Kind   string            `json:"kind"`
+	Query  string            `json:"query,omitempty"`
+	From   string            `json:"from,omitempty"`
+	To     string            `json:"to,omitempty"`
+	Orgs   []OrgComparison   `json:"orgs"`
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// CompareOrgs runs the same monitor, SLO or metric query in several orgs
+// at once and returns the results side by side, in org name order.
+func (s *MCPServer) CompareOrgs(params CompareOrgsParams) (*CompareOrgsResult, error) {
+	if len(s.orgs) == 0 {
+		return nil, fmt.Errorf("no orgs are configured; set DD_MCP_ORGS_FILE")
+	}
+	if !slices.Contains(compareKinds, params.Kind) {
+		return nil, fmt.Errorf("invalid kind: %q (use %s)", params.Kind, strings.Join(compareKinds, ", "))
+	}
+	query := strings.TrimSpace(params.Query)
+	if params.Kind == "metric" && query == "" {
+		return nil, fmt.Errorf("query parameter is required for a metric comparison")
+	}
+	orgs := params.Orgs
+	if len(orgs) == 0 {
+		orgs = s.orgNames()
+	}
+	for _, org := range orgs {
+		if _, ok := s.orgs[org]; !ok {
+			return nil, fmt.Errorf("unknown org %q (configured: %s)", org, strings.Join(s.orgNames(), ", "))
+		}
+	}
+
+	result := &CompareOrgsResult{Kind: params.Kind, Query: query, Orgs: make([]OrgComparison, 0, len(orgs))}
+	var from, to time.Time
+	if params.Kind == "metric" {
+		var err error
+		if from, err = s.timeParam("from", params.From, time.Now().Add(-time.Hour)); err != nil {
+			return nil, err
+		}
+		if to, err = s.timeParam("to", params.To, time.Now()); err != nil {
+			return nil, err
+		}
+		result.From, result.To = from.Format(time.RFC3339), to.Format(time.RFC3339)
+	}
+
+	columns, errs := fanOut(s, orgs, "orgs", func(srv *MCPServer, name string) (*OrgComparison, error) {
+		org := srv.forOrg(name)
+		column := &OrgComparison{Org: name, Site: org.site}
+		if column.Site == "" {
+			column.Site = "datadoghq.com"
+		}
+		var err error
+		switch params.Kind {
+		case "monitors":
+			err = org.compareMonitors(query, column)
+		case "slos":
+			err = org.compareSLOs(query, column)
+		case "metric":
+			err = org.compareMetric(query, from, to, column)
+		}
+		return column, err
+	})
+	if len(columns) == 0 {
+		return nil, allFailed("org", errs)
+	}
+	for _, name := range orgs {
+		if column, ok := columns[name]; ok {
+			result.Orgs = append(result.Orgs, *column)
+		}
+	}
+	result.Errors = errs
+	return result, nil
+}
+
+// compareMonitors counts the monitors matching a monitor search query by
+// state.
+func (s *MCPServer) compareMonitors(query string, column *OrgComparison) error {
+	monitors, _, err := s.searchMonitors(query, 0, 1)
+	if err != nil {
+		return err
+	}
+	column.Total = monitors.Total
+	column.ByState = monitors.ByStatus
+	column.URL = s.appURL("/monitors/manage?q=" + url.QueryEscape(query))
+	return nil
+}
+
+// compareSLOs lists the SLOs matching an SLO search query with their
+// status, counting them by state.
+func (s *MCPServer) compareSLOs(query string, column *OrgComparison) error {
+	slos, truncated, err := s.searchSLOs(query)
+	if err != nil {
+		return err
+	}
+	column.Total = int64(len(slos))
+	column.ByState = make(map[string]int64)
+	for _, slo := range slos {
+		column.ByState[slo.State]++
+	}
+	if truncated {
+		column.Notes = append(column.Notes, fmt.Sprintf("Only the first %d SLOs were counted.", column.Total))
+	}
+	sortSLOsByUrgency(slos)
+	column.SLOs = slos[:min(len(slos), maxCompareSLOs)]
+	if column.Total > int64(len(column.SLOs)) {
+		column.Notes = append(column.Notes, fmt.Sprintf("%d more SLOs are counted but not listed.", column.Total-int64(len(column.SLOs))))
+	}
+	return nil
+}
+
+// compareMetric summarizes each series of a metric query, such as an
+// estimated usage metric.
+func (s *MCPServer) compareMetric(query string, from, to time.Time, column *OrgComparison) error {
+	resp, err := s.queryMetrics(from, to, query)
+	if err != nil {
+		return err
+	}
+	column.Total = int64(len(resp.Series))
+	for i := range resp.Series {
+		if i == maxCompareSeries {
+			column.Notes = append(column.Notes, fmt.Sprintf("Only the first %d of %d series are listed.", maxCompareSeries, len(resp.Series)))
+			break
+		}
+		avg, low, high, last, ok := summarizeSeries(&resp.Series[i])
+		if !ok {
+			continue
+		}
+		column.Series = append(column.Series, OrgSeries{Scope: resp.Series[i].GetScope(), Avg: avg, Min: low, Max: high, Last: last})
+	}
+	if column.Total == 0 {
+		column.Notes = append(column.Notes, "No data in this period.")
+	}
+	return nil
+}
+
+// compareOrgsTool is listed when at least two orgs are configured.
+func (s *MCPServer) compareOrgsTool() Tool {
+	return Tool{
+		Name:        "compare_orgs",
+		Description: "Run the same monitor, SLO or metric query across several Datadog orgs (e.g., per-region orgs) and compare the results side by side",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]SchemaProperty{
+				"kind": {
+					Type:        "string",
+					Description: "What to compare: 'monitors' (counts by state), 'slos' (status and error budget) or 'metric' (series summaries, e.g., estimated usage)",
+				},
+				"query": {
+					Type:        "string",
+					Description: "Monitor search query (e.g., 'tag:\"service:checkout\"'), SLO search query, or metric query. Required for 'metric'.",
+				},
+				"orgs": {
+					Type:        "array",
+					Description: "Orgs to compare: " + strings.Join(s.orgNames(), ", ") + " (default: all)",
+					Items:       &SchemaProperty{Type: "string"},
+				},
+				"from": {
+					Type:        "string",
+					Description: "Start of a metric comparison (RFC3339 or relative). Defaults to 1h ago.",
+				},
+				"to": {
+					Type:        "string",
+					Description: "End of a metric comparison (RFC3339 or relative). Defaults to now.",
+				},
+			},
+			Required: []string{"kind"},
+		},
+		Annotations: readOnlyToolAnnotations(),
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCompareOrgs(t *testing.T) {
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		org := r.Header.Get("DD-API-KEY")
+		switch {
+		case org == "broken-key":
+			http.Error(w, `{"errors":["Forbidden"]}`, http.StatusForbidden)
+		case r.URL.Path == "/api/v1/monitor/search":
+			alerts := map[string]int{"us-key": 3, "eu-key": 1}[org]
+			fmt.Fprintf(w, `{"monitors":[],"metadata":{"total_count":%d,"page_count":1},"counts":{"status":[{"name":"Alert","count":%d},{"name":"OK","count":5}]}}`, alerts+5, alerts)
+		case r.URL.Path == "/api/v1/slo/search":
+			state := map[string]string{"us-key": "ok", "eu-key": "breached"}[org]
+			fmt.Fprintf(w, `{"data":{"attributes":{"slos":[{"data":{"id":"slo-1","type":"slo","attributes":{"name":"Checkout","overall_status":[{"state":%q,"status":99.5,"target":99.9,"error_budget_remaining":-10}]}}}]}}}`, state)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	server.orgs = map[string]OrgProfile{
+		"us":     {APIKey: "us-key", AppKey: "app"},
+		"eu":     {APIKey: "eu-key", AppKey: "app", Site: "datadoghq.eu"},
+		"broken": {APIKey: "broken-key", AppKey: "app"},
+	}
+
+	result, err := server.CompareOrgs(CompareOrgsParams{Kind: "monitors", Query: `tag:"service:checkout"`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Orgs) != 2 || result.Orgs[0].Org != "eu" || result.Orgs[1].Org != "us" {
+		t.Fatalf("expected eu and us in name order, got %+v", result.Orgs)
+	}
+	if eu := result.Orgs[0]; eu.Total != 6 || eu.ByState["Alert"] != 1 || eu.Site != "datadoghq.eu" || !strings.HasPrefix(eu.URL, "https://app.datadoghq.eu/monitors/manage") {
+		t.Fatalf("unexpected eu column: %+v", eu)
+	}
+	if us := result.Orgs[1]; us.Total != 8 || us.ByState["Alert"] != 3 || us.Site != "datadoghq.com" {
+		t.Fatalf("unexpected us column: %+v", us)
+	}
+	if _, ok := result.Errors["broken"]; !ok {
+		t.Fatalf("expected the broken org's error, got %v", result.Errors)
+	}
+
+	result, err = server.CompareOrgs(CompareOrgsParams{Kind: "slos", Orgs: []string{"us", "eu"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Orgs) != 2 || result.Orgs[0].Org != "us" || result.Orgs[1].ByState["breached"] != 1 || *result.Orgs[1].SLOs[0].ErrorBudgetRemaining != -10 {
+		t.Fatalf("unexpected SLO comparison: %+v", result.Orgs)
+	}
+
+	if _, err := server.CompareOrgs(CompareOrgsParams{Kind: "slos", Orgs: []string{"broken"}}); err == nil || !strings.Contains(err.Error(), "every org failed") {
+		t.Fatalf("expected an error when every org fails, got %v", err)
+	}
+	if _, err := server.CompareOrgs(CompareOrgsParams{Kind: "monitors", Orgs: []string{"apac"}}); err == nil {
+		t.Fatal("expected an unknown org to be rejected")
+	}
+	if _, err := server.CompareOrgs(CompareOrgsParams{Kind: "metric"}); err == nil {
+		t.Fatal("expected a metric comparison without a query to be rejected")
+	}
+}
+
+func TestLoadOrgProfiles(t *testing.T) {
+	path := writeTempFile(t, "orgs.json", `{"us": {"api_key": "a", "app_key": "b"}, "eu": {"api_key": "c"}}`)
+	if _, err := loadOrgProfiles(path); err == nil || !strings.Contains(err.Error(), `"eu"`) {
+		t.Fatalf("expected the org without an app key to be rejected, got %v", err)
+	}
+}
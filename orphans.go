@@ -0,0 +1,398 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadog"
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV2"
+)
+
+const (
+	defaultStaleMonths = 6
+	// maxOrphansPerCheck bounds each section of the report.
+	maxOrphansPerCheck  = 200
+	maxDashboardPages   = 50
+	dashboardPageSize   = 100
+	maxSLOPages         = 10
+	sloPageSize         = 1000
+	maxAuditPages       = 10
+	auditPageSize       = 1000
+	dashboardViewsQuery = "@asset.type:dashboard @action:accessed"
+)
+
+var orphanChecks = []string{"dashboards", "monitors", "slos", "facets"}
+
+// notifyHandlePattern matches @-handles in a monitor message. The @ must
+// start a word, so email addresses in prose aren't mistaken for handles.
+var notifyHandlePattern = regexp.MustCompile(`(^|[^\w])@([\w][\w.\-+/@]*)`)
+
+type OrphanAuditParams struct {
+	StaleMonths int      `json:"stale_months,omitempty"`
+	Checks      []string `json:"checks,omitempty"`
+	Facets      []string `json:"facets,omitempty"`
+	Limit       int      `json:"limit,omitempty"`
+}
+
+// OrphanedResource is one cleanup candidate and why it was flagged.
+type OrphanedResource struct {
+	ID           string `json:"id"`
+	Title        string `json:"title,omitempty"`
+	URL          string `json:"url,omitempty"`
+	Reason       string `json:"reason"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+type OrphanAuditResult struct {
+	StaleMonths int                `json:"stale_months"`
+	Since       string             `json:"since"`
+	Scanned     map[string]int     `json:"scanned"`
+	Total       int                `json:"total"`
+	Dashboards  []OrphanedResource `json:"dashboards,omitempty"`
+	Monitors    []OrphanedResource `json:"monitors,omitempty"`
+	SLOs        []OrphanedResource `json:"slos,omitempty"`
+	Facets      []OrphanedResource `json:"facets,omitempty"`
+	Notes       []string           `json:"notes,omitempty"`
+}
+
+// AuditOrphanedResources builds a cleanup report of dashboards nobody has
+// opened in StaleMonths, monitors that notify no one, SLOs built on deleted
+// monitors and log facets that no longer receive data. Each section is
+// capped at Limit entries.
+func (s *MCPServer) AuditOrphanedResources(params OrphanAuditParams) (*OrphanAuditResult, error) {
+	months := params.StaleMonths
+	if months == 0 {
+		months = defaultStaleMonths
+	}
+	if months < 1 || months > 24 {
+		return nil, fmt.Errorf("stale_months must be between 1 and 24, got %d", months)
+	}
+	limit := params.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	limit = clampArgument(s, "limit", limit, maxOrphansPerCheck)
+
+	checks := make(map[string]bool)
+	for _, c := range params.Checks {
+		c = strings.ToLower(strings.TrimSpace(c))
+		if !slices.Contains(orphanChecks, c) {
+			return nil, fmt.Errorf("unknown check %q (expected one of %s)", c, strings.Join(orphanChecks, ", "))
+		}
+		checks[c] = true
+	}
+	if len(checks) == 0 {
+		for _, c := range orphanChecks {
+			checks[c] = true
+		}
+	}
+
+	now := time.Now()
+	since := now.AddDate(0, -months, 0)
+	result := &OrphanAuditResult{
+		StaleMonths: months,
+		Since:       since.UTC().Format(time.RFC3339),
+		Scanned:     make(map[string]int),
+	}
+	add := func(section *[]OrphanedResource, r OrphanedResource) {
+		if len(*section) < limit {
+			*section = append(*section, r)
+		}
+		result.Total++
+	}
+
+	if checks["dashboards"] {
+		if err := s.auditDashboards(result, since, now, add); err != nil {
+			return nil, err
+		}
+	}
+
+	// Monitors are listed for the SLO check too, to know which still exist.
+	var monitors []datadogV1.Monitor
+	if checks["monitors"] || checks["slos"] {
+		var truncated bool
+		var err error
+		monitors, truncated, err = s.listAllMonitors()
+		if err != nil {
+			return nil, err
+		}
+		if truncated {
+			result.Notes = append(result.Notes, fmt.Sprintf("Only the first %d monitors were scanned.", len(monitors)))
+		}
+	}
+	if checks["monitors"] {
+		result.Scanned["monitors"] = len(monitors)
+		for _, m := range monitors {
+			if len(notificationHandles(m.GetMessage())) > 0 {
+				continue
+			}
+			add(&result.Monitors, OrphanedResource{
+				ID:           strconv.FormatInt(m.GetId(), 10),
+				Title:        m.GetName(),
+				URL:          s.appURL(fmt.Sprintf("/monitors/%d", m.GetId())),
+				Reason:       "The message has no @-mentions, so alerts notify no one.",
+				LastModified: formatOptionalTime(m.Modified),
+			})
+		}
+		if len(result.Monitors) > 0 {
+			result.Notes = append(result.Notes, "Monitors flagged for having no recipients may still be routed by monitor notification rules; check those before deleting.")
+		}
+	}
+	if checks["slos"] {
+		if err := s.auditSLOs(result, monitors, add); err != nil {
+			return nil, err
+		}
+	}
+	if checks["facets"] {
+		s.auditFacets(result, params.Facets, since, now, add)
+	}
+
+	if result.Total > 0 && result.Total > len(result.Dashboards)+len(result.Monitors)+len(result.SLOs)+len(result.Facets) {
+		result.Notes = append(result.Notes, fmt.Sprintf("Each section lists at most %d resources; total counts all %d candidates.", limit, result.Total))
+	}
+	return result, nil
+}
+
+// auditDashboards flags dashboards nobody has opened since since, using
+// Audit Trail view events. Without Audit Trail data it falls back to the
+// last modification time, which is a weaker signal.
+func (s *MCPServer) auditDashboards(result *OrphanAuditResult, since, now time.Time, add func(*[]OrphanedResource, OrphanedResource)) error {
+	dashboards, truncated, err := s.listAllDashboards()
+	if err != nil {
+		return err
+	}
+	if truncated {
+		result.Notes = append(result.Notes, fmt.Sprintf("Only the first %d dashboards were scanned.", len(dashboards)))
+	}
+	result.Scanned["dashboards"] = len(dashboards)
+
+	viewed, complete, err := s.dashboardViews(since, now)
+	switch {
+	case err != nil:
+		result.Notes = append(result.Notes, fmt.Sprintf("Couldn't read dashboard views from Audit Trail (%v), so dashboards are flagged by last modification instead of last view.", err))
+		viewed = nil
+	case len(viewed) == 0:
+		result.Notes = append(result.Notes, "Audit Trail returned no dashboard views; it may not be enabled. Dashboards are flagged by last modification instead of last view.")
+		viewed = nil
+	case !complete:
+		result.Notes = append(result.Notes, fmt.Sprintf("Only the first %d dashboard view events were read, so some flagged dashboards may have been viewed.", maxAuditPages*auditPageSize))
+	}
+
+	for _, d := range dashboards {
+		// Dashboards created inside the window haven't had time to go stale.
+		if d.CreatedAt != nil && d.CreatedAt.After(since) {
+			continue
+		}
+		var reason string
+		if viewed != nil {
+			if viewed[d.GetId()] {
+				continue
+			}
+			reason = "Not viewed in the period."
+		} else {
+			if d.ModifiedAt == nil || d.ModifiedAt.After(since) {
+				continue
+			}
+			reason = "Not modified in the period."
+		}
+		add(&result.Dashboards, OrphanedResource{
+			ID:           d.GetId(),
+			Title:        d.GetTitle(),
+			URL:          s.appURL(d.GetUrl()),
+			Reason:       reason,
+			LastModified: formatOptionalTime(d.ModifiedAt),
+		})
+	}
+	return nil
+}
+
+// dashboardViews returns the ids of dashboards opened between from and to
+// according to Audit Trail. complete is false when the page cap was hit.
+func (s *MCPServer) dashboardViews(from, to time.Time) (map[string]bool, bool, error) {
+	api := datadogV2.NewAuditApi(s.ddClient)
+	viewed := make(map[string]bool)
+	var cursor *string
+	for page := 0; page < maxAuditPages; page++ {
+		body := datadogV2.AuditLogsSearchEventsRequest{
+			Filter: &datadogV2.AuditLogsQueryFilter{
+				From:  datadog.PtrString(from.Format(time.RFC3339)),
+				To:    datadog.PtrString(to.Format(time.RFC3339)),
+				Query: datadog.PtrString(dashboardViewsQuery),
+			},
+			Page: &datadogV2.AuditLogsQueryPageOptions{Cursor: cursor, Limit: datadog.PtrInt32(auditPageSize)},
+		}
+		resp, _, err := api.SearchAuditLogs(s.ctx, *datadogV2.NewSearchAuditLogsOptionalParameters().WithBody(body))
+		if err != nil {
+			return nil, false, err
+		}
+		for _, event := range resp.Data {
+			if event.Attributes == nil {
+				continue
+			}
+			if asset, ok := event.Attributes.Attributes["asset"].(map[string]interface{}); ok {
+				if id, ok := asset["id"].(string); ok && id != "" {
+					viewed[id] = true
+				}
+			}
+		}
+		if resp.Meta == nil || resp.Meta.Page == nil || resp.Meta.Page.After == nil || len(resp.Data) == 0 {
+			return viewed, true, nil
+		}
+		cursor = resp.Meta.Page.After
+	}
+	return viewed, false, nil
+}
+
+// listAllMonitors lists monitors with their messages. truncated reports
+// whether the page cap was hit.
+func (s *MCPServer) listAllMonitors() ([]datadogV1.Monitor, bool, error) {
+	api := datadogV1.NewMonitorsApi(s.ddClient)
+	var monitors []datadogV1.Monitor
+	for page := int64(0); page < maxMonitorPages; page++ {
+		opts := datadogV1.NewListMonitorsOptionalParameters().WithPage(page).WithPageSize(monitorPageSize)
+		resp, _, err := api.ListMonitors(s.ctx, *opts)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to list monitors: %w", err)
+		}
+		monitors = append(monitors, resp...)
+		if len(resp) < monitorPageSize {
+			return monitors, false, nil
+		}
+	}
+	return monitors, true, nil
+}
+
+// listAllDashboards pages through every dashboard summary, up to
+// maxDashboardPages pages. The flag reports whether the list was cut short.
+func (s *MCPServer) listAllDashboards() ([]datadogV1.DashboardSummaryDefinition, bool, error) {
+	api := datadogV1.NewDashboardsApi(s.ddClient)
+	var dashboards []datadogV1.DashboardSummaryDefinition
+	for page := int64(0); page < maxDashboardPages; page++ {
+		opts := datadogV1.NewListDashboardsOptionalParameters().WithCount(dashboardPageSize).WithStart(page * dashboardPageSize)
+		resp, _, err := api.ListDashboards(s.ctx, *opts)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to list dashboards: %w", err)
+		}
+		dashboards = append(dashboards, resp.Dashboards...)
+		if len(resp.Dashboards) < dashboardPageSize {
+			return dashboards, false, nil
+		}
+	}
+	return dashboards, true, nil
+}
+
+// auditSLOs flags monitor-based SLOs that reference monitors which no
+// longer exist.
+func (s *MCPServer) auditSLOs(result *OrphanAuditResult, monitors []datadogV1.Monitor, add func(*[]OrphanedResource, OrphanedResource)) error {
+	existing := make(map[int64]bool, len(monitors))
+	for _, m := range monitors {
+		existing[m.GetId()] = true
+	}
+
+	api := datadogV1.NewServiceLevelObjectivesApi(s.ddClient)
+	scanned := 0
+	for page := int64(0); page < maxSLOPages; page++ {
+		opts := datadogV1.NewListSLOsOptionalParameters().WithLimit(sloPageSize).WithOffset(page * sloPageSize)
+		resp, _, err := api.ListSLOs(s.ctx, *opts)
+		if err != nil {
+			return fmt.Errorf("failed to list SLOs: %w", err)
+		}
+		scanned += len(resp.Data)
+		for _, slo := range resp.Data {
+			if slo.GetType() != datadogV1.SLOTYPE_MONITOR {
+				continue
+			}
+			var missing []string
+			for _, id := range slo.MonitorIds {
+				if !existing[id] {
+					missing = append(missing, strconv.FormatInt(id, 10))
+				}
+			}
+			if len(missing) == 0 {
+				continue
+			}
+			reason := fmt.Sprintf("References deleted monitors: %s.", strings.Join(missing, ", "))
+			if len(missing) == len(slo.MonitorIds) {
+				reason = fmt.Sprintf("All of its monitors were deleted (%s), so it no longer measures anything.", strings.Join(missing, ", "))
+			}
+			var modified string
+			if slo.ModifiedAt != nil {
+				modified = time.Unix(*slo.ModifiedAt, 0).UTC().Format(time.RFC3339)
+			}
+			add(&result.SLOs, OrphanedResource{
+				ID:           slo.GetId(),
+				Title:        slo.GetName(),
+				URL:          s.appURL("/slo?slo_id=" + slo.GetId()),
+				Reason:       reason,
+				LastModified: modified,
+			})
+		}
+		if len(resp.Data) < sloPageSize {
+			break
+		}
+		if page == maxSLOPages-1 {
+			result.Notes = append(result.Notes, fmt.Sprintf("Only the first %d SLOs were scanned.", scanned))
+		}
+	}
+	result.Scanned["slos"] = scanned
+	return nil
+}
+
+// auditFacets flags facets that no log in the period carries. Datadog's
+// API doesn't list facets, so the caller names the ones to check.
+func (s *MCPServer) auditFacets(result *OrphanAuditResult, facets []string, since, now time.Time, add func(*[]OrphanedResource, OrphanedResource)) {
+	if len(facets) == 0 {
+		result.Notes = append(result.Notes, "Datadog's API doesn't list log facets; pass facets to check which of them still receive data.")
+		return
+	}
+	seen := make(map[string]bool)
+	for _, facet := range facets {
+		facet = strings.TrimPrefix(strings.TrimSpace(facet), "@")
+		if facet == "" || seen[facet] {
+			continue
+		}
+		seen[facet] = true
+		exists, err := s.logsExist("@"+facet+":*", since, now)
+		if err != nil {
+			result.Notes = append(result.Notes, fmt.Sprintf("Couldn't check @%s: %v", facet, err))
+			continue
+		}
+		if !exists {
+			add(&result.Facets, OrphanedResource{
+				ID:     "@" + facet,
+				Reason: "No logs in the period carry this attribute.",
+			})
+		}
+	}
+	result.Scanned["facets"] = len(seen)
+	if len(seen) > 0 {
+		result.Notes = append(result.Notes, "Facets are checked against logs still in retention, which may be shorter than the period.")
+	}
+}
+
+// notificationHandles returns the @-handles in a monitor message that
+// route notifications. Slack's @here, @channel and @all only widen a
+// notification that another handle already sends.
+func notificationHandles(message string) []string {
+	var handles []string
+	for _, match := range notifyHandlePattern.FindAllStringSubmatch(message, -1) {
+		switch strings.ToLower(match[2]) {
+		case "here", "channel", "all":
+			continue
+		}
+		handles = append(handles, match[2])
+	}
+	return handles
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNotificationHandles(t *testing.T) {
+	got := notificationHandles("{{#is_alert}}@pagerduty-web @slack-ops{{/is_alert}} @here mail oncall@example.com or @team@example.com")
+	if !reflect.DeepEqual(got, []string{"pagerduty-web", "slack-ops", "team@example.com"}) {
+		t.Fatalf("unexpected handles: %v", got)
+	}
+	if got := notificationHandles("CPU is high. @here"); len(got) != 0 {
+		t.Fatalf("expected @here alone to notify no one, got %v", got)
+	}
+}
+
+func TestAuditOrphanedResources(t *testing.T) {
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/dashboard":
+			_, _ = w.Write([]byte(`{"dashboards":[
+				{"id":"abc-123","title":"Old","url":"/dashboard/abc-123/old","created_at":"2020-01-01T00:00:00Z","modified_at":"2020-02-01T00:00:00Z"},
+				{"id":"def-456","title":"Busy","url":"/dashboard/def-456/busy","created_at":"2020-01-01T00:00:00Z","modified_at":"2020-02-01T00:00:00Z"}]}`))
+		case "/api/v2/audit/events/search":
+			_, _ = w.Write([]byte(`{"data":[{"id":"1","attributes":{"attributes":{"asset":{"id":"def-456","type":"dashboard"}}}}]}`))
+		case "/api/v1/monitor":
+			_, _ = w.Write([]byte(`[
+				{"id":1,"name":"Silent","type":"metric alert","query":"q","message":"CPU high"},
+				{"id":2,"name":"Paged","type":"metric alert","query":"q","message":"CPU high @pagerduty-web"}]`))
+		case "/api/v1/slo":
+			_, _ = w.Write([]byte(`{"data":[
+				{"id":"slo-1","name":"Broken","type":"monitor","monitor_ids":[2,99],"thresholds":[]},
+				{"id":"slo-2","name":"Fine","type":"monitor","monitor_ids":[2],"thresholds":[]}]}`))
+		case "/api/v2/logs/events/search":
+			_, _ = w.Write([]byte(`{"data":[]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	result, err := server.AuditOrphanedResources(OrphanAuditParams{Facets: []string{"@legacy.field"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Dashboards) != 1 || result.Dashboards[0].ID != "abc-123" || result.Dashboards[0].URL != "https://app.datadoghq.com/dashboard/abc-123/old" {
+		t.Fatalf("expected only the unviewed dashboard, got %+v", result.Dashboards)
+	}
+	if len(result.Monitors) != 1 || result.Monitors[0].ID != "1" {
+		t.Fatalf("expected only the silent monitor, got %+v", result.Monitors)
+	}
+	if len(result.SLOs) != 1 || result.SLOs[0].ID != "slo-1" || !strings.Contains(result.SLOs[0].Reason, "99") {
+		t.Fatalf("expected the SLO on a deleted monitor, got %+v", result.SLOs)
+	}
+	if len(result.Facets) != 1 || result.Facets[0].ID != "@legacy.field" {
+		t.Fatalf("expected the unused facet, got %+v", result.Facets)
+	}
+	if result.Total != 4 || result.Scanned["dashboards"] != 2 || result.Scanned["slos"] != 2 {
+		t.Fatalf("unexpected totals: %d %v", result.Total, result.Scanned)
+	}
+
+	if _, err := server.AuditOrphanedResources(OrphanAuditParams{Checks: []string{"notebooks"}}); err == nil {
+		t.Fatal("expected an unknown check to be rejected")
+	}
+}
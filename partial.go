@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+)
+
+// PartialData reports records Datadog returned without fields a result
+// normally carries, so an empty value in the result can be told apart from
+// a response whose shape changed.
+type PartialData struct {
+	// Records is how many records lacked at least one field.
+	Records int `json:"records"`
+	// Missing counts the records lacking each field.
+	Missing map[string]int `json:"missing"`
+}
+
+// record notes one record missing the given fields. It allocates on first
+// use, so callers keep a nil *PartialData until something is missing:
+//
+//	partial = partial.record(missing...)
+func (p *PartialData) record(fields ...string) *PartialData {
+	if len(fields) == 0 {
+		return p
+	}
+	if p == nil {
+		p = &PartialData{Missing: make(map[string]int)}
+	}
+	p.Records++
+	for _, field := range fields {
+		p.Missing[field]++
+	}
+	return p
+}
+
+// missingFields names the fields whose value is unset, given as
+// name/present pairs in a fixed order.
+func missingFields(fields ...fieldCheck) []string {
+	var missing []string
+	for _, f := range fields {
+		if !f.present {
+			missing = append(missing, f.name)
+		}
+	}
+	return missing
+}
+
+type fieldCheck struct {
+	name    string
+	present bool
+}
+
+// recoverToolPanic turns a panic in a tool into an internal error, so one
+// malformed response can't take the whole server down.
+func recoverToolPanic(tool string, toolErr **MCPError) {
+	if r := recover(); r != nil {
+		log.Printf("panic in tool %s: %v\n%s", tool, r, debug.Stack())
+		*toolErr = &MCPError{Code: -32603, Message: fmt.Sprintf("internal error in %s: %v", tool, r)}
+	}
+}
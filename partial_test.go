@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestPartialDataRecord(t *testing.T) {
+	var partial *PartialData
+	if partial = partial.record(); partial != nil {
+		t.Fatal("expected no allocation when nothing is missing")
+	}
+	partial = partial.record("attributes")
+	partial = partial.record(missingFields(fieldCheck{"id", true}, fieldCheck{"timestamp", false}, fieldCheck{"status", false})...)
+	if partial.Records != 2 || partial.Missing["attributes"] != 1 || partial.Missing["timestamp"] != 1 || partial.Missing["id"] != 0 {
+		t.Fatalf("unexpected partial data: %+v", partial)
+	}
+}
+
+func TestQueryLogsReportsMissingAttributes(t *testing.T) {
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":[
+			{"id":"1","type":"log"},
+			{"id":"2","type":"log","attributes":{"message":"ok","status":"info","timestamp":"2026-01-20T10:00:00Z"}}]}`))
+	})
+
+	result, err := server.QueryLogs(QueryLogsParams{Query: "*", From: "2026-01-20T09:00:00Z", To: "2026-01-20T11:00:00Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Count != 2 || result.Logs[0].ID != "1" || result.Logs[0].Timestamp != nil {
+		t.Fatalf("expected the log without attributes returned empty, got %+v", result.Logs)
+	}
+	if result.Partial == nil || result.Partial.Records != 1 || result.Partial.Missing["attributes"] != 1 {
+		t.Fatalf("expected one log reported as partial, got %+v", result.Partial)
+	}
+}
+
+func TestQuerySpansReportsMissingFields(t *testing.T) {
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":[{"id":"s1","type":"spans"},{"id":"s2","type":"spans","attributes":{"trace_id":"abc","service":"checkout"}}]}`))
+	})
+
+	result, err := server.QuerySpans(QuerySpansParams{Query: "*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Count != 2 || result.Partial == nil || result.Partial.Records != 2 || result.Partial.Missing["attributes"] != 1 || result.Partial.Missing["resource_name"] != 1 {
+		t.Fatalf("unexpected partial data: %+v", result.Partial)
+	}
+}
+
+func TestRecoverToolPanic(t *testing.T) {
+	call := func() (toolErr *MCPError) {
+		defer recoverToolPanic("query_logs", &toolErr)
+		var attrs *struct{ Message string }
+		_ = attrs.Message
+		return nil
+	}
+	err := call()
+	if err == nil || err.Code != -32603 {
+		t.Fatalf("expected the panic as an internal error, got %+v", err)
+	}
+}
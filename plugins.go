@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPluginTimeout = 30 * time.Second
+	// maxPluginOutputBytes bounds what a plugin may write to stdout.
+	maxPluginOutputBytes = 8 << 20
+)
+
+// PluginConfig registers an external executable that provides tools. The
+// plugin runs with only PATH and the configured Env, so it never sees this
+// server's Datadog keys.
+type PluginConfig struct {
+	Command string            `json:"command"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+	Timeout string            `json:"timeout,omitempty"`
+}
+
+// PluginCall is written to a plugin's stdin when one of its tools is
+// called.
+type PluginCall struct {
+	Tool      string          `json:"tool"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+// PluginResult is what a plugin writes to stdout in reply to a call.
+type PluginResult struct {
+	Text  string `json:"text"`
+	Error string `json:"error,omitempty"`
+}
+
+type plugin struct {
+	config  PluginConfig
+	timeout time.Duration
+}
+
+// pluginRegistry maps plugin tool names to the plugin that serves them.
+// A nil registry has no tools.
+type pluginRegistry struct {
+	tools  []Tool
+	byName map[string]*plugin
+}
+
+// loadPlugins reads the plugin config file and asks each plugin to
+// describe its tools. Tool names may not shadow built-in tools or each
+// other.
+func loadPlugins(path string, builtin []Tool) (*pluginRegistry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugins file: %w", err)
+	}
+	var file struct {
+		Plugins []PluginConfig `json:"plugins"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse plugins file: %w", err)
+	}
+
+	registry := &pluginRegistry{byName: make(map[string]*plugin)}
+	taken := make(map[string]bool)
+	for _, tool := range builtin {
+		taken[tool.Name] = true
+	}
+
+	for _, config := range file.Plugins {
+		p, err := newPlugin(config)
+		if err != nil {
+			return nil, err
+		}
+		tools, err := p.describe(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("plugin %s: %w", config.Command, err)
+		}
+		for _, tool := range tools {
+			if tool.Name == "" {
+				return nil, fmt.Errorf("plugin %s: tool without a name", config.Command)
+			}
+			if taken[tool.Name] {
+				return nil, fmt.Errorf("plugin %s: tool %s is already defined", config.Command, tool.Name)
+			}
+			taken[tool.Name] = true
+			registry.tools = append(registry.tools, tool)
+			registry.byName[tool.Name] = p
+		}
+	}
+	return registry, nil
+}
+
+func newPlugin(config PluginConfig) (*plugin, error) {
+	if config.Command == "" {
+		return nil, fmt.Errorf("plugin entry is missing command")
+	}
+	timeout := defaultPluginTimeout
+	if config.Timeout != "" {
+		t, err := time.ParseDuration(config.Timeout)
+		if err != nil || t <= 0 {
+			return nil, fmt.Errorf("plugin %s: invalid timeout %q", config.Command, config.Timeout)
+		}
+		timeout = t
+	}
+	return &plugin{config: config, timeout: timeout}, nil
+}
+
+func (r *pluginRegistry) list() []Tool {
+	if r == nil {
+		return nil
+	}
+	return r.tools
+}
+
+func (r *pluginRegistry) lookup(name string) (*plugin, bool) {
+	if r == nil {
+		return nil, false
+	}
+	p, ok := r.byName[name]
+	return p, ok
+}
+
+// describe runs "<command> <args> describe", which must print a JSON array
+// of tools in the same shape as tools/list.
+func (p *plugin) describe(ctx context.Context) ([]Tool, error) {
+	out, err := p.run(ctx, "describe", nil)
+	if err != nil {
+		return nil, err
+	}
+	var tools []Tool
+	if err := json.Unmarshal(out, &tools); err != nil {
+		return nil, fmt.Errorf("invalid describe output: %w", err)
+	}
+	return tools, nil
+}
+
+// call runs "<command> <args> call" with a PluginCall on stdin and expects
+// a PluginResult on stdout.
+func (p *plugin) call(ctx context.Context, tool string, arguments json.RawMessage) (string, error) {
+	input, err := json.Marshal(PluginCall{Tool: tool, Arguments: arguments})
+	if err != nil {
+		return "", err
+	}
+	out, err := p.run(ctx, "call", input)
+	if err != nil {
+		return "", fmt.Errorf("tool %s failed: %w", tool, err)
+	}
+
+	var result PluginResult
+	if err := json.Unmarshal(out, &result); err != nil {
+		return "", fmt.Errorf("tool %s returned invalid output: %w", tool, err)
+	}
+	if result.Error != "" {
+		return "", errors.New(result.Error)
+	}
+	return result.Text, nil
+}
+
+func (p *plugin) run(ctx context.Context, subcommand string, input []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	args := append(append([]string{}, p.config.Args...), subcommand)
+	cmd := exec.CommandContext(ctx, p.config.Command, args...)
+	cmd.Env = []string{"PATH=" + os.Getenv("PATH")}
+	for k, v := range p.config.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	cmd.Stdin = bytes.NewReader(input)
+	// Don't wait on children that outlive a killed plugin and hold its
+	// output open.
+	cmd.WaitDelay = time.Second
+
+	stdout := &cappedBuffer{limit: maxPluginOutputBytes}
+	stderr := &cappedBuffer{limit: 4096, truncate: true}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("timed out after %s", p.timeout)
+		}
+		if msg := strings.TrimSpace(stderr.buf.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	return stdout.buf.Bytes(), nil
+}
+
+// cappedBuffer collects output up to limit bytes. Beyond that, writes fail,
+// which stops a runaway plugin, or with truncate are silently dropped.
+type cappedBuffer struct {
+	limit    int
+	truncate bool
+	buf      bytes.Buffer
+}
+
+func (c *cappedBuffer) Write(b []byte) (int, error) {
+	if c.buf.Len()+len(b) > c.limit {
+		if !c.truncate {
+			return 0, fmt.Errorf("output exceeds %d bytes", c.limit)
+		}
+		c.buf.Write(b[:max(c.limit-c.buf.Len(), 0)])
+		return len(b), nil
+	}
+	return c.buf.Write(b)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+const testPluginScript = `#!/bin/sh
+case "$1" in
+describe)
+  echo '[{"name":"cmdb_lookup","description":"Look up a host in the CMDB","inputSchema":{"type":"object","properties":{"host":{"type":"string","description":"Host name"}},"required":["host"]}}]'
+  ;;
+call)
+  input=$(cat)
+  case "$input" in
+  *fail*) echo '{"error":"host not found"}' ;;
+  *) printf '{"text":"owner=team-a key=%s greeting=%s"}' "$DD_API_KEY" "$GREETING" ;;
+  esac
+  ;;
+esac
+`
+
+func writeTestPlugin(t *testing.T, script string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin tests use a shell script")
+	}
+	dir := t.TempDir()
+	command := filepath.Join(dir, "plugin.sh")
+	if err := os.WriteFile(command, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return command
+}
+
+func writePluginsFile(t *testing.T, plugins ...PluginConfig) string {
+	t.Helper()
+	data, _ := json.Marshal(map[string]interface{}{"plugins": plugins})
+	return writeTempFile(t, "plugins.json", string(data))
+}
+
+func TestPluginTools(t *testing.T) {
+	t.Setenv("DD_API_KEY", "secret")
+	command := writeTestPlugin(t, testPluginScript)
+	registry, err := loadPlugins(writePluginsFile(t, PluginConfig{
+		Command: command,
+		Env:     map[string]string{"GREETING": "hi"},
+	}), (&MCPServer{}).ListTools())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	server := &MCPServer{plugins: registry}
+	tools := server.ListTools()
+	if last := tools[len(tools)-1]; last.Name != "cmdb_lookup" || last.InputSchema.Properties["host"].Type != "string" {
+		t.Errorf("expected plugin tool to be listed, got %+v", last)
+	}
+
+	params, _ := json.Marshal(ToolCallParams{Name: "cmdb_lookup", Arguments: json.RawMessage(`{"host":"web-1"}`)})
+	resp := server.HandleRequest(MCPRequest{Jsonrpc: "2.0", ID: 1, Method: "tools/call", Params: params})
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %v", resp.Error.Message)
+	}
+	var result ToolCallResult
+	json.Unmarshal(resp.Result, &result)
+	if text := result.Content[0].Text; text != "owner=team-a key= greeting=hi" {
+		t.Errorf("expected plugin output without Datadog keys, got %q", text)
+	}
+
+	params, _ = json.Marshal(ToolCallParams{Name: "cmdb_lookup", Arguments: json.RawMessage(`{"host":"fail"}`)})
+	resp = server.HandleRequest(MCPRequest{Jsonrpc: "2.0", ID: 2, Method: "tools/call", Params: params})
+	if resp.Error == nil || resp.Error.Message != "host not found" {
+		t.Errorf("expected plugin error to be returned, got %+v", resp.Error)
+	}
+}
+
+func TestLoadPluginsRejectsShadowing(t *testing.T) {
+	command := writeTestPlugin(t, `#!/bin/sh
+echo '[{"name":"query_logs","description":"shadow"}]'
+`)
+	_, err := loadPlugins(writePluginsFile(t, PluginConfig{Command: command}), (&MCPServer{}).ListTools())
+	if err == nil || !strings.Contains(err.Error(), "already defined") {
+		t.Errorf("expected shadowing error, got %v", err)
+	}
+}
+
+func TestPluginTimeout(t *testing.T) {
+	command := writeTestPlugin(t, `#!/bin/sh
+sleep 5
+`)
+	p, err := newPlugin(PluginConfig{Command: command, Timeout: "50ms"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := p.describe(t.Context()); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected timeout error, got %v", err)
+	}
+}
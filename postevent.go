@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadog"
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+)
+
+// Limits the v1 events intake enforces.
+const (
+	maxEventTitle          = 100
+	maxEventText           = 4000
+	maxEventAggregationKey = 100
+)
+
+var eventAlertTypes = map[string]datadogV1.EventAlertType{
+	"info":    datadogV1.EVENTALERTTYPE_INFO,
+	"success": datadogV1.EVENTALERTTYPE_SUCCESS,
+	"warning": datadogV1.EVENTALERTTYPE_WARNING,
+	"error":   datadogV1.EVENTALERTTYPE_ERROR,
+}
+
+type PostEventParams struct {
+	Title          string   `json:"title"`
+	Text           string   `json:"text,omitempty"`
+	Tags           []string `json:"tags,omitempty"`
+	AggregationKey string   `json:"aggregation_key,omitempty"`
+	AlertType      string   `json:"alert_type,omitempty"`
+}
+
+type PostEventResult struct {
+	EventID string   `json:"event_id"`
+	URL     string   `json:"url,omitempty"`
+	Title   string   `json:"title"`
+	Tags    []string `json:"tags,omitempty"`
+}
+
+// PostEvent annotates the event stream with a free-form event whose body
+// is rendered as markdown.
+func (s *MCPServer) PostEvent(params PostEventParams) (*PostEventResult, error) {
+	if err := s.requireWrites("post_event"); err != nil {
+		return nil, err
+	}
+	body, err := buildEvent(params, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	api := datadogV1.NewEventsApi(s.ddClient)
+	resp, _, err := api.CreateEvent(s.ctx, *body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to post event: %w", err)
+	}
+
+	result := &PostEventResult{Title: body.Title, Tags: body.Tags}
+	if resp.Event != nil {
+		result.EventID = resp.Event.GetIdStr()
+		result.URL = resp.Event.GetUrl()
+	}
+	return result, nil
+}
+
+func buildEvent(params PostEventParams, now time.Time) (*datadogV1.EventCreateRequest, error) {
+	title := strings.TrimSpace(params.Title)
+	if title == "" {
+		return nil, fmt.Errorf("title parameter is required")
+	}
+	if utf8.RuneCountInString(title) > maxEventTitle {
+		return nil, fmt.Errorf("title must be at most %d characters", maxEventTitle)
+	}
+	// The markdown markers count against the text limit.
+	if utf8.RuneCountInString(params.Text) > maxEventText-len("%%% \n\n %%%") {
+		return nil, fmt.Errorf("text must be at most %d characters", maxEventText-len("%%% \n\n %%%"))
+	}
+	if len(params.AggregationKey) > maxEventAggregationKey {
+		return nil, fmt.Errorf("aggregation_key must be at most %d characters", maxEventAggregationKey)
+	}
+	alertType := datadogV1.EVENTALERTTYPE_INFO
+	if params.AlertType != "" {
+		t, ok := eventAlertTypes[strings.ToLower(params.AlertType)]
+		if !ok {
+			return nil, fmt.Errorf("invalid alert_type: %s (use info, success, warning or error)", params.AlertType)
+		}
+		alertType = t
+	}
+
+	body := &datadogV1.EventCreateRequest{
+		Title:        title,
+		Text:         "%%% \n" + params.Text + "\n %%%",
+		Tags:         params.Tags,
+		AlertType:    alertType.Ptr(),
+		DateHappened: datadog.PtrInt64(now.Unix()),
+	}
+	if params.AggregationKey != "" {
+		body.AggregationKey = datadog.PtrString(params.AggregationKey)
+	}
+	return body, nil
+}
+
+// postEventTool is registered only when writes are enabled.
+var postEventTool = Tool{
+	Name:        "post_event",
+	Description: "Post an event to the Datadog event stream with a title, markdown body, tags and aggregation key, to annotate an investigation or change",
+	InputSchema: InputSchema{
+		Type: "object",
+		Properties: map[string]SchemaProperty{
+			"title": {
+				Type:        "string",
+				Description: "Event title (max 100 characters)",
+			},
+			"text": {
+				Type:        "string",
+				Description: "Event body in markdown",
+			},
+			"tags": {
+				Type:        "array",
+				Description: "Event tags (e.g., 'service:checkout', 'env:prod')",
+				Items:       &SchemaProperty{Type: "string"},
+			},
+			"aggregation_key": {
+				Type:        "string",
+				Description: "Groups related events in the event stream (max 100 characters)",
+			},
+			"alert_type": {
+				Type:        "string",
+				Description: "info, success, warning or error (default: info)",
+			},
+			"confirm": confirmProperty,
+		},
+		Required: []string{"title", "confirm"},
+	},
+	Annotations: writeToolAnnotations(false),
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildEvent(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	event, err := buildEvent(PostEventParams{
+		Title:          "Rolled back checkout",
+		Text:           "**Why:** error rate spiked",
+		Tags:           []string{"service:checkout"},
+		AggregationKey: "incident-42",
+		AlertType:      "Warning",
+	}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Text != "%%% \n**Why:** error rate spiked\n %%%" || event.GetAggregationKey() != "incident-42" || event.GetAlertType() != "warning" || event.GetDateHappened() != now.Unix() {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+
+	for _, params := range []PostEventParams{
+		{},
+		{Title: strings.Repeat("x", 101)},
+		{Title: "t", AggregationKey: strings.Repeat("k", 101)},
+		{Title: "t", Text: strings.Repeat("x", 4000)},
+		{Title: "t", AlertType: "critical"},
+	} {
+		if _, err := buildEvent(params, now); err == nil {
+			t.Errorf("expected %+v to be rejected", params)
+		}
+	}
+}
+
+func TestPostEvent(t *testing.T) {
+	var posted map[string]interface{}
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v1/events" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&posted)
+		_, _ = w.Write([]byte(`{"status":"ok","event":{"id_str":"99","url":"https://app.datadoghq.com/event/event?id=99"}}`))
+	})
+
+	if _, err := server.PostEvent(PostEventParams{Title: "Note"}); err == nil || !strings.Contains(err.Error(), "DD_MCP_ALLOW_WRITES") {
+		t.Fatalf("expected the write gate to refuse the call, got %v", err)
+	}
+
+	server.allowWrites = true
+	result, err := server.PostEvent(PostEventParams{Title: "Note", Tags: []string{"env:prod"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.EventID != "99" || posted["title"] != "Note" {
+		t.Fatalf("unexpected result %+v for posted %v", result, posted)
+	}
+}
+
+func TestWriteToolsAreAnnotated(t *testing.T) {
+	server := &MCPServer{allowWrites: true}
+	var postEvent *Tool
+	for _, tool := range server.ListTools() {
+		if tool.Name == "post_event" {
+			postEvent = &tool
+		}
+		if _, ok := tool.InputSchema.Properties["confirm"]; ok && tool.Annotations == nil {
+			t.Errorf("write tool %s has no annotations", tool.Name)
+		}
+	}
+	if postEvent == nil {
+		t.Fatal("expected post_event to be listed when writes are enabled")
+	}
+	if data, _ := json.Marshal(postEvent); !strings.Contains(string(data), `"annotations":{"readOnlyHint":false,"destructiveHint":false}`) {
+		t.Fatalf("expected post_event to be marked as a write, got %s", data)
+	}
+	for _, tool := range (&MCPServer{}).ListTools() {
+		if tool.Name == "post_event" {
+			t.Fatal("expected post_event to be hidden in read-only mode")
+		}
+	}
+}
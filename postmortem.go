@@ -0,0 +1,427 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+)
+
+const (
+	// postmortemLookback widens the incident window backwards, since the
+	// deploy or alert that started an incident comes before it is declared.
+	postmortemLookback = time.Hour
+	// postmortemLogSample is how many error logs are grouped into patterns.
+	postmortemLogSample = 1000
+	maxLogPatterns      = 10
+	maxPatternLength    = 160
+)
+
+var (
+	uuidPattern   = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+	hexIDPattern  = regexp.MustCompile(`\b(?:0x)?[0-9a-fA-F]{12,}\b`)
+	numberPattern = regexp.MustCompile(`\d+(?:\.\d+)?`)
+	spacePattern  = regexp.MustCompile(`\s+`)
+)
+
+type GeneratePostmortemParams struct {
+	IncidentID string `json:"incident_id"`
+	// Services overrides the services named on the incident.
+	Services []string `json:"services,omitempty"`
+	Env      string   `json:"env,omitempty"`
+	// Attach saves the draft as a notebook and attaches it to the
+	// incident as its postmortem. It needs writes enabled and Confirm.
+	Attach  bool `json:"attach,omitempty"`
+	Confirm bool `json:"confirm,omitempty"`
+}
+
+// LogPattern is a group of error logs whose messages differ only in IDs
+// and numbers.
+type LogPattern struct {
+	Pattern  string   `json:"pattern"`
+	Count    int      `json:"count"`
+	Services []string `json:"services,omitempty"`
+	Example  string   `json:"example"`
+}
+
+// postmortemDraft is what the draft is written from.
+type postmortemDraft struct {
+	Incident    *Incident
+	Timeline    *IncidentTimeline
+	Services    []string
+	From, To    time.Time
+	Monitors    []MonitorSummary
+	Deployments []EventEntry
+	LogPatterns []LogPattern
+	LogsSampled int
+	Notes       []string
+	Generated   time.Time
+}
+
+// GeneratePostmortem drafts a postmortem for an incident from its
+// timeline, the monitors that triggered, the deployments made and the
+// most common error logs around it. The draft is returned as markdown
+// and kept as a resource; with attach it is also saved to a notebook
+// attached to the incident. A source that can't be read is noted and
+// the rest of the draft is still written.
+func (s *MCPServer) GeneratePostmortem(params GeneratePostmortemParams) (string, error) {
+	if params.Attach {
+		if err := s.requireWrites("generate_postmortem"); err != nil {
+			return "", err
+		}
+		if !params.Confirm {
+			return "", fmt.Errorf("attach requires confirm: true")
+		}
+	}
+	incident, err := s.GetIncident(GetIncidentParams{IncidentID: params.IncidentID})
+	if err != nil {
+		return "", err
+	}
+
+	draft := &postmortemDraft{Incident: incident, Generated: time.Now().UTC()}
+	draft.From, draft.To = postmortemWindow(incident, draft.Generated)
+	draft.Services = params.Services
+	if len(draft.Services) == 0 {
+		draft.Services = incident.Fields["services"]
+	}
+	s.reportProgress(0, 4, "Reading the incident timeline", "")
+	draft.Timeline = s.incidentTimeline(incident)
+	if len(draft.Services) == 0 {
+		draft.Notes = append(draft.Notes, "The incident names no services, so monitors, deployments and logs weren't searched; pass services to include them.")
+	} else {
+		s.reportProgress(1, 4, "Finding monitors that triggered", "")
+		s.postmortemMonitors(draft)
+		s.reportProgress(2, 4, "Finding deployments", "")
+		s.postmortemDeployments(draft, params.Env)
+		s.reportProgress(3, 4, "Grouping error logs", "")
+		s.postmortemLogPatterns(draft, params.Env)
+	}
+
+	markdown := formatPostmortemMarkdown(draft)
+	uri := fmt.Sprintf("datadog://incidents/%s/postmortem", incident.ID)
+	s.documents.put(s.apiOrg(), Resource{
+		URI:         uri,
+		Name:        "Postmortem draft: " + incidentTitle(incident.IncidentSummary),
+		Description: fmt.Sprintf("Generated %s from Datadog data", draft.Generated.Format(time.RFC3339)),
+		MimeType:    "text/markdown",
+	}, markdown)
+
+	var footer []string
+	footer = append(footer, fmt.Sprintf("Draft saved as resource %s.", uri))
+	if params.Attach {
+		notebookURL, err := s.attachPostmortem(incident, draft, markdown)
+		if err != nil {
+			return "", err
+		}
+		footer = append(footer, fmt.Sprintf("Attached to the incident as its postmortem: %s", notebookURL))
+	}
+	return markdown + "\n---\n\n_" + strings.Join(footer, " ") + "_\n", nil
+}
+
+// postmortemWindow is the span the draft searches: from an hour before
+// the incident's earliest milestone to its resolution, or now.
+func postmortemWindow(incident *Incident, now time.Time) (time.Time, time.Time) {
+	from, to := now, now
+	for _, value := range []string{incident.Created, incident.Declared, incident.Detected, incident.CustomerImpactStart} {
+		if t, err := time.Parse(time.RFC3339, value); err == nil && t.Before(from) {
+			from = t
+		}
+	}
+	if resolved, err := time.Parse(time.RFC3339, incident.Resolved); err == nil {
+		to = resolved
+	}
+	return from.Add(-postmortemLookback).UTC(), to.UTC()
+}
+
+// postmortemMonitors adds the services' monitors that triggered in the
+// window to the draft and its timeline.
+func (s *MCPServer) postmortemMonitors(draft *postmortemDraft) {
+	for _, service := range draft.Services {
+		result, err := s.ListMonitors(ListMonitorsParams{Tags: []string{"service:" + service}, PerPage: maxMonitorsPerPage})
+		if err != nil {
+			draft.Notes = append(draft.Notes, fmt.Sprintf("Couldn't read monitors for %s: %v", service, err))
+			continue
+		}
+		for _, monitor := range result.Monitors {
+			triggered, err := time.Parse(time.RFC3339, monitor.LastTriggered)
+			if err != nil || triggered.Before(draft.From) || triggered.After(draft.To) {
+				continue
+			}
+			if slices.ContainsFunc(draft.Monitors, func(m MonitorSummary) bool { return m.ID == monitor.ID }) {
+				continue
+			}
+			draft.Monitors = append(draft.Monitors, monitor)
+			draft.Timeline.Entries = append(draft.Timeline.Entries, TimelineEntry{Time: monitor.LastTriggered, Kind: "monitor", Text: "Monitor triggered: " + monitor.Name})
+		}
+	}
+	sort.SliceStable(draft.Monitors, func(i, j int) bool {
+		return draft.Monitors[i].LastTriggered < draft.Monitors[j].LastTriggered
+	})
+	sortTimeline(draft.Timeline)
+	if len(draft.Monitors) > 0 {
+		draft.Notes = append(draft.Notes, "Monitors show when they last triggered, so one that triggered again after the incident isn't listed.")
+	}
+}
+
+// postmortemDeployments adds the services' deployments in the window,
+// as recorded by record_deployment, to the draft and its timeline.
+func (s *MCPServer) postmortemDeployments(draft *postmortemDraft, env string) {
+	for _, service := range draft.Services {
+		tags := []string{deploymentEventTag, "service:" + service}
+		if env != "" {
+			tags = append(tags, "env:"+env)
+		}
+		result, err := s.QueryEvents(QueryEventsParams{
+			Tags:  tags,
+			From:  draft.From.Format(time.RFC3339),
+			To:    draft.To.Format(time.RFC3339),
+			Limit: 50,
+		})
+		if err != nil {
+			draft.Notes = append(draft.Notes, fmt.Sprintf("Couldn't read deployments for %s: %v", service, err))
+			continue
+		}
+		for _, event := range result.Events {
+			draft.Deployments = append(draft.Deployments, event)
+			if event.Timestamp != nil {
+				draft.Timeline.Entries = append(draft.Timeline.Entries, TimelineEntry{Time: formatOptionalTime(event.Timestamp), Kind: "deployment", Text: event.Title})
+			}
+		}
+	}
+	sort.SliceStable(draft.Deployments, func(i, j int) bool {
+		a, b := draft.Deployments[i].Timestamp, draft.Deployments[j].Timestamp
+		return a != nil && (b == nil || a.Before(*b))
+	})
+	sortTimeline(draft.Timeline)
+}
+
+// postmortemLogPatterns groups a sample of the services' error logs in
+// the window into patterns.
+func (s *MCPServer) postmortemLogPatterns(draft *postmortemDraft, env string) {
+	query := fmt.Sprintf("service:(%s) status:error", strings.Join(draft.Services, " OR "))
+	if env != "" {
+		query += " env:" + env
+	}
+	result, err := s.QueryLogs(QueryLogsParams{
+		Query: query,
+		From:  draft.From.Format(time.RFC3339),
+		To:    draft.To.Format(time.RFC3339),
+		Limit: postmortemLogSample,
+	})
+	if err != nil {
+		draft.Notes = append(draft.Notes, fmt.Sprintf("Couldn't read error logs: %v", err))
+		return
+	}
+	draft.LogsSampled = len(result.Logs)
+	draft.LogPatterns = logPatterns(result.Logs, maxLogPatterns)
+	if draft.LogsSampled >= postmortemLogSample {
+		draft.Notes = append(draft.Notes, fmt.Sprintf("Log patterns are from the newest %d error logs in the window.", postmortemLogSample))
+	}
+}
+
+// logPatterns groups logs by their message with IDs and numbers masked,
+// most common first.
+func logPatterns(logs []LogEntry, limit int) []LogPattern {
+	index := make(map[string]int)
+	var patterns []LogPattern
+	for _, entry := range logs {
+		pattern := logPattern(entry.Message)
+		if pattern == "" {
+			continue
+		}
+		i, ok := index[pattern]
+		if !ok {
+			i = len(patterns)
+			index[pattern] = i
+			patterns = append(patterns, LogPattern{Pattern: pattern, Example: entry.Message})
+		}
+		patterns[i].Count++
+		if entry.Service != "" && !slices.Contains(patterns[i].Services, entry.Service) {
+			patterns[i].Services = append(patterns[i].Services, entry.Service)
+		}
+	}
+	sort.SliceStable(patterns, func(i, j int) bool { return patterns[i].Count > patterns[j].Count })
+	if len(patterns) > limit {
+		patterns = patterns[:limit]
+	}
+	return patterns
+}
+
+// logPattern masks the parts of a message's first line that vary between
+// occurrences of the same error.
+func logPattern(message string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	line = uuidPattern.ReplaceAllString(line, "<id>")
+	line = hexIDPattern.ReplaceAllString(line, "<id>")
+	line = numberPattern.ReplaceAllString(line, "<n>")
+	line = strings.TrimSpace(spacePattern.ReplaceAllString(line, " "))
+	if runes := []rune(line); len(runes) > maxPatternLength {
+		line = string(runes[:maxPatternLength]) + "…"
+	}
+	return line
+}
+
+func formatPostmortemMarkdown(draft *postmortemDraft) string {
+	incident := draft.Incident
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Postmortem: %s\n\n", incidentTitle(incident.IncidentSummary))
+	fmt.Fprintf(&b, "_Draft generated %s from Datadog data. Review every section before sharing it._\n\n", draft.Generated.Format(mermaidTimeLayout+" UTC"))
+
+	b.WriteString("## Summary\n\n")
+	fmt.Fprintf(&b, "- **Severity:** %s\n", cmp.Or(incident.Severity, "unknown"))
+	fmt.Fprintf(&b, "- **State:** %s\n", cmp.Or(incident.State, "unknown"))
+	if incident.Commander != nil {
+		fmt.Fprintf(&b, "- **Commander:** %s\n", incidentUserName(incident.Commander))
+	}
+	if len(draft.Services) > 0 {
+		fmt.Fprintf(&b, "- **Services:** %s\n", strings.Join(draft.Services, ", "))
+	}
+	if teams := incident.Fields["teams"]; len(teams) > 0 {
+		fmt.Fprintf(&b, "- **Teams:** %s\n", strings.Join(teams, ", "))
+	}
+	fmt.Fprintf(&b, "- **Incident:** %s\n\n", incident.URL)
+	b.WriteString("_Describe what happened in two or three sentences._\n\n")
+
+	b.WriteString("## Impact\n\n")
+	if incident.CustomerImpacted {
+		fmt.Fprintf(&b, "- **Customer impact:** %s\n", cmp.Or(incident.CustomerImpactScope, "yes, scope not recorded"))
+		if incident.CustomerImpactStart != "" {
+			fmt.Fprintf(&b, "- **Impact window:** %s to %s\n", incident.CustomerImpactStart, cmp.Or(incident.CustomerImpactEnd, "ongoing"))
+		}
+		if incident.CustomerImpactDuration != nil {
+			fmt.Fprintf(&b, "- **Impact duration:** %s\n", time.Duration(*incident.CustomerImpactDuration)*time.Second)
+		}
+	} else {
+		b.WriteString("- **Customer impact:** none recorded\n")
+	}
+	for _, metric := range []struct {
+		name    string
+		seconds *int64
+	}{
+		{"Time to detect", incident.TimeToDetect},
+		{"Time to repair", incident.TimeToRepair},
+		{"Time to resolve", incident.TimeToResolve},
+	} {
+		if metric.seconds != nil {
+			fmt.Fprintf(&b, "- **%s:** %s\n", metric.name, time.Duration(*metric.seconds)*time.Second)
+		}
+	}
+	b.WriteString("\n")
+
+	b.WriteString("## Timeline\n\n")
+	writeTimelineTable(&b, draft.Timeline.Entries)
+	b.WriteString("\n")
+
+	if len(draft.Services) > 0 {
+		b.WriteString("## Monitors That Triggered\n\n")
+		if len(draft.Monitors) == 0 {
+			b.WriteString("No monitors for these services triggered in the window.\n\n")
+		} else {
+			b.WriteString("| Monitor | Status | Last triggered (UTC) |\n|---|---|---|\n")
+			for _, monitor := range draft.Monitors {
+				fmt.Fprintf(&b, "| [%s](%s) | %s | %s |\n", markdownCell(monitor.Name), monitor.URL, monitor.Status, monitor.LastTriggered)
+			}
+			b.WriteString("\n")
+		}
+
+		b.WriteString("## Deployments\n\n")
+		if len(draft.Deployments) == 0 {
+			b.WriteString("No deployments were recorded for these services in the window.\n\n")
+		} else {
+			for _, event := range draft.Deployments {
+				fmt.Fprintf(&b, "- %s: %s\n", formatOptionalTime(event.Timestamp), event.Title)
+			}
+			b.WriteString("\n")
+		}
+
+		b.WriteString("## Error Log Patterns\n\n")
+		if len(draft.LogPatterns) == 0 {
+			b.WriteString("No error logs were found for these services in the window.\n\n")
+		} else {
+			fmt.Fprintf(&b, "The most common of %d error logs, with IDs and numbers masked:\n\n", draft.LogsSampled)
+			b.WriteString("| Count | Pattern | Services |\n|---|---|---|\n")
+			for _, pattern := range draft.LogPatterns {
+				fmt.Fprintf(&b, "| %d | `%s` | %s |\n", pattern.Count, strings.ReplaceAll(markdownCell(pattern.Pattern), "`", "'"), strings.Join(pattern.Services, ", "))
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	b.WriteString("## Root Cause\n\n_What caused the incident, and why wasn't it caught sooner?_\n\n")
+
+	b.WriteString("## Action Items\n\n")
+	items := postmortemActionItems(draft.Timeline.Entries)
+	if len(items) == 0 {
+		b.WriteString("- [ ] _Add follow-up work here._\n")
+	}
+	for _, item := range items {
+		b.WriteString(item + "\n")
+	}
+	b.WriteString("\n")
+
+	b.WriteString("## Lessons Learned\n\n- **What went well:**\n- **What went wrong:**\n- **Where we got lucky:**\n")
+
+	notes := append(slices.Clone(draft.Timeline.Notes), draft.Notes...)
+	if len(notes) > 0 {
+		b.WriteString("\n## Notes\n\n")
+		for _, note := range notes {
+			fmt.Fprintf(&b, "- %s\n", note)
+		}
+	}
+	return b.String()
+}
+
+// postmortemActionItems turns the incident's todos into a checklist.
+func postmortemActionItems(entries []TimelineEntry) []string {
+	var todos []string
+	done := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.Kind != "todo" {
+			continue
+		}
+		if content, ok := strings.CutPrefix(entry.Text, "Todo added: "); ok {
+			todos = append(todos, content)
+		} else if content, ok := strings.CutPrefix(entry.Text, "Todo completed: "); ok {
+			done[content] = true
+		}
+	}
+	items := make([]string, 0, len(todos))
+	for _, todo := range todos {
+		content, _, _ := strings.Cut(todo, " (assigned to ")
+		if done[content] {
+			items = append(items, "- [x] "+todo)
+		} else {
+			items = append(items, "- [ ] "+todo)
+		}
+	}
+	return items
+}
+
+// attachPostmortem saves the draft to a notebook covering the incident
+// window and attaches it to the incident as its postmortem.
+func (s *MCPServer) attachPostmortem(incident *Incident, draft *postmortemDraft, markdown string) (string, error) {
+	cell := datadogV1.NewNotebookCellCreateRequest(
+		datadogV1.NotebookMarkdownCellAttributesAsNotebookCellCreateRequestAttributes(datadogV1.NewNotebookMarkdownCellAttributes(
+			*datadogV1.NewNotebookMarkdownCellDefinition(markdown, datadogV1.NOTEBOOKMARKDOWNCELLDEFINITIONTYPE_MARKDOWN))),
+		datadogV1.NOTEBOOKCELLRESOURCETYPE_NOTEBOOK_CELLS)
+	attributes := datadogV1.NewNotebookCreateDataAttributes(
+		[]datadogV1.NotebookCellCreateRequest{*cell},
+		"Postmortem: "+incidentTitle(incident.IncidentSummary),
+		datadogV1.NotebookAbsoluteTimeAsNotebookGlobalTime(datadogV1.NewNotebookAbsoluteTime(draft.To, draft.From)))
+	notebook, _, err := datadogV1.NewNotebooksApi(s.ddClient).CreateNotebook(s.ctx,
+		*datadogV1.NewNotebookCreateRequest(*datadogV1.NewNotebookCreateData(*attributes, datadogV1.NOTEBOOKRESOURCETYPE_NOTEBOOKS)))
+	if err != nil {
+		return "", fmt.Errorf("failed to create postmortem notebook: %w", err)
+	}
+	notebookURL := s.appURL(fmt.Sprintf("/notebook/%d", notebook.Data.GetId()))
+
+	if err := s.createIncidentAttachment(incident.ID, "postmortem", "Postmortem", notebookURL); err != nil {
+		return "", fmt.Errorf("created notebook %s but failed to attach it to incident %s: %w", notebookURL, incident.ID, err)
+	}
+	return notebookURL, nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGeneratePostmortem(t *testing.T) {
+	incident := strings.Replace(testIncidentData, `"teams":`, `"services":{"type":"autocomplete","value":["checkout"]},"teams":`, 1)
+	var attached struct {
+		Data struct {
+			Attributes struct {
+				AttachmentType string `json:"attachment_type"`
+				Attachment     struct {
+					DocumentURL string `json:"documentUrl"`
+				} `json:"attachment"`
+			} `json:"attributes"`
+		} `json:"data"`
+	}
+	var logsQuery string
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/api/v2/incidents/8a9b2c3d":
+			_, _ = w.Write([]byte(`{"data":` + incident + `,"included":` + testIncidentUsers + `}`))
+		case r.URL.Path == "/api/v2/incidents/8a9b2c3d/relationships/todos":
+			_, _ = w.Write([]byte(`{"data":[{"id":"t-1","type":"incident_todos","attributes":{"content":"Add a canary for checkout","assignees":["@ana"],"created":"2026-01-20T09:12:00Z"}}]}`))
+		case r.URL.Path == "/api/v2/incidents/8a9b2c3d/attachments" && r.Method == http.MethodPost:
+			_ = json.NewDecoder(r.Body).Decode(&attached)
+			_, _ = w.Write([]byte(`{"data":{"id":"a-2","type":"incident_attachments"}}`))
+		case r.URL.Path == "/api/v1/monitor/search":
+			_, _ = w.Write([]byte(`{"monitors":[
+				{"id":7,"name":"Checkout error rate","status":"Alert","type":"query alert","last_triggered_ts":1768899780},
+				{"id":8,"name":"Checkout latency","status":"OK","type":"query alert","last_triggered_ts":1700000000}],
+				"metadata":{"total_count":2,"page_count":1}}`))
+		case r.URL.Path == "/api/v2/events/search":
+			_, _ = w.Write([]byte(`{"data":[{"id":"E1","attributes":{"timestamp":"2026-01-20T08:55:00Z","tags":["event_type:deployment"],"attributes":{"title":"Deployed checkout v42 to prod"}}}]}`))
+		case r.URL.Path == "/api/v2/logs/events/search":
+			body, _ := io.ReadAll(r.Body)
+			var req struct {
+				Filter struct {
+					Query string `json:"query"`
+				} `json:"filter"`
+			}
+			_ = json.Unmarshal(body, &req)
+			logsQuery = req.Filter.Query
+			_, _ = w.Write([]byte(`{"data":[
+				{"id":"1","attributes":{"message":"payment 1234 failed for order 9f2c1b7e-0a4d-4c2e-9b1a-3f5d6e7a8b9c","service":"checkout"}},
+				{"id":"2","attributes":{"message":"payment 99 failed for order 1a2b3c4d-0a4d-4c2e-9b1a-3f5d6e7a8b9c","service":"checkout"}},
+				{"id":"3","attributes":{"message":"connection reset by peer","service":"checkout"}}]}`))
+		case r.URL.Path == "/api/v1/notebooks":
+			_, _ = w.Write([]byte(`{"data":{"id":555,"type":"notebooks","attributes":{"name":"Postmortem","cells":[],"time":{"live_span":"1h"}}}}`))
+		default:
+			http.Error(w, `{"errors":["forbidden"]}`, http.StatusForbidden)
+		}
+	})
+	server.backends = mustLoadAPIBackends(t)
+	server.documents = newDocumentStore()
+
+	text, err := server.GeneratePostmortem(GeneratePostmortemParams{IncidentID: "8a9b2c3d"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# Postmortem: Incident 42: Checkout errors",
+		"- **Services:** checkout",
+		"- **Customer impact:** Card payments fail",
+		"| 2026-01-20 08:55 | Deployed checkout v42 to prod |",
+		"| 2026-01-20 09:03 | Monitor triggered: Checkout error rate |",
+		"[Checkout error rate](",
+		"| 2 | `payment <n> failed for order <id>` | checkout |",
+		"- [ ] Add a canary for checkout (assigned to @ana)",
+		"## Root Cause",
+		"Couldn't read impacts",
+		"Draft saved as resource datadog://incidents/8a9b2c3d/postmortem.",
+	} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("expected %q in:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "Checkout latency") {
+		t.Fatalf("expected the monitor that triggered before the incident to be left out:\n%s", text)
+	}
+	if logsQuery != "service:(checkout) status:error" {
+		t.Fatalf("unexpected logs query %q", logsQuery)
+	}
+	resources := server.documents.list("")
+	if len(resources) != 1 || resources[0].MimeType != "text/markdown" {
+		t.Fatalf("expected the draft to be kept as a resource, got %+v", resources)
+	}
+
+	if _, err := server.GeneratePostmortem(GeneratePostmortemParams{IncidentID: "8a9b2c3d", Attach: true, Confirm: true}); err == nil || !strings.Contains(err.Error(), "writes are disabled") {
+		t.Fatalf("expected attach to need writes, got %v", err)
+	}
+	server.allowWrites = true
+	if _, err := server.GeneratePostmortem(GeneratePostmortemParams{IncidentID: "8a9b2c3d", Attach: true}); err == nil || !strings.Contains(err.Error(), "confirm") {
+		t.Fatalf("expected attach to need confirm, got %v", err)
+	}
+	text, err = server.GeneratePostmortem(GeneratePostmortemParams{IncidentID: "8a9b2c3d", Attach: true, Confirm: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attached.Data.Attributes.AttachmentType != "postmortem" || !strings.HasSuffix(attached.Data.Attributes.Attachment.DocumentURL, "/notebook/555") {
+		t.Fatalf("unexpected attachment %+v", attached)
+	}
+	if !strings.Contains(text, "Attached to the incident as its postmortem") {
+		t.Fatalf("expected the attachment to be reported:\n%s", text)
+	}
+}
+
+func TestLogPattern(t *testing.T) {
+	for message, want := range map[string]string{
+		"timeout after 30.5s calling 10.0.0.12":                  "timeout after <n>s calling <n>.<n>",
+		"user 42 not found\n  at handler.go:12":                  "user <n> not found",
+		"trace deadbeefcafe0001 dropped":                         "trace <id> dropped",
+		"  spaced    out  ":                                      "spaced out",
+		"order 9f2c1b7e-0a4d-4c2e-9b1a-3f5d6e7a8b9c was refused": "order <id> was refused",
+	} {
+		if got := logPattern(message); got != want {
+			t.Errorf("logPattern(%q) = %q, want %q", message, got, want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	starlarkjson "go.starlark.net/lib/json"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+)
+
+// maxPostProcessSteps bounds the work one post-processing call may do, so a
+// runaway loop can't stall a tool call.
+const maxPostProcessSteps = 10_000_000
+
+// postProcessor applies per-tool Starlark functions to tool results. A
+// function named after a tool receives that tool's result as decoded JSON
+// and returns the value to send instead. A nil postProcessor does nothing.
+type postProcessor struct {
+	functions map[string]starlark.Callable
+}
+
+// loadPostProcessor runs the script once to collect its top-level
+// functions. The script may use the json module; it has no other access to
+// the outside world.
+func loadPostProcessor(path string) (*postProcessor, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read post-processing script: %w", err)
+	}
+
+	thread := &starlark.Thread{Name: "load " + path}
+	thread.SetMaxExecutionSteps(maxPostProcessSteps)
+	predeclared := starlark.StringDict{"json": starlarkjson.Module}
+	globals, err := starlark.ExecFileOptions(&syntax.FileOptions{}, thread, path, src, predeclared)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load post-processing script: %w", err)
+	}
+	// Functions run concurrently for different requests, so module-level
+	// lists and dicts must not change after loading.
+	globals.Freeze()
+
+	functions := make(map[string]starlark.Callable)
+	for name, value := range globals {
+		if fn, ok := value.(*starlark.Function); ok {
+			functions[name] = fn
+		}
+	}
+	return &postProcessor{functions: functions}, nil
+}
+
+// apply returns text rewritten by the tool's function, or text unchanged
+// when the tool has none.
+func (p *postProcessor) apply(tool, text string) (string, error) {
+	if p == nil {
+		return text, nil
+	}
+	fn, ok := p.functions[tool]
+	if !ok {
+		return text, nil
+	}
+
+	thread := &starlark.Thread{Name: "post-process " + tool}
+	thread.SetMaxExecutionSteps(maxPostProcessSteps)
+
+	decode := starlarkjson.Module.Members["decode"]
+	encode := starlarkjson.Module.Members["encode"]
+	value, err := starlark.Call(thread, decode, starlark.Tuple{starlark.String(text)}, nil)
+	if err != nil {
+		return "", fmt.Errorf("result is not JSON: %w", err)
+	}
+	value, err = starlark.Call(thread, fn, starlark.Tuple{value}, nil)
+	if err != nil {
+		return "", err
+	}
+	encoded, err := starlark.Call(thread, encode, starlark.Tuple{value}, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode result: %w", err)
+	}
+
+	// Keep the indented layout of unprocessed results.
+	var out bytes.Buffer
+	if err := json.Indent(&out, []byte(string(encoded.(starlark.String))), "", "  "); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// postProcess applies the configured script to a tool result. A failing
+// script is logged and the unprocessed result is returned, so a bad script
+// degrades output rather than breaking the tool.
+func (s *MCPServer) postProcess(tool, text string) string {
+	processed, err := s.postProcessor.apply(tool, text)
+	if err != nil {
+		log.Printf("Post-processing %s failed: %v", tool, err)
+		return text
+	}
+	return processed
+}
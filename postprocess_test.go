@@ -0,0 +1,85 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPostProcessorRewritesResults(t *testing.T) {
+	p, err := loadPostProcessor(writeTempFile(t, "post.star", `
+def query_logs(result):
+    for entry in result["logs"]:
+        entry.pop("tags")
+        entry["team"] = "payments" if entry["service"] == "checkout" else "unknown"
+    result["count_label"] = "%d logs" % result["count"]
+    return result
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	text := formatLogsResult(&QueryLogsResult{
+		Logs:  []LogEntry{{ID: "1", Service: "checkout", Tags: []string{"env:prod"}}},
+		Count: 1,
+	})
+	out, err := p.apply("query_logs", text)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{`"team": "payments"`, `"count_label": "1 logs"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %s in %s", want, out)
+		}
+	}
+	if strings.Contains(out, "env:prod") {
+		t.Errorf("expected tags to be dropped, got %s", out)
+	}
+
+	if out, _ := p.apply("server_stats", `{"a": 1}`); out != `{"a": 1}` {
+		t.Errorf("expected tools without a function to pass through, got %s", out)
+	}
+}
+
+func TestPostProcessFallsBackOnError(t *testing.T) {
+	p, err := loadPostProcessor(writeTempFile(t, "post.star", `
+def server_stats(result):
+    return result["missing"]
+
+def detect_anomalies(result):
+    for i in range(1000000000):
+        pass
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	server := &MCPServer{postProcessor: p}
+
+	if out := server.postProcess("server_stats", `{"uptime": "1s"}`); out != `{"uptime": "1s"}` {
+		t.Errorf("expected the unprocessed result on error, got %s", out)
+	}
+	if _, err := p.apply("detect_anomalies", `{}`); err == nil {
+		t.Error("expected runaway scripts to be stopped")
+	}
+}
+
+func TestPostProcessorGlobalsAreFrozen(t *testing.T) {
+	p, err := loadPostProcessor(writeTempFile(t, "post.star", `
+seen = []
+
+def server_stats(result):
+    seen.append(result["uptime"])
+    return result
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := p.apply("server_stats", `{"uptime": "1s"}`); err == nil || !strings.Contains(err.Error(), "frozen") {
+		t.Errorf("expected changing a global to fail as frozen, got %v", err)
+	}
+}
+
+func TestLoadPostProcessorSyntaxError(t *testing.T) {
+	if _, err := loadPostProcessor(writeTempFile(t, "post.star", "def broken(:\n")); err == nil {
+		t.Error("expected a syntax error")
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// prefetchTargets are the cached lists warm-up can fill, by name.
+var prefetchTargets = map[string]func(*MCPServer) (int, error){
+	"services": func(s *MCPServer) (int, error) {
+		names, err := s.serviceNames()
+		return len(names), err
+	},
+	"monitors": func(s *MCPServer) (int, error) {
+		names, err := s.monitorNames()
+		return len(names), err
+	},
+}
+
+// parsePrefetch reads a comma-separated list of prefetch targets, or
+// "all".
+func parsePrefetch(value string) ([]string, error) {
+	var targets []string
+	for _, item := range splitList(value) {
+		item = strings.ToLower(item)
+		if item == "all" {
+			targets = prefetchTargetNames()
+			continue
+		}
+		if _, ok := prefetchTargets[item]; !ok {
+			return nil, fmt.Errorf("unknown prefetch target %q (use %s or all)", item, strings.Join(prefetchTargetNames(), ", "))
+		}
+		if !slices.Contains(targets, item) {
+			targets = append(targets, item)
+		}
+	}
+	return targets, nil
+}
+
+func prefetchTargetNames() []string {
+	names := make([]string, 0, len(prefetchTargets))
+	for name := range prefetchTargets {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// warmer fills the name caches in the background, once per org, so the
+// first lookups don't wait on a full listing. A nil warmer does nothing.
+type warmer struct {
+	targets []string
+
+	mu      sync.Mutex
+	started map[string]bool
+}
+
+func newWarmer(targets []string) *warmer {
+	if len(targets) == 0 {
+		return nil
+	}
+	return &warmer{targets: targets, started: make(map[string]bool)}
+}
+
+// warmUp starts prefetching for the server's org unless it already has.
+// It returns at once; done is closed when prefetching finishes, or
+// immediately when there is nothing to do.
+func (s *MCPServer) warmUp() (done <-chan struct{}) {
+	finished := make(chan struct{})
+	w := s.warmer
+	if w == nil {
+		close(finished)
+		return finished
+	}
+	org := s.apiOrg()
+	w.mu.Lock()
+	if w.started[org] {
+		w.mu.Unlock()
+		close(finished)
+		return finished
+	}
+	if len(w.started) >= sweepThreshold {
+		clear(w.started)
+	}
+	w.started[org] = true
+	w.mu.Unlock()
+
+	go func() {
+		defer close(finished)
+		scoped, cancel := s.withContext(context.Background())
+		defer cancel()
+		for _, target := range w.targets {
+			started := time.Now()
+			count, err := prefetchTargets[target](scoped)
+			if err != nil {
+				log.Printf("Prefetching %s failed: %v", target, err)
+				continue
+			}
+			log.Printf("Prefetched %d %s in %s", count, target, time.Since(started).Round(time.Millisecond))
+		}
+	}()
+	return finished
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParsePrefetch(t *testing.T) {
+	targets, err := parsePrefetch("Monitors, services, monitors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(targets, ",") != "monitors,services" {
+		t.Errorf("unexpected targets: %v", targets)
+	}
+	if targets, _ := parsePrefetch("all"); strings.Join(targets, ",") != "monitors,services" {
+		t.Errorf("expected all to expand to every target, got %v", targets)
+	}
+	if targets, _ := parsePrefetch(""); newWarmer(targets) != nil {
+		t.Error("expected no warmer when unset")
+	}
+	if _, err := parsePrefetch("facets"); err == nil {
+		t.Error("expected an unknown target to be rejected")
+	}
+}
+
+func TestWarmUpFillsNameCache(t *testing.T) {
+	var serviceCalls, monitorCalls int32
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/services/definitions"):
+			atomic.AddInt32(&serviceCalls, 1)
+			_, _ = w.Write([]byte(`{"data":[{"type":"service-definition","attributes":{"schema":{"schema-version":"v2","dd-service":"checkout"}}}]}`))
+		case strings.HasSuffix(r.URL.Path, "/monitor"):
+			atomic.AddInt32(&monitorCalls, 1)
+			_, _ = w.Write([]byte(`[{"id":1,"name":"Checkout errors"}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	server.names = newNameCache()
+	server.warmer = newWarmer([]string{"services", "monitors"})
+
+	select {
+	case <-server.warmUp():
+	case <-time.After(5 * time.Second):
+		t.Fatal("prefetching didn't finish")
+	}
+	<-server.warmUp()
+	if serviceCalls != 1 || monitorCalls != 1 {
+		t.Fatalf("expected one listing of each, got %d services and %d monitors", serviceCalls, monitorCalls)
+	}
+
+	resolution, err := server.resolveService("checkout")
+	if err != nil || !resolution.found() {
+		t.Fatalf("expected the prefetched service, got %+v, %v", resolution, err)
+	}
+	if serviceCalls != 1 {
+		t.Error("expected the lookup to use the prefetched list")
+	}
+}
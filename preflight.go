@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// SchemaCondition is the subset of JSON Schema used to describe how a
+// tool's arguments depend on each other: which must appear together
+// (Required) and alternatives (AnyOf).
+type SchemaCondition struct {
+	Required []string          `json:"required,omitempty"`
+	AnyOf    []SchemaCondition `json:"anyOf,omitempty"`
+}
+
+// matches reports whether args satisfy c.
+func (c SchemaCondition) matches(args map[string]json.RawMessage) bool {
+	for _, name := range c.Required {
+		if !present(args, name) {
+			return false
+		}
+	}
+	if len(c.AnyOf) == 0 {
+		return true
+	}
+	for _, alt := range c.AnyOf {
+		if alt.matches(args) {
+			return true
+		}
+	}
+	return false
+}
+
+// names lists the arguments c mentions, for error messages.
+func (c SchemaCondition) names() []string {
+	names := append([]string(nil), c.Required...)
+	for _, alt := range c.AnyOf {
+		names = append(names, alt.names()...)
+	}
+	return names
+}
+
+// present treats an explicit null like an omitted argument.
+func present(args map[string]json.RawMessage, name string) bool {
+	value, ok := args[name]
+	return ok && string(value) != "null"
+}
+
+// validateArguments checks arguments against the constraints the schema
+// declares, so a call that can't succeed is refused with a precise
+// message before any Datadog request is made. Types and formats are left
+// to each tool.
+func validateArguments(schema InputSchema, arguments json.RawMessage) error {
+	args := make(map[string]json.RawMessage)
+	if len(arguments) > 0 && string(arguments) != "null" {
+		if err := json.Unmarshal(arguments, &args); err != nil {
+			return fmt.Errorf("arguments must be a JSON object: %v", err)
+		}
+	}
+
+	for _, name := range schema.Required {
+		if !present(args, name) {
+			return fmt.Errorf("missing required argument: %s", name)
+		}
+	}
+	for name, prop := range schema.Properties {
+		if prop.Const == nil || !present(args, name) {
+			continue
+		}
+		var value interface{}
+		if json.Unmarshal(args[name], &value) != nil || !reflect.DeepEqual(value, prop.Const) {
+			return fmt.Errorf("argument %s must be %v", name, prop.Const)
+		}
+	}
+	for name, needs := range schema.Dependencies {
+		if !present(args, name) {
+			continue
+		}
+		for _, need := range needs {
+			if !present(args, need) {
+				return fmt.Errorf("argument %s requires %s", name, need)
+			}
+		}
+	}
+	if len(schema.AnyOf) > 0 && !(SchemaCondition{AnyOf: schema.AnyOf}).matches(args) {
+		return fmt.Errorf("one of these arguments is required: %s", strings.Join(SchemaCondition{AnyOf: schema.AnyOf}.names(), ", "))
+	}
+	if schema.Not != nil && schema.Not.matches(args) {
+		for _, alt := range append([]SchemaCondition{*schema.Not}, schema.Not.AnyOf...) {
+			if len(alt.Required) > 0 && alt.matches(args) {
+				return fmt.Errorf("arguments %s can't be used together", strings.Join(alt.Required, " and "))
+			}
+		}
+		return fmt.Errorf("arguments %s can't be used together", strings.Join(schema.Not.names(), ", "))
+	}
+	return nil
+}
+
+// preflight validates a call against the schema of the tool it names.
+// Unknown tools are left to callTool to reject.
+func (s *MCPServer) preflight(params ToolCallParams) *MCPError {
+	for _, tool := range s.ListTools() {
+		if tool.Name != params.Name {
+			continue
+		}
+		if err := validateArguments(tool.InputSchema, params.Arguments); err != nil {
+			return &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments for %s: %v", params.Name, err)}
+		}
+		return nil
+	}
+	return nil
+}
+
+// confirmProperty is the argument every write tool requires, so a write
+// is never made by a call that merely omitted a flag.
+var confirmProperty = SchemaProperty{
+	Type:        "boolean",
+	Description: "Must be true, confirming the write was intended",
+	Const:       true,
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestValidateArguments(t *testing.T) {
+	schema := InputSchema{
+		Type: "object",
+		Properties: map[string]SchemaProperty{
+			"confirm": confirmProperty,
+		},
+		Required:     []string{"confirm"},
+		Dependencies: map[string][]string{"to": {"from"}},
+		AnyOf:        []SchemaCondition{{Required: []string{"id"}}, {Required: []string{"name"}}},
+		Not:          &SchemaCondition{AnyOf: []SchemaCondition{{Required: []string{"cursor", "from"}}, {Required: []string{"cursor", "to"}}}},
+	}
+	cases := []struct {
+		args string
+		want string
+	}{
+		{`{"confirm":true,"id":1}`, ""},
+		{`{"confirm":true,"name":"x","from":"1h","to":"5m"}`, ""},
+		{`{"id":1}`, "missing required argument: confirm"},
+		{`{"confirm":null,"id":1}`, "missing required argument: confirm"},
+		{`{"confirm":false,"id":1}`, "argument confirm must be true"},
+		{`{"confirm":true,"id":1,"to":"5m"}`, "argument to requires from"},
+		{`{"confirm":true}`, "one of these arguments is required: id, name"},
+		{`{"confirm":true,"id":1,"cursor":"c","to":"5m","from":"1h"}`, "arguments cursor and from can't be used together"},
+		{`[1]`, "arguments must be a JSON object"},
+	}
+	for _, c := range cases {
+		err := validateArguments(schema, json.RawMessage(c.args))
+		switch {
+		case c.want == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", c.args, err)
+		case c.want != "" && (err == nil || !strings.Contains(err.Error(), c.want)):
+			t.Errorf("%s: expected %q, got %v", c.args, c.want, err)
+		}
+	}
+}
+
+func TestCallToolRunsPreflight(t *testing.T) {
+	server := &MCPServer{allowWrites: true}
+	cases := map[string]ToolCallParams{
+		"argument to requires from":                               {Name: "query_logs", Arguments: json.RawMessage(`{"query":"*","to":"5m"}`)},
+		"missing required argument: confirm":                      {Name: "record_deployment", Arguments: json.RawMessage(`{"service":"web","version":"1","env":"prod"}`)},
+		"arguments monitor_id and monitor can't be used together": {Name: "resolve_runbooks", Arguments: json.RawMessage(`{"monitor_id":1,"monitor":"x"}`)},
+	}
+	for want, params := range cases {
+		_, err := server.callTool(params)
+		if err == nil || err.Code != -32602 || !strings.Contains(err.Message, want) {
+			t.Errorf("%s: expected %q, got %+v", params.Name, want, err)
+		}
+	}
+}
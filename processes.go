@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV2"
+)
+
+const (
+	defaultProcessLimit = 50
+	maxProcessLimit     = 1000
+)
+
+type QueryProcessesParams struct {
+	// Search matches command lines, such as "java" or "worker --queue".
+	Search string   `json:"search,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+	From   string   `json:"from,omitempty"`
+	To     string   `json:"to,omitempty"`
+	Limit  int32    `json:"limit,omitempty"`
+	// Cursor continues from a previous call's NextCursor.
+	Cursor string `json:"cursor,omitempty"`
+}
+
+type ProcessEntry struct {
+	Host    string   `json:"host"`
+	PID     int64    `json:"pid"`
+	PPID    int64    `json:"ppid,omitempty"`
+	User    string   `json:"user,omitempty"`
+	Command string   `json:"command"`
+	Started string   `json:"started,omitempty"`
+	Seen    string   `json:"seen,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+}
+
+type QueryProcessesResult struct {
+	Processes []ProcessEntry `json:"processes"`
+	Count     int            `json:"count"`
+	// ByHost counts the returned processes on each host.
+	ByHost     map[string]int `json:"by_host,omitempty"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+	URL        string         `json:"url"`
+	Notes      []string       `json:"notes,omitempty"`
+}
+
+// QueryProcesses searches the live processes the Agent reports, to tie
+// a host's errors to the process behind them.
+func (s *MCPServer) QueryProcesses(params QueryProcessesParams) (*QueryProcessesResult, error) {
+	limit := params.Limit
+	if limit <= 0 {
+		limit = defaultProcessLimit
+	}
+	limit = clampArgument(s, "limit", limit, maxProcessLimit)
+	opts := datadogV2.NewListProcessesOptionalParameters().WithPageLimit(limit)
+	if search := strings.TrimSpace(params.Search); search != "" {
+		opts = opts.WithSearch(search)
+	}
+	if len(params.Tags) > 0 {
+		opts = opts.WithTags(strings.Join(params.Tags, ","))
+	}
+	if params.From != "" {
+		from, err := s.timeParam("from", params.From, time.Time{})
+		if err != nil {
+			return nil, err
+		}
+		opts = opts.WithFrom(from.Unix())
+	}
+	if params.To != "" {
+		to, err := s.timeParam("to", params.To, time.Time{})
+		if err != nil {
+			return nil, err
+		}
+		opts = opts.WithTo(to.Unix())
+	}
+	if params.Cursor != "" {
+		opts = opts.WithPageCursor(params.Cursor)
+	}
+
+	resp, _, err := datadogV2.NewProcessesApi(s.ddClient).ListProcesses(s.ctx, *opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query processes: %w", err)
+	}
+
+	query := url.Values{}
+	if params.Search != "" {
+		query.Set("query", params.Search)
+	}
+	result := &QueryProcessesResult{
+		Processes: make([]ProcessEntry, 0, len(resp.Data)),
+		URL:       s.appURL("/process?" + query.Encode()),
+	}
+	for _, process := range resp.Data {
+		attrs := process.Attributes
+		if attrs == nil {
+			continue
+		}
+		tags := append([]string(nil), attrs.Tags...)
+		sort.Strings(tags)
+		result.Processes = append(result.Processes, ProcessEntry{
+			Host:    attrs.GetHost(),
+			PID:     attrs.GetPid(),
+			PPID:    attrs.GetPpid(),
+			User:    attrs.GetUser(),
+			Command: attrs.GetCmdline(),
+			Started: attrs.GetStart(),
+			Seen:    attrs.GetTimestamp(),
+			Tags:    tags,
+		})
+		if host := attrs.GetHost(); host != "" {
+			if result.ByHost == nil {
+				result.ByHost = make(map[string]int)
+			}
+			result.ByHost[host]++
+		}
+	}
+	result.Count = len(result.Processes)
+	if meta := resp.Meta; meta != nil && meta.Page != nil && meta.Page.GetAfter() != "" && int32(result.Count) >= limit {
+		result.NextCursor = meta.Page.GetAfter()
+		result.Notes = append(result.Notes, "More processes match; pass next_cursor as cursor for the next page.")
+	}
+	if result.Count == 0 {
+		result.Notes = append(result.Notes, "No processes match. Live processes need process collection enabled in the Agent (process_config.process_collection.enabled).")
+	}
+	result.Notes = append(result.Notes, "The processes API doesn't report CPU or memory; open the url to see them per process in Live Processes.")
+	return result, nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestQueryProcesses(t *testing.T) {
+	var query url.Values
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/api/v2/processes" {
+			http.NotFound(w, r)
+			return
+		}
+		query = r.URL.Query()
+		_, _ = w.Write([]byte(`{"data":[
+			{"id":"p1","type":"process","attributes":{"host":"web-1","pid":4242,"ppid":1,"user":"app","cmdline":"java -jar checkout.jar","start":"2026-01-20T08:00:00Z","timestamp":"2026-01-20T09:00:00Z","tags":["env:prod","service:checkout"]}},
+			{"id":"p2","type":"process","attributes":{"host":"web-1","pid":4300,"cmdline":"java -jar worker.jar"}}],
+			"meta":{"page":{"after":"cursor-2","size":2}}}`))
+	})
+
+	result, err := server.QueryProcesses(QueryProcessesParams{Search: "java", Tags: []string{"env:prod", "role:web"}, Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query.Get("search") != "java" || query.Get("tags") != "env:prod,role:web" || query.Get("page[limit]") != "2" {
+		t.Fatalf("unexpected query %v", query)
+	}
+	if result.Count != 2 || result.ByHost["web-1"] != 2 || result.NextCursor != "cursor-2" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	first := result.Processes[0]
+	if first.PID != 4242 || first.PPID != 1 || first.Command != "java -jar checkout.jar" || first.User != "app" || len(first.Tags) != 2 {
+		t.Errorf("unexpected process %+v", first)
+	}
+
+	if _, err := server.QueryProcesses(QueryProcessesParams{Cursor: "cursor-2"}); err != nil {
+		t.Fatal(err)
+	}
+	if query.Get("page[cursor]") != "cursor-2" || query.Get("page[limit]") != "50" {
+		t.Fatalf("expected the cursor and default limit, got %v", query)
+	}
+}
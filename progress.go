@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// RequestMeta is the _meta object a client may attach to a tools/call
+// request. A progress token asks the server for progress notifications.
+type RequestMeta struct {
+	ProgressToken json.RawMessage `json:"progressToken,omitempty"`
+}
+
+// MCPNotification is a JSON-RPC message the server sends without being
+// asked, such as a progress update.
+type MCPNotification struct {
+	Jsonrpc string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// ProgressParams follows notifications/progress. Content is an extension
+// carrying the partial result fetched since the previous notification, so
+// clients can show early results while a long fetch continues.
+type ProgressParams struct {
+	ProgressToken json.RawMessage `json:"progressToken"`
+	Progress      int             `json:"progress"`
+	Total         int             `json:"total,omitempty"`
+	Message       string          `json:"message,omitempty"`
+	Content       []TextContent   `json:"content,omitempty"`
+}
+
+// withNotifier returns a copy of the server that sends notifications
+// through notify. Transports that can't deliver them leave it unset.
+func (s *MCPServer) withNotifier(notify func(MCPNotification)) *MCPServer {
+	notifying := *s
+	notifying.notify = notify
+	return &notifying
+}
+
+// withProgress returns a copy of the server that reports progress for the
+// request identified by token.
+func (s *MCPServer) withProgress(token json.RawMessage) *MCPServer {
+	reporting := *s
+	reporting.progressToken = token
+	return &reporting
+}
+
+// reportProgress sends a progress notification with an optional partial
+// result. It does nothing unless the client asked for progress and the
+// transport can deliver notifications.
+func (s *MCPServer) reportProgress(progress, total int, message, partial string) {
+	if s.notify == nil || len(s.progressToken) == 0 {
+		return
+	}
+
+	params := ProgressParams{
+		ProgressToken: s.progressToken,
+		Progress:      progress,
+		Total:         total,
+		Message:       message,
+	}
+	if partial != "" {
+		params.Content = []TextContent{{Type: "text", Text: partial}}
+	}
+	s.notify(MCPNotification{Jsonrpc: "2.0", Method: "notifications/progress", Params: params})
+}
+
+// reportLogsPage streams one page of an auto-paginated log query.
+func (s *MCPServer) reportLogsPage(page *QueryLogsResult, fetched, limit int) {
+	if s.notify == nil || len(s.progressToken) == 0 {
+		return
+	}
+	s.reportProgress(fetched, limit, fmt.Sprintf("fetched %d of up to %d logs", fetched, limit), formatLogsResult(page))
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadog"
+)
+
+// newFakeDatadogServer returns a server whose Datadog client talks to
+// handler instead of the real API.
+func newFakeDatadogServer(t *testing.T, handler http.HandlerFunc) *MCPServer {
+	t.Helper()
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+
+	configuration := datadog.NewConfiguration()
+	enableUnstableOperations(configuration)
+	configuration.Servers = datadog.ServerConfigurations{{URL: ts.URL}}
+	server := &MCPServer{
+		ddClient:    datadog.NewAPIClient(configuration),
+		credentials: datadogCredentials{APIKey: "api-key", AppKey: "app-key"},
+	}
+	// Tests call tool methods directly, as handleRequest would on the
+	// per-request copy.
+	scoped, cancel := server.withContext(context.Background())
+	t.Cleanup(cancel)
+	return scoped
+}
+
+func TestReportProgressRequiresToken(t *testing.T) {
+	var sent []MCPNotification
+	server := (&MCPServer{}).withNotifier(func(n MCPNotification) { sent = append(sent, n) })
+
+	server.reportProgress(1, 2, "half", "")
+	if len(sent) != 0 {
+		t.Fatalf("expected no notification without a progress token, got %d", len(sent))
+	}
+
+	server.withProgress(json.RawMessage(`"tok"`)).reportProgress(1, 2, "half", "partial")
+	if len(sent) != 1 {
+		t.Fatalf("expected one notification, got %d", len(sent))
+	}
+	params := sent[0].Params.(ProgressParams)
+	if sent[0].Method != "notifications/progress" || string(params.ProgressToken) != `"tok"` {
+		t.Errorf("unexpected notification: %+v", sent[0])
+	}
+	if len(params.Content) != 1 || params.Content[0].Text != "partial" {
+		t.Errorf("expected partial content, got %+v", params.Content)
+	}
+}
+
+func TestQueryLogsPaginatesAndStreamsPages(t *testing.T) {
+	var cursors []string
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Page struct {
+				Cursor string `json:"cursor"`
+				Limit  int    `json:"limit"`
+			} `json:"page"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		cursors = append(cursors, body.Page.Cursor)
+
+		w.Header().Set("Content-Type", "application/json")
+		if body.Page.Cursor == "" {
+			fmt.Fprint(w, `{"data":[{"id":"1","attributes":{"message":"a"}},{"id":"2","attributes":{"message":"b"}}],"meta":{"page":{"after":"next"}}}`)
+			return
+		}
+		fmt.Fprint(w, `{"data":[{"id":"3","attributes":{"message":"c"}}],"meta":{"page":{}}}`)
+	})
+
+	var sent []MCPNotification
+	server = server.withNotifier(func(n MCPNotification) { sent = append(sent, n) }).withProgress(json.RawMessage(`1`))
+
+	result, err := server.QueryLogs(QueryLogsParams{Query: "service:web", Limit: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Count != 3 {
+		t.Errorf("expected 3 logs across pages, got %d", result.Count)
+	}
+	if len(cursors) != 2 || cursors[1] != "next" {
+		t.Errorf("expected the second page to use the cursor, got %q", cursors)
+	}
+	if len(sent) != 1 {
+		t.Fatalf("expected one progress notification for the first page, got %d", len(sent))
+	}
+	params := sent[0].Params.(ProgressParams)
+	if params.Progress != 2 || params.Total != 10 || !strings.Contains(params.Content[0].Text, `"id": "2"`) {
+		t.Errorf("unexpected progress: %+v", params)
+	}
+}
+
+func TestEventStream(t *testing.T) {
+	rec := httptest.NewRecorder()
+	stream := &eventStream{w: rec}
+	stream.send(MCPNotification{Jsonrpc: "2.0", Method: "notifications/progress"})
+	stream.send(MCPResponse{Jsonrpc: "2.0", ID: 1})
+
+	if ct := rec.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("expected text/event-stream, got %q", ct)
+	}
+	events := strings.Split(strings.TrimSpace(rec.Body.String()), "\n\n")
+	if len(events) != 2 || !strings.HasPrefix(events[0], "event: message\ndata: {") {
+		t.Errorf("unexpected events: %q", rec.Body.String())
+	}
+}
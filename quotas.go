@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepThreshold is the number of tracked sessions above which expired
+// entries are dropped, so short-lived sessions don't accumulate forever.
+const sweepThreshold = 1024
+
+// QuotaError reports an exhausted quota and when it frees up again.
+type QuotaError struct {
+	Quota      string
+	Limit      int
+	RetryAfter time.Duration
+}
+
+func (e *QuotaError) Error() string {
+	seconds := int(math.Ceil(e.RetryAfter.Seconds()))
+	return fmt.Sprintf("quota exceeded: %d %s; retry after %ds", e.Limit, e.Quota, seconds)
+}
+
+type quotaLimits struct {
+	CallsPerMinute int
+	LogsPerHour    int
+}
+
+// quotaWindow is a fixed-window counter.
+type quotaWindow struct {
+	start time.Time
+	count int
+}
+
+func (w *quotaWindow) current(now time.Time, length time.Duration) int {
+	if now.Sub(w.start) >= length {
+		w.start = now
+		w.count = 0
+	}
+	return w.count
+}
+
+type quotaUsage struct {
+	calls quotaWindow
+	logs  quotaWindow
+}
+
+// quotaTracker enforces per-session limits independently of Datadog's own
+// rate limits, so one runaway agent can't monopolize a shared server.
+// A nil tracker allows everything.
+type quotaTracker struct {
+	mu     sync.Mutex
+	limits quotaLimits
+	usage  map[string]*quotaUsage
+}
+
+func newQuotaTracker(limits quotaLimits) *quotaTracker {
+	if limits.CallsPerMinute <= 0 && limits.LogsPerHour <= 0 {
+		return nil
+	}
+	return &quotaTracker{
+		limits: limits,
+		usage:  make(map[string]*quotaUsage),
+	}
+}
+
+func (q *quotaTracker) session(key string, now time.Time) *quotaUsage {
+	u, ok := q.usage[key]
+	if !ok {
+		if len(q.usage) >= sweepThreshold {
+			q.sweep(now)
+		}
+		u = &quotaUsage{calls: quotaWindow{start: now}, logs: quotaWindow{start: now}}
+		q.usage[key] = u
+	}
+	return u
+}
+
+func (q *quotaTracker) sweep(now time.Time) {
+	for key, u := range q.usage {
+		if now.Sub(u.calls.start) >= time.Minute && now.Sub(u.logs.start) >= time.Hour {
+			delete(q.usage, key)
+		}
+	}
+}
+
+// allowCall counts a tool call against the session's per-minute quota.
+func (q *quotaTracker) allowCall(key string, now time.Time) error {
+	if q == nil || q.limits.CallsPerMinute <= 0 {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	u := q.session(key, now)
+	if u.calls.current(now, time.Minute) >= q.limits.CallsPerMinute {
+		return &QuotaError{
+			Quota:      "tool calls per minute",
+			Limit:      q.limits.CallsPerMinute,
+			RetryAfter: u.calls.start.Add(time.Minute).Sub(now),
+		}
+	}
+	u.calls.count++
+	return nil
+}
+
+// checkLogs fails when the session has already fetched its hourly share of
+// logs. The fetch itself is counted afterwards with recordLogs, since the
+// number of logs is only known once the query returns.
+func (q *quotaTracker) checkLogs(key string, now time.Time) error {
+	if q == nil || q.limits.LogsPerHour <= 0 {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	u := q.session(key, now)
+	if u.logs.current(now, time.Hour) >= q.limits.LogsPerHour {
+		return &QuotaError{
+			Quota:      "logs fetched per hour",
+			Limit:      q.limits.LogsPerHour,
+			RetryAfter: u.logs.start.Add(time.Hour).Sub(now),
+		}
+	}
+	return nil
+}
+
+func (q *quotaTracker) recordLogs(key string, n int, now time.Time) {
+	if q == nil || q.limits.LogsPerHour <= 0 {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	u := q.session(key, now)
+	u.logs.current(now, time.Hour)
+	u.logs.count += n
+}
+
+// withSession returns a copy of the server whose session state, and
+// quotas when the caller isn't authenticated, are kept under key.
+func (s *MCPServer) withSession(key string) *MCPServer {
+	session := *s
+	session.session = key
+	return &session
+}
+
+// quotaKey is what the current request is counted against: the caller's
+// identity, so opening new sessions doesn't reset their quota, or the
+// session when the caller isn't authenticated.
+func (s *MCPServer) quotaKey() string {
+	if s.caller != "" {
+		return s.caller
+	}
+	return s.session
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQuotaTrackerCalls(t *testing.T) {
+	q := newQuotaTracker(quotaLimits{CallsPerMinute: 2})
+	now := time.Unix(1700000000, 0)
+
+	for i := 0; i < 2; i++ {
+		if err := q.allowCall("a", now); err != nil {
+			t.Fatalf("call %d: unexpected error: %v", i, err)
+		}
+	}
+
+	err := q.allowCall("a", now.Add(20*time.Second))
+	var quotaErr *QuotaError
+	if !errors.As(err, &quotaErr) {
+		t.Fatalf("expected QuotaError, got %v", err)
+	}
+	if quotaErr.RetryAfter != 40*time.Second {
+		t.Errorf("expected retry after 40s, got %v", quotaErr.RetryAfter)
+	}
+	if !strings.Contains(err.Error(), "retry after 40s") {
+		t.Errorf("unexpected message: %s", err)
+	}
+
+	if err := q.allowCall("b", now); err != nil {
+		t.Errorf("expected separate session to have its own quota: %v", err)
+	}
+	if err := q.allowCall("a", now.Add(time.Minute)); err != nil {
+		t.Errorf("expected quota to reset after a minute: %v", err)
+	}
+}
+
+func TestQuotaTrackerLogs(t *testing.T) {
+	q := newQuotaTracker(quotaLimits{LogsPerHour: 100})
+	now := time.Unix(1700000000, 0)
+
+	if err := q.checkLogs("a", now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	q.recordLogs("a", 100, now)
+
+	if err := q.checkLogs("a", now.Add(time.Minute)); err == nil {
+		t.Error("expected logs quota to be exhausted")
+	}
+	if err := q.checkLogs("a", now.Add(time.Hour)); err != nil {
+		t.Errorf("expected logs quota to reset after an hour: %v", err)
+	}
+}
+
+func TestQuotaTrackerDisabled(t *testing.T) {
+	q := newQuotaTracker(quotaLimits{})
+	if q != nil {
+		t.Fatal("expected nil tracker when no limits are set")
+	}
+	if err := q.allowCall("a", time.Now()); err != nil {
+		t.Errorf("expected nil tracker to allow calls: %v", err)
+	}
+	q.recordLogs("a", 10, time.Now())
+}
+
+func TestQuotaTrackerSweep(t *testing.T) {
+	q := newQuotaTracker(quotaLimits{CallsPerMinute: 1})
+	now := time.Unix(1700000000, 0)
+	for i := 0; i < sweepThreshold; i++ {
+		_ = q.allowCall(string(rune('a'+i%26))+strings.Repeat("x", i), now)
+	}
+
+	_ = q.allowCall("late", now.Add(2*time.Hour))
+	if len(q.usage) != 1 {
+		t.Errorf("expected expired sessions to be swept, %d remain", len(q.usage))
+	}
+}
+
+func TestHandleToolsCallQuotaExceeded(t *testing.T) {
+	server := &MCPServer{quotas: newQuotaTracker(quotaLimits{CallsPerMinute: 1}), session: "test"}
+	params, _ := json.Marshal(ToolCallParams{Name: "unknown_tool"})
+	req := MCPRequest{Jsonrpc: "2.0", ID: 1, Method: "tools/call", Params: params}
+
+	server.HandleRequest(req)
+	resp := server.HandleRequest(req)
+	if resp.Error == nil || !strings.Contains(resp.Error.Message, "quota exceeded") {
+		t.Errorf("expected quota error, got %+v", resp.Error)
+	}
+}
+
+func TestQuotaFollowsAuthenticatedCaller(t *testing.T) {
+	server := &MCPServer{quotas: newQuotaTracker(quotaLimits{CallsPerMinute: 1})}
+	handler := httpConfig{Tokens: []string{"alice-token", "bob-token"}}.withAuth(server.httpHandler())
+	body := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"unknown_tool"}}`
+	call := func(token, session string) string {
+		t.Helper()
+		return postMCP(t, handler, body, map[string]string{"Authorization": "Bearer " + token, "Mcp-Session-Id": session}).Body.String()
+	}
+
+	call("alice-token", "s1")
+	if got := call("alice-token", "s2"); !strings.Contains(got, "quota exceeded") {
+		t.Errorf("expected a new session to share the caller's quota, got %s", got)
+	}
+	if got := call("bob-token", "s1"); strings.Contains(got, "quota exceeded") {
+		t.Errorf("expected another caller to have their own quota, got %s", got)
+	}
+}
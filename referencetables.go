@@ -0,0 +1,213 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV2"
+)
+
+const (
+	// maxReferenceKeys bounds the keys looked up in one call.
+	maxReferenceKeys = 100
+	// maxReferenceTables bounds list_reference_tables and the tables
+	// scanned when resolving a misspelled table name.
+	maxReferenceTables = 100
+)
+
+var tableIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+type ListReferenceTablesParams struct {
+	NameContains string `json:"name_contains,omitempty"`
+	Limit        int    `json:"limit,omitempty"`
+}
+
+type ReferenceTableField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type ReferenceTable struct {
+	ID          string                `json:"id"`
+	Name        string                `json:"name"`
+	Description string                `json:"description,omitempty"`
+	PrimaryKeys []string              `json:"primary_keys,omitempty"`
+	Fields      []ReferenceTableField `json:"fields,omitempty"`
+	RowCount    int64                 `json:"row_count"`
+	Source      string                `json:"source,omitempty"`
+	Status      string                `json:"status,omitempty"`
+	UpdatedAt   string                `json:"updated_at,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+}
+
+type ListReferenceTablesResult struct {
+	Tables []ReferenceTable `json:"tables"`
+	Count  int              `json:"count"`
+}
+
+type LookupReferenceTableParams struct {
+	Table string   `json:"table"`
+	Keys  []string `json:"keys"`
+}
+
+type ReferenceRow struct {
+	Key    string      `json:"key"`
+	Values interface{} `json:"values"`
+}
+
+type LookupReferenceTableResult struct {
+	Table       string         `json:"table"`
+	TableID     string         `json:"table_id"`
+	PrimaryKeys []string       `json:"primary_keys,omitempty"`
+	Rows        []ReferenceRow `json:"rows"`
+	Missing     []string       `json:"missing,omitempty"`
+	Notes       []string       `json:"notes,omitempty"`
+}
+
+func (s *MCPServer) ListReferenceTables(params ListReferenceTablesParams) (*ListReferenceTablesResult, error) {
+	limit := 50
+	if params.Limit > 0 {
+		limit = clampArgument(s, "limit", params.Limit, maxReferenceTables)
+	}
+
+	opts := datadogV2.NewListTablesOptionalParameters().WithPageLimit(int64(limit))
+	if params.NameContains != "" {
+		opts = opts.WithFilterTableNameContains(params.NameContains)
+	}
+	api := datadogV2.NewReferenceTablesApi(s.ddClient)
+	resp, _, err := api.ListTables(s.ctx, *opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reference tables: %w", err)
+	}
+
+	tables := make([]ReferenceTable, 0, len(resp.Data))
+	for _, table := range resp.Data {
+		tables = append(tables, toReferenceTable(table))
+	}
+	return &ListReferenceTablesResult{Tables: tables, Count: len(tables)}, nil
+}
+
+func toReferenceTable(table datadogV2.TableResultV2Data) ReferenceTable {
+	result := ReferenceTable{ID: table.GetId()}
+	attrs := table.Attributes
+	if attrs == nil {
+		return result
+	}
+	result.Name = attrs.GetTableName()
+	result.Description = attrs.GetDescription()
+	result.RowCount = attrs.GetRowCount()
+	result.Source = string(attrs.GetSource())
+	result.Status = attrs.GetStatus()
+	result.UpdatedAt = attrs.GetUpdatedAt()
+	result.Tags = attrs.GetTags()
+	if attrs.Schema != nil {
+		result.PrimaryKeys = attrs.Schema.PrimaryKeys
+		for _, field := range attrs.Schema.Fields {
+			result.Fields = append(result.Fields, ReferenceTableField{Name: field.Name, Type: string(field.Type)})
+		}
+	}
+	return result
+}
+
+// LookupReferenceTable fetches rows by primary key, so enrichment data
+// such as customer names can be joined into an answer.
+func (s *MCPServer) LookupReferenceTable(params LookupReferenceTableParams) (*LookupReferenceTableResult, error) {
+	if params.Table == "" {
+		return nil, fmt.Errorf("table parameter is required")
+	}
+	keys := make([]string, 0, len(params.Keys))
+	seen := make(map[string]bool)
+	for _, key := range params.Keys {
+		if key != "" && !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("keys parameter is required")
+	}
+	if len(keys) > maxReferenceKeys {
+		return nil, fmt.Errorf("too many keys: %d (max %d)", len(keys), maxReferenceKeys)
+	}
+
+	table, note, err := s.findReferenceTable(params.Table)
+	if err != nil {
+		return nil, err
+	}
+	result := &LookupReferenceTableResult{
+		Table:       table.Name,
+		TableID:     table.ID,
+		PrimaryKeys: table.PrimaryKeys,
+		Rows:        make([]ReferenceRow, 0, len(keys)),
+	}
+	if note != "" {
+		result.Notes = append(result.Notes, note)
+	}
+
+	api := datadogV2.NewReferenceTablesApi(s.ddClient)
+	resp, _, err := api.GetRowsByID(s.ctx, table.ID, keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up rows in %s: %w", table.Name, err)
+	}
+
+	found := make(map[string]bool)
+	for _, row := range resp.Data {
+		key := row.GetId()
+		found[key] = true
+		var values interface{}
+		if row.Attributes != nil {
+			values = row.Attributes.Values
+		}
+		result.Rows = append(result.Rows, ReferenceRow{Key: key, Values: values})
+	}
+	for _, key := range keys {
+		if !found[key] {
+			result.Missing = append(result.Missing, key)
+		}
+	}
+	return result, nil
+}
+
+// findReferenceTable accepts a table id or name. A misspelled name is
+// corrected when one table is clearly meant, with a note saying so.
+func (s *MCPServer) findReferenceTable(nameOrID string) (ReferenceTable, string, error) {
+	api := datadogV2.NewReferenceTablesApi(s.ddClient)
+	if tableIDPattern.MatchString(nameOrID) {
+		resp, _, err := api.GetTable(s.ctx, nameOrID)
+		if err != nil {
+			return ReferenceTable{}, "", fmt.Errorf("failed to get reference table %s: %w", nameOrID, err)
+		}
+		if resp.Data == nil {
+			return ReferenceTable{}, "", fmt.Errorf("reference table %s not found", nameOrID)
+		}
+		return toReferenceTable(*resp.Data), "", nil
+	}
+
+	opts := datadogV2.NewListTablesOptionalParameters().WithFilterTableNameExact(nameOrID)
+	resp, _, err := api.ListTables(s.ctx, *opts)
+	if err != nil {
+		return ReferenceTable{}, "", fmt.Errorf("failed to find reference table %s: %w", nameOrID, err)
+	}
+	for _, table := range resp.Data {
+		if t := toReferenceTable(table); t.Name == nameOrID {
+			return t, "", nil
+		}
+	}
+
+	all, _, err := api.ListTables(s.ctx, *datadogV2.NewListTablesOptionalParameters().WithPageLimit(maxReferenceTables))
+	if err != nil {
+		return ReferenceTable{}, "", fmt.Errorf("failed to list reference tables: %w", err)
+	}
+	byName := make(map[string]ReferenceTable)
+	entities := make([]namedEntity, 0, len(all.Data))
+	for _, table := range all.Data {
+		t := toReferenceTable(table)
+		byName[t.Name] = t
+		entities = append(entities, namedEntity{Name: t.Name})
+	}
+	resolution := resolveName(nameOrID, entities)
+	if !resolution.found() {
+		return ReferenceTable{}, "", fmt.Errorf("%s", resolution.note("reference table", nameOrID))
+	}
+	return byName[resolution.Entity.Name], resolution.note("reference table", nameOrID), nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testTablesJSON = `{"data":[
+	{"id":"00000000-0000-0000-0000-000000000001","type":"reference_table","attributes":{"table_name":"customers","row_count":2,
+		"schema":{"primary_keys":["customer_id"],"fields":[{"name":"customer_id","type":"STRING"},{"name":"name","type":"STRING"}]}}},
+	{"id":"00000000-0000-0000-0000-000000000002","type":"reference_table","attributes":{"table_name":"regions","row_count":5,
+		"schema":{"primary_keys":["code"],"fields":[{"name":"code","type":"STRING"}]}}}]}`
+
+func fakeReferenceTables(t *testing.T, rowIDs *[]string) *MCPServer {
+	t.Helper()
+	return newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/api/v2/reference-tables/tables":
+			exact := r.URL.Query().Get("filter[table_name][exact]")
+			if exact != "" && exact != "customers" && exact != "regions" {
+				_, _ = w.Write([]byte(`{"data":[]}`))
+				return
+			}
+			_, _ = w.Write([]byte(testTablesJSON))
+		case strings.HasSuffix(r.URL.Path, "/00000000-0000-0000-0000-000000000001/rows"):
+			*rowIDs = r.URL.Query()["row_id"]
+			_, _ = w.Write([]byte(`{"data":[{"id":"c-1","type":"row","attributes":{"values":{"customer_id":"c-1","name":"Acme"}}}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+func TestListReferenceTables(t *testing.T) {
+	server := fakeReferenceTables(t, nil)
+
+	result, err := server.ListReferenceTables(ListReferenceTablesParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Count != 2 || result.Tables[0].Name != "customers" || !reflect.DeepEqual(result.Tables[0].PrimaryKeys, []string{"customer_id"}) {
+		t.Fatalf("unexpected tables: %+v", result.Tables)
+	}
+	if len(result.Tables[0].Fields) != 2 || result.Tables[0].Fields[1] != (ReferenceTableField{Name: "name", Type: "STRING"}) {
+		t.Fatalf("unexpected fields: %+v", result.Tables[0].Fields)
+	}
+}
+
+func TestLookupReferenceTable(t *testing.T) {
+	var rowIDs []string
+	server := fakeReferenceTables(t, &rowIDs)
+
+	result, err := server.LookupReferenceTable(LookupReferenceTableParams{Table: "customers", Keys: []string{"c-1", "c-2", "c-1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rowIDs, []string{"c-1", "c-2"}) {
+		t.Fatalf("expected deduplicated keys, got %v", rowIDs)
+	}
+	if len(result.Rows) != 1 || result.Rows[0].Key != "c-1" || result.Rows[0].Values.(map[string]interface{})["name"] != "Acme" {
+		t.Fatalf("unexpected rows: %+v", result.Rows)
+	}
+	if !reflect.DeepEqual(result.Missing, []string{"c-2"}) || len(result.Notes) != 0 {
+		t.Fatalf("expected c-2 missing and no notes, got %+v", result)
+	}
+
+	result, err = server.LookupReferenceTable(LookupReferenceTableParams{Table: "custmers", Keys: []string{"c-1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Table != "customers" || len(result.Notes) != 1 || !strings.Contains(result.Notes[0], "closest match") {
+		t.Fatalf("expected a corrected table name, got %+v", result)
+	}
+
+	if _, err := server.LookupReferenceTable(LookupReferenceTableParams{Table: "invoices", Keys: []string{"1"}}); err == nil || !strings.Contains(err.Error(), `No reference table named "invoices"`) {
+		t.Fatalf("expected an unknown table error, got %v", err)
+	}
+	if _, err := server.LookupReferenceTable(LookupReferenceTableParams{Table: "customers"}); err == nil {
+		t.Fatal("expected missing keys to be rejected")
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadog"
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV2"
+)
+
+const (
+	// refinementSplits is how many sub-ranges a capped search suggests.
+	refinementSplits = 4
+	// minRefinementWindow is the shortest sub-range worth suggesting.
+	minRefinementWindow = time.Second
+)
+
+// SuggestedWindow is a narrower range to re-run a capped search over.
+// Count is nil when Datadog couldn't count it.
+type SuggestedWindow struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Count *int64 `json:"count,omitempty"`
+}
+
+// Refinement tells the caller how to drill into a search that hit its
+// limit: the total number of matches and the range split into equal
+// windows with their own counts.
+type Refinement struct {
+	Returned int               `json:"returned"`
+	Total    *int64            `json:"total,omitempty"`
+	Windows  []SuggestedWindow `json:"windows,omitempty"`
+	Guidance string            `json:"guidance"`
+}
+
+// refineLogs splits from..to into refinementSplits windows and counts the
+// logs matching query in each with the aggregate API, so the caller can
+// pick where to look next instead of blindly shrinking the range.
+func (s *MCPServer) refineLogs(query string, from, to time.Time, returned int) *Refinement {
+	refinement := &Refinement{Returned: returned}
+	width := to.Sub(from) / refinementSplits
+	if width < minRefinementWindow {
+		refinement.Guidance = fmt.Sprintf("Only the newest %d matching logs were returned and the range is too short to split. Add filters to the query, or raise limit.", returned)
+		return refinement
+	}
+
+	windows := make([]SuggestedWindow, refinementSplits)
+	var wg sync.WaitGroup
+	for i := range windows {
+		start := from.Add(time.Duration(i) * width)
+		end := start.Add(width)
+		if i == refinementSplits-1 {
+			end = to
+		}
+		windows[i] = SuggestedWindow{From: start.Format(time.RFC3339), To: end.Format(time.RFC3339)}
+		wg.Add(1)
+		go func(w *SuggestedWindow, start, end time.Time) {
+			defer wg.Done()
+			if count, err := s.countLogs(query, start, end); err == nil {
+				w.Count = &count
+			}
+		}(&windows[i], start, end)
+	}
+	wg.Wait()
+	refinement.Windows = windows
+
+	var total int64
+	counted := true
+	busiest := -1
+	for i, w := range windows {
+		if w.Count == nil {
+			counted = false
+			continue
+		}
+		total += *w.Count
+		if busiest < 0 || *w.Count > *windows[busiest].Count {
+			busiest = i
+		}
+	}
+	if !counted {
+		refinement.Guidance = fmt.Sprintf("Only the newest %d matching logs were returned. Counts per window weren't available; re-run the query with from and to set to one of the suggested windows, or add filters.", returned)
+		return refinement
+	}
+	refinement.Total = &total
+	refinement.Guidance = fmt.Sprintf("Only the newest %d of %d matching logs were returned. Re-run the query with from and to set to one of the suggested windows; the busiest is %s to %s with %d logs. Split again if a window still has more logs than limit, or add filters.",
+		returned, total, windows[busiest].From, windows[busiest].To, *windows[busiest].Count)
+	return refinement
+}
+
+// countLogs returns how many logs match query between from and to.
+func (s *MCPServer) countLogs(query string, from, to time.Time) (int64, error) {
+	body := datadogV2.LogsAggregateRequest{
+		Compute: []datadogV2.LogsCompute{{
+			Aggregation: datadogV2.LOGSAGGREGATIONFUNCTION_COUNT,
+			Type:        datadogV2.LOGSCOMPUTETYPE_TOTAL.Ptr(),
+		}},
+		Filter: &datadogV2.LogsQueryFilter{
+			From:  datadog.PtrString(from.Format(time.RFC3339)),
+			To:    datadog.PtrString(to.Format(time.RFC3339)),
+			Query: datadog.PtrString(query),
+		},
+	}
+	api := datadogV2.NewLogsApi(s.ddClient)
+	resp, _, err := api.AggregateLogs(s.ctx, body)
+	if err != nil {
+		return 0, err
+	}
+	if resp.Data == nil || len(resp.Data.Buckets) == 0 {
+		return 0, nil
+	}
+	for _, value := range resp.Data.Buckets[0].Computes {
+		if value.LogsAggregateBucketValueSingleNumber != nil {
+			return int64(*value.LogsAggregateBucketValueSingleNumber), nil
+		}
+	}
+	return 0, fmt.Errorf("aggregate response has no count")
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestQueryLogsSuggestsNarrowerWindows(t *testing.T) {
+	counts := map[string]int{"2026-01-20T09:00:00Z": 10, "2026-01-20T10:00:00Z": 400, "2026-01-20T11:00:00Z": 30, "2026-01-20T12:00:00Z": 0}
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v2/logs/events/search":
+			_, _ = w.Write([]byte(`{"data":[{"id":"1","attributes":{"message":"a"}},{"id":"2","attributes":{"message":"b"}}],"meta":{"page":{"after":"next"}}}`))
+		case "/api/v2/logs/analytics/aggregate":
+			data, _ := io.ReadAll(r.Body)
+			var body struct {
+				Filter struct {
+					From string `json:"from"`
+				} `json:"filter"`
+			}
+			_ = json.Unmarshal(data, &body)
+			fmt.Fprintf(w, `{"data":{"buckets":[{"by":{},"computes":{"c0":%d}}]}}`, counts[body.Filter.From])
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	result, err := server.QueryLogs(QueryLogsParams{Query: "status:error", From: "2026-01-20T09:00:00Z", To: "2026-01-20T13:00:00Z", Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	refinement := result.Refinement
+	if refinement == nil || len(refinement.Windows) != 4 {
+		t.Fatalf("expected four suggested windows, got %+v", refinement)
+	}
+	if refinement.Total == nil || *refinement.Total != 440 || refinement.Returned != 2 {
+		t.Fatalf("expected 2 of 440 logs, got %+v", refinement)
+	}
+	if w := refinement.Windows[1]; w.From != "2026-01-20T10:00:00Z" || w.To != "2026-01-20T11:00:00Z" || *w.Count != 400 {
+		t.Fatalf("unexpected second window: %+v", w)
+	}
+	if !strings.Contains(refinement.Guidance, "busiest is 2026-01-20T10:00:00Z to 2026-01-20T11:00:00Z with 400 logs") {
+		t.Fatalf("unexpected guidance: %s", refinement.Guidance)
+	}
+}
+
+func TestQueryLogsWithinLimitHasNoRefinement(t *testing.T) {
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":[{"id":"1","attributes":{"message":"a"}}]}`))
+	})
+
+	result, err := server.QueryLogs(QueryLogsParams{Query: "*", From: "2026-01-20T09:00:00Z", To: "2026-01-20T13:00:00Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Refinement != nil {
+		t.Fatalf("expected no refinement, got %+v", result.Refinement)
+	}
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+)
+
+const (
+	defaultReportPeriod = 7 * 24 * time.Hour
+	// maxReportSeries bounds the rows of a metric section's table.
+	maxReportSeries = 20
+)
+
+// reportSectionTypes are the kinds of section a report can contain.
+var reportSectionTypes = []string{"logs", "metric", "slo"}
+
+// ReportDefinition is an operator-defined report: a title and a list of
+// sections, each rendered from one query over the report's period.
+type ReportDefinition struct {
+	Name        string          `json:"name"`
+	Title       string          `json:"title"`
+	Description string          `json:"description,omitempty"`
+	Period      string          `json:"period,omitempty"`
+	Sections    []ReportSection `json:"sections"`
+}
+
+// ReportSection is one heading of a report. Logs sections count the logs
+// matching Query, metric sections summarize the series of Query, and SLO
+// sections report the SLO named by SLOID. Text is shown under the heading.
+type ReportSection struct {
+	Title string `json:"title"`
+	Type  string `json:"type"`
+	Query string `json:"query,omitempty"`
+	SLOID string `json:"slo_id,omitempty"`
+	Text  string `json:"text,omitempty"`
+}
+
+type GenerateReportParams struct {
+	Report string `json:"report"`
+	From   string `json:"from,omitempty"`
+	To     string `json:"to,omitempty"`
+}
+
+// reportRegistry holds the configured report templates. A nil registry
+// has none, and generate_report isn't listed.
+type reportRegistry struct {
+	names  []string
+	byName map[string]ReportDefinition
+}
+
+// loadReports reads report templates and checks every section can be
+// rendered.
+func loadReports(path string) (*reportRegistry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reports file: %w", err)
+	}
+	var file struct {
+		Reports []ReportDefinition `json:"reports"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse reports file: %w", err)
+	}
+
+	registry := &reportRegistry{byName: make(map[string]ReportDefinition)}
+	for _, report := range file.Reports {
+		if report.Name == "" || len(report.Sections) == 0 {
+			return nil, fmt.Errorf("report %q needs a name and at least one section", report.Name)
+		}
+		if _, ok := registry.byName[report.Name]; ok {
+			return nil, fmt.Errorf("report %s is defined twice", report.Name)
+		}
+		if _, err := parseDurationParam(report.Period, defaultReportPeriod); err != nil {
+			return nil, fmt.Errorf("report %s: invalid period: %w", report.Name, err)
+		}
+		for i, section := range report.Sections {
+			switch section.Type {
+			case "logs", "metric":
+				if section.Query == "" {
+					return nil, fmt.Errorf("report %s: section %d needs a query", report.Name, i+1)
+				}
+			case "slo":
+				if section.SLOID == "" {
+					return nil, fmt.Errorf("report %s: section %d needs an slo_id", report.Name, i+1)
+				}
+			default:
+				return nil, fmt.Errorf("report %s: section %d has invalid type %q (use %s)", report.Name, i+1, section.Type, strings.Join(reportSectionTypes, ", "))
+			}
+		}
+		if report.Title == "" {
+			report.Title = report.Name
+		}
+		registry.byName[report.Name] = report
+		registry.names = append(registry.names, report.Name)
+	}
+	sort.Strings(registry.names)
+	return registry, nil
+}
+
+func (r *reportRegistry) list() []Tool {
+	if r == nil || len(r.names) == 0 {
+		return nil
+	}
+	return []Tool{{
+		Name:        "generate_report",
+		Description: "Render a configured report template (sections of log, metric and SLO queries) as markdown, for recurring reviews such as a weekly ops review. Reports: " + strings.Join(r.names, ", "),
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]SchemaProperty{
+				"report": {
+					Type:        "string",
+					Description: "Report name: " + strings.Join(r.names, ", "),
+				},
+				"from": {
+					Type:        "string",
+					Description: "Start of the period (RFC3339 or relative, e.g., '7d'). Defaults to the report's period before 'to'.",
+				},
+				"to": {
+					Type:        "string",
+					Description: "End of the period (RFC3339 or relative). Defaults to now.",
+				},
+			},
+			Required: []string{"report"},
+		},
+		Annotations: readOnlyToolAnnotations(),
+	}}
+}
+
+// GenerateReport renders a report template as markdown. A section that
+// fails shows its error and the rest of the report is still rendered.
+func (s *MCPServer) GenerateReport(params GenerateReportParams) (string, error) {
+	if s.reports == nil {
+		return "", fmt.Errorf("no reports are configured; set DD_MCP_REPORTS_FILE")
+	}
+	report, ok := s.reports.byName[params.Report]
+	if !ok {
+		return "", fmt.Errorf("unknown report %q (available: %s)", params.Report, strings.Join(s.reports.names, ", "))
+	}
+	to, err := s.timeParam("to", params.To, time.Now())
+	if err != nil {
+		return "", err
+	}
+	period, _ := parseDurationParam(report.Period, defaultReportPeriod)
+	from, err := s.timeParam("from", params.From, to.Add(-period))
+	if err != nil {
+		return "", err
+	}
+	if !from.Before(to) {
+		return "", fmt.Errorf("from must be before to")
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", report.Title)
+	fmt.Fprintf(&b, "_%s to %s_\n\n", from.UTC().Format(time.RFC3339), to.UTC().Format(time.RFC3339))
+	if report.Description != "" {
+		fmt.Fprintf(&b, "%s\n\n", report.Description)
+	}
+	for i, section := range report.Sections {
+		s.reportProgress(i, len(report.Sections), "rendering "+section.Title, "")
+		title := section.Title
+		if title == "" {
+			title = section.Query + section.SLOID
+		}
+		fmt.Fprintf(&b, "## %s\n\n", title)
+		if section.Text != "" {
+			fmt.Fprintf(&b, "%s\n\n", section.Text)
+		}
+
+		var body string
+		switch section.Type {
+		case "logs":
+			body, err = s.reportLogs(section.Query, from, to)
+		case "metric":
+			body, err = s.reportMetric(section.Query, from, to)
+		case "slo":
+			body, err = s.reportSLO(section.SLOID, from, to)
+		}
+		if err != nil {
+			body = fmt.Sprintf("_Couldn't build this section: %v_\n", err)
+		}
+		b.WriteString(body)
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n") + "\n", nil
+}
+
+// reportLogs counts the logs in the period and compares them with the
+// period before.
+func (s *MCPServer) reportLogs(query string, from, to time.Time) (string, error) {
+	count, err := s.countLogs(query, from, to)
+	if err != nil {
+		return "", err
+	}
+	previous, err := s.countLogs(query, from.Add(-to.Sub(from)), from)
+	if err != nil {
+		return "", err
+	}
+	line := fmt.Sprintf("**%d** logs matching `%s` (previous period: %d", count, query, previous)
+	if previous > 0 {
+		line += fmt.Sprintf(", %+.1f%%", float64(count-previous)/float64(previous)*100)
+	}
+	return line + ").\n", nil
+}
+
+// reportMetric summarizes each series of the query in a table.
+func (s *MCPServer) reportMetric(query string, from, to time.Time) (string, error) {
+	resp, err := s.queryMetrics(from, to, query)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Series) == 0 {
+		return fmt.Sprintf("No data for `%s` in this period.\n", query), nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "`%s`\n\n", query)
+	b.WriteString("| Scope | Avg | Min | Max | Last |\n|---|---|---|---|---|\n")
+	for i := range resp.Series {
+		if i == maxReportSeries {
+			fmt.Fprintf(&b, "\n%d more series not shown.\n", len(resp.Series)-maxReportSeries)
+			break
+		}
+		avg, low, high, last, ok := summarizeSeries(&resp.Series[i])
+		if !ok {
+			fmt.Fprintf(&b, "| %s | - | - | - | - |\n", resp.Series[i].GetScope())
+			continue
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", resp.Series[i].GetScope(), formatReportValue(avg), formatReportValue(low), formatReportValue(high), formatReportValue(last))
+	}
+	return b.String(), nil
+}
+
+// reportSLO reports the SLO's status over the period against its target.
+func (s *MCPServer) reportSLO(id string, from, to time.Time) (string, error) {
+	api := datadogV1.NewServiceLevelObjectivesApi(s.ddClient)
+	resp, _, err := api.GetSLOHistory(s.ctx, id, from.Unix(), to.Unix())
+	if err != nil {
+		return "", fmt.Errorf("failed to get SLO %s history: %w", id, err)
+	}
+	data := resp.Data
+	if data == nil || data.Overall == nil || data.Overall.SliValue.Get() == nil {
+		return fmt.Sprintf("No SLI data for SLO %s in this period.\n", id), nil
+	}
+	sli := *data.Overall.SliValue.Get()
+
+	var b strings.Builder
+	if name := data.Overall.GetName(); name != "" {
+		fmt.Fprintf(&b, "%s: ", name)
+	}
+	fmt.Fprintf(&b, "SLI **%.3f%%**", sli)
+	timeframes := make([]string, 0, len(data.Thresholds))
+	for timeframe := range data.Thresholds {
+		timeframes = append(timeframes, timeframe)
+	}
+	sort.Strings(timeframes)
+	if len(timeframes) > 0 {
+		threshold := data.Thresholds[timeframes[0]]
+		status := "met"
+		if sli < threshold.Target {
+			status = "missed"
+		}
+		fmt.Fprintf(&b, " against a %g%% target (%s): %s", threshold.Target, timeframes[0], status)
+		if budget, ok := data.Overall.ErrorBudgetRemaining[timeframes[0]]; ok {
+			fmt.Fprintf(&b, ". Error budget remaining: %.1f%%", budget)
+		}
+	}
+	b.WriteString(".\n")
+	fmt.Fprintf(&b, "\n[SLO in Datadog](%s)\n", s.appURL("/slo?slo_id="+id))
+	return b.String(), nil
+}
+
+// summarizeSeries returns the mean, minimum, maximum and latest value of
+// a series, skipping gaps.
+func summarizeSeries(meta *datadogV1.MetricsQueryMetadata) (avg, low, high, last float64, ok bool) {
+	var sum float64
+	var n int
+	low, high = math.Inf(1), math.Inf(-1)
+	for _, p := range meta.Pointlist {
+		if len(p) < 2 || p[1] == nil {
+			continue
+		}
+		v := *p[1]
+		sum += v
+		n++
+		low, high, last = math.Min(low, v), math.Max(high, v), v
+	}
+	if n == 0 {
+		return 0, 0, 0, 0, false
+	}
+	return sum / float64(n), low, high, last, true
+}
+
+func formatReportValue(v float64) string {
+	return fmt.Sprintf("%.4g", v)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const testReports = `{"reports": [{
+  "name": "weekly-ops",
+  "title": "Weekly Ops Review",
+  "sections": [
+    {"title": "Errors", "type": "logs", "query": "status:error"},
+    {"title": "Latency", "type": "metric", "query": "avg:latency{*} by {env}"},
+    {"title": "Availability", "type": "slo", "slo_id": "slo-1", "text": "Agreed with payments."},
+    {"title": "Broken", "type": "slo", "slo_id": "missing"}
+  ]
+}]}`
+
+func TestGenerateReport(t *testing.T) {
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v2/logs/analytics/aggregate":
+			data, _ := io.ReadAll(r.Body)
+			var body struct {
+				Filter struct {
+					From string `json:"from"`
+				} `json:"filter"`
+			}
+			_ = json.Unmarshal(data, &body)
+			count := 120
+			if body.Filter.From == "2026-01-05T00:00:00Z" {
+				count = 100
+			}
+			fmt.Fprintf(w, `{"data":{"buckets":[{"by":{},"computes":{"c0":%d}}]}}`, count)
+		case "/api/v1/query":
+			_, _ = w.Write([]byte(`{"status":"ok","series":[{"scope":"env:prod","pointlist":[[1,10],[2,null],[3,30]]}]}`))
+		case "/api/v1/slo/slo-1/history":
+			_, _ = w.Write([]byte(`{"data":{"overall":{"name":"Checkout","sli_value":99.5,"error_budget_remaining":{"7d":-400}},"thresholds":{"7d":{"target":99.9,"timeframe":"7d"}}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	registry, err := loadReports(writeTempFile(t, "reports.json", testReports))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.reports = registry
+	if tools := registry.list(); len(tools) != 1 || !strings.Contains(tools[0].Description, "weekly-ops") {
+		t.Fatalf("expected generate_report naming the report, got %+v", tools)
+	}
+
+	report, err := server.GenerateReport(GenerateReportParams{Report: "weekly-ops", From: "2026-01-12T00:00:00Z", To: "2026-01-19T00:00:00Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# Weekly Ops Review",
+		"**120** logs matching `status:error` (previous period: 100, +20.0%).",
+		"| env:prod | 20 | 10 | 30 | 30 |",
+		"Agreed with payments.",
+		"Checkout: SLI **99.500%** against a 99.9% target (7d): missed. Error budget remaining: -400.0%.",
+		"## Broken\n\n_Couldn't build this section:",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report is missing %q:\n%s", want, report)
+		}
+	}
+
+	if _, err := server.GenerateReport(GenerateReportParams{Report: "monthly"}); err == nil || !strings.Contains(err.Error(), "weekly-ops") {
+		t.Fatalf("expected an unknown report to list the available ones, got %v", err)
+	}
+}
+
+func TestLoadReportsRejectsInvalidSections(t *testing.T) {
+	for _, content := range []string{
+		`{"reports": [{"name": "r", "sections": [{"type": "traces", "query": "x"}]}]}`,
+		`{"reports": [{"name": "r", "sections": [{"type": "logs"}]}]}`,
+		`{"reports": [{"name": "r", "sections": [{"type": "slo"}]}]}`,
+		`{"reports": [{"name": "r", "period": "soon", "sections": [{"type": "logs", "query": "x"}]}]}`,
+		`{"reports": [{"name": "r", "sections": []}]}`,
+	} {
+		if _, err := loadReports(writeTempFile(t, "reports.json", content)); err == nil {
+			t.Errorf("expected %s to be rejected", content)
+		}
+	}
+}
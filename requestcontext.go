@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// defaultRequestTimeout bounds a request when DD_MCP_REQUEST_TIMEOUT is
+// unset. It is generous because some tools page through many results.
+const defaultRequestTimeout = 2 * time.Minute
+
+// datadogCredentials is the key pair Datadog calls are made with: the
+// server's own, or a user's in gateway mode.
+type datadogCredentials struct {
+	APIKey string
+	AppKey string
+}
+
+// datadogContext derives a context for Datadog calls from parent, carrying
+// the server's credentials, site and the request's attribution.
+func (s *MCPServer) datadogContext(parent context.Context) context.Context {
+	return withAttribution(newDatadogContext(parent, s.credentials.APIKey, s.credentials.AppKey, s.site), s.attribution)
+}
+
+// withContext returns a copy of the server whose Datadog calls run under a
+// context derived from parent, ending after requestTimeout. Every request
+// gets its own, so concurrent requests can be cancelled independently. The
+// caller must call the returned cancel function.
+func (s *MCPServer) withContext(parent context.Context) (*MCPServer, context.CancelFunc) {
+	ctx := s.datadogContext(parent)
+	var cancel context.CancelFunc
+	if s.requestTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, s.requestTimeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	scoped := *s
+	scoped.ctx = ctx
+	return &scoped, cancel
+}
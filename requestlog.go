@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultRequestLogPayloadBytes = 1024
+
+// scrubbedKeys are argument and header names whose values are never
+// logged, compared case-insensitively.
+var scrubbedKeys = []string{
+	"api_key", "apikey", "app_key", "appkey", "application_key",
+	"dd-api-key", "dd-application-key", "authorization", "cookie",
+	"password", "secret", "token", "access_token", "jwt",
+}
+
+// requestLogger writes a debug record for every JSON-RPC request and its
+// response, so protocol problems with a particular client can be
+// reproduced from the logs. It is shared by every copy of the server.
+type requestLogger struct {
+	logger *slog.Logger
+	// payloads adds each request's params and response's result,
+	// scrubbed and cut to maxPayloadBytes; otherwise only the envelope
+	// is logged.
+	payloads        bool
+	maxPayloadBytes int
+	// sampleRate is the share of successful requests logged; errors are
+	// always logged.
+	sampleRate float64
+	// clients limits logging to sessions these clients initialized.
+	clients []string
+	random  func() float64
+
+	mu sync.Mutex
+	// sessions maps each session to the client that initialized it.
+	sessions map[string]string
+}
+
+// loadRequestLogger reads DD_MCP_REQUEST_LOG ("envelope" or "payload")
+// and its options. It returns nil when request logging is off.
+func loadRequestLogger(w io.Writer) (*requestLogger, error) {
+	mode := strings.ToLower(strings.TrimSpace(os.Getenv("DD_MCP_REQUEST_LOG")))
+	if mode == "" || mode == "off" {
+		return nil, nil
+	}
+	if mode != "envelope" && mode != "payload" {
+		return nil, fmt.Errorf("invalid DD_MCP_REQUEST_LOG %q: use off, envelope or payload", mode)
+	}
+	sampleRate := 1.0
+	if value := os.Getenv("DD_MCP_REQUEST_LOG_SAMPLE_RATE"); value != "" {
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil || rate < 0 || rate > 1 {
+			return nil, fmt.Errorf("invalid DD_MCP_REQUEST_LOG_SAMPLE_RATE %q: use a number from 0 to 1", value)
+		}
+		sampleRate = rate
+	}
+	maxPayloadBytes := defaultRequestLogPayloadBytes
+	if value := os.Getenv("DD_MCP_REQUEST_LOG_PAYLOAD_BYTES"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid DD_MCP_REQUEST_LOG_PAYLOAD_BYTES %q: use a positive number of bytes", value)
+		}
+		maxPayloadBytes = n
+	}
+	return newRequestLogger(w, mode == "payload", sampleRate, maxPayloadBytes, splitList(os.Getenv("DD_MCP_REQUEST_LOG_CLIENTS"))), nil
+}
+
+func newRequestLogger(w io.Writer, payloads bool, sampleRate float64, maxPayloadBytes int, clients []string) *requestLogger {
+	for i, client := range clients {
+		clients[i] = strings.ToLower(client)
+	}
+	return &requestLogger{
+		logger:          slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		payloads:        payloads,
+		maxPayloadBytes: maxPayloadBytes,
+		sampleRate:      sampleRate,
+		clients:         clients,
+		random:          rand.Float64,
+		sessions:        make(map[string]string),
+	}
+}
+
+// log records one request and its response.
+func (l *requestLogger) log(session string, req MCPRequest, resp MCPResponse, elapsed time.Duration) {
+	if l == nil {
+		return
+	}
+	client := l.client(session, req)
+	if len(l.clients) > 0 && !slices.Contains(l.clients, strings.ToLower(strings.SplitN(client, "/", 2)[0])) {
+		return
+	}
+	if resp.Error == nil && l.sampleRate < 1 && l.random() >= l.sampleRate {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.Int("id", req.ID),
+		slog.String("method", req.Method),
+		slog.String("session", session),
+		slog.Float64("duration_ms", float64(elapsed.Microseconds())/1000),
+		slog.Int("request_bytes", len(req.Params)),
+		slog.Int("response_bytes", len(resp.Result)),
+	}
+	if client != "" {
+		attrs = append(attrs, slog.String("client", client))
+	}
+	if tool := toolName(req); tool != "" {
+		attrs = append(attrs, slog.String("tool", tool))
+	}
+	if resp.Error != nil {
+		attrs = append(attrs, slog.Int("error_code", resp.Error.Code), slog.String("error", resp.Error.Message))
+	}
+	if l.payloads {
+		if len(req.Params) > 0 {
+			attrs = append(attrs, slog.String("params", l.payload(req.Params)))
+		}
+		if len(resp.Result) > 0 {
+			attrs = append(attrs, slog.String("result", l.payload(resp.Result)))
+		}
+	}
+	l.logger.LogAttrs(context.Background(), slog.LevelDebug, "mcp request", attrs...)
+}
+
+// client returns the name and version of the client that initialized a
+// session, remembering it when req is the initialize request.
+func (l *requestLogger) client(session string, req MCPRequest) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if req.Method == "initialize" {
+		var init struct {
+			ClientInfo struct {
+				Name    string `json:"name"`
+				Version string `json:"version"`
+			} `json:"clientInfo"`
+		}
+		if json.Unmarshal(req.Params, &init) == nil && init.ClientInfo.Name != "" {
+			if _, ok := l.sessions[session]; !ok && len(l.sessions) >= sweepThreshold {
+				clear(l.sessions)
+			}
+			l.sessions[session] = strings.TrimSuffix(init.ClientInfo.Name+"/"+init.ClientInfo.Version, "/")
+		}
+	}
+	return l.sessions[session]
+}
+
+// payload scrubs credentials from a JSON payload and cuts it to the
+// configured size.
+func (l *requestLogger) payload(raw json.RawMessage) string {
+	var value interface{}
+	text := string(raw)
+	if json.Unmarshal(raw, &value) == nil {
+		if data, err := json.Marshal(scrubPayload(value)); err == nil {
+			text = string(data)
+		}
+	} else {
+		// Payloads that aren't JSON could hold anything.
+		text = "[unparsed]"
+	}
+	if len(text) > l.maxPayloadBytes {
+		cut, _ := truncateUTF8(text, l.maxPayloadBytes)
+		text = fmt.Sprintf("%s...(%d more bytes)", cut, len(text)-len(cut))
+	}
+	return text
+}
+
+// scrubPayload replaces the values of credential-like keys, at any depth.
+func scrubPayload(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if slices.Contains(scrubbedKeys, strings.ToLower(key)) {
+				v[key] = "[scrubbed]"
+				continue
+			}
+			v[key] = scrubPayload(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = scrubPayload(item)
+		}
+	}
+	return value
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readRequestLog(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("expected JSON records, got %q", line)
+		}
+		records = append(records, record)
+	}
+	buf.Reset()
+	return records
+}
+
+func TestRequestLoggerPayloads(t *testing.T) {
+	var buf bytes.Buffer
+	server := &MCPServer{session: "http:abc", requestLog: newRequestLogger(&buf, true, 1, 80, nil)}
+
+	server.HandleRequest(MCPRequest{Jsonrpc: "2.0", ID: 1, Method: "initialize", Params: json.RawMessage(`{"clientInfo":{"name":"Cursor","version":"1.2"}}`)})
+	records := readRequestLog(t, &buf)
+	if len(records) != 1 || records[0]["level"] != "DEBUG" || records[0]["method"] != "initialize" || records[0]["client"] != "Cursor/1.2" {
+		t.Fatalf("unexpected initialize record %v", records)
+	}
+	if result, _ := records[0]["result"].(string); !strings.Contains(result, "more bytes)") || len(result) > 120 {
+		t.Errorf("expected the result cut to the payload size, got %q", result)
+	}
+
+	params := json.RawMessage(`{"name":"no_such_tool","arguments":{"api_key":"abc123","nested":[{"Password":"hunter2"}]}}`)
+	server.HandleRequest(MCPRequest{Jsonrpc: "2.0", ID: 2, Method: "tools/call", Params: params})
+	records = readRequestLog(t, &buf)
+	if len(records) != 1 {
+		t.Fatalf("expected one record, got %v", records)
+	}
+	record := records[0]
+	if record["tool"] != "no_such_tool" || record["client"] != "Cursor/1.2" || record["error_code"] == nil || record["session"] != "http:abc" {
+		t.Errorf("unexpected envelope %v", record)
+	}
+	logged, _ := record["params"].(string)
+	if strings.Contains(logged, "abc123") || strings.Contains(logged, "hunter2") || !strings.Contains(logged, "[scrubbed]") {
+		t.Errorf("expected credentials scrubbed, got %q", logged)
+	}
+}
+
+func TestRequestLoggerSampling(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newRequestLogger(&buf, false, 0.25, defaultRequestLogPayloadBytes, []string{"Cursor"})
+	logger.random = func() float64 { return 0.5 }
+
+	init := MCPRequest{ID: 1, Method: "initialize", Params: json.RawMessage(`{"clientInfo":{"name":"cursor"}}`)}
+	logger.log("a", init, MCPResponse{Result: json.RawMessage(`{}`)}, time.Millisecond)
+	logger.log("a", MCPRequest{ID: 2, Method: "tools/list"}, MCPResponse{Error: &MCPError{Code: -32601, Message: "nope"}}, time.Millisecond)
+	records := readRequestLog(t, &buf)
+	if len(records) != 1 || records[0]["error"] != "nope" || records[0]["params"] != nil {
+		t.Fatalf("expected only the error, without payloads, got %v", records)
+	}
+
+	logger.random = func() float64 { return 0.1 }
+	logger.log("a", MCPRequest{ID: 3, Method: "tools/list"}, MCPResponse{}, time.Millisecond)
+	logger.log("b", MCPRequest{ID: 4, Method: "tools/list"}, MCPResponse{}, time.Millisecond)
+	if records := readRequestLog(t, &buf); len(records) != 1 || records[0]["session"] != "a" {
+		t.Fatalf("expected the sampled request from the listed client only, got %v", records)
+	}
+}
+
+func TestLoadRequestLogger(t *testing.T) {
+	t.Setenv("DD_MCP_REQUEST_LOG", "")
+	if logger, err := loadRequestLogger(&bytes.Buffer{}); err != nil || logger != nil {
+		t.Fatalf("expected logging off by default, got %v, %v", logger, err)
+	}
+	t.Setenv("DD_MCP_REQUEST_LOG", "payload")
+	t.Setenv("DD_MCP_REQUEST_LOG_SAMPLE_RATE", "1.5")
+	if _, err := loadRequestLogger(&bytes.Buffer{}); err == nil {
+		t.Fatal("expected a sample rate above 1 to be rejected")
+	}
+	t.Setenv("DD_MCP_REQUEST_LOG_SAMPLE_RATE", "0.1")
+	logger, err := loadRequestLogger(&bytes.Buffer{})
+	if err != nil || !logger.payloads || logger.sampleRate != 0.1 || logger.maxPayloadBytes != defaultRequestLogPayloadBytes {
+		t.Fatalf("unexpected logger %+v, %v", logger, err)
+	}
+	t.Setenv("DD_MCP_REQUEST_LOG", "verbose")
+	if _, err := loadRequestLogger(&bytes.Buffer{}); err == nil {
+		t.Fatal("expected an unknown mode to be rejected")
+	}
+}
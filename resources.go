@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// maxDocuments bounds the generated documents kept per org; the oldest
+// are dropped first.
+const maxDocuments = 50
+
+// Resource describes a document the server generated, such as a
+// postmortem draft, which clients read with resources/read.
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text"`
+}
+
+type ResourcesCapability struct{}
+
+type ResourcesListResult struct {
+	Resources []Resource `json:"resources"`
+}
+
+type ResourceReadParams struct {
+	URI string `json:"uri"`
+}
+
+type ResourceReadResult struct {
+	Contents []ResourceContents `json:"contents"`
+}
+
+type storedDocument struct {
+	Resource
+	text string
+}
+
+// documentStore keeps the documents tools generate, per org, so they can
+// be read again as resources without rerunning the tool. A nil store
+// keeps nothing.
+type documentStore struct {
+	mu   sync.Mutex
+	docs map[string][]storedDocument
+}
+
+func newDocumentStore() *documentStore {
+	return &documentStore{docs: make(map[string][]storedDocument)}
+}
+
+// put stores a document, replacing any earlier one with the same URI.
+func (st *documentStore) put(org string, resource Resource, text string) {
+	if st == nil {
+		return
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	docs := st.docs[org]
+	for i, doc := range docs {
+		if doc.URI == resource.URI {
+			docs = append(docs[:i], docs[i+1:]...)
+			break
+		}
+	}
+	docs = append(docs, storedDocument{Resource: resource, text: text})
+	if len(docs) > maxDocuments {
+		docs = docs[len(docs)-maxDocuments:]
+	}
+	st.docs[org] = docs
+}
+
+// list returns an org's documents, newest first.
+func (st *documentStore) list(org string) []Resource {
+	resources := make([]Resource, 0)
+	if st == nil {
+		return resources
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	docs := st.docs[org]
+	for i := len(docs) - 1; i >= 0; i-- {
+		resources = append(resources, docs[i].Resource)
+	}
+	return resources
+}
+
+func (st *documentStore) get(org, uri string) (storedDocument, bool) {
+	if st == nil {
+		return storedDocument{}, false
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	for _, doc := range st.docs[org] {
+		if doc.URI == uri {
+			return doc, true
+		}
+	}
+	return storedDocument{}, false
+}
+
+// readResource answers resources/read for a generated document.
+func (s *MCPServer) readResource(raw json.RawMessage) (*ResourceReadResult, *MCPError) {
+	var params ResourceReadParams
+	if err := json.Unmarshal(raw, &params); err != nil || params.URI == "" {
+		return nil, &MCPError{Code: -32602, Message: "uri is required"}
+	}
+	doc, ok := s.documents.get(s.apiOrg(), params.URI)
+	if !ok {
+		// -32002 is the MCP code for an unknown resource.
+		return nil, &MCPError{Code: -32002, Message: fmt.Sprintf("resource not found: %s", params.URI)}
+	}
+	return &ResourceReadResult{Contents: []ResourceContents{{URI: doc.URI, MimeType: doc.MimeType, Text: doc.text}}}, nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestResourcesListAndRead(t *testing.T) {
+	server := &MCPServer{documents: newDocumentStore()}
+	server.documents.put("", Resource{URI: "datadog://incidents/1/postmortem", Name: "first", MimeType: "text/markdown"}, "# One")
+	server.documents.put("", Resource{URI: "datadog://incidents/2/postmortem", Name: "second", MimeType: "text/markdown"}, "# Two")
+	server.documents.put("other-org", Resource{URI: "datadog://incidents/3/postmortem", Name: "elsewhere"}, "# Three")
+
+	resp := server.HandleRequest(MCPRequest{Jsonrpc: "2.0", ID: 1, Method: "resources/list"})
+	if resp.Error != nil {
+		t.Fatal(resp.Error.Message)
+	}
+	var list ResourcesListResult
+	_ = json.Unmarshal(resp.Result, &list)
+	if len(list.Resources) != 2 || list.Resources[0].Name != "second" {
+		t.Fatalf("expected this org's documents newest first, got %+v", list.Resources)
+	}
+
+	resp = server.HandleRequest(MCPRequest{Jsonrpc: "2.0", ID: 2, Method: "resources/read", Params: json.RawMessage(`{"uri":"datadog://incidents/1/postmortem"}`)})
+	if resp.Error != nil {
+		t.Fatal(resp.Error.Message)
+	}
+	var read ResourceReadResult
+	_ = json.Unmarshal(resp.Result, &read)
+	if len(read.Contents) != 1 || read.Contents[0].Text != "# One" || read.Contents[0].MimeType != "text/markdown" {
+		t.Fatalf("unexpected contents %+v", read.Contents)
+	}
+
+	resp = server.HandleRequest(MCPRequest{Jsonrpc: "2.0", ID: 3, Method: "resources/read", Params: json.RawMessage(`{"uri":"datadog://incidents/3/postmortem"}`)})
+	if resp.Error == nil || resp.Error.Code != -32002 {
+		t.Fatalf("expected another org's document to be not found, got %+v", resp)
+	}
+}
+
+func TestDocumentStoreReplacesAndBounds(t *testing.T) {
+	store := newDocumentStore()
+	for i := 0; i < maxDocuments+5; i++ {
+		store.put("", Resource{URI: "doc://" + string(rune('a'+i%26)) + string(rune('a'+i/26))}, "text")
+	}
+	if n := len(store.list("")); n != maxDocuments {
+		t.Fatalf("expected %d documents, got %d", maxDocuments, n)
+	}
+	store.put("", Resource{URI: "doc://same"}, "old")
+	store.put("", Resource{URI: "doc://same"}, "new")
+	if doc, ok := store.get("", "doc://same"); !ok || doc.text != "new" {
+		t.Fatalf("expected the document to be replaced, got %+v", doc)
+	}
+	if n := len(store.list("")); n != maxDocuments {
+		t.Fatalf("expected replacing not to grow the store, got %d", n)
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"container/list"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"unicode/utf8"
+)
+
+// defaultResultStoreBytes bounds the memory held by stored continuations
+// when DD_MCP_RESULT_STORE_BYTES is not set.
+const defaultResultStoreBytes = 64 << 20
+
+type ResultStoreStats struct {
+	Entries   int `json:"entries"`
+	Bytes     int `json:"bytes"`
+	MaxBytes  int `json:"max_bytes"`
+	Evictions int `json:"evictions"`
+	Hits      int `json:"hits"`
+	Misses    int `json:"misses"`
+}
+
+type storedResult struct {
+	key  string
+	data string
+}
+
+// resultStore is a byte-bounded LRU for results that are handed out in
+// pieces. When adding a result would exceed the budget, the least recently
+// used results are evicted, so a long-lived server doesn't grow without
+// bound.
+type resultStore struct {
+	mu       sync.Mutex
+	maxBytes int
+	bytes    int
+	order    *list.List
+	items    map[string]*list.Element
+	stats    ResultStoreStats
+}
+
+func newResultStore(maxBytes int) *resultStore {
+	return &resultStore{
+		maxBytes: maxBytes,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// put stores data under key, evicting older results as needed. Results
+// larger than the whole budget are not stored.
+func (r *resultStore) put(key, data string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(data) > r.maxBytes {
+		return false
+	}
+	if el, ok := r.items[key]; ok {
+		r.remove(el)
+	}
+	for r.bytes+len(data) > r.maxBytes {
+		r.remove(r.order.Back())
+		r.stats.Evictions++
+	}
+
+	r.items[key] = r.order.PushFront(&storedResult{key: key, data: data})
+	r.bytes += len(data)
+	return true
+}
+
+func (r *resultStore) get(key string) (string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	el, ok := r.items[key]
+	if !ok {
+		r.stats.Misses++
+		return "", false
+	}
+	r.stats.Hits++
+	r.order.MoveToFront(el)
+	return el.Value.(*storedResult).data, true
+}
+
+func (r *resultStore) delete(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if el, ok := r.items[key]; ok {
+		r.remove(el)
+	}
+}
+
+func (r *resultStore) remove(el *list.Element) {
+	result := el.Value.(*storedResult)
+	r.order.Remove(el)
+	delete(r.items, result.key)
+	r.bytes -= len(result.data)
+}
+
+func (r *resultStore) snapshot() ResultStoreStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := r.stats
+	stats.Entries = len(r.items)
+	stats.Bytes = r.bytes
+	stats.MaxBytes = r.maxBytes
+	return stats
+}
+
+// paginateResult returns text unchanged when it fits in maxResultBytes.
+// Otherwise it returns the first chunk and stores the remainder for
+// fetch_continuation.
+func (s *MCPServer) paginateResult(text string) string {
+	if s.maxResultBytes <= 0 || s.results == nil || len(text) <= s.maxResultBytes {
+		return text
+	}
+
+	cut := s.maxResultBytes
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+
+	id, err := newContinuationID()
+	if err != nil || !s.results.put(s.resultKey(id), text[cut:]) {
+		return text
+	}
+	return fmt.Sprintf("%s\n\n[truncated: %d more bytes; call fetch_continuation with id %q]", text[:cut], len(text)-cut, id)
+}
+
+// FetchContinuation returns the rest of a truncated result. It is paged
+// again on the way out like any other tool result.
+func (s *MCPServer) FetchContinuation(id string) (string, error) {
+	if s.results == nil {
+		return "", fmt.Errorf("result continuation is not enabled")
+	}
+	rest, ok := s.results.get(s.resultKey(id))
+	if !ok {
+		return "", fmt.Errorf("unknown or expired continuation id: %s", id)
+	}
+	s.results.delete(s.resultKey(id))
+	return rest, nil
+}
+
+// resultKey scopes stored results and downloads to the session that
+// produced them, so one client can't read another's by guessing an id.
+func (s *MCPServer) resultKey(id string) string {
+	return s.session + "/" + id
+}
+
+func newContinuationID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
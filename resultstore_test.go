@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestResultStoreEviction(t *testing.T) {
+	store := newResultStore(10)
+
+	store.put("a", "1234")
+	store.put("b", "5678")
+	if _, ok := store.get("a"); !ok {
+		t.Fatal("expected a to be stored")
+	}
+
+	// a was used more recently than b, so b is evicted first.
+	store.put("c", "abcd")
+	if _, ok := store.get("b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	if _, ok := store.get("a"); !ok {
+		t.Error("expected a to survive eviction")
+	}
+
+	stats := store.snapshot()
+	if stats.Entries != 2 || stats.Bytes != 8 || stats.Evictions != 1 || stats.Misses != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	if store.put("big", strings.Repeat("x", 11)) {
+		t.Error("expected oversized result to be rejected")
+	}
+}
+
+func TestResultStoreReplace(t *testing.T) {
+	store := newResultStore(10)
+	store.put("a", "1234")
+	store.put("a", "12")
+
+	if stats := store.snapshot(); stats.Entries != 1 || stats.Bytes != 2 {
+		t.Errorf("unexpected stats after replace: %+v", stats)
+	}
+}
+
+var continuationIDPattern = regexp.MustCompile(`fetch_continuation with id "([0-9a-f]+)"`)
+
+func TestPaginateResult(t *testing.T) {
+	server := &MCPServer{results: newResultStore(1024), maxResultBytes: 10}
+	text := "héllo wörld, this is long"
+
+	first := server.paginateResult(text)
+	match := continuationIDPattern.FindStringSubmatch(first)
+	if match == nil {
+		t.Fatalf("expected truncation notice, got %q", first)
+	}
+
+	var collected strings.Builder
+	collected.WriteString(strings.SplitN(first, "\n\n[truncated", 2)[0])
+	for match != nil {
+		rest, err := server.FetchContinuation(match[1])
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		page := server.paginateResult(rest)
+		collected.WriteString(strings.SplitN(page, "\n\n[truncated", 2)[0])
+		match = continuationIDPattern.FindStringSubmatch(page)
+	}
+
+	if collected.String() != text {
+		t.Errorf("expected %q after reassembly, got %q", text, collected.String())
+	}
+	if _, err := server.FetchContinuation("missing"); err == nil {
+		t.Error("expected error for unknown continuation id")
+	}
+
+	match = continuationIDPattern.FindStringSubmatch(server.withSession("a").paginateResult(text))
+	if _, err := server.withSession("b").FetchContinuation(match[1]); err == nil {
+		t.Error("expected another session's continuation to be refused")
+	}
+	if _, err := server.withSession("a").FetchContinuation(match[1]); err != nil {
+		t.Errorf("expected the session's own continuation, got %v", err)
+	}
+}
+
+func TestPaginateResultDisabled(t *testing.T) {
+	server := &MCPServer{results: newResultStore(1024)}
+	text := strings.Repeat("x", 5000)
+	if got := server.paginateResult(text); got != text {
+		t.Error("expected text to pass through when no limit is configured")
+	}
+}
+
+func TestHandleServerStats(t *testing.T) {
+	server := &MCPServer{results: newResultStore(1024)}
+	params, _ := json.Marshal(ToolCallParams{Name: "server_stats"})
+	resp := server.HandleRequest(MCPRequest{Jsonrpc: "2.0", ID: 1, Method: "tools/call", Params: params})
+
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %v", resp.Error.Message)
+	}
+	if !strings.Contains(string(resp.Result), "max_bytes") {
+		t.Errorf("expected result store stats in result: %s", resp.Result)
+	}
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+)
+
+const (
+	// maxRunbookBytes caps how much of a runbook page is read and returned.
+	maxRunbookBytes = 64 * 1024
+	runbookTimeout  = 10 * time.Second
+)
+
+type ResolveRunbooksParams struct {
+	MonitorID    int64  `json:"monitor_id,omitempty"`
+	Monitor      string `json:"monitor,omitempty"`
+	Service      string `json:"service,omitempty"`
+	FetchContent bool   `json:"fetch_content,omitempty"`
+}
+
+type Runbook struct {
+	Title        string `json:"title,omitempty"`
+	URL          string `json:"url"`
+	Source       string `json:"source"`
+	Content      string `json:"content,omitempty"`
+	ContentError string `json:"content_error,omitempty"`
+}
+
+type ResolveRunbooksResult struct {
+	MonitorID int64     `json:"monitor_id,omitempty"`
+	Monitor   string    `json:"monitor,omitempty"`
+	Service   string    `json:"service,omitempty"`
+	Runbooks  []Runbook `json:"runbooks"`
+	Notes     []string  `json:"notes,omitempty"`
+}
+
+var (
+	markdownLinkPattern = regexp.MustCompile(`\[([^\]]+)\]\((https?://[^)\s]+)\)`)
+	bareURLPattern      = regexp.MustCompile(`https?://[^\s)\]>"']+`)
+	htmlTagPattern      = regexp.MustCompile(`(?s)<script.*?</script>|<style.*?</style>|<[^>]+>`)
+	blankLinesPattern   = regexp.MustCompile(`\n\s*\n+`)
+)
+
+// runbookHints are words that mark a link in a monitor message as a
+// runbook rather than, say, a dashboard link.
+var runbookHints = []string{"runbook", "playbook", "run-book", "wiki", "confluence", "notion", "procedure", "sop"}
+
+func (s *MCPServer) ResolveRunbooks(params ResolveRunbooksParams) (*ResolveRunbooksResult, error) {
+	if params.MonitorID == 0 && params.Monitor == "" && params.Service == "" {
+		return nil, fmt.Errorf("monitor_id, monitor or service parameter is required")
+	}
+
+	var notes []string
+	if params.MonitorID == 0 && params.Monitor != "" {
+		resolution, err := s.resolveMonitor(params.Monitor)
+		if err != nil {
+			return nil, err
+		}
+		if !resolution.found() {
+			return nil, fmt.Errorf("%s", resolution.note("monitor", params.Monitor))
+		}
+		if resolution.Corrected {
+			notes = append(notes, resolution.note("monitor", params.Monitor))
+		}
+		params.MonitorID = resolution.Entity.ID
+	}
+
+	result := &ResolveRunbooksResult{
+		MonitorID: params.MonitorID,
+		Service:   params.Service,
+		Runbooks:  make([]Runbook, 0),
+		Notes:     notes,
+	}
+
+	if params.MonitorID != 0 {
+		api := datadogV1.NewMonitorsApi(s.ddClient)
+		monitor, _, err := api.GetMonitor(s.ctx, params.MonitorID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get monitor %d: %w", params.MonitorID, err)
+		}
+		result.Monitor = monitor.GetName()
+		result.Runbooks = append(result.Runbooks, monitorRunbooks(monitor)...)
+		if result.Service == "" {
+			result.Service = tagValue(monitor.GetTags(), "service")
+		}
+	}
+
+	if result.Service != "" {
+		links, err := s.fetchServiceLinks(result.Service)
+		if err != nil {
+			// The lookup may have failed because the name is misspelled.
+			if resolution, resolveErr := s.resolveService(result.Service); resolveErr == nil && resolution.Corrected {
+				result.Notes = append(result.Notes, resolution.note("service", result.Service))
+				result.Service = resolution.Entity.Name
+				links, err = s.fetchServiceLinks(result.Service)
+			} else if resolveErr == nil && !resolution.found() && len(resolution.Suggestions) > 0 {
+				result.Notes = append(result.Notes, resolution.note("service", result.Service))
+			}
+		}
+		if err != nil {
+			result.Notes = append(result.Notes, fmt.Sprintf("Service catalog lookup failed: %v", err))
+		}
+		for _, l := range links {
+			if l.Type == "runbook" {
+				result.Runbooks = append(result.Runbooks, Runbook{Title: l.Name, URL: l.URL, Source: "service_catalog"})
+			}
+		}
+	}
+
+	result.Runbooks = dedupeRunbooks(result.Runbooks)
+	if len(result.Runbooks) == 0 {
+		result.Notes = append(result.Notes, "No runbook links were found on the monitor or in the service definition.")
+	}
+
+	if params.FetchContent {
+		client := runbookClient(s.runbookHosts)
+		for i := range result.Runbooks {
+			rb := &result.Runbooks[i]
+			if !hostAllowed(rb.URL, s.runbookHosts) {
+				rb.ContentError = "host is not in DD_MCP_RUNBOOK_HOSTS"
+				continue
+			}
+			content, err := s.fetchRunbookContent(client, rb.URL)
+			if err != nil {
+				rb.ContentError = err.Error()
+				continue
+			}
+			rb.Content = content
+		}
+	}
+
+	return result, nil
+}
+
+// monitorRunbooks collects runbooks attached to a monitor as assets plus
+// links in its message that look like runbooks.
+func monitorRunbooks(monitor datadogV1.Monitor) []Runbook {
+	runbooks := make([]Runbook, 0)
+	for _, asset := range monitorAssets(monitor) {
+		if asset.Category == "runbook" {
+			runbooks = append(runbooks, Runbook{Title: asset.Name, URL: asset.URL, Source: "monitor_asset"})
+		}
+	}
+	return append(runbooks, messageRunbooks(monitor.GetMessage())...)
+}
+
+func messageRunbooks(message string) []Runbook {
+	runbooks := make([]Runbook, 0)
+	linked := make(map[string]bool)
+	for _, m := range markdownLinkPattern.FindAllStringSubmatch(message, -1) {
+		linked[m[2]] = true
+		if looksLikeRunbook(m[1] + " " + m[2]) {
+			runbooks = append(runbooks, Runbook{Title: m[1], URL: m[2], Source: "monitor_message"})
+		}
+	}
+
+	for _, line := range strings.Split(message, "\n") {
+		for _, u := range bareURLPattern.FindAllString(line, -1) {
+			u = strings.TrimRight(u, ".,;")
+			if linked[u] || !looksLikeRunbook(line) {
+				continue
+			}
+			linked[u] = true
+			runbooks = append(runbooks, Runbook{URL: u, Source: "monitor_message"})
+		}
+	}
+	return runbooks
+}
+
+func looksLikeRunbook(text string) bool {
+	text = strings.ToLower(text)
+	for _, hint := range runbookHints {
+		if strings.Contains(text, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+func dedupeRunbooks(runbooks []Runbook) []Runbook {
+	seen := make(map[string]bool)
+	result := make([]Runbook, 0, len(runbooks))
+	for _, rb := range runbooks {
+		if seen[rb.URL] {
+			continue
+		}
+		seen[rb.URL] = true
+		result = append(result, rb)
+	}
+	return result
+}
+
+// hostAllowed reports whether the URL's host is one of the allowed hosts
+// or a subdomain of one. Only https URLs are fetched.
+func hostAllowed(rawURL string, allowed []string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, a := range allowed {
+		a = strings.ToLower(a)
+		if host == a || strings.HasSuffix(host, "."+a) {
+			return true
+		}
+	}
+	return false
+}
+
+// runbookClient fetches runbooks, following a redirect only to another
+// allowed host, so a page on an allowed host can't send the fetch elsewhere.
+func runbookClient(allowed []string) *http.Client {
+	return &http.Client{
+		Timeout: runbookTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return fmt.Errorf("stopped after 10 redirects")
+			}
+			if !hostAllowed(req.URL.String(), allowed) {
+				return fmt.Errorf("redirect to %s is not in DD_MCP_RUNBOOK_HOSTS", req.URL.Host)
+			}
+			return nil
+		},
+	}
+}
+
+// fetchRunbookContent fetches a runbook as text, giving up when the
+// request that asked for it is cancelled.
+func (s *MCPServer) fetchRunbookContent(client *http.Client, rawURL string) (string, error) {
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch runbook: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch runbook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch runbook: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRunbookBytes))
+	if err != nil {
+		return "", fmt.Errorf("failed to read runbook: %w", err)
+	}
+
+	content := string(body)
+	if strings.Contains(resp.Header.Get("Content-Type"), "html") {
+		content = htmlToText(content)
+	}
+	return strings.TrimSpace(content), nil
+}
+
+// htmlToText is a deliberately small tag stripper; runbook pages only need
+// to be readable, not faithfully rendered.
+func htmlToText(html string) string {
+	text := htmlTagPattern.ReplaceAllString(html, "\n")
+	replacer := strings.NewReplacer("&nbsp;", " ", "&amp;", "&", "&lt;", "<", "&gt;", ">", "&quot;", `"`, "&#39;", "'")
+	text = replacer.Replace(text)
+	return blankLinesPattern.ReplaceAllString(text, "\n\n")
+}
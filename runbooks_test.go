@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+)
+
+func TestMonitorRunbooks(t *testing.T) {
+	// Built from JSON, as clients that predate monitor assets only keep
+	// them as additional properties.
+	message, _ := json.Marshal(`CPU is high on {{host.name}}.
+See the [runbook](https://wiki.example.com/rb/cpu) and the [dashboard](https://app.datadoghq.com/dash/1).
+Playbook: https://confluence.example.com/x/abc.
+@pagerduty-core`)
+	var monitor datadogV1.Monitor
+	if err := json.Unmarshal([]byte(`{"type":"metric alert","query":"avg(last_5m):avg:system.cpu.user{*} > 90","message":`+string(message)+`,
+		"assets":[{"category":"runbook","name":"CPU runbook","url":"https://wiki.example.com/rb/cpu"}]}`), &monitor); err != nil {
+		t.Fatal(err)
+	}
+
+	runbooks := dedupeRunbooks(monitorRunbooks(monitor))
+	if len(runbooks) != 2 {
+		t.Fatalf("expected 2 runbooks, got %d: %+v", len(runbooks), runbooks)
+	}
+	if runbooks[0].Source != "monitor_asset" || runbooks[0].Title != "CPU runbook" {
+		t.Errorf("expected asset runbook first, got %+v", runbooks[0])
+	}
+	if runbooks[1].URL != "https://confluence.example.com/x/abc" {
+		t.Errorf("unexpected bare runbook URL: %s", runbooks[1].URL)
+	}
+}
+
+func TestHostAllowed(t *testing.T) {
+	allowed := []string{"wiki.example.com", "atlassian.net"}
+	tests := []struct {
+		url      string
+		expected bool
+	}{
+		{url: "https://wiki.example.com/rb", expected: true},
+		{url: "https://acme.atlassian.net/wiki/x", expected: true},
+		{url: "http://wiki.example.com/rb", expected: false},
+		{url: "https://evil-wiki.example.com.attacker.io/", expected: false},
+		{url: "https://example.com/", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if got := hostAllowed(tt.url, allowed); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFetchRunbookContent(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<html><head><style>p{}</style></head><body><h1>CPU</h1><p>Restart the &amp; worker</p></body></html>`))
+	}))
+	defer ts.Close()
+
+	content, err := (&MCPServer{ctx: context.Background()}).fetchRunbookContent(ts.Client(), ts.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(content, "Restart the & worker") || strings.Contains(content, "<p>") || strings.Contains(content, "p{}") {
+		t.Errorf("unexpected content: %q", content)
+	}
+}
+
+func TestRunbookClientRedirects(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/moved":
+			http.Redirect(w, r, "/rb", http.StatusFound)
+		case "/away":
+			http.Redirect(w, r, "https://internal.example.net/secrets", http.StatusFound)
+		default:
+			_, _ = w.Write([]byte("Restart the worker"))
+		}
+	}))
+	defer ts.Close()
+
+	server := &MCPServer{ctx: context.Background()}
+	client := runbookClient([]string{"127.0.0.1"})
+	client.Transport = ts.Client().Transport
+	if content, err := server.fetchRunbookContent(client, ts.URL+"/moved"); err != nil || content != "Restart the worker" {
+		t.Fatalf("expected a redirect on an allowed host to be followed, got %q (%v)", content, err)
+	}
+	if _, err := server.fetchRunbookContent(client, ts.URL+"/away"); err == nil || !strings.Contains(err.Error(), "not in DD_MCP_RUNBOOK_HOSTS") {
+		t.Fatalf("expected a redirect to another host to be refused, got %v", err)
+	}
+}
+
+func TestFetchRunbookContentCancelled(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := (&MCPServer{ctx: ctx}).fetchRunbookContent(ts.Client(), ts.URL); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a cancelled request to stop the fetch, got %v", err)
+	}
+}
+
+func TestResolveRunbooksRequiresTarget(t *testing.T) {
+	server := &MCPServer{}
+	if _, err := server.ResolveRunbooks(ResolveRunbooksParams{}); err == nil {
+		t.Error("expected error when neither monitor_id nor service is set")
+	}
+}
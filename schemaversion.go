@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"strings"
+)
+
+// outputChange is a change to a tool's result format that parsers may
+// notice, such as a field added to every log.
+type outputChange struct {
+	// summary says what the new version changed, for deprecation notes.
+	summary string
+	// downgrade rewrites a decoded result of the new version in the
+	// version before it.
+	downgrade func(result map[string]interface{})
+}
+
+// outputChanges lists each tool's format changes, oldest first: the
+// first entry made version 2. Tools without an entry are at version 1.
+// A downgrade stays here for at least one release after its change, so
+// automations that parse results can pin schema_version and move on in
+// their own time.
+var outputChanges = map[string][]outputChange{
+	"query_logs": {{
+		summary: "adds the client location to logs",
+		downgrade: func(result map[string]interface{}) {
+			dropItemField(result["logs"], "client")
+			if services, ok := result["services"].(map[string]interface{}); ok {
+				for _, service := range services {
+					if service, ok := service.(map[string]interface{}); ok {
+						dropItemField(service["logs"], "client")
+					}
+				}
+			}
+		},
+	}},
+	"list_monitors": {{
+		summary: "adds the maintenance window a monitor last triggered in",
+		downgrade: func(result map[string]interface{}) {
+			dropItemField(result["monitors"], "maintenance")
+		},
+	}},
+	"get_monitor": {{
+		summary: "adds the maintenance window a group last triggered in",
+		downgrade: func(result map[string]interface{}) {
+			dropItemField(result["groups"], "maintenance")
+		},
+	}},
+	"alert_fatigue_report": {{
+		summary: "adds the alerts excluded per maintenance window",
+		downgrade: func(result map[string]interface{}) {
+			delete(result, "maintenance")
+		},
+	}},
+}
+
+// dropItemField removes field from each object in a decoded array.
+func dropItemField(items interface{}, field string) {
+	list, _ := items.([]interface{})
+	for _, item := range list {
+		if item, ok := item.(map[string]interface{}); ok {
+			delete(item, field)
+		}
+	}
+}
+
+// outputSchemaVersion is the latest version of a tool's result format.
+func outputSchemaVersion(tool string) int {
+	return len(outputChanges[tool]) + 1
+}
+
+// schemaVersionProperty describes the schema_version argument every tool
+// but plugins takes.
+func schemaVersionProperty(tool string) SchemaProperty {
+	latest := outputSchemaVersion(tool)
+	description := "Result format version; only 1 so far"
+	if latest > 1 {
+		description = fmt.Sprintf("Result format version, 1 to %d (default: %d). Pin it to keep parsing an older format after a change.", latest, latest)
+	}
+	return SchemaProperty{Type: "integer", Description: description}
+}
+
+// withSchemaVersion adds the schema_version argument to each tool's
+// schema, leaving plugins' own schemas alone.
+func (s *MCPServer) withSchemaVersion(tools []Tool) []Tool {
+	for i, tool := range tools {
+		if _, ok := s.plugins.lookup(tool.Name); ok {
+			continue
+		}
+		// Some tools are shared across calls, so copy before adding.
+		properties := maps.Clone(tool.InputSchema.Properties)
+		if properties == nil {
+			properties = make(map[string]SchemaProperty)
+		}
+		properties["schema_version"] = schemaVersionProperty(tool.Name)
+		tools[i].InputSchema.Properties = properties
+	}
+	return tools
+}
+
+// schemaVersion removes the schema_version argument from a call,
+// returning the version asked for or the latest when it's omitted.
+// Plugins format their own results, so their calls are left as they are
+// with version 0.
+func (s *MCPServer) schemaVersion(params ToolCallParams) (json.RawMessage, int, *MCPError) {
+	if _, ok := s.plugins.lookup(params.Name); ok {
+		return params.Arguments, 0, nil
+	}
+	latest := outputSchemaVersion(params.Name)
+	var args map[string]json.RawMessage
+	if len(params.Arguments) == 0 || json.Unmarshal(params.Arguments, &args) != nil {
+		// Leave malformed arguments for the tool to report.
+		return params.Arguments, latest, nil
+	}
+	raw, ok := args["schema_version"]
+	if !ok {
+		return params.Arguments, latest, nil
+	}
+	version := latest
+	if string(raw) != "null" {
+		if err := json.Unmarshal(raw, &version); err != nil || version < 1 || version > latest {
+			return nil, 0, &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments for %s: schema_version must be from 1 to %d", params.Name, latest)}
+		}
+	}
+	delete(args, "schema_version")
+	arguments, err := json.Marshal(args)
+	if err != nil {
+		return nil, 0, &MCPError{Code: -32602, Message: fmt.Sprintf("invalid arguments: %v", err)}
+	}
+	return arguments, version, nil
+}
+
+// versionOutput stamps a JSON object result with its schema_version,
+// first rewriting it in an older format when one was asked for. Results
+// that aren't JSON objects, such as rendered markdown, are left as they
+// are.
+func versionOutput(tool, text string, version int) string {
+	changes := outputChanges[tool]
+	if version > len(changes) {
+		if text == "{}" {
+			return fmt.Sprintf("{\n  \"schema_version\": %d\n}", version)
+		}
+		// Insert rather than re-encode, keeping the result's field order.
+		if rest, ok := strings.CutPrefix(text, "{\n"); ok {
+			return fmt.Sprintf("{\n  \"schema_version\": %d,\n%s", version, rest)
+		}
+		return text
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(text))
+	// Keep IDs beyond float64's precision intact.
+	decoder.UseNumber()
+	var result map[string]interface{}
+	if err := decoder.Decode(&result); err != nil || result == nil {
+		return text
+	}
+	for i := len(changes) - 1; i >= version-1; i-- {
+		changes[i].downgrade(result)
+	}
+	result["schema_version"] = version
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return text
+	}
+	return string(data)
+}
+
+// schemaDeprecation is the note returned with a result in an older
+// format, saying what the next version changed.
+func schemaDeprecation(tool string, version int) string {
+	changes := outputChanges[tool]
+	if version < 1 || version > len(changes) {
+		return ""
+	}
+	return fmt.Sprintf("%s result format %d is deprecated and kept for at least one more release; version %d %s.", tool, version, version+1, changes[version-1].summary)
+}
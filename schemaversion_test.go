@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestVersionOutput(t *testing.T) {
+	latest := formatLogsResult(&QueryLogsResult{Logs: []LogEntry{
+		{ID: "a", Message: "POST /login 401", Client: &ClientLocation{IP: "81.2.69.160", Country: "GB"}},
+	}, Count: 1})
+
+	stamped := versionOutput("query_logs", latest, 2)
+	if !strings.HasPrefix(stamped, "{\n  \"schema_version\": 2,\n  \"logs\"") {
+		t.Fatalf("expected the version first, got %s", stamped)
+	}
+	var current struct {
+		SchemaVersion int        `json:"schema_version"`
+		Logs          []LogEntry `json:"logs"`
+	}
+	if err := json.Unmarshal([]byte(stamped), &current); err != nil || current.SchemaVersion != 2 || current.Logs[0].Client == nil {
+		t.Fatalf("expected the latest format, got %s (%v)", stamped, err)
+	}
+
+	var previous map[string]interface{}
+	if err := json.Unmarshal([]byte(versionOutput("query_logs", latest, 1)), &previous); err != nil {
+		t.Fatal(err)
+	}
+	entry := previous["logs"].([]interface{})[0].(map[string]interface{})
+	if _, ok := entry["client"]; ok || entry["id"] != "a" || previous["schema_version"] != float64(1) {
+		t.Fatalf("expected version 1 without client locations, got %v", previous)
+	}
+
+	if got := versionOutput("list_users", "{}", 1); got != "{\n  \"schema_version\": 1\n}" {
+		t.Errorf("expected an empty result stamped, got %q", got)
+	}
+	if got := versionOutput("generate_report", "# Weekly review", 1); got != "# Weekly review" {
+		t.Errorf("expected markdown left alone, got %q", got)
+	}
+	if schemaDeprecation("query_logs", 2) != "" || !strings.Contains(schemaDeprecation("query_logs", 1), "client location") {
+		t.Error("expected a deprecation note only for the older format")
+	}
+}
+
+func TestSchemaVersionArgument(t *testing.T) {
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":7,"name":"Checkout errors","type":"query alert","query":"avg(last_5m):avg:checkout.errors{*} > 5","overall_state":"OK"}`))
+	})
+
+	call := func(arguments string) (*ToolCallResult, *MCPError) {
+		t.Helper()
+		params, _ := json.Marshal(ToolCallParams{Name: "get_monitor", Arguments: json.RawMessage(arguments)})
+		resp := server.HandleRequest(MCPRequest{Jsonrpc: "2.0", ID: 1, Method: "tools/call", Params: params})
+		if resp.Error != nil {
+			return nil, resp.Error
+		}
+		var result ToolCallResult
+		if err := json.Unmarshal(resp.Result, &result); err != nil {
+			t.Fatal(err)
+		}
+		return &result, nil
+	}
+
+	result, err := call(`{"monitor_id":7}`)
+	if err != nil {
+		t.Fatal(err.Message)
+	}
+	if result.Meta.SchemaVersion != 2 || result.Meta.Deprecation != "" || !strings.Contains(result.Content[0].Text, `"schema_version": 2`) {
+		t.Fatalf("expected the latest format, got %+v", result)
+	}
+
+	result, err = call(`{"monitor_id":7,"schema_version":1}`)
+	if err != nil {
+		t.Fatal(err.Message)
+	}
+	if result.Meta.SchemaVersion != 1 || result.Meta.Deprecation == "" || !strings.Contains(result.Content[0].Text, `"schema_version": 1`) {
+		t.Fatalf("expected the pinned format with a deprecation note, got %+v", result)
+	}
+
+	if _, err := call(`{"monitor_id":7,"schema_version":3}`); err == nil || err.Code != -32602 {
+		t.Fatalf("expected an unknown version to be rejected, got %+v", err)
+	}
+
+	for _, tool := range server.ListTools() {
+		if _, ok := tool.InputSchema.Properties["schema_version"]; !ok {
+			t.Errorf("expected %s to take schema_version", tool.Name)
+		}
+	}
+}
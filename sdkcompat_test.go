@@ -0,0 +1,118 @@
+//go:build sdkcompat
+
+// These tests run the tool handlers end to end against each client
+// library version in the Makefile's SDK_VERSIONS:
+//
+//	make test-sdk-matrix
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"testing"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+)
+
+const sdkModule = "github.com/DataDog/datadog-api-client-go/v2"
+
+func TestSDKVersion(t *testing.T) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		t.Skip("no build info")
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == sdkModule {
+			t.Logf("testing against %s %s", sdkModule, dep.Version)
+			return
+		}
+	}
+	t.Fatalf("%s is not a dependency", sdkModule)
+}
+
+// TestSDKCompatMonitorAssets checks that assets survive the client's
+// monitor model, including in versions without the field.
+func TestSDKCompatMonitorAssets(t *testing.T) {
+	var monitor datadogV1.Monitor
+	if err := json.Unmarshal([]byte(`{"type":"metric alert","query":"avg(last_5m):avg:system.cpu.user{*} > 90",
+		"assets":[{"category":"runbook","name":"CPU runbook","url":"https://wiki.example.com/rb/cpu"}]}`), &monitor); err != nil {
+		t.Fatal(err)
+	}
+	if assets := monitorAssets(monitor); len(assets) != 1 || assets[0].Category != "runbook" || assets[0].URL != "https://wiki.example.com/rb/cpu" {
+		t.Fatalf("unexpected assets %+v", assets)
+	}
+}
+
+func TestSDKCompatToolHandlers(t *testing.T) {
+	responses := map[string]string{
+		"/api/v1/monitor/7": `{"id":7,"name":"High CPU","type":"metric alert","query":"avg(last_5m):avg:system.cpu.user{*} > 90",
+			"overall_state":"Alert","tags":["service:web"],"message":"See https://wiki.example.com/runbooks/cpu",
+			"assets":[{"category":"runbook","name":"CPU runbook","url":"https://wiki.example.com/rb/cpu"}]}`,
+		"/api/v1/monitor/search":                         `{"monitors":[{"id":7,"name":"High CPU","status":"Alert","type":"metric alert"}],"metadata":{"total_count":1,"page_count":1}}`,
+		"/api/v2/logs/events/search":                     `{"data":[{"id":"1","attributes":{"message":"boom","service":"web","status":"error","timestamp":"2026-01-20T09:00:00Z"}}]}`,
+		"/api/v2/incidents/8a9b2c3d":                     `{"data":` + testIncidentData + `,"included":` + testIncidentUsers + `}`,
+		"/api/v2/incidents/8a9b2c3d/relationships/todos": `{"data":[]}`,
+		"/api/v2/incidents/8a9b2c3d/attachments": `{"data":[{"id":"a-1","type":"incident_attachments",
+			"attributes":{"attachment_type":"link","attachment":{"title":"Runbook","documentUrl":"https://wiki.example.com/checkout"},"modified":"2026-01-20T09:15:00Z"}}]}`,
+		"/api/v1/hosts":         `{"host_list":[{"name":"web-1","up":true,"apps":["nginx"]}],"total_returned":1,"total_matching":1}`,
+		"/api/v2/processes":     `{"data":[{"id":"p1","type":"process","attributes":{"host":"web-1","pid":42,"cmdline":"nginx"}}]}`,
+		"/api/v2/containers":    `{"data":[{"id":"c1","type":"container","attributes":{"name":"web","host":"web-1","state":"running"}}]}`,
+		"/api/v1/slo/search":    `{"data":{"attributes":{"slos":[{"data":{"id":"slo-1","type":"slo","attributes":{"name":"Web availability","overall_status":[{"state":"ok"}]}}}]}}}`,
+		"/api/v2/events/search": `{"data":[{"id":"E1","attributes":{"timestamp":"2026-01-20T08:55:00Z","attributes":{"title":"Deployed web"}}}]}`,
+	}
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			http.Error(w, `{"errors":["not found"]}`, http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	})
+	server.backends = mustLoadAPIBackends(t)
+
+	for _, tt := range []struct {
+		tool, arguments, want string
+	}{
+		{"get_monitor", `{"monitor_id":7}`, "High CPU"},
+		{"list_monitors", `{}`, "High CPU"},
+		{"resolve_runbooks", `{"monitor_id":7}`, "https://wiki.example.com/rb/cpu"},
+		{"query_logs", `{"query":"service:web"}`, "boom"},
+		{"get_incident_timeline", `{"incident_id":"8a9b2c3d"}`, "Runbook https://wiki.example.com/checkout"},
+		{"list_hosts", `{}`, "web-1"},
+		{"query_processes", `{}`, "nginx"},
+		{"list_containers", `{}`, "running"},
+		{"list_slos", `{}`, "Web availability"},
+		{"query_events", `{}`, "Deployed web"},
+	} {
+		t.Run(tt.tool, func(t *testing.T) {
+			params, _ := json.Marshal(ToolCallParams{Name: tt.tool, Arguments: json.RawMessage(tt.arguments)})
+			resp := server.HandleRequest(MCPRequest{Jsonrpc: "2.0", ID: 1, Method: "tools/call", Params: params})
+			if resp.Error != nil {
+				t.Fatalf("unexpected error: %s", resp.Error.Message)
+			}
+			if !strings.Contains(string(resp.Result), tt.want) {
+				t.Fatalf("expected %s in %s", tt.want, resp.Result)
+			}
+		})
+	}
+}
+
+// TestSDKCompatNoPanics calls every tool against empty responses. Errors
+// are expected; panics, which a changed model usually causes, are not.
+func TestSDKCompatNoPanics(t *testing.T) {
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	})
+	server.backends = mustLoadAPIBackends(t)
+	for _, tool := range server.ListTools() {
+		params, _ := json.Marshal(ToolCallParams{Name: tool.Name, Arguments: json.RawMessage(`{}`)})
+		resp := server.HandleRequest(MCPRequest{Jsonrpc: "2.0", ID: 1, Method: "tools/call", Params: params})
+		if resp.Error != nil && strings.HasPrefix(resp.Error.Message, "internal error in") {
+			t.Errorf("%s: %s", tool.Name, resp.Error.Message)
+		}
+	}
+}
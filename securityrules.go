@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	securityRulesPageSize    = 100
+	maxSecurityRulePages     = 10
+	defaultSecurityRuleLimit = 50
+	maxSecurityRuleLimit     = 500
+)
+
+// securitySeverities are the severities a rule case can raise, lowest
+// first.
+var securitySeverities = []string{"info", "low", "medium", "high", "critical"}
+
+type ListSecurityRulesParams struct {
+	// RuleID returns that rule alone, with its message, such as the rule
+	// a signal names in its workflow.rule.id attribute.
+	RuleID string `json:"rule_id,omitempty"`
+	// Query searches rule names and queries.
+	Query string `json:"query,omitempty"`
+	// Enabled keeps only enabled, or only disabled, rules.
+	Enabled *bool `json:"enabled,omitempty"`
+	// MinSeverity keeps rules with a case at this severity or above.
+	MinSeverity string `json:"min_severity,omitempty"`
+	// Type keeps rules of one type, such as "log_detection".
+	Type  string `json:"type,omitempty"`
+	Limit int    `json:"limit,omitempty"`
+}
+
+type SecurityRuleQuery struct {
+	Name       string   `json:"name,omitempty"`
+	Query      string   `json:"query"`
+	DataSource string   `json:"data_source,omitempty"`
+	GroupBy    []string `json:"group_by,omitempty"`
+}
+
+type SecurityRuleCase struct {
+	Name      string `json:"name,omitempty"`
+	Condition string `json:"condition,omitempty"`
+	Severity  string `json:"severity"`
+}
+
+type SecurityRule struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Type    string `json:"type,omitempty"`
+	Enabled bool   `json:"enabled"`
+	// Default is set for Datadog's out-of-the-box rules.
+	Default bool `json:"default"`
+	// Severity is the highest severity any case raises.
+	Severity string              `json:"severity,omitempty"`
+	Queries  []SecurityRuleQuery `json:"queries"`
+	Cases    []SecurityRuleCase  `json:"cases"`
+	Tags     []string            `json:"tags,omitempty"`
+	// Message is only returned for a single rule, as it's often long.
+	Message string `json:"message,omitempty"`
+	Updated string `json:"updated,omitempty"`
+	URL     string `json:"url"`
+}
+
+type ListSecurityRulesResult struct {
+	Rules []SecurityRule `json:"rules"`
+	Count int            `json:"count"`
+	// Matched is how many rules passed the filters, before the limit.
+	Matched int      `json:"matched"`
+	URL     string   `json:"url"`
+	Notes   []string `json:"notes,omitempty"`
+}
+
+// securityRuleData is a detection rule as the API returns it. The
+// client's model is a oneOf over rule kinds that gains fields in most
+// releases, so rules are read through datadogGet.
+type securityRuleData struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Type      string   `json:"type"`
+	IsEnabled bool     `json:"isEnabled"`
+	IsDefault bool     `json:"isDefault"`
+	Message   string   `json:"message"`
+	Tags      []string `json:"tags"`
+	UpdatedAt int64    `json:"updatedAt"`
+	Queries   []struct {
+		Name          string   `json:"name"`
+		Query         string   `json:"query"`
+		DataSource    string   `json:"dataSource"`
+		GroupByFields []string `json:"groupByFields"`
+		RuleID        string   `json:"ruleId"`
+	} `json:"queries"`
+	Cases []struct {
+		Name      string `json:"name"`
+		Condition string `json:"condition"`
+		Status    string `json:"status"`
+	} `json:"cases"`
+}
+
+// ListSecurityRules lists the org's detection rules with their queries
+// and case severities, to explain which rule raised a signal and how it
+// could be tuned.
+func (s *MCPServer) ListSecurityRules(params ListSecurityRulesParams) (*ListSecurityRulesResult, error) {
+	var minRank int
+	if params.MinSeverity != "" {
+		minRank = slices.Index(securitySeverities, strings.ToLower(params.MinSeverity))
+		if minRank < 0 {
+			return nil, fmt.Errorf("invalid min_severity: %s (use %s)", params.MinSeverity, strings.Join(securitySeverities, ", "))
+		}
+	}
+	limit := params.Limit
+	if limit <= 0 {
+		limit = defaultSecurityRuleLimit
+	}
+	limit = clampArgument(s, "limit", limit, maxSecurityRuleLimit)
+
+	if params.RuleID != "" {
+		var resp securityRuleData
+		if err := s.datadogGet("/api/v2/security_monitoring/rules/"+url.PathEscape(params.RuleID), nil, &resp); err != nil {
+			return nil, fmt.Errorf("failed to get security rule %s: %w", params.RuleID, err)
+		}
+		rule := s.securityRule(resp)
+		rule.Message = resp.Message
+		return &ListSecurityRulesResult{Rules: []SecurityRule{rule}, Count: 1, Matched: 1, URL: rule.URL}, nil
+	}
+
+	result := &ListSecurityRulesResult{
+		Rules: make([]SecurityRule, 0),
+		URL:   s.appURL("/security/rules?" + url.Values{"query": {params.Query}}.Encode()),
+	}
+	for page := 0; page < maxSecurityRulePages; page++ {
+		query := url.Values{
+			"page[size]":   {strconv.Itoa(securityRulesPageSize)},
+			"page[number]": {strconv.Itoa(page)},
+		}
+		if params.Query != "" {
+			query.Set("query", params.Query)
+		}
+		var resp struct {
+			Data []securityRuleData `json:"data"`
+		}
+		if err := s.datadogGet("/api/v2/security_monitoring/rules", query, &resp); err != nil {
+			return nil, fmt.Errorf("failed to list security rules: %w", err)
+		}
+		for _, data := range resp.Data {
+			rule := s.securityRule(data)
+			if params.Enabled != nil && rule.Enabled != *params.Enabled {
+				continue
+			}
+			if params.Type != "" && !strings.EqualFold(rule.Type, params.Type) {
+				continue
+			}
+			if params.MinSeverity != "" && slices.Index(securitySeverities, rule.Severity) < minRank {
+				continue
+			}
+			result.Matched++
+			if len(result.Rules) < limit {
+				result.Rules = append(result.Rules, rule)
+			}
+		}
+		if len(resp.Data) < securityRulesPageSize {
+			break
+		}
+		if page == maxSecurityRulePages-1 {
+			result.Notes = append(result.Notes, fmt.Sprintf("Only the first %d rules were checked; narrow the query to see the rest.", maxSecurityRulePages*securityRulesPageSize))
+		}
+	}
+	result.Count = len(result.Rules)
+	if result.Matched > result.Count {
+		result.Notes = append(result.Notes, fmt.Sprintf("%d more rules match; raise limit or narrow the filters to see them.", result.Matched-result.Count))
+	}
+	if result.Matched == 0 {
+		result.Notes = append(result.Notes, "No detection rules match. Rules need Cloud SIEM or another Security product enabled.")
+	}
+	return result, nil
+}
+
+func (s *MCPServer) securityRule(data securityRuleData) SecurityRule {
+	rule := SecurityRule{
+		ID:      data.ID,
+		Name:    data.Name,
+		Type:    data.Type,
+		Enabled: data.IsEnabled,
+		Default: data.IsDefault,
+		Queries: make([]SecurityRuleQuery, 0, len(data.Queries)),
+		Cases:   make([]SecurityRuleCase, 0, len(data.Cases)),
+		Tags:    data.Tags,
+		URL:     s.appURL("/security/rules/view/" + url.PathEscape(data.ID)),
+	}
+	sort.Strings(rule.Tags)
+	if data.UpdatedAt > 0 {
+		rule.Updated = time.UnixMilli(data.UpdatedAt).UTC().Format(time.RFC3339)
+	}
+	for _, q := range data.Queries {
+		query := SecurityRuleQuery{Name: q.Name, Query: q.Query, DataSource: q.DataSource, GroupBy: q.GroupByFields}
+		if query.Query == "" && q.RuleID != "" {
+			// Signal correlation rules query other rules' signals.
+			query.Query = "signals from rule " + q.RuleID
+		}
+		rule.Queries = append(rule.Queries, query)
+	}
+	highest := -1
+	for _, c := range data.Cases {
+		rule.Cases = append(rule.Cases, SecurityRuleCase{Name: c.Name, Condition: c.Condition, Severity: c.Status})
+		highest = max(highest, slices.Index(securitySeverities, c.Status))
+	}
+	if highest >= 0 {
+		rule.Severity = securitySeverities[highest]
+	}
+	return rule
+}
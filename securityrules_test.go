@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestListSecurityRules(t *testing.T) {
+	var query url.Values
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v2/security_monitoring/rules":
+			query = r.URL.Query()
+			_, _ = w.Write([]byte(`{"data":[
+				{"id":"abc-123","name":"Brute force on login","type":"log_detection","isEnabled":true,"isDefault":true,"updatedAt":1768899780000,
+					"tags":["source:auth","tactic:TA0006"],
+					"queries":[{"name":"failed","query":"@evt.name:authentication @evt.outcome:failure","dataSource":"logs","groupByFields":["@usr.id"]}],
+					"cases":[{"name":"many","condition":"failed > 10","status":"medium"},{"name":"lots","condition":"failed > 100","status":"high"}]},
+				{"id":"def-456","name":"Chatty rule","type":"log_detection","isEnabled":false,
+					"queries":[{"query":"service:noisy"}],"cases":[{"condition":"a > 0","status":"info"}]},
+				{"id":"ghi-789","name":"Correlated","type":"signal_correlation","isEnabled":true,
+					"queries":[{"ruleId":"abc-123"}],"cases":[{"status":"critical"}]}]}`))
+		case "/api/v2/security_monitoring/rules/abc-123":
+			_, _ = w.Write([]byte(`{"id":"abc-123","name":"Brute force on login","type":"log_detection","isEnabled":true,"message":"## Goal\nDetect brute force.","cases":[{"status":"high"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	result, err := server.ListSecurityRules(ListSecurityRulesParams{Query: "login"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query.Get("query") != "login" || query.Get("page[size]") != "100" || query.Get("page[number]") != "0" {
+		t.Fatalf("unexpected query %v", query)
+	}
+	if result.Count != 3 || result.Matched != 3 {
+		t.Fatalf("expected every rule, got %+v", result)
+	}
+	first := result.Rules[0]
+	if first.Severity != "high" || !first.Enabled || !first.Default || len(first.Cases) != 2 || first.Queries[0].GroupBy[0] != "@usr.id" || first.Updated != "2026-01-20T09:03:00Z" {
+		t.Errorf("unexpected rule %+v", first)
+	}
+	if first.Message != "" || !strings.HasSuffix(first.URL, "/security/rules/view/abc-123") {
+		t.Errorf("expected no message and a rule link, got %+v", first)
+	}
+	if q := result.Rules[2].Queries[0].Query; q != "signals from rule abc-123" {
+		t.Errorf("unexpected correlation query %q", q)
+	}
+
+	enabled := true
+	result, err = server.ListSecurityRules(ListSecurityRulesParams{Enabled: &enabled, MinSeverity: "HIGH", Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Count != 1 || result.Matched != 2 || result.Rules[0].ID != "abc-123" || len(result.Notes) != 1 {
+		t.Fatalf("expected the two enabled high rules with one returned, got %+v", result)
+	}
+
+	result, err = server.ListSecurityRules(ListSecurityRulesParams{RuleID: "abc-123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Count != 1 || result.Rules[0].Message != "## Goal\nDetect brute force." {
+		t.Fatalf("expected the single rule with its message, got %+v", result)
+	}
+
+	if _, err := server.ListSecurityRules(ListSecurityRulesParams{MinSeverity: "severe"}); err == nil {
+		t.Fatal("expected an unknown severity to be rejected")
+	}
+}
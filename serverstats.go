@@ -0,0 +1,43 @@
+package main
+
+import (
+	"time"
+)
+
+type ServerStats struct {
+	StartedAt   string            `json:"started_at"`
+	Uptime      string            `json:"uptime"`
+	ResultStore *ResultStoreStats `json:"result_store,omitempty"`
+	// APIBackends is the backend each dual-path Datadog API uses.
+	APIBackends map[string]string `json:"api_backends,omitempty"`
+	// ReducedMode explains why tools are missing when the server has no
+	// application key.
+	ReducedMode string `json:"reduced_mode,omitempty"`
+	// DisabledProducts are products whose tools are hidden on this site.
+	DisabledProducts []string `json:"disabled_products,omitempty"`
+	// Failover reports reads served by the secondary keys.
+	Failover *FailoverStats `json:"failover,omitempty"`
+}
+
+// Stats reports the server's own health and resource usage.
+func (s *MCPServer) Stats() *ServerStats {
+	stats := &ServerStats{
+		StartedAt: s.startedAt.Format(time.RFC3339),
+		Uptime:    time.Since(s.startedAt).Round(time.Second).String(),
+	}
+	if s.results != nil {
+		store := s.results.snapshot()
+		stats.ResultStore = &store
+	}
+	if s.backends != nil {
+		stats.APIBackends = s.backends.report(s.apiOrg())
+	}
+	if s.apiKeyOnly {
+		stats.ReducedMode = apiKeyOnlyReason
+	}
+	stats.DisabledProducts = s.disabledProducts
+	if s.failover != nil {
+		stats.Failover = s.failover.stats()
+	}
+	return stats
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV2"
+)
+
+const (
+	defaultServiceLimit = 50
+	maxServiceLimit     = 500
+	serviceCatalogPage  = 100
+)
+
+// ServiceLink is a link declared in a service definition, normalized across
+// the v1, v2 and v2.x schema shapes.
+type ServiceLink struct {
+	Name string `json:"name,omitempty"`
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// serviceDefinitionLinks mirrors only the link-bearing fields of the
+// service definition schemas. v2.x uses "links" with a type, v2 also lists
+// "repos", and v1 keeps everything under "external-resources".
+type serviceDefinitionLinks struct {
+	Links []struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+		URL  string `json:"url"`
+	} `json:"links"`
+	Repos []struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	} `json:"repos"`
+	ExternalResources []struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+		URL  string `json:"url"`
+	} `json:"external-resources"`
+}
+
+// fetchServiceLinks returns the links declared in a service's definition.
+func (s *MCPServer) fetchServiceLinks(service string) ([]ServiceLink, error) {
+	api := datadogV2.NewServiceDefinitionApi(s.ddClient)
+	resp, _, err := api.GetServiceDefinition(s.ctx, service)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service definition for %s: %w", service, err)
+	}
+	if resp.Data == nil || resp.Data.Attributes == nil || resp.Data.Attributes.Schema == nil {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(resp.Data.Attributes.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service definition for %s: %w", service, err)
+	}
+	return parseServiceLinks(raw)
+}
+
+func parseServiceLinks(raw []byte) ([]ServiceLink, error) {
+	var def serviceDefinitionLinks
+	if err := json.Unmarshal(raw, &def); err != nil {
+		return nil, fmt.Errorf("failed to parse service definition links: %w", err)
+	}
+
+	links := make([]ServiceLink, 0)
+	for _, l := range def.Links {
+		links = append(links, ServiceLink{Name: l.Name, Type: strings.ToLower(l.Type), URL: l.URL})
+	}
+	for _, r := range def.Repos {
+		links = append(links, ServiceLink{Name: r.Name, Type: "repo", URL: r.URL})
+	}
+	for _, r := range def.ExternalResources {
+		links = append(links, ServiceLink{Name: r.Name, Type: strings.ToLower(r.Type), URL: r.URL})
+	}
+	return links, nil
+}
+
+// repoURL returns the first repository link, if any.
+func repoURL(links []ServiceLink) string {
+	for _, l := range links {
+		if l.Type == "repo" && l.URL != "" {
+			return l.URL
+		}
+	}
+	return ""
+}
+
+type ListServicesParams struct {
+	// Query keeps services whose name or description contains it.
+	Query string `json:"query,omitempty"`
+	Team  string `json:"team,omitempty"`
+	Limit int    `json:"limit,omitempty"`
+}
+
+// ServiceContact is a contact declared in a service definition.
+type ServiceContact struct {
+	Type    string `json:"type"`
+	Name    string `json:"name,omitempty"`
+	Contact string `json:"contact"`
+}
+
+type CatalogService struct {
+	Name        string           `json:"name"`
+	Team        string           `json:"team,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Application string           `json:"application,omitempty"`
+	Tier        string           `json:"tier,omitempty"`
+	Lifecycle   string           `json:"lifecycle,omitempty"`
+	Contacts    []ServiceContact `json:"contacts,omitempty"`
+	// OnCall links to the service's PagerDuty or Opsgenie service and any
+	// on-call links in its definition.
+	OnCall []ServiceLink `json:"on_call,omitempty"`
+	Repos  []string      `json:"repos,omitempty"`
+	// Links are the definition's other links, such as runbooks and docs.
+	Links         []ServiceLink `json:"links,omitempty"`
+	SchemaVersion string        `json:"schema_version,omitempty"`
+	URL           string        `json:"url"`
+}
+
+type ListServicesResult struct {
+	Services []CatalogService `json:"services"`
+	Count    int              `json:"count"`
+	// Total is how many services matched before limit was applied.
+	Total int  `json:"total"`
+	More  bool `json:"more,omitempty"`
+}
+
+// serviceDefinitionSummary mirrors the ownership fields of the service
+// definition schemas. v1 nests the name under "info" and the team under
+// "org"; PagerDuty is a URL before v2.1 and an object from then on.
+type serviceDefinitionSummary struct {
+	SchemaVersion string `json:"schema-version"`
+	Service       string `json:"dd-service"`
+	Team          string `json:"team"`
+	Description   string `json:"description"`
+	Application   string `json:"application"`
+	Tier          string `json:"tier"`
+	Lifecycle     string `json:"lifecycle"`
+	Contacts      []struct {
+		Type    string `json:"type"`
+		Name    string `json:"name"`
+		Contact string `json:"contact"`
+	} `json:"contacts"`
+	Integrations struct {
+		PagerDuty json.RawMessage `json:"pagerduty"`
+		Opsgenie  struct {
+			ServiceURL string `json:"service-url"`
+		} `json:"opsgenie"`
+	} `json:"integrations"`
+	Info struct {
+		Service     string `json:"dd-service"`
+		Description string `json:"description"`
+		Tier        string `json:"service-tier"`
+	} `json:"info"`
+	Org struct {
+		Team        string `json:"team"`
+		Application string `json:"application"`
+	} `json:"org"`
+	Contact struct {
+		Email string `json:"email"`
+		Slack string `json:"slack"`
+	} `json:"contact"`
+}
+
+// ListServices lists the Software Catalog's service definitions with their
+// owners, on-call links and repositories.
+func (s *MCPServer) ListServices(params ListServicesParams) (*ListServicesResult, error) {
+	limit := params.Limit
+	if limit <= 0 {
+		limit = defaultServiceLimit
+	}
+	if limit > maxServiceLimit {
+		return nil, fmt.Errorf("limit must be at most %d", maxServiceLimit)
+	}
+
+	api := datadogV2.NewServiceDefinitionApi(s.ddClient)
+	query := strings.ToLower(strings.TrimSpace(params.Query))
+	var services []CatalogService
+	for page := int64(0); ; page++ {
+		opts := datadogV2.NewListServiceDefinitionsOptionalParameters().WithPageSize(serviceCatalogPage).WithPageNumber(page)
+		resp, _, err := api.ListServiceDefinitions(s.ctx, *opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list services: %w", err)
+		}
+		for _, def := range resp.Data {
+			if def.Attributes == nil || def.Attributes.Schema == nil {
+				continue
+			}
+			raw, err := json.Marshal(def.Attributes.Schema)
+			if err != nil {
+				continue
+			}
+			service, err := parseServiceDefinition(raw)
+			if err != nil || service.Name == "" {
+				continue
+			}
+			if params.Team != "" && !strings.EqualFold(service.Team, params.Team) {
+				continue
+			}
+			if query != "" && !strings.Contains(strings.ToLower(service.Name), query) && !strings.Contains(strings.ToLower(service.Description), query) {
+				continue
+			}
+			service.URL = s.appURL("/services?selectedService=" + url.QueryEscape(service.Name))
+			services = append(services, service)
+		}
+		if len(resp.Data) < serviceCatalogPage {
+			break
+		}
+	}
+
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+	result := &ListServicesResult{Total: len(services)}
+	if len(services) > limit {
+		services, result.More = services[:limit], true
+	}
+	result.Services = services
+	result.Count = len(services)
+	if result.Services == nil {
+		result.Services = []CatalogService{}
+	}
+	return result, nil
+}
+
+// parseServiceDefinition reads a service definition of any schema version.
+func parseServiceDefinition(raw []byte) (CatalogService, error) {
+	var def serviceDefinitionSummary
+	if err := json.Unmarshal(raw, &def); err != nil {
+		return CatalogService{}, fmt.Errorf("failed to parse service definition: %w", err)
+	}
+	links, err := parseServiceLinks(raw)
+	if err != nil {
+		return CatalogService{}, err
+	}
+
+	service := CatalogService{
+		Name:          cmp.Or(def.Service, def.Info.Service),
+		Team:          cmp.Or(def.Team, def.Org.Team),
+		Description:   cmp.Or(def.Description, def.Info.Description),
+		Application:   cmp.Or(def.Application, def.Org.Application),
+		Tier:          cmp.Or(def.Tier, def.Info.Tier),
+		Lifecycle:     def.Lifecycle,
+		SchemaVersion: def.SchemaVersion,
+	}
+	for _, c := range def.Contacts {
+		service.Contacts = append(service.Contacts, ServiceContact{Type: strings.ToLower(c.Type), Name: c.Name, Contact: c.Contact})
+	}
+	if def.Contact.Email != "" {
+		service.Contacts = append(service.Contacts, ServiceContact{Type: "email", Contact: def.Contact.Email})
+	}
+	if def.Contact.Slack != "" {
+		service.Contacts = append(service.Contacts, ServiceContact{Type: "slack", Contact: def.Contact.Slack})
+	}
+
+	if pagerDuty := pagerDutyURL(def.Integrations.PagerDuty); pagerDuty != "" {
+		service.OnCall = append(service.OnCall, ServiceLink{Type: "pagerduty", URL: pagerDuty})
+	}
+	if def.Integrations.Opsgenie.ServiceURL != "" {
+		service.OnCall = append(service.OnCall, ServiceLink{Type: "opsgenie", URL: def.Integrations.Opsgenie.ServiceURL})
+	}
+	for _, l := range links {
+		switch l.Type {
+		case "repo":
+			service.Repos = append(service.Repos, l.URL)
+		case "oncall", "on-call", "pagerduty", "opsgenie":
+			service.OnCall = append(service.OnCall, l)
+		default:
+			service.Links = append(service.Links, l)
+		}
+	}
+	return service, nil
+}
+
+// pagerDutyURL reads the PagerDuty integration, which is a bare URL in the
+// v1 and v2 schemas and {"service-url": ...} from v2.1.
+func pagerDutyURL(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var link string
+	if json.Unmarshal(raw, &link) == nil {
+		return link
+	}
+	var integration struct {
+		ServiceURL string `json:"service-url"`
+	}
+	if json.Unmarshal(raw, &integration) == nil {
+		return integration.ServiceURL
+	}
+	return ""
+}
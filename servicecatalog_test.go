@@ -0,0 +1,104 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestParseServiceLinks(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		expected string
+	}{
+		{
+			name:     "v2.2 links",
+			raw:      `{"schema-version":"v2.2","links":[{"name":"Runbook","type":"runbook","url":"https://wiki/rb"},{"name":"Source","type":"repo","url":"https://github.com/acme/api"}]}`,
+			expected: "https://github.com/acme/api",
+		},
+		{
+			name:     "v2 repos",
+			raw:      `{"schema-version":"v2","repos":[{"name":"api","url":"https://gitlab.com/acme/api"}]}`,
+			expected: "https://gitlab.com/acme/api",
+		},
+		{
+			name:     "v1 external resources",
+			raw:      `{"schema-version":"v1","external-resources":[{"name":"src","type":"repo","url":"https://github.com/acme/v1"}]}`,
+			expected: "https://github.com/acme/v1",
+		},
+		{
+			name:     "no repository",
+			raw:      `{"schema-version":"v2.2","links":[{"type":"doc","url":"https://docs"}]}`,
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			links, err := parseServiceLinks([]byte(tt.raw))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if repo := repoURL(links); repo != tt.expected {
+				t.Errorf("expected repo %q, got %q", tt.expected, repo)
+			}
+		})
+	}
+}
+
+func TestListServices(t *testing.T) {
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/services/definitions") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":[
+			{"type":"service-definition","attributes":{"schema":{"schema-version":"v2.2","dd-service":"checkout","team":"payments","tier":"1",
+				"contacts":[{"type":"slack","name":"Payments","contact":"https://slack.com/app_redirect?channel=payments"}],
+				"integrations":{"pagerduty":{"service-url":"https://acme.pagerduty.com/service-directory/P1"}},
+				"links":[{"name":"Source","type":"repo","url":"https://github.com/acme/checkout"},{"name":"Runbook","type":"runbook","url":"https://wiki/checkout"}]}}},
+			{"type":"service-definition","attributes":{"schema":{"schema-version":"v2","dd-service":"cart","team":"payments",
+				"integrations":{"pagerduty":"https://acme.pagerduty.com/service-directory/P2"},"repos":[{"name":"cart","url":"https://github.com/acme/cart"}]}}},
+			{"type":"service-definition","attributes":{"schema":{"schema-version":"v1","info":{"dd-service":"auth","description":"Login and sessions"},
+				"org":{"team":"identity"},"contact":{"email":"identity@acme.com"}}}}]}`))
+	})
+
+	result, err := server.ListServices(ListServicesParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Count != 3 || result.Services[0].Name != "auth" || result.Services[2].Name != "checkout" {
+		t.Fatalf("expected all services sorted by name, got %+v", result)
+	}
+	auth, cart, checkout := result.Services[0], result.Services[1], result.Services[2]
+	if auth.Team != "identity" || auth.Description != "Login and sessions" || len(auth.Contacts) != 1 || auth.Contacts[0].Type != "email" {
+		t.Fatalf("expected the v1 definition's owner fields, got %+v", auth)
+	}
+	if len(cart.OnCall) != 1 || cart.OnCall[0].URL != "https://acme.pagerduty.com/service-directory/P2" || cart.Repos[0] != "https://github.com/acme/cart" {
+		t.Fatalf("expected the v2 PagerDuty URL and repo, got %+v", cart)
+	}
+	if checkout.OnCall[0].Type != "pagerduty" || len(checkout.Repos) != 1 || len(checkout.Links) != 1 || checkout.Links[0].Type != "runbook" {
+		t.Fatalf("expected on-call, repo and other links separated, got %+v", checkout)
+	}
+	if checkout.URL != "https://app.datadoghq.com/services?selectedService=checkout" {
+		t.Fatalf("unexpected catalog link: %s", checkout.URL)
+	}
+
+	result, err = server.ListServices(ListServicesParams{Team: "Payments", Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Count != 1 || result.Total != 2 || !result.More || result.Services[0].Name != "cart" {
+		t.Fatalf("expected the team filter and limit applied, got %+v", result)
+	}
+
+	result, err = server.ListServices(ListServicesParams{Query: "login"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Count != 1 || result.Services[0].Name != "auth" {
+		t.Fatalf("expected the description to match, got %+v", result)
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// maxServices bounds how many services one call may fan out over.
+const maxServices = 10
+
+// ServiceLogsResult is a log search run once per service. Each service's
+// result is keyed by the name the caller asked for; services whose query
+// failed are listed in Errors instead.
+type ServiceLogsResult struct {
+	Query    string                      `json:"query"`
+	Count    int                         `json:"count"`
+	Services map[string]*QueryLogsResult `json:"services"`
+	Errors   map[string]string           `json:"errors,omitempty"`
+}
+
+// ServiceMetricsResult is a metric analysis run once per service.
+type ServiceMetricsResult struct {
+	Metric   string                          `json:"metric"`
+	Services map[string]*MetricInsightResult `json:"services"`
+	Errors   map[string]string               `json:"errors,omitempty"`
+}
+
+// normalizeServices trims and de-duplicates a services argument.
+func normalizeServices(services []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var names []string
+	for _, service := range services {
+		service = strings.TrimSpace(service)
+		if service == "" || seen[service] {
+			continue
+		}
+		seen[service] = true
+		names = append(names, service)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("services must name at least one service")
+	}
+	if len(names) > maxServices {
+		return nil, fmt.Errorf("at most %d services can be queried at once, got %d", maxServices, len(names))
+	}
+	return names, nil
+}
+
+// QueryServiceLogs runs a log search scoped to each service concurrently,
+// so an incident spanning several services takes one round trip instead
+// of one per service.
+func (s *MCPServer) QueryServiceLogs(params QueryLogsParams) (*ServiceLogsResult, error) {
+	if params.Query == "" {
+		return nil, fmt.Errorf("query parameter is required")
+	}
+	if hasTagFilter(params.Query, "service") {
+		return nil, fmt.Errorf("query already filters on service; remove it when using services")
+	}
+	services, err := normalizeServices(params.Services)
+	if err != nil {
+		return nil, err
+	}
+
+	results, errs := fanOut(s, services, "services", func(srv *MCPServer, service string) (*QueryLogsResult, error) {
+		scoped := params
+		scoped.Services = nil
+		scoped.Query = scopeSearchQuery(params.Query, [][2]string{{"service", service}})
+		return srv.QueryLogs(scoped)
+	})
+	if len(results) == 0 {
+		return nil, allFailed("service", errs)
+	}
+
+	result := &ServiceLogsResult{Query: params.Query, Services: results, Errors: errs}
+	for _, r := range results {
+		result.Count += r.Count
+	}
+	return result, nil
+}
+
+// DetectServiceAnomalies runs detect_anomalies once per service.
+func (s *MCPServer) DetectServiceAnomalies(params AnomalyParams) (*ServiceMetricsResult, error) {
+	return s.metricsByService(params.Metric, params.Services, func(srv *MCPServer, metric string) (*MetricInsightResult, error) {
+		scoped := params
+		scoped.Services = nil
+		scoped.Metric = metric
+		return srv.DetectAnomalies(scoped)
+	})
+}
+
+// ForecastServiceMetrics runs forecast_metric once per service.
+func (s *MCPServer) ForecastServiceMetrics(params ForecastParams) (*ServiceMetricsResult, error) {
+	return s.metricsByService(params.Metric, params.Services, func(srv *MCPServer, metric string) (*MetricInsightResult, error) {
+		scoped := params
+		scoped.Services = nil
+		scoped.Metric = metric
+		return srv.ForecastMetric(scoped)
+	})
+}
+
+// metricsByService scopes metric to each service and runs analyze over
+// the scoped queries concurrently.
+func (s *MCPServer) metricsByService(metric string, services []string, analyze func(*MCPServer, string) (*MetricInsightResult, error)) (*ServiceMetricsResult, error) {
+	if metric == "" {
+		return nil, fmt.Errorf("metric parameter is required")
+	}
+	if hasTagFilter(metric, "service") {
+		return nil, fmt.Errorf("metric already filters on service; remove it when using services")
+	}
+	if !strings.Contains(metric, "{") {
+		return nil, fmt.Errorf("metric has no {scope} to add the service to")
+	}
+	services, err := normalizeServices(services)
+	if err != nil {
+		return nil, err
+	}
+
+	results, errs := fanOut(s, services, "services", func(srv *MCPServer, service string) (*MetricInsightResult, error) {
+		return analyze(srv, scopeMetricQuery(metric, [][2]string{{"service", service}}))
+	})
+	if len(results) == 0 {
+		return nil, allFailed("service", errs)
+	}
+	return &ServiceMetricsResult{Metric: metric, Services: results, Errors: errs}, nil
+}
+
+// fanOut runs query for every name at once, such as each service or
+// org. Each run gets its own copy of the server without the progress
+// token, since interleaved page updates from several queries would make
+// progress go backwards; progress is reported per finished name instead.
+func fanOut[T any](s *MCPServer, names []string, noun string, query func(*MCPServer, string) (T, error)) (map[string]T, map[string]string) {
+	results := make(map[string]T)
+	var errs map[string]string
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	done := 0
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			quiet := *s
+			quiet.progressToken = nil
+			result, err := query(&quiet, name)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if errs == nil {
+					errs = make(map[string]string)
+				}
+				errs[name] = err.Error()
+			} else {
+				results[name] = result
+			}
+			done++
+			s.reportProgress(done, len(names), fmt.Sprintf("queried %d of %d %s", done, len(names), noun), "")
+		}(name)
+	}
+	wg.Wait()
+	return results, errs
+}
+
+// allFailed reports the errors of a fan-out in which every noun failed,
+// in a stable order.
+func allFailed(noun string, errs map[string]string) error {
+	names := make([]string, 0, len(errs))
+	for name := range errs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	messages := make([]string, 0, len(names))
+	for _, name := range names {
+		messages = append(messages, name+": "+errs[name])
+	}
+	return fmt.Errorf("every %s failed: %s", noun, strings.Join(messages, "; "))
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestQueryServiceLogs(t *testing.T) {
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		data, _ := io.ReadAll(r.Body)
+		var body struct {
+			Filter struct {
+				Query string `json:"query"`
+			} `json:"filter"`
+		}
+		_ = json.Unmarshal(data, &body)
+		switch body.Filter.Query {
+		case "status:error service:web":
+			_, _ = w.Write([]byte(`{"data":[{"id":"1","attributes":{"service":"web"}},{"id":"2","attributes":{"service":"web"}}]}`))
+		case "status:error service:api":
+			_, _ = w.Write([]byte(`{"data":[{"id":"3","attributes":{"service":"api"}}]}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"errors":["bad query"]}`))
+		}
+	})
+
+	result, err := server.QueryServiceLogs(QueryLogsParams{Query: "status:error", Services: []string{"web", " api", "web", "broken"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Count != 3 || result.Services["web"].Count != 2 || result.Services["api"].Count != 1 {
+		t.Fatalf("unexpected per-service results: %+v", result)
+	}
+	if result.Services["api"].Query != "status:error service:api" {
+		t.Fatalf("expected the api query to be scoped, got %q", result.Services["api"].Query)
+	}
+	if _, ok := result.Errors["broken"]; !ok || len(result.Services) != 2 {
+		t.Fatalf("expected broken to be reported as an error, got %+v", result.Errors)
+	}
+
+	if _, err := server.QueryServiceLogs(QueryLogsParams{Query: "service:web", Services: []string{"api"}}); err == nil {
+		t.Fatal("expected a query that filters on service to be rejected")
+	}
+	if _, err := server.QueryServiceLogs(QueryLogsParams{Query: "*", Services: []string{"broken"}}); err == nil || !strings.Contains(err.Error(), "broken:") {
+		t.Fatalf("expected every service failing to be an error, got %v", err)
+	}
+}
+
+func TestServiceMetricsScopeEachService(t *testing.T) {
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"ok","series":[]}`))
+	})
+
+	result, err := server.DetectServiceAnomalies(AnomalyParams{Metric: "avg:latency{env:prod}", Services: []string{"web", "api"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := result.Services["api"].Query; got != "anomalies(avg:latency{env:prod,service:api}, 'basic', 2)" {
+		t.Fatalf("unexpected api query: %s", got)
+	}
+	if len(result.Services) != 2 || result.Errors != nil {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	if _, err := server.ForecastServiceMetrics(ForecastParams{Metric: "avg:latency", Services: []string{"web"}}); err == nil {
+		t.Fatal("expected a metric without a scope to be rejected")
+	}
+	if _, err := normalizeServices(strings.Split("a,b,c,d,e,f,g,h,i,j,k", ",")); err == nil {
+		t.Fatal("expected too many services to be rejected")
+	}
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sessionContextTTL is how long an untouched session's pinned context is
+// kept once the store needs sweeping.
+const sessionContextTTL = 24 * time.Hour
+
+// SetContextParams updates the session's pinned defaults. Omitted fields
+// keep their current value, an empty string unpins a field, and Clear
+// unpins everything before the other fields are applied.
+type SetContextParams struct {
+	Service *string `json:"service,omitempty"`
+	Env     *string `json:"env,omitempty"`
+	Cluster *string `json:"cluster,omitempty"`
+	Window  *string `json:"window,omitempty"`
+	Clear   bool    `json:"clear,omitempty"`
+}
+
+// SessionContext is the set of defaults pinned for a session. They are
+// merged into later tool calls unless the call says otherwise.
+type SessionContext struct {
+	Service string `json:"service,omitempty"`
+	Env     string `json:"env,omitempty"`
+	Cluster string `json:"cluster,omitempty"`
+	Window  string `json:"window,omitempty"`
+}
+
+// tags returns the pinned scope as Datadog tags, keyed by tag name.
+func (c SessionContext) tags() [][2]string {
+	var tags [][2]string
+	if c.Service != "" {
+		tags = append(tags, [2]string{"service", c.Service})
+	}
+	if c.Env != "" {
+		tags = append(tags, [2]string{"env", c.Env})
+	}
+	if c.Cluster != "" {
+		tags = append(tags, [2]string{"kube_cluster_name", c.Cluster})
+	}
+	return tags
+}
+
+type sessionContextEntry struct {
+	context  SessionContext
+	lastUsed time.Time
+}
+
+// contextStore holds pinned defaults per session. It is shared by every
+// copy of the server.
+type contextStore struct {
+	mu       sync.Mutex
+	sessions map[string]*sessionContextEntry
+}
+
+func newContextStore() *contextStore {
+	return &contextStore{sessions: make(map[string]*sessionContextEntry)}
+}
+
+func (c *contextStore) get(key string, now time.Time) SessionContext {
+	if c == nil {
+		return SessionContext{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.sessions[key]
+	if !ok {
+		return SessionContext{}
+	}
+	entry.lastUsed = now
+	return entry.context
+}
+
+func (c *contextStore) set(key string, ctx SessionContext, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ctx == (SessionContext{}) {
+		delete(c.sessions, key)
+		return
+	}
+	if _, ok := c.sessions[key]; !ok && len(c.sessions) >= sweepThreshold {
+		for k, entry := range c.sessions {
+			if now.Sub(entry.lastUsed) >= sessionContextTTL {
+				delete(c.sessions, k)
+			}
+		}
+	}
+	c.sessions[key] = &sessionContextEntry{context: ctx, lastUsed: now}
+}
+
+func (s *MCPServer) SetContext(params SetContextParams) (*SessionContext, error) {
+	if s.contexts == nil {
+		return nil, fmt.Errorf("session context is not available")
+	}
+	now := time.Now()
+	ctx := s.contexts.get(s.session, now)
+	if params.Clear {
+		ctx = SessionContext{}
+	}
+	if params.Service != nil {
+		ctx.Service = strings.TrimSpace(*params.Service)
+	}
+	if params.Env != nil {
+		ctx.Env = strings.TrimSpace(*params.Env)
+	}
+	if params.Cluster != nil {
+		ctx.Cluster = strings.TrimSpace(*params.Cluster)
+	}
+	if params.Window != nil {
+		ctx.Window = strings.TrimSpace(*params.Window)
+		if _, err := parseDurationParam(ctx.Window, 0); err != nil {
+			return nil, err
+		}
+	}
+	s.contexts.set(s.session, ctx, now)
+	return &ctx, nil
+}
+
+func (s *MCPServer) GetContext() *SessionContext {
+	ctx := s.contexts.get(s.session, time.Now())
+	return &ctx
+}
+
+// applySessionContext fills the session's pinned defaults into a tool's
+// arguments. Explicit arguments win: a scope tag is only added when the
+// query doesn't already filter on that tag, and the window only replaces
+// an omitted time range.
+func (s *MCPServer) applySessionContext(tool string, arguments json.RawMessage) json.RawMessage {
+	ctx := s.contexts.get(s.session, time.Now())
+	if ctx == (SessionContext{}) {
+		return arguments
+	}
+
+	args := make(map[string]interface{})
+	if len(arguments) > 0 {
+		if err := json.Unmarshal(arguments, &args); err != nil {
+			// Leave malformed arguments for the tool to report.
+			return arguments
+		}
+	}
+
+	var window string
+	if ctx.Window != "" {
+		// from only takes Go durations, so normalize "7d" and friends.
+		if d, err := parseDurationParam(ctx.Window, 0); err == nil {
+			window = d.String()
+		}
+	}
+
+	tags := ctx.tags()
+	if _, ok := args["services"]; ok && ctx.Service != "" {
+		// An explicit list of services replaces the pinned one.
+		tags = tags[1:]
+	}
+
+	switch tool {
+	case "query_logs", "alert_fatigue_report":
+		query, _ := args["query"].(string)
+		args["query"] = scopeSearchQuery(query, tags)
+	case "query_events":
+		// Tags rather than the query, so the scope survives the v1 fallback.
+		query, _ := args["query"].(string)
+		var eventTags []interface{}
+		if existing, ok := args["tags"].([]interface{}); ok {
+			eventTags = existing
+		}
+		for _, tag := range tags {
+			if !hasTagFilter(query, tag[0]) && !hasTagInList(eventTags, tag[0]) {
+				eventTags = append(eventTags, tag[0]+":"+tag[1])
+			}
+		}
+		if len(eventTags) > 0 {
+			args["tags"] = eventTags
+		}
+	case "detect_anomalies", "forecast_metric":
+		if metric, ok := args["metric"].(string); ok {
+			args["metric"] = scopeMetricQuery(metric, tags)
+		}
+	default:
+		return arguments
+	}
+
+	if window != "" {
+		switch tool {
+		case "query_logs", "query_events":
+			if from, _ := args["from"].(string); from == "" {
+				args["from"] = window
+			}
+		default:
+			if w, _ := args["window"].(string); w == "" {
+				args["window"] = window
+			}
+		}
+	}
+
+	merged, err := json.Marshal(args)
+	if err != nil {
+		return arguments
+	}
+	return merged
+}
+
+// scopeSearchQuery appends the tags a log or event search doesn't already
+// filter on.
+func scopeSearchQuery(query string, tags [][2]string) string {
+	parts := []string{}
+	if strings.TrimSpace(query) != "" {
+		parts = append(parts, query)
+	}
+	for _, tag := range tags {
+		if !hasTagFilter(query, tag[0]) {
+			parts = append(parts, tag[0]+":"+tag[1])
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// scopeMetricQuery adds the tags to every {scope} in a metric query that
+// doesn't already filter on them; {*} is replaced outright.
+func scopeMetricQuery(query string, tags [][2]string) string {
+	var out strings.Builder
+	rest := query
+	for {
+		open := strings.IndexByte(rest, '{')
+		if open < 0 {
+			break
+		}
+		end := strings.IndexByte(rest[open:], '}')
+		if end < 0 {
+			break
+		}
+		end += open
+		scope := strings.TrimSpace(rest[open+1 : end])
+
+		var filters []string
+		if scope != "" && scope != "*" {
+			filters = append(filters, scope)
+		}
+		for _, tag := range tags {
+			if !hasTagFilter(scope, tag[0]) {
+				filters = append(filters, tag[0]+":"+tag[1])
+			}
+		}
+		if len(filters) == 0 {
+			filters = []string{"*"}
+		}
+
+		out.WriteString(rest[:open+1])
+		out.WriteString(strings.Join(filters, ","))
+		out.WriteByte('}')
+		rest = rest[end+1:]
+	}
+	out.WriteString(rest)
+	return out.String()
+}
+
+// hasTagFilter reports whether query mentions key as a tag filter,
+// including negated and attribute forms (-env:, @env:).
+func hasTagFilter(query, key string) bool {
+	query = strings.ToLower(query)
+	key = strings.ToLower(key) + ":"
+	for i := strings.Index(query, key); i >= 0; {
+		if i == 0 || strings.ContainsRune(" ,({-@!", rune(query[i-1])) {
+			return true
+		}
+		next := strings.Index(query[i+1:], key)
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	return false
+}
+
+func hasTagInList(tags []interface{}, key string) bool {
+	for _, tag := range tags {
+		if text, ok := tag.(string); ok && hasTagFilter(text, key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestSetContextMergesAndClears(t *testing.T) {
+	server := &MCPServer{contexts: newContextStore(), session: "a"}
+
+	if _, err := server.SetContext(SetContextParams{Service: strPtr("checkout"), Env: strPtr("prod")}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.SetContext(SetContextParams{Window: strPtr("7d")}); err != nil {
+		t.Fatal(err)
+	}
+	got := *server.GetContext()
+	want := SessionContext{Service: "checkout", Env: "prod", Window: "7d"}
+	if got != want {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+
+	if other := *server.withSession("b").GetContext(); other != (SessionContext{}) {
+		t.Fatalf("expected another session to have no context, got %+v", other)
+	}
+
+	if _, err := server.SetContext(SetContextParams{Env: strPtr("")}); err != nil {
+		t.Fatal(err)
+	}
+	if got := server.GetContext(); got.Env != "" || got.Service != "checkout" {
+		t.Fatalf("expected env unpinned and service kept, got %+v", got)
+	}
+
+	if _, err := server.SetContext(SetContextParams{Clear: true, Cluster: strPtr("east")}); err != nil {
+		t.Fatal(err)
+	}
+	if got := *server.GetContext(); got != (SessionContext{Cluster: "east"}) {
+		t.Fatalf("expected only the cluster after clear, got %+v", got)
+	}
+
+	if _, err := server.SetContext(SetContextParams{Window: strPtr("soon")}); err == nil {
+		t.Fatal("expected an invalid window to be rejected")
+	}
+}
+
+func TestApplySessionContext(t *testing.T) {
+	server := &MCPServer{contexts: newContextStore(), session: "a"}
+	if _, err := server.SetContext(SetContextParams{Service: strPtr("checkout"), Env: strPtr("prod"), Window: strPtr("2d")}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		tool string
+		args string
+		want map[string]interface{}
+	}{
+		{
+			tool: "query_logs",
+			args: `{"query":"status:error env:staging"}`,
+			want: map[string]interface{}{"query": "status:error env:staging service:checkout", "from": "48h0m0s"},
+		},
+		{
+			tool: "query_logs",
+			args: `{"query":"status:error","from":"15m"}`,
+			want: map[string]interface{}{"query": "status:error service:checkout env:prod", "from": "15m"},
+		},
+		{
+			tool: "alert_fatigue_report",
+			args: `{}`,
+			want: map[string]interface{}{"query": "service:checkout env:prod", "window": "48h0m0s"},
+		},
+		{
+			tool: "query_events",
+			args: `{"tags":["env:qa"]}`,
+			want: map[string]interface{}{"tags": []interface{}{"env:qa", "service:checkout"}, "from": "48h0m0s"},
+		},
+		{
+			tool: "detect_anomalies",
+			args: `{"metric":"avg:latency{*} / avg:requests{region:us,-env:dev}","window":"1h"}`,
+			want: map[string]interface{}{"metric": "avg:latency{service:checkout,env:prod} / avg:requests{region:us,-env:dev,service:checkout}", "window": "1h"},
+		},
+		{
+			tool: "query_logs",
+			args: `{"query":"status:error","services":["web","api"]}`,
+			want: map[string]interface{}{"query": "status:error env:prod", "services": []interface{}{"web", "api"}, "from": "48h0m0s"},
+		},
+	}
+	for _, tt := range tests {
+		var got map[string]interface{}
+		if err := json.Unmarshal(server.applySessionContext(tt.tool, json.RawMessage(tt.args)), &got); err != nil {
+			t.Fatal(err)
+		}
+		gotJSON, _ := json.Marshal(got)
+		wantJSON, _ := json.Marshal(tt.want)
+		if string(gotJSON) != string(wantJSON) {
+			t.Errorf("%s %s: expected %s, got %s", tt.tool, tt.args, wantJSON, gotJSON)
+		}
+	}
+
+	// Tools outside the list are passed through untouched.
+	args := json.RawMessage(`{"service":"payments"}`)
+	if got := server.applySessionContext("record_deployment", args); string(got) != string(args) {
+		t.Fatalf("expected record_deployment arguments unchanged, got %s", got)
+	}
+}
+
+func TestSessionContextAppliedToToolCalls(t *testing.T) {
+	var query string
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req struct {
+			Filter struct {
+				Query string `json:"query"`
+			} `json:"filter"`
+		}
+		_ = json.Unmarshal(body, &req)
+		// Later searches are empty-result diagnostics.
+		if strings.Contains(r.URL.Path, "/logs/") && query == "" {
+			query = req.Filter.Query
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":[]}`))
+	})
+	server.contexts = newContextStore()
+	server.session = "stdio"
+
+	if _, mcpErr := server.callTool(ToolCallParams{Name: "set_context", Arguments: json.RawMessage(`{"service":"checkout","env":"prod"}`)}); mcpErr != nil {
+		t.Fatal(mcpErr.Message)
+	}
+	if _, mcpErr := server.callTool(ToolCallParams{Name: "query_logs", Arguments: json.RawMessage(`{"query":"status:error"}`)}); mcpErr != nil {
+		t.Fatal(mcpErr.Message)
+	}
+	if query != "status:error service:checkout env:prod" {
+		t.Fatalf("expected pinned scope in the log query, got %q", query)
+	}
+
+	text, mcpErr := server.callTool(ToolCallParams{Name: "get_context"})
+	if mcpErr != nil {
+		t.Fatal(mcpErr.Message)
+	}
+	if !strings.Contains(text, `"service": "checkout"`) {
+		t.Fatalf("expected get_context to report the service, got %s", text)
+	}
+}
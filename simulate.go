@@ -0,0 +1,381 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultSimulationDays   = 7
+	maxSimulationDays       = 30
+	defaultSimulationWindow = 5 * time.Minute
+	// maxSimulatedGroups bounds the groups listed; the totals cover all.
+	maxSimulatedGroups = 20
+	// maxSimulatedEpisodes bounds the alerts listed per group.
+	maxSimulatedEpisodes = 10
+)
+
+// monitorQueryPattern matches a metric monitor query such as
+// "avg(last_5m):avg:system.cpu.user{env:prod} by {host} > 90".
+var monitorQueryPattern = regexp.MustCompile(`^\s*(avg|min|max|sum)\(last_(\d+[mhdw])\):(.+?)\s*(>=|<=|>|<)\s*(-?[0-9.]+(?:[eE][-+]?[0-9]+)?)\s*$`)
+
+var simulationComparators = []string{">", ">=", "<", "<="}
+
+type SimulateMonitorParams struct {
+	Query       string   `json:"query"`
+	Threshold   *float64 `json:"threshold,omitempty"`
+	Comparator  string   `json:"comparator,omitempty"`
+	Aggregation string   `json:"aggregation,omitempty"`
+	Window      string   `json:"window,omitempty"`
+	Days        int      `json:"days,omitempty"`
+}
+
+// AlertEpisode is one stretch of time the monitor would have alerted.
+// Ongoing is set when it was still alerting at the end of the data.
+type AlertEpisode struct {
+	Start    string  `json:"start"`
+	End      string  `json:"end"`
+	Duration string  `json:"duration"`
+	Peak     float64 `json:"peak"`
+	Ongoing  bool    `json:"ongoing,omitempty"`
+}
+
+type GroupSimulation struct {
+	Scope        string         `json:"scope"`
+	Alerts       int            `json:"alerts"`
+	AlertingTime string         `json:"alerting_time"`
+	Longest      string         `json:"longest,omitempty"`
+	Episodes     []AlertEpisode `json:"episodes,omitempty"`
+
+	alerting time.Duration
+}
+
+// SimulatedValues summarizes the evaluated values across every group, to
+// pick a threshold that alerts as often as wanted.
+type SimulatedValues struct {
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+	P99 float64 `json:"p99"`
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+type SimulateMonitorResult struct {
+	MonitorQuery    string            `json:"monitor_query"`
+	From            string            `json:"from"`
+	To              string            `json:"to"`
+	Resolution      string            `json:"resolution,omitempty"`
+	Alerts          int               `json:"alerts"`
+	AlertingTime    string            `json:"alerting_time"`
+	AlertingPercent float64           `json:"alerting_percent"`
+	GroupsEvaluated int               `json:"groups_evaluated"`
+	GroupsAlerting  int               `json:"groups_alerting"`
+	Values          *SimulatedValues  `json:"values,omitempty"`
+	Groups          []GroupSimulation `json:"groups"`
+	Notes           []string          `json:"notes,omitempty"`
+}
+
+// monitorCondition is a parsed metric monitor: the metric query, how its
+// values are reduced over the evaluation window, and the threshold test.
+type monitorCondition struct {
+	metric      string
+	aggregation string
+	window      time.Duration
+	comparator  string
+	threshold   float64
+}
+
+func (c monitorCondition) String() string {
+	return fmt.Sprintf("%s(last_%s):%s %s %s", c.aggregation, formatWindow(c.window), c.metric, c.comparator, strconv.FormatFloat(c.threshold, 'g', -1, 64))
+}
+
+func (c monitorCondition) breached(v float64) bool {
+	switch c.comparator {
+	case ">":
+		return v > c.threshold
+	case ">=":
+		return v >= c.threshold
+	case "<":
+		return v < c.threshold
+	}
+	return v <= c.threshold
+}
+
+// parseMonitorCondition reads a full monitor query, or a bare metric query
+// with the threshold and window given separately. Explicit parameters
+// override the query's own.
+func parseMonitorCondition(params SimulateMonitorParams) (*monitorCondition, error) {
+	query := strings.TrimSpace(params.Query)
+	if query == "" {
+		return nil, fmt.Errorf("query parameter is required")
+	}
+	cond := &monitorCondition{metric: query, aggregation: "avg", window: defaultSimulationWindow, comparator: ">"}
+	hasThreshold := false
+	if m := monitorQueryPattern.FindStringSubmatch(query); m != nil {
+		window, err := parseDurationParam(m[2], defaultSimulationWindow)
+		if err != nil {
+			return nil, err
+		}
+		threshold, err := strconv.ParseFloat(m[5], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid threshold in query: %s", m[5])
+		}
+		cond.aggregation, cond.window, cond.metric, cond.comparator, cond.threshold = m[1], window, strings.TrimSpace(m[3]), m[4], threshold
+		hasThreshold = true
+	} else if strings.Contains(query, "(last_") {
+		return nil, fmt.Errorf("unsupported monitor query: %s (use e.g. 'avg(last_5m):avg:system.cpu.user{*} > 90')", query)
+	}
+
+	if params.Threshold != nil {
+		cond.threshold = *params.Threshold
+		hasThreshold = true
+	}
+	if !hasThreshold {
+		return nil, fmt.Errorf("threshold parameter is required when the query has no threshold")
+	}
+	if params.Comparator != "" {
+		if !slices.Contains(simulationComparators, params.Comparator) {
+			return nil, fmt.Errorf("invalid comparator: %s (use %s)", params.Comparator, strings.Join(simulationComparators, ", "))
+		}
+		cond.comparator = params.Comparator
+	}
+	if params.Aggregation != "" {
+		switch agg := strings.ToLower(params.Aggregation); agg {
+		case "avg", "min", "max", "sum":
+			cond.aggregation = agg
+		default:
+			return nil, fmt.Errorf("invalid aggregation: %s (use avg, min, max or sum)", params.Aggregation)
+		}
+	}
+	if params.Window != "" {
+		window, err := parseDurationParam(params.Window, defaultSimulationWindow)
+		if err != nil {
+			return nil, err
+		}
+		cond.window = window
+	}
+	return cond, nil
+}
+
+// SimulateMonitor replays a proposed metric monitor over the past days of
+// data and reports how often, and for how long, it would have alerted.
+func (s *MCPServer) SimulateMonitor(params SimulateMonitorParams) (*SimulateMonitorResult, error) {
+	cond, err := parseMonitorCondition(params)
+	if err != nil {
+		return nil, err
+	}
+	days := params.Days
+	if days <= 0 {
+		days = defaultSimulationDays
+	}
+	days = clampArgument(s, "days", days, maxSimulationDays)
+
+	// A day per query keeps the points close to the resolution a monitor
+	// sees; a longer range comes back rolled up more coarsely.
+	to := time.Now().Truncate(time.Minute)
+	from := to.AddDate(0, 0, -days)
+	series := make(map[string]map[int64]float64)
+	for day := 0; day < days; day++ {
+		s.reportProgress(day, days, fmt.Sprintf("fetching day %d of %d", day+1, days), "")
+		start := from.AddDate(0, 0, day)
+		resp, err := s.queryMetrics(start, start.AddDate(0, 0, 1), cond.metric)
+		if err != nil {
+			return nil, err
+		}
+		for i := range resp.Series {
+			scope := resp.Series[i].GetScope()
+			if series[scope] == nil {
+				series[scope] = make(map[int64]float64)
+			}
+			for _, p := range resp.Series[i].Pointlist {
+				if len(p) < 2 || p[0] == nil || p[1] == nil {
+					continue
+				}
+				series[scope][int64(*p[0])] = *p[1]
+			}
+		}
+	}
+
+	result := &SimulateMonitorResult{
+		MonitorQuery: cond.String(),
+		From:         from.Format(time.RFC3339),
+		To:           to.Format(time.RFC3339),
+		Groups:       make([]GroupSimulation, 0),
+	}
+	var evaluated []float64
+	var intervals []int64
+	var alerting time.Duration
+	for scope, points := range series {
+		times := make([]int64, 0, len(points))
+		for t := range points {
+			times = append(times, t)
+		}
+		sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+		for i := 1; i < len(times); i++ {
+			intervals = append(intervals, times[i]-times[i-1])
+		}
+		group, values := simulateGroup(cond, scope, times, points)
+		if len(values) == 0 {
+			continue
+		}
+		result.GroupsEvaluated++
+		evaluated = append(evaluated, values...)
+		if group.Alerts > 0 {
+			result.GroupsAlerting++
+			result.Alerts += group.Alerts
+			alerting += group.alerting
+			result.Groups = append(result.Groups, group)
+		}
+	}
+	result.AlertingTime = alerting.String()
+	if result.GroupsEvaluated > 0 {
+		total := to.Sub(from) * time.Duration(result.GroupsEvaluated)
+		result.AlertingPercent = math.Round(float64(alerting)/float64(total)*1000) / 10
+	}
+	if len(evaluated) > 0 {
+		sort.Float64s(evaluated)
+		result.Values = &SimulatedValues{
+			P50: percentileOf(evaluated, 50),
+			P90: percentileOf(evaluated, 90),
+			P99: percentileOf(evaluated, 99),
+			Min: evaluated[0],
+			Max: evaluated[len(evaluated)-1],
+		}
+	}
+
+	// Most alerts first, then the most time alerting.
+	sort.SliceStable(result.Groups, func(i, j int) bool {
+		if result.Groups[i].Alerts != result.Groups[j].Alerts {
+			return result.Groups[i].Alerts > result.Groups[j].Alerts
+		}
+		if result.Groups[i].alerting != result.Groups[j].alerting {
+			return result.Groups[i].alerting > result.Groups[j].alerting
+		}
+		return result.Groups[i].Scope < result.Groups[j].Scope
+	})
+	if len(result.Groups) > maxSimulatedGroups {
+		result.Notes = append(result.Notes, fmt.Sprintf("%d groups would have alerted; only the %d noisiest are listed.", len(result.Groups), maxSimulatedGroups))
+		result.Groups = result.Groups[:maxSimulatedGroups]
+	}
+
+	switch {
+	case result.GroupsEvaluated == 0:
+		result.Notes = append(result.Notes, "No data for this query in the period.")
+	case len(intervals) > 0:
+		sort.Slice(intervals, func(i, j int) bool { return intervals[i] < intervals[j] })
+		resolution := time.Duration(intervals[len(intervals)/2]) * time.Millisecond
+		result.Resolution = resolution.String()
+		if resolution > cond.window {
+			result.Notes = append(result.Notes, fmt.Sprintf("The data's %s resolution is coarser than the %s window, so each evaluation sees one rolled-up point and short spikes may be missed.", resolution, cond.window))
+		}
+	}
+	return result, nil
+}
+
+// simulateGroup evaluates the condition at each point of one group's
+// series over the trailing window, and folds consecutive breaches into
+// alert episodes. It returns the group and every evaluated value.
+func simulateGroup(cond *monitorCondition, scope string, times []int64, points map[int64]float64) (GroupSimulation, []float64) {
+	group := GroupSimulation{Scope: scope}
+	values := make([]float64, 0, len(times))
+	var episode *AlertEpisode
+	var episodeStart time.Time
+	var longest time.Duration
+	closeEpisode := func(end time.Time, ongoing bool) {
+		d := end.Sub(episodeStart)
+		group.alerting += d
+		longest = max(longest, d)
+		episode.End, episode.Duration, episode.Ongoing = end.UTC().Format(time.RFC3339), d.String(), ongoing
+		if len(group.Episodes) < maxSimulatedEpisodes {
+			group.Episodes = append(group.Episodes, *episode)
+		}
+		episode = nil
+	}
+
+	first := 0
+	for i, t := range times {
+		// The window is (t-window, t].
+		for times[first] <= t-cond.window.Milliseconds() {
+			first++
+		}
+		v := aggregateWindow(cond.aggregation, times[first:i+1], points)
+		values = append(values, v)
+		at := time.UnixMilli(t)
+		if cond.breached(v) {
+			if episode == nil {
+				group.Alerts++
+				episode = &AlertEpisode{Start: at.UTC().Format(time.RFC3339), Peak: v}
+				episodeStart = at
+			} else if worse(cond.comparator, v, episode.Peak) {
+				episode.Peak = v
+			}
+			continue
+		}
+		if episode != nil {
+			closeEpisode(at, false)
+		}
+	}
+	if episode != nil {
+		closeEpisode(time.UnixMilli(times[len(times)-1]), true)
+	}
+	group.AlertingTime = group.alerting.String()
+	if group.Alerts > 0 {
+		group.Longest = longest.String()
+	}
+	return group, values
+}
+
+func aggregateWindow(aggregation string, times []int64, points map[int64]float64) float64 {
+	result := points[times[0]]
+	sum := 0.0
+	for _, t := range times {
+		v := points[t]
+		sum += v
+		switch aggregation {
+		case "min":
+			result = math.Min(result, v)
+		case "max":
+			result = math.Max(result, v)
+		}
+	}
+	switch aggregation {
+	case "avg":
+		return sum / float64(len(times))
+	case "sum":
+		return sum
+	}
+	return result
+}
+
+// worse reports whether v is further past the threshold than peak.
+func worse(comparator string, v, peak float64) bool {
+	if strings.HasPrefix(comparator, "<") {
+		return v < peak
+	}
+	return v > peak
+}
+
+// percentileOf returns the pth percentile of sorted values by the
+// nearest-rank method.
+func percentileOf(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
+
+// formatWindow renders a window the way monitor queries write it, such
+// as "5m" or "1h".
+func formatWindow(d time.Duration) string {
+	switch {
+	case d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	}
+	return fmt.Sprintf("%dm", d/time.Minute)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseMonitorCondition(t *testing.T) {
+	cond, err := parseMonitorCondition(SimulateMonitorParams{Query: "max(last_15m):avg:system.cpu.user{env:prod} by {host} >= 90"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cond.aggregation != "max" || cond.window != 15*time.Minute || cond.metric != "avg:system.cpu.user{env:prod} by {host}" || cond.comparator != ">=" || cond.threshold != 90 {
+		t.Fatalf("unexpected condition: %+v", cond)
+	}
+
+	threshold := 0.5
+	cond, err = parseMonitorCondition(SimulateMonitorParams{Query: "avg:checkout.success_rate{*}", Threshold: &threshold, Comparator: "<", Window: "1h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cond.String() != "avg(last_1h):avg:checkout.success_rate{*} < 0.5" {
+		t.Fatalf("unexpected condition: %s", cond)
+	}
+
+	if _, err := parseMonitorCondition(SimulateMonitorParams{Query: "avg:system.cpu.user{*}"}); err == nil {
+		t.Fatal("expected a metric query without a threshold to be rejected")
+	}
+	if _, err := parseMonitorCondition(SimulateMonitorParams{Query: "change(avg(last_5m),last_5m):avg:system.cpu.user{*} > 1"}); err == nil {
+		t.Fatal("expected an unsupported monitor query to be rejected")
+	}
+}
+
+func TestSimulateMonitor(t *testing.T) {
+	calls := 0
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		from, _ := strconv.ParseInt(r.URL.Query().Get("from"), 10, 64)
+		to, _ := strconv.ParseInt(r.URL.Query().Get("to"), 10, 64)
+		calls++
+		// web-1 spikes for 30 minutes, two hours into the first day.
+		spikeStart, spikeEnd := int64(-1), int64(-1)
+		if calls == 1 {
+			spikeStart, spikeEnd = from+2*3600, from+2*3600+1800
+		}
+		var web1, web2 []string
+		for ts := from; ts < to; ts += 300 {
+			value := 50
+			if ts >= spikeStart && ts < spikeEnd {
+				value = 95
+			}
+			web1 = append(web1, fmt.Sprintf("[%d,%d]", ts*1000, value))
+			web2 = append(web2, fmt.Sprintf("[%d,40]", ts*1000))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"status":"ok","series":[{"scope":"host:web-1","pointlist":[%s]},{"scope":"host:web-2","pointlist":[%s]}]}`,
+			strings.Join(web1, ","), strings.Join(web2, ","))
+	})
+
+	result, err := server.SimulateMonitor(SimulateMonitorParams{Query: "avg(last_5m):avg:system.cpu.user{*} by {host} > 90", Days: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Fatalf("expected one query per day, got %d", calls)
+	}
+	if result.Alerts != 1 || result.GroupsEvaluated != 2 || result.GroupsAlerting != 1 || result.AlertingTime != "30m0s" {
+		t.Fatalf("expected one 30 minute alert, got %+v", result)
+	}
+	if result.Resolution != "5m0s" || len(result.Notes) != 0 {
+		t.Fatalf("expected a 5m resolution and no notes, got %q %v", result.Resolution, result.Notes)
+	}
+	group := result.Groups[0]
+	if group.Scope != "host:web-1" || len(group.Episodes) != 1 || group.Episodes[0].Peak != 95 || group.Episodes[0].Ongoing {
+		t.Fatalf("unexpected group: %+v", group)
+	}
+	if result.Values == nil || result.Values.Max != 95 || result.Values.Min != 40 {
+		t.Fatalf("unexpected value summary: %+v", result.Values)
+	}
+
+	// Over a 1h average the 30 minute spike never reaches 90.
+	calls = 0
+	result, err = server.SimulateMonitor(SimulateMonitorParams{Query: "avg(last_5m):avg:system.cpu.user{*} by {host} > 90", Window: "1h", Days: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Alerts != 0 || len(result.Groups) != 0 {
+		t.Fatalf("expected no alerts over a 1h window, got %+v", result)
+	}
+}
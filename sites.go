@@ -0,0 +1,136 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// govSite is the US1-FED site for US government customers. It serves the
+// API from api.ddog-gov.com and lacks some products the commercial sites
+// have.
+const govSite = "ddog-gov.com"
+
+// siteAliases maps the region names people use to the site the Datadog
+// client expects.
+var siteAliases = map[string]string{
+	"us1":     "datadoghq.com",
+	"us3":     "us3.datadoghq.com",
+	"us5":     "us5.datadoghq.com",
+	"eu":      "datadoghq.eu",
+	"eu1":     "datadoghq.eu",
+	"ap1":     "ap1.datadoghq.com",
+	"ap2":     "ap2.datadoghq.com",
+	"us1-fed": govSite,
+	"gov":     govSite,
+	"fips":    govSite,
+}
+
+// normalizeSite turns a region name or a URL copied from the browser,
+// such as https://app.ddog-gov.com/, into a site the Datadog client
+// accepts. Anything else is returned lowercased and otherwise unchanged.
+func normalizeSite(value string) string {
+	site := strings.ToLower(strings.TrimSpace(value))
+	site = strings.TrimPrefix(site, "https://")
+	site = strings.TrimPrefix(site, "http://")
+	site, _, _ = strings.Cut(site, "/")
+	if alias, ok := siteAliases[site]; ok {
+		return alias
+	}
+	for _, prefix := range []string{"app.", "api."} {
+		if rest := strings.TrimPrefix(site, prefix); rest != site && strings.Contains(rest, ".") {
+			return rest
+		}
+	}
+	return site
+}
+
+// productTools lists, for each Datadog product a site may lack, the tools
+// that depend on it.
+var productTools = map[string][]string{
+	"incidents":        {"list_incidents", "get_incident", "get_incident_timeline", "generate_postmortem"},
+	"service_catalog":  {"list_services"},
+	"reference_tables": {"list_reference_tables", "lookup_reference_table"},
+	"synthetics":       {"list_synthetic_tests", "get_synthetic_results", "trigger_synthetic_test", "get_synthetic_batch"},
+	"usage":            {"detect_usage_anomalies", "get_usage", "get_estimated_cost"},
+}
+
+// siteDisabledProducts are the products each site doesn't offer.
+var siteDisabledProducts = map[string][]string{
+	govSite: {"incidents", "service_catalog", "reference_tables"},
+}
+
+// parseDisabledProducts reads DD_MCP_DISABLED_PRODUCTS, which replaces
+// the site's own list: a comma-separated list of products, or "none".
+// Without it the site's defaults apply.
+func parseDisabledProducts(value, site string) ([]string, error) {
+	if strings.TrimSpace(value) == "" {
+		return siteDisabledProducts[site], nil
+	}
+	products := make([]string, 0)
+	for _, item := range splitList(value) {
+		item = strings.ToLower(item)
+		if item == "none" {
+			continue
+		}
+		if _, ok := productTools[item]; !ok {
+			return nil, fmt.Errorf("unknown product %q (use %s or none)", item, strings.Join(productNames(), ", "))
+		}
+		if !slices.Contains(products, item) {
+			products = append(products, item)
+		}
+	}
+	return products, nil
+}
+
+func productNames() []string {
+	names := make([]string, 0, len(productTools))
+	for name := range productTools {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// disabledProduct returns the disabled product a tool depends on, if any.
+func (s *MCPServer) disabledProduct(tool string) string {
+	for _, product := range s.disabledProducts {
+		if slices.Contains(productTools[product], tool) {
+			return product
+		}
+	}
+	return ""
+}
+
+// requireProduct refuses a tool whose product is disabled on this site,
+// saying so rather than calling it unknown.
+func (s *MCPServer) requireProduct(name string) *MCPError {
+	if product := s.disabledProduct(name); product != "" {
+		return &MCPError{Code: -32000, Message: fmt.Sprintf("%s isn't available: %s", name, s.productReason(product))}
+	}
+	return nil
+}
+
+func (s *MCPServer) productReason(product string) string {
+	return fmt.Sprintf("the %s product is disabled on the %s site. Set DD_MCP_DISABLED_PRODUCTS to change which products are disabled.", product, cmp.Or(s.site, "datadoghq.com"))
+}
+
+// siteInstructions tells clients at initialize which products are off.
+func (s *MCPServer) siteInstructions() string {
+	if len(s.disabledProducts) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Site %s: these products are disabled and their tools are hidden: %s.", cmp.Or(s.site, "datadoghq.com"), strings.Join(s.disabledProducts, ", "))
+}
+
+// explainSiteError adds a hint to a Not Found from the gov site, which
+// is how Datadog answers for products the site doesn't offer.
+func (s *MCPServer) explainSiteError(toolErr *MCPError) {
+	if toolErr == nil || toolErr.Code != -32000 || s.site != govSite {
+		return
+	}
+	if strings.Contains(toolErr.Message, "404 Not Found") {
+		toolErr.Message += fmt.Sprintf(" The %s site doesn't offer every Datadog product; if this one is missing there, add it to DD_MCP_DISABLED_PRODUCTS to hide its tools.", govSite)
+	}
+}
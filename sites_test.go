@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeSite(t *testing.T) {
+	for input, want := range map[string]string{
+		"":                          "",
+		"datadoghq.com":             "datadoghq.com",
+		"US1-FED":                   "ddog-gov.com",
+		"gov":                       "ddog-gov.com",
+		"https://app.ddog-gov.com/": "ddog-gov.com",
+		"api.datadoghq.eu":          "datadoghq.eu",
+		"us3.datadoghq.com":         "us3.datadoghq.com",
+		"eu":                        "datadoghq.eu",
+	} {
+		if got := normalizeSite(input); got != want {
+			t.Errorf("normalizeSite(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestParseDisabledProducts(t *testing.T) {
+	products, err := parseDisabledProducts("", govSite)
+	if err != nil || !slices.Contains(products, "incidents") {
+		t.Fatalf("expected the gov site defaults, got %v, %v", products, err)
+	}
+	if products, _ := parseDisabledProducts("", "datadoghq.com"); len(products) != 0 {
+		t.Errorf("expected nothing disabled on the commercial site, got %v", products)
+	}
+	if products, _ := parseDisabledProducts("none", govSite); len(products) != 0 {
+		t.Errorf("expected none to enable everything, got %v", products)
+	}
+	if products, _ := parseDisabledProducts("Synthetics, synthetics", "datadoghq.com"); !slices.Equal(products, []string{"synthetics"}) {
+		t.Errorf("unexpected products: %v", products)
+	}
+	if _, err := parseDisabledProducts("apm", govSite); err == nil {
+		t.Error("expected an unknown product to be rejected")
+	}
+}
+
+func TestDisabledProductsHideTools(t *testing.T) {
+	server := &MCPServer{site: govSite, disabledProducts: siteDisabledProducts[govSite]}
+	var names []string
+	for _, tool := range server.ListTools() {
+		names = append(names, tool.Name)
+	}
+	for _, hidden := range []string{"list_incidents", "get_incident_timeline", "list_services", "lookup_reference_table"} {
+		if slices.Contains(names, hidden) {
+			t.Errorf("expected %s to be hidden", hidden)
+		}
+	}
+	if !slices.Contains(names, "query_logs") {
+		t.Errorf("expected other tools to stay, got %v", names)
+	}
+
+	params, _ := json.Marshal(ToolCallParams{Name: "list_incidents"})
+	resp := server.HandleRequest(MCPRequest{Jsonrpc: "2.0", ID: 1, Method: "tools/call", Params: params})
+	if resp.Error == nil || !strings.Contains(resp.Error.Message, "incidents product is disabled on the ddog-gov.com site") {
+		t.Fatalf("expected a hidden tool to explain why, got %+v", resp.Error)
+	}
+
+	resp = server.HandleRequest(MCPRequest{Jsonrpc: "2.0", ID: 2, Method: "initialize"})
+	var init InitializeResult
+	if err := json.Unmarshal(resp.Result, &init); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(init.Instructions, "ddog-gov.com") || !strings.Contains(init.Instructions, "service_catalog") {
+		t.Errorf("expected initialize to list the disabled products, got %q", init.Instructions)
+	}
+
+	toolErr := &MCPError{Code: -32000, Message: "failed to list SLOs: 404 Not Found"}
+	server.explainSiteError(toolErr)
+	if !strings.Contains(toolErr.Message, "DD_MCP_DISABLED_PRODUCTS") {
+		t.Errorf("expected a gov Not Found to get a hint, got %q", toolErr.Message)
+	}
+	toolErr = &MCPError{Code: -32000, Message: "failed to list SLOs: 404 Not Found"}
+	(&MCPServer{}).explainSiteError(toolErr)
+	if strings.Contains(toolErr.Message, "DD_MCP_DISABLED_PRODUCTS") {
+		t.Errorf("expected no hint on the commercial site, got %q", toolErr.Message)
+	}
+}
@@ -0,0 +1,471 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+)
+
+const (
+	sloSearchPageSize = 100
+	maxSLOSearchPages = 10
+	defaultSLOPerPage = 30
+	maxSLOPerPage     = 100
+)
+
+var sloStates = []string{"breached", "warning", "ok", "no_data"}
+
+// SLOSummary is an SLO with its status over its primary timeframe.
+type SLOSummary struct {
+	ID                   string   `json:"id"`
+	Name                 string   `json:"name"`
+	Type                 string   `json:"type,omitempty"`
+	Tags                 []string `json:"tags,omitempty"`
+	Timeframe            string   `json:"timeframe,omitempty"`
+	State                string   `json:"state,omitempty"`
+	Status               *float64 `json:"status,omitempty"`
+	Target               *float64 `json:"target,omitempty"`
+	ErrorBudgetRemaining *float64 `json:"error_budget_remaining,omitempty"`
+	// MonitorIDs are the monitors a monitor-based SLO is computed from.
+	MonitorIDs []int64 `json:"monitor_ids,omitempty"`
+	URL        string  `json:"url"`
+}
+
+type ListSLOsParams struct {
+	Query   string   `json:"query,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+	States  []string `json:"states,omitempty"`
+	Page    int64    `json:"page,omitempty"`
+	PerPage int64    `json:"per_page,omitempty"`
+}
+
+type ListSLOsResult struct {
+	SLOs      []SLOSummary     `json:"slos"`
+	Total     int64            `json:"total"`
+	Page      int64            `json:"page"`
+	PageCount int64            `json:"page_count"`
+	PerPage   int64            `json:"per_page"`
+	ByState   map[string]int64 `json:"by_state,omitempty"`
+	Notes     []string         `json:"notes,omitempty"`
+}
+
+type GetSLOStatusParams struct {
+	SLOID string `json:"slo_id"`
+}
+
+// SLOTimeframeStatus is an SLO's SLI and error budget over one of its
+// timeframes, measured up to now.
+type SLOTimeframeStatus struct {
+	Timeframe            string   `json:"timeframe"`
+	Target               float64  `json:"target"`
+	Warning              *float64 `json:"warning,omitempty"`
+	SLI                  *float64 `json:"sli,omitempty"`
+	ErrorBudgetRemaining *float64 `json:"error_budget_remaining,omitempty"`
+	State                string   `json:"state"`
+	Error                string   `json:"error,omitempty"`
+}
+
+type SLOStatus struct {
+	ID          string               `json:"id"`
+	Name        string               `json:"name"`
+	Type        string               `json:"type,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	MonitorIDs  []int64              `json:"monitor_ids,omitempty"`
+	Timeframes  []SLOTimeframeStatus `json:"timeframes"`
+	URL         string               `json:"url"`
+}
+
+// searchSLOs returns every SLO matching an SLO search query with its
+// status, up to maxSLOSearchPages pages. Truncated is set when more were
+// left unread.
+func (s *MCPServer) searchSLOs(query string) (slos []SLOSummary, truncated bool, err error) {
+	api := datadogV1.NewServiceLevelObjectivesApi(s.ddClient)
+	for page := int64(0); page < maxSLOSearchPages; page++ {
+		opts := datadogV1.NewSearchSLOOptionalParameters().WithPageSize(sloSearchPageSize).WithPageNumber(page)
+		if query != "" {
+			opts = opts.WithQuery(query)
+		}
+		resp, _, err := api.SearchSLO(s.ctx, *opts)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to search SLOs: %w", err)
+		}
+		var found []datadogV1.SearchServiceLevelObjective
+		if resp.Data != nil && resp.Data.Attributes != nil {
+			found = resp.Data.Attributes.Slos
+		}
+		for _, slo := range found {
+			if slo.Data == nil || slo.Data.Attributes == nil {
+				continue
+			}
+			id, attrs := slo.Data.GetId(), slo.Data.Attributes
+			entry := SLOSummary{
+				ID:         id,
+				Name:       attrs.GetName(),
+				Type:       string(attrs.GetSloType()),
+				Tags:       attrs.AllTags,
+				State:      "no_data",
+				MonitorIDs: attrs.GetMonitorIds(),
+				URL:        s.appURL("/slo?slo_id=" + id),
+			}
+			// The first status is the SLO's primary timeframe.
+			if len(attrs.OverallStatus) > 0 {
+				status := attrs.OverallStatus[0]
+				if state := status.GetState(); state != "" {
+					entry.State = string(state)
+				}
+				entry.Timeframe = string(status.GetTimeframe())
+				entry.Status = status.Status.Get()
+				entry.Target = status.Target
+				entry.ErrorBudgetRemaining = status.ErrorBudgetRemaining.Get()
+			}
+			slos = append(slos, entry)
+		}
+		if len(found) < sloSearchPageSize {
+			return slos, false, nil
+		}
+	}
+	return slos, true, nil
+}
+
+// sortSLOsByUrgency orders breached SLOs first, then those with the least
+// error budget left, then by name.
+func sortSLOsByUrgency(slos []SLOSummary) {
+	budget := func(slo SLOSummary) float64 {
+		if slo.ErrorBudgetRemaining == nil {
+			return math.Inf(1)
+		}
+		return *slo.ErrorBudgetRemaining
+	}
+	sort.SliceStable(slos, func(i, j int) bool {
+		if slos[i].State != slos[j].State {
+			return sloStateOrder(slos[i].State) < sloStateOrder(slos[j].State)
+		}
+		if budget(slos[i]) != budget(slos[j]) {
+			return budget(slos[i]) < budget(slos[j])
+		}
+		return slos[i].Name < slos[j].Name
+	})
+}
+
+func sloStateOrder(state string) int {
+	switch state {
+	case "breached":
+		return 0
+	case "warning":
+		return 1
+	case "ok":
+		return 2
+	}
+	return 3
+}
+
+// ListSLOs searches SLOs and filters them by tag and state, most urgent
+// first, a page at a time.
+func (s *MCPServer) ListSLOs(params ListSLOsParams) (*ListSLOsResult, error) {
+	states := make(map[string]bool)
+	for _, state := range params.States {
+		state = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(state), " ", "_"))
+		if !slices.Contains(sloStates, state) {
+			return nil, fmt.Errorf("invalid state: %q (use %s)", state, strings.Join(sloStates, ", "))
+		}
+		states[state] = true
+	}
+	perPage := params.PerPage
+	if perPage <= 0 {
+		perPage = defaultSLOPerPage
+	}
+	perPage = clampArgument(s, "per_page", perPage, maxSLOPerPage)
+	page := max(params.Page, 0)
+
+	found, truncated, err := s.searchSLOs(strings.TrimSpace(params.Query))
+	if err != nil {
+		return nil, err
+	}
+	result := &ListSLOsResult{SLOs: make([]SLOSummary, 0), Page: page, PerPage: perPage, ByState: make(map[string]int64)}
+	var matched []SLOSummary
+	for _, slo := range found {
+		if !hasAllTags(slo.Tags, params.Tags) || (len(states) > 0 && !states[slo.State]) {
+			continue
+		}
+		matched = append(matched, slo)
+		result.ByState[slo.State]++
+	}
+	sortSLOsByUrgency(matched)
+
+	result.Total = int64(len(matched))
+	result.PageCount = (result.Total + perPage - 1) / perPage
+	if start := page * perPage; start < result.Total {
+		result.SLOs = append(result.SLOs, matched[start:min(start+perPage, result.Total)]...)
+	}
+	if truncated {
+		result.Notes = append(result.Notes, fmt.Sprintf("Only the first %d SLOs matching the query were read; narrow the query to see the rest.", maxSLOSearchPages*sloSearchPageSize))
+	}
+	if page+1 < result.PageCount {
+		result.Notes = append(result.Notes, fmt.Sprintf("More SLOs match; request page %d for the next ones.", page+1))
+	}
+	return result, nil
+}
+
+// hasAllTags reports whether tags include every wanted tag, ignoring
+// case.
+func hasAllTags(tags, wanted []string) bool {
+	for _, want := range wanted {
+		found := false
+		for _, tag := range tags {
+			if strings.EqualFold(tag, strings.TrimSpace(want)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// GetSLOStatus returns an SLO's current SLI and error budget for each of
+// its timeframes.
+func (s *MCPServer) GetSLOStatus(params GetSLOStatusParams) (*SLOStatus, error) {
+	id := strings.TrimSpace(params.SLOID)
+	if id == "" {
+		return nil, fmt.Errorf("slo_id parameter is required")
+	}
+	api := datadogV1.NewServiceLevelObjectivesApi(s.ddClient)
+	resp, _, err := api.GetSLO(s.ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SLO %s: %w", id, err)
+	}
+	if resp.Data == nil {
+		return nil, fmt.Errorf("SLO %s not found", id)
+	}
+	slo := resp.Data
+	status := &SLOStatus{
+		ID:          id,
+		Name:        slo.GetName(),
+		Type:        string(slo.GetType()),
+		Description: slo.GetDescription(),
+		Tags:        slo.Tags,
+		MonitorIDs:  slo.MonitorIds,
+		Timeframes:  make([]SLOTimeframeStatus, 0, len(slo.Thresholds)),
+		URL:         s.appURL("/slo?slo_id=" + id),
+	}
+
+	now := time.Now()
+	for _, threshold := range slo.Thresholds {
+		timeframe := string(threshold.Timeframe)
+		entry := SLOTimeframeStatus{Timeframe: timeframe, Target: threshold.Target, Warning: threshold.Warning, State: "no_data"}
+		window, err := parseDurationParam(timeframe, 0)
+		if err != nil {
+			entry.Error = "custom timeframes aren't measured"
+			status.Timeframes = append(status.Timeframes, entry)
+			continue
+		}
+		history, _, err := api.GetSLOHistory(s.ctx, id, now.Add(-window).Unix(), now.Unix())
+		if err != nil {
+			entry.Error = fmt.Sprintf("failed to get SLO history: %v", err)
+			status.Timeframes = append(status.Timeframes, entry)
+			continue
+		}
+		if data := history.Data; data != nil && data.Overall != nil && data.Overall.SliValue.Get() != nil {
+			sli := *data.Overall.SliValue.Get()
+			entry.SLI = &sli
+			if budget, ok := data.Overall.ErrorBudgetRemaining[timeframe]; ok {
+				entry.ErrorBudgetRemaining = &budget
+			} else if threshold.Target < 100 {
+				budget := math.Round((sli-threshold.Target)/(100-threshold.Target)*1000) / 10
+				entry.ErrorBudgetRemaining = &budget
+			}
+			switch {
+			case sli < threshold.Target:
+				entry.State = "breached"
+			case threshold.Warning != nil && sli < *threshold.Warning:
+				entry.State = "warning"
+			default:
+				entry.State = "ok"
+			}
+		}
+		status.Timeframes = append(status.Timeframes, entry)
+	}
+	return status, nil
+}
+
+// maxSLOHistoryPoints bounds the points of a metric SLO's history; finer
+// intervals are merged.
+const maxSLOHistoryPoints = 200
+
+type GetSLOHistoryParams struct {
+	SLOID string `json:"slo_id"`
+	From  string `json:"from,omitempty"`
+	To    string `json:"to,omitempty"`
+}
+
+// SLOHistoryPoint is a metric SLO's good and total events over one
+// interval. BurnRate is how fast the interval spent error budget relative
+// to the target: 1 spends it exactly over the timeframe.
+type SLOHistoryPoint struct {
+	Time     string   `json:"time"`
+	Good     float64  `json:"good"`
+	Total    float64  `json:"total"`
+	SLI      *float64 `json:"sli,omitempty"`
+	BurnRate *float64 `json:"burn_rate,omitempty"`
+}
+
+// SLODowntime is a stretch an SLO counted as down.
+type SLODowntime struct {
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	Duration string `json:"duration"`
+}
+
+type SLOHistoryResult struct {
+	SLOID                string             `json:"slo_id"`
+	Name                 string             `json:"name,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	From                 string             `json:"from"`
+	To                   string             `json:"to"`
+	SLI                  *float64           `json:"sli,omitempty"`
+	Timeframe            string             `json:"timeframe,omitempty"`
+	Target               *float64           `json:"target,omitempty"`
+	ErrorBudgetRemaining map[string]float64 `json:"error_budget_remaining,omitempty"`
+	Interval             string             `json:"interval,omitempty"`
+	Points               []SLOHistoryPoint  `json:"points,omitempty"`
+	Downtime             []SLODowntime      `json:"downtime,omitempty"`
+	URL                  string             `json:"url"`
+	Notes                []string           `json:"notes,omitempty"`
+}
+
+// GetSLOHistory returns an SLO's SLI over a window as a time series: good
+// and total events per interval for a metric SLO, and the periods it was
+// down for a monitor or time-slice SLO.
+func (s *MCPServer) GetSLOHistory(params GetSLOHistoryParams) (*SLOHistoryResult, error) {
+	id := strings.TrimSpace(params.SLOID)
+	if id == "" {
+		return nil, fmt.Errorf("slo_id parameter is required")
+	}
+	to, err := s.timeParam("to", params.To, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	from, err := s.timeParam("from", params.From, to.Add(-7*24*time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("from must be before to")
+	}
+
+	api := datadogV1.NewServiceLevelObjectivesApi(s.ddClient)
+	resp, _, err := api.GetSLOHistory(s.ctx, id, from.Unix(), to.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SLO %s history: %w", id, err)
+	}
+	result := &SLOHistoryResult{
+		SLOID: id,
+		From:  from.UTC().Format(time.RFC3339),
+		To:    to.UTC().Format(time.RFC3339),
+		URL:   s.appURL("/slo?slo_id=" + id),
+	}
+	for _, e := range resp.Errors {
+		result.Notes = append(result.Notes, e.GetError())
+	}
+	data := resp.Data
+	if data == nil {
+		result.Notes = append(result.Notes, "No history was returned for this SLO.")
+		return result, nil
+	}
+	result.Type = string(data.GetType())
+
+	// Burn rates are measured against the same timeframe reportSLO uses.
+	timeframes := make([]string, 0, len(data.Thresholds))
+	for timeframe := range data.Thresholds {
+		timeframes = append(timeframes, timeframe)
+	}
+	sort.Strings(timeframes)
+	if len(timeframes) > 0 {
+		target := data.Thresholds[timeframes[0]].Target
+		result.Timeframe, result.Target = timeframes[0], &target
+	}
+	if overall := data.Overall; overall != nil {
+		result.Name = overall.GetName()
+		result.SLI = overall.SliValue.Get()
+		result.ErrorBudgetRemaining = overall.ErrorBudgetRemaining
+		result.Downtime = sloDowntime(overall.History, to)
+	}
+	if data.Series != nil {
+		result.Points, result.Interval = sloHistoryPoints(data.Series, result.Target)
+	}
+	if len(data.Groups) > 0 {
+		result.Notes = append(result.Notes, fmt.Sprintf("The SLO has %d groups; the history covers them all together.", len(data.Groups)))
+	}
+	if result.SLI == nil && len(result.Points) == 0 {
+		result.Notes = append(result.Notes, "No SLI data in this window.")
+	}
+	return result, nil
+}
+
+// sloHistoryPoints pairs a metric SLO's numerator and denominator into
+// points, merging intervals so at most maxSLOHistoryPoints are returned.
+func sloHistoryPoints(series *datadogV1.SLOHistoryMetrics, target *float64) ([]SLOHistoryPoint, string) {
+	n := min(len(series.Times), len(series.Numerator.Values), len(series.Denominator.Values))
+	if n == 0 {
+		return nil, ""
+	}
+	merge := (n + maxSLOHistoryPoints - 1) / maxSLOHistoryPoints
+	points := make([]SLOHistoryPoint, 0, (n+merge-1)/merge)
+	for i := 0; i < n; i += merge {
+		point := SLOHistoryPoint{Time: time.UnixMilli(int64(series.Times[i])).UTC().Format(time.RFC3339)}
+		for j := i; j < min(i+merge, n); j++ {
+			point.Good += series.Numerator.Values[j]
+			point.Total += series.Denominator.Values[j]
+		}
+		if point.Total > 0 {
+			sli := math.Round(point.Good/point.Total*100*1e4) / 1e4
+			point.SLI = &sli
+			if target != nil && *target < 100 {
+				burn := math.Round((100-sli)/(100-*target)*100) / 100
+				point.BurnRate = &burn
+			}
+		}
+		points = append(points, point)
+	}
+	return points, (time.Duration(series.Interval*int64(merge)) * time.Second).String()
+}
+
+// sloDowntime turns a monitor or time-slice SLO's state history, pairs of
+// a timestamp and a state (0 up, 1 down, 2 no data), into its downtime
+// periods. A period still open at the end of the window ends at to.
+func sloDowntime(history [][]float64, to time.Time) []SLODowntime {
+	var downtime []SLODowntime
+	var start *time.Time
+	closePeriod := func(end time.Time) {
+		downtime = append(downtime, SLODowntime{
+			Start:    start.UTC().Format(time.RFC3339),
+			End:      end.UTC().Format(time.RFC3339),
+			Duration: end.Sub(*start).String(),
+		})
+		start = nil
+	}
+	for _, entry := range history {
+		if len(entry) < 2 {
+			continue
+		}
+		at := time.Unix(int64(entry[0]), 0)
+		switch {
+		case entry[1] == 1 && start == nil:
+			start = &at
+		case entry[1] != 1 && start != nil:
+			closePeriod(at)
+		}
+	}
+	if start != nil {
+		closePeriod(to)
+	}
+	return downtime
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+)
+
+func TestListSLOs(t *testing.T) {
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/slo/search" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"attributes":{"slos":[
+			{"data":{"id":"slo-ok","type":"slo","attributes":{"name":"Search latency","all_tags":["team:web"],"overall_status":[{"state":"ok","timeframe":"30d","status":99.95,"target":99.9,"error_budget_remaining":50}]}}},
+			{"data":{"id":"slo-low","type":"slo","attributes":{"name":"Checkout latency","all_tags":["team:payments"],"overall_status":[{"state":"warning","timeframe":"30d","status":99.91,"target":99.9,"error_budget_remaining":10}]}}},
+			{"data":{"id":"slo-out","type":"slo","attributes":{"name":"Checkout errors","all_tags":["team:payments"],"overall_status":[{"state":"breached","timeframe":"30d","status":99.5,"target":99.9,"error_budget_remaining":-400}]}}},
+			{"data":{"id":"slo-new","type":"slo","attributes":{"name":"Refunds","all_tags":["team:payments"]}}}]}}}`))
+	})
+
+	result, err := server.ListSLOs(ListSLOsParams{Tags: []string{"Team:Payments"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 3 || result.SLOs[0].ID != "slo-out" || result.SLOs[1].ID != "slo-low" || result.SLOs[2].State != "no_data" {
+		t.Fatalf("expected the payments SLOs most urgent first, got %+v", result.SLOs)
+	}
+	if result.ByState["breached"] != 1 || result.SLOs[0].Timeframe != "30d" || *result.SLOs[0].ErrorBudgetRemaining != -400 {
+		t.Fatalf("unexpected summary: %+v", result)
+	}
+
+	result, err = server.ListSLOs(ListSLOsParams{States: []string{"breached", "warning"}, PerPage: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 2 || result.PageCount != 2 || len(result.SLOs) != 1 || len(result.Notes) != 1 {
+		t.Fatalf("expected the first of two burning SLOs, got %+v", result)
+	}
+
+	if _, err := server.ListSLOs(ListSLOsParams{States: []string{"burning"}}); err == nil {
+		t.Fatal("expected an invalid state to be rejected")
+	}
+}
+
+func TestGetSLOStatus(t *testing.T) {
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/slo/slo-1":
+			_, _ = w.Write([]byte(`{"data":{"id":"slo-1","name":"Checkout errors","type":"metric","tags":["team:payments"],
+				"thresholds":[{"timeframe":"7d","target":99.9,"warning":99.95},{"timeframe":"30d","target":99.5},{"timeframe":"custom","target":99}]}}`))
+		case "/api/v1/slo/slo-1/history":
+			// Both windows end now, so the 30d one is told apart by length.
+			sli, budget := `99.92`, `{"7d":20}`
+			from, _ := strconv.ParseInt(r.URL.Query().Get("from_ts"), 10, 64)
+			to, _ := strconv.ParseInt(r.URL.Query().Get("to_ts"), 10, 64)
+			if to-from > 8*86400 {
+				sli, budget = `99.4`, `{}`
+			}
+			_, _ = w.Write([]byte(`{"data":{"overall":{"name":"Checkout errors","sli_value":` + sli + `,"error_budget_remaining":` + budget + `}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	status, err := server.GetSLOStatus(GetSLOStatusParams{SLOID: "slo-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status.Timeframes) != 3 {
+		t.Fatalf("expected three timeframes, got %+v", status.Timeframes)
+	}
+	week, month, custom := status.Timeframes[0], status.Timeframes[1], status.Timeframes[2]
+	if week.State != "warning" || *week.SLI != 99.92 || *week.ErrorBudgetRemaining != 20 {
+		t.Fatalf("unexpected 7d status: %+v", week)
+	}
+	if month.State != "breached" || *month.ErrorBudgetRemaining != -20 {
+		t.Fatalf("expected the 30d budget computed from the SLI, got %+v", month)
+	}
+	if custom.State != "no_data" || custom.Error == "" {
+		t.Fatalf("expected the custom timeframe to be skipped, got %+v", custom)
+	}
+}
+
+func TestGetSLOHistory(t *testing.T) {
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/slo/metric-slo/history":
+			_, _ = w.Write([]byte(`{"data":{"type":"metric","thresholds":{"7d":{"timeframe":"7d","target":99}},
+				"overall":{"name":"Checkout errors","sli_value":99.5,"error_budget_remaining":{"7d":50}},
+				"series":{"interval":3600,"query":"q","res_type":"time_series","resp_version":2,"times":[1768899600000,1768903200000],
+					"numerator":{"count":2,"sum":1090,"values":[995,95]},"denominator":{"count":2,"sum":1100,"values":[1000,100]}}}}`))
+		case "/api/v1/slo/monitor-slo/history":
+			_, _ = w.Write([]byte(`{"data":{"type":"monitor","thresholds":{"30d":{"timeframe":"30d","target":99.9}},
+				"overall":{"name":"API up","sli_value":99.8,"history":[[1768899600,0],[1768903200,1],[1768905000,0],[1768906800,1]]}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	result, err := server.GetSLOHistory(GetSLOHistoryParams{SLOID: "metric-slo", From: "2026-01-20T09:00:00Z", To: "2026-01-20T11:00:00Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *result.SLI != 99.5 || result.Timeframe != "7d" || result.Interval != "1h0m0s" || len(result.Points) != 2 {
+		t.Fatalf("unexpected history: %+v", result)
+	}
+	if p := result.Points[1]; *p.SLI != 95 || *p.BurnRate != 5 || p.Time != "2026-01-20T10:00:00Z" {
+		t.Fatalf("expected the second hour to burn budget 5x, got %+v", p)
+	}
+
+	result, err = server.GetSLOHistory(GetSLOHistoryParams{SLOID: "monitor-slo", From: "2026-01-20T09:00:00Z", To: "2026-01-20T11:00:00Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Downtime) != 2 || result.Downtime[0].Duration != "30m0s" || result.Downtime[1].End != "2026-01-20T11:00:00Z" {
+		t.Fatalf("expected two downtime periods, the last open until the end, got %+v", result.Downtime)
+	}
+}
+
+func TestSLOHistoryPointsMerge(t *testing.T) {
+	series := &datadogV1.SLOHistoryMetrics{Interval: 60}
+	for i := 0; i < 2*maxSLOHistoryPoints+1; i++ {
+		series.Times = append(series.Times, float64(i*60000))
+		series.Numerator.Values = append(series.Numerator.Values, 1)
+		series.Denominator.Values = append(series.Denominator.Values, 1)
+	}
+	points, interval := sloHistoryPoints(series, nil)
+	// 401 one-minute points merge three at a time.
+	if len(points) != 134 || interval != "3m0s" || points[0].Total != 3 {
+		t.Fatalf("expected 134 merged points, got %d at %s (%+v)", len(points), interval, points[0])
+	}
+}
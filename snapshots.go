@@ -0,0 +1,364 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+)
+
+const (
+	// maxSnapshotVersions is how many distinct definitions are kept per
+	// resource.
+	maxSnapshotVersions = 10
+	// maxSnapshotResources bounds the store; the resources seen longest
+	// ago are dropped first.
+	maxSnapshotResources = 1000
+	// maxResourceChanges bounds the changes diff_resource lists.
+	maxResourceChanges = 200
+)
+
+// volatileFields are left out of snapshots because they change without
+// anyone editing the resource.
+var volatileFields = map[string][]string{
+	"monitor":   {"id", "overall_state", "overall_state_modified", "state", "matching_downtimes", "created", "modified", "creator", "deleted"},
+	"dashboard": {"id", "url", "created_at", "modified_at", "author_handle", "author_name"},
+}
+
+// resourceVersion is one definition of a resource, as first and last
+// seen.
+type resourceVersion struct {
+	Hash       string          `json:"hash"`
+	Definition json.RawMessage `json:"definition"`
+	FirstSeen  time.Time       `json:"first_seen"`
+	LastSeen   time.Time       `json:"last_seen"`
+	// Modified is when Datadog says the resource was last changed.
+	Modified string `json:"modified,omitempty"`
+}
+
+// snapshotStore keeps the definitions of monitors and dashboards each
+// time they are fetched, so later fetches can say what changed. With a
+// path it is saved there after each change and survives restarts. A nil
+// store records nothing.
+type snapshotStore struct {
+	path string
+
+	mu        sync.Mutex
+	resources map[string][]resourceVersion
+}
+
+func loadSnapshotStore(path string) (*snapshotStore, error) {
+	store := &snapshotStore{path: path, resources: make(map[string][]resourceVersion)}
+	if path == "" {
+		return store, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshots file: %w", err)
+	}
+	if err := json.Unmarshal(data, &store.resources); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshots file: %w", err)
+	}
+	return store, nil
+}
+
+// record stores a resource's current definition and returns its history
+// before this call, oldest first.
+func (st *snapshotStore) record(key string, current resourceVersion) []resourceVersion {
+	if st == nil {
+		return nil
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	history := st.resources[key]
+	before := append([]resourceVersion(nil), history...)
+	if n := len(history); n > 0 && history[n-1].Hash == current.Hash {
+		history[n-1].LastSeen = current.LastSeen
+		history[n-1].Modified = current.Modified
+	} else {
+		history = append(history, current)
+		if len(history) > maxSnapshotVersions {
+			history = history[len(history)-maxSnapshotVersions:]
+		}
+	}
+	st.resources[key] = history
+	if len(st.resources) > maxSnapshotResources {
+		st.evictOldest()
+	}
+	st.save()
+	return before
+}
+
+// evictOldest drops the resource seen longest ago.
+func (st *snapshotStore) evictOldest() {
+	var oldestKey string
+	var oldest time.Time
+	for key, history := range st.resources {
+		if seen := history[len(history)-1].LastSeen; oldestKey == "" || seen.Before(oldest) {
+			oldestKey, oldest = key, seen
+		}
+	}
+	delete(st.resources, oldestKey)
+}
+
+// save writes the store to its file, if it has one. Failures are logged:
+// losing a snapshot must not fail the fetch that made it.
+func (st *snapshotStore) save() {
+	if st.path == "" {
+		return
+	}
+	data, err := json.Marshal(st.resources)
+	if err == nil {
+		tmp := filepath.Join(filepath.Dir(st.path), "."+filepath.Base(st.path)+".tmp")
+		if err = os.WriteFile(tmp, data, 0o600); err == nil {
+			err = os.Rename(tmp, st.path)
+		}
+	}
+	if err != nil {
+		log.Printf("Saving snapshots failed: %v", err)
+	}
+}
+
+// snapshotResource records the definition of a fetched monitor or
+// dashboard and returns what was recorded before.
+func (s *MCPServer) snapshotResource(kind, id string, resource interface{}, modified string) (resourceVersion, []resourceVersion, error) {
+	definition, err := canonicalDefinition(kind, resource)
+	if err != nil {
+		return resourceVersion{}, nil, err
+	}
+	sum := sha256.Sum256(definition)
+	now := time.Now().UTC()
+	current := resourceVersion{
+		Hash:       hex.EncodeToString(sum[:]),
+		Definition: definition,
+		FirstSeen:  now,
+		LastSeen:   now,
+		Modified:   modified,
+	}
+	return current, s.snapshots.record(s.apiOrg()+"|"+kind+":"+id, current), nil
+}
+
+// canonicalDefinition renders a resource as JSON without its volatile
+// fields. Object keys come out sorted, so equal definitions hash equally.
+func canonicalDefinition(kind string, resource interface{}) (json.RawMessage, error) {
+	raw, err := json.Marshal(resource)
+	if err != nil {
+		return nil, err
+	}
+	var definition map[string]interface{}
+	if err := json.Unmarshal(raw, &definition); err != nil {
+		return nil, err
+	}
+	for _, field := range volatileFields[kind] {
+		delete(definition, field)
+	}
+	if kind == "dashboard" {
+		// Widget IDs are reassigned when a dashboard is saved.
+		stripWidgetIDs(definition["widgets"])
+	}
+	return json.Marshal(definition)
+}
+
+func stripWidgetIDs(value interface{}) {
+	widgets, _ := value.([]interface{})
+	for _, w := range widgets {
+		widget, _ := w.(map[string]interface{})
+		if widget == nil {
+			continue
+		}
+		delete(widget, "id")
+		if definition, ok := widget["definition"].(map[string]interface{}); ok {
+			stripWidgetIDs(definition["widgets"])
+		}
+	}
+}
+
+type DiffResourceParams struct {
+	// Type is "monitor" or "dashboard".
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// ResourceChange is one field that differs between two definitions. A
+// missing Before means the field was added; a missing After, removed.
+type ResourceChange struct {
+	Path   string      `json:"path"`
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+type DiffResourceResult struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Changed reports whether the definition differs from the last time
+	// it was fetched.
+	Changed bool `json:"changed"`
+	// Since is when the definition compared against was last seen; From
+	// and To bound the recorded change shown when nothing changed since.
+	Since string `json:"since,omitempty"`
+	From  string `json:"from,omitempty"`
+	To    string `json:"to,omitempty"`
+	// Modified is when Datadog says the resource was last changed.
+	Modified string           `json:"modified,omitempty"`
+	Changes  []ResourceChange `json:"changes"`
+	// Versions is how many distinct definitions have been recorded.
+	Versions int      `json:"versions"`
+	URL      string   `json:"url"`
+	Notes    []string `json:"notes,omitempty"`
+}
+
+// DiffResource fetches a monitor or dashboard and shows how its
+// definition changed since it was last fetched by any tool.
+func (s *MCPServer) DiffResource(params DiffResourceParams) (*DiffResourceResult, error) {
+	result := &DiffResourceResult{Type: params.Type, Changes: make([]ResourceChange, 0)}
+	var resource interface{}
+	switch params.Type {
+	case "monitor":
+		id, err := strconv.ParseInt(params.ID, 10, 64)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid monitor id: %q", params.ID)
+		}
+		monitor, httpResp, err := datadogV1.NewMonitorsApi(s.ddClient).GetMonitor(s.ctx, id)
+		if err != nil {
+			if httpStatus(httpResp) == http.StatusNotFound {
+				return nil, fmt.Errorf("monitor %d not found", id)
+			}
+			return nil, fmt.Errorf("failed to get monitor %d: %w", id, err)
+		}
+		resource = monitor
+		result.ID, result.Name = params.ID, monitor.GetName()
+		result.Modified = formatOptionalTime(monitor.Modified)
+		result.URL = s.appURL(fmt.Sprintf("/monitors/%d", id))
+	case "dashboard":
+		id := dashboardID(params.ID)
+		if id == "" {
+			return nil, fmt.Errorf("id parameter is required")
+		}
+		dashboard, httpResp, err := datadogV1.NewDashboardsApi(s.ddClient).GetDashboard(s.ctx, id)
+		if err != nil {
+			if httpStatus(httpResp) == http.StatusNotFound {
+				return nil, fmt.Errorf("dashboard %s not found", id)
+			}
+			return nil, fmt.Errorf("failed to get dashboard %s: %w", id, err)
+		}
+		resource = dashboard
+		result.ID, result.Name = id, dashboard.GetTitle()
+		result.Modified = formatOptionalTime(dashboard.ModifiedAt)
+		result.URL = s.appURL(dashboard.GetUrl())
+	default:
+		return nil, fmt.Errorf("invalid type: %q (use monitor or dashboard)", params.Type)
+	}
+
+	current, history, err := s.snapshotResource(params.Type, result.ID, resource, result.Modified)
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot %s %s: %w", params.Type, result.ID, err)
+	}
+	result.Versions = len(history)
+	if len(history) == 0 || history[len(history)-1].Hash != current.Hash {
+		result.Versions++
+	}
+	if len(history) == 0 {
+		result.Notes = append(result.Notes, fmt.Sprintf("This %s hasn't been fetched before, so there is nothing to compare with yet. Its definition is now recorded; call diff_resource again later to see changes.", params.Type))
+		return result, nil
+	}
+
+	last := history[len(history)-1]
+	result.Since = last.LastSeen.Format(time.RFC3339)
+	var before, after resourceVersion
+	switch {
+	case last.Hash != current.Hash:
+		result.Changed = true
+		before, after = last, current
+	case len(history) > 1:
+		before, after = history[len(history)-2], last
+		result.From, result.To = before.LastSeen.Format(time.RFC3339), after.FirstSeen.Format(time.RFC3339)
+		result.Notes = append(result.Notes, fmt.Sprintf("Unchanged since it was last seen at %s; the changes shown are the last recorded change, made between %s and %s.", result.Since, result.From, result.To))
+	default:
+		result.Notes = append(result.Notes, fmt.Sprintf("Unchanged since it was first seen at %s.", history[0].FirstSeen.Format(time.RFC3339)))
+		return result, nil
+	}
+
+	changes, err := diffDefinitions(before.Definition, after.Definition)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) > maxResourceChanges {
+		result.Notes = append(result.Notes, fmt.Sprintf("Showing the first %d of %d changed fields.", maxResourceChanges, len(changes)))
+		changes = changes[:maxResourceChanges]
+	}
+	result.Changes = changes
+	return result, nil
+}
+
+// diffDefinitions lists the fields that differ between two definitions,
+// by path, such as "options.thresholds.critical" or "widgets[2].title".
+func diffDefinitions(before, after json.RawMessage) ([]ResourceChange, error) {
+	var b, a interface{}
+	if err := json.Unmarshal(before, &b); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(after, &a); err != nil {
+		return nil, err
+	}
+	old, now := make(map[string]interface{}), make(map[string]interface{})
+	flattenJSON("", b, old)
+	flattenJSON("", a, now)
+
+	changes := make([]ResourceChange, 0)
+	for path, value := range old {
+		if next, ok := now[path]; !ok {
+			changes = append(changes, ResourceChange{Path: path, Before: value})
+		} else if !reflect.DeepEqual(value, next) {
+			changes = append(changes, ResourceChange{Path: path, Before: value, After: next})
+		}
+	}
+	for path, value := range now {
+		if _, ok := old[path]; !ok {
+			changes = append(changes, ResourceChange{Path: path, After: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+// flattenJSON maps each leaf of a decoded JSON value to its path. Empty
+// objects and arrays are leaves too, so adding the first item shows.
+func flattenJSON(path string, value interface{}, out map[string]interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			out[path] = v
+		}
+		for key, child := range v {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			flattenJSON(childPath, child, out)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			out[path] = v
+		}
+		for i, child := range v {
+			flattenJSON(fmt.Sprintf("%s[%d]", path, i), child, out)
+		}
+	default:
+		out[path] = v
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiffResource(t *testing.T) {
+	critical, state := "0.9", "OK"
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/monitor/7":
+			_, _ = w.Write([]byte(`{"id":7,"name":"Disk full","type":"metric alert","query":"avg(last_5m):x > ` + critical + `",
+				"overall_state":"` + state + `","options":{"thresholds":{"critical":` + critical + `}}}`))
+		case "/api/v1/dashboard/abc-def":
+			_, _ = w.Write([]byte(`{"id":"abc-def","title":"Checkout","layout_type":"ordered","url":"/dashboard/abc-def/checkout",
+				"widgets":[{"id":` + map[string]string{"0.9": "1", "0.95": "2"}[critical] + `,"definition":{"type":"note","content":"hello"}}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	path := filepath.Join(t.TempDir(), "snapshots.json")
+	store, err := loadSnapshotStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.snapshots = store
+
+	result, err := server.DiffResource(DiffResourceParams{Type: "monitor", ID: "7"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Changed || result.Versions != 1 || len(result.Notes) != 1 || result.Name != "Disk full" {
+		t.Fatalf("expected the first fetch to only record, got %+v", result)
+	}
+
+	// A state change alone isn't an edit.
+	state = "Alert"
+	if _, err := server.GetMonitor(GetMonitorParams{MonitorID: 7}); err != nil {
+		t.Fatal(err)
+	}
+	result, _ = server.DiffResource(DiffResourceParams{Type: "monitor", ID: "7"})
+	if result.Changed || result.Versions != 1 {
+		t.Fatalf("expected no change, got %+v", result)
+	}
+
+	critical = "0.95"
+	result, err = server.DiffResource(DiffResourceParams{Type: "monitor", ID: "7"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Changed || result.Versions != 2 || len(result.Changes) != 2 || result.Since == "" {
+		t.Fatalf("expected the threshold change, got %+v", result)
+	}
+	change := result.Changes[0]
+	if change.Path != "options.thresholds.critical" || change.Before != 0.9 || change.After != 0.95 {
+		t.Errorf("unexpected change: %+v", change)
+	}
+
+	result, _ = server.DiffResource(DiffResourceParams{Type: "monitor", ID: "7"})
+	if result.Changed || len(result.Changes) != 2 || result.From == "" || !strings.Contains(result.Notes[0], "last recorded change") {
+		t.Errorf("expected the last recorded change when unchanged since, got %+v", result)
+	}
+
+	reloaded, err := loadSnapshotStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reloaded.resources["|monitor:7"]) != 2 {
+		t.Errorf("expected both versions saved, got %+v", reloaded.resources)
+	}
+
+	// Dashboards get new widget IDs on save; that isn't a change.
+	critical = "0.9"
+	if _, err := server.GetDashboard(GetDashboardParams{DashboardID: "https://app.datadoghq.com/dashboard/abc-def/checkout"}); err != nil {
+		t.Fatal(err)
+	}
+	critical = "0.95"
+	result, err = server.DiffResource(DiffResourceParams{Type: "dashboard", ID: "abc-def"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Changed || result.Name != "Checkout" {
+		t.Errorf("expected new widget IDs to be ignored, got %+v", result)
+	}
+
+	if _, err := server.DiffResource(DiffResourceParams{Type: "slo", ID: "1"}); err == nil {
+		t.Error("expected an unknown type to be rejected")
+	}
+}
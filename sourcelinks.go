@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxSourceLinks bounds how many references are linked per log entry, so a
+// deep stack trace doesn't dwarf the log itself.
+const maxSourceLinks = 10
+
+type SourceLink struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+	URL  string `json:"url"`
+}
+
+type sourceRef struct {
+	File string
+	Line int
+}
+
+var (
+	// fileLinePattern matches "path/to/file.go:42" style references used by
+	// Go, Java, JavaScript, Ruby and most other runtimes.
+	fileLinePattern = regexp.MustCompile(`([\w./@-]+\.(?:go|py|java|kt|scala|js|jsx|ts|tsx|mjs|rb|rs|cs|php|c|cc|cpp|h|hpp|swift|ex|exs)):(\d+)`)
+	// pythonFramePattern matches Python's `File "app/views.py", line 12` frames.
+	pythonFramePattern = regexp.MustCompile(`File "([^"]+)", line (\d+)`)
+)
+
+// vendoredPathMarkers identify frames from dependencies or the runtime,
+// which never live in the service's own repository.
+var vendoredPathMarkers = []string{
+	"/vendor/", "node_modules/", "site-packages/", "dist-packages/",
+	"/go/src/runtime/", "/usr/local/go/", "/usr/lib/", "/pkg/mod/", "<",
+}
+
+// workdirPrefixes are common container working directories that hold a
+// checkout of the repository root.
+var workdirPrefixes = []string{"/usr/src/app/", "/app/", "/src/", "/workspace/", "/code/", "/opt/app/"}
+
+func extractSourceRefs(text string) []sourceRef {
+	refs := make([]sourceRef, 0)
+	seen := make(map[sourceRef]bool)
+	for _, pattern := range []*regexp.Regexp{pythonFramePattern, fileLinePattern} {
+		for _, m := range pattern.FindAllStringSubmatch(text, -1) {
+			line, err := strconv.Atoi(m[2])
+			if err != nil || line <= 0 {
+				continue
+			}
+			ref := sourceRef{File: m[1], Line: line}
+			if seen[ref] {
+				continue
+			}
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// repoRelativePath maps a path from a stack frame onto a path inside the
+// repository. Frames that include the repository's own import path (as Go
+// binaries do) are cut there; otherwise known container working
+// directories are stripped. Frames that can't be mapped are skipped.
+func repoRelativePath(repo, file string) (string, bool) {
+	for _, marker := range vendoredPathMarkers {
+		if strings.Contains(file, marker) {
+			return "", false
+		}
+	}
+
+	if u, err := url.Parse(repo); err == nil && u.Host != "" {
+		modulePath := u.Host + strings.TrimSuffix(u.Path, ".git") + "/"
+		if idx := strings.Index(file, modulePath); idx >= 0 {
+			return file[idx+len(modulePath):], true
+		}
+	}
+
+	if strings.HasPrefix(file, "/") {
+		for _, prefix := range workdirPrefixes {
+			if rest, ok := strings.CutPrefix(file, prefix); ok {
+				return rest, true
+			}
+		}
+		return "", false
+	}
+	return strings.TrimPrefix(file, "./"), true
+}
+
+// buildSourceURL renders a link to a line of a file at a given ref on
+// GitHub or GitLab. Other hosts are not linked.
+func buildSourceURL(repo, ref, path string, line int) (string, bool) {
+	u, err := url.Parse(strings.TrimSuffix(repo, ".git"))
+	if err != nil || u.Host == "" || ref == "" {
+		return "", false
+	}
+	base := strings.TrimSuffix(u.Scheme+"://"+u.Host+u.Path, "/")
+
+	switch {
+	case strings.Contains(u.Host, "github"):
+		return fmt.Sprintf("%s/blob/%s/%s#L%d", base, ref, path, line), true
+	case strings.Contains(u.Host, "gitlab"):
+		return fmt.Sprintf("%s/-/blob/%s/%s#L%d", base, ref, path, line), true
+	}
+	return "", false
+}
+
+// deployedRef picks the revision to link at: the commit SHA when the
+// source code integration tagged one, otherwise the version tag.
+func deployedRef(tags []string) string {
+	if sha := tagValue(tags, "git.commit.sha"); sha != "" {
+		return sha
+	}
+	return tagValue(tags, "version")
+}
+
+func sourceLinksFor(repo, ref, text string) []SourceLink {
+	links := make([]SourceLink, 0)
+	for _, r := range extractSourceRefs(text) {
+		path, ok := repoRelativePath(repo, r.File)
+		if !ok {
+			continue
+		}
+		link, ok := buildSourceURL(repo, ref, path, r.Line)
+		if !ok {
+			continue
+		}
+		links = append(links, SourceLink{File: r.File, Line: r.Line, URL: link})
+		if len(links) == maxSourceLinks {
+			break
+		}
+	}
+	return links
+}
+
+// linkSources attaches source links to log entries whose message or error
+// stack references files of a service with a repository in its service
+// definition. Service definitions are looked up once per service; lookup
+// failures only disable linking for that service.
+func (s *MCPServer) linkSources(logs []LogEntry, stacks []string) {
+	repos := make(map[string]string)
+	for i := range logs {
+		entry := &logs[i]
+		text := entry.Message + "\n" + stacks[i]
+		if entry.Service == "" || !fileLinePattern.MatchString(text) && !pythonFramePattern.MatchString(text) {
+			continue
+		}
+
+		ref := deployedRef(entry.Tags)
+		if ref == "" {
+			continue
+		}
+
+		repo, ok := repos[entry.Service]
+		if !ok {
+			links, err := s.fetchServiceLinks(entry.Service)
+			if err != nil {
+				log.Printf("Source linking disabled for %s: %v", entry.Service, err)
+			}
+			repo = repoURL(links)
+			repos[entry.Service] = repo
+		}
+		if repo == "" {
+			continue
+		}
+
+		if links := sourceLinksFor(repo, ref, text); len(links) > 0 {
+			entry.SourceLinks = links
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestExtractSourceRefs(t *testing.T) {
+	text := `panic: boom
+goroutine 1 [running]:
+main.handler()
+	/go/src/github.com/acme/checkout/internal/api/handler.go:42 +0x1d
+Traceback (most recent call last):
+  File "app/views.py", line 12, in index`
+
+	refs := extractSourceRefs(text)
+	if len(refs) != 2 {
+		t.Fatalf("expected 2 refs, got %d: %+v", len(refs), refs)
+	}
+	if refs[0].File != "app/views.py" || refs[0].Line != 12 {
+		t.Errorf("unexpected python ref: %+v", refs[0])
+	}
+	if refs[1].File != "/go/src/github.com/acme/checkout/internal/api/handler.go" || refs[1].Line != 42 {
+		t.Errorf("unexpected go ref: %+v", refs[1])
+	}
+}
+
+func TestRepoRelativePath(t *testing.T) {
+	repo := "https://github.com/acme/checkout"
+	tests := []struct {
+		file     string
+		expected string
+		ok       bool
+	}{
+		{file: "/go/src/github.com/acme/checkout/internal/api/handler.go", expected: "internal/api/handler.go", ok: true},
+		{file: "/app/src/index.js", expected: "src/index.js", ok: true},
+		{file: "./lib/util.rb", expected: "lib/util.rb", ok: true},
+		{file: "/usr/local/go/src/net/http/server.go", ok: false},
+		{file: "/home/runner/other/file.go", ok: false},
+		{file: "node_modules/express/lib/router.js", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			path, ok := repoRelativePath(repo, tt.file)
+			if ok != tt.ok || path != tt.expected {
+				t.Errorf("expected (%q, %v), got (%q, %v)", tt.expected, tt.ok, path, ok)
+			}
+		})
+	}
+}
+
+func TestBuildSourceURL(t *testing.T) {
+	tests := []struct {
+		repo     string
+		expected string
+		ok       bool
+	}{
+		{repo: "https://github.com/acme/checkout.git", expected: "https://github.com/acme/checkout/blob/v1.2.0/main.go#L7", ok: true},
+		{repo: "https://gitlab.com/acme/checkout/", expected: "https://gitlab.com/acme/checkout/-/blob/v1.2.0/main.go#L7", ok: true},
+		{repo: "https://bitbucket.org/acme/checkout", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.repo, func(t *testing.T) {
+			link, ok := buildSourceURL(tt.repo, "v1.2.0", "main.go", 7)
+			if ok != tt.ok || link != tt.expected {
+				t.Errorf("expected (%q, %v), got (%q, %v)", tt.expected, tt.ok, link, ok)
+			}
+		})
+	}
+}
+
+func TestDeployedRef(t *testing.T) {
+	if ref := deployedRef([]string{"version:1.2.0", "git.commit.sha:abc123"}); ref != "abc123" {
+		t.Errorf("expected commit sha to win, got %s", ref)
+	}
+	if ref := deployedRef([]string{"version:1.2.0"}); ref != "1.2.0" {
+		t.Errorf("expected version fallback, got %s", ref)
+	}
+}
+
+func TestErrorStack(t *testing.T) {
+	attrs := map[string]interface{}{
+		"error": map[string]interface{}{"stack": "at handler (src/app.js:10:5)"},
+	}
+	if stack := errorStack(attrs); stack != "at handler (src/app.js:10:5)" {
+		t.Errorf("unexpected stack: %q", stack)
+	}
+	if stack := errorStack(nil); stack != "" {
+		t.Errorf("expected empty stack, got %q", stack)
+	}
+}
@@ -0,0 +1,413 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadog"
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV2"
+)
+
+// maxSpansLimit bounds one query_spans call, the most spans the API
+// returns per page.
+const maxSpansLimit = 1000
+
+type QuerySpansParams struct {
+	Query string `json:"query"`
+	From  string `json:"from,omitempty"`
+	To    string `json:"to,omitempty"`
+	Limit int    `json:"limit,omitempty"`
+	// Sort is "-timestamp" (newest first) or "timestamp".
+	Sort string `json:"sort,omitempty"`
+}
+
+type SpanEntry struct {
+	TraceID    string     `json:"trace_id"`
+	SpanID     string     `json:"span_id,omitempty"`
+	ParentID   string     `json:"parent_id,omitempty"`
+	Service    string     `json:"service"`
+	Resource   string     `json:"resource"`
+	Operation  string     `json:"operation,omitempty"`
+	Start      *time.Time `json:"start,omitempty"`
+	DurationMs *float64   `json:"duration_ms,omitempty"`
+	Status     string     `json:"status,omitempty"`
+	Env        string     `json:"env,omitempty"`
+	Host       string     `json:"host,omitempty"`
+	URL        string     `json:"url,omitempty"`
+}
+
+type QuerySpansResult struct {
+	Spans []SpanEntry `json:"spans"`
+	Count int         `json:"count"`
+	Query string      `json:"query"`
+	From  string      `json:"from"`
+	To    string      `json:"to"`
+	Sort  string      `json:"sort"`
+	// More is set when further spans matched beyond the limit.
+	More    bool         `json:"more,omitempty"`
+	Notes   []string     `json:"notes,omitempty"`
+	Partial *PartialData `json:"partial,omitempty"`
+}
+
+// QuerySpans searches indexed APM spans.
+func (s *MCPServer) QuerySpans(params QuerySpansParams) (*QuerySpansResult, error) {
+	if params.Query == "" {
+		return nil, fmt.Errorf("query parameter is required")
+	}
+	from, err := s.timeParam("from", params.From, time.Now().Add(-time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.timeParam("to", params.To, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	sort := datadogV2.SPANSSORT_TIMESTAMP_DESCENDING
+	if params.Sort != "" {
+		parsed, err := datadogV2.NewSpansSortFromValue(params.Sort)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sort: %s (use -timestamp or timestamp)", params.Sort)
+		}
+		sort = *parsed
+	}
+
+	limit := 50
+	if params.Limit > 0 {
+		limit = clampArgument(s, "limit", params.Limit, maxSpansLimit)
+	}
+
+	body := datadogV2.SpansListRequest{
+		Data: &datadogV2.SpansListRequestData{
+			Type: datadogV2.SPANSLISTREQUESTTYPE_SEARCH_REQUEST.Ptr(),
+			Attributes: &datadogV2.SpansListRequestAttributes{
+				Filter: &datadogV2.SpansQueryFilter{
+					From:  datadog.PtrString(from.Format(time.RFC3339)),
+					To:    datadog.PtrString(to.Format(time.RFC3339)),
+					Query: datadog.PtrString(params.Query),
+				},
+				Page: &datadogV2.SpansListRequestPage{Limit: datadog.PtrInt32(int32(limit))},
+				Sort: sort.Ptr(),
+			},
+		},
+	}
+
+	api := datadogV2.NewSpansApi(s.ddClient)
+	resp, _, err := api.ListSpans(s.ctx, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query spans: %w", err)
+	}
+
+	spans := make([]SpanEntry, 0, len(resp.Data))
+	var partial *PartialData
+	for _, span := range resp.Data {
+		entry, missing := s.toSpanEntry(span)
+		spans = append(spans, entry)
+		partial = partial.record(missing...)
+	}
+
+	var notes []string
+	for _, warning := range resp.GetMeta().Warnings {
+		notes = append(notes, fmt.Sprintf("Datadog warning: %s", warning.GetDetail()))
+	}
+
+	return &QuerySpansResult{
+		Spans:   spans,
+		Count:   len(spans),
+		Query:   params.Query,
+		From:    from.Format(time.RFC3339),
+		To:      to.Format(time.RFC3339),
+		Sort:    string(sort),
+		More:    resp.GetMeta().Page.GetAfter() != "",
+		Notes:   notes,
+		Partial: partial,
+	}, nil
+}
+
+// toSpanEntry also names the fields every span should have but this one
+// lacks.
+func (s *MCPServer) toSpanEntry(span datadogV2.Span) (SpanEntry, []string) {
+	attrs := span.Attributes
+	if attrs == nil {
+		return SpanEntry{}, []string{"attributes"}
+	}
+	entry := SpanEntry{
+		TraceID:  attrs.GetTraceId(),
+		SpanID:   attrs.GetSpanId(),
+		ParentID: attrs.GetParentId(),
+		Service:  attrs.GetService(),
+		Resource: attrs.GetResourceName(),
+		Start:    attrs.StartTimestamp,
+		Env:      attrs.GetEnv(),
+		Host:     attrs.GetHost(),
+		Status:   spanStatus(attrs),
+	}
+	if entry.ParentID == "0" {
+		entry.ParentID = ""
+	}
+	if name, ok := attrs.Custom["operation_name"].(string); ok {
+		entry.Operation = name
+	}
+	if ms, ok := spanDurationMs(attrs); ok {
+		entry.DurationMs = &ms
+	}
+	if entry.TraceID != "" {
+		entry.URL = s.appURL("/apm/trace/" + entry.TraceID)
+	}
+	return entry, missingFields(
+		fieldCheck{"trace_id", attrs.TraceId != nil},
+		fieldCheck{"span_id", attrs.SpanId != nil},
+		fieldCheck{"service", attrs.Service != nil},
+		fieldCheck{"resource_name", attrs.ResourceName != nil},
+		fieldCheck{"start_timestamp", attrs.StartTimestamp != nil},
+		fieldCheck{"duration", entry.DurationMs != nil},
+	)
+}
+
+// spanDurationMs prefers the span's recorded duration, in nanoseconds, over
+// the difference of its timestamps, which are only millisecond-precise.
+func spanDurationMs(attrs *datadogV2.SpansAttributes) (float64, bool) {
+	if ns, ok := attrs.Custom["duration"].(float64); ok {
+		return ns / 1e6, true
+	}
+	if attrs.StartTimestamp != nil && attrs.EndTimestamp != nil {
+		return float64(attrs.EndTimestamp.Sub(*attrs.StartTimestamp).Microseconds()) / 1e3, true
+	}
+	return 0, false
+}
+
+// spanStatus reads the span's status, falling back to its error flag.
+func spanStatus(attrs *datadogV2.SpansAttributes) string {
+	for _, fields := range []map[string]interface{}{attrs.Custom, attrs.Attributes} {
+		if status, ok := fields["status"].(string); ok && status != "" {
+			return status
+		}
+	}
+	if flag, ok := attrs.Custom["error"].(float64); ok {
+		if flag != 0 {
+			return "error"
+		}
+		return "ok"
+	}
+	return ""
+}
+
+// maxSpanGroups bounds the buckets aggregate_spans asks for per group-by
+// facet.
+const maxSpanGroups = 100
+
+// spanFacetAliases maps friendly group-by names to span facets.
+var spanFacetAliases = map[string]string{
+	"resource":  "resource_name",
+	"operation": "operation_name",
+}
+
+type AggregateSpansParams struct {
+	Query string `json:"query,omitempty"`
+	From  string `json:"from,omitempty"`
+	To    string `json:"to,omitempty"`
+	// Compute lists "count" or "<aggregation>:<measure>", such as
+	// "pc95:@duration"; a bare aggregation uses @duration.
+	Compute []string `json:"compute,omitempty"`
+	GroupBy []string `json:"group_by,omitempty"`
+	Limit   int      `json:"limit,omitempty"`
+}
+
+type SpanBucket struct {
+	By     map[string]string  `json:"by,omitempty"`
+	Values map[string]float64 `json:"values"`
+}
+
+type AggregateSpansResult struct {
+	Query   string       `json:"query"`
+	From    string       `json:"from"`
+	To      string       `json:"to"`
+	Compute []string     `json:"compute"`
+	GroupBy []string     `json:"group_by,omitempty"`
+	Buckets []SpanBucket `json:"buckets"`
+	Notes   []string     `json:"notes,omitempty"`
+}
+
+// spanCompute is one parsed compute: its label in the result and the
+// request it becomes.
+type spanCompute struct {
+	label    string
+	compute  datadogV2.SpansCompute
+	duration bool
+}
+
+func parseSpanCompute(spec string) (spanCompute, error) {
+	spec = strings.TrimSpace(spec)
+	aggregation, metric, _ := strings.Cut(spec, ":")
+	aggregation = strings.ToLower(aggregation)
+	// p95 is the usual spelling; the API wants pc95.
+	if strings.HasPrefix(aggregation, "p") && !strings.HasPrefix(aggregation, "pc") {
+		aggregation = "pc" + aggregation[1:]
+	}
+	fn, err := datadogV2.NewSpansAggregationFunctionFromValue(aggregation)
+	if err != nil {
+		return spanCompute{}, fmt.Errorf("invalid compute: %s (use count, avg, sum, min, max, median, cardinality or pc75-pc99, optionally followed by :<measure>)", spec)
+	}
+	c := spanCompute{compute: datadogV2.SpansCompute{Aggregation: *fn, Type: datadogV2.SPANSCOMPUTETYPE_TOTAL.Ptr()}}
+	if *fn == datadogV2.SPANSAGGREGATIONFUNCTION_COUNT {
+		if metric != "" {
+			return spanCompute{}, fmt.Errorf("invalid compute: %s (count takes no measure)", spec)
+		}
+		c.label = "count"
+		return c, nil
+	}
+	if metric == "" {
+		if *fn == datadogV2.SPANSAGGREGATIONFUNCTION_CARDINALITY {
+			return spanCompute{}, fmt.Errorf("invalid compute: %s (cardinality needs a facet, e.g. cardinality:@usr.id)", spec)
+		}
+		metric = "@duration"
+	}
+	c.compute.Metric = datadog.PtrString(metric)
+	c.duration = metric == "@duration" || metric == "duration"
+	c.label = aggregation + ":" + metric
+	return c, nil
+}
+
+// AggregateSpans computes counts and measure aggregations over spans,
+// optionally grouped by facets, without fetching the spans themselves.
+func (s *MCPServer) AggregateSpans(params AggregateSpansParams) (*AggregateSpansResult, error) {
+	query := params.Query
+	if query == "" {
+		query = "*"
+	}
+	from, err := s.timeParam("from", params.From, time.Now().Add(-time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.timeParam("to", params.To, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	specs := params.Compute
+	if len(specs) == 0 {
+		specs = []string{"count"}
+	}
+	computes := make([]spanCompute, 0, len(specs))
+	for _, spec := range specs {
+		c, err := parseSpanCompute(spec)
+		if err != nil {
+			return nil, err
+		}
+		computes = append(computes, c)
+	}
+
+	limit := 10
+	if params.Limit > 0 {
+		limit = clampArgument(s, "limit", params.Limit, maxSpanGroups)
+	}
+	// Datadog orders each facet's buckets by the first compute.
+	first := computes[0].compute
+	sort := &datadogV2.SpansAggregateSort{
+		Aggregation: first.Aggregation.Ptr(),
+		Metric:      first.Metric,
+		Order:       datadogV2.SPANSSORTORDER_DESCENDING.Ptr(),
+		Type:        datadogV2.SPANSAGGREGATESORTTYPE_MEASURE.Ptr(),
+	}
+	facets := make([]string, 0, len(params.GroupBy))
+	groupBy := make([]datadogV2.SpansGroupBy, 0, len(params.GroupBy))
+	for _, facet := range params.GroupBy {
+		if alias, ok := spanFacetAliases[facet]; ok {
+			facet = alias
+		}
+		facets = append(facets, facet)
+		groupBy = append(groupBy, datadogV2.SpansGroupBy{
+			Facet: facet,
+			Limit: datadog.PtrInt64(int64(limit)),
+			Sort:  sort,
+		})
+	}
+
+	attributes := &datadogV2.SpansAggregateRequestAttributes{
+		Filter: &datadogV2.SpansQueryFilter{
+			From:  datadog.PtrString(from.Format(time.RFC3339)),
+			To:    datadog.PtrString(to.Format(time.RFC3339)),
+			Query: datadog.PtrString(query),
+		},
+	}
+	for _, c := range computes {
+		attributes.Compute = append(attributes.Compute, c.compute)
+	}
+	if len(groupBy) > 0 {
+		attributes.GroupBy = groupBy
+	}
+	body := datadogV2.SpansAggregateRequest{
+		Data: &datadogV2.SpansAggregateData{
+			Attributes: attributes,
+			Type:       datadogV2.SPANSAGGREGATEREQUESTTYPE_AGGREGATE_REQUEST.Ptr(),
+		},
+	}
+
+	api := datadogV2.NewSpansApi(s.ddClient)
+	resp, _, err := api.AggregateSpans(s.ctx, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate spans: %w", err)
+	}
+
+	result := &AggregateSpansResult{
+		Query:   query,
+		From:    from.Format(time.RFC3339),
+		To:      to.Format(time.RFC3339),
+		GroupBy: facets,
+		Buckets: make([]SpanBucket, 0, len(resp.Data)),
+	}
+	hasDuration := false
+	for _, c := range computes {
+		result.Compute = append(result.Compute, c.label)
+		hasDuration = hasDuration || c.duration
+	}
+	for _, bucket := range resp.Data {
+		result.Buckets = append(result.Buckets, toSpanBucket(bucket, computes))
+	}
+	label := computes[0].label
+	slices.SortStableFunc(result.Buckets, func(a, b SpanBucket) int {
+		return cmp.Compare(b.Values[label], a.Values[label])
+	})
+
+	if hasDuration {
+		result.Notes = append(result.Notes, "Duration values are in milliseconds.")
+	}
+	if len(facets) > 0 {
+		result.Notes = append(result.Notes, fmt.Sprintf("Each group-by facet keeps its top %d values by %s.", limit, label))
+	}
+	for _, warning := range resp.GetMeta().Warnings {
+		result.Notes = append(result.Notes, fmt.Sprintf("Datadog warning: %s", warning.GetDetail()))
+	}
+	return result, nil
+}
+
+// toSpanBucket reads a bucket's group values and its computes, which
+// Datadog keys c0, c1... in request order.
+func toSpanBucket(bucket datadogV2.SpansAggregateBucket, computes []spanCompute) SpanBucket {
+	out := SpanBucket{Values: make(map[string]float64, len(computes))}
+	if bucket.Attributes == nil {
+		return out
+	}
+	for facet, value := range bucket.Attributes.By {
+		if out.By == nil {
+			out.By = make(map[string]string)
+		}
+		out.By[facet] = fmt.Sprint(value)
+	}
+	for i, c := range computes {
+		value, ok := bucket.Attributes.Computes[fmt.Sprintf("c%d", i)]
+		if !ok || value.SpansAggregateBucketValueSingleNumber == nil {
+			continue
+		}
+		v := *value.SpansAggregateBucketValueSingleNumber
+		if c.duration {
+			// Span durations are recorded in nanoseconds.
+			v /= 1e6
+		}
+		out.Values[c.label] = v
+	}
+	return out
+}
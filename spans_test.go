@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestQuerySpans(t *testing.T) {
+	var request map[string]interface{}
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/spans/events/search" {
+			http.NotFound(w, r)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(data, &request)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":[
+			{"id":"s1","type":"spans","attributes":{"trace_id":"abc","span_id":"1","parent_id":"0","service":"checkout","resource_name":"POST /pay",
+				"start_timestamp":"2026-01-20T10:00:00Z","end_timestamp":"2026-01-20T10:00:01.5Z","env":"prod",
+				"custom":{"duration":1500000000,"error":1,"operation_name":"http.request"}}},
+			{"id":"s2","type":"spans","attributes":{"trace_id":"def","span_id":"2","parent_id":"7","service":"checkout","resource_name":"GET /cart",
+				"start_timestamp":"2026-01-20T10:00:00Z","end_timestamp":"2026-01-20T10:00:00.250Z","custom":{"status":"ok"}}}],
+			"meta":{"page":{"after":"next"},"warnings":[{"code":"partial","detail":"results may be incomplete"}]}}`))
+	})
+
+	result, err := server.QuerySpans(QuerySpansParams{Query: "service:checkout", From: "2026-01-20T09:00:00Z", To: "2026-01-20T11:00:00Z", Limit: 2, Sort: "timestamp"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	attrs := request["data"].(map[string]interface{})["attributes"].(map[string]interface{})
+	if attrs["sort"] != "timestamp" || attrs["page"].(map[string]interface{})["limit"] != float64(2) {
+		t.Fatalf("unexpected request: %v", attrs)
+	}
+	if result.Count != 2 || !result.More || len(result.Notes) != 1 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	first, second := result.Spans[0], result.Spans[1]
+	if first.TraceID != "abc" || first.Resource != "POST /pay" || first.Status != "error" || first.Operation != "http.request" || first.ParentID != "" {
+		t.Fatalf("unexpected span: %+v", first)
+	}
+	if *first.DurationMs != 1500 || first.URL != "https://app.datadoghq.com/apm/trace/abc" {
+		t.Fatalf("expected the recorded duration and a trace link, got %+v", first)
+	}
+	if *second.DurationMs != 250 || second.Status != "ok" || second.ParentID != "7" {
+		t.Fatalf("expected the duration from the timestamps, got %+v", second)
+	}
+
+	if _, err := server.QuerySpans(QuerySpansParams{Query: "*", Sort: "duration"}); err == nil {
+		t.Fatal("expected an invalid sort to be rejected")
+	}
+}
+
+func TestAggregateSpans(t *testing.T) {
+	var request map[string]interface{}
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/spans/analytics/aggregate" {
+			http.NotFound(w, r)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(data, &request)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":[
+			{"type":"bucket","attributes":{"by":{"resource_name":"GET /cart"},"computes":{"c0":120000000,"c1":40}}},
+			{"type":"bucket","attributes":{"by":{"resource_name":"POST /pay"},"computes":{"c0":1500000000,"c1":12}}}]}`))
+	})
+
+	result, err := server.AggregateSpans(AggregateSpansParams{Query: "service:checkout", Compute: []string{"p95", "count"}, GroupBy: []string{"resource"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	attrs := request["data"].(map[string]interface{})["attributes"].(map[string]interface{})
+	compute := attrs["compute"].([]interface{})[0].(map[string]interface{})
+	groupBy := attrs["group_by"].([]interface{})[0].(map[string]interface{})
+	if compute["aggregation"] != "pc95" || compute["metric"] != "@duration" || groupBy["facet"] != "resource_name" || groupBy["limit"] != float64(10) {
+		t.Fatalf("unexpected request: %v", attrs)
+	}
+	if len(result.Buckets) != 2 || result.Compute[0] != "pc95:@duration" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	slowest := result.Buckets[0]
+	if slowest.By["resource_name"] != "POST /pay" || slowest.Values["pc95:@duration"] != 1500 || slowest.Values["count"] != 12 {
+		t.Fatalf("expected the slowest endpoint first in milliseconds, got %+v", slowest)
+	}
+
+	for _, compute := range []string{"p42", "count:@duration", "cardinality"} {
+		if _, err := server.AggregateSpans(AggregateSpansParams{Compute: []string{compute}}); err == nil {
+			t.Errorf("expected compute %q to be rejected", compute)
+		}
+	}
+}
+
+func TestGetTrace(t *testing.T) {
+	var queries []string
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var request map[string]interface{}
+		data, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(data, &request)
+		attrs := request["data"].(map[string]interface{})["attributes"].(map[string]interface{})
+		queries = append(queries, attrs["filter"].(map[string]interface{})["query"].(string))
+		w.Header().Set("Content-Type", "application/json")
+		if attrs["page"].(map[string]interface{})["cursor"] == nil {
+			_, _ = w.Write([]byte(`{"data":[
+				{"id":"s1","type":"spans","attributes":{"trace_id":"42","span_id":"1","parent_id":"0","service":"web","resource_name":"POST /pay",
+					"start_timestamp":"2026-01-20T10:00:00Z","end_timestamp":"2026-01-20T10:00:01.5Z","custom":{"operation_name":"http.request"}}},
+				{"id":"s3","type":"spans","attributes":{"trace_id":"42","span_id":"3","parent_id":"2","service":"postgres","resource_name":"SELECT",
+					"start_timestamp":"2026-01-20T10:00:00.300Z","end_timestamp":"2026-01-20T10:00:00.380Z"}}],
+				"meta":{"page":{"after":"next"}}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[
+			{"id":"s2","type":"spans","attributes":{"trace_id":"42","span_id":"2","parent_id":"1","service":"payments","resource_name":"charge",
+				"start_timestamp":"2026-01-20T10:00:00.100Z","end_timestamp":"2026-01-20T10:00:01.300Z","custom":{"error":1}}},
+			{"id":"s4","type":"spans","attributes":{"trace_id":"42","span_id":"4","parent_id":"9","service":"web","resource_name":"render",
+				"start_timestamp":"2026-01-20T10:00:01.400Z","end_timestamp":"2026-01-20T10:00:01.450Z"}}],
+			"meta":{"page":{}}}`))
+	})
+
+	text, err := server.GetTrace(GetTraceParams{TraceID: "42", Format: "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queries) != 2 || queries[0] != "trace_id:42" {
+		t.Fatalf("expected two pages of a trace_id search, got %v", queries)
+	}
+	var trace Trace
+	if err := json.Unmarshal([]byte(text), &trace); err != nil {
+		t.Fatal(err)
+	}
+	if trace.Spans != 4 || trace.Errors != 1 || trace.DurationMs != 1500 || len(trace.Services) != 3 {
+		t.Fatalf("unexpected totals: %+v", trace)
+	}
+	if len(trace.Roots) != 2 || trace.Roots[0].SpanID != "1" || trace.Roots[1].SpanID != "4" || len(trace.Notes) != 1 {
+		t.Fatalf("expected the root and the orphaned span at the top level, got %+v", trace)
+	}
+	charge := trace.Roots[0].Children[0]
+	if charge.SpanID != "2" || charge.OffsetMs != 100 || len(charge.Children) != 1 || charge.Children[0].SpanID != "3" {
+		t.Fatalf("expected the spans nested by parent, got %+v", charge)
+	}
+
+	queries = nil
+	markdown, err := server.GetTrace(GetTraceParams{TraceID: "42"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"4 spans across payments, postgres, web, 1.5s",
+		"- **web** POST /pay (http.request) 1.5s at +0s\n",
+		"  - **payments** charge 1.2s at +100ms **ERROR**\n",
+		"    - **postgres** SELECT 80ms at +300ms\n",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Fatalf("expected %q in:\n%s", want, markdown)
+		}
+	}
+
+	diagram, err := server.GetTrace(GetTraceParams{TraceID: "42", Format: "mermaid"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"```mermaid\nsequenceDiagram\n    participant p0 as web\n    participant p1 as payments\n    participant p2 as postgres\n",
+		"    Note over p0: POST /pay 1.5s\n",
+		"    p0-xp1: charge 1.2s ERROR\n",
+		"    p1->>p2: SELECT 80ms\n",
+		"    Note over p0: render 50ms\n",
+	} {
+		if !strings.Contains(diagram, want) {
+			t.Fatalf("expected %q in:\n%s", want, diagram)
+		}
+	}
+
+	if _, err := server.GetTrace(GetTraceParams{TraceID: " "}); err == nil {
+		t.Fatal("expected a missing trace_id to be rejected")
+	}
+}
@@ -0,0 +1,453 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+)
+
+const (
+	defaultSyntheticsLimit = 50
+	maxSyntheticsLimit     = 500
+	syntheticsPageSize     = 100
+	// maxSyntheticsPages bounds the test and monitor listings.
+	maxSyntheticsPages = 50
+
+	defaultSyntheticResultsLimit = 20
+	maxSyntheticResultsLimit     = 100
+	// maxFailureDetails bounds how many failed runs are fetched in full
+	// for their failure and steps.
+	maxFailureDetails = 5
+)
+
+type ListSyntheticTestsParams struct {
+	// Tags keeps tests carrying every one of them.
+	Tags []string `json:"tags,omitempty"`
+	// Type is "api", "browser" or "mobile".
+	Type string `json:"type,omitempty"`
+	// Query keeps tests whose name contains it.
+	Query string `json:"query,omitempty"`
+	Limit int    `json:"limit,omitempty"`
+}
+
+type SyntheticTest struct {
+	PublicID string `json:"public_id"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Subtype  string `json:"subtype,omitempty"`
+	// Status is "live" or "paused"; State is the test monitor's status,
+	// such as OK or Alert.
+	Status    string   `json:"status"`
+	State     string   `json:"state,omitempty"`
+	Locations []string `json:"locations"`
+	Tags      []string `json:"tags,omitempty"`
+	MonitorID int64    `json:"monitor_id,omitempty"`
+	URL       string   `json:"url"`
+}
+
+type ListSyntheticTestsResult struct {
+	Tests []SyntheticTest `json:"tests"`
+	Count int             `json:"count"`
+	// Total is how many tests matched before limit was applied.
+	Total int  `json:"total"`
+	More  bool `json:"more,omitempty"`
+	// ByState counts the matching tests in each state.
+	ByState map[string]int `json:"by_state,omitempty"`
+	Notes   []string       `json:"notes,omitempty"`
+}
+
+// ListSyntheticTests lists Synthetic tests with where they run and whether
+// they are currently passing. Failing tests come first.
+func (s *MCPServer) ListSyntheticTests(params ListSyntheticTestsParams) (*ListSyntheticTestsResult, error) {
+	limit := params.Limit
+	if limit <= 0 {
+		limit = defaultSyntheticsLimit
+	}
+	if limit > maxSyntheticsLimit {
+		return nil, fmt.Errorf("limit must be at most %d", maxSyntheticsLimit)
+	}
+	switch params.Type {
+	case "", "api", "browser", "mobile":
+	default:
+		return nil, fmt.Errorf("invalid type: %s (use api, browser or mobile)", params.Type)
+	}
+
+	api := datadogV1.NewSyntheticsApi(s.ddClient)
+	query := strings.ToLower(params.Query)
+	var tests []SyntheticTest
+	for page := int64(0); page < maxSyntheticsPages; page++ {
+		opts := datadogV1.NewListTestsOptionalParameters().WithPageSize(syntheticsPageSize).WithPageNumber(page)
+		resp, _, err := api.ListTests(s.ctx, *opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list synthetic tests: %w", err)
+		}
+		for _, t := range resp.Tests {
+			test := SyntheticTest{
+				PublicID:  t.GetPublicId(),
+				Name:      t.GetName(),
+				Type:      string(t.GetType()),
+				Subtype:   string(t.GetSubtype()),
+				Status:    string(t.GetStatus()),
+				Locations: t.Locations,
+				Tags:      t.Tags,
+				MonitorID: t.GetMonitorId(),
+				URL:       s.appURL("/synthetics/details/" + t.GetPublicId()),
+			}
+			if params.Type != "" && test.Type != params.Type {
+				continue
+			}
+			if query != "" && !strings.Contains(strings.ToLower(test.Name), query) {
+				continue
+			}
+			if !hasAllTags(test.Tags, params.Tags) {
+				continue
+			}
+			if test.Locations == nil {
+				test.Locations = []string{}
+			}
+			tests = append(tests, test)
+		}
+		if len(resp.Tests) < syntheticsPageSize {
+			break
+		}
+	}
+
+	result := &ListSyntheticTestsResult{Total: len(tests)}
+	if states, err := s.syntheticMonitorStates(); err != nil {
+		result.Notes = append(result.Notes, fmt.Sprintf("Couldn't read test states from their monitors: %v", err))
+	} else {
+		result.ByState = make(map[string]int)
+		for i := range tests {
+			tests[i].State = states[tests[i].MonitorID]
+			if tests[i].State != "" {
+				result.ByState[tests[i].State]++
+			}
+		}
+	}
+
+	sort.SliceStable(tests, func(i, j int) bool {
+		if ri, rj := syntheticStateRank(tests[i]), syntheticStateRank(tests[j]); ri != rj {
+			return ri < rj
+		}
+		return tests[i].Name < tests[j].Name
+	})
+	if len(tests) > limit {
+		tests, result.More = tests[:limit], true
+	}
+	if tests == nil {
+		tests = []SyntheticTest{}
+	}
+	result.Tests, result.Count = tests, len(tests)
+	return result, nil
+}
+
+// syntheticMonitorStates maps each Synthetics monitor to its status.
+func (s *MCPServer) syntheticMonitorStates() (map[int64]string, error) {
+	states := make(map[int64]string)
+	for page := int64(0); page < maxSyntheticsPages; page++ {
+		result, _, err := s.searchMonitors("type:synthetics", page, syntheticsPageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range result.Monitors {
+			states[m.ID] = m.Status
+		}
+		if len(result.Monitors) < syntheticsPageSize {
+			break
+		}
+	}
+	return states, nil
+}
+
+// syntheticStateRank orders failing tests first and paused ones last.
+func syntheticStateRank(test SyntheticTest) int {
+	switch {
+	case test.Status == "paused":
+		return 3
+	case test.State == "Alert" || test.State == "Warn":
+		return 0
+	case test.State == "OK":
+		return 2
+	}
+	return 1
+}
+
+type GetSyntheticResultsParams struct {
+	// TestID is the test's public ID, such as abc-def-ghi.
+	TestID string `json:"test_id"`
+	From   string `json:"from,omitempty"`
+	To     string `json:"to,omitempty"`
+	// Locations keeps runs from these locations, such as aws:us-east-1.
+	Locations []string `json:"locations,omitempty"`
+	Limit     int      `json:"limit,omitempty"`
+}
+
+// SyntheticResult is one run of a test from one location.
+type SyntheticResult struct {
+	ResultID string `json:"result_id"`
+	Time     string `json:"time"`
+	Location string `json:"location"`
+	Passed   bool   `json:"passed"`
+	// ResponseTimeMs is the total request time for API tests and the run
+	// duration for browser tests.
+	ResponseTimeMs float64 `json:"response_time_ms,omitempty"`
+	// Timings break an API test's request down by phase, in milliseconds.
+	Timings map[string]float64 `json:"timings,omitempty"`
+	// Failure is set on failed runs fetched in full.
+	Failure *SyntheticFailure `json:"failure,omitempty"`
+}
+
+type SyntheticFailure struct {
+	Code       string `json:"code,omitempty"`
+	Message    string `json:"message,omitempty"`
+	HTTPStatus int64  `json:"http_status,omitempty"`
+	// Steps are the browser steps that failed.
+	Steps []SyntheticStep `json:"steps,omitempty"`
+}
+
+type SyntheticStep struct {
+	Step        int     `json:"step"`
+	Description string  `json:"description,omitempty"`
+	Type        string  `json:"type,omitempty"`
+	Error       string  `json:"error,omitempty"`
+	DurationMs  float64 `json:"duration_ms,omitempty"`
+	URL         string  `json:"url,omitempty"`
+}
+
+type SyntheticResultsSummary struct {
+	Runs     int     `json:"runs"`
+	Passed   int     `json:"passed"`
+	Failed   int     `json:"failed"`
+	PassRate float64 `json:"pass_rate"`
+	// FailuresByLocation counts failed runs at each location.
+	FailuresByLocation map[string]int `json:"failures_by_location,omitempty"`
+	AvgResponseMs      float64        `json:"avg_response_ms,omitempty"`
+	P95ResponseMs      float64        `json:"p95_response_ms,omitempty"`
+}
+
+type GetSyntheticResultsResult struct {
+	TestID  string                  `json:"test_id"`
+	Name    string                  `json:"name"`
+	Type    string                  `json:"type"`
+	From    string                  `json:"from"`
+	To      string                  `json:"to"`
+	Summary SyntheticResultsSummary `json:"summary"`
+	Results []SyntheticResult       `json:"results"`
+	More    bool                    `json:"more,omitempty"`
+	URL     string                  `json:"url"`
+	Notes   []string                `json:"notes,omitempty"`
+}
+
+// GetSyntheticResults fetches a test's recent runs, newest first, with
+// why the latest failures failed.
+func (s *MCPServer) GetSyntheticResults(params GetSyntheticResultsParams) (*GetSyntheticResultsResult, error) {
+	if params.TestID == "" {
+		return nil, fmt.Errorf("test_id parameter is required")
+	}
+	limit := params.Limit
+	if limit <= 0 {
+		limit = defaultSyntheticResultsLimit
+	}
+	if limit > maxSyntheticResultsLimit {
+		return nil, fmt.Errorf("limit must be at most %d", maxSyntheticResultsLimit)
+	}
+	from, err := s.timeParam("from", params.From, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.timeParam("to", params.To, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	api := datadogV1.NewSyntheticsApi(s.ddClient)
+	test, _, err := api.GetTest(s.ctx, params.TestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get synthetic test %s: %w", params.TestID, err)
+	}
+	result := &GetSyntheticResultsResult{
+		TestID: params.TestID,
+		Name:   test.GetName(),
+		Type:   string(test.GetType()),
+		From:   from.Format(time.RFC3339),
+		To:     to.Format(time.RFC3339),
+		URL:    s.appURL("/synthetics/details/" + params.TestID),
+	}
+
+	var runs []SyntheticResult
+	switch test.GetType() {
+	case datadogV1.SYNTHETICSTESTDETAILSTYPE_API:
+		opts := datadogV1.NewGetAPITestLatestResultsOptionalParameters().WithFromTs(from.UnixMilli()).WithToTs(to.UnixMilli())
+		if len(params.Locations) > 0 {
+			opts = opts.WithProbeDc(params.Locations)
+		}
+		resp, _, err := api.GetAPITestLatestResults(s.ctx, params.TestID, *opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get synthetic results: %w", err)
+		}
+		for _, r := range resp.Results {
+			run := SyntheticResult{
+				ResultID: r.GetResultId(),
+				Time:     syntheticCheckTime(r.GetCheckTime()),
+				Location: r.GetProbeDc(),
+				Passed:   r.Result.GetPassed(),
+			}
+			if timings, ok := r.Result.GetTimingsOk(); ok {
+				run.Timings = syntheticTimings(timings)
+				run.ResponseTimeMs = timings.GetTotal()
+			}
+			runs = append(runs, run)
+		}
+	case datadogV1.SYNTHETICSTESTDETAILSTYPE_BROWSER:
+		opts := datadogV1.NewGetBrowserTestLatestResultsOptionalParameters().WithFromTs(from.UnixMilli()).WithToTs(to.UnixMilli())
+		if len(params.Locations) > 0 {
+			opts = opts.WithProbeDc(params.Locations)
+		}
+		resp, _, err := api.GetBrowserTestLatestResults(s.ctx, params.TestID, *opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get synthetic results: %w", err)
+		}
+		for _, r := range resp.Results {
+			runs = append(runs, SyntheticResult{
+				ResultID:       r.GetResultId(),
+				Time:           syntheticCheckTime(r.GetCheckTime()),
+				Location:       r.GetProbeDc(),
+				Passed:         r.Result.GetErrorCount() == 0,
+				ResponseTimeMs: r.Result.GetDuration(),
+			})
+		}
+	default:
+		return nil, fmt.Errorf("results for %s tests aren't supported; use the test's page in Datadog: %s", result.Type, result.URL)
+	}
+
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].Time > runs[j].Time })
+	result.Summary = summarizeSyntheticRuns(runs)
+	if len(runs) > limit {
+		runs, result.More = runs[:limit], true
+	}
+
+	detailed := 0
+	for i := range runs {
+		if runs[i].Passed || detailed == maxFailureDetails {
+			continue
+		}
+		detailed++
+		failure, err := s.syntheticFailure(api, test.GetType(), params.TestID, runs[i].ResultID)
+		if err != nil {
+			result.Notes = append(result.Notes, fmt.Sprintf("Couldn't fetch the failure of run %s: %v", runs[i].ResultID, err))
+			continue
+		}
+		runs[i].Failure = failure
+	}
+	if detailed == maxFailureDetails && result.Summary.Failed > detailed {
+		result.Notes = append(result.Notes, fmt.Sprintf("Failure details are included for the %d most recent failed runs only.", detailed))
+	}
+	if len(runs) == 0 {
+		result.Notes = append(result.Notes, "The test didn't run in this window.")
+	}
+	if runs == nil {
+		runs = []SyntheticResult{}
+	}
+	result.Results = runs
+	return result, nil
+}
+
+// syntheticFailure fetches one run in full and reports why it failed.
+func (s *MCPServer) syntheticFailure(api *datadogV1.SyntheticsApi, testType datadogV1.SyntheticsTestDetailsType, testID, resultID string) (*SyntheticFailure, error) {
+	if testType == datadogV1.SYNTHETICSTESTDETAILSTYPE_BROWSER {
+		full, _, err := api.GetBrowserTestResult(s.ctx, testID, resultID)
+		if err != nil {
+			return nil, err
+		}
+		data := full.GetResult()
+		failure := &SyntheticFailure{Message: data.GetError()}
+		if f, ok := data.GetFailureOk(); ok {
+			failure.Code = string(f.GetCode())
+			failure.Message = cmp.Or(f.GetMessage(), failure.Message)
+		}
+		for i, step := range data.StepDetails {
+			if step.GetError() == "" && step.Failure == nil {
+				continue
+			}
+			failure.Steps = append(failure.Steps, SyntheticStep{
+				Step:        i + 1,
+				Description: step.GetDescription(),
+				Type:        string(step.GetType()),
+				Error:       cmp.Or(step.Failure.GetMessage(), step.GetError()),
+				DurationMs:  step.GetDuration(),
+				URL:         step.GetUrl(),
+			})
+		}
+		return failure, nil
+	}
+
+	full, _, err := api.GetAPITestResult(s.ctx, testID, resultID)
+	if err != nil {
+		return nil, err
+	}
+	data := full.GetResult()
+	failure := &SyntheticFailure{HTTPStatus: data.GetHttpStatusCode()}
+	if f, ok := data.GetFailureOk(); ok {
+		failure.Code = string(f.GetCode())
+		failure.Message = f.GetMessage()
+	}
+	return failure, nil
+}
+
+// summarizeSyntheticRuns totals runs by outcome and location.
+func summarizeSyntheticRuns(runs []SyntheticResult) SyntheticResultsSummary {
+	summary := SyntheticResultsSummary{Runs: len(runs)}
+	var times []float64
+	for _, run := range runs {
+		if run.Passed {
+			summary.Passed++
+		} else {
+			summary.Failed++
+			if summary.FailuresByLocation == nil {
+				summary.FailuresByLocation = make(map[string]int)
+			}
+			summary.FailuresByLocation[run.Location]++
+		}
+		if run.ResponseTimeMs > 0 {
+			times = append(times, run.ResponseTimeMs)
+		}
+	}
+	if summary.Runs > 0 {
+		summary.PassRate = math.Round(float64(summary.Passed)/float64(summary.Runs)*1000) / 10
+	}
+	if len(times) > 0 {
+		sort.Float64s(times)
+		var total float64
+		for _, t := range times {
+			total += t
+		}
+		summary.AvgResponseMs = math.Round(total / float64(len(times)))
+		summary.P95ResponseMs = math.Round(percentileOf(times, 95))
+	}
+	return summary
+}
+
+// syntheticTimings lists the phases of an API request that took time.
+func syntheticTimings(t *datadogV1.SyntheticsTiming) map[string]float64 {
+	timings := make(map[string]float64)
+	for name, value := range map[string]*float64{
+		"dns": t.Dns, "tcp": t.Tcp, "ssl": t.Ssl, "first_byte": t.FirstByte,
+		"download": t.Download, "redirect": t.Redirect, "wait": t.Wait, "total": t.Total,
+	} {
+		if value != nil && *value > 0 {
+			timings[name] = math.Round(*value*10) / 10
+		}
+	}
+	return timings
+}
+
+// syntheticCheckTime formats a result's check time, in epoch
+// milliseconds.
+func syntheticCheckTime(ms float64) string {
+	return time.UnixMilli(int64(ms)).UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestListSyntheticTests(t *testing.T) {
+	var search string
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/synthetics/tests":
+			_, _ = w.Write([]byte(`{"tests":[
+				{"public_id":"abc-123","name":"Checkout API","type":"api","subtype":"http","status":"live","locations":["aws:us-east-1"],"tags":["env:prod"],"monitor_id":1},
+				{"public_id":"def-456","name":"Login flow","type":"browser","status":"live","locations":["aws:eu-west-1"],"tags":["env:prod"],"monitor_id":2},
+				{"public_id":"ghi-789","name":"Old check","type":"api","subtype":"ssl","status":"paused","tags":["env:prod"],"monitor_id":3},
+				{"public_id":"jkl-012","name":"Staging API","type":"api","subtype":"http","status":"live","tags":["env:staging"],"monitor_id":4}]}`))
+		case "/api/v1/monitor/search":
+			search = r.URL.Query().Get("query")
+			_, _ = w.Write([]byte(`{"monitors":[
+				{"id":1,"status":"OK"},{"id":2,"status":"Alert"},{"id":3,"status":"OK"},{"id":4,"status":"Alert"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	result, err := server.ListSyntheticTests(ListSyntheticTestsParams{Tags: []string{"env:prod"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if search != "type:synthetics" {
+		t.Fatalf("unexpected monitor search: %s", search)
+	}
+	if result.Total != 3 || result.Count != 3 || result.More {
+		t.Fatalf("unexpected counts: %+v", result)
+	}
+	if first := result.Tests[0]; first.PublicID != "def-456" || first.State != "Alert" || first.URL != "https://app.datadoghq.com/synthetics/details/def-456" {
+		t.Errorf("expected the failing test first, got %+v", first)
+	}
+	if last := result.Tests[2]; last.PublicID != "ghi-789" || len(last.Locations) != 0 || last.Locations == nil {
+		t.Errorf("expected the paused test last with empty locations, got %+v", last)
+	}
+	if result.ByState["OK"] != 2 || result.ByState["Alert"] != 1 {
+		t.Errorf("unexpected state counts: %v", result.ByState)
+	}
+
+	result, err = server.ListSyntheticTests(ListSyntheticTestsParams{Type: "api", Query: "checkout", Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Count != 1 || result.Tests[0].Subtype != "http" || result.Tests[0].Locations[0] != "aws:us-east-1" {
+		t.Errorf("unexpected filtered result: %+v", result)
+	}
+
+	if _, err := server.ListSyntheticTests(ListSyntheticTestsParams{Type: "grpc"}); err == nil {
+		t.Error("expected an unknown type to be rejected")
+	}
+}
+
+func TestGetSyntheticResults(t *testing.T) {
+	var probes []string
+	var detailed []string
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/synthetics/tests/abc-123":
+			_, _ = w.Write([]byte(`{"public_id":"abc-123","name":"Checkout API","type":"api"}`))
+		case "/api/v1/synthetics/tests/abc-123/results":
+			probes = r.URL.Query()["probe_dc"]
+			_, _ = w.Write([]byte(`{"results":[
+				{"result_id":"r1","check_time":1768903100000,"probe_dc":"aws:us-east-1","result":{"passed":true,"timings":{"dns":5,"total":120}}},
+				{"result_id":"r2","check_time":1768903200000,"probe_dc":"aws:eu-west-1","result":{"passed":false,"timings":{"total":3000}}},
+				{"result_id":"r3","check_time":1768903000000,"probe_dc":"aws:us-east-1","result":{"passed":true,"timings":{"total":180}}}]}`))
+		case "/api/v1/synthetics/tests/abc-123/results/r2":
+			detailed = append(detailed, "r2")
+			_, _ = w.Write([]byte(`{"result_id":"r2","result":{"httpStatusCode":503,"failure":{"code":"INCORRECT_ASSERTION","message":"status code is 503, expected 200"}}}`))
+		case "/api/v1/synthetics/tests/web-1":
+			_, _ = w.Write([]byte(`{"public_id":"web-1","name":"Login flow","type":"browser"}`))
+		case "/api/v1/synthetics/tests/browser/web-1/results":
+			_, _ = w.Write([]byte(`{"results":[{"result_id":"b1","check_time":1768903200000,"probe_dc":"aws:us-east-1","result":{"duration":8000,"errorCount":1}}]}`))
+		case "/api/v1/synthetics/tests/browser/web-1/results/b1":
+			_, _ = w.Write([]byte(`{"result_id":"b1","result":{"error":"Step failed","stepDetails":[
+				{"description":"Navigate to login","type":"goToUrl","duration":900},
+				{"description":"Click sign in","type":"click","duration":30000,"error":"Element not found","url":"https://example.com/login"}]}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	result, err := server.GetSyntheticResults(GetSyntheticResultsParams{TestID: "abc-123", Locations: []string{"aws:us-east-1", "aws:eu-west-1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(probes) != 2 {
+		t.Errorf("expected the locations to be sent, got %v", probes)
+	}
+	if result.Name != "Checkout API" || result.Results[0].ResultID != "r2" || result.Results[0].Time != "2026-01-20T10:00:00Z" {
+		t.Fatalf("expected the newest run first, got %+v", result)
+	}
+	failure := result.Results[0].Failure
+	if failure == nil || failure.HTTPStatus != 503 || failure.Code != "INCORRECT_ASSERTION" || len(detailed) != 1 {
+		t.Fatalf("expected the failed run's details, got %+v", failure)
+	}
+	if result.Results[1].Failure != nil || result.Results[1].Timings["dns"] != 5 {
+		t.Errorf("expected passed runs to keep their timings only, got %+v", result.Results[1])
+	}
+	summary := result.Summary
+	if summary.Runs != 3 || summary.Failed != 1 || summary.PassRate != 66.7 || summary.FailuresByLocation["aws:eu-west-1"] != 1 || summary.P95ResponseMs != 3000 || summary.AvgResponseMs != 1100 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+
+	result, err = server.GetSyntheticResults(GetSyntheticResultsParams{TestID: "web-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := result.Results[0]
+	if run.Passed || run.ResponseTimeMs != 8000 || run.Failure == nil || len(run.Failure.Steps) != 1 {
+		t.Fatalf("unexpected browser run: %+v", run)
+	}
+	if step := run.Failure.Steps[0]; step.Step != 2 || step.Error != "Element not found" || step.Type != "click" {
+		t.Errorf("unexpected failed step: %+v", step)
+	}
+
+	if _, err := server.GetSyntheticResults(GetSyntheticResultsParams{}); err == nil {
+		t.Error("expected test_id to be required")
+	}
+}
+
+func TestTriggerSyntheticTest(t *testing.T) {
+	interval := syntheticsPollInterval
+	syntheticsPollInterval = time.Millisecond
+	t.Cleanup(func() { syntheticsPollInterval = interval })
+
+	var triggered string
+	polls := 0
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/synthetics/tests/trigger":
+			body, _ := io.ReadAll(r.Body)
+			triggered = string(body)
+			_, _ = w.Write([]byte(`{"batch_id":"batch-1","results":[{"public_id":"abc-123","result_id":"r1","location":1}]}`))
+		case "/api/v1/synthetics/ci/batch/batch-1":
+			polls++
+			if polls == 1 {
+				_, _ = w.Write([]byte(`{"data":{"status":"in_progress","results":[{"test_public_id":"abc-123","test_name":"Checkout API","location":"aws:us-east-1","status":"in_progress","result_id":"r1"}]}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"data":{"status":"failed","results":[{"test_public_id":"abc-123","test_name":"Checkout API","location":"aws:us-east-1","status":"failed","duration":1200,"result_id":"r1"}]}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	if _, err := server.TriggerSyntheticTest(TriggerSyntheticTestParams{TestIDs: []string{"abc-123"}}); err == nil {
+		t.Fatal("expected the trigger to need writes enabled")
+	}
+	server.allowWrites = true
+
+	result, err := server.TriggerSyntheticTest(TriggerSyntheticTestParams{TestIDs: []string{"abc-123"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(triggered, `"public_id":"abc-123"`) {
+		t.Errorf("unexpected trigger body: %s", triggered)
+	}
+	if !result.Done || result.Status != "failed" || polls != 2 || len(result.Notes) != 0 {
+		t.Fatalf("expected to poll until the batch finished, got %+v after %d polls", result, polls)
+	}
+	run := result.Runs[0]
+	if run.Status != "failed" || run.DurationMs != 1200 || run.URL != "https://app.datadoghq.com/synthetics/details/abc-123/result/r1" {
+		t.Errorf("unexpected run: %+v", run)
+	}
+
+	result, err = server.TriggerSyntheticTest(TriggerSyntheticTestParams{TestIDs: []string{"abc-123"}, Wait: "0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Done || result.Status != "in_progress" || len(result.Runs) != 1 || len(result.Notes) != 1 || polls != 2 {
+		t.Errorf("expected to return right after triggering, got %+v", result)
+	}
+
+	if _, err := server.TriggerSyntheticTest(TriggerSyntheticTestParams{}); err == nil {
+		t.Error("expected test_ids to be required")
+	}
+
+	// Checking on a batch only reads, so it works with writes disabled.
+	server.allowWrites = false
+	polls = 0
+	result, err = server.GetSyntheticBatch(GetSyntheticBatchParams{BatchID: "batch-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Done || result.Status != "failed" || result.BatchID != "batch-1" || polls != 2 {
+		t.Errorf("expected to poll the batch until it finished, got %+v after %d polls", result, polls)
+	}
+}
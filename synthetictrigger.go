@@ -0,0 +1,243 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+)
+
+const (
+	// maxTriggeredTests bounds one trigger, which runs each test from
+	// every one of its locations.
+	maxTriggeredTests     = 10
+	defaultSyntheticsWait = 2 * time.Minute
+	maxSyntheticsWait     = 10 * time.Minute
+)
+
+// syntheticsPollInterval is how often a triggered batch is checked.
+var syntheticsPollInterval = 5 * time.Second
+
+type TriggerSyntheticTestParams struct {
+	// TestIDs are the public IDs of the tests to run.
+	TestIDs []string `json:"test_ids"`
+	// Wait is how long to wait for the runs to finish, such as "5m"; "0"
+	// returns right after triggering.
+	Wait string `json:"wait,omitempty"`
+}
+
+type GetSyntheticBatchParams struct {
+	// BatchID is the batch_id an earlier trigger returned.
+	BatchID string `json:"batch_id"`
+	// Wait is how long to wait for the runs to finish; "0" returns the
+	// batch's current status.
+	Wait string `json:"wait,omitempty"`
+}
+
+// SyntheticRun is one triggered test run from one location.
+type SyntheticRun struct {
+	TestID   string `json:"test_id"`
+	TestName string `json:"test_name,omitempty"`
+	Location string `json:"location,omitempty"`
+	// Status is in_progress, passed, failed or skipped.
+	Status     string  `json:"status"`
+	DurationMs float64 `json:"duration_ms,omitempty"`
+	ResultID   string  `json:"result_id,omitempty"`
+	URL        string  `json:"url,omitempty"`
+}
+
+type TriggerSyntheticTestResult struct {
+	BatchID string `json:"batch_id"`
+	// Status is the batch's overall status: in_progress, passed or failed.
+	Status string `json:"status"`
+	// Done is set once every run has finished.
+	Done  bool           `json:"done"`
+	Runs  []SyntheticRun `json:"runs"`
+	Notes []string       `json:"notes,omitempty"`
+}
+
+// syntheticsBatch is the CI batch status. It is read directly rather than
+// through the client library, whose model rejects runs still in progress.
+type syntheticsBatch struct {
+	Data struct {
+		Status  string `json:"status"`
+		Results []struct {
+			TestPublicID string  `json:"test_public_id"`
+			TestName     string  `json:"test_name"`
+			Location     string  `json:"location"`
+			Status       string  `json:"status"`
+			Duration     float64 `json:"duration"`
+			ResultID     string  `json:"result_id"`
+		} `json:"results"`
+	} `json:"data"`
+}
+
+// TriggerSyntheticTest runs Synthetic tests on demand, as a CI run would,
+// and waits for their results.
+func (s *MCPServer) TriggerSyntheticTest(params TriggerSyntheticTestParams) (*TriggerSyntheticTestResult, error) {
+	if err := s.requireWrites("trigger_synthetic_test"); err != nil {
+		return nil, err
+	}
+	if len(params.TestIDs) == 0 {
+		return nil, fmt.Errorf("test_ids parameter is required")
+	}
+	if len(params.TestIDs) > maxTriggeredTests {
+		return nil, fmt.Errorf("at most %d tests can be triggered at once", maxTriggeredTests)
+	}
+	wait, err := syntheticsWait(params.Wait)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TriggerSyntheticTestResult{}
+	tests := make([]datadogV1.SyntheticsTriggerTest, 0, len(params.TestIDs))
+	for _, id := range params.TestIDs {
+		tests = append(tests, *datadogV1.NewSyntheticsTriggerTest(id))
+	}
+	resp, _, err := datadogV1.NewSyntheticsApi(s.ddClient).TriggerTests(s.ctx, *datadogV1.NewSyntheticsTriggerBody(tests))
+	if err != nil {
+		return nil, fmt.Errorf("failed to trigger synthetic tests: %w", err)
+	}
+	result.BatchID = resp.GetBatchId()
+	if result.BatchID == "" {
+		return nil, fmt.Errorf("no runs were triggered; check the test IDs")
+	}
+	// The trigger response lists the runs before the batch knows about them.
+	result.Status = "in_progress"
+	for _, run := range resp.Results {
+		result.Runs = append(result.Runs, SyntheticRun{
+			TestID:   run.GetPublicId(),
+			Status:   "in_progress",
+			ResultID: run.GetResultId(),
+			URL:      s.syntheticResultURL(run.GetPublicId(), run.GetResultId()),
+		})
+	}
+
+	return s.waitForSyntheticsBatch(result, wait)
+}
+
+// GetSyntheticBatch checks on the runs of an earlier trigger, waiting for
+// them to finish. It only reads, so it needs no write access.
+func (s *MCPServer) GetSyntheticBatch(params GetSyntheticBatchParams) (*TriggerSyntheticTestResult, error) {
+	if params.BatchID == "" {
+		return nil, fmt.Errorf("batch_id parameter is required")
+	}
+	wait, err := syntheticsWait(params.Wait)
+	if err != nil {
+		return nil, err
+	}
+	result := &TriggerSyntheticTestResult{BatchID: params.BatchID}
+	if err := s.pollSyntheticsBatch(result); err != nil {
+		return nil, err
+	}
+	return s.waitForSyntheticsBatch(result, wait)
+}
+
+// syntheticsWait parses the wait argument, where "0" means not to wait.
+func syntheticsWait(value string) (time.Duration, error) {
+	if value == "0" {
+		return 0, nil
+	}
+	wait, err := parseDurationParam(value, defaultSyntheticsWait)
+	if err != nil {
+		return 0, err
+	}
+	if wait > maxSyntheticsWait {
+		return 0, fmt.Errorf("wait must be at most %s", maxSyntheticsWait)
+	}
+	return wait, nil
+}
+
+// waitForSyntheticsBatch polls the batch, reporting progress, until every
+// run has finished or wait runs out.
+func (s *MCPServer) waitForSyntheticsBatch(result *TriggerSyntheticTestResult, wait time.Duration) (*TriggerSyntheticTestResult, error) {
+	deadline := time.Now().Add(wait)
+	for !result.Done && !time.Now().Add(syntheticsPollInterval).After(deadline) {
+		finished := countFinishedRuns(result.Runs)
+		s.reportProgress(finished, len(result.Runs), fmt.Sprintf("%d of %d runs finished", finished, len(result.Runs)), "")
+		select {
+		case <-s.ctx.Done():
+			return nil, s.ctx.Err()
+		case <-time.After(syntheticsPollInterval):
+		}
+		if err := s.pollSyntheticsBatch(result); err != nil {
+			return nil, err
+		}
+	}
+
+	if !result.Done {
+		result.Notes = append(result.Notes, fmt.Sprintf("Some runs are still in progress; call get_synthetic_batch with batch_id %s to check on them.", result.BatchID))
+	}
+	if result.Runs == nil {
+		result.Runs = []SyntheticRun{}
+	}
+	return result, nil
+}
+
+// pollSyntheticsBatch refreshes result from the batch's current status.
+func (s *MCPServer) pollSyntheticsBatch(result *TriggerSyntheticTestResult) error {
+	var batch syntheticsBatch
+	if err := s.datadogGet("/api/v1/synthetics/ci/batch/"+url.PathEscape(result.BatchID), nil, &batch); err != nil {
+		return fmt.Errorf("failed to get synthetics batch %s: %w", result.BatchID, err)
+	}
+	if len(batch.Data.Results) == 0 {
+		// The batch can take a moment to appear after triggering.
+		return nil
+	}
+	result.Status = batch.Data.Status
+	result.Runs = make([]SyntheticRun, 0, len(batch.Data.Results))
+	for _, r := range batch.Data.Results {
+		result.Runs = append(result.Runs, SyntheticRun{
+			TestID:     r.TestPublicID,
+			TestName:   r.TestName,
+			Location:   r.Location,
+			Status:     r.Status,
+			DurationMs: r.Duration,
+			ResultID:   r.ResultID,
+			URL:        s.syntheticResultURL(r.TestPublicID, r.ResultID),
+		})
+	}
+	result.Done = result.Status != "in_progress" && countFinishedRuns(result.Runs) == len(result.Runs)
+	return nil
+}
+
+func (s *MCPServer) syntheticResultURL(testID, resultID string) string {
+	if resultID == "" {
+		return s.appURL("/synthetics/details/" + testID)
+	}
+	return s.appURL("/synthetics/details/" + testID + "/result/" + resultID)
+}
+
+func countFinishedRuns(runs []SyntheticRun) int {
+	finished := 0
+	for _, run := range runs {
+		if run.Status != "in_progress" {
+			finished++
+		}
+	}
+	return finished
+}
+
+// triggerSyntheticTool is registered only when writes are enabled.
+var triggerSyntheticTool = Tool{
+	Name:        "trigger_synthetic_test",
+	Description: "Run Synthetic tests on demand, as a CI run would, and wait for their results, to verify a fix before closing an incident",
+	InputSchema: InputSchema{
+		Type: "object",
+		Properties: map[string]SchemaProperty{
+			"test_ids": {
+				Type:        "array",
+				Description: "Public IDs of the tests to run (max 10)",
+				Items:       &SchemaProperty{Type: "string"},
+			},
+			"wait": {
+				Type:        "string",
+				Description: "How long to wait for the runs to finish (e.g., '5m'; max 10m, default 2m). '0' returns right after triggering.",
+			},
+			"confirm": confirmProperty,
+		},
+		Required: []string{"test_ids", "confirm"},
+	},
+	Annotations: writeToolAnnotations(false),
+}
@@ -0,0 +1,360 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+)
+
+const (
+	defaultTagViolations = 50
+	// maxTagViolations bounds the violators listed per resource type; the
+	// counts still cover every resource scanned.
+	maxTagViolations = 200
+	// maxTagFixes bounds the writes one call may make.
+	maxTagFixes = 500
+	// maxTagPolicyDashboards bounds how many dashboards are fetched one
+	// by one, since dashboard summaries don't carry tags.
+	maxTagPolicyDashboards = 200
+	maxHostPages           = 10
+	hostPageSize           = 1000
+)
+
+var tagPolicyResources = []string{"hosts", "monitors", "dashboards"}
+
+type TagPolicyParams struct {
+	RequiredKeys []string `json:"required_keys"`
+	Resources    []string `json:"resources,omitempty"`
+	Name         string   `json:"name,omitempty"`
+	Fixes        []string `json:"fixes,omitempty"`
+	Apply        bool     `json:"apply,omitempty"`
+	Confirm      bool     `json:"confirm,omitempty"`
+	Limit        int      `json:"limit,omitempty"`
+}
+
+// TagViolation is a resource missing required tag keys, with the fixes
+// planned for it or, when applying, the ones made.
+type TagViolation struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name,omitempty"`
+	URL     string   `json:"url,omitempty"`
+	Missing []string `json:"missing"`
+	Planned []string `json:"planned,omitempty"`
+	Fixed   []string `json:"fixed,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+type TagCompliance struct {
+	Scanned   int     `json:"scanned"`
+	Compliant int     `json:"compliant"`
+	Percent   float64 `json:"percent_compliant"`
+	// Checked are the required keys that apply to this resource type.
+	Checked      []string       `json:"checked"`
+	MissingByKey map[string]int `json:"missing_by_key,omitempty"`
+	Violations   []TagViolation `json:"violations"`
+	Omitted      int            `json:"omitted,omitempty"`
+}
+
+type TagPolicyResult struct {
+	RequiredKeys []string                  `json:"required_keys"`
+	DryRun       bool                      `json:"dry_run"`
+	Resources    map[string]*TagCompliance `json:"resources"`
+	Planned      int                       `json:"planned"`
+	Applied      int                       `json:"applied"`
+	Failed       int                       `json:"failed"`
+	Notes        []string                  `json:"notes,omitempty"`
+}
+
+// taggedResource is anything the policy is checked against. fix adds
+// tags to it.
+type taggedResource struct {
+	ID   string
+	Name string
+	URL  string
+	Tags []string
+	fix  func(tags []string) error
+}
+
+// AuditTagPolicy checks hosts, monitors and dashboards for required tag
+// keys and reports compliance. Fixes give a value for a missing key; they
+// are only planned unless Apply is set, which needs writes enabled and
+// Confirm.
+func (s *MCPServer) AuditTagPolicy(params TagPolicyParams) (*TagPolicyResult, error) {
+	keys, err := normalizeTagKeys(params.RequiredKeys)
+	if err != nil {
+		return nil, err
+	}
+	resources := params.Resources
+	if len(resources) == 0 {
+		resources = tagPolicyResources
+	}
+	for _, r := range resources {
+		if !slices.Contains(tagPolicyResources, r) {
+			return nil, fmt.Errorf("invalid resource: %s (use %s)", r, strings.Join(tagPolicyResources, ", "))
+		}
+	}
+	fixes := make(map[string]string)
+	for _, fix := range params.Fixes {
+		key, value, ok := strings.Cut(strings.TrimSpace(fix), ":")
+		key = strings.ToLower(key)
+		if !ok || key == "" || value == "" {
+			return nil, fmt.Errorf("invalid fix: %q (use key:value)", fix)
+		}
+		if !slices.Contains(keys, key) {
+			return nil, fmt.Errorf("fix %q is for %s, which isn't a required key", fix, key)
+		}
+		fixes[key] = key + ":" + value
+	}
+	if params.Apply {
+		if err := s.requireWrites("audit_tag_policy"); err != nil {
+			return nil, err
+		}
+		if !params.Confirm {
+			return nil, fmt.Errorf("apply requires confirm: true")
+		}
+		if len(fixes) == 0 {
+			return nil, fmt.Errorf("apply requires at least one fix")
+		}
+	}
+	limit := params.Limit
+	if limit <= 0 {
+		limit = defaultTagViolations
+	}
+	limit = clampArgument(s, "limit", limit, maxTagViolations)
+
+	result := &TagPolicyResult{RequiredKeys: keys, DryRun: !params.Apply, Resources: make(map[string]*TagCompliance)}
+	for _, kind := range resources {
+		if _, done := result.Resources[kind]; done {
+			continue
+		}
+		checked := keys
+		if kind == "dashboards" {
+			// Dashboards only accept team: tags.
+			checked = nil
+			if slices.Contains(keys, "team") {
+				checked = []string{"team"}
+			}
+			if len(checked) < len(keys) {
+				result.Notes = append(result.Notes, "Dashboards only support team tags, so only the team key is checked on them.")
+			}
+			if len(checked) == 0 {
+				continue
+			}
+		}
+
+		items, err := s.taggedResources(kind, params.Name, result)
+		if err != nil {
+			return nil, err
+		}
+		compliance := &TagCompliance{Scanned: len(items), Checked: checked, Violations: make([]TagViolation, 0)}
+		for _, item := range items {
+			missing := missingTagKeys(item.Tags, checked)
+			if len(missing) == 0 {
+				compliance.Compliant++
+				continue
+			}
+			if compliance.MissingByKey == nil {
+				compliance.MissingByKey = make(map[string]int)
+			}
+			for _, key := range missing {
+				compliance.MissingByKey[key]++
+			}
+
+			violation := TagViolation{ID: item.ID, Name: item.Name, URL: item.URL, Missing: missing}
+			for _, key := range missing {
+				if tag, ok := fixes[key]; ok {
+					violation.Planned = append(violation.Planned, tag)
+				}
+			}
+			result.Planned += len(violation.Planned)
+			if params.Apply && len(violation.Planned) > 0 {
+				switch {
+				case result.Applied+result.Failed >= maxTagFixes:
+					violation.Error = fmt.Sprintf("not applied: the %d-write limit for one call was reached", maxTagFixes)
+				default:
+					if err := item.fix(violation.Planned); err != nil {
+						violation.Error = err.Error()
+						result.Failed++
+					} else {
+						violation.Fixed, violation.Planned = violation.Planned, nil
+						result.Applied++
+					}
+				}
+			}
+			if len(compliance.Violations) < limit {
+				compliance.Violations = append(compliance.Violations, violation)
+			} else {
+				compliance.Omitted++
+			}
+		}
+		if compliance.Scanned > 0 {
+			compliance.Percent = float64(int(float64(compliance.Compliant)/float64(compliance.Scanned)*1000)) / 10
+		}
+		result.Resources[kind] = compliance
+	}
+
+	if result.DryRun && result.Planned > 0 {
+		result.Notes = append(result.Notes, "Dry run: no tags were changed. Re-run with apply: true and confirm: true to add the planned tags.")
+	}
+	return result, nil
+}
+
+// taggedResources lists one kind of resource with its tags, keeping those
+// whose name contains name.
+func (s *MCPServer) taggedResources(kind, name string, result *TagPolicyResult) ([]taggedResource, error) {
+	matches := func(n string) bool {
+		return name == "" || strings.Contains(strings.ToLower(n), strings.ToLower(name))
+	}
+	var items []taggedResource
+	switch kind {
+	case "hosts":
+		hosts, truncated, err := s.listAllHosts()
+		if err != nil {
+			return nil, err
+		}
+		if truncated {
+			result.Notes = append(result.Notes, fmt.Sprintf("Only the first %d hosts were scanned.", len(hosts)))
+		}
+		api := datadogV1.NewTagsApi(s.ddClient)
+		for _, h := range hosts {
+			host := h.GetHostName()
+			if host == "" || !matches(host) {
+				continue
+			}
+			var tags []string
+			for _, sourceTags := range h.TagsBySource {
+				tags = append(tags, sourceTags...)
+			}
+			items = append(items, taggedResource{
+				ID:   host,
+				Name: host,
+				URL:  s.appURL("/infrastructure?host=" + host),
+				Tags: tags,
+				fix: func(add []string) error {
+					_, _, err := api.CreateHostTags(s.ctx, host, datadogV1.HostTags{Host: &host, Tags: add})
+					return err
+				},
+			})
+		}
+
+	case "monitors":
+		monitors, truncated, err := s.listAllMonitors()
+		if err != nil {
+			return nil, err
+		}
+		if truncated {
+			result.Notes = append(result.Notes, fmt.Sprintf("Only the first %d monitors were scanned.", len(monitors)))
+		}
+		api := datadogV1.NewMonitorsApi(s.ddClient)
+		for _, m := range monitors {
+			if !matches(m.GetName()) {
+				continue
+			}
+			id, tags := m.GetId(), m.Tags
+			items = append(items, taggedResource{
+				ID:   fmt.Sprint(id),
+				Name: m.GetName(),
+				URL:  s.appURL(fmt.Sprintf("/monitors/%d", id)),
+				Tags: tags,
+				fix: func(add []string) error {
+					_, _, err := api.UpdateMonitor(s.ctx, id, datadogV1.MonitorUpdateRequest{Tags: append(append([]string(nil), tags...), add...)})
+					return err
+				},
+			})
+		}
+
+	case "dashboards":
+		summaries, truncated, err := s.listAllDashboards()
+		if err != nil {
+			return nil, err
+		}
+		if truncated {
+			result.Notes = append(result.Notes, fmt.Sprintf("Only the first %d dashboards were listed.", len(summaries)))
+		}
+		api := datadogV1.NewDashboardsApi(s.ddClient)
+		fetched := 0
+		for _, summary := range summaries {
+			if !matches(summary.GetTitle()) {
+				continue
+			}
+			if fetched == maxTagPolicyDashboards {
+				result.Notes = append(result.Notes, fmt.Sprintf("Only the first %d matching dashboards were checked; pass name to narrow the scan.", maxTagPolicyDashboards))
+				break
+			}
+			fetched++
+			dashboard, _, err := api.GetDashboard(s.ctx, summary.GetId())
+			if err != nil {
+				return nil, fmt.Errorf("failed to get dashboard %s: %w", summary.GetId(), err)
+			}
+			id := summary.GetId()
+			items = append(items, taggedResource{
+				ID:   id,
+				Name: summary.GetTitle(),
+				URL:  s.appURL(summary.GetUrl()),
+				Tags: dashboard.GetTags(),
+				fix: func(add []string) error {
+					dashboard.SetTags(append(dashboard.GetTags(), add...))
+					_, _, err := api.UpdateDashboard(s.ctx, id, dashboard)
+					return err
+				},
+			})
+		}
+	}
+	return items, nil
+}
+
+// listAllHosts pages through the infrastructure host list.
+func (s *MCPServer) listAllHosts() ([]datadogV1.Host, bool, error) {
+	api := datadogV1.NewHostsApi(s.ddClient)
+	var hosts []datadogV1.Host
+	for page := int64(0); page < maxHostPages; page++ {
+		opts := datadogV1.NewListHostsOptionalParameters().WithStart(page * hostPageSize).WithCount(hostPageSize)
+		resp, _, err := api.ListHosts(s.ctx, *opts)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to list hosts: %w", err)
+		}
+		hosts = append(hosts, resp.HostList...)
+		if len(resp.HostList) < hostPageSize {
+			return hosts, false, nil
+		}
+	}
+	return hosts, true, nil
+}
+
+func normalizeTagKeys(keys []string) ([]string, error) {
+	var normalized []string
+	for _, key := range keys {
+		key = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(key), ":"))
+		if key == "" || strings.Contains(key, ":") {
+			return nil, fmt.Errorf("invalid required key: %q", key)
+		}
+		if !slices.Contains(normalized, key) {
+			normalized = append(normalized, key)
+		}
+	}
+	if len(normalized) == 0 {
+		return nil, fmt.Errorf("required_keys parameter is required")
+	}
+	return normalized, nil
+}
+
+// missingTagKeys returns the keys with no key:value tag (or bare key tag)
+// in tags.
+func missingTagKeys(tags, keys []string) []string {
+	present := make(map[string]bool)
+	for _, tag := range tags {
+		key, _, _ := strings.Cut(tag, ":")
+		present[strings.ToLower(key)] = true
+	}
+	var missing []string
+	for _, key := range keys {
+		if !present[key] {
+			missing = append(missing, key)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestMissingTagKeys(t *testing.T) {
+	got := missingTagKeys([]string{"Env:prod", "team", "version:1"}, []string{"env", "service", "team"})
+	if !reflect.DeepEqual(got, []string{"service"}) {
+		t.Fatalf("unexpected missing keys: %v", got)
+	}
+}
+
+func TestAuditTagPolicy(t *testing.T) {
+	var mu sync.Mutex
+	writes := make(map[string][]string)
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != http.MethodGet {
+			var body struct {
+				Tags []string `json:"tags"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			mu.Lock()
+			writes[r.Method+" "+r.URL.Path] = body.Tags
+			mu.Unlock()
+		}
+		switch r.URL.Path {
+		case "/api/v1/hosts":
+			_, _ = w.Write([]byte(`{"host_list":[
+				{"host_name":"web-1","tags_by_source":{"Datadog":["env:prod"],"Users":["team:web"]}},
+				{"host_name":"web-2","tags_by_source":{"Datadog":["env:prod"]}}],"total_returned":2}`))
+		case "/api/v1/tags/hosts/web-2":
+			_, _ = w.Write([]byte(`{"host":"web-2","tags":["team:web"]}`))
+		case "/api/v1/monitor":
+			_, _ = w.Write([]byte(`[{"id":1,"name":"CPU","type":"metric alert","query":"q","tags":["service:api"]}]`))
+		case "/api/v1/monitor/1":
+			_, _ = w.Write([]byte(`{"id":1,"name":"CPU","type":"metric alert","query":"q"}`))
+		case "/api/v1/dashboard":
+			_, _ = w.Write([]byte(`{"dashboards":[{"id":"abc-123","title":"Web","url":"/dashboard/abc-123/web"}]}`))
+		case "/api/v1/dashboard/abc-123":
+			_, _ = w.Write([]byte(`{"id":"abc-123","title":"Web","layout_type":"ordered","widgets":[],"tags":["team:web"]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	params := TagPolicyParams{RequiredKeys: []string{"env", "team"}, Fixes: []string{"team:web"}}
+	result, err := server.AuditTagPolicy(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hosts := result.Resources["hosts"]
+	if hosts.Scanned != 2 || hosts.Compliant != 1 || hosts.Percent != 50 || len(hosts.Violations) != 1 || hosts.Violations[0].ID != "web-2" {
+		t.Fatalf("unexpected host compliance: %+v", hosts)
+	}
+	if !reflect.DeepEqual(hosts.Violations[0].Planned, []string{"team:web"}) {
+		t.Fatalf("expected a planned team tag, got %+v", hosts.Violations[0])
+	}
+	monitors := result.Resources["monitors"]
+	if monitors.Compliant != 0 || !reflect.DeepEqual(monitors.Violations[0].Missing, []string{"env", "team"}) || monitors.MissingByKey["env"] != 1 {
+		t.Fatalf("unexpected monitor compliance: %+v", monitors)
+	}
+	if dashboards := result.Resources["dashboards"]; dashboards.Compliant != 1 || !reflect.DeepEqual(dashboards.Checked, []string{"team"}) {
+		t.Fatalf("expected dashboards checked for team only, got %+v", dashboards)
+	}
+	if !result.DryRun || result.Planned != 2 || len(writes) != 0 {
+		t.Fatalf("expected a dry run planning two fixes, got %+v, writes %v", result, writes)
+	}
+
+	params.Apply = true
+	params.Confirm = true
+	if _, err := server.AuditTagPolicy(params); err == nil || !strings.Contains(err.Error(), "DD_MCP_ALLOW_WRITES") {
+		t.Fatalf("expected apply to be refused without writes, got %v", err)
+	}
+
+	server.allowWrites = true
+	result, err = server.AuditTagPolicy(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.DryRun || result.Applied != 2 || result.Failed != 0 {
+		t.Fatalf("expected two fixes applied, got %+v", result)
+	}
+	if got := writes["POST /api/v1/tags/hosts/web-2"]; !reflect.DeepEqual(got, []string{"team:web"}) {
+		t.Fatalf("unexpected host tags written: %v", writes)
+	}
+	if got := writes["PUT /api/v1/monitor/1"]; !reflect.DeepEqual(got, []string{"service:api", "team:web"}) {
+		t.Fatalf("expected the monitor to keep its tags, got %v", writes)
+	}
+
+	params.Confirm = false
+	if _, err := server.AuditTagPolicy(params); err == nil {
+		t.Fatal("expected apply without confirm to be rejected")
+	}
+	if _, err := server.AuditTagPolicy(TagPolicyParams{RequiredKeys: []string{"env"}, Fixes: []string{"team:web"}}); err == nil {
+		t.Fatal("expected a fix for a key outside the policy to be rejected")
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV2"
+)
+
+const (
+	defaultTeamsPerPage = 50
+	maxTeamsPerPage     = 100
+	// teamMembersPageSize and maxTeamMemberPages bound how many members
+	// get_team lists.
+	teamMembersPageSize = 100
+	maxTeamMemberPages  = 5
+)
+
+type ListTeamsParams struct {
+	// Query matches team names and handles.
+	Query string `json:"query,omitempty"`
+	// Mine keeps the teams the key's user belongs to.
+	Mine    bool  `json:"mine,omitempty"`
+	Page    int64 `json:"page,omitempty"`
+	PerPage int64 `json:"per_page,omitempty"`
+}
+
+type TeamLinkSummary struct {
+	Label string `json:"label"`
+	URL   string `json:"url"`
+}
+
+type TeamSummary struct {
+	ID          string            `json:"id"`
+	Handle      string            `json:"handle"`
+	Name        string            `json:"name"`
+	Summary     string            `json:"summary,omitempty"`
+	MemberCount int32             `json:"member_count"`
+	Links       []TeamLinkSummary `json:"links,omitempty"`
+}
+
+type ListTeamsResult struct {
+	Teams []TeamSummary `json:"teams"`
+	// Total counts the teams matching the filters on every page.
+	Total     int64    `json:"total"`
+	Page      int64    `json:"page"`
+	PageCount int64    `json:"page_count"`
+	PerPage   int64    `json:"per_page"`
+	URL       string   `json:"url"`
+	Notes     []string `json:"notes,omitempty"`
+}
+
+type GetTeamParams struct {
+	// Team is a team's handle or ID.
+	Team string `json:"team"`
+}
+
+type TeamMember struct {
+	UserID string `json:"user_id"`
+	Name   string `json:"name,omitempty"`
+	Email  string `json:"email,omitempty"`
+	Handle string `json:"handle,omitempty"`
+	// Role is "admin" for team admins and unset for other members.
+	Role string `json:"role,omitempty"`
+}
+
+// TeamDetail is a team with its members and links, such as its runbooks,
+// repositories and chat channels.
+type TeamDetail struct {
+	ID          string            `json:"id"`
+	Handle      string            `json:"handle"`
+	Name        string            `json:"name"`
+	Summary     string            `json:"summary,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Created     string            `json:"created,omitempty"`
+	MemberCount int32             `json:"member_count"`
+	Members     []TeamMember      `json:"members"`
+	Links       []TeamLinkSummary `json:"links"`
+	Notes       []string          `json:"notes,omitempty"`
+}
+
+// ListTeams lists the org's teams with their handles and links, a page at
+// a time, so an alert can be routed to the team that owns it.
+func (s *MCPServer) ListTeams(params ListTeamsParams) (*ListTeamsResult, error) {
+	perPage := params.PerPage
+	if perPage <= 0 {
+		perPage = defaultTeamsPerPage
+	}
+	perPage = clampArgument(s, "per_page", perPage, maxTeamsPerPage)
+	if params.Page < 0 {
+		return nil, fmt.Errorf("page must not be negative")
+	}
+
+	opts := datadogV2.NewListTeamsOptionalParameters().
+		WithPageNumber(params.Page).
+		WithPageSize(perPage).
+		WithSort(datadogV2.LISTTEAMSSORT_NAME).
+		WithInclude([]datadogV2.ListTeamsInclude{datadogV2.LISTTEAMSINCLUDE_TEAM_LINKS})
+	if query := strings.TrimSpace(params.Query); query != "" {
+		opts = opts.WithFilterKeyword(query)
+	}
+	if params.Mine {
+		opts = opts.WithFilterMe(true)
+	}
+	resp, _, err := datadogV2.NewTeamsApi(s.ddClient).ListTeams(s.ctx, *opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list teams: %w", err)
+	}
+
+	links := make(map[string]TeamLinkSummary)
+	for _, item := range resp.Included {
+		if link := item.TeamLink; link != nil {
+			links[link.GetId()] = TeamLinkSummary{Label: link.Attributes.GetLabel(), URL: link.Attributes.GetUrl()}
+		}
+	}
+
+	result := &ListTeamsResult{
+		Teams:   make([]TeamSummary, 0, len(resp.Data)),
+		Page:    params.Page,
+		PerPage: perPage,
+		URL:     s.appURL("/teams"),
+	}
+	for _, team := range resp.Data {
+		summary := TeamSummary{
+			ID:          team.GetId(),
+			Handle:      team.Attributes.GetHandle(),
+			Name:        team.Attributes.GetName(),
+			Summary:     team.Attributes.GetSummary(),
+			MemberCount: team.Attributes.GetUserCount(),
+		}
+		if rel := team.Relationships; rel != nil && rel.TeamLinks != nil {
+			for _, ref := range rel.TeamLinks.Data {
+				if link, ok := links[ref.GetId()]; ok {
+					summary.Links = append(summary.Links, link)
+				}
+			}
+		}
+		result.Teams = append(result.Teams, summary)
+	}
+	if meta := resp.Meta; meta != nil && meta.Pagination != nil {
+		result.Total = meta.Pagination.GetTotal()
+	}
+	result.PageCount = (result.Total + perPage - 1) / perPage
+
+	if result.PageCount > params.Page+1 {
+		result.Notes = append(result.Notes, fmt.Sprintf("This is page %d of %d (pages start at 0); pass page=%d for more.", params.Page, result.PageCount, params.Page+1))
+	}
+	return result, nil
+}
+
+// GetTeam returns a team, found by handle or ID, with its members and
+// links.
+func (s *MCPServer) GetTeam(params GetTeamParams) (*TeamDetail, error) {
+	ref := strings.TrimPrefix(strings.TrimSpace(params.Team), "@")
+	if ref == "" {
+		return nil, fmt.Errorf("team is required")
+	}
+	api := datadogV2.NewTeamsApi(s.ddClient)
+	team, err := s.findTeam(api, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &TeamDetail{
+		ID:          team.GetId(),
+		Handle:      team.Attributes.GetHandle(),
+		Name:        team.Attributes.GetName(),
+		Summary:     team.Attributes.GetSummary(),
+		Description: team.Attributes.GetDescription(),
+		Created:     formatOptionalTime(team.Attributes.CreatedAt),
+		MemberCount: team.Attributes.GetUserCount(),
+		Members:     []TeamMember{},
+		Links:       []TeamLinkSummary{},
+	}
+
+	links, _, err := api.GetTeamLinks(s.ctx, detail.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get team links: %w", err)
+	}
+	slices.SortStableFunc(links.Data, func(a, b datadogV2.TeamLink) int {
+		return cmp.Compare(a.Attributes.GetPosition(), b.Attributes.GetPosition())
+	})
+	for _, link := range links.Data {
+		detail.Links = append(detail.Links, TeamLinkSummary{Label: link.Attributes.GetLabel(), URL: link.Attributes.GetUrl()})
+	}
+
+	for page := int64(0); page < maxTeamMemberPages; page++ {
+		resp, _, err := api.GetTeamMemberships(s.ctx, detail.ID, *datadogV2.NewGetTeamMembershipsOptionalParameters().
+			WithPageNumber(page).WithPageSize(teamMembersPageSize).WithSort(datadogV2.GETTEAMMEMBERSHIPSSORT_NAME))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get team members: %w", err)
+		}
+		users := make(map[string]*datadogV2.User)
+		for _, item := range resp.Included {
+			if item.User != nil {
+				users[item.User.GetId()] = item.User
+			}
+		}
+		for _, membership := range resp.Data {
+			member := TeamMember{}
+			if rel := membership.Relationships; rel != nil && rel.User != nil {
+				member.UserID = rel.User.Data.GetId()
+			}
+			if attrs := membership.Attributes; attrs != nil {
+				if role := attrs.Role.Get(); role != nil {
+					member.Role = string(*role)
+				}
+			}
+			if user := users[member.UserID]; user != nil && user.Attributes != nil {
+				member.Name = user.Attributes.GetName()
+				member.Email = user.Attributes.GetEmail()
+				member.Handle = user.Attributes.GetHandle()
+			}
+			detail.Members = append(detail.Members, member)
+		}
+		if len(resp.Data) < teamMembersPageSize {
+			break
+		}
+	}
+
+	if int(detail.MemberCount) > len(detail.Members) {
+		detail.Notes = append(detail.Notes, fmt.Sprintf("Only the first %d of %d members are listed.", len(detail.Members), detail.MemberCount))
+	}
+	if len(detail.Members) > 0 && !slices.ContainsFunc(detail.Members, func(m TeamMember) bool { return m.Role == "admin" }) {
+		detail.Notes = append(detail.Notes, "The team has no admin; nobody on it can manage its members or links.")
+	}
+	return detail, nil
+}
+
+// findTeam looks a team up by handle, or by ID when no handle matches.
+func (s *MCPServer) findTeam(api *datadogV2.TeamsApi, ref string) (*datadogV2.Team, error) {
+	resp, _, err := api.ListTeams(s.ctx, *datadogV2.NewListTeamsOptionalParameters().
+		WithFilterKeyword(ref).WithPageSize(maxTeamsPerPage))
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up team %s: %w", ref, err)
+	}
+	for i, team := range resp.Data {
+		if strings.EqualFold(team.Attributes.GetHandle(), ref) || team.GetId() == ref {
+			return &resp.Data[i], nil
+		}
+	}
+
+	byID, httpResp, err := api.GetTeam(s.ctx, ref)
+	if err != nil {
+		if httpStatus(httpResp) == http.StatusNotFound {
+			return nil, fmt.Errorf("no team with handle or ID %s; use list_teams to find it", ref)
+		}
+		return nil, fmt.Errorf("failed to get team %s: %w", ref, err)
+	}
+	if byID.Data == nil {
+		return nil, fmt.Errorf("no team with handle or ID %s; use list_teams to find it", ref)
+	}
+	return byID.Data, nil
+}
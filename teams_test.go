@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestListTeams(t *testing.T) {
+	var query string
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		query = r.URL.RawQuery
+		_, _ = w.Write([]byte(`{
+			"data":[
+				{"id":"t1","type":"team","attributes":{"handle":"payments","name":"Payments","summary":"Checkout and billing","user_count":6},
+				 "relationships":{"team_links":{"data":[{"id":"l1","type":"team_links"}]}}},
+				{"id":"t2","type":"team","attributes":{"handle":"payments-infra","name":"Payments Infra","user_count":2}}],
+			"included":[{"id":"l1","type":"team_links","attributes":{"label":"Runbook","url":"https://wiki.example.com/payments"}}],
+			"meta":{"pagination":{"total":3}}}`))
+	})
+
+	result, err := server.ListTeams(ListTeamsParams{Query: "payments", PerPage: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"filter%5Bkeyword%5D=payments", "include=team_links", "page%5Bsize%5D=2"} {
+		if !strings.Contains(query, want) {
+			t.Fatalf("expected %s in the request, got %s", want, query)
+		}
+	}
+	if len(result.Teams) != 2 || result.Total != 3 || result.PageCount != 2 || len(result.Notes) != 1 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if team := result.Teams[0]; team.Handle != "payments" || team.MemberCount != 6 || len(team.Links) != 1 || team.Links[0].Label != "Runbook" {
+		t.Fatalf("unexpected team %+v", team)
+	}
+}
+
+func TestGetTeam(t *testing.T) {
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v2/team":
+			_, _ = w.Write([]byte(`{"data":[
+				{"id":"t2","type":"team","attributes":{"handle":"payments-infra","name":"Payments Infra","user_count":1}},
+				{"id":"t1","type":"team","attributes":{"handle":"payments","name":"Payments","user_count":2}}]}`))
+		case "/api/v2/team/t1/links":
+			_, _ = w.Write([]byte(`{"data":[
+				{"id":"l2","type":"team_links","attributes":{"label":"Slack","url":"https://example.slack.com/archives/C1","position":1}},
+				{"id":"l1","type":"team_links","attributes":{"label":"Runbook","url":"https://wiki.example.com/payments","position":0}}]}`))
+		case "/api/v2/team/t1/memberships":
+			_, _ = w.Write([]byte(`{
+				"data":[
+					{"id":"m1","type":"team_memberships","attributes":{"role":"admin"},"relationships":{"user":{"data":{"id":"u1","type":"users"}}}},
+					{"id":"m2","type":"team_memberships","attributes":{"role":null},"relationships":{"user":{"data":{"id":"u2","type":"users"}}}}],
+				"included":[
+					{"id":"u1","type":"users","attributes":{"name":"Ana","email":"ana@example.com","handle":"ana@example.com"}},
+					{"id":"u2","type":"users","attributes":{"name":"Bo","email":"bo@example.com","handle":"bo@example.com"}}]}`))
+		case "/api/v2/team/missing":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":["Not found"]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	team, err := server.GetTeam(GetTeamParams{Team: "@Payments"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if team.ID != "t1" || len(team.Links) != 2 || team.Links[0].Label != "Runbook" {
+		t.Fatalf("expected the exact handle with links in order, got %+v", team)
+	}
+	if len(team.Members) != 2 || team.Members[0].Role != "admin" || team.Members[0].Email != "ana@example.com" || team.Members[1].Role != "" {
+		t.Fatalf("unexpected members %+v", team.Members)
+	}
+	if len(team.Notes) != 0 {
+		t.Fatalf("expected no notes, got %v", team.Notes)
+	}
+
+	if _, err := server.GetTeam(GetTeamParams{Team: "missing"}); err == nil || !strings.Contains(err.Error(), "no team with handle or ID missing") {
+		t.Fatalf("expected an unknown team to be reported, got %v", err)
+	}
+}
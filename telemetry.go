@@ -0,0 +1,306 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	otelprom "go.opentelemetry.io/otel/exporters/prometheus"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/kmesiab/go-dd-mcp"
+
+// instruments are recorded through the global OpenTelemetry providers,
+// which are no-ops until setupTelemetry installs exporting ones.
+type instruments struct {
+	tracer             trace.Tracer
+	meter              metric.Meter
+	requests           metric.Int64Counter
+	requestDuration    metric.Float64Histogram
+	apiCalls           metric.Int64Counter
+	apiDuration        metric.Float64Histogram
+	rateLimitRemaining metric.Int64Gauge
+}
+
+func newInstruments(tracer trace.Tracer, meter metric.Meter) *instruments {
+	inst := &instruments{tracer: tracer, meter: meter}
+
+	// Instrument creation only fails for invalid names, which are constant
+	// here, so errors are deliberately ignored.
+	inst.requests, _ = meter.Int64Counter("mcp.server.requests",
+		metric.WithDescription("MCP requests handled, by method, tool and outcome"))
+	inst.requestDuration, _ = meter.Float64Histogram("mcp.server.request.duration",
+		metric.WithDescription("Time to handle an MCP request"), metric.WithUnit("s"))
+	inst.apiCalls, _ = meter.Int64Counter("mcp.datadog.api.calls",
+		metric.WithDescription("Calls made to the Datadog API, by endpoint and status"))
+	inst.apiDuration, _ = meter.Float64Histogram("mcp.datadog.api.duration",
+		metric.WithDescription("Latency of Datadog API calls"), metric.WithUnit("s"))
+	inst.rateLimitRemaining, _ = meter.Int64Gauge("mcp.datadog.ratelimit.remaining",
+		metric.WithDescription("Requests left in the current Datadog rate limit period, by rate limit name"))
+	return inst
+}
+
+// observeResultStore reports the result store's size and hit counts on
+// every collection.
+func (inst *instruments) observeResultStore(store *resultStore) error {
+	hits, _ := inst.meter.Int64ObservableCounter("mcp.result_store.hits",
+		metric.WithDescription("Continuation lookups that found a stored result"))
+	misses, _ := inst.meter.Int64ObservableCounter("mcp.result_store.misses",
+		metric.WithDescription("Continuation lookups for unknown or evicted results"))
+	evictions, _ := inst.meter.Int64ObservableCounter("mcp.result_store.evictions",
+		metric.WithDescription("Stored results evicted to stay within the byte budget"))
+	size, _ := inst.meter.Int64ObservableGauge("mcp.result_store.size",
+		metric.WithDescription("Bytes held by stored results"), metric.WithUnit("By"))
+
+	_, err := inst.meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		stats := store.snapshot()
+		o.ObserveInt64(hits, int64(stats.Hits))
+		o.ObserveInt64(misses, int64(stats.Misses))
+		o.ObserveInt64(evictions, int64(stats.Evictions))
+		o.ObserveInt64(size, int64(stats.Bytes))
+		return nil
+	}, hits, misses, evictions, size)
+	return err
+}
+
+// telemetryEnabled reports whether OTLP export was requested, either
+// explicitly or by configuring the standard OTLP endpoint variables.
+func telemetryEnabled() bool {
+	if enabled, err := strconv.ParseBool(os.Getenv("DD_MCP_OTEL_ENABLED")); err == nil {
+		return enabled
+	}
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" ||
+		os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != "" ||
+		os.Getenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT") != ""
+}
+
+// prometheusEnabled reports whether /metrics should be served in HTTP mode.
+func prometheusEnabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("DD_MCP_PROMETHEUS_ENABLED"))
+	return enabled
+}
+
+// telemetrySetup holds what setupTelemetry installed: a function that flushes
+// and stops the exporters, and the Prometheus handler when requested.
+type telemetrySetup struct {
+	shutdown       func(context.Context) error
+	metricsHandler http.Handler
+}
+
+// setupTelemetry installs the requested exporters as the global providers.
+// OTLP/HTTP endpoints, headers and sampling come from the standard OTEL_*
+// environment variables. Prometheus only needs a meter reader; traces are
+// exported over OTLP alone.
+func setupTelemetry(ctx context.Context, otlp, prom bool) (*telemetrySetup, error) {
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		semconv.ServiceName("datadog-mcp-server"),
+		semconv.ServiceVersion(serverVersion),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build telemetry resource: %w", err)
+	}
+
+	var shutdowns []func(context.Context) error
+	meterOptions := []sdkmetric.Option{sdkmetric.WithResource(res)}
+	setup := &telemetrySetup{}
+
+	if otlp {
+		traceExporter, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+		}
+		tracerProvider := sdktrace.NewTracerProvider(
+			sdktrace.WithBatcher(traceExporter),
+			sdktrace.WithResource(res),
+		)
+		otel.SetTracerProvider(tracerProvider)
+		shutdowns = append(shutdowns, tracerProvider.Shutdown)
+
+		metricExporter, err := otlpmetrichttp.New(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP metric exporter: %w", err)
+		}
+		meterOptions = append(meterOptions, sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter)))
+	}
+
+	if prom {
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+		promExporter, err := otelprom.New(otelprom.WithRegisterer(registry))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Prometheus exporter: %w", err)
+		}
+		meterOptions = append(meterOptions, sdkmetric.WithReader(promExporter))
+		setup.metricsHandler = promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	}
+
+	meterProvider := sdkmetric.NewMeterProvider(meterOptions...)
+	otel.SetMeterProvider(meterProvider)
+	shutdowns = append(shutdowns, meterProvider.Shutdown)
+
+	setup.shutdown = func(ctx context.Context) error {
+		var errs []error
+		for _, shutdown := range shutdowns {
+			errs = append(errs, shutdown(ctx))
+		}
+		return errors.Join(errs...)
+	}
+	return setup, nil
+}
+
+// toolName extracts the tool name from a tools/call request for labeling.
+func toolName(req MCPRequest) string {
+	if req.Method != "tools/call" {
+		return ""
+	}
+	var params ToolCallParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return ""
+	}
+	return params.Name
+}
+
+// traceRequest wraps handling of one MCP request in a span and records the
+// request metrics. The span is carried on the server's context so Datadog
+// calls made while handling the request become its children.
+func (s *MCPServer) traceRequest(req MCPRequest, handle func(*MCPServer) MCPResponse) MCPResponse {
+	if s.telemetry == nil {
+		return handle(s)
+	}
+
+	attrs := []attribute.KeyValue{attribute.String("rpc.method", req.Method)}
+	if tool := toolName(req); tool != "" {
+		attrs = append(attrs, attribute.String("mcp.tool", tool))
+	}
+
+	parent := s.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, span := s.telemetry.tracer.Start(parent, "mcp "+req.Method,
+		trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attrs...))
+	defer span.End()
+
+	traced := *s
+	traced.ctx = ctx
+
+	start := time.Now()
+	resp := handle(&traced)
+
+	outcome := "ok"
+	if resp.Error != nil {
+		outcome = "error"
+		span.SetStatus(codes.Error, resp.Error.Message)
+		span.SetAttributes(attribute.Int("rpc.jsonrpc.error_code", resp.Error.Code))
+	}
+	attrs = append(attrs, attribute.String("outcome", outcome))
+	s.telemetry.requests.Add(ctx, 1, metric.WithAttributes(attrs...))
+	s.telemetry.requestDuration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+
+	return resp
+}
+
+// instrumentedTransport records a client span and metrics for every call
+// the Datadog client makes.
+type instrumentedTransport struct {
+	base        http.RoundTripper
+	instruments *instruments
+}
+
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := t.instruments.tracer.Start(req.Context(), "datadog "+req.Method+" "+routeTemplate(req.URL.Path),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(req.Method),
+			semconv.URLPath(req.URL.Path),
+			semconv.ServerAddress(req.URL.Host),
+		))
+	defer span.End()
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+
+	status := "error"
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		status = strconv.Itoa(resp.StatusCode)
+		span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+		if resp.StatusCode >= 400 {
+			span.SetStatus(codes.Error, resp.Status)
+		}
+	}
+
+	attrs := metric.WithAttributes(
+		attribute.String("http.route", routeTemplate(req.URL.Path)),
+		attribute.String("http.status", status),
+	)
+	t.instruments.apiCalls.Add(ctx, 1, attrs)
+	t.instruments.apiDuration.Record(ctx, time.Since(start).Seconds(), attrs)
+	if resp != nil {
+		t.recordRateLimit(ctx, resp.Header)
+	}
+
+	return resp, err
+}
+
+// recordRateLimit reports the remaining budget from Datadog's rate limit
+// headers, which are only sent on rate-limited endpoints.
+func (t *instrumentedTransport) recordRateLimit(ctx context.Context, header http.Header) {
+	remaining, err := strconv.ParseInt(header.Get("X-RateLimit-Remaining"), 10, 64)
+	if err != nil {
+		return
+	}
+	name := header.Get("X-RateLimit-Name")
+	if name == "" {
+		name = "unknown"
+	}
+	t.instruments.rateLimitRemaining.Record(ctx, remaining,
+		metric.WithAttributes(attribute.String("ratelimit.name", name)))
+}
+
+// routeTemplate replaces identifier segments of an API path with "{id}" so
+// metrics aren't split per monitor, dashboard or event.
+func routeTemplate(path string) string {
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if seg == "" {
+			continue
+		}
+		if _, err := strconv.ParseInt(seg, 10, 64); err == nil || looksLikeID(seg) {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// looksLikeID matches UUIDs and other long opaque identifiers; API path
+// words are short and contain no digits mixed with dashes.
+func looksLikeID(seg string) bool {
+	if len(seg) < 8 {
+		return false
+	}
+	hasDigit := strings.ContainsAny(seg, "0123456789")
+	return hasDigit && (strings.Count(seg, "-") >= 2 || len(seg) >= 16)
+}
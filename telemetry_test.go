@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func testInstruments(t *testing.T) (*instruments, *tracetest.SpanRecorder, *sdkmetric.ManualReader) {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")
+
+	inst := newInstruments(tracerProvider.Tracer("test"), meter)
+	return inst, recorder, reader
+}
+
+func counterTotal(t *testing.T, reader *sdkmetric.ManualReader, name string) int64 {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("failed to collect metrics: %v", err)
+	}
+	var total int64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if sum, ok := m.Data.(metricdata.Sum[int64]); ok && m.Name == name {
+				for _, dp := range sum.DataPoints {
+					total += dp.Value
+				}
+			}
+		}
+	}
+	return total
+}
+
+func TestTraceRequest(t *testing.T) {
+	inst, recorder, reader := testInstruments(t)
+	server := &MCPServer{telemetry: inst}
+
+	params, _ := json.Marshal(ToolCallParams{Name: "unknown_tool"})
+	resp := server.HandleRequest(MCPRequest{Jsonrpc: "2.0", ID: 1, Method: "tools/call", Params: params})
+	if resp.Error == nil {
+		t.Fatal("expected unknown tool error")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 || spans[0].Name() != "mcp tools/call" {
+		t.Fatalf("expected one mcp tools/call span, got %d", len(spans))
+	}
+	if spans[0].Status().Code.String() != "Error" {
+		t.Errorf("expected error status, got %v", spans[0].Status())
+	}
+	if total := counterTotal(t, reader, "mcp.server.requests"); total != 1 {
+		t.Errorf("expected 1 request counted, got %d", total)
+	}
+}
+
+func TestInstrumentedTransport(t *testing.T) {
+	inst, recorder, reader := testInstruments(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	client := &http.Client{Transport: &instrumentedTransport{base: http.DefaultTransport, instruments: inst}}
+	resp, err := client.Get(ts.URL + "/api/v1/monitor/12345")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	spans := recorder.Ended()
+	if len(spans) != 1 || spans[0].Name() != "datadog GET /api/v1/monitor/{id}" {
+		t.Fatalf("unexpected spans: %v", spans)
+	}
+	if total := counterTotal(t, reader, "mcp.datadog.api.calls"); total != 1 {
+		t.Errorf("expected 1 API call counted, got %d", total)
+	}
+}
+
+func TestRouteTemplate(t *testing.T) {
+	tests := map[string]string{
+		"/api/v2/logs/events/search":                       "/api/v2/logs/events/search",
+		"/api/v1/monitor/12345":                            "/api/v1/monitor/{id}",
+		"/api/v2/incidents/8a9b2c3d-1234-5678-9abc-def012": "/api/v2/incidents/{id}",
+		"/api/v1/dashboard/abc-def-ghi":                    "/api/v1/dashboard/abc-def-ghi",
+	}
+	for path, expected := range tests {
+		if got := routeTemplate(path); got != expected {
+			t.Errorf("routeTemplate(%q) = %q, want %q", path, got, expected)
+		}
+	}
+}
+
+func TestPrometheusMetrics(t *testing.T) {
+	setup, err := setupTelemetry(context.Background(), false, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { _ = setup.shutdown(context.Background()) })
+
+	inst := newInstruments(otel.Tracer("test"), otel.Meter("test"))
+	store := newResultStore(1024)
+	if err := inst.observeResultStore(store); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	store.put("a", "data")
+	store.get("a")
+	store.get("missing")
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Name", "logs_query")
+		w.Header().Set("X-RateLimit-Remaining", "42")
+	}))
+	defer ts.Close()
+	client := &http.Client{Transport: &instrumentedTransport{base: http.DefaultTransport, instruments: inst}}
+	resp, err := client.Get(ts.URL + "/api/v2/logs/events/search")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	server := &MCPServer{metricsHandler: setup.metricsHandler}
+	rec := httptest.NewRecorder()
+	server.httpHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		"mcp_datadog_api_calls_total",
+		`mcp_datadog_ratelimit_remaining{otel_scope_name="test"`,
+		"mcp_result_store_hits_total",
+		"mcp_result_store_misses_total",
+		"go_goroutines",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in metrics output", want)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// TenantCredentials is one user's Datadog key pair in the credential store.
+type TenantCredentials struct {
+	APIKey string `json:"api_key"`
+	AppKey string `json:"app_key"`
+}
+
+// tenantStore maps authenticated users onto their own Datadog keys so a
+// single gateway can serve many users without sharing credentials. Users
+// are identified either by a header set by a trusted auth proxy or by the
+// subject of an HS256-signed JWT.
+type tenantStore struct {
+	credentials map[string]TenantCredentials
+	userHeader  string
+	jwtSecret   []byte
+	jwtClaim    string
+}
+
+func loadTenantStore(path, userHeader, jwtSecret, jwtClaim string) (*tenantStore, error) {
+	if userHeader == "" && jwtSecret == "" {
+		return nil, fmt.Errorf("gateway mode needs DD_MCP_USER_HEADER or DD_MCP_JWT_SECRET to identify users")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials file: %w", err)
+	}
+
+	credentials := make(map[string]TenantCredentials)
+	if err := json.Unmarshal(data, &credentials); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials file: %w", err)
+	}
+	for user, creds := range credentials {
+		if creds.APIKey == "" || creds.AppKey == "" {
+			return nil, fmt.Errorf("credentials for %s must include api_key and app_key", user)
+		}
+	}
+
+	if jwtClaim == "" {
+		jwtClaim = "sub"
+	}
+	return &tenantStore{
+		credentials: credentials,
+		userHeader:  userHeader,
+		jwtSecret:   []byte(jwtSecret),
+		jwtClaim:    jwtClaim,
+	}, nil
+}
+
+// identify returns the user making the request. With a JWT secret every
+// request must carry a valid token; the user header is never a fallback,
+// as any client could send it.
+func (t *tenantStore) identify(r *http.Request) (string, error) {
+	if len(t.jwtSecret) > 0 {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			return "", fmt.Errorf("request is not authenticated: a bearer token is required")
+		}
+		return verifyJWT(token, t.jwtSecret, t.jwtClaim, time.Now())
+	}
+	if t.userHeader != "" {
+		if user := r.Header.Get(t.userHeader); user != "" {
+			return user, nil
+		}
+	}
+	return "", fmt.Errorf("request is not authenticated")
+}
+
+func (t *tenantStore) lookup(user string) (TenantCredentials, error) {
+	creds, ok := t.credentials[user]
+	if !ok {
+		return TenantCredentials{}, fmt.Errorf("no Datadog credentials configured for %s", user)
+	}
+	return creds, nil
+}
+
+// verifyJWT checks an HS256 token's signature and expiry and returns the
+// string value of the given claim.
+func verifyJWT(token string, secret []byte, claim string, now time.Time) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("malformed token")
+	}
+
+	header, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", fmt.Errorf("malformed token header")
+	}
+	var h struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(header, &h); err != nil || h.Alg != "HS256" {
+		return "", fmt.Errorf("unsupported token algorithm")
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, mac.Sum(nil)) {
+		return "", fmt.Errorf("invalid token signature")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("malformed token payload")
+	}
+	claims := make(map[string]interface{})
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", fmt.Errorf("malformed token payload")
+	}
+
+	if exp, ok := claims["exp"].(float64); ok && now.Unix() >= int64(exp) {
+		return "", fmt.Errorf("token has expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Unix() < int64(nbf) {
+		return "", fmt.Errorf("token is not valid yet")
+	}
+
+	user, ok := claims[claim].(string)
+	if !ok || user == "" {
+		return "", fmt.Errorf("token has no %s claim", claim)
+	}
+	return user, nil
+}
+
+// forTenant returns a copy of the server that calls Datadog with the
+// tenant's own keys. Everything else is shared.
+func (s *MCPServer) forTenant(creds TenantCredentials) *MCPServer {
+	tenant := *s
+	tenant.credentials = datadogCredentials{APIKey: creds.APIKey, AppKey: creds.AppKey}
+	return &tenant
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func signJWT(t *testing.T, secret, payload string) string {
+	t.Helper()
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	body := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(header + "." + body))
+	return header + "." + body + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyJWT(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tests := []struct {
+		name        string
+		token       string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "valid token",
+			token:    signJWT(t, "secret", `{"sub":"alice","exp":1700000600}`),
+			expected: "alice",
+		},
+		{
+			name:        "wrong secret",
+			token:       signJWT(t, "other", `{"sub":"alice"}`),
+			expectError: true,
+		},
+		{
+			name:        "expired",
+			token:       signJWT(t, "secret", `{"sub":"alice","exp":1699999999}`),
+			expectError: true,
+		},
+		{
+			name:        "missing claim",
+			token:       signJWT(t, "secret", `{"email":"alice@example.com"}`),
+			expectError: true,
+		},
+		{
+			name:        "malformed",
+			token:       "not-a-jwt",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := verifyJWT(tt.token, []byte("secret"), "sub", now)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if user != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, user)
+			}
+		})
+	}
+}
+
+func TestLoadTenantStore(t *testing.T) {
+	path := writeTempFile(t, "credentials.json", `{"alice":{"api_key":"a1","app_key":"a2"}}`)
+
+	if _, err := loadTenantStore(path, "", "", ""); err == nil {
+		t.Error("expected error when no identity source is configured")
+	}
+
+	store, err := loadTenantStore(path, "X-Forwarded-User", "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	req := httptest.NewRequest("POST", "/mcp", nil)
+	req.Header.Set("X-Forwarded-User", "alice")
+	user, err := store.identify(req)
+	if err != nil || user != "alice" {
+		t.Fatalf("expected alice, got %q (%v)", user, err)
+	}
+	if creds, err := store.lookup(user); err != nil || creds.APIKey != "a1" {
+		t.Errorf("unexpected credentials: %+v (%v)", creds, err)
+	}
+	if _, err := store.lookup("bob"); err == nil {
+		t.Error("expected error for unknown user")
+	}
+
+	jwtStore, err := loadTenantStore(path, "X-Forwarded-User", "secret", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := jwtStore.identify(req); err == nil {
+		t.Error("expected the user header to be ignored when a JWT secret is set")
+	}
+	req.Header.Set("Authorization", "Bearer "+signJWT(t, "secret", `{"sub":"alice"}`))
+	if user, err := jwtStore.identify(req); err != nil || user != "alice" {
+		t.Errorf("expected alice from the token, got %q (%v)", user, err)
+	}
+
+	incomplete := writeTempFile(t, "credentials.json", `{"alice":{"api_key":"a1"}}`)
+	if _, err := loadTenantStore(incomplete, "X-Forwarded-User", "", ""); err == nil {
+		t.Error("expected error for credentials without app_key")
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	defaultMaxPoints = 120
+	minMaxPoints     = 3
+	maxMaxPoints     = 1000
+)
+
+// alignmentSteps are the intervals series are resampled to, so aligned
+// timestamps fall on round times.
+var alignmentSteps = []time.Duration{
+	time.Second, 5 * time.Second, 10 * time.Second, 15 * time.Second, 30 * time.Second,
+	time.Minute, 2 * time.Minute, 5 * time.Minute, 10 * time.Minute, 15 * time.Minute, 30 * time.Minute,
+	time.Hour, 2 * time.Hour, 3 * time.Hour, 6 * time.Hour, 12 * time.Hour, 24 * time.Hour,
+}
+
+// SeriesResolution says how returned series were resampled from what
+// Datadog sent.
+type SeriesResolution struct {
+	// Interval is the spacing of the returned points; each is the average
+	// of the raw points in its interval.
+	Interval  string `json:"interval"`
+	RawPoints int    `json:"raw_points"`
+	Points    int    `json:"points"`
+}
+
+// resampleSeries downsamples series to at most maxPoints points each and
+// aligns them to common timestamps, with a null value where a series has
+// no data, so several series can be read side by side. Findings should
+// be drawn from the raw series first, since averaging smooths out spikes.
+// It returns nil resolution when the series are left as they are.
+func (s *MCPServer) resampleSeries(series []BandSeries, maxPoints int) ([]BandSeries, *SeriesResolution) {
+	if maxPoints <= 0 {
+		maxPoints = defaultMaxPoints
+	}
+	if maxPoints < minMaxPoints {
+		s.adjustArgument("max_points", maxPoints, minMaxPoints, fmt.Sprintf("raised to the minimum of %d", minMaxPoints))
+		maxPoints = minMaxPoints
+	}
+	maxPoints = clampArgument(s, "max_points", maxPoints, maxMaxPoints)
+
+	var first, last time.Time
+	rawPoints := 0
+	native := time.Duration(0)
+	aligned := true
+	for _, series := range series {
+		rawPoints = max(rawPoints, len(series.Points))
+		for i, p := range series.Points {
+			if first.IsZero() || p.Timestamp.Before(first) {
+				first = p.Timestamp
+			}
+			if p.Timestamp.After(last) {
+				last = p.Timestamp
+			}
+			if i > 0 {
+				if gap := p.Timestamp.Sub(series.Points[i-1].Timestamp); gap > 0 && (native == 0 || gap < native) {
+					native = gap
+				}
+			}
+		}
+	}
+	if rawPoints == 0 {
+		return series, nil
+	}
+	for i := 1; i < len(series) && aligned; i++ {
+		aligned = sameTimestamps(series[0].Points, series[i].Points)
+	}
+	if rawPoints <= maxPoints && aligned {
+		return series, nil
+	}
+
+	step := alignmentStep(last.Sub(first), native, maxPoints)
+	start := first.Truncate(step)
+	buckets := int(last.Sub(start)/step) + 1
+
+	resampled := make([]BandSeries, len(series))
+	used := make([]bool, buckets)
+	sums := make([][]bandSum, len(series))
+	for i, series := range series {
+		sums[i] = make([]bandSum, buckets)
+		for _, p := range series.Points {
+			b := int(p.Timestamp.Sub(start) / step)
+			sums[i][b].add(p)
+			used[b] = true
+		}
+	}
+	points := 0
+	for i := range series {
+		resampled[i] = BandSeries{Scope: series[i].Scope, Points: make([]BandPoint, 0, buckets)}
+		for b := range buckets {
+			if !used[b] {
+				continue
+			}
+			point := sums[i][b].point(start.Add(time.Duration(b) * step))
+			resampled[i].Points = append(resampled[i].Points, point)
+		}
+		points = max(points, len(resampled[i].Points))
+	}
+	return resampled, &SeriesResolution{Interval: step.String(), RawPoints: rawPoints, Points: points}
+}
+
+// alignmentStep picks the smallest round interval, no finer than the raw
+// one, that fits span into maxPoints points. Two are held back for the
+// partial intervals at either end.
+func alignmentStep(span, native time.Duration, maxPoints int) time.Duration {
+	need := max(native, span/time.Duration(maxPoints-2))
+	// A native interval that already fits is kept, even when it isn't
+	// round, so aligning series doesn't coarsen them.
+	if native > 0 && need == native {
+		return native
+	}
+	for _, step := range alignmentSteps {
+		if step >= need {
+			return step
+		}
+	}
+	day := 24 * time.Hour
+	return (need + day - 1) / day * day
+}
+
+// sameTimestamps reports whether two series have points at the same
+// times.
+func sameTimestamps(a, b []BandPoint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Timestamp.Equal(b[i].Timestamp) {
+			return false
+		}
+	}
+	return true
+}
+
+// bandSum averages the values and bands of the raw points in one
+// interval, each over the points that have it.
+type bandSum struct {
+	value, lower, upper meanOf
+}
+
+type meanOf struct {
+	sum float64
+	n   int
+}
+
+func (m *meanOf) add(v *float64) {
+	if v != nil {
+		m.sum += *v
+		m.n++
+	}
+}
+
+func (m meanOf) mean() *float64 {
+	if m.n == 0 {
+		return nil
+	}
+	v := m.sum / float64(m.n)
+	return &v
+}
+
+func (b *bandSum) add(p BandPoint) {
+	b.value.add(p.Value)
+	b.lower.add(p.Lower)
+	b.upper.add(p.Upper)
+}
+
+func (b bandSum) point(ts time.Time) BandPoint {
+	return BandPoint{Timestamp: ts, Value: b.value.mean(), Lower: b.lower.mean(), Upper: b.upper.mean()}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func bandPoints(start time.Time, step time.Duration, values ...float64) []BandPoint {
+	points := make([]BandPoint, len(values))
+	for i, v := range values {
+		v := v
+		points[i] = BandPoint{Timestamp: start.Add(time.Duration(i) * step), Value: &v}
+	}
+	return points
+}
+
+func TestResampleSeriesDownsamples(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	values := make([]float64, 24*60*60)
+	for i := range values {
+		values[i] = float64(i % 60)
+	}
+	server := (&MCPServer{}).withAdjustments()
+	series, resolution := server.resampleSeries([]BandSeries{{Scope: "host:a", Points: bandPoints(start, time.Second, values...)}}, 0)
+
+	if resolution == nil || resolution.Interval != "15m0s" || resolution.RawPoints != len(values) || resolution.Points != 96 {
+		t.Fatalf("expected a day of seconds in 15m intervals, got %+v", resolution)
+	}
+	if len(series[0].Points) != 96 || !series[0].Points[1].Timestamp.Equal(start.Add(15*time.Minute)) {
+		t.Fatalf("unexpected points %+v", series[0].Points[:2])
+	}
+	if v := *series[0].Points[0].Value; v != 29.5 {
+		t.Fatalf("expected each point to average its interval, got %v", v)
+	}
+
+	series, resolution = server.resampleSeries(series, 2)
+	if resolution == nil || resolution.Points > minMaxPoints || len(series[0].Points) > minMaxPoints {
+		t.Fatalf("expected at most %d points, got %+v", minMaxPoints, resolution)
+	}
+	if adjustments := server.argumentAdjustments(); len(adjustments) != 1 || adjustments[0].Used != minMaxPoints {
+		t.Fatalf("expected max_points to be raised, got %+v", adjustments)
+	}
+}
+
+func TestResampleSeriesAligns(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	a := BandSeries{Scope: "host:a", Points: bandPoints(start, time.Minute, 1, 2, 3, 4)}
+	b := BandSeries{Scope: "host:b", Points: bandPoints(start.Add(2*time.Minute), time.Minute, 10, 20, 30)}
+
+	unchanged, resolution := (&MCPServer{}).resampleSeries([]BandSeries{a}, 0)
+	if resolution != nil || len(unchanged[0].Points) != 4 {
+		t.Fatalf("expected a short series to be left alone, got %+v", resolution)
+	}
+
+	series, resolution := (&MCPServer{}).resampleSeries([]BandSeries{a, b}, 0)
+	if resolution == nil || resolution.Interval != "1m0s" || resolution.Points != 5 {
+		t.Fatalf("expected the native interval to be kept, got %+v", resolution)
+	}
+	for _, s := range series {
+		if len(s.Points) != 5 {
+			t.Fatalf("expected both series on five common timestamps, got %+v", s)
+		}
+	}
+	if series[0].Points[4].Value != nil || *series[1].Points[4].Value != 30 || series[1].Points[0].Value != nil || *series[1].Points[2].Value != 10 {
+		t.Fatalf("expected nulls where a series has no data, got %+v", series)
+	}
+}
+
+func TestAlignmentStep(t *testing.T) {
+	for _, tc := range []struct {
+		span, native time.Duration
+		maxPoints    int
+		want         time.Duration
+	}{
+		{time.Hour, 20 * time.Second, 500, 20 * time.Second},
+		{time.Hour, 20 * time.Second, 100, time.Minute},
+		{7 * 24 * time.Hour, time.Minute, 120, 2 * time.Hour},
+		{365 * 24 * time.Hour, time.Hour, 100, 4 * 24 * time.Hour},
+	} {
+		if got := alignmentStep(tc.span, tc.native, tc.maxPoints); got != tc.want {
+			t.Errorf("alignmentStep(%s, %s, %d) = %s, want %s", tc.span, tc.native, tc.maxPoints, got, tc.want)
+		}
+	}
+}
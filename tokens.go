@@ -0,0 +1,351 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// defaultTokenEstimator approximates tokens as four characters each, which
+// is close for English prose and JSON with most tokenizers.
+var defaultTokenEstimator = tokenEstimator{unit: "chars", perToken: 4}
+
+// budgetNoteTokens is left free in a budget for the note saying how to
+// fetch the rest of a reduced result.
+const budgetNoteTokens = 64
+
+// usableBudget is what a reduced result may fill, leaving room for its
+// note.
+func usableBudget(budget int) int {
+	return budget - min(budgetNoteTokens, budget/2)
+}
+
+// ResultMeta is returned in a tool result's _meta.
+type ResultMeta struct {
+	// EstimatedTokens is the estimated size of the returned text.
+	EstimatedTokens int `json:"estimated_tokens"`
+	// The rest is set when the result was reduced to fit the client's
+	// token budget.
+	TokenBudget    int    `json:"token_budget,omitempty"`
+	OriginalTokens int    `json:"original_tokens,omitempty"`
+	Reduced        string `json:"reduced,omitempty"`
+	// Continuation is the fetch_continuation id of the full or remaining
+	// result.
+	Continuation string `json:"continuation,omitempty"`
+	// Adjustments lists the arguments the tool didn't use as given.
+	Adjustments []ArgumentAdjustment `json:"adjustments,omitempty"`
+	// Failover says when reads were served by the secondary keys.
+	Failover []string `json:"failover,omitempty"`
+	// SchemaVersion is the result format version; Deprecation is set when
+	// the call pinned an older one.
+	SchemaVersion int    `json:"schema_version,omitempty"`
+	Deprecation   string `json:"deprecation,omitempty"`
+}
+
+// tokenEstimator estimates token counts from characters or words, so
+// results can be sized without shipping a model's tokenizer.
+type tokenEstimator struct {
+	// unit is "chars" or "words"; perToken is how many make a token.
+	unit     string
+	perToken float64
+}
+
+// parseTokenEstimator reads "chars", "words", or either with a ratio, such
+// as "chars:3.5" or "words:0.75".
+func parseTokenEstimator(spec string) (tokenEstimator, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return defaultTokenEstimator, nil
+	}
+	unit, ratio, hasRatio := strings.Cut(spec, ":")
+	estimator := tokenEstimator{unit: unit}
+	switch unit {
+	case "chars":
+		estimator.perToken = 4
+	case "words":
+		estimator.perToken = 0.75
+	default:
+		return tokenEstimator{}, fmt.Errorf("invalid token estimator %q: use chars or words, optionally with a ratio such as chars:4", spec)
+	}
+	if hasRatio {
+		value, err := strconv.ParseFloat(ratio, 64)
+		if err != nil || value <= 0 || math.IsInf(value, 0) {
+			return tokenEstimator{}, fmt.Errorf("invalid token estimator %q: the ratio must be a positive number", spec)
+		}
+		estimator.perToken = value
+	}
+	return estimator, nil
+}
+
+func (e tokenEstimator) orDefault() tokenEstimator {
+	if e.perToken <= 0 {
+		return defaultTokenEstimator
+	}
+	return e
+}
+
+// estimate returns the estimated tokens in text.
+func (e tokenEstimator) estimate(text string) int {
+	e = e.orDefault()
+	var units int
+	if e.unit == "words" {
+		units = len(strings.Fields(text))
+	} else {
+		units = utf8.RuneCountInString(text)
+	}
+	return int(math.Ceil(float64(units) / e.perToken))
+}
+
+// prefix returns the longest prefix of text estimated at no more than
+// tokens, cut on a rune or word boundary.
+func (e tokenEstimator) prefix(text string, tokens int) string {
+	e = e.orDefault()
+	limit := int(float64(tokens) * e.perToken)
+	if limit <= 0 {
+		return ""
+	}
+	units := 0
+	if e.unit == "words" {
+		inWord := false
+		for i, r := range text {
+			if unicode.IsSpace(r) {
+				inWord = false
+				continue
+			}
+			if !inWord {
+				if units == limit {
+					return text[:i]
+				}
+				units++
+				inWord = true
+			}
+		}
+		return text
+	}
+	for i := range text {
+		if units == limit {
+			return text[:i]
+		}
+		units++
+	}
+	return text
+}
+
+// tokenBudgets caps the estimated tokens of each result by client, named
+// as in the clientInfo the client sends to initialize. A nil value sets
+// no budget.
+type tokenBudgets struct {
+	Default int            `json:"default"`
+	Clients map[string]int `json:"clients"`
+	// Overflow is what happens to a result over budget: "summary" trims
+	// its longest lists, "continuation" cuts it for fetch_continuation.
+	Overflow string `json:"overflow"`
+
+	mu sync.Mutex
+	// sessions maps each session to the client that initialized it.
+	sessions map[string]string
+}
+
+func loadTokenBudgets(path string) (*tokenBudgets, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token budgets file: %w", err)
+	}
+	var budgets tokenBudgets
+	if err := json.Unmarshal(data, &budgets); err != nil {
+		return nil, fmt.Errorf("failed to parse token budgets file: %w", err)
+	}
+	if budgets.Overflow == "" {
+		budgets.Overflow = "summary"
+	}
+	if budgets.Overflow != "summary" && budgets.Overflow != "continuation" {
+		return nil, fmt.Errorf("invalid token budget overflow %q: use summary or continuation", budgets.Overflow)
+	}
+	if budgets.Default < 0 {
+		return nil, fmt.Errorf("token budget default must not be negative")
+	}
+	for client, budget := range budgets.Clients {
+		if budget < 0 {
+			return nil, fmt.Errorf("token budget for client %q must not be negative", client)
+		}
+	}
+	budgets.sessions = make(map[string]string)
+	return &budgets, nil
+}
+
+// setClient remembers which client initialized a session.
+func (b *tokenBudgets) setClient(session, client string) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.sessions[session]; !ok && len(b.sessions) >= sweepThreshold {
+		// Clients that never come back would otherwise accumulate.
+		clear(b.sessions)
+	}
+	b.sessions[session] = client
+}
+
+// budget returns the token budget for a session's results; zero means
+// unlimited.
+func (b *tokenBudgets) budget(session string) int {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	client := b.sessions[session]
+	b.mu.Unlock()
+	for name, budget := range b.Clients {
+		if strings.EqualFold(name, client) {
+			return budget
+		}
+	}
+	return b.Default
+}
+
+// recordClient notes the client named in an initialize request.
+func (s *MCPServer) recordClient(params json.RawMessage) {
+	var init struct {
+		ClientInfo struct {
+			Name string `json:"name"`
+		} `json:"clientInfo"`
+	}
+	if json.Unmarshal(params, &init) == nil {
+		s.tokenBudgets.setClient(s.session, init.ClientInfo.Name)
+	}
+}
+
+// fitTokenBudget reduces a result estimated over the session's token
+// budget, then applies the byte limit, and describes the text returned.
+func (s *MCPServer) fitTokenBudget(text string) (string, *ResultMeta) {
+	meta := &ResultMeta{}
+	budget := s.tokenBudgets.budget(s.session)
+	if tokens := s.tokens.estimate(text); budget > 0 && tokens > budget {
+		meta.TokenBudget, meta.OriginalTokens = budget, tokens
+		if reduced, id, ok := s.summarizeResult(text, budget); ok {
+			text, meta.Reduced, meta.Continuation = reduced, "summary", id
+		} else if reduced, id, ok := s.continueResult(text, budget); ok {
+			text, meta.Reduced, meta.Continuation = reduced, "continuation", id
+		}
+	}
+	text = s.paginateResult(text)
+	meta.EstimatedTokens = s.tokens.estimate(text)
+	return text, meta
+}
+
+// summarizeResult halves the longest lists of a JSON object result until
+// it fits the budget, noting what was left out. The full result is kept
+// for fetch_continuation.
+func (s *MCPServer) summarizeResult(text string, budget int) (string, string, bool) {
+	if s.tokenBudgets.Overflow != "summary" || s.results == nil {
+		return "", "", false
+	}
+	var root map[string]interface{}
+	if err := json.Unmarshal([]byte(text), &root); err != nil {
+		return "", "", false
+	}
+
+	omitted := make(map[string]int)
+	for {
+		data, err := json.MarshalIndent(root, "", "  ")
+		if err != nil {
+			return "", "", false
+		}
+		if s.tokens.estimate(string(data)) <= usableBudget(budget) {
+			break
+		}
+		ref, ok := longestList(root, "")
+		if !ok {
+			return "", "", false
+		}
+		keep := (len(ref.list) + 1) / 2
+		omitted[ref.path] += len(ref.list) - keep
+		ref.set(ref.list[:keep])
+	}
+
+	id, err := newContinuationID()
+	if err != nil || !s.results.put(s.resultKey(id), text) {
+		return "", "", false
+	}
+	root["_summary"] = map[string]interface{}{
+		"omitted": omitted,
+		"note":    fmt.Sprintf("Lists were shortened to fit a budget of %d tokens; call fetch_continuation with id %q for the full result.", budget, id),
+	}
+	data, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return "", "", false
+	}
+	return string(data), id, true
+}
+
+// continueResult cuts a result at the budget and keeps the rest for
+// fetch_continuation.
+func (s *MCPServer) continueResult(text string, budget int) (string, string, bool) {
+	if s.results == nil {
+		return "", "", false
+	}
+	head := s.tokens.prefix(text, usableBudget(budget))
+	id, err := newContinuationID()
+	if err != nil || !s.results.put(s.resultKey(id), text[len(head):]) {
+		return "", "", false
+	}
+	rest := s.tokens.estimate(text[len(head):])
+	return fmt.Sprintf("%s\n\n[truncated to fit a budget of %d tokens: about %d more tokens; call fetch_continuation with id %q]", head, budget, rest, id), id, true
+}
+
+// listRef points at a list inside decoded JSON so it can be shortened in
+// place.
+type listRef struct {
+	path string
+	list []interface{}
+	set  func([]interface{})
+}
+
+// longestList finds the list with the most items, of at least two, under
+// v. Keys are visited in order so ties always resolve the same way.
+func longestList(v interface{}, path string) (listRef, bool) {
+	var best listRef
+	found := false
+	consider := func(ref listRef) {
+		if len(ref.list) >= 2 && (!found || len(ref.list) > len(best.list)) {
+			best, found = ref, true
+		}
+	}
+	visit := func(child interface{}, childPath string, set func([]interface{})) {
+		if list, ok := child.([]interface{}); ok {
+			consider(listRef{path: childPath, list: list, set: set})
+		}
+		if ref, ok := longestList(child, childPath); ok {
+			consider(ref)
+		}
+	}
+
+	switch node := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(node))
+		for key := range node {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			visit(node[key], childPath, func(list []interface{}) { node[key] = list })
+		}
+	case []interface{}:
+		for i := range node {
+			visit(node[i], fmt.Sprintf("%s[%d]", path, i), func(list []interface{}) { node[i] = list })
+		}
+	}
+	return best, found
+}
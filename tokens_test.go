@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestTokenEstimator(t *testing.T) {
+	chars, err := parseTokenEstimator("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := chars.estimate("héllo wörld"); got != 3 {
+		t.Errorf("expected 11 runes to be 3 tokens, got %d", got)
+	}
+	if got := chars.prefix("héllo wörld", 1); got != "héll" {
+		t.Errorf("expected a four-rune prefix, got %q", got)
+	}
+
+	words, err := parseTokenEstimator("words:0.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := words.estimate("one two  three"); got != 6 {
+		t.Errorf("expected 3 words to be 6 tokens, got %d", got)
+	}
+	if got := words.prefix("one two  three four", 4); got != "one two  " {
+		t.Errorf("expected a two-word prefix, got %q", got)
+	}
+
+	if got := (tokenEstimator{}).estimate("12345678"); got != 2 {
+		t.Errorf("expected the zero estimator to use the default, got %d", got)
+	}
+	for _, spec := range []string{"bytes", "chars:0", "words:x"} {
+		if _, err := parseTokenEstimator(spec); err == nil {
+			t.Errorf("expected %q to be rejected", spec)
+		}
+	}
+}
+
+func TestLoadTokenBudgets(t *testing.T) {
+	budgets, err := loadTokenBudgets(writeTempFile(t, "budgets.json", `{"default": 100, "clients": {"Cursor": 50}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if budgets.Overflow != "summary" {
+		t.Errorf("expected summary overflow by default, got %q", budgets.Overflow)
+	}
+	budgets.setClient("a", "cursor")
+	budgets.setClient("b", "other")
+	if budgets.budget("a") != 50 || budgets.budget("b") != 100 || budgets.budget("unknown") != 100 {
+		t.Errorf("unexpected budgets: %d, %d, %d", budgets.budget("a"), budgets.budget("b"), budgets.budget("unknown"))
+	}
+	if (*tokenBudgets)(nil).budget("a") != 0 {
+		t.Error("expected no budget without a config")
+	}
+
+	for _, content := range []string{`{"overflow": "drop"}`, `{"default": -1}`, `{"clients": {"x": -5}}`, `{`} {
+		if _, err := loadTokenBudgets(writeTempFile(t, "budgets.json", content)); err == nil {
+			t.Errorf("expected %s to be rejected", content)
+		}
+	}
+}
+
+// longExplainQuery returns a query whose explain_query result lists many
+// filters.
+func longExplainQuery(filters int) json.RawMessage {
+	terms := make([]string, filters)
+	for i := range terms {
+		terms[i] = fmt.Sprintf("service:svc-%d", i)
+	}
+	args, _ := json.Marshal(ExplainQueryParams{Query: strings.Join(terms, " OR "), Kind: "logs"})
+	return args
+}
+
+func callWithBudget(t *testing.T, overflow string) (*MCPServer, string, ResultMeta) {
+	t.Helper()
+	budgets, err := loadTokenBudgets(writeTempFile(t, "budgets.json", `{"default": 0, "clients": {"cursor": 1500}, "overflow": "`+overflow+`"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &MCPServer{results: newResultStore(1 << 20), tokenBudgets: budgets, session: "stdio"}
+	server.HandleRequest(MCPRequest{Jsonrpc: "2.0", ID: 1, Method: "initialize", Params: json.RawMessage(`{"clientInfo":{"name":"Cursor","version":"1.0"}}`)})
+
+	params, _ := json.Marshal(ToolCallParams{Name: "explain_query", Arguments: longExplainQuery(100)})
+	resp := server.HandleRequest(MCPRequest{Jsonrpc: "2.0", ID: 2, Method: "tools/call", Params: params})
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %v", resp.Error.Message)
+	}
+	var result ToolCallResult
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Meta == nil {
+		t.Fatalf("expected _meta in %s", resp.Result)
+	}
+	return server, result.Content[0].Text, *result.Meta
+}
+
+func TestTokenBudgetSummary(t *testing.T) {
+	server, text, meta := callWithBudget(t, "summary")
+	if meta.Reduced != "summary" || meta.TokenBudget != 1500 || meta.OriginalTokens <= 1500 || meta.EstimatedTokens > 1500 {
+		t.Fatalf("expected the result summarized within budget, got %+v", meta)
+	}
+	if meta.EstimatedTokens != (tokenEstimator{}).estimate(text) {
+		t.Errorf("expected the estimate to describe the returned text, got %d", meta.EstimatedTokens)
+	}
+
+	var summarized struct {
+		Filters []interface{} `json:"filters"`
+		Summary struct {
+			Omitted map[string]int `json:"omitted"`
+		} `json:"_summary"`
+	}
+	if err := json.Unmarshal([]byte(text), &summarized); err != nil {
+		t.Fatalf("expected the summary to stay valid JSON: %v", err)
+	}
+	if omitted := summarized.Summary.Omitted["filters"]; omitted == 0 || len(summarized.Filters)+omitted != 100 {
+		t.Fatalf("expected the omitted filters counted, got %d kept and %v", len(summarized.Filters), summarized.Summary.Omitted)
+	}
+
+	full, err := server.FetchContinuation(meta.Continuation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(full, "svc-99") {
+		t.Error("expected the continuation to hold the full result")
+	}
+}
+
+func TestTokenBudgetContinuation(t *testing.T) {
+	server, text, meta := callWithBudget(t, "continuation")
+	if meta.Reduced != "continuation" || meta.EstimatedTokens > 1500 {
+		t.Fatalf("expected the result cut within budget, got %+v", meta)
+	}
+	if !strings.Contains(text, "call fetch_continuation with id "+fmt.Sprintf("%q", meta.Continuation)) {
+		t.Fatalf("expected a continuation notice, got %q", text)
+	}
+	rest, err := server.FetchContinuation(meta.Continuation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(rest, "svc-99") {
+		t.Error("expected the rest of the result in the continuation")
+	}
+}
+
+func TestTokenMetaWithoutBudget(t *testing.T) {
+	server := &MCPServer{results: newResultStore(1024)}
+	params, _ := json.Marshal(ToolCallParams{Name: "explain_query", Arguments: longExplainQuery(100)})
+	resp := server.HandleRequest(MCPRequest{Jsonrpc: "2.0", ID: 1, Method: "tools/call", Params: params})
+	var result ToolCallResult
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Meta == nil || result.Meta.Reduced != "" || result.Meta.EstimatedTokens != (tokenEstimator{}).estimate(result.Content[0].Text) {
+		t.Fatalf("expected only an estimate, got %+v", result.Meta)
+	}
+}
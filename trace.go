@@ -0,0 +1,321 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadog"
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV2"
+)
+
+// maxTraceSpans bounds the spans get_trace fetches for one trace.
+const maxTraceSpans = 3000
+
+type GetTraceParams struct {
+	TraceID string `json:"trace_id"`
+	// From and To bound the search; traces are found by their spans'
+	// start times.
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+	// Format is "markdown" (default), "json" or "mermaid".
+	Format string `json:"format,omitempty"`
+}
+
+// TraceNode is a span with the spans it called.
+type TraceNode struct {
+	SpanEntry
+	// OffsetMs is how long after the trace started the span started.
+	OffsetMs float64      `json:"offset_ms"`
+	Children []*TraceNode `json:"children,omitempty"`
+}
+
+type Trace struct {
+	TraceID    string       `json:"trace_id"`
+	Spans      int          `json:"spans"`
+	Services   []string     `json:"services"`
+	Errors     int          `json:"errors"`
+	Start      *time.Time   `json:"start,omitempty"`
+	DurationMs float64      `json:"duration_ms"`
+	Roots      []*TraceNode `json:"roots"`
+	URL        string       `json:"url"`
+	// Truncated is set when the trace has more than maxTraceSpans spans.
+	Truncated bool         `json:"truncated,omitempty"`
+	Notes     []string     `json:"notes,omitempty"`
+	Partial   *PartialData `json:"partial,omitempty"`
+}
+
+// GetTrace fetches every indexed span of a trace and arranges them by
+// parent.
+func (s *MCPServer) GetTrace(params GetTraceParams) (string, error) {
+	traceID := strings.TrimSpace(params.TraceID)
+	if traceID == "" {
+		return "", fmt.Errorf("trace_id parameter is required")
+	}
+	format := params.Format
+	if format == "" {
+		format = "markdown"
+	}
+	if format != "markdown" && format != "json" && format != "mermaid" {
+		return "", fmt.Errorf("invalid format: %s (use markdown, json or mermaid)", params.Format)
+	}
+	from, err := s.timeParam("from", params.From, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		return "", err
+	}
+	to, err := s.timeParam("to", params.To, time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	body := datadogV2.SpansListRequest{
+		Data: &datadogV2.SpansListRequestData{
+			Type: datadogV2.SPANSLISTREQUESTTYPE_SEARCH_REQUEST.Ptr(),
+			Attributes: &datadogV2.SpansListRequestAttributes{
+				Filter: &datadogV2.SpansQueryFilter{
+					From:  datadog.PtrString(from.Format(time.RFC3339)),
+					To:    datadog.PtrString(to.Format(time.RFC3339)),
+					Query: datadog.PtrString("trace_id:" + traceID),
+				},
+				Page: &datadogV2.SpansListRequestPage{},
+				Sort: datadogV2.SPANSSORT_TIMESTAMP_ASCENDING.Ptr(),
+			},
+		},
+	}
+
+	api := datadogV2.NewSpansApi(s.ddClient)
+	trace := &Trace{TraceID: traceID, URL: s.appURL("/apm/trace/" + traceID)}
+	var spans []SpanEntry
+	for len(spans) < maxTraceSpans {
+		body.Data.Attributes.Page.Limit = datadog.PtrInt32(int32(min(maxTraceSpans-len(spans), maxSpansLimit)))
+		resp, _, err := api.ListSpans(s.ctx, body)
+		if err != nil {
+			return "", fmt.Errorf("failed to fetch trace spans: %w", err)
+		}
+		for _, span := range resp.Data {
+			entry, missing := s.toSpanEntry(span)
+			spans = append(spans, entry)
+			trace.Partial = trace.Partial.record(missing...)
+		}
+		cursor := resp.GetMeta().Page.GetAfter()
+		if cursor == "" || len(resp.Data) == 0 {
+			break
+		}
+		if len(spans) >= maxTraceSpans {
+			trace.Truncated = true
+			break
+		}
+		body.Data.Attributes.Page.Cursor = datadog.PtrString(cursor)
+	}
+	if len(spans) == 0 {
+		return "", fmt.Errorf("no spans found for trace %s between %s and %s; widen from/to, or the spans may not have been indexed",
+			traceID, from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
+
+	buildTrace(trace, spans)
+	if trace.Truncated {
+		trace.Notes = append(trace.Notes, fmt.Sprintf("The trace has more than %d spans; only the first %d are shown.", maxTraceSpans, maxTraceSpans))
+	}
+
+	if format == "json" {
+		data, err := json.MarshalIndent(trace, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal trace: %w", err)
+		}
+		return string(data), nil
+	}
+	if format == "mermaid" {
+		return formatTraceMermaid(trace), nil
+	}
+	return formatTraceMarkdown(trace), nil
+}
+
+// buildTrace links spans to their parents and fills in the trace's
+// totals. Spans whose parent wasn't fetched become extra roots.
+func buildTrace(trace *Trace, spans []SpanEntry) {
+	nodes := make(map[string]*TraceNode, len(spans))
+	ordered := make([]*TraceNode, 0, len(spans))
+	var start, end time.Time
+	for _, span := range spans {
+		node := &TraceNode{SpanEntry: span}
+		if span.SpanID != "" {
+			nodes[span.SpanID] = node
+		}
+		ordered = append(ordered, node)
+
+		if !slices.Contains(trace.Services, span.Service) && span.Service != "" {
+			trace.Services = append(trace.Services, span.Service)
+		}
+		if span.Status == "error" {
+			trace.Errors++
+		}
+		if span.Start != nil {
+			if start.IsZero() || span.Start.Before(start) {
+				start = *span.Start
+			}
+			finish := *span.Start
+			if span.DurationMs != nil {
+				finish = finish.Add(time.Duration(*span.DurationMs * float64(time.Millisecond)))
+			}
+			if finish.After(end) {
+				end = finish
+			}
+		}
+	}
+	sort.Strings(trace.Services)
+	trace.Spans = len(spans)
+	if !start.IsZero() {
+		trace.Start = &start
+		trace.DurationMs = float64(end.Sub(start).Microseconds()) / 1e3
+	}
+
+	orphans := 0
+	for _, node := range ordered {
+		if node.Start != nil {
+			node.OffsetMs = float64(node.Start.Sub(start).Microseconds()) / 1e3
+		}
+		parent, ok := nodes[node.ParentID]
+		switch {
+		case node.ParentID == "":
+			trace.Roots = append(trace.Roots, node)
+		case ok && parent != node:
+			parent.Children = append(parent.Children, node)
+		default:
+			orphans++
+			trace.Roots = append(trace.Roots, node)
+		}
+	}
+	if orphans > 0 {
+		trace.Notes = append(trace.Notes, fmt.Sprintf("%d spans' parents weren't indexed, so they are shown at the top level.", orphans))
+	}
+	sortTraceNodes(trace.Roots)
+}
+
+func sortTraceNodes(nodes []*TraceNode) {
+	sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].OffsetMs < nodes[j].OffsetMs })
+	for _, node := range nodes {
+		sortTraceNodes(node.Children)
+	}
+}
+
+func formatTraceMarkdown(trace *Trace) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Trace %s\n\n", trace.TraceID)
+	fmt.Fprintf(&b, "%d spans across %s, %s", trace.Spans, strings.Join(trace.Services, ", "), formatSpanDuration(trace.DurationMs))
+	if trace.Start != nil {
+		fmt.Fprintf(&b, ", starting %s", trace.Start.UTC().Format(time.RFC3339))
+	}
+	switch trace.Errors {
+	case 0:
+	case 1:
+		b.WriteString(", 1 error")
+	default:
+		fmt.Fprintf(&b, ", %d errors", trace.Errors)
+	}
+	fmt.Fprintf(&b, ". [Open in Datadog](%s)\n\n", trace.URL)
+
+	var walk func(nodes []*TraceNode, depth int)
+	walk = func(nodes []*TraceNode, depth int) {
+		for _, node := range nodes {
+			b.WriteString(strings.Repeat("  ", depth))
+			fmt.Fprintf(&b, "- **%s** %s", node.Service, node.Resource)
+			if node.Operation != "" {
+				fmt.Fprintf(&b, " (%s)", node.Operation)
+			}
+			if node.DurationMs != nil {
+				fmt.Fprintf(&b, " %s", formatSpanDuration(*node.DurationMs))
+			}
+			fmt.Fprintf(&b, " at +%s", formatSpanDuration(node.OffsetMs))
+			if node.Status == "error" {
+				b.WriteString(" **ERROR**")
+			}
+			b.WriteString("\n")
+			walk(node.Children, depth+1)
+		}
+	}
+	walk(trace.Roots, 0)
+
+	if len(trace.Notes) > 0 {
+		b.WriteString("\n")
+		for _, note := range trace.Notes {
+			fmt.Fprintf(&b, "_%s_\n", note)
+		}
+	}
+	return b.String()
+}
+
+// formatTraceMermaid renders the trace as Mermaid sequence diagram source,
+// with a participant per service and an arrow for each call, crossed when
+// the called span failed. Root spans are notes over their service.
+func formatTraceMermaid(trace *Trace) string {
+	participants := make(map[string]string)
+	var declared, calls strings.Builder
+	participant := func(service string) string {
+		id, ok := participants[service]
+		if !ok {
+			id = fmt.Sprintf("p%d", len(participants))
+			participants[service] = id
+			fmt.Fprintf(&declared, "    participant %s as %s\n", id, mermaidText(cmp.Or(service, "unknown")))
+		}
+		return id
+	}
+	label := func(node *TraceNode) string {
+		text := mermaidText(node.Resource)
+		if node.DurationMs != nil {
+			text += " " + formatSpanDuration(*node.DurationMs)
+		}
+		if node.Status == "error" {
+			text += " ERROR"
+		}
+		return text
+	}
+
+	var walk func(parent *TraceNode, nodes []*TraceNode)
+	walk = func(parent *TraceNode, nodes []*TraceNode) {
+		for _, node := range nodes {
+			if parent == nil {
+				fmt.Fprintf(&calls, "    Note over %s: %s\n", participant(node.Service), label(node))
+			} else {
+				arrow := "->>"
+				if node.Status == "error" {
+					arrow = "-x"
+				}
+				from := participant(parent.Service)
+				fmt.Fprintf(&calls, "    %s%s%s: %s\n", from, arrow, participant(node.Service), label(node))
+			}
+			walk(node, node.Children)
+		}
+	}
+	walk(nil, trace.Roots)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Trace %s\n\n[Open in Datadog](%s)\n\n", trace.TraceID, trace.URL)
+	b.WriteString("```mermaid\nsequenceDiagram\n")
+	b.WriteString(declared.String())
+	b.WriteString(calls.String())
+	b.WriteString("```\n")
+	if len(trace.Notes) > 0 {
+		b.WriteString("\n")
+		for _, note := range trace.Notes {
+			fmt.Fprintf(&b, "_%s_\n", note)
+		}
+	}
+	return b.String()
+}
+
+// formatSpanDuration prints milliseconds the way Go prints durations,
+// rounded to what matters at that scale.
+func formatSpanDuration(ms float64) string {
+	d := time.Duration(ms * float64(time.Millisecond))
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond).String()
+	}
+	return d.Round(time.Microsecond).String()
+}
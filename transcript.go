@@ -0,0 +1,235 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// maxTranscriptCalls bounds each session's transcript; the oldest
+	// calls are dropped first.
+	maxTranscriptCalls = 200
+	// maxTranscriptResultBytes is how much of each result is kept. Full
+	// results are usually far too large to attach to a ticket.
+	maxTranscriptResultBytes = 2000
+	// transcriptTTL is how long an idle session's transcript is kept once
+	// the store needs sweeping.
+	transcriptTTL = 24 * time.Hour
+)
+
+type ExportSessionParams struct {
+	Format string `json:"format,omitempty"`
+	Last   int    `json:"last,omitempty"`
+}
+
+// TranscriptCall is one recorded tool call. Arguments are as the client
+// sent them; Context holds the defaults pinned with set_context at the
+// time, which were merged into them.
+type TranscriptCall struct {
+	Seq         int             `json:"seq"`
+	Time        string          `json:"time"`
+	Duration    string          `json:"duration"`
+	Tool        string          `json:"tool"`
+	Arguments   json.RawMessage `json:"arguments,omitempty"`
+	Context     *SessionContext `json:"context,omitempty"`
+	Result      string          `json:"result,omitempty"`
+	ResultBytes int             `json:"result_bytes,omitempty"`
+	Truncated   bool            `json:"truncated,omitempty"`
+	Error       string          `json:"error,omitempty"`
+}
+
+type SessionTranscript struct {
+	ExportedAt string           `json:"exported_at"`
+	Calls      []TranscriptCall `json:"calls"`
+	// Omitted counts earlier calls that aren't included.
+	Omitted int `json:"omitted,omitempty"`
+}
+
+type transcript struct {
+	calls    []TranscriptCall
+	seq      int
+	lastUsed time.Time
+}
+
+// transcriptStore records each session's tool calls for export_session.
+// It is shared by every copy of the server.
+type transcriptStore struct {
+	mu       sync.Mutex
+	sessions map[string]*transcript
+}
+
+func newTranscriptStore() *transcriptStore {
+	return &transcriptStore{sessions: make(map[string]*transcript)}
+}
+
+func (t *transcriptStore) record(key string, call TranscriptCall, now time.Time) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, ok := t.sessions[key]
+	if !ok {
+		if len(t.sessions) >= sweepThreshold {
+			for k, e := range t.sessions {
+				if now.Sub(e.lastUsed) >= transcriptTTL {
+					delete(t.sessions, k)
+				}
+			}
+		}
+		entry = &transcript{}
+		t.sessions[key] = entry
+	}
+	entry.seq++
+	call.Seq = entry.seq
+	entry.calls = append(entry.calls, call)
+	if len(entry.calls) > maxTranscriptCalls {
+		entry.calls = entry.calls[len(entry.calls)-maxTranscriptCalls:]
+	}
+	entry.lastUsed = now
+}
+
+// calls returns a copy of the session's recorded calls and the number of
+// calls made before the first one returned.
+func (t *transcriptStore) calls(key string) ([]TranscriptCall, int) {
+	if t == nil {
+		return nil, 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, ok := t.sessions[key]
+	if !ok {
+		return nil, 0
+	}
+	calls := append([]TranscriptCall(nil), entry.calls...)
+	return calls, entry.seq - len(calls)
+}
+
+// recordCall adds a finished tool call to the session's transcript.
+func (s *MCPServer) recordCall(params ToolCallParams, started time.Time, text string, toolErr *MCPError) {
+	if s.transcripts == nil || params.Name == "export_session" {
+		return
+	}
+	call := TranscriptCall{
+		Time:      started.UTC().Format(time.RFC3339),
+		Duration:  time.Since(started).Round(time.Millisecond).String(),
+		Tool:      params.Name,
+		Arguments: params.Arguments,
+	}
+	if ctx := s.contexts.get(s.session, started); ctx != (SessionContext{}) {
+		call.Context = &ctx
+	}
+	if toolErr != nil {
+		call.Error = toolErr.Message
+	} else {
+		call.ResultBytes = len(text)
+		call.Result, call.Truncated = truncateUTF8(text, maxTranscriptResultBytes)
+	}
+	s.transcripts.record(s.session, call, time.Now())
+}
+
+// ExportSession renders the session's tool calls as a markdown or JSON
+// transcript, for attaching to incident tickets and postmortems.
+func (s *MCPServer) ExportSession(params ExportSessionParams) (string, error) {
+	format := strings.ToLower(params.Format)
+	if format == "" {
+		format = "markdown"
+	}
+	if format != "markdown" && format != "json" {
+		return "", fmt.Errorf("format must be markdown or json, got %q", params.Format)
+	}
+	if params.Last < 0 {
+		return "", fmt.Errorf("last must not be negative")
+	}
+
+	calls, omitted := s.transcripts.calls(s.session)
+	if params.Last > 0 && params.Last < len(calls) {
+		omitted += len(calls) - params.Last
+		calls = calls[len(calls)-params.Last:]
+	}
+	export := SessionTranscript{
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+		Calls:      calls,
+		Omitted:    omitted,
+	}
+	if export.Calls == nil {
+		export.Calls = make([]TranscriptCall, 0)
+	}
+	if format == "json" {
+		return formatResult(export), nil
+	}
+	return formatTranscriptMarkdown(export), nil
+}
+
+func formatTranscriptMarkdown(export SessionTranscript) string {
+	var b strings.Builder
+	b.WriteString("# Session transcript\n\n")
+	fmt.Fprintf(&b, "Exported %s. %d tool calls", export.ExportedAt, len(export.Calls))
+	if export.Omitted > 0 {
+		fmt.Fprintf(&b, " (%d earlier calls omitted)", export.Omitted)
+	}
+	b.WriteString(".\n")
+
+	for _, call := range export.Calls {
+		fmt.Fprintf(&b, "\n## %d. %s\n\n", call.Seq, call.Tool)
+		fmt.Fprintf(&b, "Called at %s, took %s.\n", call.Time, call.Duration)
+		if call.Context != nil {
+			pinned, _ := json.Marshal(call.Context)
+			fmt.Fprintf(&b, "\nSession context: `%s`\n", pinned)
+		}
+		if len(call.Arguments) > 0 {
+			var pretty strings.Builder
+			var v interface{}
+			if json.Unmarshal(call.Arguments, &v) == nil {
+				data, _ := json.MarshalIndent(v, "", "  ")
+				pretty.Write(data)
+			} else {
+				pretty.Write(call.Arguments)
+			}
+			b.WriteString("\n**Arguments:**\n\n")
+			writeFenced(&b, "json", pretty.String())
+		}
+		if call.Error != "" {
+			fmt.Fprintf(&b, "\n**Error:** %s\n", call.Error)
+			continue
+		}
+		if call.Truncated {
+			fmt.Fprintf(&b, "\n**Result** (first %d of %d bytes):\n\n", len(call.Result), call.ResultBytes)
+		} else {
+			b.WriteString("\n**Result:**\n\n")
+		}
+		writeFenced(&b, "", call.Result)
+	}
+	return b.String()
+}
+
+// writeFenced writes content as a code block whose fence is longer than
+// any run of backticks in it, so results can't break out of the block.
+func writeFenced(b *strings.Builder, lang, content string) {
+	longest, run := 0, 0
+	for _, r := range content {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+	fmt.Fprintf(b, "%s%s\n%s\n%s\n", fence, lang, strings.TrimRight(content, "\n"), fence)
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a character.
+func truncateUTF8(s string, n int) (string, bool) {
+	if len(s) <= n {
+		return s, false
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n], true
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportSession(t *testing.T) {
+	server := &MCPServer{session: "stdio", transcripts: newTranscriptStore(), contexts: newContextStore()}
+	server.contexts.set("stdio", SessionContext{Env: "prod"}, time.Now())
+
+	call := func(name, args, text string, toolErr *MCPError) {
+		server.recordCall(ToolCallParams{Name: name, Arguments: json.RawMessage(args)}, time.Now(), text, toolErr)
+	}
+	call("query_logs", `{"query":"service:web"}`, "```\n"+strings.Repeat("x", maxTranscriptResultBytes), nil)
+	call("get_context", `{}`, "", &MCPError{Code: -32000, Message: "boom"})
+	call("export_session", `{}`, "ignored", nil)
+
+	text, err := server.ExportSession(ExportSessionParams{Format: "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var export SessionTranscript
+	if err := json.Unmarshal([]byte(text), &export); err != nil {
+		t.Fatal(err)
+	}
+	if len(export.Calls) != 2 {
+		t.Fatalf("expected export_session itself not to be recorded, got %+v", export.Calls)
+	}
+	first := export.Calls[0]
+	if first.Seq != 1 || !first.Truncated || len(first.Result) != maxTranscriptResultBytes || first.ResultBytes != maxTranscriptResultBytes+4 {
+		t.Fatalf("expected a truncated result summary, got %+v", first)
+	}
+	var args bytes.Buffer
+	_ = json.Compact(&args, first.Arguments)
+	if first.Context == nil || first.Context.Env != "prod" || args.String() != `{"query":"service:web"}` {
+		t.Fatalf("expected the raw arguments and pinned context, got %+v", first)
+	}
+	if export.Calls[1].Error != "boom" {
+		t.Fatalf("expected the error to be recorded, got %+v", export.Calls[1])
+	}
+
+	markdown, err := server.ExportSession(ExportSessionParams{Last: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(markdown, "1 tool calls (1 earlier calls omitted)") || !strings.Contains(markdown, "## 2. get_context") || !strings.Contains(markdown, "**Error:** boom") {
+		t.Fatalf("unexpected markdown:\n%s", markdown)
+	}
+
+	markdown, _ = server.ExportSession(ExportSessionParams{})
+	if !strings.Contains(markdown, "````\n```\nxxx") {
+		t.Fatalf("expected a fence longer than the result's backticks:\n%s", markdown[:200])
+	}
+
+	if _, err := server.ExportSession(ExportSessionParams{Format: "pdf"}); err == nil {
+		t.Fatal("expected an unsupported format to be rejected")
+	}
+}
+
+func TestTranscriptStoreKeepsRecentCalls(t *testing.T) {
+	store := newTranscriptStore()
+	for i := 0; i < maxTranscriptCalls+5; i++ {
+		store.record("s", TranscriptCall{Tool: "query_logs"}, time.Now())
+	}
+	calls, omitted := store.calls("s")
+	if len(calls) != maxTranscriptCalls || omitted != 5 || calls[0].Seq != 6 {
+		t.Fatalf("expected the last %d calls, got %d from seq %d with %d omitted", maxTranscriptCalls, len(calls), calls[0].Seq, omitted)
+	}
+}
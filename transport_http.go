@@ -0,0 +1,418 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// maxRequestBytes bounds the size of a JSON-RPC request body.
+const maxRequestBytes = 1 << 20
+
+// defaultShutdownTimeout leaves headroom under Kubernetes' default 30s
+// termination grace period.
+const defaultShutdownTimeout = 25 * time.Second
+
+// httpHandler serves JSON-RPC requests posted to /mcp, downloads of
+// results too large for one response and, when enabled, Prometheus metrics
+// on /metrics.
+func (s *MCPServer) httpHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/mcp", withCompression(http.HandlerFunc(s.handleHTTP)))
+	mux.Handle("GET /results/{id}", withCompression(http.HandlerFunc(s.handleResultDownload)))
+	if s.metricsHandler != nil {
+		mux.Handle("/metrics", s.metricsHandler)
+	}
+	return mux
+}
+
+func (s *MCPServer) handleHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	server, ok := s.requestServer(w, r)
+	if !ok {
+		return
+	}
+
+	var req MCPRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&req); err != nil {
+		writeJSON(w, MCPResponse{
+			Jsonrpc: "2.0",
+			Error:   &MCPError{Code: -32700, Message: "parse error"},
+		})
+		return
+	}
+
+	if !acceptsEventStream(r) {
+		writeJSON(w, server.limitFrame(server.HandleRequestContext(r.Context(), req)))
+		return
+	}
+
+	// Clients that accept an event stream get notifications as they happen.
+	// The response switches to SSE only once there is one to send.
+	stream := &eventStream{w: w}
+	resp := server.withNotifier(func(n MCPNotification) {
+		stream.send(server.limitNotification(n))
+	}).HandleRequestContext(r.Context(), req)
+	resp = server.limitFrame(resp)
+	if stream.started {
+		stream.send(resp)
+		return
+	}
+	writeJSON(w, resp)
+}
+
+// requestServer returns the server that handles r: scoped to the caller's
+// session and, in gateway mode, to the user's Datadog credentials. It
+// writes an error response and returns false if the user can't be served.
+func (s *MCPServer) requestServer(w http.ResponseWriter, r *http.Request) (*MCPServer, bool) {
+	attribution := requestAttribution{Transport: "http", Session: r.Header.Get("Mcp-Session-Id")}
+	if s.tenants == nil {
+		server := s.withSession(sessionKey(r))
+		server.caller, _ = r.Context().Value(callerKey{}).(string)
+		server.attribution = attribution
+		return server, true
+	}
+
+	user, err := s.tenants.identify(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return nil, false
+	}
+	creds, err := s.tenants.lookup(user)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return nil, false
+	}
+	// Quotas follow the user across sessions in gateway mode.
+	server := s.forTenant(creds).withSession("user:" + user)
+	server.caller = "user:" + user
+	attribution.User = user
+	server.attribution = attribution
+	server.warmUp()
+	return server, true
+}
+
+// limitFrame replaces a tool result whose response would exceed
+// maxFrameBytes with a pointer to /results/{id}, where the caller can
+// download the full text once.
+func (s *MCPServer) limitFrame(resp MCPResponse) MCPResponse {
+	if s.maxFrameBytes <= 0 || len(resp.Result) <= s.maxFrameBytes {
+		return resp
+	}
+
+	var result ToolCallResult
+	if err := json.Unmarshal(resp.Result, &result); err != nil || len(result.Content) == 0 {
+		return resp
+	}
+	var text strings.Builder
+	for _, item := range result.Content {
+		text.WriteString(item.Text)
+	}
+
+	id, err := newContinuationID()
+	if err != nil || s.results == nil || !s.results.put(s.resultKey(id), text.String()) {
+		resp.Result = nil
+		resp.Error = &MCPError{
+			Code:    -32000,
+			Message: fmt.Sprintf("result of %d bytes exceeds the maximum frame size of %d bytes", text.Len(), s.maxFrameBytes),
+		}
+		return resp
+	}
+
+	pointer, _ := json.Marshal(ToolCallResult{Content: []TextContent{{
+		Type: "text",
+		Text: fmt.Sprintf("The result is %d bytes, more than this server's %d-byte frame limit. Download it once with GET /results/%s.", text.Len(), s.maxFrameBytes, id),
+	}}})
+	resp.Result = pointer
+	return resp
+}
+
+// limitNotification drops the partial content of a progress notification
+// that would exceed maxFrameBytes; the final result still carries it.
+func (s *MCPServer) limitNotification(n MCPNotification) MCPNotification {
+	params, ok := n.Params.(ProgressParams)
+	if s.maxFrameBytes <= 0 || !ok || len(params.Content) == 0 {
+		return n
+	}
+	if data, err := json.Marshal(n); err == nil && len(data) > s.maxFrameBytes {
+		params.Content = nil
+		n.Params = params
+	}
+	return n
+}
+
+func (s *MCPServer) handleResultDownload(w http.ResponseWriter, r *http.Request) {
+	server, ok := s.requestServer(w, r)
+	if !ok {
+		return
+	}
+
+	key := server.resultKey(r.PathValue("id"))
+	text, ok := s.results.get(key)
+	if !ok {
+		http.Error(w, "unknown or expired result", http.StatusNotFound)
+		return
+	}
+	s.results.delete(key)
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	io.WriteString(w, text)
+}
+
+func acceptsEventStream(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// eventStream writes JSON-RPC messages as server-sent events.
+type eventStream struct {
+	w       http.ResponseWriter
+	started bool
+}
+
+func (e *eventStream) send(v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("Error encoding event: %v", err)
+		return
+	}
+	if !e.started {
+		e.w.Header().Set("Content-Type", "text/event-stream")
+		e.w.Header().Set("Cache-Control", "no-cache")
+		e.started = true
+	}
+	fmt.Fprintf(e.w, "event: message\ndata: %s\n\n", data)
+	if f, ok := e.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// sessionKey identifies the caller's session: the MCP session header when
+// the client sends one, otherwise the remote address.
+func sessionKey(r *http.Request) string {
+	if id := r.Header.Get("Mcp-Session-Id"); id != "" {
+		return "session:" + id
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "addr:" + host
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
+
+// httpConfig holds the listener and authentication settings for the HTTP
+// transport.
+type httpConfig struct {
+	Addr            string
+	TLSCert         string
+	TLSKey          string
+	ClientCA        string
+	Tokens          []string
+	AllowedOrigins  []string
+	ShutdownTimeout time.Duration
+}
+
+func loadHTTPConfig() httpConfig {
+	cfg := httpConfig{
+		Addr:           os.Getenv("DD_MCP_HTTP_ADDR"),
+		TLSCert:        os.Getenv("DD_MCP_TLS_CERT"),
+		TLSKey:         os.Getenv("DD_MCP_TLS_KEY"),
+		ClientCA:       os.Getenv("DD_MCP_TLS_CLIENT_CA"),
+		Tokens:         splitList(os.Getenv("DD_MCP_AUTH_TOKENS")),
+		AllowedOrigins: splitList(os.Getenv("DD_MCP_ALLOWED_ORIGINS")),
+	}
+	if cfg.Addr == "" {
+		cfg.Addr = ":8080"
+	}
+	cfg.ShutdownTimeout = defaultShutdownTimeout
+	if timeout, err := time.ParseDuration(os.Getenv("DD_MCP_SHUTDOWN_TIMEOUT")); err == nil && timeout > 0 {
+		cfg.ShutdownTimeout = timeout
+	}
+	return cfg
+}
+
+// validate refuses to start a listener that nobody has to authenticate
+// against, since it would hand out org-wide Datadog access.
+func (c httpConfig) validate(server *MCPServer) error {
+	if (c.TLSCert == "") != (c.TLSKey == "") {
+		return fmt.Errorf("DD_MCP_TLS_CERT and DD_MCP_TLS_KEY must be set together")
+	}
+	if c.ClientCA != "" && c.TLSCert == "" {
+		return fmt.Errorf("DD_MCP_TLS_CLIENT_CA requires DD_MCP_TLS_CERT and DD_MCP_TLS_KEY")
+	}
+	if len(c.Tokens) > 0 && server.tenants != nil && len(server.tenants.jwtSecret) > 0 {
+		return fmt.Errorf("DD_MCP_AUTH_TOKENS and DD_MCP_JWT_SECRET both use the Authorization header; configure only one")
+	}
+	if len(c.Tokens) == 0 && c.ClientCA == "" && server.tenants == nil {
+		return fmt.Errorf("HTTP transport requires authentication: set DD_MCP_AUTH_TOKENS, DD_MCP_TLS_CLIENT_CA or DD_MCP_CREDENTIALS_FILE")
+	}
+	// A user header is only as trustworthy as whoever may connect, so the
+	// proxy setting it must authenticate too.
+	if t := server.tenants; t != nil && t.userHeader != "" && len(t.jwtSecret) == 0 && len(c.Tokens) == 0 && c.ClientCA == "" {
+		return fmt.Errorf("DD_MCP_USER_HEADER can be set by any client; also set DD_MCP_AUTH_TOKENS or DD_MCP_TLS_CLIENT_CA so only the auth proxy can connect")
+	}
+	return nil
+}
+
+// withAuth rejects requests from origins outside the allowlist and, when
+// configured, requests without a verified client certificate or a valid
+// bearer token. The TLS layer verifies any certificate presented but
+// doesn't require one, so health probes can connect without it.
+func (c httpConfig) withAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if origin := r.Header.Get("Origin"); origin != "" && !originAllowed(origin, c.AllowedOrigins) {
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+			return
+		}
+
+		if c.ClientCA != "" && (r.TLS == nil || len(r.TLS.VerifiedChains) == 0) {
+			http.Error(w, "client certificate required", http.StatusUnauthorized)
+			return
+		}
+
+		caller := ""
+		if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+			caller = "cert:" + r.TLS.VerifiedChains[0][0].Subject.String()
+		}
+		if len(c.Tokens) > 0 {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || !tokenValid(token, c.Tokens) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="datadog-mcp-server"`)
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			// Hashed, so the token itself isn't kept in memory as a key.
+			sum := sha256.Sum256([]byte(token))
+			caller = "token:" + hex.EncodeToString(sum[:8])
+		}
+		if caller != "" {
+			r = r.WithContext(context.WithValue(r.Context(), callerKey{}, caller))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// callerKey holds the identity withAuth authenticated a request as.
+type callerKey struct{}
+
+func originAllowed(origin string, allowed []string) bool {
+	for _, a := range allowed {
+		if a == "*" || strings.EqualFold(a, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func tokenValid(token string, tokens []string) bool {
+	valid := false
+	for _, t := range tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+			valid = true
+		}
+	}
+	return valid
+}
+
+func (c httpConfig) tlsConfig() (*tls.Config, error) {
+	if c.ClientCA == "" {
+		return nil, nil
+	}
+
+	pem, err := os.ReadFile(c.ClientCA)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", c.ClientCA)
+	}
+	return &tls.Config{
+		ClientCAs:  pool,
+		ClientAuth: tls.VerifyClientCertIfGiven,
+		MinVersion: tls.VersionTLS12,
+	}, nil
+}
+
+// serveHTTP serves until SIGINT or SIGTERM, then fails readiness and lets
+// in-flight requests finish within DD_MCP_SHUTDOWN_TIMEOUT.
+func serveHTTP(server *MCPServer, cfg httpConfig) error {
+	if err := cfg.validate(server); err != nil {
+		return err
+	}
+
+	tlsConfig, err := cfg.tlsConfig()
+	if err != nil {
+		return err
+	}
+
+	ready := newReadiness(server.checkDatadog)
+	httpServer := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           cfg.handler(server, ready),
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		if cfg.TLSCert != "" {
+			log.Printf("Listening for MCP requests on %s (TLS)", cfg.Addr)
+			errCh <- httpServer.ListenAndServeTLS(cfg.TLSCert, cfg.TLSKey)
+			return
+		}
+		log.Printf("Listening for MCP requests on %s", cfg.Addr)
+		errCh <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Printf("Shutting down, waiting up to %s for in-flight requests", cfg.ShutdownTimeout)
+	ready.drain()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	return httpServer.Shutdown(shutdownCtx)
+}
+
+// handler routes the unauthenticated probe endpoints and puts everything
+// else behind withAuth.
+func (c httpConfig) handler(server *MCPServer, ready *readiness) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", handleHealthz)
+	mux.HandleFunc("/readyz", ready.handleReadyz)
+	mux.Handle("/", c.withAuth(server.httpHandler()))
+	return mux
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func postMCP(t *testing.T, handler http.Handler, body string, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body))
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHTTPHandlerToolsList(t *testing.T) {
+	server := &MCPServer{}
+	rec := postMCP(t, server.httpHandler(), `{"jsonrpc":"2.0","id":7,"method":"tools/list"}`, nil)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	var resp MCPResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if resp.ID != 7 || resp.Error != nil {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
+
+func TestHTTPHandlerRejectsGet(t *testing.T) {
+	server := &MCPServer{}
+	req := httptest.NewRequest(http.MethodGet, "/mcp", nil)
+	rec := httptest.NewRecorder()
+	server.httpHandler().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", rec.Code)
+	}
+}
+
+func TestHTTPHandlerParseError(t *testing.T) {
+	server := &MCPServer{}
+	rec := postMCP(t, server.httpHandler(), `{not json`, nil)
+
+	var resp MCPResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if resp.Error == nil || resp.Error.Code != -32700 {
+		t.Errorf("expected parse error, got %+v", resp.Error)
+	}
+}
+
+func TestHTTPHandlerGateway(t *testing.T) {
+	server := &MCPServer{
+		tenants: &tenantStore{
+			credentials: map[string]TenantCredentials{"alice": {APIKey: "a1", AppKey: "a2"}},
+			userHeader:  "X-Forwarded-User",
+		},
+	}
+	handler := server.httpHandler()
+	body := `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`
+
+	if rec := postMCP(t, handler, body, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without identity, got %d", rec.Code)
+	}
+	if rec := postMCP(t, handler, body, map[string]string{"X-Forwarded-User": "bob"}); rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for unknown user, got %d", rec.Code)
+	}
+	if rec := postMCP(t, handler, body, map[string]string{"X-Forwarded-User": "alice"}); rec.Code != http.StatusOK {
+		t.Errorf("expected 200 for known user, got %d", rec.Code)
+	}
+}
+
+func TestWithAuth(t *testing.T) {
+	cfg := httpConfig{
+		Tokens:         []string{"s3cret"},
+		AllowedOrigins: []string{"https://app.example.com"},
+	}
+	handler := cfg.withAuth((&MCPServer{}).httpHandler())
+	body := `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`
+
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		expected int
+	}{
+		{name: "missing token", headers: nil, expected: http.StatusUnauthorized},
+		{name: "wrong token", headers: map[string]string{"Authorization": "Bearer nope"}, expected: http.StatusUnauthorized},
+		{name: "valid token", headers: map[string]string{"Authorization": "Bearer s3cret"}, expected: http.StatusOK},
+		{
+			name:     "disallowed origin",
+			headers:  map[string]string{"Authorization": "Bearer s3cret", "Origin": "https://evil.example.com"},
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "allowed origin",
+			headers:  map[string]string{"Authorization": "Bearer s3cret", "Origin": "https://app.example.com"},
+			expected: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := postMCP(t, handler, body, tt.headers); rec.Code != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, rec.Code)
+			}
+		})
+	}
+}
+
+func TestHTTPConfigValidate(t *testing.T) {
+	server := &MCPServer{}
+	tests := []struct {
+		name        string
+		cfg         httpConfig
+		expectError bool
+	}{
+		{name: "no authentication", cfg: httpConfig{}, expectError: true},
+		{name: "bearer token", cfg: httpConfig{Tokens: []string{"t"}}, expectError: false},
+		{name: "mTLS", cfg: httpConfig{TLSCert: "c", TLSKey: "k", ClientCA: "ca"}, expectError: false},
+		{name: "client CA without TLS", cfg: httpConfig{ClientCA: "ca"}, expectError: true},
+		{name: "cert without key", cfg: httpConfig{TLSCert: "c", Tokens: []string{"t"}}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.validate(server)
+			if tt.expectError && err == nil {
+				t.Errorf("expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+
+	gateway := &MCPServer{tenants: &tenantStore{userHeader: "X-Forwarded-User"}}
+	if err := (httpConfig{}).validate(gateway); err == nil {
+		t.Error("expected a user header without caller authentication to be refused")
+	}
+	if err := (httpConfig{Tokens: []string{"t"}}).validate(gateway); err != nil {
+		t.Errorf("expected a user header behind a bearer token to be accepted, got %v", err)
+	}
+	if err := (httpConfig{}).validate(&MCPServer{tenants: &tenantStore{jwtSecret: []byte("s")}}); err != nil {
+		t.Errorf("expected JWTs to authenticate callers on their own, got %v", err)
+	}
+}
+
+func TestLimitFrameOffersDownload(t *testing.T) {
+	server := &MCPServer{results: newResultStore(1 << 20), maxFrameBytes: 100}
+	handler := server.httpHandler()
+	owner := server.withSession("session:abc")
+
+	big := strings.Repeat("x", 500)
+	result, _ := json.Marshal(ToolCallResult{Content: []TextContent{{Type: "text", Text: big}}})
+	resp := owner.limitFrame(MCPResponse{Jsonrpc: "2.0", ID: 1, Result: result})
+
+	var pointer ToolCallResult
+	if err := json.Unmarshal(resp.Result, &pointer); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	text := pointer.Content[0].Text
+	path := text[strings.Index(text, "/results/") : len(text)-1]
+
+	download := func(session string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Mcp-Session-Id", session)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := download("other"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected another session to get 404, got %d", rec.Code)
+	}
+	if rec := download("abc"); rec.Code != http.StatusOK || rec.Body.String() != big {
+		t.Errorf("expected the full result, got %d with %d bytes", rec.Code, rec.Body.Len())
+	}
+	if rec := download("abc"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected the download to work once, got %d", rec.Code)
+	}
+}
+
+func TestLimitFrameLeavesSmallResults(t *testing.T) {
+	server := &MCPServer{results: newResultStore(1 << 20), maxFrameBytes: 1000}
+	result, _ := json.Marshal(ToolCallResult{Content: []TextContent{{Type: "text", Text: "small"}}})
+	resp := server.limitFrame(MCPResponse{Jsonrpc: "2.0", ID: 1, Result: result})
+	if string(resp.Result) != string(result) {
+		t.Errorf("expected small result to pass through, got %s", resp.Result)
+	}
+}
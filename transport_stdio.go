@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"sync"
+)
+
+// CancelledParams is the payload of a notifications/cancelled message.
+type CancelledParams struct {
+	RequestID int    `json:"requestId"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// inFlight tracks the running requests of a stream so a client can cancel
+// them by id.
+type inFlight struct {
+	mu       sync.Mutex
+	requests map[int]*flight
+}
+
+type flight struct {
+	cancel    context.CancelFunc
+	cancelled bool
+}
+
+func newInFlight() *inFlight {
+	return &inFlight{requests: make(map[int]*flight)}
+}
+
+func (f *inFlight) start(id int, cancel context.CancelFunc) *flight {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	entry := &flight{cancel: cancel}
+	f.requests[id] = entry
+	return entry
+}
+
+// cancel aborts request id if it is still running.
+func (f *inFlight) cancel(id int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if entry, ok := f.requests[id]; ok {
+		entry.cancel()
+		entry.cancelled = true
+	}
+}
+
+// finish forgets entry and reports whether the client cancelled it. A
+// reused id only ever refers to its latest request.
+func (f *inFlight) finish(id int, entry *flight) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	entry.cancel()
+	if f.requests[id] == entry {
+		delete(f.requests, id)
+	}
+	return entry.cancelled
+}
+
+func serveStdio(server *MCPServer) {
+	serveStream(server, os.Stdin, os.Stdout)
+}
+
+// serveStream reads JSON-RPC messages from r and writes replies to w. Each
+// request runs in its own goroutine, so a slow tool call doesn't hold up
+// the rest and notifications/cancelled can abort it. Per the MCP spec, a
+// cancelled request gets no response.
+func serveStream(server *MCPServer, r io.Reader, w io.Writer) {
+	decoder := json.NewDecoder(r)
+	encoder := json.NewEncoder(w)
+	var mu sync.Mutex
+	write := func(v interface{}, what string) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err := encoder.Encode(v); err != nil {
+			log.Printf("Error encoding %s: %v", what, err)
+		}
+	}
+	server = server.withNotifier(func(n MCPNotification) {
+		write(n, "notification")
+	})
+
+	requests := newInFlight()
+	var wg sync.WaitGroup
+	for {
+		var req MCPRequest
+		if err := decoder.Decode(&req); err != nil {
+			if err == io.EOF {
+				break
+			}
+			log.Printf("Error decoding request: %v", err)
+			continue
+		}
+
+		if req.Method == "notifications/cancelled" {
+			var params CancelledParams
+			if err := json.Unmarshal(req.Params, &params); err != nil {
+				log.Printf("Error decoding cancellation: %v", err)
+				continue
+			}
+			requests.cancel(params.RequestID)
+			continue
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		entry := requests.start(req.ID, cancel)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp := server.HandleRequestContext(ctx, req)
+			if requests.finish(req.ID, entry) {
+				return
+			}
+			write(resp, "response")
+		}()
+	}
+	wg.Wait()
+}
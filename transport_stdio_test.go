@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadog"
+)
+
+func TestWithContextScopesEachRequest(t *testing.T) {
+	server := &MCPServer{credentials: datadogCredentials{APIKey: "shared", AppKey: "app"}, requestTimeout: time.Minute}
+
+	first, cancelFirst := server.withContext(context.Background())
+	second, cancelSecond := server.forTenant(TenantCredentials{APIKey: "alice", AppKey: "alice-app"}).withContext(context.Background())
+	defer cancelSecond()
+
+	if deadline, ok := first.ctx.Deadline(); !ok || time.Until(deadline) > time.Minute {
+		t.Fatalf("expected a deadline within the request timeout, got %v %v", deadline, ok)
+	}
+	keys := second.ctx.Value(datadog.ContextAPIKeys).(map[string]datadog.APIKey)
+	if keys["apiKeyAuth"].Key != "alice" {
+		t.Fatalf("expected the tenant's key, got %q", keys["apiKeyAuth"].Key)
+	}
+	if server.ctx != nil {
+		t.Fatal("expected the shared server to hold no context")
+	}
+
+	cancelFirst()
+	if first.ctx.Err() == nil || second.ctx.Err() != nil {
+		t.Fatal("expected cancelling one request to leave the other running")
+	}
+}
+
+func TestServeStreamCancelsRequests(t *testing.T) {
+	started := make(chan struct{})
+	aborted := make(chan struct{})
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		// The server only notices a dropped connection once the body is read.
+		_, _ = io.ReadAll(r.Body)
+		close(started)
+		select {
+		case <-r.Context().Done():
+			close(aborted)
+		case <-time.After(5 * time.Second):
+		}
+	})
+
+	in, input := io.Pipe()
+	var out bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		serveStream(server, in, &out)
+		close(done)
+	}()
+
+	params, _ := json.Marshal(ToolCallParams{Name: "query_logs", Arguments: json.RawMessage(`{"query":"*"}`)})
+	send := func(v interface{}) {
+		data, _ := json.Marshal(v)
+		if _, err := fmt.Fprintf(input, "%s\n", data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	send(MCPRequest{Jsonrpc: "2.0", ID: 1, Method: "tools/call", Params: params})
+	<-started
+	send(MCPRequest{Jsonrpc: "2.0", Method: "notifications/cancelled", Params: json.RawMessage(`{"requestId":1,"reason":"user aborted"}`)})
+	select {
+	case <-aborted:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the Datadog call to be cancelled")
+	}
+	send(MCPRequest{Jsonrpc: "2.0", ID: 2, Method: "tools/list"})
+	input.Close()
+	<-done
+
+	decoder := json.NewDecoder(&out)
+	var ids []int
+	for {
+		var resp MCPResponse
+		if err := decoder.Decode(&resp); err != nil {
+			break
+		}
+		ids = append(ids, resp.ID)
+	}
+	if len(ids) != 1 || ids[0] != 2 {
+		t.Fatalf("expected only the tools/list response, got ids %v", ids)
+	}
+}
@@ -0,0 +1,384 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+)
+
+// maxUsageBaselineMonths bounds how far back detect_usage_anomalies looks.
+const maxUsageBaselineMonths = 12
+
+type UsageAnomaliesParams struct {
+	// Month is "2006-01"; it defaults to the current, partial month.
+	Month string `json:"month,omitempty"`
+	// Months is how many months before Month form the baseline.
+	Months int `json:"months,omitempty"`
+	// Threshold is the change, in percent, that counts as an anomaly.
+	Threshold float64 `json:"threshold,omitempty"`
+}
+
+// UsageDriver is the index, metric or tag set whose change accounts for
+// most of a product's change against the previous month.
+type UsageDriver struct {
+	// Source is "logs_index", "metric" or "tags".
+	Source   string  `json:"source"`
+	Name     string  `json:"name"`
+	Current  float64 `json:"current"`
+	Previous float64 `json:"previous"`
+	// Share is the driver's part of the product's total change, in percent.
+	Share float64 `json:"share_percent"`
+}
+
+type ProductUsage struct {
+	Product string  `json:"product"`
+	Unit    string  `json:"unit"`
+	Usage   float64 `json:"usage"`
+	// Projected extrapolates summed usage to the whole month while the
+	// month is still running.
+	Projected     *float64 `json:"projected,omitempty"`
+	Baseline      float64  `json:"baseline"`
+	ChangePercent *float64 `json:"change_percent,omitempty"`
+	// Anomaly is "increase", "decrease" or "new" when the change passes
+	// the threshold.
+	Anomaly string       `json:"anomaly,omitempty"`
+	Driver  *UsageDriver `json:"driver,omitempty"`
+}
+
+type UsageAnomaliesResult struct {
+	Month string `json:"month"`
+	// MonthProgress is how much of the month has elapsed, in percent.
+	MonthProgress float64        `json:"month_progress_percent"`
+	Baseline      []string       `json:"baseline_months"`
+	Threshold     float64        `json:"threshold_percent"`
+	Anomalies     int            `json:"anomalies"`
+	Products      []ProductUsage `json:"products"`
+	Notes         []string       `json:"notes,omitempty"`
+}
+
+// usageProduct reads one product from the monthly usage summary. Summed
+// products accumulate over the month, so a partial month is projected;
+// the others are averages or percentiles and are compared as they are.
+type usageProduct struct {
+	name        string
+	unit        string
+	summed      bool
+	summary     func(*datadogV1.UsageSummaryDate) *int64
+	attribution datadogV1.MonthlyUsageAttributionSupportedMetrics
+}
+
+var usageProducts = []usageProduct{
+	{"infra_hosts", "hosts", false, func(u *datadogV1.UsageSummaryDate) *int64 { return u.InfraHostTop99p }, datadogV1.MONTHLYUSAGEATTRIBUTIONSUPPORTEDMETRICS_INFRA_HOST_USAGE},
+	{"apm_hosts", "hosts", false, func(u *datadogV1.UsageSummaryDate) *int64 { return u.ApmHostTop99p }, datadogV1.MONTHLYUSAGEATTRIBUTIONSUPPORTEDMETRICS_APM_HOST_USAGE},
+	{"containers", "containers", false, func(u *datadogV1.UsageSummaryDate) *int64 { return u.ContainerAvg }, datadogV1.MONTHLYUSAGEATTRIBUTIONSUPPORTEDMETRICS_CONTAINER_USAGE},
+	{"custom_metrics", "timeseries", false, func(u *datadogV1.UsageSummaryDate) *int64 { return u.CustomTsAvg }, ""},
+	{"ingested_logs", "bytes", true, func(u *datadogV1.UsageSummaryDate) *int64 { return u.IngestedEventsBytesSum }, datadogV1.MONTHLYUSAGEATTRIBUTIONSUPPORTEDMETRICS_INGESTED_LOGS_BYTES_USAGE},
+	{"indexed_logs", "events", true, func(u *datadogV1.UsageSummaryDate) *int64 { return u.IndexedEventsCountSum }, ""},
+	{"indexed_spans", "spans", true, func(u *datadogV1.UsageSummaryDate) *int64 { return u.TraceSearchIndexedEventsCountSum }, datadogV1.MONTHLYUSAGEATTRIBUTIONSUPPORTEDMETRICS_INDEXED_SPANS_USAGE},
+	{"rum_sessions", "sessions", true, func(u *datadogV1.UsageSummaryDate) *int64 { return u.RumBrowserAndMobileSessionCount }, datadogV1.MONTHLYUSAGEATTRIBUTIONSUPPORTEDMETRICS_RUM_BROWSER_MOBILE_SESSIONS_USAGE},
+	{"synthetics_api_tests", "test runs", true, func(u *datadogV1.UsageSummaryDate) *int64 { return u.SyntheticsCheckCallsCountSum }, datadogV1.MONTHLYUSAGEATTRIBUTIONSUPPORTEDMETRICS_API_USAGE},
+	{"synthetics_browser_tests", "test runs", true, func(u *datadogV1.UsageSummaryDate) *int64 { return u.SyntheticsBrowserCheckCallsCountSum }, datadogV1.MONTHLYUSAGEATTRIBUTIONSUPPORTEDMETRICS_BROWSER_USAGE},
+	{"fargate_tasks", "tasks", false, func(u *datadogV1.UsageSummaryDate) *int64 { return u.FargateTasksCountAvg }, datadogV1.MONTHLYUSAGEATTRIBUTIONSUPPORTEDMETRICS_FARGATE_USAGE},
+	{"lambda_functions", "functions", false, func(u *datadogV1.UsageSummaryDate) *int64 { return u.AwsLambdaFuncCount }, ""},
+	{"profiled_hosts", "hosts", false, func(u *datadogV1.UsageSummaryDate) *int64 { return u.ProfilingHostTop99p }, datadogV1.MONTHLYUSAGEATTRIBUTIONSUPPORTEDMETRICS_PROFILED_HOST_USAGE},
+	{"dbm_hosts", "hosts", false, func(u *datadogV1.UsageSummaryDate) *int64 { return u.DbmHostTop99p }, datadogV1.MONTHLYUSAGEATTRIBUTIONSUPPORTEDMETRICS_DBM_HOSTS_USAGE},
+}
+
+// DetectUsageAnomalies compares a month's usage of each product with the
+// average of the months before and, for products that changed by more
+// than the threshold, looks up what drove the change.
+func (s *MCPServer) DetectUsageAnomalies(params UsageAnomaliesParams) (*UsageAnomaliesResult, error) {
+	now := time.Now().UTC()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if params.Month != "" {
+		parsed, err := time.Parse("2006-01", params.Month)
+		if err != nil {
+			return nil, fmt.Errorf("invalid month: %s (use YYYY-MM)", params.Month)
+		}
+		if parsed.After(month) {
+			return nil, fmt.Errorf("month %s is in the future", params.Month)
+		}
+		month = parsed
+	}
+	months := 3
+	if params.Months > 0 {
+		months = clampArgument(s, "months", params.Months, maxUsageBaselineMonths)
+	}
+	threshold := 25.0
+	if params.Threshold > 0 {
+		threshold = params.Threshold
+	}
+
+	next := month.AddDate(0, 1, 0)
+	progress := 1.0
+	if now.Before(next) {
+		progress = now.Sub(month).Hours() / next.Sub(month).Hours()
+	}
+	first := month.AddDate(0, -months, 0)
+
+	api := datadogV1.NewUsageMeteringApi(s.ddClient)
+	resp, _, err := api.GetUsageSummary(s.ctx, first, *datadogV1.NewGetUsageSummaryOptionalParameters().WithEndMonth(month))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage summary: %w", err)
+	}
+	byMonth := make(map[string]*datadogV1.UsageSummaryDate)
+	for i := range resp.Usage {
+		if date := resp.Usage[i].Date; date != nil {
+			byMonth[date.UTC().Format("2006-01")] = &resp.Usage[i]
+		}
+	}
+
+	result := &UsageAnomaliesResult{
+		Month:         month.Format("2006-01"),
+		MonthProgress: math.Round(progress*1000) / 10,
+		Threshold:     threshold,
+		Products:      []ProductUsage{},
+	}
+	var baseline []*datadogV1.UsageSummaryDate
+	for m := first; m.Before(month); m = m.AddDate(0, 1, 0) {
+		if usage := byMonth[m.Format("2006-01")]; usage != nil {
+			baseline = append(baseline, usage)
+			result.Baseline = append(result.Baseline, m.Format("2006-01"))
+		}
+	}
+	current := byMonth[result.Month]
+	if current == nil {
+		return nil, fmt.Errorf("no usage reported for %s", result.Month)
+	}
+	if len(baseline) == 0 {
+		result.Notes = append(result.Notes, "No earlier months have usage to compare with.")
+		return result, nil
+	}
+	if progress < 0.1 {
+		result.Notes = append(result.Notes, "Less than 10% of the month has elapsed, so projections are rough.")
+	}
+
+	for _, product := range usageProducts {
+		usage, ok := compareUsage(product, current, baseline, progress, threshold)
+		if !ok {
+			continue
+		}
+		if usage.Anomaly != "" {
+			result.Anomalies++
+			driver, err := s.usageDriver(api, product, month, progress, now)
+			if err != nil {
+				result.Notes = append(result.Notes, fmt.Sprintf("Couldn't find what drove %s: %v", product.name, err))
+			}
+			usage.Driver = driver
+		}
+		result.Products = append(result.Products, usage)
+	}
+
+	sort.SliceStable(result.Products, func(i, j int) bool {
+		a, b := result.Products[i], result.Products[j]
+		if (a.Anomaly != "") != (b.Anomaly != "") {
+			return a.Anomaly != ""
+		}
+		return math.Abs(derefOr(a.ChangePercent, math.Inf(1))) > math.Abs(derefOr(b.ChangePercent, math.Inf(1)))
+	})
+	if progress < 1 {
+		result.Notes = append(result.Notes, "Summed products are projected to the full month from usage so far; hosts, containers and other averages are compared as they stand.")
+	}
+	return result, nil
+}
+
+// compareUsage compares one product's usage with its baseline average. It
+// reports false when the product has no usage at all.
+func compareUsage(product usageProduct, current *datadogV1.UsageSummaryDate, baseline []*datadogV1.UsageSummaryDate, progress, threshold float64) (ProductUsage, bool) {
+	value := product.summary(current)
+	var total float64
+	seen := value != nil && *value != 0
+	for _, usage := range baseline {
+		if v := product.summary(usage); v != nil {
+			total += float64(*v)
+			seen = seen || *v != 0
+		}
+	}
+	if !seen {
+		return ProductUsage{}, false
+	}
+
+	out := ProductUsage{
+		Product:  product.name,
+		Unit:     product.unit,
+		Baseline: total / float64(len(baseline)),
+	}
+	if value != nil {
+		out.Usage = float64(*value)
+	}
+	compared := out.Usage
+	if product.summed && progress < 1 && progress > 0 {
+		projected := math.Round(out.Usage / progress)
+		out.Projected = &projected
+		compared = projected
+	}
+	if out.Baseline == 0 {
+		out.Anomaly = "new"
+		return out, true
+	}
+	change := math.Round((compared-out.Baseline)/out.Baseline*1000) / 10
+	out.ChangePercent = &change
+	switch {
+	case change >= threshold:
+		out.Anomaly = "increase"
+	case change <= -threshold:
+		out.Anomaly = "decrease"
+	}
+	return out, true
+}
+
+func derefOr(v *float64, fallback float64) float64 {
+	if v == nil {
+		return fallback
+	}
+	return *v
+}
+
+// usageDriver finds the part of a product that changed most against the
+// previous month: the logs index for indexed logs, the metric for custom
+// metrics, and the usage attribution tags for the rest.
+func (s *MCPServer) usageDriver(api *datadogV1.UsageMeteringApi, product usageProduct, month time.Time, progress float64, now time.Time) (*UsageDriver, error) {
+	prev := month.AddDate(0, -1, 0)
+	scale := 1.0
+	if product.summed && progress > 0 && progress < 1 {
+		scale = 1 / progress
+	}
+
+	var current, previous map[string]float64
+	var source string
+	switch {
+	case product.name == "indexed_logs":
+		source = "logs_index"
+		end := month.AddDate(0, 1, 0)
+		if now.Before(end) {
+			end = now.Truncate(time.Hour)
+		}
+		resp, _, err := api.GetUsageLogsByIndex(s.ctx, prev, *datadogV1.NewGetUsageLogsByIndexOptionalParameters().WithEndHr(end))
+		if err != nil {
+			return nil, err
+		}
+		current, previous = make(map[string]float64), make(map[string]float64)
+		for _, hour := range resp.Usage {
+			if hour.Hour == nil {
+				continue
+			}
+			target := previous
+			if !hour.Hour.Before(month) {
+				target = current
+			}
+			target[hour.GetIndexName()] += float64(hour.GetEventCount())
+		}
+	case product.name == "custom_metrics":
+		source = "metric"
+		var err error
+		if current, err = s.topAvgMetrics(api, month); err != nil {
+			return nil, err
+		}
+		if previous, err = s.topAvgMetrics(api, prev); err != nil {
+			return nil, err
+		}
+	case product.attribution != "":
+		source = "tags"
+		var err error
+		current, previous, err = s.attributedUsage(api, product.attribution, prev, month)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, nil
+	}
+	return largestChange(source, current, previous, scale), nil
+}
+
+func (s *MCPServer) topAvgMetrics(api *datadogV1.UsageMeteringApi, month time.Time) (map[string]float64, error) {
+	resp, _, err := api.GetUsageTopAvgMetrics(s.ctx, *datadogV1.NewGetUsageTopAvgMetricsOptionalParameters().WithMonth(month).WithLimit(100))
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]float64, len(resp.Usage))
+	for _, metric := range resp.Usage {
+		values[metric.GetMetricName()] = float64(metric.GetAvgMetricHour())
+	}
+	return values, nil
+}
+
+// attributedUsage sums a product's monthly usage attribution per tag set
+// for the previous and the given month.
+func (s *MCPServer) attributedUsage(api *datadogV1.UsageMeteringApi, field datadogV1.MonthlyUsageAttributionSupportedMetrics, prev, month time.Time) (map[string]float64, map[string]float64, error) {
+	resp, _, err := api.GetMonthlyUsageAttribution(s.ctx, prev, field,
+		*datadogV1.NewGetMonthlyUsageAttributionOptionalParameters().WithEndMonth(month.AddDate(0, 1, 0)))
+	if err != nil {
+		return nil, nil, err
+	}
+	current, previous := make(map[string]float64), make(map[string]float64)
+	for _, body := range resp.Usage {
+		if body.Month == nil || body.Values == nil {
+			continue
+		}
+		// Values has one field per product; read the requested one by
+		// its JSON name.
+		raw, err := json.Marshal(body.Values)
+		if err != nil {
+			continue
+		}
+		var values map[string]float64
+		if err := json.Unmarshal(raw, &values); err != nil {
+			continue
+		}
+		target := previous
+		if !body.Month.Before(month) {
+			target = current
+		}
+		target[tagSetLabel(body.Tags)] += values[string(field)]
+	}
+	return current, previous, nil
+}
+
+func tagSetLabel(tags map[string][]string) string {
+	var parts []string
+	for key, values := range tags {
+		for _, value := range values {
+			parts = append(parts, key+":"+value)
+		}
+	}
+	if len(parts) == 0 {
+		return "untagged"
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
+
+// largestChange picks the name whose (scaled) current value moved most
+// from its previous value.
+func largestChange(source string, current, previous map[string]float64, scale float64) *UsageDriver {
+	var total float64
+	var best *UsageDriver
+	names := make(map[string]bool)
+	for name := range current {
+		names[name] = true
+	}
+	for name := range previous {
+		names[name] = true
+	}
+	for name := range names {
+		now := math.Round(current[name] * scale)
+		delta := now - previous[name]
+		total += delta
+		if best == nil || math.Abs(delta) > math.Abs(best.Current-best.Previous) ||
+			(math.Abs(delta) == math.Abs(best.Current-best.Previous) && name < best.Name) {
+			best = &UsageDriver{Source: source, Name: name, Current: now, Previous: previous[name]}
+		}
+	}
+	if best == nil || best.Current == best.Previous {
+		return nil
+	}
+	if total != 0 {
+		best.Share = math.Round((best.Current-best.Previous)/total*1000) / 10
+	}
+	return best
+}
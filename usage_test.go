@@ -0,0 +1,72 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+)
+
+func TestDetectUsageAnomalies(t *testing.T) {
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/usage/summary":
+			_, _ = w.Write([]byte(`{"usage":[
+				{"date":"2025-11-01T00:00:00Z","infra_host_top99p":100,"indexed_events_count_sum":1100,"custom_ts_avg":500},
+				{"date":"2025-12-01T00:00:00Z","infra_host_top99p":100,"indexed_events_count_sum":900,"custom_ts_avg":500},
+				{"date":"2026-01-01T00:00:00Z","infra_host_top99p":105,"indexed_events_count_sum":2000,"custom_ts_avg":500,"apm_host_top99p":3}]}`))
+		case "/api/v1/usage/logs_by_index":
+			_, _ = w.Write([]byte(`{"usage":[
+				{"hour":"2025-12-01T00:00:00Z","index_name":"main","event_count":800},
+				{"hour":"2025-12-01T01:00:00Z","index_name":"debug","event_count":100},
+				{"hour":"2026-01-02T00:00:00Z","index_name":"main","event_count":950},
+				{"hour":"2026-01-03T00:00:00Z","index_name":"debug","event_count":1050}]}`))
+		case "/api/v1/usage/monthly-attribution":
+			if r.URL.Query().Get("fields") != "apm_host_usage" {
+				t.Errorf("unexpected attribution fields %q", r.URL.Query().Get("fields"))
+			}
+			_, _ = w.Write([]byte(`{"usage":[{"month":"2026-01-01T00:00:00Z","tags":{"team":["payments"]},"values":{"apm_host_usage":3}}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	result, err := server.DetectUsageAnomalies(UsageAnomaliesParams{Month: "2026-01", Months: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.MonthProgress != 100 || len(result.Baseline) != 2 || result.Anomalies != 2 || len(result.Products) != 4 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	apm, logs := result.Products[0], result.Products[1]
+	if apm.Product != "apm_hosts" || apm.Anomaly != "new" || apm.Driver == nil || apm.Driver.Name != "team:payments" || apm.Driver.Source != "tags" {
+		t.Fatalf("expected new APM hosts driven by the payments team, got %+v (driver %+v)", apm, apm.Driver)
+	}
+	if logs.Product != "indexed_logs" || logs.Anomaly != "increase" || *logs.ChangePercent != 100 || logs.Projected != nil {
+		t.Fatalf("expected indexed logs to double, got %+v", logs)
+	}
+	if d := logs.Driver; d == nil || d.Name != "debug" || d.Current != 1050 || d.Previous != 100 || d.Share != 86.4 {
+		t.Fatalf("expected the debug index as the driver, got %+v", d)
+	}
+	if infra := result.Products[2]; infra.Product == "" || infra.Anomaly != "" || infra.Driver != nil {
+		t.Fatalf("expected unflagged products after the anomalies, got %+v", infra)
+	}
+
+	if _, err := server.DetectUsageAnomalies(UsageAnomaliesParams{Month: "January"}); err == nil {
+		t.Fatal("expected an invalid month to be rejected")
+	}
+}
+
+func TestCompareUsageProjectsSummedProducts(t *testing.T) {
+	logs := usageProducts[5]
+	current := int64(500)
+	previous := int64(1000)
+	usage, ok := compareUsage(logs,
+		&datadogV1.UsageSummaryDate{IndexedEventsCountSum: &current},
+		[]*datadogV1.UsageSummaryDate{{IndexedEventsCountSum: &previous}}, 0.25, 25)
+	if !ok || *usage.Projected != 2000 || *usage.ChangePercent != 100 || usage.Anomaly != "increase" {
+		t.Fatalf("expected a quarter-month of 500 to project to 2000, got %+v", usage)
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV2"
+)
+
+const (
+	// maxUsageDays bounds get_usage's window; hourly usage is kept for
+	// about six months but a longer window means many pages.
+	maxUsageDays = 90
+	// maxUsagePages bounds the hourly usage pages get_usage reads.
+	maxUsagePages = 20
+)
+
+// defaultUsageFamilies are the ingestion-heavy product families get_usage
+// reports when none are asked for.
+var defaultUsageFamilies = []string{"logs", "indexed_logs", "ingested_spans", "indexed_spans", "timeseries"}
+
+// usageFamilyAliases maps the names people use for products to the
+// product families of the usage API.
+var usageFamilyAliases = map[string][]string{
+	"apm":            {"ingested_spans", "indexed_spans"},
+	"spans":          {"ingested_spans", "indexed_spans"},
+	"custom_metrics": {"timeseries"},
+	"metrics":        {"timeseries"},
+	"hosts":          {"infra_hosts"},
+}
+
+type GetUsageParams struct {
+	// ProductFamilies are usage API product families such as "logs" or
+	// "timeseries", or the aliases in usageFamilyAliases.
+	ProductFamilies []string `json:"product_families,omitempty"`
+	From            string   `json:"from,omitempty"`
+	To              string   `json:"to,omitempty"`
+	// Interval is "hour" or "day".
+	Interval string `json:"interval,omitempty"`
+}
+
+type UsagePoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     int64     `json:"value"`
+}
+
+// UsageTrend is one usage type of a product family over the window.
+type UsageTrend struct {
+	ProductFamily string `json:"product_family"`
+	UsageType     string `json:"usage_type"`
+	Total         int64  `json:"total"`
+	// ChangePercent compares the second half of the window with the
+	// first; it is unset when the first half had no usage.
+	ChangePercent *float64     `json:"change_percent,omitempty"`
+	Points        []UsagePoint `json:"points"`
+}
+
+type GetUsageResult struct {
+	From            string       `json:"from"`
+	To              string       `json:"to"`
+	Interval        string       `json:"interval"`
+	ProductFamilies []string     `json:"product_families"`
+	Usage           []UsageTrend `json:"usage"`
+	Truncated       bool         `json:"truncated,omitempty"`
+	Notes           []string     `json:"notes,omitempty"`
+}
+
+// GetUsage reads hourly usage by product family and sums it per usage
+// type into hourly or daily points, so ingestion trends for logs, spans
+// and custom metrics can be compared over time.
+func (s *MCPServer) GetUsage(params GetUsageParams) (*GetUsageResult, error) {
+	now := time.Now().UTC()
+	from, err := s.timeParam("from", params.From, now.Add(-7*24*time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.timeParam("to", params.To, now)
+	if err != nil {
+		return nil, err
+	}
+	if !to.After(from) {
+		return nil, fmt.Errorf("to must be after from")
+	}
+	if to.Sub(from) > maxUsageDays*24*time.Hour {
+		earliest := to.Add(-maxUsageDays * 24 * time.Hour)
+		s.adjustArgument("from", from.Format(time.RFC3339), earliest.Format(time.RFC3339), fmt.Sprintf("the window is limited to %d days", maxUsageDays))
+		from = earliest
+	}
+	from, to = from.UTC().Truncate(time.Hour), to.UTC()
+
+	interval := strings.ToLower(params.Interval)
+	if interval == "" {
+		interval = "day"
+		if to.Sub(from) <= 48*time.Hour {
+			interval = "hour"
+		}
+	}
+	var step time.Duration
+	switch interval {
+	case "hour":
+		step = time.Hour
+	case "day":
+		step = 24 * time.Hour
+	default:
+		return nil, fmt.Errorf("invalid interval: %s (use hour or day)", params.Interval)
+	}
+
+	families := usageFamilies(params.ProductFamilies)
+	result := &GetUsageResult{
+		From:            from.Format(time.RFC3339),
+		To:              to.Format(time.RFC3339),
+		Interval:        interval,
+		ProductFamilies: families,
+		Usage:           []UsageTrend{},
+	}
+
+	type key struct{ family, usageType string }
+	buckets := make(map[key]map[time.Time]int64)
+	api := datadogV2.NewUsageMeteringApi(s.ddClient)
+	opts := datadogV2.NewGetHourlyUsageOptionalParameters().WithFilterTimestampEnd(to).WithPageLimit(500)
+	for page := 0; ; page++ {
+		if page == maxUsagePages {
+			result.Truncated = true
+			result.Notes = append(result.Notes, fmt.Sprintf("Stopped after %d pages of hourly usage; narrow the window or product families for complete totals.", maxUsagePages))
+			break
+		}
+		resp, _, err := api.GetHourlyUsage(s.ctx, from, strings.Join(families, ","), *opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get hourly usage: %w", err)
+		}
+		for _, hour := range resp.Data {
+			attrs := hour.Attributes
+			if attrs == nil || attrs.Timestamp == nil {
+				continue
+			}
+			at := attrs.Timestamp.UTC().Truncate(step)
+			for _, m := range attrs.Measurements {
+				value := m.Value.Get()
+				if value == nil {
+					continue
+				}
+				k := key{attrs.GetProductFamily(), m.GetUsageType()}
+				if buckets[k] == nil {
+					buckets[k] = make(map[time.Time]int64)
+				}
+				buckets[k][at] += *value
+			}
+		}
+		next := ""
+		if resp.Meta != nil && resp.Meta.Pagination != nil && resp.Meta.Pagination.NextRecordId.Get() != nil {
+			next = *resp.Meta.Pagination.NextRecordId.Get()
+		}
+		if next == "" {
+			break
+		}
+		opts.WithPageNextRecordId(next)
+	}
+
+	middle := from.Add(to.Sub(from) / 2)
+	for k, points := range buckets {
+		trend := UsageTrend{ProductFamily: k.family, UsageType: k.usageType, Points: make([]UsagePoint, 0, len(points))}
+		var first, second int64
+		for at, value := range points {
+			trend.Total += value
+			trend.Points = append(trend.Points, UsagePoint{Timestamp: at, Value: value})
+			if at.Before(middle) {
+				first += value
+			} else {
+				second += value
+			}
+		}
+		if trend.Total == 0 {
+			continue
+		}
+		if first > 0 {
+			change := math.Round(float64(second-first)/float64(first)*1000) / 10
+			trend.ChangePercent = &change
+		}
+		slices.SortFunc(trend.Points, func(a, b UsagePoint) int { return a.Timestamp.Compare(b.Timestamp) })
+		result.Usage = append(result.Usage, trend)
+	}
+	slices.SortFunc(result.Usage, func(a, b UsageTrend) int {
+		return cmp.Or(cmp.Compare(a.ProductFamily, b.ProductFamily), cmp.Compare(a.UsageType, b.UsageType))
+	})
+
+	if len(result.Usage) == 0 {
+		result.Notes = append(result.Notes, "No usage was reported for these product families in this window; hourly usage can lag by up to 72 hours.")
+	} else if now.Sub(to) < 72*time.Hour {
+		result.Notes = append(result.Notes, "Hourly usage can lag by up to 72 hours, so the latest points may be incomplete and the second half's change understated.")
+	}
+	return result, nil
+}
+
+// usageFamilies resolves aliases and drops duplicates, keeping the order
+// they were asked for in.
+func usageFamilies(requested []string) []string {
+	if len(requested) == 0 {
+		return slices.Clone(defaultUsageFamilies)
+	}
+	var families []string
+	for _, family := range requested {
+		family = strings.ToLower(strings.TrimSpace(family))
+		resolved, ok := usageFamilyAliases[family]
+		if !ok {
+			resolved = []string{family}
+		}
+		for _, f := range resolved {
+			if f != "" && !slices.Contains(families, f) {
+				families = append(families, f)
+			}
+		}
+	}
+	if len(families) == 0 {
+		return slices.Clone(defaultUsageFamilies)
+	}
+	return families
+}
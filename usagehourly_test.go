@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestGetUsage(t *testing.T) {
+	var requests []string
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/api/v2/usage/hourly_usage" {
+			http.NotFound(w, r)
+			return
+		}
+		query := r.URL.Query()
+		requests = append(requests, query.Get("filter[product_families]"))
+		if query.Get("page[next_record_id]") == "" {
+			_, _ = w.Write([]byte(`{"data":[
+				{"attributes":{"product_family":"logs","timestamp":"2026-03-01T00:00:00Z","measurements":[{"usage_type":"ingested_events_bytes","value":100},{"usage_type":"indexed_events_count","value":null}]}},
+				{"attributes":{"product_family":"logs","timestamp":"2026-03-01T13:00:00Z","measurements":[{"usage_type":"ingested_events_bytes","value":50}]}},
+				{"attributes":{"product_family":"timeseries","timestamp":"2026-03-01T00:00:00Z","measurements":[{"usage_type":"num_custom_timeseries","value":0}]}}],
+				"meta":{"pagination":{"next_record_id":"page-2"}}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[
+			{"attributes":{"product_family":"logs","timestamp":"2026-03-03T09:00:00Z","measurements":[{"usage_type":"ingested_events_bytes","value":300}]}}],
+			"meta":{"pagination":{"next_record_id":null}}}`))
+	})
+
+	result, err := server.GetUsage(GetUsageParams{ProductFamilies: []string{"logs", "custom_metrics", "LOGS"}, From: "2026-03-01T00:00:00Z", To: "2026-03-04T00:00:00Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(requests, []string{"logs,timeseries", "logs,timeseries"}) {
+		t.Fatalf("expected both pages with aliases resolved, got %q", requests)
+	}
+	if result.Interval != "day" || len(result.Usage) != 1 {
+		t.Fatalf("expected daily points for the one family with usage, got %+v", result)
+	}
+	logs := result.Usage[0]
+	if logs.UsageType != "ingested_events_bytes" || logs.Total != 450 || len(logs.Points) != 2 || logs.Points[0].Value != 150 {
+		t.Fatalf("unexpected logs usage %+v", logs)
+	}
+	if logs.ChangePercent == nil || *logs.ChangePercent != 100 {
+		t.Fatalf("expected the second half to double the first, got %v", logs.ChangePercent)
+	}
+
+	if _, err := server.GetUsage(GetUsageParams{Interval: "week"}); err == nil {
+		t.Fatal("expected an invalid interval to be rejected")
+	}
+}
+
+func TestUsageFamilies(t *testing.T) {
+	if got := usageFamilies(nil); !reflect.DeepEqual(got, defaultUsageFamilies) {
+		t.Fatalf("expected the defaults, got %v", got)
+	}
+	if got := usageFamilies([]string{"apm", " indexed_spans ", "rum"}); !reflect.DeepEqual(got, []string{"ingested_spans", "indexed_spans", "rum"}) {
+		t.Fatalf("unexpected families %v", got)
+	}
+}
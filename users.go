@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV2"
+)
+
+const (
+	defaultUsersPerPage = 50
+	maxUsersPerPage     = 100
+	// staleLoginAge is how long since their last login an active user
+	// is called out as possibly no longer needing access.
+	staleLoginAge = 90 * 24 * time.Hour
+)
+
+// userStatuses maps the statuses accepted by list_users to the values of
+// the users API status filter.
+var userStatuses = map[string]string{
+	"active":   "Active",
+	"pending":  "Pending",
+	"disabled": "Disabled",
+}
+
+type ListUsersParams struct {
+	Statuses []string `json:"statuses,omitempty"`
+	// Email keeps users whose email contains it, ignoring case.
+	Email   string `json:"email,omitempty"`
+	Page    int64  `json:"page,omitempty"`
+	PerPage int64  `json:"per_page,omitempty"`
+}
+
+type UserSummary struct {
+	ID     string   `json:"id"`
+	Name   string   `json:"name,omitempty"`
+	Email  string   `json:"email"`
+	Handle string   `json:"handle,omitempty"`
+	Status string   `json:"status"`
+	Roles  []string `json:"roles"`
+	// LastLogin is unset for users who never logged in.
+	LastLogin      string `json:"last_login,omitempty"`
+	Created        string `json:"created,omitempty"`
+	ServiceAccount bool   `json:"service_account,omitempty"`
+	MFAEnabled     bool   `json:"mfa_enabled"`
+}
+
+type ListUsersResult struct {
+	Users []UserSummary `json:"users"`
+	// Total counts the users matching the filters on every page.
+	Total     int64    `json:"total"`
+	Page      int64    `json:"page"`
+	PageCount int64    `json:"page_count"`
+	PerPage   int64    `json:"per_page"`
+	URL       string   `json:"url"`
+	Notes     []string `json:"notes,omitempty"`
+}
+
+// ListUsers lists the org's users with their roles and last login, a page
+// at a time, for access reviews.
+func (s *MCPServer) ListUsers(params ListUsersParams) (*ListUsersResult, error) {
+	var statuses []string
+	for _, status := range params.Statuses {
+		value, ok := userStatuses[strings.ToLower(strings.TrimSpace(status))]
+		if !ok {
+			return nil, fmt.Errorf("invalid status: %s (use active, pending or disabled)", status)
+		}
+		statuses = append(statuses, value)
+	}
+	perPage := params.PerPage
+	if perPage <= 0 {
+		perPage = defaultUsersPerPage
+	}
+	perPage = clampArgument(s, "per_page", perPage, maxUsersPerPage)
+	if params.Page < 0 {
+		return nil, fmt.Errorf("page must not be negative")
+	}
+	email := strings.TrimSpace(params.Email)
+
+	opts := datadogV2.NewListUsersOptionalParameters().
+		WithPageNumber(params.Page).
+		WithPageSize(perPage).
+		WithSort("email")
+	if email != "" {
+		// The API filter matches names and handles too, so the emails are
+		// checked again below.
+		opts = opts.WithFilter(email)
+	}
+	if len(statuses) > 0 {
+		opts = opts.WithFilterStatus(strings.Join(statuses, ","))
+	}
+	resp, _, err := datadogV2.NewUsersApi(s.ddClient).ListUsers(s.ctx, *opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	roles := make(map[string]string)
+	for _, item := range resp.Included {
+		if role := item.Role; role != nil && role.Id != nil {
+			roles[*role.Id] = role.Attributes.GetName()
+		}
+	}
+
+	result := &ListUsersResult{
+		Users:   make([]UserSummary, 0, len(resp.Data)),
+		Page:    params.Page,
+		PerPage: perPage,
+		URL:     s.appURL("/organization-settings/users"),
+	}
+	now := time.Now()
+	stale, skipped := 0, 0
+	for _, user := range resp.Data {
+		attrs := user.Attributes
+		if email != "" && !strings.Contains(strings.ToLower(attrs.GetEmail()), strings.ToLower(email)) {
+			skipped++
+			continue
+		}
+		summary := UserSummary{
+			ID:             user.GetId(),
+			Name:           attrs.GetName(),
+			Email:          attrs.GetEmail(),
+			Handle:         attrs.GetHandle(),
+			Status:         attrs.GetStatus(),
+			Roles:          []string{},
+			Created:        formatOptionalTime(attrs.CreatedAt),
+			ServiceAccount: attrs.GetServiceAccount(),
+			MFAEnabled:     attrs.GetMfaEnabled(),
+		}
+		if login := attrs.LastLoginTime.Get(); login != nil {
+			summary.LastLogin = login.UTC().Format(time.RFC3339)
+		}
+		if rel := user.Relationships; rel != nil && rel.Roles != nil {
+			for _, role := range rel.Roles.Data {
+				name := roles[role.GetId()]
+				if name == "" {
+					name = role.GetId()
+				}
+				summary.Roles = append(summary.Roles, name)
+			}
+			sort.Strings(summary.Roles)
+		}
+		if summary.Status == "Active" && !summary.ServiceAccount {
+			if login := attrs.LastLoginTime.Get(); login == nil || now.Sub(*login) > staleLoginAge {
+				stale++
+			}
+		}
+		result.Users = append(result.Users, summary)
+	}
+	if meta := resp.Meta; meta != nil && meta.Page != nil {
+		result.Total = meta.Page.GetTotalFilteredCount()
+		if result.Total == 0 && email == "" && len(statuses) == 0 {
+			result.Total = meta.Page.GetTotalCount()
+		}
+	}
+	result.PageCount = (result.Total + perPage - 1) / perPage
+
+	if stale > 0 {
+		result.Notes = append(result.Notes, fmt.Sprintf("%d active users on this page haven't logged in for %d days or never have; review whether they still need access.", stale, int(staleLoginAge.Hours()/24)))
+	}
+	if skipped > 0 {
+		result.Notes = append(result.Notes, fmt.Sprintf("%d users matched the email text only in their name or handle and were left out; total still counts them.", skipped))
+	}
+	if result.PageCount > params.Page+1 {
+		result.Notes = append(result.Notes, fmt.Sprintf("This is page %d of %d (pages start at 0); pass page=%d for more.", params.Page, result.PageCount, params.Page+1))
+	}
+	return result, nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestListUsers(t *testing.T) {
+	var query string
+	recent := time.Now().Add(-24 * time.Hour).UTC().Format(time.RFC3339)
+	server := newFakeDatadogServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/api/v2/users" {
+			http.NotFound(w, r)
+			return
+		}
+		query = r.URL.RawQuery
+		_, _ = w.Write([]byte(`{
+			"data":[
+				{"id":"u1","type":"users","attributes":{"email":"ana@contractor.com","name":"Ana","status":"Active","mfa_enabled":true,"last_login_time":"` + recent + `"},
+				 "relationships":{"roles":{"data":[{"id":"r2","type":"roles"},{"id":"r1","type":"roles"}]}}},
+				{"id":"u2","type":"users","attributes":{"email":"bo@contractor.com","name":"Bo","status":"Active","last_login_time":null},
+				 "relationships":{"roles":{"data":[{"id":"r3","type":"roles"}]}}},
+				{"id":"u3","type":"users","attributes":{"email":"cy@example.com","name":"contractor.com bot","status":"Active","service_account":true}}],
+			"included":[
+				{"id":"r1","type":"roles","attributes":{"name":"Datadog Standard Role"}},
+				{"id":"r2","type":"roles","attributes":{"name":"Datadog Admin Role"}}],
+			"meta":{"page":{"total_count":40,"total_filtered_count":3}}}`))
+	})
+
+	result, err := server.ListUsers(ListUsersParams{Statuses: []string{"Active", "pending"}, Email: "@Contractor.com", PerPage: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"filter=%40Contractor.com", "filter%5Bstatus%5D=Active%2CPending", "page%5Bsize%5D=2"} {
+		if !strings.Contains(query, want) {
+			t.Fatalf("expected %s in the request, got %s", want, query)
+		}
+	}
+	if len(result.Users) != 2 || result.Total != 3 || result.PageCount != 2 {
+		t.Fatalf("expected the service account matched by name to be left out, got %+v", result)
+	}
+	ana, bo := result.Users[0], result.Users[1]
+	if strings.Join(ana.Roles, ",") != "Datadog Admin Role,Datadog Standard Role" || !ana.MFAEnabled || ana.LastLogin == "" {
+		t.Fatalf("unexpected user %+v", ana)
+	}
+	if bo.LastLogin != "" || len(bo.Roles) != 1 || bo.Roles[0] != "r3" {
+		t.Fatalf("expected an unknown role's id and no login, got %+v", bo)
+	}
+	notes := strings.Join(result.Notes, " ")
+	if !strings.Contains(notes, "1 active users on this page haven't logged in") || !strings.Contains(notes, "1 users matched the email text only") || !strings.Contains(notes, "page=1") {
+		t.Fatalf("unexpected notes %v", result.Notes)
+	}
+
+	if _, err := server.ListUsers(ListUsersParams{Statuses: []string{"deleted"}}); err == nil {
+		t.Fatal("expected an invalid status to be rejected")
+	}
+}